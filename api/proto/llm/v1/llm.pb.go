@@ -38,7 +38,31 @@ type PromptRequest struct {
 	// Temperature for sampling (0.0 - 2.0)
 	Temperature float32 `protobuf:"fixed32,5,opt,name=temperature,proto3" json:"temperature,omitempty"`
 	// Optional system prompt for context
-	SystemPrompt  string `protobuf:"bytes,6,opt,name=system_prompt,json=systemPrompt,proto3" json:"system_prompt,omitempty"`
+	SystemPrompt string `protobuf:"bytes,6,opt,name=system_prompt,json=systemPrompt,proto3" json:"system_prompt,omitempty"`
+	// Optional output constraint: "json" for any JSON, or a JSON schema document
+	Format string `protobuf:"bytes,7,opt,name=format,proto3" json:"format,omitempty"`
+	// Nucleus sampling probability mass (0 uses the model's default)
+	TopP float32 `protobuf:"fixed32,8,opt,name=top_p,json=topP,proto3" json:"top_p,omitempty"`
+	// Number of most likely tokens sampled from (0 uses the model's default)
+	TopK int32 `protobuf:"varint,9,opt,name=top_k,json=topK,proto3" json:"top_k,omitempty"`
+	// Penalty for repeated tokens (0 uses the model's default)
+	RepeatPenalty float32 `protobuf:"fixed32,10,opt,name=repeat_penalty,json=repeatPenalty,proto3" json:"repeat_penalty,omitempty"`
+	// Random seed, for reproducible output; random if unset
+	Seed *int64 `protobuf:"varint,11,opt,name=seed,proto3,oneof" json:"seed,omitempty"`
+	// Sequences that end generation when produced
+	Stop []string `protobuf:"bytes,12,rep,name=stop,proto3" json:"stop,omitempty"`
+	// Context window size in tokens (0 uses the model's default)
+	NumCtx int32 `protobuf:"varint,13,opt,name=num_ctx,json=numCtx,proto3" json:"num_ctx,omitempty"`
+	// Earlier turns of the conversation, oldest first. When set, the prompt is
+	// sent as the next user message through Ollama's chat API.
+	History []*ChatMessage `protobuf:"bytes,14,rep,name=history,proto3" json:"history,omitempty"`
+	// Ollama context tokens returned by an earlier response. The prompt
+	// continues from them, skipping re-evaluation of the cached prefix. Can't
+	// be combined with history.
+	Context []int32 `protobuf:"varint,15,rep,packed,name=context,proto3" json:"context,omitempty"`
+	// Return the context tokens of the response so the next request can
+	// continue from it
+	ReturnContext bool `protobuf:"varint,16,opt,name=return_context,json=returnContext,proto3" json:"return_context,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -115,6 +139,130 @@ func (x *PromptRequest) GetSystemPrompt() string {
 	return ""
 }
 
+func (x *PromptRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *PromptRequest) GetTopP() float32 {
+	if x != nil {
+		return x.TopP
+	}
+	return 0
+}
+
+func (x *PromptRequest) GetTopK() int32 {
+	if x != nil {
+		return x.TopK
+	}
+	return 0
+}
+
+func (x *PromptRequest) GetRepeatPenalty() float32 {
+	if x != nil {
+		return x.RepeatPenalty
+	}
+	return 0
+}
+
+func (x *PromptRequest) GetSeed() int64 {
+	if x != nil && x.Seed != nil {
+		return *x.Seed
+	}
+	return 0
+}
+
+func (x *PromptRequest) GetStop() []string {
+	if x != nil {
+		return x.Stop
+	}
+	return nil
+}
+
+func (x *PromptRequest) GetNumCtx() int32 {
+	if x != nil {
+		return x.NumCtx
+	}
+	return 0
+}
+
+func (x *PromptRequest) GetHistory() []*ChatMessage {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+func (x *PromptRequest) GetContext() []int32 {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+func (x *PromptRequest) GetReturnContext() bool {
+	if x != nil {
+		return x.ReturnContext
+	}
+	return false
+}
+
+// ChatMessage is one turn of a conversation
+type ChatMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "user" or "assistant"
+	Role          string `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	Content       string `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ChatMessage) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
 // PromptResponse contains the generated text
 type PromptResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -131,26 +279,1097 @@ type PromptResponse struct {
 	// Time taken for inference in milliseconds
 	InferenceTimeMs int64 `protobuf:"varint,6,opt,name=inference_time_ms,json=inferenceTimeMs,proto3" json:"inference_time_ms,omitempty"`
 	// The model used for generation
-	Model         string `protobuf:"bytes,7,opt,name=model,proto3" json:"model,omitempty"`
+	Model string `protobuf:"bytes,7,opt,name=model,proto3" json:"model,omitempty"`
+	// Optional Ed25519 signature over the result, made with the worker's key
+	Signature []byte `protobuf:"bytes,8,opt,name=signature,proto3" json:"signature,omitempty"`
+	// Token usage and accounting summary
+	Usage *Usage `protobuf:"bytes,9,opt,name=usage,proto3" json:"usage,omitempty"`
+	// The parameters the response was generated with, including the seed
+	// chosen when the request had none
+	Parameters *GenerationParameters `protobuf:"bytes,10,opt,name=parameters,proto3" json:"parameters,omitempty"`
+	// Ollama context tokens, when requested with return_context
+	Context       []int32 `protobuf:"varint,11,rep,packed,name=context,proto3" json:"context,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PromptResponse) Reset() {
+	*x = PromptResponse{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PromptResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PromptResponse) ProtoMessage() {}
+
+func (x *PromptResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PromptResponse.ProtoReflect.Descriptor instead.
+func (*PromptResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PromptResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *PromptResponse) GetResponse() string {
+	if x != nil {
+		return x.Response
+	}
+	return ""
+}
+
+func (x *PromptResponse) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *PromptResponse) GetCompletionTokens() int32 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *PromptResponse) GetTotalTokens() int32 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+func (x *PromptResponse) GetInferenceTimeMs() int64 {
+	if x != nil {
+		return x.InferenceTimeMs
+	}
+	return 0
+}
+
+func (x *PromptResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *PromptResponse) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *PromptResponse) GetUsage() *Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+func (x *PromptResponse) GetParameters() *GenerationParameters {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *PromptResponse) GetContext() []int32 {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+// GenerationParameters are the sampling parameters used for a generation.
+// Zero values mean the model's default was used.
+type GenerationParameters struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Temperature   float32                `protobuf:"fixed32,1,opt,name=temperature,proto3" json:"temperature,omitempty"`
+	MaxTokens     int32                  `protobuf:"varint,2,opt,name=max_tokens,json=maxTokens,proto3" json:"max_tokens,omitempty"`
+	TopP          float32                `protobuf:"fixed32,3,opt,name=top_p,json=topP,proto3" json:"top_p,omitempty"`
+	TopK          int32                  `protobuf:"varint,4,opt,name=top_k,json=topK,proto3" json:"top_k,omitempty"`
+	RepeatPenalty float32                `protobuf:"fixed32,5,opt,name=repeat_penalty,json=repeatPenalty,proto3" json:"repeat_penalty,omitempty"`
+	Seed          int64                  `protobuf:"varint,6,opt,name=seed,proto3" json:"seed,omitempty"`
+	Stop          []string               `protobuf:"bytes,7,rep,name=stop,proto3" json:"stop,omitempty"`
+	NumCtx        int32                  `protobuf:"varint,8,opt,name=num_ctx,json=numCtx,proto3" json:"num_ctx,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerationParameters) Reset() {
+	*x = GenerationParameters{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerationParameters) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerationParameters) ProtoMessage() {}
+
+func (x *GenerationParameters) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerationParameters.ProtoReflect.Descriptor instead.
+func (*GenerationParameters) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GenerationParameters) GetTemperature() float32 {
+	if x != nil {
+		return x.Temperature
+	}
+	return 0
+}
+
+func (x *GenerationParameters) GetMaxTokens() int32 {
+	if x != nil {
+		return x.MaxTokens
+	}
+	return 0
+}
+
+func (x *GenerationParameters) GetTopP() float32 {
+	if x != nil {
+		return x.TopP
+	}
+	return 0
+}
+
+func (x *GenerationParameters) GetTopK() int32 {
+	if x != nil {
+		return x.TopK
+	}
+	return 0
+}
+
+func (x *GenerationParameters) GetRepeatPenalty() float32 {
+	if x != nil {
+		return x.RepeatPenalty
+	}
+	return 0
+}
+
+func (x *GenerationParameters) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+func (x *GenerationParameters) GetStop() []string {
+	if x != nil {
+		return x.Stop
+	}
+	return nil
+}
+
+func (x *GenerationParameters) GetNumCtx() int32 {
+	if x != nil {
+		return x.NumCtx
+	}
+	return 0
+}
+
+// Usage summarizes the cost of a request, mirroring OpenAI's usage block
+type Usage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of tokens in the prompt
+	PromptTokens int32 `protobuf:"varint,1,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	// Number of tokens generated
+	CompletionTokens int32 `protobuf:"varint,2,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	// Total tokens used
+	TotalTokens int32 `protobuf:"varint,3,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`
+	// Estimated cost in USD based on the configured model pricing
+	EstimatedCost float64 `protobuf:"fixed64,4,opt,name=estimated_cost,json=estimatedCost,proto3" json:"estimated_cost,omitempty"`
+	// Whether the response was served from cache
+	CacheHit bool `protobuf:"varint,5,opt,name=cache_hit,json=cacheHit,proto3" json:"cache_hit,omitempty"`
+	// Number of additional backend attempts made for the request
+	Retries       int32 `protobuf:"varint,6,opt,name=retries,proto3" json:"retries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Usage) Reset() {
+	*x = Usage{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Usage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Usage) ProtoMessage() {}
+
+func (x *Usage) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Usage.ProtoReflect.Descriptor instead.
+func (*Usage) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Usage) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetCompletionTokens() int32 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetTotalTokens() int32 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetEstimatedCost() float64 {
+	if x != nil {
+		return x.EstimatedCost
+	}
+	return 0
+}
+
+func (x *Usage) GetCacheHit() bool {
+	if x != nil {
+		return x.CacheHit
+	}
+	return false
+}
+
+func (x *Usage) GetRetries() int32 {
+	if x != nil {
+		return x.Retries
+	}
+	return 0
+}
+
+// TokenResponse for streaming responses
+type TokenResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The unique request identifier
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// A single token or chunk of text
+	Token string `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	// Whether this is the final token
+	Done bool `protobuf:"varint,3,opt,name=done,proto3" json:"done,omitempty"`
+	// Running count of tokens generated
+	TokensGenerated int32 `protobuf:"varint,4,opt,name=tokens_generated,json=tokensGenerated,proto3" json:"tokens_generated,omitempty"`
+	// The model used for generation (set on the final message)
+	Model string `protobuf:"bytes,5,opt,name=model,proto3" json:"model,omitempty"`
+	// Number of tokens in the prompt (set on the final message)
+	PromptTokens int32 `protobuf:"varint,6,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	// Time taken for inference in milliseconds (set on the final message)
+	InferenceTimeMs int64 `protobuf:"varint,7,opt,name=inference_time_ms,json=inferenceTimeMs,proto3" json:"inference_time_ms,omitempty"`
+	// Optional Ed25519 signature over the complete result (set on the final message)
+	Signature []byte `protobuf:"bytes,8,opt,name=signature,proto3" json:"signature,omitempty"`
+	// The parameters the response was generated with (set on the final message)
+	Parameters *GenerationParameters `protobuf:"bytes,9,opt,name=parameters,proto3" json:"parameters,omitempty"`
+	// Ollama context tokens, when requested with return_context (set on the
+	// final message)
+	Context       []int32 `protobuf:"varint,10,rep,packed,name=context,proto3" json:"context,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenResponse) Reset() {
+	*x = TokenResponse{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenResponse) ProtoMessage() {}
+
+func (x *TokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenResponse.ProtoReflect.Descriptor instead.
+func (*TokenResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TokenResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *TokenResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *TokenResponse) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *TokenResponse) GetTokensGenerated() int32 {
+	if x != nil {
+		return x.TokensGenerated
+	}
+	return 0
+}
+
+func (x *TokenResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *TokenResponse) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *TokenResponse) GetInferenceTimeMs() int64 {
+	if x != nil {
+		return x.InferenceTimeMs
+	}
+	return 0
+}
+
+func (x *TokenResponse) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *TokenResponse) GetParameters() *GenerationParameters {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *TokenResponse) GetContext() []int32 {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+// HealthCheckRequest for worker health verification
+type HealthCheckRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Optional: include a timestamp for latency measurement
+	Timestamp     int64 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckRequest) Reset() {
+	*x = HealthCheckRequest{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckRequest) ProtoMessage() {}
+
+func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
+func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *HealthCheckRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// HealthCheckResponse from worker
+type HealthCheckResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Whether the worker is healthy and ready
+	Healthy bool `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	// Current load on the worker (0.0 - 1.0)
+	Load float32 `protobuf:"fixed32,2,opt,name=load,proto3" json:"load,omitempty"`
+	// Number of requests currently being processed
+	ActiveRequests int32 `protobuf:"varint,3,opt,name=active_requests,json=activeRequests,proto3" json:"active_requests,omitempty"`
+	// Worker version for compatibility checks
+	Version string `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	// Whether Ollama is reachable
+	OllamaConnected bool `protobuf:"varint,5,opt,name=ollama_connected,json=ollamaConnected,proto3" json:"ollama_connected,omitempty"`
+	// Stable, operator-assigned identity of the worker (empty if unset)
+	InstanceId string `protobuf:"bytes,6,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	// Worker clock at the time of the response, in Unix milliseconds
+	Timestamp int64 `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Worker clock minus the request timestamp in milliseconds, including
+	// one-way network latency (0 if the request carried no timestamp)
+	ClockSkewMs int64 `protobuf:"varint,8,opt,name=clock_skew_ms,json=clockSkewMs,proto3" json:"clock_skew_ms,omitempty"`
+	// Models available on the worker's Ollama instance
+	Models []string `protobuf:"bytes,9,rep,name=models,proto3" json:"models,omitempty"`
+	// Models currently loaded into memory on the worker's Ollama instance
+	LoadedModels []*LoadedModel `protobuf:"bytes,10,rep,name=loaded_models,json=loadedModels,proto3" json:"loaded_models,omitempty"`
+	// Inferences the worker runs at once; active_requests counts those
+	// running (0 if the worker doesn't report it)
+	Capacity int32 `protobuf:"varint,11,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	// Requests waiting for an inference slot, and how many may wait before
+	// more are rejected
+	QueueDepth    int32 `protobuf:"varint,12,opt,name=queue_depth,json=queueDepth,proto3" json:"queue_depth,omitempty"`
+	MaxQueueDepth int32 `protobuf:"varint,13,opt,name=max_queue_depth,json=maxQueueDepth,proto3" json:"max_queue_depth,omitempty"`
+	// Estimated wait for a slot of a request sent now, in milliseconds
+	EstimatedWaitMs int64 `protobuf:"varint,14,opt,name=estimated_wait_ms,json=estimatedWaitMs,proto3" json:"estimated_wait_ms,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *HealthCheckResponse) Reset() {
+	*x = HealthCheckResponse{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckResponse) ProtoMessage() {}
+
+func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
+func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HealthCheckResponse) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+func (x *HealthCheckResponse) GetLoad() float32 {
+	if x != nil {
+		return x.Load
+	}
+	return 0
+}
+
+func (x *HealthCheckResponse) GetActiveRequests() int32 {
+	if x != nil {
+		return x.ActiveRequests
+	}
+	return 0
+}
+
+func (x *HealthCheckResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *HealthCheckResponse) GetOllamaConnected() bool {
+	if x != nil {
+		return x.OllamaConnected
+	}
+	return false
+}
+
+func (x *HealthCheckResponse) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *HealthCheckResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *HealthCheckResponse) GetClockSkewMs() int64 {
+	if x != nil {
+		return x.ClockSkewMs
+	}
+	return 0
+}
+
+func (x *HealthCheckResponse) GetModels() []string {
+	if x != nil {
+		return x.Models
+	}
+	return nil
+}
+
+func (x *HealthCheckResponse) GetLoadedModels() []*LoadedModel {
+	if x != nil {
+		return x.LoadedModels
+	}
+	return nil
+}
+
+func (x *HealthCheckResponse) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *HealthCheckResponse) GetQueueDepth() int32 {
+	if x != nil {
+		return x.QueueDepth
+	}
+	return 0
+}
+
+func (x *HealthCheckResponse) GetMaxQueueDepth() int32 {
+	if x != nil {
+		return x.MaxQueueDepth
+	}
+	return 0
+}
+
+func (x *HealthCheckResponse) GetEstimatedWaitMs() int64 {
+	if x != nil {
+		return x.EstimatedWaitMs
+	}
+	return 0
+}
+
+// LoadedModel describes a model loaded into memory
+type LoadedModel struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The model name
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Total memory used by the model in bytes
+	Size int64 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	// GPU memory used by the model in bytes
+	SizeVram int64 `protobuf:"varint,3,opt,name=size_vram,json=sizeVram,proto3" json:"size_vram,omitempty"`
+	// When the model will be unloaded if idle, in Unix milliseconds
+	ExpiresAt     int64 `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoadedModel) Reset() {
+	*x = LoadedModel{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoadedModel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadedModel) ProtoMessage() {}
+
+func (x *LoadedModel) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadedModel.ProtoReflect.Descriptor instead.
+func (*LoadedModel) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LoadedModel) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LoadedModel) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *LoadedModel) GetSizeVram() int64 {
+	if x != nil {
+		return x.SizeVram
+	}
+	return 0
+}
+
+func (x *LoadedModel) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+// EmbedRequest contains the input for embedding generation
+type EmbedRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The unique request identifier for tracing
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// The texts to embed
+	Input []string `protobuf:"bytes,2,rep,name=input,proto3" json:"input,omitempty"`
+	// The embedding model to use (e.g., "nomic-embed-text")
+	Model         string `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedRequest) Reset() {
+	*x = EmbedRequest{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedRequest) ProtoMessage() {}
+
+func (x *EmbedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedRequest.ProtoReflect.Descriptor instead.
+func (*EmbedRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *EmbedRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *EmbedRequest) GetInput() []string {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+func (x *EmbedRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+// Embedding is a single embedding vector
+type Embedding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []float32              `protobuf:"fixed32,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Embedding) Reset() {
+	*x = Embedding{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Embedding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Embedding) ProtoMessage() {}
+
+func (x *Embedding) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Embedding.ProtoReflect.Descriptor instead.
+func (*Embedding) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Embedding) GetValues() []float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// EmbedResponse contains one embedding per input, in order
+type EmbedResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The unique request identifier (echoed back)
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// The embedding vectors
+	Embeddings []*Embedding `protobuf:"bytes,2,rep,name=embeddings,proto3" json:"embeddings,omitempty"`
+	// The model used for embedding
+	Model string `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	// Number of tokens in the input
+	PromptTokens  int32 `protobuf:"varint,4,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedResponse) Reset() {
+	*x = EmbedResponse{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedResponse) ProtoMessage() {}
+
+func (x *EmbedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedResponse.ProtoReflect.Descriptor instead.
+func (*EmbedResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EmbedResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *EmbedResponse) GetEmbeddings() []*Embedding {
+	if x != nil {
+		return x.Embeddings
+	}
+	return nil
+}
+
+func (x *EmbedResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *EmbedResponse) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+// CountTokensRequest is a prompt to count the tokens of
+type CountTokensRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The unique request identifier for tracing
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// The model whose tokenizer to use
+	Model string `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	// The prompt text
+	Prompt string `protobuf:"bytes,3,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	// Optional system prompt, counted with the prompt
+	SystemPrompt  string `protobuf:"bytes,4,opt,name=system_prompt,json=systemPrompt,proto3" json:"system_prompt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CountTokensRequest) Reset() {
+	*x = CountTokensRequest{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CountTokensRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountTokensRequest) ProtoMessage() {}
+
+func (x *CountTokensRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountTokensRequest.ProtoReflect.Descriptor instead.
+func (*CountTokensRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CountTokensRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *CountTokensRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *CountTokensRequest) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *CountTokensRequest) GetSystemPrompt() string {
+	if x != nil {
+		return x.SystemPrompt
+	}
+	return ""
+}
+
+// CountTokensResponse holds a prompt's token count
+type CountTokensResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The unique request identifier (echoed back)
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// The model the tokens were counted for
+	Model string `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	// Number of tokens in the prompt
+	Tokens int32 `protobuf:"varint,3,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	// Whether tokens is an estimate from the prompt's length, for backends
+	// without a tokenizer API
+	Estimated bool `protobuf:"varint,4,opt,name=estimated,proto3" json:"estimated,omitempty"`
+	// The model's context window in tokens as configured on the worker, 0 if
+	// unknown
+	ContextLength int32 `protobuf:"varint,5,opt,name=context_length,json=contextLength,proto3" json:"context_length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CountTokensResponse) Reset() {
+	*x = CountTokensResponse{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CountTokensResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountTokensResponse) ProtoMessage() {}
+
+func (x *CountTokensResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountTokensResponse.ProtoReflect.Descriptor instead.
+func (*CountTokensResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CountTokensResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *CountTokensResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *CountTokensResponse) GetTokens() int32 {
+	if x != nil {
+		return x.Tokens
+	}
+	return 0
+}
+
+func (x *CountTokensResponse) GetEstimated() bool {
+	if x != nil {
+		return x.Estimated
+	}
+	return false
+}
+
+func (x *CountTokensResponse) GetContextLength() int32 {
+	if x != nil {
+		return x.ContextLength
+	}
+	return 0
+}
+
+// PullModelRequest names a model to download
+type PullModelRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The model to pull (e.g., "llama3.2:3b")
+	Model string `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	// Allow pulling from registries without TLS
+	Insecure      bool `protobuf:"varint,2,opt,name=insecure,proto3" json:"insecure,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PromptResponse) Reset() {
-	*x = PromptResponse{}
-	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[1]
+func (x *PullModelRequest) Reset() {
+	*x = PullModelRequest{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PromptResponse) String() string {
+func (x *PullModelRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PromptResponse) ProtoMessage() {}
+func (*PullModelRequest) ProtoMessage() {}
 
-func (x *PromptResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[1]
+func (x *PullModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -161,90 +1380,165 @@ func (x *PromptResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PromptResponse.ProtoReflect.Descriptor instead.
-func (*PromptResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use PullModelRequest.ProtoReflect.Descriptor instead.
+func (*PullModelRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *PromptResponse) GetRequestId() string {
+func (x *PullModelRequest) GetModel() string {
 	if x != nil {
-		return x.RequestId
+		return x.Model
 	}
 	return ""
 }
 
-func (x *PromptResponse) GetResponse() string {
+func (x *PullModelRequest) GetInsecure() bool {
 	if x != nil {
-		return x.Response
+		return x.Insecure
+	}
+	return false
+}
+
+// PullModelProgress reports the progress of a pull
+type PullModelProgress struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Ollama's description of the current step; "success" when complete
+	Status string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	// The layer being downloaded
+	Digest string `protobuf:"bytes,2,opt,name=digest,proto3" json:"digest,omitempty"`
+	// Size of the layer in bytes
+	Total int64 `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	// Bytes of the layer downloaded so far
+	Completed     int64 `protobuf:"varint,4,opt,name=completed,proto3" json:"completed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PullModelProgress) Reset() {
+	*x = PullModelProgress{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PullModelProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PullModelProgress) ProtoMessage() {}
+
+func (x *PullModelProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PullModelProgress.ProtoReflect.Descriptor instead.
+func (*PullModelProgress) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *PullModelProgress) GetStatus() string {
+	if x != nil {
+		return x.Status
 	}
 	return ""
 }
 
-func (x *PromptResponse) GetPromptTokens() int32 {
+func (x *PullModelProgress) GetDigest() string {
 	if x != nil {
-		return x.PromptTokens
+		return x.Digest
 	}
-	return 0
+	return ""
 }
 
-func (x *PromptResponse) GetCompletionTokens() int32 {
+func (x *PullModelProgress) GetTotal() int64 {
 	if x != nil {
-		return x.CompletionTokens
+		return x.Total
 	}
 	return 0
 }
 
-func (x *PromptResponse) GetTotalTokens() int32 {
+func (x *PullModelProgress) GetCompleted() int64 {
 	if x != nil {
-		return x.TotalTokens
+		return x.Completed
 	}
 	return 0
 }
 
-func (x *PromptResponse) GetInferenceTimeMs() int64 {
+// DeleteModelRequest names a model to remove
+type DeleteModelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteModelRequest) Reset() {
+	*x = DeleteModelRequest{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteModelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteModelRequest) ProtoMessage() {}
+
+func (x *DeleteModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[16]
 	if x != nil {
-		return x.InferenceTimeMs
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *PromptResponse) GetModel() string {
+// Deprecated: Use DeleteModelRequest.ProtoReflect.Descriptor instead.
+func (*DeleteModelRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DeleteModelRequest) GetModel() string {
 	if x != nil {
 		return x.Model
 	}
 	return ""
 }
 
-// TokenResponse for streaming responses
-type TokenResponse struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// The unique request identifier
-	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
-	// A single token or chunk of text
-	Token string `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
-	// Whether this is the final token
-	Done bool `protobuf:"varint,3,opt,name=done,proto3" json:"done,omitempty"`
-	// Running count of tokens generated
-	TokensGenerated int32 `protobuf:"varint,4,opt,name=tokens_generated,json=tokensGenerated,proto3" json:"tokens_generated,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+// DeleteModelResponse is returned once the model is removed
+type DeleteModelResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TokenResponse) Reset() {
-	*x = TokenResponse{}
-	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[2]
+func (x *DeleteModelResponse) Reset() {
+	*x = DeleteModelResponse{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TokenResponse) String() string {
+func (x *DeleteModelResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TokenResponse) ProtoMessage() {}
+func (*DeleteModelResponse) ProtoMessage() {}
 
-func (x *TokenResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[2]
+func (x *DeleteModelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -255,63 +1549,124 @@ func (x *TokenResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TokenResponse.ProtoReflect.Descriptor instead.
-func (*TokenResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use DeleteModelResponse.ProtoReflect.Descriptor instead.
+func (*DeleteModelResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *TokenResponse) GetRequestId() string {
+// CopyModelRequest copies a model under a new name
+type CopyModelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Destination   string                 `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CopyModelRequest) Reset() {
+	*x = CopyModelRequest{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CopyModelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CopyModelRequest) ProtoMessage() {}
+
+func (x *CopyModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[18]
 	if x != nil {
-		return x.RequestId
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *TokenResponse) GetToken() string {
+// Deprecated: Use CopyModelRequest.ProtoReflect.Descriptor instead.
+func (*CopyModelRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CopyModelRequest) GetSource() string {
 	if x != nil {
-		return x.Token
+		return x.Source
 	}
 	return ""
 }
 
-func (x *TokenResponse) GetDone() bool {
+func (x *CopyModelRequest) GetDestination() string {
 	if x != nil {
-		return x.Done
+		return x.Destination
 	}
-	return false
+	return ""
 }
 
-func (x *TokenResponse) GetTokensGenerated() int32 {
+// CopyModelResponse is returned once the copy exists
+type CopyModelResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CopyModelResponse) Reset() {
+	*x = CopyModelResponse{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CopyModelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CopyModelResponse) ProtoMessage() {}
+
+func (x *CopyModelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[19]
 	if x != nil {
-		return x.TokensGenerated
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-// HealthCheckRequest for worker health verification
-type HealthCheckRequest struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// Optional: include a timestamp for latency measurement
-	Timestamp     int64 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+// Deprecated: Use CopyModelResponse.ProtoReflect.Descriptor instead.
+func (*CopyModelResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{19}
+}
+
+// ShowModelRequest names a model to describe
+type ShowModelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthCheckRequest) Reset() {
-	*x = HealthCheckRequest{}
-	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[3]
+func (x *ShowModelRequest) Reset() {
+	*x = ShowModelRequest{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckRequest) String() string {
+func (x *ShowModelRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckRequest) ProtoMessage() {}
+func (*ShowModelRequest) ProtoMessage() {}
 
-func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[3]
+func (x *ShowModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -322,50 +1677,60 @@ func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
-func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use ShowModelRequest.ProtoReflect.Descriptor instead.
+func (*ShowModelRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *HealthCheckRequest) GetTimestamp() int64 {
+func (x *ShowModelRequest) GetModel() string {
 	if x != nil {
-		return x.Timestamp
+		return x.Model
 	}
-	return 0
+	return ""
 }
 
-// HealthCheckResponse from worker
-type HealthCheckResponse struct {
+// ShowModelResponse describes a model
+type ShowModelResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Whether the worker is healthy and ready
-	Healthy bool `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
-	// Current load on the worker (0.0 - 1.0)
-	Load float32 `protobuf:"fixed32,2,opt,name=load,proto3" json:"load,omitempty"`
-	// Number of requests currently being processed
-	ActiveRequests int32 `protobuf:"varint,3,opt,name=active_requests,json=activeRequests,proto3" json:"active_requests,omitempty"`
-	// Worker version for compatibility checks
-	Version string `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
-	// Whether Ollama is reachable
-	OllamaConnected bool `protobuf:"varint,5,opt,name=ollama_connected,json=ollamaConnected,proto3" json:"ollama_connected,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	// The model described
+	Model string `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	// The Modelfile the model was built from
+	Modelfile string `protobuf:"bytes,2,opt,name=modelfile,proto3" json:"modelfile,omitempty"`
+	// Default generation parameters, one per line
+	Parameters string `protobuf:"bytes,3,opt,name=parameters,proto3" json:"parameters,omitempty"`
+	// The prompt template
+	Template string `protobuf:"bytes,4,opt,name=template,proto3" json:"template,omitempty"`
+	// Model family (e.g., "llama")
+	Family string `protobuf:"bytes,5,opt,name=family,proto3" json:"family,omitempty"`
+	// Parameter count (e.g., "3.2B")
+	ParameterSize string `protobuf:"bytes,6,opt,name=parameter_size,json=parameterSize,proto3" json:"parameter_size,omitempty"`
+	// Quantization (e.g., "Q4_K_M")
+	QuantizationLevel string `protobuf:"bytes,7,opt,name=quantization_level,json=quantizationLevel,proto3" json:"quantization_level,omitempty"`
+	// Weight format (e.g., "gguf")
+	Format string `protobuf:"bytes,8,opt,name=format,proto3" json:"format,omitempty"`
+	// Features the model supports (e.g., "completion", "tools", "vision")
+	Capabilities []string `protobuf:"bytes,9,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	// When the model was last modified, in Unix milliseconds
+	ModifiedAt    int64 `protobuf:"varint,10,opt,name=modified_at,json=modifiedAt,proto3" json:"modified_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthCheckResponse) Reset() {
-	*x = HealthCheckResponse{}
-	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[4]
+func (x *ShowModelResponse) Reset() {
+	*x = ShowModelResponse{}
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckResponse) String() string {
+func (x *ShowModelResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckResponse) ProtoMessage() {}
+func (*ShowModelResponse) ProtoMessage() {}
 
-func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[4]
+func (x *ShowModelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_llm_v1_llm_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -376,51 +1741,86 @@ func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
-func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use ShowModelResponse.ProtoReflect.Descriptor instead.
+func (*ShowModelResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_llm_v1_llm_proto_rawDescGZIP(), []int{21}
 }
 
-func (x *HealthCheckResponse) GetHealthy() bool {
+func (x *ShowModelResponse) GetModel() string {
 	if x != nil {
-		return x.Healthy
+		return x.Model
 	}
-	return false
+	return ""
 }
 
-func (x *HealthCheckResponse) GetLoad() float32 {
+func (x *ShowModelResponse) GetModelfile() string {
 	if x != nil {
-		return x.Load
+		return x.Modelfile
 	}
-	return 0
+	return ""
 }
 
-func (x *HealthCheckResponse) GetActiveRequests() int32 {
+func (x *ShowModelResponse) GetParameters() string {
 	if x != nil {
-		return x.ActiveRequests
+		return x.Parameters
 	}
-	return 0
+	return ""
 }
 
-func (x *HealthCheckResponse) GetVersion() string {
+func (x *ShowModelResponse) GetTemplate() string {
 	if x != nil {
-		return x.Version
+		return x.Template
 	}
 	return ""
 }
 
-func (x *HealthCheckResponse) GetOllamaConnected() bool {
+func (x *ShowModelResponse) GetFamily() string {
 	if x != nil {
-		return x.OllamaConnected
+		return x.Family
 	}
-	return false
+	return ""
+}
+
+func (x *ShowModelResponse) GetParameterSize() string {
+	if x != nil {
+		return x.ParameterSize
+	}
+	return ""
+}
+
+func (x *ShowModelResponse) GetQuantizationLevel() string {
+	if x != nil {
+		return x.QuantizationLevel
+	}
+	return ""
+}
+
+func (x *ShowModelResponse) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ShowModelResponse) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *ShowModelResponse) GetModifiedAt() int64 {
+	if x != nil {
+		return x.ModifiedAt
+	}
+	return 0
 }
 
 var File_api_proto_llm_v1_llm_proto protoreflect.FileDescriptor
 
 const file_api_proto_llm_v1_llm_proto_rawDesc = "" +
 	"\n" +
-	"\x1aapi/proto/llm/v1/llm.proto\x12\x06llm.v1\"\xc2\x01\n" +
+	"\x1aapi/proto/llm/v1/llm.proto\x12\x06llm.v1\"\xea\x03\n" +
 	"\rPromptRequest\x12\x1d\n" +
 	"\n" +
 	"request_id\x18\x01 \x01(\tR\trequestId\x12\x16\n" +
@@ -429,7 +1829,22 @@ const file_api_proto_llm_v1_llm_proto_rawDesc = "" +
 	"\n" +
 	"max_tokens\x18\x04 \x01(\x05R\tmaxTokens\x12 \n" +
 	"\vtemperature\x18\x05 \x01(\x02R\vtemperature\x12#\n" +
-	"\rsystem_prompt\x18\x06 \x01(\tR\fsystemPrompt\"\x82\x02\n" +
+	"\rsystem_prompt\x18\x06 \x01(\tR\fsystemPrompt\x12\x16\n" +
+	"\x06format\x18\a \x01(\tR\x06format\x12\x13\n" +
+	"\x05top_p\x18\b \x01(\x02R\x04topP\x12\x13\n" +
+	"\x05top_k\x18\t \x01(\x05R\x04topK\x12%\n" +
+	"\x0erepeat_penalty\x18\n" +
+	" \x01(\x02R\rrepeatPenalty\x12\x17\n" +
+	"\x04seed\x18\v \x01(\x03H\x00R\x04seed\x88\x01\x01\x12\x12\n" +
+	"\x04stop\x18\f \x03(\tR\x04stop\x12\x17\n" +
+	"\anum_ctx\x18\r \x01(\x05R\x06numCtx\x12-\n" +
+	"\ahistory\x18\x0e \x03(\v2\x13.llm.v1.ChatMessageR\ahistory\x12\x18\n" +
+	"\acontext\x18\x0f \x03(\x05R\acontext\x12%\n" +
+	"\x0ereturn_context\x18\x10 \x01(\bR\rreturnContextB\a\n" +
+	"\x05_seed\";\n" +
+	"\vChatMessage\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"\x9d\x03\n" +
 	"\x0ePromptResponse\x12\x1d\n" +
 	"\n" +
 	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1a\n" +
@@ -438,26 +1853,144 @@ const file_api_proto_llm_v1_llm_proto_rawDesc = "" +
 	"\x11completion_tokens\x18\x04 \x01(\x05R\x10completionTokens\x12!\n" +
 	"\ftotal_tokens\x18\x05 \x01(\x05R\vtotalTokens\x12*\n" +
 	"\x11inference_time_ms\x18\x06 \x01(\x03R\x0finferenceTimeMs\x12\x14\n" +
-	"\x05model\x18\a \x01(\tR\x05model\"\x83\x01\n" +
+	"\x05model\x18\a \x01(\tR\x05model\x12\x1c\n" +
+	"\tsignature\x18\b \x01(\fR\tsignature\x12#\n" +
+	"\x05usage\x18\t \x01(\v2\r.llm.v1.UsageR\x05usage\x12<\n" +
+	"\n" +
+	"parameters\x18\n" +
+	" \x01(\v2\x1c.llm.v1.GenerationParametersR\n" +
+	"parameters\x12\x18\n" +
+	"\acontext\x18\v \x03(\x05R\acontext\"\xe9\x01\n" +
+	"\x14GenerationParameters\x12 \n" +
+	"\vtemperature\x18\x01 \x01(\x02R\vtemperature\x12\x1d\n" +
+	"\n" +
+	"max_tokens\x18\x02 \x01(\x05R\tmaxTokens\x12\x13\n" +
+	"\x05top_p\x18\x03 \x01(\x02R\x04topP\x12\x13\n" +
+	"\x05top_k\x18\x04 \x01(\x05R\x04topK\x12%\n" +
+	"\x0erepeat_penalty\x18\x05 \x01(\x02R\rrepeatPenalty\x12\x12\n" +
+	"\x04seed\x18\x06 \x01(\x03R\x04seed\x12\x12\n" +
+	"\x04stop\x18\a \x03(\tR\x04stop\x12\x17\n" +
+	"\anum_ctx\x18\b \x01(\x05R\x06numCtx\"\xda\x01\n" +
+	"\x05Usage\x12#\n" +
+	"\rprompt_tokens\x18\x01 \x01(\x05R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x02 \x01(\x05R\x10completionTokens\x12!\n" +
+	"\ftotal_tokens\x18\x03 \x01(\x05R\vtotalTokens\x12%\n" +
+	"\x0eestimated_cost\x18\x04 \x01(\x01R\restimatedCost\x12\x1b\n" +
+	"\tcache_hit\x18\x05 \x01(\bR\bcacheHit\x12\x18\n" +
+	"\aretries\x18\x06 \x01(\x05R\aretries\"\xe0\x02\n" +
 	"\rTokenResponse\x12\x1d\n" +
 	"\n" +
 	"request_id\x18\x01 \x01(\tR\trequestId\x12\x14\n" +
 	"\x05token\x18\x02 \x01(\tR\x05token\x12\x12\n" +
 	"\x04done\x18\x03 \x01(\bR\x04done\x12)\n" +
-	"\x10tokens_generated\x18\x04 \x01(\x05R\x0ftokensGenerated\"2\n" +
+	"\x10tokens_generated\x18\x04 \x01(\x05R\x0ftokensGenerated\x12\x14\n" +
+	"\x05model\x18\x05 \x01(\tR\x05model\x12#\n" +
+	"\rprompt_tokens\x18\x06 \x01(\x05R\fpromptTokens\x12*\n" +
+	"\x11inference_time_ms\x18\a \x01(\x03R\x0finferenceTimeMs\x12\x1c\n" +
+	"\tsignature\x18\b \x01(\fR\tsignature\x12<\n" +
+	"\n" +
+	"parameters\x18\t \x01(\v2\x1c.llm.v1.GenerationParametersR\n" +
+	"parameters\x12\x18\n" +
+	"\acontext\x18\n" +
+	" \x03(\x05R\acontext\"2\n" +
 	"\x12HealthCheckRequest\x12\x1c\n" +
-	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\"\xb1\x01\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\"\xf7\x03\n" +
 	"\x13HealthCheckResponse\x12\x18\n" +
 	"\ahealthy\x18\x01 \x01(\bR\ahealthy\x12\x12\n" +
 	"\x04load\x18\x02 \x01(\x02R\x04load\x12'\n" +
 	"\x0factive_requests\x18\x03 \x01(\x05R\x0eactiveRequests\x12\x18\n" +
 	"\aversion\x18\x04 \x01(\tR\aversion\x12)\n" +
-	"\x10ollama_connected\x18\x05 \x01(\bR\x0follamaConnected2\xd9\x01\n" +
+	"\x10ollama_connected\x18\x05 \x01(\bR\x0follamaConnected\x12\x1f\n" +
+	"\vinstance_id\x18\x06 \x01(\tR\n" +
+	"instanceId\x12\x1c\n" +
+	"\ttimestamp\x18\a \x01(\x03R\ttimestamp\x12\"\n" +
+	"\rclock_skew_ms\x18\b \x01(\x03R\vclockSkewMs\x12\x16\n" +
+	"\x06models\x18\t \x03(\tR\x06models\x128\n" +
+	"\rloaded_models\x18\n" +
+	" \x03(\v2\x13.llm.v1.LoadedModelR\floadedModels\x12\x1a\n" +
+	"\bcapacity\x18\v \x01(\x05R\bcapacity\x12\x1f\n" +
+	"\vqueue_depth\x18\f \x01(\x05R\n" +
+	"queueDepth\x12&\n" +
+	"\x0fmax_queue_depth\x18\r \x01(\x05R\rmaxQueueDepth\x12*\n" +
+	"\x11estimated_wait_ms\x18\x0e \x01(\x03R\x0festimatedWaitMs\"q\n" +
+	"\vLoadedModel\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x1b\n" +
+	"\tsize_vram\x18\x03 \x01(\x03R\bsizeVram\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\"Y\n" +
+	"\fEmbedRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x14\n" +
+	"\x05input\x18\x02 \x03(\tR\x05input\x12\x14\n" +
+	"\x05model\x18\x03 \x01(\tR\x05model\"#\n" +
+	"\tEmbedding\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\x02R\x06values\"\x9c\x01\n" +
+	"\rEmbedResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x121\n" +
+	"\n" +
+	"embeddings\x18\x02 \x03(\v2\x11.llm.v1.EmbeddingR\n" +
+	"embeddings\x12\x14\n" +
+	"\x05model\x18\x03 \x01(\tR\x05model\x12#\n" +
+	"\rprompt_tokens\x18\x04 \x01(\x05R\fpromptTokens\"\x86\x01\n" +
+	"\x12CountTokensRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x14\n" +
+	"\x05model\x18\x02 \x01(\tR\x05model\x12\x16\n" +
+	"\x06prompt\x18\x03 \x01(\tR\x06prompt\x12#\n" +
+	"\rsystem_prompt\x18\x04 \x01(\tR\fsystemPrompt\"\xa7\x01\n" +
+	"\x13CountTokensResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x14\n" +
+	"\x05model\x18\x02 \x01(\tR\x05model\x12\x16\n" +
+	"\x06tokens\x18\x03 \x01(\x05R\x06tokens\x12\x1c\n" +
+	"\testimated\x18\x04 \x01(\bR\testimated\x12%\n" +
+	"\x0econtext_length\x18\x05 \x01(\x05R\rcontextLength\"D\n" +
+	"\x10PullModelRequest\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x1a\n" +
+	"\binsecure\x18\x02 \x01(\bR\binsecure\"w\n" +
+	"\x11PullModelProgress\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x16\n" +
+	"\x06digest\x18\x02 \x01(\tR\x06digest\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x03R\x05total\x12\x1c\n" +
+	"\tcompleted\x18\x04 \x01(\x03R\tcompleted\"*\n" +
+	"\x12DeleteModelRequest\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\"\x15\n" +
+	"\x13DeleteModelResponse\"L\n" +
+	"\x10CopyModelRequest\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12 \n" +
+	"\vdestination\x18\x02 \x01(\tR\vdestination\"\x13\n" +
+	"\x11CopyModelResponse\"(\n" +
+	"\x10ShowModelRequest\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\"\xce\x02\n" +
+	"\x11ShowModelResponse\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x1c\n" +
+	"\tmodelfile\x18\x02 \x01(\tR\tmodelfile\x12\x1e\n" +
+	"\n" +
+	"parameters\x18\x03 \x01(\tR\n" +
+	"parameters\x12\x1a\n" +
+	"\btemplate\x18\x04 \x01(\tR\btemplate\x12\x16\n" +
+	"\x06family\x18\x05 \x01(\tR\x06family\x12%\n" +
+	"\x0eparameter_size\x18\x06 \x01(\tR\rparameterSize\x12-\n" +
+	"\x12quantization_level\x18\a \x01(\tR\x11quantizationLevel\x12\x16\n" +
+	"\x06format\x18\b \x01(\tR\x06format\x12\"\n" +
+	"\fcapabilities\x18\t \x03(\tR\fcapabilities\x12\x1f\n" +
+	"\vmodified_at\x18\n" +
+	" \x01(\x03R\n" +
+	"modifiedAt2\xd7\x02\n" +
 	"\n" +
 	"LLMService\x12=\n" +
 	"\fGenerateText\x12\x15.llm.v1.PromptRequest\x1a\x16.llm.v1.PromptResponse\x12D\n" +
 	"\x12StreamGenerateText\x12\x15.llm.v1.PromptRequest\x1a\x15.llm.v1.TokenResponse0\x01\x12F\n" +
-	"\vHealthCheck\x12\x1a.llm.v1.HealthCheckRequest\x1a\x1b.llm.v1.HealthCheckResponseB<Z:github.com/hugovillarreal/neurogate/api/proto/llm/v1;llmv1b\x06proto3"
+	"\vHealthCheck\x12\x1a.llm.v1.HealthCheckRequest\x1a\x1b.llm.v1.HealthCheckResponse\x124\n" +
+	"\x05Embed\x12\x14.llm.v1.EmbedRequest\x1a\x15.llm.v1.EmbedResponse\x12F\n" +
+	"\vCountTokens\x12\x1a.llm.v1.CountTokensRequest\x1a\x1b.llm.v1.CountTokensResponse2\xa3\x02\n" +
+	"\x11ModelAdminService\x12B\n" +
+	"\tPullModel\x12\x18.llm.v1.PullModelRequest\x1a\x19.llm.v1.PullModelProgress0\x01\x12F\n" +
+	"\vDeleteModel\x12\x1a.llm.v1.DeleteModelRequest\x1a\x1b.llm.v1.DeleteModelResponse\x12@\n" +
+	"\tCopyModel\x12\x18.llm.v1.CopyModelRequest\x1a\x19.llm.v1.CopyModelResponse\x12@\n" +
+	"\tShowModel\x12\x18.llm.v1.ShowModelRequest\x1a\x19.llm.v1.ShowModelResponseB<Z:github.com/hugovillarreal/neurogate/api/proto/llm/v1;llmv1b\x06proto3"
 
 var (
 	file_api_proto_llm_v1_llm_proto_rawDescOnce sync.Once
@@ -471,26 +2004,61 @@ func file_api_proto_llm_v1_llm_proto_rawDescGZIP() []byte {
 	return file_api_proto_llm_v1_llm_proto_rawDescData
 }
 
-var file_api_proto_llm_v1_llm_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_api_proto_llm_v1_llm_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_api_proto_llm_v1_llm_proto_goTypes = []any{
-	(*PromptRequest)(nil),       // 0: llm.v1.PromptRequest
-	(*PromptResponse)(nil),      // 1: llm.v1.PromptResponse
-	(*TokenResponse)(nil),       // 2: llm.v1.TokenResponse
-	(*HealthCheckRequest)(nil),  // 3: llm.v1.HealthCheckRequest
-	(*HealthCheckResponse)(nil), // 4: llm.v1.HealthCheckResponse
+	(*PromptRequest)(nil),        // 0: llm.v1.PromptRequest
+	(*ChatMessage)(nil),          // 1: llm.v1.ChatMessage
+	(*PromptResponse)(nil),       // 2: llm.v1.PromptResponse
+	(*GenerationParameters)(nil), // 3: llm.v1.GenerationParameters
+	(*Usage)(nil),                // 4: llm.v1.Usage
+	(*TokenResponse)(nil),        // 5: llm.v1.TokenResponse
+	(*HealthCheckRequest)(nil),   // 6: llm.v1.HealthCheckRequest
+	(*HealthCheckResponse)(nil),  // 7: llm.v1.HealthCheckResponse
+	(*LoadedModel)(nil),          // 8: llm.v1.LoadedModel
+	(*EmbedRequest)(nil),         // 9: llm.v1.EmbedRequest
+	(*Embedding)(nil),            // 10: llm.v1.Embedding
+	(*EmbedResponse)(nil),        // 11: llm.v1.EmbedResponse
+	(*CountTokensRequest)(nil),   // 12: llm.v1.CountTokensRequest
+	(*CountTokensResponse)(nil),  // 13: llm.v1.CountTokensResponse
+	(*PullModelRequest)(nil),     // 14: llm.v1.PullModelRequest
+	(*PullModelProgress)(nil),    // 15: llm.v1.PullModelProgress
+	(*DeleteModelRequest)(nil),   // 16: llm.v1.DeleteModelRequest
+	(*DeleteModelResponse)(nil),  // 17: llm.v1.DeleteModelResponse
+	(*CopyModelRequest)(nil),     // 18: llm.v1.CopyModelRequest
+	(*CopyModelResponse)(nil),    // 19: llm.v1.CopyModelResponse
+	(*ShowModelRequest)(nil),     // 20: llm.v1.ShowModelRequest
+	(*ShowModelResponse)(nil),    // 21: llm.v1.ShowModelResponse
 }
 var file_api_proto_llm_v1_llm_proto_depIdxs = []int32{
-	0, // 0: llm.v1.LLMService.GenerateText:input_type -> llm.v1.PromptRequest
-	0, // 1: llm.v1.LLMService.StreamGenerateText:input_type -> llm.v1.PromptRequest
-	3, // 2: llm.v1.LLMService.HealthCheck:input_type -> llm.v1.HealthCheckRequest
-	1, // 3: llm.v1.LLMService.GenerateText:output_type -> llm.v1.PromptResponse
-	2, // 4: llm.v1.LLMService.StreamGenerateText:output_type -> llm.v1.TokenResponse
-	4, // 5: llm.v1.LLMService.HealthCheck:output_type -> llm.v1.HealthCheckResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1,  // 0: llm.v1.PromptRequest.history:type_name -> llm.v1.ChatMessage
+	4,  // 1: llm.v1.PromptResponse.usage:type_name -> llm.v1.Usage
+	3,  // 2: llm.v1.PromptResponse.parameters:type_name -> llm.v1.GenerationParameters
+	3,  // 3: llm.v1.TokenResponse.parameters:type_name -> llm.v1.GenerationParameters
+	8,  // 4: llm.v1.HealthCheckResponse.loaded_models:type_name -> llm.v1.LoadedModel
+	10, // 5: llm.v1.EmbedResponse.embeddings:type_name -> llm.v1.Embedding
+	0,  // 6: llm.v1.LLMService.GenerateText:input_type -> llm.v1.PromptRequest
+	0,  // 7: llm.v1.LLMService.StreamGenerateText:input_type -> llm.v1.PromptRequest
+	6,  // 8: llm.v1.LLMService.HealthCheck:input_type -> llm.v1.HealthCheckRequest
+	9,  // 9: llm.v1.LLMService.Embed:input_type -> llm.v1.EmbedRequest
+	12, // 10: llm.v1.LLMService.CountTokens:input_type -> llm.v1.CountTokensRequest
+	14, // 11: llm.v1.ModelAdminService.PullModel:input_type -> llm.v1.PullModelRequest
+	16, // 12: llm.v1.ModelAdminService.DeleteModel:input_type -> llm.v1.DeleteModelRequest
+	18, // 13: llm.v1.ModelAdminService.CopyModel:input_type -> llm.v1.CopyModelRequest
+	20, // 14: llm.v1.ModelAdminService.ShowModel:input_type -> llm.v1.ShowModelRequest
+	2,  // 15: llm.v1.LLMService.GenerateText:output_type -> llm.v1.PromptResponse
+	5,  // 16: llm.v1.LLMService.StreamGenerateText:output_type -> llm.v1.TokenResponse
+	7,  // 17: llm.v1.LLMService.HealthCheck:output_type -> llm.v1.HealthCheckResponse
+	11, // 18: llm.v1.LLMService.Embed:output_type -> llm.v1.EmbedResponse
+	13, // 19: llm.v1.LLMService.CountTokens:output_type -> llm.v1.CountTokensResponse
+	15, // 20: llm.v1.ModelAdminService.PullModel:output_type -> llm.v1.PullModelProgress
+	17, // 21: llm.v1.ModelAdminService.DeleteModel:output_type -> llm.v1.DeleteModelResponse
+	19, // 22: llm.v1.ModelAdminService.CopyModel:output_type -> llm.v1.CopyModelResponse
+	21, // 23: llm.v1.ModelAdminService.ShowModel:output_type -> llm.v1.ShowModelResponse
+	15, // [15:24] is the sub-list for method output_type
+	6,  // [6:15] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_llm_v1_llm_proto_init() }
@@ -498,15 +2066,16 @@ func file_api_proto_llm_v1_llm_proto_init() {
 	if File_api_proto_llm_v1_llm_proto != nil {
 		return
 	}
+	file_api_proto_llm_v1_llm_proto_msgTypes[0].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_llm_v1_llm_proto_rawDesc), len(file_api_proto_llm_v1_llm_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   22,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_api_proto_llm_v1_llm_proto_goTypes,
 		DependencyIndexes: file_api_proto_llm_v1_llm_proto_depIdxs,
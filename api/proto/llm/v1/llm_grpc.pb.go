@@ -25,6 +25,8 @@ const (
 	LLMService_GenerateText_FullMethodName       = "/llm.v1.LLMService/GenerateText"
 	LLMService_StreamGenerateText_FullMethodName = "/llm.v1.LLMService/StreamGenerateText"
 	LLMService_HealthCheck_FullMethodName        = "/llm.v1.LLMService/HealthCheck"
+	LLMService_Embed_FullMethodName              = "/llm.v1.LLMService/Embed"
+	LLMService_CountTokens_FullMethodName        = "/llm.v1.LLMService/CountTokens"
 )
 
 // LLMServiceClient is the client API for LLMService service.
@@ -40,6 +42,11 @@ type LLMServiceClient interface {
 	StreamGenerateText(ctx context.Context, in *PromptRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TokenResponse], error)
 	// HealthCheck allows the gateway to verify worker availability
 	HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error)
+	// Embed computes embedding vectors for the given inputs
+	Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
+	// CountTokens counts the tokens of a prompt as the model would see them,
+	// so clients can check it against the context window before generating
+	CountTokens(ctx context.Context, in *CountTokensRequest, opts ...grpc.CallOption) (*CountTokensResponse, error)
 }
 
 type lLMServiceClient struct {
@@ -89,6 +96,26 @@ func (c *lLMServiceClient) HealthCheck(ctx context.Context, in *HealthCheckReque
 	return out, nil
 }
 
+func (c *lLMServiceClient) Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmbedResponse)
+	err := c.cc.Invoke(ctx, LLMService_Embed_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lLMServiceClient) CountTokens(ctx context.Context, in *CountTokensRequest, opts ...grpc.CallOption) (*CountTokensResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountTokensResponse)
+	err := c.cc.Invoke(ctx, LLMService_CountTokens_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LLMServiceServer is the server API for LLMService service.
 // All implementations must embed UnimplementedLLMServiceServer
 // for forward compatibility.
@@ -102,6 +129,11 @@ type LLMServiceServer interface {
 	StreamGenerateText(*PromptRequest, grpc.ServerStreamingServer[TokenResponse]) error
 	// HealthCheck allows the gateway to verify worker availability
 	HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error)
+	// Embed computes embedding vectors for the given inputs
+	Embed(context.Context, *EmbedRequest) (*EmbedResponse, error)
+	// CountTokens counts the tokens of a prompt as the model would see them,
+	// so clients can check it against the context window before generating
+	CountTokens(context.Context, *CountTokensRequest) (*CountTokensResponse, error)
 	mustEmbedUnimplementedLLMServiceServer()
 }
 
@@ -121,6 +153,12 @@ func (UnimplementedLLMServiceServer) StreamGenerateText(*PromptRequest, grpc.Ser
 func (UnimplementedLLMServiceServer) HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method HealthCheck not implemented")
 }
+func (UnimplementedLLMServiceServer) Embed(context.Context, *EmbedRequest) (*EmbedResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Embed not implemented")
+}
+func (UnimplementedLLMServiceServer) CountTokens(context.Context, *CountTokensRequest) (*CountTokensResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CountTokens not implemented")
+}
 func (UnimplementedLLMServiceServer) mustEmbedUnimplementedLLMServiceServer() {}
 func (UnimplementedLLMServiceServer) testEmbeddedByValue()                    {}
 
@@ -189,6 +227,42 @@ func _LLMService_HealthCheck_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LLMService_Embed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmbedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LLMServiceServer).Embed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LLMService_Embed_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LLMServiceServer).Embed(ctx, req.(*EmbedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LLMService_CountTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountTokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LLMServiceServer).CountTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LLMService_CountTokens_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LLMServiceServer).CountTokens(ctx, req.(*CountTokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LLMService_ServiceDesc is the grpc.ServiceDesc for LLMService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -204,6 +278,14 @@ var LLMService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "HealthCheck",
 			Handler:    _LLMService_HealthCheck_Handler,
 		},
+		{
+			MethodName: "Embed",
+			Handler:    _LLMService_Embed_Handler,
+		},
+		{
+			MethodName: "CountTokens",
+			Handler:    _LLMService_CountTokens_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -214,3 +296,237 @@ var LLMService_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "api/proto/llm/v1/llm.proto",
 }
+
+const (
+	ModelAdminService_PullModel_FullMethodName   = "/llm.v1.ModelAdminService/PullModel"
+	ModelAdminService_DeleteModel_FullMethodName = "/llm.v1.ModelAdminService/DeleteModel"
+	ModelAdminService_CopyModel_FullMethodName   = "/llm.v1.ModelAdminService/CopyModel"
+	ModelAdminService_ShowModel_FullMethodName   = "/llm.v1.ModelAdminService/ShowModel"
+)
+
+// ModelAdminServiceClient is the client API for ModelAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ModelAdminService manages the models on a worker's Ollama instance, so
+// operators can manage models through the gateway's admin API
+type ModelAdminServiceClient interface {
+	// PullModel downloads a model and streams progress until it completes
+	PullModel(ctx context.Context, in *PullModelRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PullModelProgress], error)
+	// DeleteModel removes a model
+	DeleteModel(ctx context.Context, in *DeleteModelRequest, opts ...grpc.CallOption) (*DeleteModelResponse, error)
+	// CopyModel copies a model under a new name
+	CopyModel(ctx context.Context, in *CopyModelRequest, opts ...grpc.CallOption) (*CopyModelResponse, error)
+	// ShowModel returns a model's details
+	ShowModel(ctx context.Context, in *ShowModelRequest, opts ...grpc.CallOption) (*ShowModelResponse, error)
+}
+
+type modelAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewModelAdminServiceClient(cc grpc.ClientConnInterface) ModelAdminServiceClient {
+	return &modelAdminServiceClient{cc}
+}
+
+func (c *modelAdminServiceClient) PullModel(ctx context.Context, in *PullModelRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PullModelProgress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ModelAdminService_ServiceDesc.Streams[0], ModelAdminService_PullModel_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PullModelRequest, PullModelProgress]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ModelAdminService_PullModelClient = grpc.ServerStreamingClient[PullModelProgress]
+
+func (c *modelAdminServiceClient) DeleteModel(ctx context.Context, in *DeleteModelRequest, opts ...grpc.CallOption) (*DeleteModelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteModelResponse)
+	err := c.cc.Invoke(ctx, ModelAdminService_DeleteModel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *modelAdminServiceClient) CopyModel(ctx context.Context, in *CopyModelRequest, opts ...grpc.CallOption) (*CopyModelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CopyModelResponse)
+	err := c.cc.Invoke(ctx, ModelAdminService_CopyModel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *modelAdminServiceClient) ShowModel(ctx context.Context, in *ShowModelRequest, opts ...grpc.CallOption) (*ShowModelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShowModelResponse)
+	err := c.cc.Invoke(ctx, ModelAdminService_ShowModel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ModelAdminServiceServer is the server API for ModelAdminService service.
+// All implementations must embed UnimplementedModelAdminServiceServer
+// for forward compatibility.
+//
+// ModelAdminService manages the models on a worker's Ollama instance, so
+// operators can manage models through the gateway's admin API
+type ModelAdminServiceServer interface {
+	// PullModel downloads a model and streams progress until it completes
+	PullModel(*PullModelRequest, grpc.ServerStreamingServer[PullModelProgress]) error
+	// DeleteModel removes a model
+	DeleteModel(context.Context, *DeleteModelRequest) (*DeleteModelResponse, error)
+	// CopyModel copies a model under a new name
+	CopyModel(context.Context, *CopyModelRequest) (*CopyModelResponse, error)
+	// ShowModel returns a model's details
+	ShowModel(context.Context, *ShowModelRequest) (*ShowModelResponse, error)
+	mustEmbedUnimplementedModelAdminServiceServer()
+}
+
+// UnimplementedModelAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedModelAdminServiceServer struct{}
+
+func (UnimplementedModelAdminServiceServer) PullModel(*PullModelRequest, grpc.ServerStreamingServer[PullModelProgress]) error {
+	return status.Error(codes.Unimplemented, "method PullModel not implemented")
+}
+func (UnimplementedModelAdminServiceServer) DeleteModel(context.Context, *DeleteModelRequest) (*DeleteModelResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteModel not implemented")
+}
+func (UnimplementedModelAdminServiceServer) CopyModel(context.Context, *CopyModelRequest) (*CopyModelResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CopyModel not implemented")
+}
+func (UnimplementedModelAdminServiceServer) ShowModel(context.Context, *ShowModelRequest) (*ShowModelResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ShowModel not implemented")
+}
+func (UnimplementedModelAdminServiceServer) mustEmbedUnimplementedModelAdminServiceServer() {}
+func (UnimplementedModelAdminServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeModelAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ModelAdminServiceServer will
+// result in compilation errors.
+type UnsafeModelAdminServiceServer interface {
+	mustEmbedUnimplementedModelAdminServiceServer()
+}
+
+func RegisterModelAdminServiceServer(s grpc.ServiceRegistrar, srv ModelAdminServiceServer) {
+	// If the following call panics, it indicates UnimplementedModelAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ModelAdminService_ServiceDesc, srv)
+}
+
+func _ModelAdminService_PullModel_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PullModelRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ModelAdminServiceServer).PullModel(m, &grpc.GenericServerStream[PullModelRequest, PullModelProgress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ModelAdminService_PullModelServer = grpc.ServerStreamingServer[PullModelProgress]
+
+func _ModelAdminService_DeleteModel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteModelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelAdminServiceServer).DeleteModel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ModelAdminService_DeleteModel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelAdminServiceServer).DeleteModel(ctx, req.(*DeleteModelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ModelAdminService_CopyModel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CopyModelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelAdminServiceServer).CopyModel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ModelAdminService_CopyModel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelAdminServiceServer).CopyModel(ctx, req.(*CopyModelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ModelAdminService_ShowModel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShowModelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelAdminServiceServer).ShowModel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ModelAdminService_ShowModel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelAdminServiceServer).ShowModel(ctx, req.(*ShowModelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ModelAdminService_ServiceDesc is the grpc.ServiceDesc for ModelAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ModelAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "llm.v1.ModelAdminService",
+	HandlerType: (*ModelAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DeleteModel",
+			Handler:    _ModelAdminService_DeleteModel_Handler,
+		},
+		{
+			MethodName: "CopyModel",
+			Handler:    _ModelAdminService_CopyModel_Handler,
+		},
+		{
+			MethodName: "ShowModel",
+			Handler:    _ModelAdminService_ShowModel_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "PullModel",
+			Handler:       _ModelAdminService_PullModel_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/proto/llm/v1/llm.proto",
+}
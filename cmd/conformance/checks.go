@@ -0,0 +1,465 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// invalidKey is sent by the auth checks; no deployment should accept it
+const invalidKey = "conformance-invalid-key"
+
+// checks run in order; later checks may rely on the gateway answering
+// prompts, which "prompt/basic" verifies first
+var checks = []check{
+	{"health/live", checkLive},
+	{"health/overall", checkHealth},
+	{"health/ready", checkReady},
+	{"status/public", checkStatus},
+	{"auth/missing-key", checkMissingKey},
+	{"auth/invalid-key", checkInvalidKey},
+	{"errors/not-found", checkNotFound},
+	{"errors/invalid-body", checkInvalidBody},
+	{"errors/missing-query", checkMissingQuery},
+	{"errors/unknown-job", checkUnknownJob},
+	{"prompt/basic", checkPrompt},
+	{"quota/headers", checkQuotaHeaders},
+	{"jobs/lifecycle", checkJob},
+	{"streaming/admin-attach", checkStreaming},
+	{"cancellation/client-disconnect", checkCancellation},
+}
+
+func checkLive(ctx context.Context, r *runner) (Outcome, string) {
+	res, err := r.do(ctx, "GET", "/health/live", "", nil)
+	if err != nil {
+		return Fail, err.Error()
+	}
+	var body struct {
+		Status string `json:"status"`
+	}
+	if res.status != http.StatusOK || json.Unmarshal(res.body, &body) != nil || body.Status != "alive" {
+		return Fail, fmt.Sprintf("expected 200 with status alive, got %d %s", res.status, snippet(res.body))
+	}
+	return Pass, ""
+}
+
+func checkHealth(ctx context.Context, r *runner) (Outcome, string) {
+	res, err := r.do(ctx, "GET", "/health", "", nil)
+	if err != nil {
+		return Fail, err.Error()
+	}
+	var body struct {
+		Status string                     `json:"status"`
+		Checks map[string]json.RawMessage `json:"checks"`
+	}
+	if err := json.Unmarshal(res.body, &body); err != nil {
+		return Fail, "invalid JSON body: " + err.Error()
+	}
+
+	switch {
+	case !slices.Contains([]string{"healthy", "degraded", "unhealthy"}, body.Status):
+		return Fail, fmt.Sprintf("unknown status %q", body.Status)
+	case (res.status == http.StatusServiceUnavailable) != (body.Status == "unhealthy"):
+		return Fail, fmt.Sprintf("status %s returned HTTP %d; only unhealthy should return 503", body.Status, res.status)
+	case res.status != http.StatusOK && res.status != http.StatusServiceUnavailable:
+		return Fail, fmt.Sprintf("unexpected HTTP %d", res.status)
+	case len(body.Checks) == 0:
+		return Fail, "no checks reported"
+	}
+	return Pass, fmt.Sprintf("%s, %d checks", body.Status, len(body.Checks))
+}
+
+func checkReady(ctx context.Context, r *runner) (Outcome, string) {
+	res, err := r.do(ctx, "GET", "/health/ready", "", nil)
+	if err != nil {
+		return Fail, err.Error()
+	}
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(res.body, &body); err != nil {
+		return Fail, "invalid JSON body: " + err.Error()
+	}
+
+	ready := body.Status == "healthy" || body.Status == "degraded"
+	notReady := body.Status == "unhealthy" || body.Status == "draining"
+	switch {
+	case res.status == http.StatusOK && ready, res.status == http.StatusServiceUnavailable && notReady:
+		return Pass, body.Status
+	default:
+		return Fail, fmt.Sprintf("HTTP %d with status %q; degraded must stay ready, unhealthy and draining must not", res.status, body.Status)
+	}
+}
+
+func checkStatus(ctx context.Context, r *runner) (Outcome, string) {
+	res, err := r.do(ctx, "GET", "/status", "", nil)
+	if err != nil {
+		return Fail, err.Error()
+	}
+	var body struct {
+		Status string `json:"status"`
+	}
+	if res.status != http.StatusOK || json.Unmarshal(res.body, &body) != nil {
+		return Fail, fmt.Sprintf("expected 200 with a JSON body, got %d %s", res.status, snippet(res.body))
+	}
+	if !slices.Contains([]string{"operational", "degraded", "outage"}, body.Status) {
+		return Fail, fmt.Sprintf("unknown status %q", body.Status)
+	}
+	if !strings.Contains(res.header.Get("Cache-Control"), "max-age") {
+		return Fail, "missing Cache-Control max-age"
+	}
+	return Pass, body.Status
+}
+
+func checkMissingKey(ctx context.Context, r *runner) (Outcome, string) {
+	if r.apiKey == "" {
+		return Skip, "no -api-key given; assuming authentication is disabled"
+	}
+	res, err := r.do(ctx, "POST", "/prompt", "", r.promptBody("conformance"))
+	if err != nil {
+		return Fail, err.Error()
+	}
+	return expectError(res, http.StatusUnauthorized)
+}
+
+func checkInvalidKey(ctx context.Context, r *runner) (Outcome, string) {
+	if r.apiKey == "" {
+		return Skip, "no -api-key given; assuming authentication is disabled"
+	}
+	res, err := r.do(ctx, "POST", "/prompt", invalidKey, r.promptBody("conformance"))
+	if err != nil {
+		return Fail, err.Error()
+	}
+	return expectError(res, http.StatusUnauthorized)
+}
+
+func checkNotFound(ctx context.Context, r *runner) (Outcome, string) {
+	res, err := r.do(ctx, "GET", "/conformance-does-not-exist", r.apiKey, nil)
+	if err != nil {
+		return Fail, err.Error()
+	}
+	return expectError(res, http.StatusNotFound)
+}
+
+func checkInvalidBody(ctx context.Context, r *runner) (Outcome, string) {
+	res, err := r.do(ctx, "POST", "/prompt", r.apiKey, []byte("{"))
+	if err != nil {
+		return Fail, err.Error()
+	}
+	return expectError(res, http.StatusBadRequest)
+}
+
+func checkMissingQuery(ctx context.Context, r *runner) (Outcome, string) {
+	res, err := r.do(ctx, "POST", "/prompt", r.apiKey, []byte(`{"query": ""}`))
+	if err != nil {
+		return Fail, err.Error()
+	}
+	return expectError(res, http.StatusBadRequest)
+}
+
+func checkUnknownJob(ctx context.Context, r *runner) (Outcome, string) {
+	res, err := r.do(ctx, "GET", "/jobs/job-conformance-missing", r.apiKey, nil)
+	if err != nil {
+		return Fail, err.Error()
+	}
+	return expectError(res, http.StatusNotFound)
+}
+
+func checkPrompt(ctx context.Context, r *runner) (Outcome, string) {
+	res, err := r.do(ctx, "POST", "/prompt", r.apiKey, r.promptBody("Reply with one word."))
+	if err != nil {
+		return Fail, err.Error()
+	}
+	if res.status != http.StatusOK {
+		return Fail, fmt.Sprintf("expected 200, got %d %s", res.status, snippet(res.body))
+	}
+
+	var body struct {
+		RequestID string `json:"request_id"`
+		Response  string `json:"response"`
+		Model     string `json:"model"`
+		Usage     struct {
+			PromptTokens     int32 `json:"prompt_tokens"`
+			CompletionTokens int32 `json:"completion_tokens"`
+			TotalTokens      int32 `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(res.body, &body); err != nil {
+		return Fail, "invalid JSON body: " + err.Error()
+	}
+	switch {
+	case body.RequestID == "":
+		return Fail, "missing request_id"
+	case body.Model == "":
+		return Fail, "missing model"
+	case body.Usage.TotalTokens != body.Usage.PromptTokens+body.Usage.CompletionTokens:
+		return Fail, fmt.Sprintf("usage total %d != prompt %d + completion %d",
+			body.Usage.TotalTokens, body.Usage.PromptTokens, body.Usage.CompletionTokens)
+	}
+	return Pass, fmt.Sprintf("%s, %d tokens", body.Model, body.Usage.TotalTokens)
+}
+
+func checkQuotaHeaders(ctx context.Context, r *runner) (Outcome, string) {
+	res, err := r.do(ctx, "POST", "/prompt", r.apiKey, r.promptBody("Reply with one word."))
+	if err != nil {
+		return Fail, err.Error()
+	}
+	if res.status == http.StatusTooManyRequests {
+		if res.header.Get("Retry-After") == "" {
+			return Fail, "429 without Retry-After"
+		}
+		return Pass, "quota exhausted, Retry-After set"
+	}
+
+	checked := 0
+	for _, resource := range []string{"Requests", "Tokens"} {
+		limitHeader := res.header.Get("X-Quota-Limit-" + resource)
+		if limitHeader == "" {
+			continue
+		}
+		limit, err1 := strconv.ParseInt(limitHeader, 10, 64)
+		remaining, err2 := strconv.ParseInt(res.header.Get("X-Quota-Remaining-"+resource), 10, 64)
+		if err1 != nil || err2 != nil || remaining < 0 || remaining > limit {
+			return Fail, fmt.Sprintf("invalid %s quota headers: limit %q, remaining %q",
+				strings.ToLower(resource), limitHeader, res.header.Get("X-Quota-Remaining-"+resource))
+		}
+		checked++
+	}
+	if checked == 0 {
+		return Skip, "no quota headers; quotas are not configured for this key"
+	}
+
+	reset, err := strconv.ParseInt(res.header.Get("X-Quota-Reset"), 10, 64)
+	if err != nil || time.Unix(reset, 0).Before(time.Now().Add(-time.Minute)) {
+		return Fail, fmt.Sprintf("X-Quota-Reset %q is not a future unix time", res.header.Get("X-Quota-Reset"))
+	}
+	return Pass, ""
+}
+
+func checkJob(ctx context.Context, r *runner) (Outcome, string) {
+	res, err := r.do(ctx, "POST", "/jobs", r.apiKey, r.promptBody("Reply with one word."))
+	if err != nil {
+		return Fail, err.Error()
+	}
+	var job struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+		Error  *struct {
+			Error string `json:"error"`
+		} `json:"error"`
+	}
+	if res.status != http.StatusAccepted || json.Unmarshal(res.body, &job) != nil || job.ID == "" {
+		return Fail, fmt.Sprintf("expected 202 with a job, got %d %s", res.status, snippet(res.body))
+	}
+	location := res.header.Get("Location")
+	if location != "/jobs/"+job.ID {
+		return Fail, fmt.Sprintf("expected Location /jobs/%s, got %q", job.ID, location)
+	}
+
+	for job.Status == "queued" || job.Status == "running" {
+		select {
+		case <-ctx.Done():
+			return Fail, "job did not finish: " + job.Status
+		case <-time.After(200 * time.Millisecond):
+		}
+		res, err := r.do(ctx, "GET", location, r.apiKey, nil)
+		if err != nil {
+			return Fail, err.Error()
+		}
+		if res.status != http.StatusOK || json.Unmarshal(res.body, &job) != nil {
+			return Fail, fmt.Sprintf("polling job: got %d %s", res.status, snippet(res.body))
+		}
+	}
+
+	if job.Status != "completed" {
+		detail := job.Status
+		if job.Error != nil {
+			detail += ": " + job.Error.Error
+		}
+		return Fail, "job " + detail
+	}
+
+	// Jobs are private to the key that created them
+	if r.apiKey != "" {
+		res, err := r.do(ctx, "GET", location, invalidKey, nil)
+		if err != nil {
+			return Fail, err.Error()
+		}
+		if res.status != http.StatusUnauthorized && res.status != http.StatusNotFound {
+			return Fail, fmt.Sprintf("job visible to another key: got %d", res.status)
+		}
+	}
+	return Pass, job.ID
+}
+
+func checkStreaming(ctx context.Context, r *runner) (Outcome, string) {
+	if r.adminKey == "" {
+		return Skip, "no -admin-key given"
+	}
+
+	id, done, cancel, err := r.startObservedPrompt(ctx)
+	if err != nil {
+		return Fail, err.Error()
+	}
+	defer cancel()
+	if id == "" {
+		return Skip, "prompt finished before it was observed in flight; try a slower -model"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+"/admin/requests/"+id+"/stream", nil)
+	if err != nil {
+		return Fail, err.Error()
+	}
+	req.Header.Set("Authorization", "Bearer "+r.adminKey)
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return Fail, err.Error()
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Skip, "prompt finished before the stream attached"
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return Fail, fmt.Sprintf("expected 200 text/event-stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	tokens := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		switch scanner.Text() {
+		case "event: token":
+			tokens++
+		case "event: done":
+			<-done
+			return Pass, fmt.Sprintf("%d token events", tokens)
+		}
+	}
+	return Fail, fmt.Sprintf("stream ended without a done event after %d tokens", tokens)
+}
+
+func checkCancellation(ctx context.Context, r *runner) (Outcome, string) {
+	if r.adminKey == "" {
+		return Skip, "no -admin-key given"
+	}
+
+	id, done, cancel, err := r.startObservedPrompt(ctx)
+	if err != nil {
+		cancel()
+		return Fail, err.Error()
+	}
+	if id == "" {
+		cancel()
+		return Skip, "prompt finished before it was observed in flight; try a slower -model"
+	}
+
+	// Disconnect and expect the gateway to abandon the request promptly
+	cancel()
+	<-done
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		ids, err := r.inflightIDs(ctx)
+		if err != nil {
+			return Fail, err.Error()
+		}
+		if !ids[id] {
+			return Pass, ""
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return Fail, fmt.Sprintf("request %s still in flight 10s after the client disconnected", id)
+}
+
+// startObservedPrompt sends a prompt in the background and waits for it to
+// appear in the admin in-flight list. It returns the request ID (empty if the
+// prompt finished first), a channel closed when the prompt returns, and a
+// function that cancels the prompt.
+func (r *runner) startObservedPrompt(ctx context.Context) (string, <-chan struct{}, context.CancelFunc, error) {
+	before, err := r.inflightIDs(ctx)
+	if err != nil {
+		return "", nil, func() {}, err
+	}
+
+	promptCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.do(promptCtx, "POST", "/prompt", r.apiKey, r.promptBody("Count slowly from one to fifty, one number per line."))
+	}()
+
+	for {
+		select {
+		case <-done:
+			return "", done, cancel, nil
+		case <-ctx.Done():
+			return "", done, cancel, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		ids, err := r.inflightIDs(ctx)
+		if err != nil {
+			return "", done, cancel, err
+		}
+		for id := range ids {
+			if !before[id] {
+				return id, done, cancel, nil
+			}
+		}
+	}
+}
+
+// inflightIDs lists the IDs of requests the gateway is generating
+func (r *runner) inflightIDs(ctx context.Context) (map[string]bool, error) {
+	res, err := r.do(ctx, "GET", "/admin/requests", r.adminKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.status != http.StatusOK {
+		return nil, fmt.Errorf("listing in-flight requests: got %d %s", res.status, snippet(res.body))
+	}
+
+	var body struct {
+		Requests []struct {
+			ID string `json:"id"`
+		} `json:"requests"`
+	}
+	if err := json.Unmarshal(res.body, &body); err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(body.Requests))
+	for _, req := range body.Requests {
+		ids[req.ID] = true
+	}
+	return ids, nil
+}
+
+// expectError checks for the given status and a JSON error body whose code
+// matches it
+func expectError(res *response, status int) (Outcome, string) {
+	var body struct {
+		Error string `json:"error"`
+		Code  int    `json:"code"`
+	}
+	if res.status != status {
+		return Fail, fmt.Sprintf("expected %d, got %d %s", status, res.status, snippet(res.body))
+	}
+	if err := json.Unmarshal(res.body, &body); err != nil || body.Error == "" || body.Code != status {
+		return Fail, fmt.Sprintf("expected a JSON error body with code %d, got %s", status, snippet(res.body))
+	}
+	return Pass, body.Error
+}
+
+// snippet shortens a response body for reports
+func snippet(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) > 120 {
+		s = s[:120] + "..."
+	}
+	return s
+}
@@ -0,0 +1,194 @@
+// conformance - Black-box checks against a running NeuroGate gateway
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `Usage: conformance [flags]
+
+Runs black-box checks (auth, error codes, quota headers, jobs, streaming,
+cancellation, health semantics) against a running gateway and prints a
+report. Exits with status 1 if any check fails.
+
+Checks that need something the deployment doesn't offer, such as an admin
+key or configured quotas, are skipped rather than failed.
+
+Flags:
+`
+
+// Outcome is the result of a single check
+type Outcome string
+
+const (
+	Pass Outcome = "PASS"
+	Fail Outcome = "FAIL"
+	Skip Outcome = "SKIP"
+)
+
+// Result is a check's outcome as reported
+type Result struct {
+	Name     string  `json:"name"`
+	Outcome  Outcome `json:"outcome"`
+	Detail   string  `json:"detail,omitempty"`
+	Duration float64 `json:"duration_ms"`
+}
+
+// runner holds the target gateway and credentials shared by all checks
+type runner struct {
+	baseURL  string
+	apiKey   string
+	adminKey string
+	model    string
+	timeout  time.Duration
+	http     *http.Client
+}
+
+// check is a named conformance check. It returns Pass, Fail or Skip with a
+// short explanation.
+type check struct {
+	name string
+	run  func(ctx context.Context, r *runner) (Outcome, string)
+}
+
+func main() {
+	fs := flag.NewFlagSet("conformance", flag.ExitOnError)
+	addr := fs.String("addr", getEnv("NEUROGATE_URL", "http://localhost:8080"), "Gateway base URL (NEUROGATE_URL)")
+	apiKey := fs.String("api-key", os.Getenv("NEUROGATE_API_KEY"), "API key; empty assumes authentication is disabled (NEUROGATE_API_KEY)")
+	adminKey := fs.String("admin-key", os.Getenv("NEUROGATE_ADMIN_KEY"), "Admin API key, enables streaming and cancellation checks (NEUROGATE_ADMIN_KEY)")
+	model := fs.String("model", "", "Model for prompt requests; empty uses the gateway default")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Timeout for each check")
+	run := fs.String("run", "", "Only run checks whose name contains this string")
+	jsonOut := fs.Bool("json", false, "Print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+
+	r := &runner{
+		baseURL:  strings.TrimSuffix(*addr, "/"),
+		apiKey:   *apiKey,
+		adminKey: *adminKey,
+		model:    *model,
+		timeout:  *timeout,
+		http:     &http.Client{},
+	}
+
+	var results []Result
+	for _, c := range checks {
+		if *run != "" && !strings.Contains(c.name, *run) {
+			continue
+		}
+		results = append(results, r.runCheck(c))
+	}
+
+	failed := report(os.Stdout, results, *jsonOut)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// runCheck runs a check with its own deadline
+func (r *runner) runCheck(c check) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	outcome, detail := c.run(ctx, r)
+	return Result{
+		Name:     c.name,
+		Outcome:  outcome,
+		Detail:   detail,
+		Duration: float64(time.Since(start).Microseconds()) / 1000,
+	}
+}
+
+// report prints the results and returns the number of failed checks
+func report(w io.Writer, results []Result, asJSON bool) int {
+	counts := make(map[Outcome]int)
+	for _, res := range results {
+		counts[res.Outcome]++
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(map[string]interface{}{
+			"results": results,
+			"passed":  counts[Pass],
+			"failed":  counts[Fail],
+			"skipped": counts[Skip],
+		})
+		return counts[Fail]
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, res := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.0fms\n", res.Outcome, res.Name, res.Detail, res.Duration)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d passed, %d failed, %d skipped\n", counts[Pass], counts[Fail], counts[Skip])
+	return counts[Fail]
+}
+
+// response is a fully read HTTP response
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// do sends a request with the given Authorization token (none if empty) and
+// reads the whole response
+func (r *runner) do(ctx context.Context, method, path, token string, body []byte) (*response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &response{status: resp.StatusCode, header: resp.Header, body: respBody}, nil
+}
+
+// promptBody returns a small /prompt request body
+func (r *runner) promptBody(query string) []byte {
+	body, _ := json.Marshal(map[string]interface{}{
+		"query":      query,
+		"model":      r.model,
+		"max_tokens": 16,
+	})
+	return body
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
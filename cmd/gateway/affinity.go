@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hugovillarreal/neurogate/pkg/rendezvous"
+)
+
+// affinityKey returns the key that pins a request to a worker, empty for
+// requests routed round robin. Requests of one conversation share a key so
+// they reach the worker whose Ollama prompt cache already holds its history.
+func (g *Gateway) affinityKey(req *PromptRequest) string {
+	if !g.conversationAffinity {
+		return ""
+	}
+	return req.ConversationID
+}
+
+// affinityWorker picks the available worker ranked highest for key by
+// rendezvous hashing, skipping exclude. Adding or removing a worker only
+// moves the conversations it owned, and conversations return to their
+// preferred worker once it recovers. A busy worker is passed over for the
+// next ranked one with a free inference slot, as in nextWorker. The result
+// reports whether the preferred worker was chosen.
+func (g *Gateway) affinityWorker(key string, exclude *Worker) (*Worker, bool, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if len(g.workers) == 0 {
+		return nil, false, fmt.Errorf("no workers available")
+	}
+
+	ids := make([]string, len(g.workers))
+	for i, worker := range g.workers {
+		ids[i] = worker.ID
+	}
+	ranked := rendezvous.Rank(key, ids)
+	var picker capacityPicker
+	for _, i := range ranked {
+		worker := g.workers[i]
+		if worker != exclude && worker.Healthy.Load() && worker.CB.Available() && picker.offer(worker) {
+			return worker, i == ranked[0], nil
+		}
+	}
+
+	if worker, err := picker.pick(); worker != nil || err != nil {
+		return worker, worker == g.workers[ranked[0]], err
+	}
+	return nil, false, fmt.Errorf("all workers are unavailable")
+}
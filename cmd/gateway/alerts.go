@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/anomaly"
+	"github.com/hugovillarreal/neurogate/pkg/quota"
+)
+
+// alertQuotaExhaustion is the alert kind sent to a key's webhook when it
+// crosses a soft quota threshold
+const alertQuotaExhaustion = "quota_near_exhaustion"
+
+// UsageAlert is the webhook payload sent to a key's alert webhook when its
+// usage looks unusual
+type UsageAlert struct {
+	Event     string    `json:"event"`
+	Kind      string    `json:"kind"`
+	KeyID     string    `json:"key_id"`
+	Requests  int64     `json:"requests,omitempty"` // In the current detection interval
+	Errors    int64     `json:"errors,omitempty"`
+	Baseline  *float64  `json:"baseline,omitempty"` // Typical requests per interval
+	Interval  string    `json:"interval,omitempty"`
+	Quota     *QuotaHit `json:"quota,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// QuotaHit describes the quota threshold behind a quota_near_exhaustion alert
+type QuotaHit struct {
+	Resource  string    `json:"resource"`
+	Threshold float64   `json:"threshold"`
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// recordAlertUsage feeds a finished request into the anomaly detector when
+// the caller's key has an alert webhook. Any 4xx or 5xx counts as an error.
+func (g *Gateway) recordAlertUsage(authHeader string, status int) {
+	hash := g.keyHashOf(authHeader)
+	if _, ok := g.apiKeys.alertWebhookFor(hash); !ok {
+		return
+	}
+	g.anomalies.Record(hash, status >= 400)
+}
+
+// notifyAnomaly delivers a detected anomaly to the key's alert webhook
+func (g *Gateway) notifyAnomaly(e anomaly.Event) {
+	baseline := e.Baseline
+	g.sendUsageAlert(e.Key, UsageAlert{
+		Kind:      string(e.Kind),
+		Requests:  e.Requests,
+		Errors:    e.Errors,
+		Baseline:  &baseline,
+		Interval:  g.anomalies.Interval().String(),
+		Timestamp: e.Timestamp,
+	})
+}
+
+// notifyQuotaAlert delivers a crossed quota threshold to the key's alert
+// webhook, if it has one
+func (g *Gateway) notifyQuotaAlert(e quota.Event) {
+	g.sendUsageAlert(e.Key, UsageAlert{
+		Kind: alertQuotaExhaustion,
+		Quota: &QuotaHit{
+			Resource:  string(e.Warning.Resource),
+			Threshold: e.Warning.Threshold,
+			Used:      e.Warning.Used,
+			Limit:     e.Warning.Limit,
+			ResetAt:   e.ResetAt,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+func (g *Gateway) sendUsageAlert(hash string, alert UsageAlert) {
+	url, ok := g.apiKeys.alertWebhookFor(hash)
+	if !ok {
+		return
+	}
+	alert.Event = "usage.alert"
+	alert.KeyID = hashID(hash)
+
+	log := g.log.With("key_id", alert.KeyID, "kind", alert.Kind)
+	log.Warn("usage alert")
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return
+	}
+	g.postWebhook(url, body, log.With("webhook_url", url))
+}
+
+// handleSetAlertWebhook handles PUT /alerts/webhook, letting a key register
+// its own alert webhook
+func (g *Gateway) handleSetAlertWebhook(w http.ResponseWriter, r *http.Request) {
+	hash, ok := g.alertKey(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		URL string `json:"url"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		g.writeAPIError(w, apiErr)
+		return
+	}
+	req.URL = strings.TrimSpace(req.URL)
+	if !validWebhookURL(req.URL) {
+		g.writeError(w, http.StatusBadRequest, "invalid url", "must be an http(s) URL")
+		return
+	}
+
+	g.apiKeys.setAlertWebhook(hash, req.URL)
+	g.saveKeys()
+	g.log.Info("alert webhook registered", "key_id", hashID(hash))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(req)
+}
+
+// handleDeleteAlertWebhook handles DELETE /alerts/webhook
+func (g *Gateway) handleDeleteAlertWebhook(w http.ResponseWriter, r *http.Request) {
+	hash, ok := g.alertKey(w, r)
+	if !ok {
+		return
+	}
+
+	g.apiKeys.setAlertWebhook(hash, "")
+	g.saveKeys()
+	g.anomalies.Forget(hash)
+	g.log.Info("alert webhook removed", "key_id", hashID(hash))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// alertKey returns the keyHash of the caller's API key. Alert webhooks
+// belong to a key, so they need API key authentication to be enabled.
+func (g *Gateway) alertKey(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if !g.apiKeys.enabled() {
+		g.writeError(w, http.StatusNotFound, "not found", "alert webhooks require API keys")
+		return "", false
+	}
+	authHeader := r.Header.Get("Authorization")
+	if !g.apiKeys.valid(authHeader) {
+		g.auditAuthFailure(r, "api")
+		g.writeError(w, http.StatusUnauthorized, "invalid or missing API key", "")
+		return "", false
+	}
+	return keyHash(bearerToken(authHeader)), true
+}
@@ -0,0 +1,38 @@
+package main
+
+import "fmt"
+
+// defaultModelAlias names the model of requests that don't choose one
+const defaultModelAlias = "default"
+
+// modelAliases maps the logical model names clients use to the models the
+// workers run, so operators can swap models without client changes
+type modelAliases map[string]string
+
+// newModelAliases validates alias entries. Aliases resolve in one step, so
+// an alias can't point at another alias.
+func newModelAliases(entries map[string]string) (modelAliases, error) {
+	aliases := make(modelAliases, len(entries))
+	for alias, model := range entries {
+		if model == "" {
+			return nil, fmt.Errorf("alias %s: model is required", alias)
+		}
+		if _, ok := entries[model]; ok {
+			return nil, fmt.Errorf("alias %s: target %s is itself an alias", alias, model)
+		}
+		aliases[alias] = model
+	}
+	return aliases, nil
+}
+
+// resolve returns the model an alias stands for. Requests without a model
+// get the "default" alias, or "" to leave the choice to the worker.
+func (a modelAliases) resolve(model string) string {
+	if model == "" {
+		return a[defaultModelAlias]
+	}
+	if target, ok := a[model]; ok {
+		return target
+	}
+	return model
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/scheduler"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AutoscalingSignal is the demand on the worker fleet, served by
+// GET /autoscaling for KEDA's metrics-api scaler or an HPA external metric.
+// Only workers in rotation (healthy, with a closed or half-open breaker)
+// count towards slots and demand.
+type AutoscalingSignal struct {
+	Model         string    `json:"model,omitempty"` // Workers serving this model only, if set
+	Workers       int       `json:"workers"`
+	ActiveWorkers int       `json:"active_workers"` // Workers in rotation
+	Slots         int       `json:"slots"`          // Concurrent generations of active workers reporting capacity
+	Running       int       `json:"running"`
+	Queued        int       `json:"queued"`      // Requests queued on workers
+	Scheduled     int       `json:"scheduled"`   // Prompts waiting in the scheduler; not counted per model
+	JobsQueued    int       `json:"jobs_queued"` // Async jobs waiting for a job runner
+	QueueDepth    int       `json:"queue_depth"` // Queued plus Scheduled plus JobsQueued
+	Demand        int       `json:"demand"`      // Running plus QueueDepth
+	Utilization   float64   `json:"utilization"` // Demand over Slots; above 1 means requests wait
+	AvgWaitMs     int64     `json:"avg_wait_ms"` // Mean estimated queue wait of active workers
+	MaxWaitMs     int64     `json:"max_wait_ms"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// handleAutoscaling handles GET /autoscaling, optionally for one model
+func (g *Gateway) handleAutoscaling(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(g.autoscalingSignal(r.URL.Query().Get("model")))
+}
+
+// autoscalingSignal sums the capacity reported by workers serving model, or
+// every worker if model is empty. A worker's load is the larger of its last
+// report and the gateway's requests in flight to it, as when routing.
+func (g *Gateway) autoscalingSignal(model string) AutoscalingSignal {
+	s := AutoscalingSignal{Model: model, Timestamp: time.Now().UTC()}
+	var waits time.Duration
+	var reporting int
+
+	g.mu.RLock()
+	for _, w := range g.workers {
+		if model != "" {
+			models := w.Models.Load()
+			if models == nil || !slices.Contains(*models, model) {
+				continue
+			}
+		}
+		s.Workers++
+		if !w.Healthy.Load() || !breakerUp(w.CB.State()) {
+			continue
+		}
+		s.ActiveWorkers++
+
+		c, busy, ok := w.busy()
+		if !ok {
+			// Without a reported capacity, only the gateway's own requests
+			// are known
+			s.Running += int(w.Inflight.Load())
+			continue
+		}
+		running := min(busy, c.Slots)
+		s.Slots += int(c.Slots)
+		s.Running += int(running)
+		s.Queued += int(busy - running)
+		waits += c.EstimatedWait
+		s.MaxWaitMs = max(s.MaxWaitMs, c.EstimatedWait.Milliseconds())
+		reporting++
+	}
+	g.mu.RUnlock()
+
+	if g.scheduler != nil && model == "" {
+		for _, p := range scheduler.Priorities() {
+			s.Scheduled += g.scheduler.Queued(p)
+		}
+	}
+	if g.jobs != nil {
+		s.JobsQueued = len(g.jobs.queue)
+	}
+	s.QueueDepth = s.Queued + s.Scheduled + s.JobsQueued
+	s.Demand = s.Running + s.QueueDepth
+	if s.Slots > 0 {
+		s.Utilization = math.Round(float64(s.Demand)/float64(s.Slots)*1000) / 1000
+	}
+	if reporting > 0 {
+		s.AvgWaitMs = (waits / time.Duration(reporting)).Milliseconds()
+	}
+	return s
+}
+
+// registerAutoscalingMetrics exports the fleet-wide signal as gauges, for
+// scaling on Prometheus queries instead of GET /autoscaling
+func (g *Gateway) registerAutoscalingMetrics() {
+	gauge := func(name, help string, value func(AutoscalingSignal) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "neurogate_gateway",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return value(g.autoscalingSignal("")) })
+	}
+	prometheus.MustRegister(
+		gauge("fleet_slots", "Concurrent generation slots of workers in rotation",
+			func(s AutoscalingSignal) float64 { return float64(s.Slots) }),
+		gauge("fleet_demand", "Requests running or queued on workers in rotation or in the scheduler, plus queued async jobs",
+			func(s AutoscalingSignal) float64 { return float64(s.Demand) }),
+		gauge("fleet_queue_depth", "Requests queued on workers in rotation or in the scheduler, plus queued async jobs",
+			func(s AutoscalingSignal) float64 { return float64(s.QueueDepth) }),
+		gauge("fleet_utilization", "Fleet demand over slots; above 1 means requests wait",
+			func(s AutoscalingSignal) float64 { return s.Utilization }),
+		gauge("fleet_wait_seconds", "Mean estimated queue wait of workers in rotation",
+			func(s AutoscalingSignal) float64 { return float64(s.AvgWaitMs) / 1000 }),
+	)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/audit"
+	"github.com/hugovillarreal/neurogate/pkg/circuitbreaker"
+)
+
+// BreakerAction is an operator override applied to a worker's circuit breaker
+type BreakerAction string
+
+const (
+	BreakerTrip      BreakerAction = "trip"       // Open now; recover after the open timeout
+	BreakerForceOpen BreakerAction = "force_open" // Keep out of rotation until reset
+	BreakerDisable   BreakerAction = "disable"    // Ignore failures until reset
+	BreakerReset     BreakerAction = "reset"      // Close and end any override
+)
+
+// BreakerStatus describes a worker's circuit breaker
+type BreakerStatus struct {
+	WorkerID        string    `json:"worker_id"`
+	State           string    `json:"state"`
+	LastStateChange time.Time `json:"last_state_change"`
+}
+
+// workerByName finds a configured worker by ID or address
+func (g *Gateway) workerByName(name string) *Worker {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for _, w := range g.workers {
+		if w.ID == name || w.Address == name {
+			return w
+		}
+	}
+	if g.emergencyWorker != nil && (g.emergencyWorker.ID == name || g.emergencyWorker.Address == name) {
+		return g.emergencyWorker
+	}
+	if g.moderationWorker != nil && (g.moderationWorker.ID == name || g.moderationWorker.Address == name) {
+		return g.moderationWorker
+	}
+	return nil
+}
+
+// handleBreakerAction handles POST /admin/workers/{id}/circuit-breaker
+func (g *Gateway) handleBreakerAction(w http.ResponseWriter, r *http.Request) {
+	worker := g.workerByName(r.PathValue("id"))
+	if worker == nil {
+		g.writeError(w, http.StatusNotFound, "worker not found", "")
+		return
+	}
+
+	var req struct {
+		Action BreakerAction `json:"action"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		g.writeAPIError(w, apiErr)
+		return
+	}
+
+	switch req.Action {
+	case BreakerTrip:
+		worker.CB.Trip()
+	case BreakerForceOpen:
+		worker.CB.ForceOpen()
+	case BreakerDisable:
+		worker.CB.Disable()
+	case BreakerReset:
+		worker.CB.Reset()
+	default:
+		g.writeError(w, http.StatusBadRequest, "invalid action", "use trip, force_open, disable or reset")
+		return
+	}
+	g.log.Warn("circuit breaker overridden", "worker", worker.ID, "action", string(req.Action))
+
+	stats := worker.CB.Stats()
+	g.recordAudit(r, audit.Event{
+		Type:    audit.EventBreakerOverride,
+		Actor:   auditActor(r),
+		Target:  worker.ID,
+		Outcome: stats.State.String(),
+		Details: map[string]string{"action": string(req.Action)},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BreakerStatus{
+		WorkerID:        worker.ID,
+		State:           stats.State.String(),
+		LastStateChange: stats.LastStateChange,
+	})
+}
+
+// breakerUp reports whether a breaker state lets traffic reach its worker
+func breakerUp(state circuitbreaker.State) bool {
+	return state != circuitbreaker.StateOpen && state != circuitbreaker.StateForcedOpen
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+)
+
+// workerCapacity is a worker's inference capacity as of its last health
+// check
+type workerCapacity struct {
+	Slots         int32 // 0 if the worker doesn't report its capacity
+	Running       int32
+	Queued        int32
+	MaxQueued     int32
+	EstimatedWait time.Duration
+}
+
+// newWorkerCapacity reads the capacity reported in a health check
+func newWorkerCapacity(resp *llmv1.HealthCheckResponse) *workerCapacity {
+	return &workerCapacity{
+		Slots:         resp.Capacity,
+		Running:       resp.ActiveRequests,
+		Queued:        resp.QueueDepth,
+		MaxQueued:     resp.MaxQueueDepth,
+		EstimatedWait: time.Duration(resp.EstimatedWaitMs) * time.Millisecond,
+	}
+}
+
+// busy returns how many requests a worker is running or queuing: the
+// larger of its last report and the requests the gateway has in flight to
+// it, which counts those sent since. ok is false if the worker doesn't
+// report its capacity.
+func (w *Worker) busy() (c *workerCapacity, busy int32, ok bool) {
+	c = w.Capacity.Load()
+	if c == nil || c.Slots <= 0 {
+		return nil, 0, false
+	}
+	return c, max(c.Running+c.Queued, w.Inflight.Load()), true
+}
+
+// hasFreeSlot reports whether a request sent to the worker would start
+// without queuing. Workers that don't report their capacity always do.
+func (w *Worker) hasFreeSlot() bool {
+	c, busy, ok := w.busy()
+	return !ok || busy < c.Slots
+}
+
+// saturated reports whether the worker's queue is full, so that it would
+// reject a request sent to it
+func (w *Worker) saturated() bool {
+	c, busy, ok := w.busy()
+	return ok && busy >= c.Slots+c.MaxQueued
+}
+
+// estimatedWait returns the worker's estimate of how long a request would
+// queue
+func (w *Worker) estimatedWait() time.Duration {
+	if c := w.Capacity.Load(); c != nil {
+		return c.EstimatedWait
+	}
+	return 0
+}
+
+// trackInflight counts a request sent to a worker until the returned
+// function is called
+func (g *Gateway) trackInflight(worker *Worker) func() {
+	worker.Inflight.Add(1)
+	g.metrics.IncWorkerInflight(worker.ID)
+	return func() {
+		worker.Inflight.Add(-1)
+		g.metrics.DecWorkerInflight(worker.ID)
+	}
+}
+
+// saturatedError is returned when every available worker's queue is full
+type saturatedError struct {
+	wait time.Duration // Shortest estimated wait among the workers
+}
+
+func (e *saturatedError) Error() string {
+	return fmt.Sprintf("all workers are at capacity (estimated wait %s)", e.wait.Round(time.Millisecond))
+}
+
+// deadlineError is returned when a request would queue on a worker for
+// longer than it has left before its deadline
+type deadlineError struct {
+	wait      time.Duration // Estimated queue wait on the best worker
+	remaining time.Duration
+}
+
+func (e *deadlineError) Error() string {
+	return fmt.Sprintf("estimated queue wait %s exceeds the %s left before the request's deadline",
+		e.wait.Round(time.Millisecond), e.remaining.Round(time.Millisecond))
+}
+
+// checkDeadline fails with a *deadlineError if a request with ctx's deadline
+// sent to worker couldn't start before the deadline
+func checkDeadline(ctx context.Context, worker *Worker) error {
+	deadline, ok := ctx.Deadline()
+	if !ok || worker.hasFreeSlot() {
+		return nil
+	}
+	if wait, remaining := worker.estimatedWait(), time.Until(deadline); wait > remaining {
+		return &deadlineError{wait: wait, remaining: remaining}
+	}
+	return nil
+}
+
+// noWorkerError converts a worker selection failure to a response. When
+// every worker is saturated, or would queue the request past its deadline,
+// the request is shed, and the client asked to retry after the shortest
+// estimated wait, or a second if the workers have no estimate yet.
+func (g *Gateway) noWorkerError(err error) *apiError {
+	var tooLate *deadlineError
+	if errors.As(err, &tooLate) {
+		g.metrics.RecordShed("deadline")
+		return &apiError{
+			Status:     http.StatusServiceUnavailable,
+			Message:    "deadline cannot be met",
+			Detail:     err.Error(),
+			Code:       CodeOverloaded,
+			RetryAfter: max(tooLate.wait, time.Second),
+		}
+	}
+
+	var saturated *saturatedError
+	if errors.As(err, &saturated) {
+		g.metrics.RecordShed("workers_saturated")
+		return &apiError{
+			Status:     http.StatusServiceUnavailable,
+			Message:    "all workers at capacity",
+			Detail:     err.Error(),
+			Code:       CodeOverloaded,
+			RetryAfter: max(saturated.wait, time.Second),
+		}
+	}
+	return &apiError{Status: http.StatusServiceUnavailable, Message: "no workers available", Detail: err.Error(), Code: CodeWorkerUnavailable}
+}
+
+// capacityPicker chooses among available workers offered in order of
+// preference: the first with a free slot or, if none has one, the one with
+// the shortest estimated wait among those with room in their queue.
+type capacityPicker struct {
+	queued    *Worker
+	saturated *Worker
+}
+
+// offer considers a worker, reporting whether it has a free slot and should
+// be taken without looking further
+func (p *capacityPicker) offer(w *Worker) bool {
+	switch {
+	case w.hasFreeSlot():
+		return true
+	case w.saturated():
+		if p.saturated == nil || w.estimatedWait() < p.saturated.estimatedWait() {
+			p.saturated = w
+		}
+	case p.queued == nil || w.estimatedWait() < p.queued.estimatedWait():
+		p.queued = w
+	}
+	return false
+}
+
+// pick returns the worker to queue on, if no worker had a free slot. err is
+// a *saturatedError if all offered workers were saturated, and nil if none
+// were offered.
+func (p *capacityPicker) pick() (*Worker, error) {
+	if p.queued != nil {
+		return p.queued, nil
+	}
+	if p.saturated != nil {
+		return nil, &saturatedError{wait: p.saturated.estimatedWait()}
+	}
+	return nil, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/cloud"
+	"github.com/hugovillarreal/neurogate/pkg/logger"
+)
+
+// cloudWorkerID stands in for the worker ID of responses served by the
+// cloud fallback
+const cloudWorkerID = "cloud"
+
+// CloudConfig configures the cloud fallback strategy
+type CloudConfig struct {
+	Provider     string        // "openai" or "anthropic"; empty disables the cloud fallback
+	URL          string        // API base URL; empty uses the provider's
+	APIKey       string        // Provider API key
+	Model        string        // Cloud model serving every request
+	MaxTokens    int32         // Ceiling on max_tokens sent to the cloud. Default: 1024
+	Budget       float64       // USD per BudgetWindow, priced with MODEL_PRICING; 0 for no cap
+	BudgetWindow time.Duration // Default: 24h
+	MaxQueueWait time.Duration // Jobs queued longer go to the cloud; 0 disables
+	Timeout      time.Duration // Per request. Default: 60s
+}
+
+// cloudBackend is the configured cloud fallback
+type cloudBackend struct {
+	client       *cloud.Client
+	model        string
+	maxTokens    int32
+	budget       *cloud.Budget
+	maxQueueWait time.Duration
+}
+
+// setupCloud creates the cloud fallback client
+func (g *Gateway) setupCloud(cfg CloudConfig) error {
+	if cfg.Provider == "" {
+		return nil
+	}
+	if cfg.Model == "" {
+		return fmt.Errorf("model is required")
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = 1024
+	}
+	if cfg.BudgetWindow <= 0 {
+		cfg.BudgetWindow = 24 * time.Hour
+	}
+
+	client, err := cloud.New(cloud.Config{
+		Provider: cfg.Provider,
+		URL:      cfg.URL,
+		APIKey:   cfg.APIKey,
+		Timeout:  cfg.Timeout,
+	})
+	if err != nil {
+		return err
+	}
+	g.cloud = &cloudBackend{
+		client:       client,
+		model:        cfg.Model,
+		maxTokens:    cfg.MaxTokens,
+		budget:       cloud.NewBudget(cfg.Budget, cfg.BudgetWindow),
+		maxQueueWait: cfg.MaxQueueWait,
+	}
+	return nil
+}
+
+// overflowsToCloud reports whether a job has waited in the queue longer
+// than the cloud fallback allows, and may go to the cloud instead
+func (g *Gateway) overflowsToCloud(req *PromptRequest, strategies []string) bool {
+	if g.cloud == nil || g.cloud.maxQueueWait <= 0 || req.queueWait <= g.cloud.maxQueueWait {
+		return false
+	}
+	for _, s := range strategies {
+		if s == fallbackCloud {
+			return true
+		}
+	}
+	return false
+}
+
+// completeInCloud serves a request from the cloud model. Requests with a
+// format or a context handle are refused, as the cloud model can't honor
+// them, as are requests once the budget is spent.
+func (g *Gateway) completeInCloud(ctx context.Context, requestID string, req *PromptRequest, start time.Time) (*PromptResponse, error) {
+	if req.Format != nil || req.Context != "" {
+		return nil, fmt.Errorf("request uses format or context")
+	}
+	if !g.cloud.budget.Allow() {
+		g.metrics.RecordCloudRequest("budget_exhausted")
+		return nil, fmt.Errorf("budget exhausted")
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 || maxTokens > g.cloud.maxTokens {
+		maxTokens = g.cloud.maxTokens
+	}
+	messages := make([]cloud.Message, 0, len(req.history)+1)
+	for _, m := range req.history {
+		messages = append(messages, cloud.Message{Role: m.Role, Content: m.Content})
+	}
+	messages = append(messages, cloud.Message{Role: "user", Content: req.Query})
+
+	logger.FromContext(ctx).Warn("sending request to cloud", "provider", g.cloud.client.Provider(), "model", g.cloud.model)
+	result, err := g.cloud.client.Complete(ctx, &cloud.Request{
+		Model:       g.cloud.model,
+		System:      req.SystemPrompt,
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stop:        req.Stop,
+	})
+	if err != nil {
+		g.metrics.RecordCloudRequest("error")
+		return nil, err
+	}
+	g.metrics.RecordCloudRequest("success")
+
+	// Usage is priced as the configured model, which the provider may
+	// report under a dated name
+	cost := g.pricing.cost(g.cloud.model, result.PromptTokens, result.CompletionTokens, 0)
+	g.cloud.budget.Spend(cost)
+
+	// Cloud results are never cached, like emergency ones
+	return &PromptResponse{
+		RequestID: requestID,
+		Response:  result.Text,
+		Model:     result.Model,
+		Tokens:    result.PromptTokens + result.CompletionTokens,
+		LatencyMs: time.Since(start).Milliseconds(),
+		WorkerID:  cloudWorkerID,
+		Degraded:  fallbackCloud,
+		Cloud:     true,
+		Usage: Usage{
+			PromptTokens:     result.PromptTokens,
+			CompletionTokens: result.CompletionTokens,
+			TotalTokens:      result.PromptTokens + result.CompletionTokens,
+			EstimatedCost:    cost,
+		},
+	}, nil
+}
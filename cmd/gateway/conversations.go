@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+	"github.com/hugovillarreal/neurogate/pkg/guardrails"
+	"github.com/hugovillarreal/neurogate/pkg/logger"
+	"github.com/hugovillarreal/neurogate/pkg/scheduler"
+	"github.com/hugovillarreal/neurogate/pkg/session"
+)
+
+// maxConversationIDLength limits client-chosen conversation IDs
+const maxConversationIDLength = 128
+
+// Conversation is the response body of GET /conversations/{id}
+type Conversation struct {
+	ID       string            `json:"conversation_id"`
+	Messages []session.Message `json:"messages"`
+}
+
+// validateConversationID checks that an ID is short and made of letters,
+// digits and "-", "_", "." or ":"
+func validateConversationID(id string) error {
+	if len(id) > maxConversationIDLength {
+		return fmt.Errorf("must be at most %d characters", maxConversationIDLength)
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return fmt.Errorf("invalid character %q", c)
+		}
+	}
+	return nil
+}
+
+// conversationKey scopes a conversation to the key that owns it, so clients
+// can't read or extend each other's conversations by guessing IDs
+func conversationKey(owner, id string) string {
+	return owner + ":" + id
+}
+
+// runConversation serves a prompt continuing a conversation: the stored
+// history is sent along with the query, and the exchange is appended to it
+// once the response has passed the post-checks of policy and moderation.
+// Errors are returned as *apiError.
+func (g *Gateway) runConversation(ctx context.Context, requestID string, req *PromptRequest, ticket scheduler.Ticket, owner string, policy *guardrails.Pipeline, fallback []string) (*PromptResponse, error) {
+	key := conversationKey(owner, req.ConversationID)
+	history, err := g.sessions.Get(ctx, key)
+	if err != nil {
+		return nil, &apiError{Status: http.StatusServiceUnavailable, Message: "conversation store unavailable", Detail: err.Error()}
+	}
+	req.history = history
+
+	resp, err := g.completePrompt(ctx, requestID, req, ticket, fallback)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.reviewResponse(ctx, policy, resp); err != nil {
+		return nil, err
+	}
+	resp.ConversationID = req.ConversationID
+
+	// The response is returned even if it can't be saved; the client can
+	// resend the turn
+	now := time.Now().UTC()
+	err = g.sessions.Append(ctx, key,
+		session.Message{Role: "user", Content: req.Query, CreatedAt: now},
+		session.Message{Role: "assistant", Content: resp.Response, CreatedAt: now},
+	)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to save conversation", "conversation_id", req.ConversationID, "error", err)
+	}
+	return resp, nil
+}
+
+// chatHistory converts stored messages for a worker request
+func chatHistory(messages []session.Message) []*llmv1.ChatMessage {
+	if len(messages) == 0 {
+		return nil
+	}
+	history := make([]*llmv1.ChatMessage, len(messages))
+	for i, m := range messages {
+		history[i] = &llmv1.ChatMessage{Role: m.Role, Content: m.Content}
+	}
+	return history
+}
+
+// handleConversation handles GET and DELETE /conversations/{id}
+func (g *Gateway) handleConversation(w http.ResponseWriter, r *http.Request) {
+	authHeader := r.Header.Get("Authorization")
+	id := r.PathValue("id")
+	if validateConversationID(id) != nil {
+		g.writeError(w, http.StatusNotFound, "conversation not found", "")
+		return
+	}
+	key := conversationKey(g.ownerOf(authHeader), id)
+
+	if r.Method == "DELETE" {
+		if err := g.sessions.Delete(r.Context(), key); err != nil {
+			g.writeError(w, http.StatusServiceUnavailable, "conversation store unavailable", err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	messages, err := g.sessions.Get(r.Context(), key)
+	if err != nil {
+		g.writeError(w, http.StatusServiceUnavailable, "conversation store unavailable", err.Error())
+		return
+	}
+	if len(messages) == 0 {
+		g.writeError(w, http.StatusNotFound, "conversation not found", "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Conversation{ID: id, Messages: messages})
+}
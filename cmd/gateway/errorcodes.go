@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// ErrorCode identifies the kind of an API error for programs. Unlike error
+// messages, codes are stable across releases.
+type ErrorCode string
+
+// Error codes. Errors without a specific code get the one of their HTTP
+// status (see codeForStatus).
+const (
+	CodeInvalidRequest    ErrorCode = "INVALID_REQUEST"
+	CodeUnauthorized      ErrorCode = "UNAUTHORIZED"
+	CodeForbidden         ErrorCode = "FORBIDDEN"
+	CodeNotFound          ErrorCode = "NOT_FOUND"
+	CodeMethodNotAllowed  ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeRequestTooLarge   ErrorCode = "REQUEST_TOO_LARGE"
+	CodeModelNotFound     ErrorCode = "MODEL_NOT_FOUND"
+	CodeContextTooLong    ErrorCode = "CONTEXT_TOO_LONG"
+	CodeContentBlocked    ErrorCode = "CONTENT_BLOCKED"
+	CodeRateLimited       ErrorCode = "RATE_LIMITED"
+	CodeQuotaExceeded     ErrorCode = "QUOTA_EXCEEDED"
+	CodeOverloaded        ErrorCode = "OVERLOADED"
+	CodeWorkerUnavailable ErrorCode = "WORKER_UNAVAILABLE"
+	CodeUnavailable       ErrorCode = "UNAVAILABLE"
+	CodeTimeout           ErrorCode = "TIMEOUT"
+	CodeNotSupported      ErrorCode = "NOT_SUPPORTED"
+	CodeInternal          ErrorCode = "INTERNAL"
+)
+
+// retryable reports whether the same request may succeed if sent again,
+// after the Retry-After delay when the response has one. Quotas reset on a
+// schedule, so their errors aren't worth retrying automatically.
+func (c ErrorCode) retryable() bool {
+	switch c {
+	case CodeRateLimited, CodeOverloaded, CodeWorkerUnavailable, CodeUnavailable, CodeTimeout:
+		return true
+	}
+	return false
+}
+
+// codeForStatus returns the code of errors with no more specific one
+func codeForStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusRequestEntityTooLarge:
+		return CodeRequestTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotSupported
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	return CodeInternal
+}
+
+// grpcErrors maps the status codes of failed worker calls to API errors.
+// Codes not listed are internal errors.
+var grpcErrors = map[codes.Code]struct {
+	status  int
+	code    ErrorCode
+	message string
+}{
+	codes.NotFound:          {http.StatusNotFound, CodeModelNotFound, "model not found"},
+	codes.InvalidArgument:   {http.StatusBadRequest, CodeInvalidRequest, "invalid request"},
+	codes.OutOfRange:        {http.StatusBadRequest, CodeContextTooLong, "prompt exceeds the model's context"},
+	codes.ResourceExhausted: {http.StatusTooManyRequests, CodeOverloaded, "worker out of resources"},
+	codes.Unavailable:       {http.StatusServiceUnavailable, CodeWorkerUnavailable, "worker unavailable"},
+	codes.DeadlineExceeded:  {http.StatusGatewayTimeout, CodeTimeout, "worker timed out"},
+	codes.Unimplemented:     {http.StatusNotImplemented, CodeNotSupported, "not supported by the worker"},
+}
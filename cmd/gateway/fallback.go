@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/cache"
+	"github.com/hugovillarreal/neurogate/pkg/logger"
+)
+
+// Degradation strategies used when no workers are available
+const (
+	fallbackStale     = "stale"     // serve an expired cached response
+	fallbackEmergency = "emergency" // route to the emergency worker
+	fallbackCloud     = "cloud"     // forward to the cloud provider
+)
+
+// fallbackFor returns the degradation strategies for a request to endpoint.
+// A key's own policy overrides the gateway default, and endpoints outside
+// FALLBACK_ENDPOINTS never degrade.
+func (g *Gateway) fallbackFor(endpoint, authHeader string) []string {
+	if !g.fallbackEndpoints[endpoint] {
+		return nil
+	}
+	if strategies, ok := g.apiKeys.fallbackFor(g.keyHashOf(authHeader)); ok {
+		return strategies
+	}
+	return g.fallbackStrategies
+}
+
+// degrade tries each strategy in order and returns the first response it can
+// produce. Responses are marked with the strategy that produced them.
+func (g *Gateway) degrade(ctx context.Context, requestID string, req *PromptRequest, key cache.Key, strategies []string, start time.Time) (*PromptResponse, bool) {
+	requestLog := logger.FromContext(ctx)
+
+	for _, strategy := range strategies {
+		switch strategy {
+		case fallbackStale:
+			if g.cache == nil || !req.cacheable() {
+				continue
+			}
+			cached, _, ok := g.cache.GetStale(key)
+			if !ok {
+				continue
+			}
+			requestLog.Warn("serving stale cached response", "age", time.Since(cached.CreatedAt).Round(time.Second))
+			g.metrics.RecordFallback(strategy)
+
+			resp := cachedResponse(requestID, cached, start)
+			resp.Degraded = fallbackStale
+			return resp, true
+
+		case fallbackEmergency:
+			if g.emergencyWorker == nil {
+				continue
+			}
+			emergencyReq := *req
+			if g.emergencyModel != "" && g.emergencyModel != req.Model {
+				emergencyReq.Model = g.emergencyModel
+				// Context tokens only mean something to the model that
+				// produced them
+				emergencyReq.Context = ""
+			}
+
+			result, err := g.forward(ctx, g.emergencyWorker, requestID, &emergencyReq)
+			if err != nil {
+				requestLog.Warn("emergency worker failed", "worker_id", g.emergencyWorker.ID, "error", err)
+				continue
+			}
+			requestLog.Warn("served by emergency worker", "worker_id", g.emergencyWorker.ID, "model", result.Model)
+			g.metrics.RecordFallback(strategy)
+
+			// Emergency results are never cached so they can't outlive the outage
+			return &PromptResponse{
+				RequestID: requestID,
+				Response:  result.Response,
+				Model:     result.Model,
+				Tokens:    result.TotalTokens,
+				LatencyMs: time.Since(start).Milliseconds(),
+				WorkerID:  g.emergencyWorker.ID,
+				Degraded:  fallbackEmergency,
+				Usage:     g.usage(result, 0),
+				Context:   responseContext(req, result),
+
+				Parameters: generationParameters(result.Parameters),
+			}, true
+
+		case fallbackCloud:
+			if g.cloud == nil {
+				continue
+			}
+			resp, err := g.completeInCloud(ctx, requestID, req, start)
+			if err != nil {
+				requestLog.Warn("cloud fallback failed", "error", err)
+				continue
+			}
+			requestLog.Warn("served by cloud", "model", resp.Model)
+			g.metrics.RecordFallback(strategy)
+			return resp, true
+		}
+	}
+
+	return nil, false
+}
+
+// parseFallbackStrategies parses a comma-separated list of strategies,
+// ignoring unknown names
+func parseFallbackStrategies(s string) []string {
+	var strategies []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if validFallbackStrategy(part) {
+			strategies = append(strategies, part)
+		}
+	}
+	return strategies
+}
+
+func validFallbackStrategy(s string) bool {
+	return s == fallbackStale || s == fallbackEmergency || s == fallbackCloud
+}
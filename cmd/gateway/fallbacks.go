@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+	"github.com/hugovillarreal/neurogate/pkg/logger"
+)
+
+// modelFallbacks maps a model to the models tried, in order, when it fails
+// or times out
+type modelFallbacks map[string][]string
+
+// newModelFallbacks parses chains written as "model=first|second"
+func newModelFallbacks(entries map[string]string) (modelFallbacks, error) {
+	fallbacks := make(modelFallbacks, len(entries))
+	for model, value := range entries {
+		var chain []string
+		for _, next := range strings.Split(value, "|") {
+			next = strings.TrimSpace(next)
+			if next == "" {
+				return nil, fmt.Errorf("model %s: empty fallback model in %q", model, value)
+			}
+			if next == model {
+				return nil, fmt.Errorf("model %s: can't fall back to itself", model)
+			}
+			chain = append(chain, next)
+		}
+		fallbacks[model] = chain
+	}
+	return fallbacks, nil
+}
+
+// shouldFallBack reports whether a failed generation may succeed with
+// another model: the model is missing, or the worker failed or timed out.
+// Invalid requests would fail the same way on any model.
+func shouldFallBack(err error) bool {
+	status := toAPIError(err).Status
+	return status == http.StatusNotFound || status >= http.StatusInternalServerError
+}
+
+// fallBack runs a request that failed with err through the fallback chain
+// of its model, returning the first response, the worker that served it and
+// the number of models tried. Each model gets its own model policy. When
+// every model fails, the last error is returned.
+func (g *Gateway) fallBack(ctx context.Context, requestID string, req *PromptRequest, err error) (*llmv1.PromptResponse, *Worker, int, error) {
+	requestLog := logger.FromContext(ctx)
+	attempts := 0
+	for _, model := range g.modelFallbacks[req.Model] {
+		if !shouldFallBack(err) || ctx.Err() != nil {
+			break
+		}
+
+		attempt := *req
+		attempt.Model = model
+		if policyErr := g.modelPolicies.apply(&attempt); policyErr != nil {
+			requestLog.Warn("skipping fallback model outside its policy", "fallback_model", model, "error", policyErr)
+			continue
+		}
+		worker, selErr := g.selectWorker(ctx, g.affinityKey(req), nil)
+		if selErr != nil {
+			break
+		}
+
+		requestLog.Warn("falling back to another model", "model", req.Model, "fallback_model", model, "error", err)
+		g.metrics.RecordModelFallback(req.Model, model)
+		attempts++
+		var resp *llmv1.PromptResponse
+		resp, err = g.forward(ctx, worker, requestID, &attempt)
+		if err == nil {
+			return resp, worker, attempts, nil
+		}
+	}
+	return nil, nil, attempts, err
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hugovillarreal/neurogate/pkg/jsonschema"
+)
+
+// formatJSON is the format asking for any valid JSON
+const formatJSON = "json"
+
+// parseFormat validates a request's format: "json", a JSON schema object, or
+// absent for free text. The compiled schema is returned for schema formats.
+func parseFormat(raw json.RawMessage) (*jsonschema.Schema, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var name string
+	if json.Unmarshal(raw, &name) == nil {
+		if name != formatJSON {
+			return nil, fmt.Errorf(`format must be "json" or a JSON schema object`)
+		}
+		return nil, nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, fmt.Errorf(`format must be "json" or a JSON schema object`)
+	}
+	return jsonschema.Compile(raw)
+}
+
+// workerFormat returns a validated format as sent to workers: "json", the
+// schema document, or empty for free text
+func workerFormat(raw json.RawMessage) string {
+	var name string
+	if json.Unmarshal(raw, &name) == nil {
+		return name
+	}
+	return string(raw)
+}
+
+// checkFormat verifies that a response follows the request's format. Models
+// usually do when asked, but nothing guarantees it.
+func checkFormat(raw json.RawMessage, text string) error {
+	if workerFormat(raw) == "" {
+		return nil
+	}
+
+	schema, err := parseFormat(raw)
+	if err != nil {
+		return err
+	}
+	if schema == nil {
+		if !json.Valid([]byte(text)) {
+			return &apiError{Status: http.StatusBadGateway, Message: "response does not match format", Detail: "response is not valid JSON"}
+		}
+		return nil
+	}
+	if err := schema.Validate([]byte(text)); err != nil {
+		return &apiError{Status: http.StatusBadGateway, Message: "response does not match format", Detail: err.Error()}
+	}
+	return nil
+}
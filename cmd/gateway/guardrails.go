@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hugovillarreal/neurogate/pkg/guardrails"
+)
+
+// guardrailsFor returns the guardrail policy of the key in an Authorization
+// header: its own policy if it has one, else the gateway default. A key
+// naming a policy that is no longer configured gets the default.
+func (g *Gateway) guardrailsFor(authHeader string) *guardrails.Pipeline {
+	if name, ok := g.apiKeys.guardrailsFor(g.keyHashOf(authHeader)); ok {
+		if policy, ok := g.guardrails[name]; ok {
+			return policy
+		}
+	}
+	return g.guardrails[g.defaultGuardrails]
+}
+
+// checkPrompt runs a request's query through the pre-checks of policy,
+// keeping any rewrite. Errors are returned as *apiError.
+func (g *Gateway) checkPrompt(policy *guardrails.Pipeline, req *PromptRequest) error {
+	query, err := policy.CheckPrompt(req.Query)
+	if err != nil {
+		return g.guardrailError(err, http.StatusBadRequest, "prompt violates policy")
+	}
+	req.Query = query
+	return nil
+}
+
+// checkResponse runs a response through the post-checks of policy, keeping
+// any rewrite. Errors are returned as *apiError.
+func (g *Gateway) checkResponse(policy *guardrails.Pipeline, resp *PromptResponse) error {
+	text, err := policy.CheckResponse(resp.Response)
+	if err != nil {
+		return g.guardrailError(err, http.StatusBadGateway, "response violates policy")
+	}
+	resp.Response = text
+	return nil
+}
+
+// guardrailError converts a failed check to an apiError carrying the
+// violation
+func (g *Gateway) guardrailError(err error, status int, message string) *apiError {
+	var v *guardrails.Violation
+	if !errors.As(err, &v) {
+		return &apiError{Status: http.StatusInternalServerError, Message: "guardrail check failed", Detail: err.Error()}
+	}
+	g.metrics.RecordGuardrailViolation(v.Policy, v.Check, string(v.Stage))
+	return &apiError{Status: status, Message: message, Detail: v.Reason, Code: CodeContentBlocked, Violation: v}
+}
+
+// validateGuardrailPolicies rejects access configs naming policies that
+// aren't configured
+func (g *Gateway) validateGuardrailPolicies(doc *AccessConfig) error {
+	for i, policy := range doc.Keys {
+		if policy.Guardrails == "" {
+			continue
+		}
+		if _, ok := g.guardrails[policy.Guardrails]; !ok {
+			return fmt.Errorf("keys[%d]: unknown guardrail policy %q", i, policy.Guardrails)
+		}
+	}
+	return nil
+}
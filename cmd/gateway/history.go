@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/redact"
+	"github.com/hugovillarreal/neurogate/pkg/store"
+)
+
+// Limits on the number of records returned by GET /requests
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// historyPruneInterval is how often records past the retention are removed
+const historyPruneInterval = 10 * time.Minute
+
+// HistoryRecord is the metadata kept for a completed request
+type HistoryRecord struct {
+	RequestID        string    `json:"request_id"`
+	Endpoint         string    `json:"endpoint"`
+	KeyID            string    `json:"key_id,omitempty"` // Hashed API key
+	Tenant           string    `json:"tenant,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	WorkerID         string    `json:"worker_id,omitempty"`
+	Status           int       `json:"status"`
+	Error            string    `json:"error,omitempty"`
+	PromptTokens     int32     `json:"prompt_tokens"`
+	CompletionTokens int32     `json:"completion_tokens"`
+	EstimatedCost    float64   `json:"estimated_cost,omitempty"`
+	Cached           bool      `json:"cached,omitempty"`
+	Degraded         string    `json:"degraded,omitempty"`
+	LatencyMs        int64     `json:"latency_ms"`
+	CompletedAt      time.Time `json:"completed_at"`
+
+	// Bodies are only kept with REQUEST_HISTORY_CONTENT, after redaction
+	Prompt   string `json:"prompt,omitempty"`
+	Response string `json:"response,omitempty"`
+}
+
+// promptHistory describes a successful prompt
+func promptHistory(endpoint string, resp *PromptResponse) HistoryRecord {
+	return HistoryRecord{
+		RequestID:        resp.RequestID,
+		Endpoint:         endpoint,
+		Model:            resp.Model,
+		WorkerID:         resp.WorkerID,
+		Status:           http.StatusOK,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		EstimatedCost:    resp.Usage.EstimatedCost,
+		Cached:           resp.Cached,
+		Degraded:         resp.Degraded,
+		LatencyMs:        resp.LatencyMs,
+	}
+}
+
+// recordHistory saves a completed request when request history is enabled.
+// Prompt and response bodies are kept only if a content policy is set.
+func (g *Gateway) recordHistory(authHeader string, rec HistoryRecord, prompt, response string) {
+	if g.historyRetention <= 0 {
+		return
+	}
+
+	if caller := g.historyCaller(authHeader); caller != "" {
+		rec.KeyID = caller
+		rec.Tenant = g.tenantOf(authHeader)
+	}
+	if g.historyContent != nil {
+		rec.Prompt = g.historyContent.Apply(prompt)
+		rec.Response = g.historyContent.Apply(response)
+	}
+	rec.CompletedAt = time.Now().UTC()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := store.PutJSON(ctx, g.store, collectionHistory, historyKey(rec), rec); err != nil {
+		g.log.Warn("failed to save request history", "request_id", rec.RequestID, "error", err)
+	}
+}
+
+// historyCaller returns the ID history records are kept under: the hashed
+// key ID, or for JWT callers an ID of their tenant and subject, so they
+// still see their requests once the token is refreshed
+func (g *Gateway) historyCaller(authHeader string) string {
+	if claims, ok := g.verifyJWT(authHeader); ok {
+		return "jwt-" + hashOwner("jwt:" + claims.String(g.jwtClaim) + ":" + claims.String("sub"))[:8]
+	}
+	if hash := g.keyHashOf(authHeader); hash != "" {
+		return hashID(hash)
+	}
+	return ""
+}
+
+// historyKey orders records by completion time
+func historyKey(rec HistoryRecord) string {
+	return fmt.Sprintf("%020d-%s", rec.CompletedAt.UnixNano(), rec.RequestID)
+}
+
+// runHistoryPruner periodically removes records older than the retention
+func (g *Gateway) runHistoryPruner() {
+	ticker := time.NewTicker(historyPruneInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		g.pruneHistory(time.Now().Add(-g.historyRetention))
+	}
+}
+
+// pruneHistory removes records completed before cutoff
+func (g *Gateway) pruneHistory(cutoff time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	stored, err := g.store.List(ctx, collectionHistory)
+	if err != nil {
+		g.log.Warn("failed to list request history", "error", err)
+		return
+	}
+	// Keys start with the zero-padded completion time
+	oldest := fmt.Sprintf("%020d", cutoff.UnixNano())
+	for key := range stored {
+		if key < oldest {
+			g.store.Delete(ctx, collectionHistory, key)
+		}
+	}
+}
+
+// handleListHistory handles GET /requests. Admin keys see every record and
+// may filter by key ID; API keys see only their own requests. Further
+// filters: model, endpoint, status, since (RFC 3339 or a duration such as
+// 1h) and limit.
+func (g *Gateway) handleListHistory(w http.ResponseWriter, r *http.Request) {
+	if g.historyRetention <= 0 {
+		g.writeError(w, http.StatusNotFound, "request history disabled", "set REQUEST_HISTORY_RETENTION to enable")
+		return
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	query := r.URL.Query()
+	keyFilter := query.Get("key")
+	switch {
+	case len(g.adminKeys) > 0 && validateKey(authHeader, g.adminKeys):
+	case g.authEnabled() && g.validateAPIKey(authHeader):
+		own := g.historyCaller(authHeader)
+		if keyFilter != "" && keyFilter != own {
+			g.writeError(w, http.StatusForbidden, "cannot view another key's requests", "")
+			return
+		}
+		keyFilter = own
+	default:
+		g.auditAuthFailure(r, "api")
+		g.writeError(w, http.StatusUnauthorized, "invalid or missing API key", "admin or API key required")
+		return
+	}
+
+	var since time.Time
+	if s := query.Get("since"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil {
+			since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, s); err == nil {
+			since = t
+		} else {
+			g.writeError(w, http.StatusBadRequest, "invalid since", "use an RFC 3339 time or a duration such as 1h")
+			return
+		}
+	}
+	status := 0
+	if s := query.Get("status"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			g.writeError(w, http.StatusBadRequest, "invalid status", err.Error())
+			return
+		}
+		status = n
+	}
+	limit := defaultHistoryLimit
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			g.writeError(w, http.StatusBadRequest, "invalid limit", "must be a positive integer")
+			return
+		}
+		limit = min(n, maxHistoryLimit)
+	}
+
+	stored, err := g.store.List(r.Context(), collectionHistory)
+	if err != nil {
+		g.writeError(w, http.StatusInternalServerError, "failed to read request history", err.Error())
+		return
+	}
+
+	records := make([]HistoryRecord, 0)
+	for _, value := range stored {
+		var rec HistoryRecord
+		if err := json.Unmarshal(value, &rec); err != nil {
+			continue
+		}
+		if (keyFilter != "" && rec.KeyID != keyFilter) ||
+			(query.Get("model") != "" && rec.Model != query.Get("model")) ||
+			(query.Get("endpoint") != "" && rec.Endpoint != query.Get("endpoint")) ||
+			(status != 0 && rec.Status != status) ||
+			rec.CompletedAt.Before(since) {
+			continue
+		}
+		records = append(records, rec)
+	}
+
+	// Newest first
+	sort.Slice(records, func(i, j int) bool { return records[i].CompletedAt.After(records[j].CompletedAt) })
+	if len(records) > limit {
+		records = records[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"requests": records,
+		"count":    len(records),
+	})
+}
+
+// parseHistoryContent returns the policy applied to stored bodies, or nil
+// when bodies aren't kept
+func parseHistoryContent(mode string, policy redact.Policy) (*redact.Policy, error) {
+	if mode == "" {
+		return nil, nil
+	}
+	m, err := redact.ParseMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	policy.Mode = m
+	return &policy, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many chunks a slow admin viewer may fall behind
+// before it is disconnected
+const subscriberBuffer = 256
+
+// inflightRequest tracks a generation in progress so that admin clients can
+// attach to its token stream without affecting the original caller
+type inflightRequest struct {
+	ID        string
+	WorkerID  string
+	Model     string
+	StartedAt time.Time
+
+	mu          sync.Mutex
+	tokens      []string
+	subscribers map[chan string]struct{}
+	done        bool
+}
+
+// publish records a chunk and forwards it to all subscribers
+func (r *inflightRequest) publish(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens = append(r.tokens, token)
+	for ch := range r.subscribers {
+		select {
+		case ch <- token:
+		default:
+			// Viewer can't keep up; drop it rather than block generation
+			delete(r.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// finish marks the request complete and closes all subscriber channels
+func (r *inflightRequest) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.done = true
+	for ch := range r.subscribers {
+		close(ch)
+	}
+	r.subscribers = nil
+}
+
+// subscribe returns the chunks produced so far and a channel that receives
+// subsequent chunks. The channel is closed when the request finishes.
+func (r *inflightRequest) subscribe() ([]string, <-chan string, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history := append([]string(nil), r.tokens...)
+	ch := make(chan string, subscriberBuffer)
+
+	if r.done {
+		close(ch)
+		return history, ch, func() {}
+	}
+
+	r.subscribers[ch] = struct{}{}
+	unsubscribe := func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, ok := r.subscribers[ch]; ok {
+			delete(r.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return history, ch, unsubscribe
+}
+
+// inflightTracker indexes in-flight requests by request ID
+type inflightTracker struct {
+	mu       sync.RWMutex
+	requests map[string]*inflightRequest
+}
+
+func newInflightTracker() *inflightTracker {
+	return &inflightTracker{
+		requests: make(map[string]*inflightRequest),
+	}
+}
+
+// start registers a new in-flight request
+func (t *inflightTracker) start(id, workerID, model string) *inflightRequest {
+	req := &inflightRequest{
+		ID:          id,
+		WorkerID:    workerID,
+		Model:       model,
+		StartedAt:   time.Now(),
+		subscribers: make(map[chan string]struct{}),
+	}
+
+	t.mu.Lock()
+	t.requests[id] = req
+	t.mu.Unlock()
+
+	return req
+}
+
+// end finishes and unregisters a request
+func (t *inflightTracker) end(req *inflightRequest) {
+	req.finish()
+
+	t.mu.Lock()
+	delete(t.requests, req.ID)
+	t.mu.Unlock()
+}
+
+// get looks up an in-flight request by ID
+func (t *inflightTracker) get(id string) (*inflightRequest, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	req, ok := t.requests[id]
+	return req, ok
+}
+
+// list returns all in-flight requests, oldest first
+func (t *inflightTracker) list() []*inflightRequest {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	reqs := make([]*inflightRequest, 0, len(t.requests))
+	for _, r := range t.requests {
+		reqs = append(reqs, r)
+	}
+	sort.Slice(reqs, func(i, j int) bool {
+		return reqs[i].StartedAt.Before(reqs[j].StartedAt)
+	})
+	return reqs
+}
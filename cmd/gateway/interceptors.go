@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/reqmeta"
+	"github.com/hugovillarreal/neurogate/pkg/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WorkerCallConfig configures the client interceptors of calls to workers
+type WorkerCallConfig struct {
+	// Timeouts by method name (e.g. "Embed"), applied unless the call's
+	// context ends sooner. Calls may set their own with callTimeout.
+	Timeouts map[string]time.Duration
+
+	// Calls to idempotent methods that fail with Unavailable are retried on
+	// the same worker up to Retries times, RetryBackoff apart, within the
+	// call's deadline. Generation isn't retried here; the gateway retries
+	// it on another worker.
+	Retries      int
+	RetryBackoff time.Duration
+}
+
+// defaultWorkerCallTimeouts apply to methods without a configured timeout
+var defaultWorkerCallTimeouts = map[string]time.Duration{
+	"Embed":       30 * time.Second,
+	"CountTokens": 10 * time.Second,
+	"ShowModel":   30 * time.Second,
+}
+
+// retryableWorkerMethods are safe to send to a worker again
+var retryableWorkerMethods = map[string]bool{
+	"Embed":       true,
+	"CountTokens": true,
+	"ShowModel":   true,
+}
+
+// newWorkerCallConfig merges timeout overrides (e.g. from
+// WORKER_CALL_TIMEOUTS) over the defaults. Unparseable durations are
+// ignored.
+func newWorkerCallConfig(timeouts map[string]string, retries int, backoff time.Duration) WorkerCallConfig {
+	cfg := WorkerCallConfig{
+		Timeouts:     make(map[string]time.Duration, len(defaultWorkerCallTimeouts)+len(timeouts)),
+		Retries:      max(retries, 0),
+		RetryBackoff: backoff,
+	}
+	for method, d := range defaultWorkerCallTimeouts {
+		cfg.Timeouts[method] = d
+	}
+	for method, value := range timeouts {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			cfg.Timeouts[method] = d
+		}
+	}
+	return cfg
+}
+
+// callTimeout is a call option setting the call's timeout, overriding its
+// method's
+type callTimeout struct {
+	grpc.EmptyCallOption
+	d time.Duration
+}
+
+// timeoutOf returns the timeout set by a callTimeout option, else the
+// method's
+func (c WorkerCallConfig) timeoutOf(method string, opts []grpc.CallOption) time.Duration {
+	for _, opt := range opts {
+		if t, ok := opt.(callTimeout); ok {
+			return t.d
+		}
+	}
+	return c.Timeouts[path.Base(method)]
+}
+
+// workerDialOptions returns the interceptor chain of calls to a worker, from
+// the outermost: tracing, so a call's span covers its retries; metrics and
+// the worker's stats; request metadata; the timeout; and retries, which
+// share the timeout.
+func (g *Gateway) workerDialOptions(stats *workerStats) []grpc.DialOption {
+	cfg := g.workerCalls
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(
+			tracing.UnaryClientInterceptor(),
+			g.unaryMetricsInterceptor(stats),
+			reqmeta.UnaryClientInterceptor(),
+			cfg.unaryTimeoutInterceptor(),
+			cfg.unaryRetryInterceptor(),
+		),
+		grpc.WithChainStreamInterceptor(
+			tracing.StreamClientInterceptor(),
+			g.streamMetricsInterceptor(stats),
+			reqmeta.StreamClientInterceptor(),
+			cfg.streamTimeoutInterceptor(),
+		),
+	}
+}
+
+// recordWorkerCall records a finished call's status and duration, counting
+// calls that serve requests in the worker's stats
+func (g *Gateway) recordWorkerCall(stats *workerStats, method string, d time.Duration, err error) {
+	method = path.Base(method)
+	g.metrics.RecordWorkerCall(method, status.Code(err).String(), d.Seconds())
+	if servingMethods[method] {
+		stats.record(d, err)
+	}
+}
+
+// unaryMetricsInterceptor records each call's status and duration
+func (g *Gateway) unaryMetricsInterceptor(stats *workerStats) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		g.recordWorkerCall(stats, method, time.Since(start), err)
+		return err
+	}
+}
+
+// streamMetricsInterceptor records each stream's status and duration when
+// it ends, or when its context is done if the caller stops reading first
+func (g *Gateway) streamMetricsInterceptor(stats *workerStats) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		var once sync.Once
+		record := func(err error) {
+			once.Do(func() { g.recordWorkerCall(stats, method, time.Since(start), err) })
+		}
+
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			record(err)
+			return nil, err
+		}
+		stop := context.AfterFunc(ctx, func() { record(status.FromContextError(ctx.Err()).Err()) })
+		return &observedStream{ClientStream: cs, done: func(err error) {
+			stop()
+			record(err)
+		}}, nil
+	}
+}
+
+// unaryTimeoutInterceptor applies the call's timeout
+func (c WorkerCallConfig) unaryTimeoutInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if d := c.timeoutOf(method, opts); d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// streamTimeoutInterceptor applies the stream's timeout, which covers
+// reading the whole stream
+func (c WorkerCallConfig) streamTimeoutInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		d := c.timeoutOf(method, opts)
+		if d <= 0 {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, d)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		return &observedStream{ClientStream: cs, done: func(error) { cancel() }}, nil
+	}
+}
+
+// unaryRetryInterceptor retries idempotent calls the worker was unavailable
+// for
+func (c WorkerCallConfig) unaryRetryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if !retryableWorkerMethods[path.Base(method)] {
+			return err
+		}
+		for attempt := 0; attempt < c.Retries && status.Code(err) == codes.Unavailable; attempt++ {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(c.RetryBackoff):
+			}
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return err
+	}
+}
+
+// observedStream calls done once when the stream ends: when receiving
+// fails, with nil for a stream that ended normally
+type observedStream struct {
+	grpc.ClientStream
+	done func(error)
+	once sync.Once
+}
+
+func (s *observedStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		result := err
+		if err == io.EOF {
+			result = nil
+		}
+		s.once.Do(func() { s.done(result) })
+	}
+	return err
+}
+
+// timedOut reports whether a worker call failed for its deadline or its
+// caller's
+func timedOut(ctx context.Context, err error) bool {
+	return ctx.Err() == context.DeadlineExceeded || status.Code(err) == codes.DeadlineExceeded
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/logger"
+	"github.com/hugovillarreal/neurogate/pkg/scheduler"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// JobStatus is the lifecycle state of an async job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+// JobRequest is the REST API request body for POST /jobs
+type JobRequest struct {
+	PromptRequest
+	WebhookURL string `json:"webhook_url,omitempty"`
+}
+
+// Job is an asynchronously executed prompt request
+type Job struct {
+	ID          string          `json:"id"`
+	Status      JobStatus       `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	Result      *PromptResponse `json:"result,omitempty"`
+	Error       *ErrorResponse  `json:"error,omitempty"`
+
+	request    PromptRequest
+	webhookURL string
+	owner      string // hash of the submitting API key
+	authHeader string // charged for quota usage on completion
+
+	spanContext trace.SpanContext // trace of the submitting request, continued by the runner
+}
+
+// jobStore holds jobs in memory and runs them on a fixed pool of runners.
+// Job snapshots are also written to the gateway's store so finished jobs
+// stay queryable across restarts.
+type jobStore struct {
+	mu        sync.RWMutex
+	jobs      map[string]*Job
+	queue     chan *Job
+	webhooks  chan Job // Finished jobs waiting for webhook delivery
+	retention time.Duration
+}
+
+func newJobStore(queueSize int, retention time.Duration) *jobStore {
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+	if retention <= 0 {
+		retention = time.Hour
+	}
+	return &jobStore{
+		jobs:      make(map[string]*Job),
+		queue:     make(chan *Job, queueSize),
+		webhooks:  make(chan Job, queueSize),
+		retention: retention,
+	}
+}
+
+// enqueue registers a job and queues it, returning false if the queue is full
+func (s *jobStore) enqueue(job *Job) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case s.queue <- job:
+		s.jobs[job.ID] = job
+		return true
+	default:
+		return false
+	}
+}
+
+// get returns a snapshot of a job so callers can't race with runners
+func (s *jobStore) get(id string) (Job, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// update applies fn to a job under the store lock
+func (s *jobStore) update(job *Job, fn func(*Job)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(job)
+}
+
+// restore adds a finished job loaded from storage
+func (s *jobStore) restore(job *Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+}
+
+// expire removes finished jobs older than the retention period and returns
+// their IDs
+func (s *jobStore) expire() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []string
+	cutoff := time.Now().Add(-s.retention)
+	for id, job := range s.jobs {
+		if job.CompletedAt != nil && job.CompletedAt.Before(cutoff) {
+			delete(s.jobs, id)
+			expired = append(expired, id)
+		}
+	}
+	return expired
+}
+
+// startJobRunners starts the goroutines that execute queued jobs, and as
+// many delivering their webhooks so slow webhooks don't hold up jobs
+func (g *Gateway) startJobRunners(n int) {
+	if n <= 0 {
+		n = 4
+	}
+	for i := 0; i < n; i++ {
+		go func() {
+			for job := range g.jobs.queue {
+				g.metrics.SetQueueDepth(len(g.jobs.queue))
+				g.runJob(job)
+			}
+		}()
+		go func() {
+			for job := range g.jobs.webhooks {
+				g.deliverWebhook(job)
+			}
+		}()
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			for _, id := range g.jobs.expire() {
+				g.deleteJob(id)
+			}
+		}
+	}()
+}
+
+// runJob executes a job and queues its webhook, if any
+func (g *Gateway) runJob(job *Job) {
+	started := time.Now()
+	// The job is shared with readers copying it under the store's lock, so
+	// the prompt is run from a copy of its request
+	req := job.request
+	req.queueWait = started.Sub(job.CreatedAt)
+	g.metrics.RecordQueueWait(req.queueWait.Seconds())
+	g.jobs.update(job, func(j *Job) {
+		j.Status = JobRunning
+		j.StartedAt = &started
+	})
+	g.saveJob(job.ID)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), job.spanContext)
+	ctx = logger.ToContext(ctx, g.log.WithRequestID(job.ID))
+	resp, err := g.runPrompt(ctx, job.ID, &req, job.authHeader, g.fallbackFor("/jobs", job.authHeader))
+
+	completed := time.Now()
+	g.jobs.update(job, func(j *Job) {
+		j.CompletedAt = &completed
+		if err != nil {
+			errResp := toAPIError(err).response()
+			j.Status = JobFailed
+			j.Error = &errResp
+			return
+		}
+		j.Status = JobCompleted
+		j.Result = resp
+	})
+	g.saveJob(job.ID)
+
+	if err == nil {
+		g.recordQuota(nil, job.authHeader, billableTokens(resp))
+		g.recordCost(nil, job.authHeader, resp)
+		g.recordHistory(job.authHeader, promptHistory("/jobs", resp), req.Query, resp.Response)
+	} else {
+		apiErr := toAPIError(err)
+		g.recordHistory(job.authHeader, HistoryRecord{
+			RequestID: job.ID,
+			Endpoint:  "/jobs",
+			Model:     req.Model,
+			Status:    apiErr.Status,
+			Error:     apiErr.Message,
+			LatencyMs: completed.Sub(started).Milliseconds(),
+		}, req.Query, "")
+	}
+
+	if job.webhookURL != "" {
+		snapshot, _ := g.jobs.get(job.ID)
+		select {
+		case g.jobs.webhooks <- snapshot:
+		default:
+			g.log.Warn("job webhook queue full, dropping delivery", "job_id", job.ID, "webhook_url", job.webhookURL)
+		}
+	}
+}
+
+// deliverWebhook POSTs the finished job to its webhook URL
+func (g *Gateway) deliverWebhook(job Job) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return
+	}
+	g.postWebhook(job.webhookURL, body, g.log.With("job_id", job.ID, "webhook_url", job.webhookURL))
+}
+
+// handleCreateJob handles POST /jobs
+func (g *Gateway) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	authHeader := r.Header.Get("Authorization")
+	var req JobRequest
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		g.writeAPIError(w, apiErr)
+		return
+	}
+	fields := g.limits.validatePrompt(&req.PromptRequest)
+	if req.WebhookURL != "" && !validWebhookURL(req.WebhookURL) {
+		fields = append(fields, FieldError{Field: "webhook_url", Message: "must be an http(s) URL"})
+	}
+	if len(fields) > 0 {
+		g.writeAPIError(w, invalidRequest(fields...))
+		return
+	}
+
+	req.Model = g.modelAliases.resolve(req.Model)
+
+	if err := req.validateContext(); err != nil {
+		g.writeAPIError(w, invalidRequest(FieldError{Field: "context", Message: err.Error()}))
+		return
+	}
+
+	if err := g.modelPolicies.apply(&req.PromptRequest); err != nil {
+		g.writeAPIError(w, toAPIError(err))
+		return
+	}
+
+	if err := g.checkPrompt(g.guardrailsFor(authHeader), &req.PromptRequest); err != nil {
+		g.writeAPIError(w, toAPIError(err))
+		return
+	}
+
+	// Jobs are bulk work unless they ask otherwise
+	if req.Priority == "" {
+		req.Priority = scheduler.Batch.String()
+	}
+
+	job := &Job{
+		ID:         newJobID(),
+		Status:     JobQueued,
+		CreatedAt:  time.Now(),
+		request:    req.PromptRequest,
+		webhookURL: req.WebhookURL,
+		owner:      g.ownerOf(authHeader),
+		authHeader: authHeader,
+
+		spanContext: trace.SpanContextFromContext(r.Context()),
+	}
+
+	if !g.jobs.enqueue(job) {
+		g.metrics.RecordShed("queue_full")
+		g.writeAPIError(w, &apiError{Status: http.StatusServiceUnavailable, Message: "job queue full", Detail: "retry later", Code: CodeOverloaded})
+		return
+	}
+	g.metrics.SetQueueDepth(len(g.jobs.queue))
+
+	g.log.Info("job queued", "job_id", job.ID)
+	g.saveJob(job.ID)
+
+	snapshot, _ := g.jobs.get(job.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// handleGetJob handles GET /jobs/{id}
+func (g *Gateway) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	authHeader := r.Header.Get("Authorization")
+	job, ok := g.jobs.get(r.PathValue("id"))
+	// Jobs are only visible to the key that submitted them
+	if !ok || job.owner != g.ownerOf(authHeader) {
+		g.writeError(w, http.StatusNotFound, "job not found", "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// newJobID returns a random job identifier
+func newJobID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "job-" + hex.EncodeToString(b)
+}
+
+// hashOwner derives a non-reversible owner tag from a caller's identity
+func hashOwner(identity string) string {
+	sum := sha256.Sum256([]byte(identity))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/quota"
+	"github.com/hugovillarreal/neurogate/pkg/scheduler"
+)
+
+// accessConfigVersion is the current version of the AccessConfig document
+const accessConfigVersion = 1
+
+// maxTenantLength bounds a key's tenant, which becomes a metric label
+const maxTenantLength = 64
+
+// keyStore holds the API keys accepted by the gateway. Keys start from
+// API_KEYS and can be replaced at runtime through the admin import endpoint.
+// Keys and their policies are held by keyHash.
+type keyStore struct {
+	mu           sync.RWMutex
+	keys         map[string]bool
+	fallback     map[string][]string // per-key degradation strategies
+	alertWebhook map[string]string   // per-key usage alert webhooks
+	tenant       map[string]string   // per-key tenant for usage metrics
+	guardrails   map[string]string   // per-key guardrail policy names
+	priority     map[string]string   // per-key highest scheduling priority
+	weight       map[string]float64  // per-key fair queuing weights
+}
+
+func newKeyStore(keys []string) *keyStore {
+	hashes := make(map[string]bool)
+	for key := range parseKeys(keys) {
+		hashes[keyHash(key)] = true
+	}
+	return &keyStore{
+		keys:         hashes,
+		fallback:     make(map[string][]string),
+		alertWebhook: make(map[string]string),
+		tenant:       make(map[string]string),
+		guardrails:   make(map[string]string),
+		priority:     make(map[string]string),
+		weight:       make(map[string]float64),
+	}
+}
+
+// enabled reports whether API key authentication is required
+func (s *keyStore) enabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.keys) > 0
+}
+
+// valid checks a "Bearer <token>" header against the stored keys
+func (s *keyStore) valid(authHeader string) bool {
+	token := bearerToken(authHeader)
+	if token == "" {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[keyHash(token)]
+}
+
+// has reports whether the key with the given hash is stored
+func (s *keyStore) has(hash string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[hash]
+}
+
+// fallbackFor returns the key's degradation strategies, if it has its own
+func (s *keyStore) fallbackFor(hash string) ([]string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	strategies, ok := s.fallback[hash]
+	return strategies, ok
+}
+
+// alertWebhookFor returns the key's usage alert webhook, if it has one
+func (s *keyStore) alertWebhookFor(hash string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	url, ok := s.alertWebhook[hash]
+	return url, ok
+}
+
+// tenantFor returns the key's tenant, or "" if it has none
+func (s *keyStore) tenantFor(hash string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tenant[hash]
+}
+
+// guardrailsFor returns the key's guardrail policy name, if it has its own
+func (s *keyStore) guardrailsFor(hash string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	name, ok := s.guardrails[hash]
+	return name, ok
+}
+
+// priorityFor returns the key's highest scheduling priority, if it has one
+func (s *keyStore) priorityFor(hash string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	name, ok := s.priority[hash]
+	return name, ok
+}
+
+// weightFor returns the key's fair queuing weight, if it has its own
+func (s *keyStore) weightFor(hash string) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	weight, ok := s.weight[hash]
+	return weight, ok
+}
+
+// setAlertWebhook sets the key's usage alert webhook; an empty url removes it
+func (s *keyStore) setAlertWebhook(hash, url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if url == "" {
+		delete(s.alertWebhook, hash)
+		return
+	}
+	s.alertWebhook[hash] = url
+}
+
+// list returns the hashes of all keys in sorted order
+func (s *keyStore) list() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hashes := make([]string, 0, len(s.keys))
+	for h := range s.keys {
+		hashes = append(hashes, h)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// AccessConfig is the document exchanged by the key import/export endpoints
+type AccessConfig struct {
+	Version    int         `json:"version"`
+	ExportedAt *time.Time  `json:"exported_at,omitempty"`
+	Keys       []KeyPolicy `json:"keys"`
+}
+
+// KeyPolicy is an API key and its access policy. Imports name the key or
+// its hash; exports and the store only hold the hash.
+type KeyPolicy struct {
+	Key      string        `json:"key,omitempty"`
+	KeyHash  string        `json:"key_hash,omitempty"` // Hex SHA-256 of the key
+	Quota    *quota.Limits `json:"quota,omitempty"`    // nil uses the default quota
+	Fallback *[]string     `json:"fallback,omitempty"` // nil uses FALLBACK_STRATEGIES; empty disables fallback
+
+	AlertWebhook string `json:"alert_webhook,omitempty"` // Receives usage alerts for this key
+	Tenant       string `json:"tenant,omitempty"`        // Groups the key's usage metrics; empty uses the key ID
+	Guardrails   string `json:"guardrails,omitempty"`    // Guardrail policy name; empty uses GUARDRAIL_DEFAULT_POLICY
+
+	Priority string  `json:"priority,omitempty"` // Highest scheduling priority, "interactive" or "batch"; empty is interactive
+	Weight   float64 `json:"weight,omitempty"`   // Fair queuing weight against other keys and tenants; 0 is 1
+}
+
+// ImportResult summarizes the changes made (or that would be made) by an import
+type ImportResult struct {
+	Created   int  `json:"created"`
+	Updated   int  `json:"updated"`
+	Unchanged int  `json:"unchanged"`
+	Removed   int  `json:"removed"`
+	DryRun    bool `json:"dry_run"`
+
+	// Hashes of the keys affected, for the audit log
+	created, updated, removed []string
+}
+
+// hash returns the keyHash of the policy's key
+func (p KeyPolicy) hash() string {
+	if p.Key != "" {
+		return keyHash(p.Key)
+	}
+	return p.KeyHash
+}
+
+// handleExportKeys handles GET /admin/keys/export
+func (g *Gateway) handleExportKeys(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	doc := AccessConfig{
+		Version:    accessConfigVersion,
+		ExportedAt: &now,
+		Keys:       g.keyPolicies(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="neurogate-keys.json"`)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(doc)
+}
+
+// keyPolicies returns every API key's hash with its policy, sorted by hash
+func (g *Gateway) keyPolicies() []KeyPolicy {
+	policies := make([]KeyPolicy, 0)
+	for _, hash := range g.apiKeys.list() {
+		policy := KeyPolicy{KeyHash: hash}
+		if limits, ok := g.quota.Override(hash); ok {
+			policy.Quota = &limits
+		}
+		if strategies, ok := g.apiKeys.fallbackFor(hash); ok {
+			policy.Fallback = &strategies
+		}
+		policy.AlertWebhook, _ = g.apiKeys.alertWebhookFor(hash)
+		policy.Tenant = g.apiKeys.tenantFor(hash)
+		policy.Guardrails, _ = g.apiKeys.guardrailsFor(hash)
+		policy.Priority, _ = g.apiKeys.priorityFor(hash)
+		policy.Weight, _ = g.apiKeys.weightFor(hash)
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// handleImportKeys handles POST /admin/keys/import. Keys in the document are
+// created or updated; importing the same document twice changes nothing.
+// With ?mode=replace, keys missing from the document are removed.
+// With ?dry_run=true, the changes are reported but not applied.
+func (g *Gateway) handleImportKeys(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = "merge"
+	}
+	if mode != "merge" && mode != "replace" {
+		g.writeError(w, http.StatusBadRequest, "invalid mode", "must be merge or replace")
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	// Unknown fields are rejected, as a misspelled one would otherwise
+	// reset a replaced key's setting to its default
+	var doc AccessConfig
+	if apiErr := decodeBody(r, &doc); apiErr != nil {
+		g.writeAPIError(w, apiErr)
+		return
+	}
+	if err := validateAccessConfig(&doc); err != nil {
+		g.writeError(w, http.StatusBadRequest, "invalid access config", err.Error())
+		return
+	}
+	if err := g.validateGuardrailPolicies(&doc); err != nil {
+		g.writeError(w, http.StatusBadRequest, "invalid access config", err.Error())
+		return
+	}
+
+	// An empty key set disables authentication entirely, so never let a
+	// replace import get there by accident
+	if mode == "replace" && len(doc.Keys) == 0 {
+		g.writeError(w, http.StatusBadRequest, "refusing to remove all keys", "replace import must contain at least one key")
+		return
+	}
+
+	result := g.importKeys(doc, mode == "replace", dryRun)
+	if !dryRun {
+		g.saveKeys()
+		g.auditKeyChanges(r, result)
+	}
+
+	g.log.Info("access config imported",
+		"mode", mode,
+		"dry_run", dryRun,
+		"created", result.Created,
+		"updated", result.Updated,
+		"unchanged", result.Unchanged,
+		"removed", result.Removed,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// importKeys applies an access config under the key store lock so that
+// concurrent imports don't interleave
+func (g *Gateway) importKeys(doc AccessConfig, replace, dryRun bool) ImportResult {
+	s := g.apiKeys
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := ImportResult{DryRun: dryRun}
+	seen := make(map[string]bool, len(doc.Keys))
+
+	for _, policy := range doc.Keys {
+		hash := policy.hash()
+		seen[hash] = true
+		current, hasOverride := g.quota.Override(hash)
+		fallback, hasFallback := s.fallback[hash]
+
+		switch {
+		case !s.keys[hash]:
+			result.Created++
+			result.created = append(result.created, hash)
+		case quotaChanged(current, hasOverride, policy.Quota),
+			fallbackChanged(fallback, hasFallback, policy.Fallback),
+			s.alertWebhook[hash] != policy.AlertWebhook,
+			s.tenant[hash] != policy.Tenant,
+			s.guardrails[hash] != policy.Guardrails,
+			s.priority[hash] != policy.Priority,
+			s.weight[hash] != policy.Weight:
+			result.Updated++
+			result.updated = append(result.updated, hash)
+		default:
+			result.Unchanged++
+			continue
+		}
+
+		if dryRun {
+			continue
+		}
+		s.keys[hash] = true
+		if policy.Quota != nil {
+			g.quota.SetLimits(hash, *policy.Quota)
+		} else {
+			g.quota.ClearLimits(hash)
+		}
+		if policy.Fallback != nil {
+			s.fallback[hash] = append([]string{}, *policy.Fallback...)
+		} else {
+			delete(s.fallback, hash)
+		}
+		if policy.AlertWebhook != "" {
+			s.alertWebhook[hash] = policy.AlertWebhook
+		} else if _, ok := s.alertWebhook[hash]; ok {
+			delete(s.alertWebhook, hash)
+			g.anomalies.Forget(hash)
+		}
+		if policy.Tenant != "" {
+			s.tenant[hash] = policy.Tenant
+		} else {
+			delete(s.tenant, hash)
+		}
+		if policy.Guardrails != "" {
+			s.guardrails[hash] = policy.Guardrails
+		} else {
+			delete(s.guardrails, hash)
+		}
+		if policy.Priority != "" {
+			s.priority[hash] = policy.Priority
+		} else {
+			delete(s.priority, hash)
+		}
+		if policy.Weight > 0 {
+			s.weight[hash] = policy.Weight
+		} else {
+			delete(s.weight, hash)
+		}
+	}
+
+	if replace {
+		for hash := range s.keys {
+			if seen[hash] {
+				continue
+			}
+			result.Removed++
+			result.removed = append(result.removed, hash)
+			if !dryRun {
+				delete(s.keys, hash)
+				delete(s.fallback, hash)
+				delete(s.alertWebhook, hash)
+				delete(s.tenant, hash)
+				delete(s.guardrails, hash)
+				delete(s.priority, hash)
+				delete(s.weight, hash)
+				g.quota.ClearLimits(hash)
+				g.anomalies.Forget(hash)
+			}
+		}
+	}
+
+	return result
+}
+
+// quotaChanged reports whether an imported quota differs from the current one
+func quotaChanged(current quota.Limits, hasOverride bool, imported *quota.Limits) bool {
+	if imported == nil {
+		return hasOverride
+	}
+	return !hasOverride || current != *imported
+}
+
+// fallbackChanged reports whether imported fallback strategies differ from the
+// current ones
+func fallbackChanged(current []string, hasFallback bool, imported *[]string) bool {
+	if imported == nil {
+		return hasFallback
+	}
+	return !hasFallback || !slices.Equal(current, *imported)
+}
+
+// validateAccessConfig rejects documents that can't be applied cleanly
+func validateAccessConfig(doc *AccessConfig) error {
+	if doc.Version != accessConfigVersion {
+		return fmt.Errorf("unsupported version %d, expected %d", doc.Version, accessConfigVersion)
+	}
+
+	seen := make(map[string]bool, len(doc.Keys))
+	for i, policy := range doc.Keys {
+		switch {
+		case policy.Key == "" && policy.KeyHash == "":
+			return fmt.Errorf("keys[%d]: key or key_hash is required", i)
+		case policy.Key != "" && policy.KeyHash != "":
+			return fmt.Errorf("keys[%d]: only one of key and key_hash may be set", i)
+		case policy.KeyHash != "" && !isKeyHash(policy.KeyHash):
+			return fmt.Errorf("keys[%d]: key_hash must be a lowercase hex SHA-256", i)
+		}
+		hash := policy.hash()
+		if seen[hash] {
+			return fmt.Errorf("keys[%d]: duplicate key %s", i, hashID(hash))
+		}
+		seen[hash] = true
+
+		if q := policy.Quota; q != nil && (q.Requests < 0 || q.Tokens < 0) {
+			return fmt.Errorf("keys[%d]: quota limits must not be negative", i)
+		}
+		if policy.Fallback != nil {
+			for _, strategy := range *policy.Fallback {
+				if !validFallbackStrategy(strategy) {
+					return fmt.Errorf("keys[%d]: unknown fallback strategy %q", i, strategy)
+				}
+			}
+		}
+		if policy.AlertWebhook != "" && !validWebhookURL(policy.AlertWebhook) {
+			return fmt.Errorf("keys[%d]: alert_webhook must be an http(s) URL", i)
+		}
+		if len(policy.Tenant) > maxTenantLength {
+			return fmt.Errorf("keys[%d]: tenant must be at most %d characters", i, maxTenantLength)
+		}
+		if policy.Priority != "" {
+			if _, err := scheduler.ParsePriority(policy.Priority); err != nil {
+				return fmt.Errorf("keys[%d]: priority must be interactive or batch", i)
+			}
+		}
+		if policy.Weight < 0 {
+			return fmt.Errorf("keys[%d]: weight must not be negative", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+)
+
+// contextHandleVersion prefixes context handles so the encoding can change
+const contextHandleVersion = 1
+
+// maxContextTokens bounds the context a handle may carry
+const maxContextTokens = 1 << 18
+
+// encodeContext packs Ollama context tokens into an opaque handle for
+// clients. The handle records the model, since the tokens mean nothing to
+// any other.
+func encodeContext(model string, tokens []int32) string {
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+len(model)+len(tokens)*3)
+	buf = append(buf, contextHandleVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(model)))
+	buf = append(buf, model...)
+	for _, t := range tokens {
+		buf = binary.AppendUvarint(buf, uint64(uint32(t)))
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// decodeContext unpacks a handle made by encodeContext
+func decodeContext(handle string) (model string, tokens []int32, err error) {
+	buf, err := base64.RawURLEncoding.DecodeString(handle)
+	if err != nil || len(buf) == 0 || buf[0] != contextHandleVersion {
+		return "", nil, fmt.Errorf("malformed context handle")
+	}
+	buf = buf[1:]
+
+	n, size := binary.Uvarint(buf)
+	if size <= 0 || n > uint64(len(buf)-size) {
+		return "", nil, fmt.Errorf("malformed context handle")
+	}
+	model = string(buf[size : size+int(n)])
+	buf = buf[size+int(n):]
+
+	for len(buf) > 0 {
+		t, size := binary.Uvarint(buf)
+		if size <= 0 || t > 1<<31-1 {
+			return "", nil, fmt.Errorf("malformed context handle")
+		}
+		if len(tokens) == maxContextTokens {
+			return "", nil, fmt.Errorf("context exceeds %d tokens", maxContextTokens)
+		}
+		tokens = append(tokens, int32(t))
+		buf = buf[size:]
+	}
+	return model, tokens, nil
+}
+
+// validateContext checks a request's context handle, defaulting the model
+// to the one the context belongs to. Context tokens continue a single
+// generation, so they can't be mixed with a gateway-managed conversation.
+func (r *PromptRequest) validateContext() error {
+	if r.ConversationID != "" && (r.Context != "" || r.ReturnContext) {
+		return fmt.Errorf("context can't be combined with conversation_id")
+	}
+	if r.Context == "" {
+		return nil
+	}
+
+	model, _, err := decodeContext(r.Context)
+	if err != nil {
+		return err
+	}
+	if r.Model == "" {
+		r.Model = model
+	} else if r.Model != model {
+		return fmt.Errorf("context belongs to model %q", model)
+	}
+	return nil
+}
+
+// responseContext returns the context handle for a worker response, if the
+// request asked for one
+func responseContext(req *PromptRequest, resp *llmv1.PromptResponse) string {
+	if !req.ReturnContext || len(resp.Context) == 0 {
+		return ""
+	}
+	return encodeContext(resp.Model, resp.Context)
+}
+
+// contextTokens returns the tokens of a validated request's context handle
+func (r *PromptRequest) contextTokens() []int32 {
+	if r.Context == "" {
+		return nil
+	}
+	_, tokens, _ := decodeContext(r.Context)
+	return tokens
+}
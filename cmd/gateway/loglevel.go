@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// LogLevelStatus reports the gateway's current log level
+type LogLevelStatus struct {
+	Level string `json:"level"`
+}
+
+// handleGetLogLevel handles GET /admin/log-level
+func (g *Gateway) handleGetLogLevel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LogLevelStatus{Level: strings.ToLower(g.log.Level().String())})
+}
+
+// handleSetLogLevel handles PUT /admin/log-level. The change lasts until the
+// next change or restart.
+func (g *Gateway) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req LogLevelStatus
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		g.writeAPIError(w, apiErr)
+		return
+	}
+	from := g.log.Level()
+	if err := g.log.SetLevel(req.Level); err != nil {
+		g.writeError(w, http.StatusBadRequest, "invalid log level", err.Error())
+		return
+	}
+	g.log.Warn("log level changed", "from", strings.ToLower(from.String()), "level", strings.ToLower(g.log.Level().String()), "trigger", "admin")
+
+	g.handleGetLogLevel(w, r)
+}
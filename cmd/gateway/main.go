@@ -4,11 +4,19 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -16,13 +24,37 @@ import (
 	"time"
 
 	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+	"github.com/hugovillarreal/neurogate/pkg/anomaly"
+	"github.com/hugovillarreal/neurogate/pkg/audit"
+	"github.com/hugovillarreal/neurogate/pkg/cache"
 	"github.com/hugovillarreal/neurogate/pkg/circuitbreaker"
+	"github.com/hugovillarreal/neurogate/pkg/guardrails"
 	"github.com/hugovillarreal/neurogate/pkg/health"
+	"github.com/hugovillarreal/neurogate/pkg/jwt"
+	"github.com/hugovillarreal/neurogate/pkg/listener"
 	"github.com/hugovillarreal/neurogate/pkg/logger"
 	"github.com/hugovillarreal/neurogate/pkg/metrics"
-
+	"github.com/hugovillarreal/neurogate/pkg/moderation"
+	"github.com/hugovillarreal/neurogate/pkg/quota"
+	"github.com/hugovillarreal/neurogate/pkg/ratelimit"
+	"github.com/hugovillarreal/neurogate/pkg/redact"
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+	"github.com/hugovillarreal/neurogate/pkg/reqmeta"
+	"github.com/hugovillarreal/neurogate/pkg/requestid"
+	"github.com/hugovillarreal/neurogate/pkg/scheduler"
+	"github.com/hugovillarreal/neurogate/pkg/session"
+	"github.com/hugovillarreal/neurogate/pkg/signing"
+	"github.com/hugovillarreal/neurogate/pkg/store"
+	"github.com/hugovillarreal/neurogate/pkg/tracing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -31,14 +63,47 @@ const (
 	version            = "1.0.0"
 )
 
+// Backends of the rate limit, quota, response cache and worker state stores
+const (
+	backendMemory = "memory"
+	backendRedis  = "redis"
+)
+
 // Worker represents a backend worker node
 type Worker struct {
 	ID      string
 	Address string
-	Conn    *grpc.ClientConn
+	Conn    *workerConns
 	Client  llmv1.LLMServiceClient
+	Admin   llmv1.ModelAdminServiceClient
 	CB      *circuitbreaker.CircuitBreaker
 	Healthy atomic.Bool
+
+	// Verifies result signatures when configured (nil otherwise)
+	PublicKey ed25519.PublicKey
+
+	// Estimated clock offset from the gateway in milliseconds, updated by
+	// health checks, and whether it exceeds the configured threshold
+	ClockSkewMs atomic.Int64
+	ClockSkewed atomic.Bool
+
+	// Models reported by the worker's last successful health check, and
+	// those loaded into memory
+	Models       atomic.Pointer[[]string]
+	LoadedModels atomic.Pointer[[]*llmv1.LoadedModel]
+
+	// Capacity reported by the worker's last successful health check, and
+	// requests the gateway has in flight to it
+	Capacity atomic.Pointer[workerCapacity]
+	Inflight atomic.Int32
+
+	// Calls made to the worker, and when it was last probed
+	Stats           *workerStats
+	LastHealthCheck atomic.Pointer[time.Time]
+
+	// Consecutive probe results, owned by the worker's probe goroutine
+	probeFailures  int
+	probeSuccesses int
 }
 
 // Gateway is the main load balancer
@@ -47,12 +112,229 @@ type Gateway struct {
 	metrics       *metrics.Metrics
 	healthChecker *health.Checker
 
+	// The API's routes and middleware
+	handler http.Handler
+
 	mu          sync.RWMutex
 	workers     []*Worker
 	workerIndex atomic.Uint32
 
 	// API Key validation
-	apiKeys map[string]bool
+	apiKeys   *keyStore
+	adminKeys map[string]bool
+
+	// Response caches (nil when disabled), and the Redis client they share
+	// between replicas (nil when kept in memory)
+	cache         cache.Store
+	semanticCache cache.SemanticStore
+	embedModel    string
+	cacheRedis    *redis.Client
+
+	// In-flight requests, observable via the admin API
+	inflight *inflightTracker
+
+	// Async jobs
+	jobs          *jobStore
+	webhookSecret string
+
+	// Webhook delivery: hosts that may be on internal addresses use
+	// allowedWebhookClient, others the client refusing them
+	webhookHosts         map[string]bool
+	webhookClient        *http.Client
+	allowedWebhookClient *http.Client
+
+	// Reject responses that don't follow the requested output format
+	validateFormat bool
+
+	// Guardrail policies by name, and the one used by keys without their own
+	guardrails        guardrails.Policies
+	defaultGuardrails string
+
+	// Classifier moderation; moderator is nil when disabled
+	moderator        *moderation.Moderator
+	moderation       ModerationConfig
+	moderationStages map[guardrails.Stage]bool
+	moderationWorker *Worker // nil when classifying on the pool
+
+	// Conversation histories by owner and conversation ID, and whether a
+	// conversation's requests are pinned to one worker
+	sessions             session.Store
+	conversationAffinity bool
+
+	// Result signature verification
+	workerPublicKeys  map[string]string
+	requireSignatures bool
+
+	// Per-key quotas. Keys without a default or per-key limit are unlimited.
+	quota           *quota.Manager
+	quotaWebhookURL string
+
+	// Tenants: JWT authentication, and quotas and rate limits shared by
+	// every key of a tenant. jwt is nil when JWTs aren't accepted.
+	jwt         *jwt.Verifier
+	jwtClaim    string
+	tenantQuota *quota.Manager
+	tenantRates *ratelimit.Limiter
+
+	// Shares quota usage and rate limits between replicas; nil keeps them
+	// in memory
+	limitsRedis *redis.Client
+
+	// Usage anomaly detection for keys with an alert webhook
+	anomalies *anomaly.Detector
+
+	// Persistence for keys, jobs and quota usage
+	store     store.Store
+	keySaveMu sync.Mutex
+
+	// Security event log (nil when disabled)
+	auditLog *audit.Log
+
+	// Completed request history; 0 retention disables it. Bodies are kept
+	// only when historyContent is set.
+	historyRetention time.Duration
+	historyContent   *redact.Policy
+
+	// Workers whose clocks differ from the gateway by more than this are
+	// flagged; 0 disables the check
+	clockSkewThreshold time.Duration
+
+	// Worker health probing, and the failures shared with other gateways
+	// (nil when not shared)
+	workerHealth WorkerHealthConfig
+	workerSync   *workerSync
+	workerConn   WorkerConnConfig
+	workerCalls  WorkerCallConfig
+	limits       RequestLimits
+
+	// Circuit breakers by worker ID, optionally persisted to the store
+	breakers        *circuitbreaker.Registry
+	persistBreakers bool
+	breakerSaveMu   sync.Mutex
+
+	// Graceful degradation when no workers are available
+	fallbackStrategies []string
+	fallbackEndpoints  map[string]bool
+	emergencyWorker    *Worker // nil when not configured
+	emergencyModel     string
+	cloud              *cloudBackend // nil when not configured
+
+	// Queues prompts for workers by priority and fairly between keys and
+	// tenants; nil when disabled
+	scheduler        *scheduler.Scheduler
+	schedulerJobWait time.Duration
+
+	// Logical model names, and per-model timeouts and generation limits
+	modelAliases   modelAliases
+	modelFallbacks modelFallbacks
+	modelPolicies  modelPolicies
+
+	// Prices used to estimate request cost
+	pricing modelPricing
+
+	// Public status page state
+	latency  *latencyTracker
+	incident atomic.Pointer[Incident]
+}
+
+// Config holds gateway configuration
+type Config struct {
+	WorkerAddresses    []string
+	APIKeys            []string
+	AdminKeys          []string      // Keys allowed to call /admin endpoints; none disables them
+	CacheTTL           time.Duration // 0 disables the response cache
+	CacheMaxEntries    int
+	CacheMaxEntryBytes int    // Larger responses aren't cached; 0 is unlimited
+	CacheStore         string // Response cache backend: "memory" or "redis"
+
+	SemanticCacheThreshold float64 // Minimum cosine similarity for a hit; 0 disables
+	SemanticCacheModel     string  // Embedding model used for the semantic cache
+
+	WorkerPublicKeys  map[string]string // Base64 Ed25519 public keys by worker address or ID
+	RequireSignatures bool              // Reject unsigned results and workers without a key
+
+	ValidateFormat bool // Check responses against the request's JSON format or schema
+
+	GuardrailPolicies      map[string]string // Check names joined by "+" by policy name
+	DefaultGuardrailPolicy string            // Policy for keys without their own; "none" runs no checks
+	Guardrails             guardrails.Config // Settings of the built-in checks
+
+	Moderation ModerationConfig // Classifier moderation of prompts and responses
+
+	SessionStore string         // Conversation history backend: "memory" or "redis"
+	Sessions     session.Config // Conversation TTL and history limit
+	LimitsStore  string         // Rate limit and quota backend: "memory" or "redis"
+	RedisURL     string         // Redis server used by the "redis" session and limits stores
+
+	ConversationAffinity bool // Route each conversation's requests to the same worker while it is available
+
+	JobWorkers    int           // Number of concurrently running async jobs
+	JobQueueSize  int           // Maximum queued async jobs
+	JobRetention  time.Duration // How long finished jobs remain queryable
+	WebhookSecret string        // HMAC key for signing job, quota and alert webhooks; optional
+
+	WebhookAllowedHosts []string // Hosts job and alert webhooks may reach on internal addresses
+
+	QuotaRequests   int64         // Requests per key per window; 0 is unlimited
+	QuotaTokens     int64         // Tokens per key per window; 0 is unlimited
+	QuotaWindow     time.Duration // Length of the quota window
+	QuotaThresholds []float64     // Soft warning thresholds as fractions of the quota
+	QuotaWebhookURL string        // Receives soft quota warnings; optional
+
+	Tenants TenantConfig // JWT authentication and per-tenant limits
+
+	AlertInterval    time.Duration // Interval over which per-key usage is compared
+	AlertSpikeFactor float64       // Request increase over the usual rate that triggers an alert
+	AlertErrorRate   float64       // Error fraction that triggers an alert
+	AlertMinRequests int64         // Requests in an interval before alerts are considered
+	AlertCooldown    time.Duration // Minimum time between repeated alerts of one kind
+
+	UsageMetricsMaxConsumers int // Keys or tenants with their own usage series; the rest share "other"
+
+	Store store.Config // Backend persisting keys, jobs and quota usage
+
+	AuditLog     string // Audit log backend: "file", "store" or empty to disable
+	AuditLogFile string // Path of the file backend
+	AuditLogKey  string // HMAC key for audit record hashes; optional
+
+	HistoryRetention time.Duration  // How long completed request records are kept; 0 disables history
+	HistoryContent   *redact.Policy // Applied to stored prompt and response bodies; nil doesn't store them
+
+	ClockSkewThreshold time.Duration // Maximum tolerated worker clock skew; 0 disables
+
+	WorkerHealth WorkerHealthConfig // Worker probe schedule and thresholds
+	WorkerState  WorkerStateConfig  // Sharing of worker failures with other gateways
+	WorkerConn   WorkerConnConfig   // Keepalive and connections of worker clients
+	WorkerCalls  WorkerCallConfig   // Timeouts and retries of calls to workers
+	Limits       RequestLimits      // Size limits of request bodies
+
+	Scheduler SchedulerConfig // Priority classes and fair queuing of prompts sent to workers
+
+	// Configuration shared by every worker's circuit breaker. Breakers open
+	// on a failure rate over a sliding window when WindowSize or
+	// WindowDuration is set, else after FailureThreshold consecutive failures.
+	// HalfOpenMaxRequests limits concurrent recovery probes, and the open
+	// timeout grows by BackoffMultiplier each time a probe fails.
+	CircuitBreaker circuitbreaker.Config
+
+	PersistBreakers bool // Save breaker state to the store so restarts keep bad workers out
+
+	FallbackStrategies     []string      // Ordered strategies ("stale", "emergency", "cloud") used when no workers are available
+	FallbackEndpoints      []string      // Endpoints allowed to degrade
+	FallbackStaleTTL       time.Duration // How long past expiry cached responses may be served stale
+	EmergencyWorkerAddress string        // Worker used by the "emergency" strategy; optional
+	EmergencyModel         string        // Model used on the emergency worker; empty keeps the requested model
+	Cloud                  CloudConfig   // Provider used by the "cloud" strategy; optional
+
+	ModelAliases   map[string]string // Models by logical name; "default" is used when a request has none
+	ModelFallbacks map[string]string // Models tried in order when a model fails, e.g. "llama3.2:8b|llama3.2:1b"
+	ModelPolicies  ModelPolicyConfig // Per-model timeouts, max_tokens and temperature bounds
+
+	ModelPricing          map[string]string // USD per million prompt/completion tokens by model
+	ModelInferencePricing map[string]string // USD per second of worker inference time by model
+
+	HealthFailureThreshold int // Consecutive unhealthy runs before /health reports it
+	HealthSuccessThreshold int // Consecutive healthy runs before /health recovers
 }
 
 // PromptRequest is the REST API request body
@@ -62,6 +344,79 @@ type PromptRequest struct {
 	MaxTokens    int32   `json:"max_tokens,omitempty"`
 	Temperature  float32 `json:"temperature,omitempty"`
 	SystemPrompt string  `json:"system_prompt,omitempty"`
+	Sampling
+
+	// "json" for any JSON output, or a JSON schema object it must follow
+	Format json.RawMessage `json:"format,omitempty"`
+
+	// Continues a conversation: the gateway sends its earlier messages
+	// with the query and records the exchange
+	ConversationID string `json:"conversation_id,omitempty"`
+
+	// Continues from the context handle of an earlier response, letting
+	// Ollama skip re-evaluating that prompt. ReturnContext asks for the
+	// response's handle.
+	Context       string `json:"context,omitempty"`
+	ReturnContext bool   `json:"return_context,omitempty"`
+
+	// "interactive" or "batch", the class the scheduler queues the request
+	// in. Defaults to interactive for /prompt and batch for /jobs; a key's
+	// policy may lower it.
+	Priority string `json:"priority,omitempty"`
+
+	history   []session.Message // Set for conversation requests
+	queueWait time.Duration     // Set for jobs, to the time they were queued
+}
+
+// cacheable reports whether a request's response depends only on what the
+// cache key covers. Conversations and context depend on earlier turns, and
+// cached responses carry no context handle.
+func (r *PromptRequest) cacheable() bool {
+	return r.ConversationID == "" && r.Context == "" && !r.ReturnContext
+}
+
+// Sampling holds the generation parameters beyond temperature and max
+// tokens. Zero values use the model's defaults.
+type Sampling struct {
+	TopP          float32  `json:"top_p,omitempty"`
+	TopK          int32    `json:"top_k,omitempty"`
+	RepeatPenalty float32  `json:"repeat_penalty,omitempty"`
+	Seed          *int64   `json:"seed,omitempty"` // Random if unset
+	Stop          []string `json:"stop,omitempty"`
+	NumCtx        int32    `json:"num_ctx,omitempty"` // Context window size in tokens
+}
+
+// validate checks that the parameters are in range
+func (s *Sampling) validate() []FieldError {
+	var fields []FieldError
+	if s.TopP < 0 || s.TopP > 1 {
+		fields = append(fields, FieldError{Field: "top_p", Message: "must be between 0 and 1"})
+	}
+	if s.TopK < 0 {
+		fields = append(fields, FieldError{Field: "top_k", Message: "must not be negative"})
+	}
+	if s.RepeatPenalty < 0 {
+		fields = append(fields, FieldError{Field: "repeat_penalty", Message: "must not be negative"})
+	}
+	if s.NumCtx < 0 {
+		fields = append(fields, FieldError{Field: "num_ctx", Message: "must not be negative"})
+	}
+	if len(s.Stop) > maxStopSequences {
+		fields = append(fields, FieldError{Field: "stop", Message: fmt.Sprintf("must have at most %d sequences", maxStopSequences)})
+	}
+	return fields
+}
+
+// maxStopSequences limits the stop sequences of a request
+const maxStopSequences = 16
+
+// cacheKey encodes the parameters for a cache key, empty when none are set
+func (s *Sampling) cacheKey() string {
+	if s.TopP == 0 && s.TopK == 0 && s.RepeatPenalty == 0 && s.Seed == nil && len(s.Stop) == 0 && s.NumCtx == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(s)
+	return string(data)
 }
 
 // PromptResponse is the REST API response body
@@ -72,39 +427,343 @@ type PromptResponse struct {
 	Tokens    int32  `json:"tokens"`
 	LatencyMs int64  `json:"latency_ms"`
 	WorkerID  string `json:"worker_id"`
+	Cached    bool   `json:"cached"`
+	Degraded  string `json:"degraded,omitempty"` // "stale", "emergency" or "cloud" when served by a fallback
+	Cloud     bool   `json:"cloud,omitempty"`    // Served by the cloud provider
+	Usage     Usage  `json:"usage"`
+
+	FallbackFrom string `json:"fallback_from,omitempty"` // Requested model, when a fallback model served the request
+
+	ConversationID string `json:"conversation_id,omitempty"`
+	Context        string `json:"context,omitempty"` // With return_context
+
+	Parameters *GenerationParameters `json:"parameters,omitempty"`
+
+	Moderation *ModerationReport `json:"moderation,omitempty"` // Flagged content, when MODERATION_ACTION is "flag"
+}
+
+// GenerationParameters are the parameters a response was generated with,
+// including the seed the worker chose if the request had none. Sending them
+// with the same query and model reproduces the response.
+type GenerationParameters struct {
+	Temperature float32 `json:"temperature,omitempty"`
+	MaxTokens   int32   `json:"max_tokens,omitempty"`
+	Sampling
+}
+
+// generationParameters converts the parameters reported by a worker, which
+// is nil for workers that don't report them
+func generationParameters(p *llmv1.GenerationParameters) *GenerationParameters {
+	if p == nil {
+		return nil
+	}
+	seed := p.Seed
+	return &GenerationParameters{
+		Temperature: p.Temperature,
+		MaxTokens:   p.MaxTokens,
+		Sampling: Sampling{
+			TopP:          p.TopP,
+			TopK:          p.TopK,
+			RepeatPenalty: p.RepeatPenalty,
+			Seed:          &seed,
+			Stop:          p.Stop,
+			NumCtx:        p.NumCtx,
+		},
+	}
 }
 
 // ErrorResponse represents an API error
 type ErrorResponse struct {
 	Error   string `json:"error"`
-	Code    int    `json:"code"`
+	Code    int    `json:"code"` // HTTP status
 	Message string `json:"message,omitempty"`
+
+	// The kind of error, and whether sending the same request again may
+	// succeed
+	ErrorCode ErrorCode `json:"error_code"`
+	Retryable bool      `json:"retryable"`
+
+	Violation *guardrails.Violation `json:"violation,omitempty"`
+
+	// The request body's invalid fields, for 400s
+	Fields []FieldError `json:"fields,omitempty"`
 }
 
 // NewGateway creates a new gateway instance
-func NewGateway(log *logger.Logger, workerAddresses []string, apiKeys []string) (*Gateway, error) {
-	m := metrics.NewGatewayMetrics("neurogate_gateway")
+func NewGateway(log *logger.Logger, cfg Config) (*Gateway, error) {
+	m := metrics.NewBuilder("neurogate_gateway").
+		With(metrics.ComponentHTTP, metrics.ComponentRouting, metrics.ComponentCache, metrics.ComponentAdmission, metrics.ComponentUsage).
+		ConsumerLimit(cfg.UsageMetricsMaxConsumers).
+		Build()
 	h := health.NewChecker(version)
 
-	// Parse API keys into a map for O(1) lookup
-	keyMap := make(map[string]bool)
-	for _, key := range apiKeys {
-		if key != "" {
-			keyMap[key] = true
-		}
-	}
+	// Parse API keys into maps for O(1) lookup
+	adminKeyMap := parseKeys(cfg.AdminKeys)
 
 	g := &Gateway{
 		log:           log,
 		metrics:       m,
 		healthChecker: h,
 		workers:       make([]*Worker, 0),
-		apiKeys:       keyMap,
+		apiKeys:       newKeyStore(cfg.APIKeys),
+		adminKeys:     adminKeyMap,
+		inflight:      newInflightTracker(),
+
+		workerPublicKeys:  cfg.WorkerPublicKeys,
+		requireSignatures: cfg.RequireSignatures,
+
+		jobs:          newJobStore(cfg.JobQueueSize, cfg.JobRetention),
+		webhookSecret: cfg.WebhookSecret,
+
+		webhookHosts:         webhookHostSet(cfg.WebhookAllowedHosts, cfg.QuotaWebhookURL),
+		webhookClient:        newWebhookClient(true),
+		allowedWebhookClient: newWebhookClient(false),
+
+		validateFormat: cfg.ValidateFormat,
+
+		conversationAffinity: cfg.ConversationAffinity,
+
+		clockSkewThreshold: cfg.ClockSkewThreshold,
+		workerHealth:       cfg.WorkerHealth.withDefaults(),
+		workerConn:         cfg.WorkerConn,
+		workerCalls:        cfg.WorkerCalls,
+		limits:             cfg.Limits,
+
+		fallbackStrategies: cfg.FallbackStrategies,
+		fallbackEndpoints:  parseKeys(cfg.FallbackEndpoints),
+		emergencyModel:     cfg.EmergencyModel,
+
+		historyRetention: cfg.HistoryRetention,
+		historyContent:   cfg.HistoryContent,
+
+		pricing: newModelPricing(cfg.ModelPricing, cfg.ModelInferencePricing),
+		latency: newLatencyTracker(statusLatencyWindow),
+	}
+
+	// Every worker's breaker shares one configuration, and one collector
+	// exports them all
+	breakerCfg := cfg.CircuitBreaker
+	breakerCfg.IsFailure = isWorkerFailure
+	breakerCfg.OnStateChange = func(name string, from, to circuitbreaker.State) {
+		g.log.Info("circuit breaker state change",
+			"worker", name,
+			"from", from.String(),
+			"to", to.String(),
+		)
+		g.saveBreakers()
+		if g.scheduler != nil {
+			// Workers enter or leave rotation with their breakers
+			g.scheduler.Refresh()
+		}
+		if g.workerSync != nil {
+			switch to {
+			case circuitbreaker.StateOpen:
+				// Report what remains of the breaker's backed-off timeout
+				if remaining := g.breakers.Get(name).OpenRemaining(); remaining > 0 {
+					g.workerSync.report(name, reportBreakerOpen, remaining)
+				}
+			case circuitbreaker.StateClosed:
+				g.workerSync.withdraw(name, reportBreakerOpen)
+			}
+		}
+	}
+	g.breakers = circuitbreaker.NewRegistry(breakerCfg)
+	g.persistBreakers = cfg.PersistBreakers
+	prometheus.MustRegister(g.breakers.Collector("neurogate_gateway", "worker"))
+	g.registerAutoscalingMetrics()
+
+	if g.scheduler = g.newScheduler(cfg.Scheduler); g.scheduler != nil {
+		g.schedulerJobWait = cfg.Scheduler.JobMaxWait
+		g.registerSchedulerMetrics()
+		log.Info("scheduler enabled", "max_concurrency", cfg.Scheduler.MaxConcurrency, "max_queued", cfg.Scheduler.MaxQueued)
+	}
+
+	switch cfg.WorkerState.Store {
+	case "", backendMemory:
+	case backendRedis:
+		redisCfg, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid worker state store: %w", err)
+		}
+		g.workerSync = newWorkerSync(g, redis.New(redisCfg), cfg.WorkerState.SyncInterval)
+		log.Info("sharing worker failures through redis", "gateway", g.workerSync.id, "interval", g.workerSync.interval)
+	default:
+		return nil, fmt.Errorf("unknown worker state store %q", cfg.WorkerState.Store)
+	}
+
+	switch cfg.CacheStore {
+	case "", backendMemory:
+	case backendRedis:
+		redisCfg, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache store: %w", err)
+		}
+		g.cacheRedis = redis.New(redisCfg)
+		log.Info("redis response cache enabled")
+	default:
+		return nil, fmt.Errorf("unknown cache store %q", cfg.CacheStore)
+	}
+
+	if cfg.CacheTTL > 0 {
+		cacheCfg := cache.Config{
+			TTL:           cfg.CacheTTL,
+			MaxEntries:    cfg.CacheMaxEntries,
+			MaxEntryBytes: cfg.CacheMaxEntryBytes,
+			StaleTTL:      cfg.FallbackStaleTTL,
+			OnError:       g.cacheFailed,
+		}
+		if g.cacheRedis != nil {
+			g.cache = cache.NewRedis(g.cacheRedis, cacheCfg)
+		} else {
+			g.cache = cache.New(cacheCfg)
+		}
+		log.Info("response cache enabled", "ttl", cfg.CacheTTL, "max_entries", cfg.CacheMaxEntries)
+	}
+
+	if cfg.SemanticCacheThreshold > 0 {
+		semanticCfg := cache.SemanticConfig{
+			TTL:           cfg.CacheTTL,
+			MaxEntries:    cfg.CacheMaxEntries,
+			MaxEntryBytes: cfg.CacheMaxEntryBytes,
+			Threshold:     cfg.SemanticCacheThreshold,
+			OnError:       g.cacheFailed,
+		}
+		if g.cacheRedis != nil {
+			g.semanticCache = cache.NewRedisSemantic(g.cacheRedis, semanticCfg)
+		} else {
+			g.semanticCache = cache.NewSemantic(semanticCfg)
+		}
+		g.embedModel = cfg.SemanticCacheModel
+		log.Info("semantic cache enabled",
+			"threshold", cfg.SemanticCacheThreshold,
+			"embed_model", cfg.SemanticCacheModel,
+		)
+	}
+
+	switch cfg.LimitsStore {
+	case "", backendMemory:
+	case backendRedis:
+		redisCfg, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limits store: %w", err)
+		}
+		g.limitsRedis = redis.New(redisCfg)
+		log.Info("redis rate limits and quotas enabled")
+	default:
+		return nil, fmt.Errorf("unknown limits store %q", cfg.LimitsStore)
+	}
+
+	g.quota = quota.New(quota.Config{
+		Window:       cfg.QuotaWindow,
+		Defaults:     quota.Limits{Requests: cfg.QuotaRequests, Tokens: cfg.QuotaTokens},
+		Thresholds:   cfg.QuotaThresholds,
+		OnWarning:    g.notifyQuotaWarning,
+		Store:        g.quotaStore(),
+		OnStoreError: g.limitsStoreFailed,
+	})
+	g.quotaWebhookURL = cfg.QuotaWebhookURL
+	if err := g.setupTenants(cfg.Tenants, cfg.QuotaWindow); err != nil {
+		return nil, fmt.Errorf("invalid tenant config: %w", err)
+	}
+	if g.jwt != nil {
+		log.Info("JWT authentication enabled", "tenant_claim", g.jwtClaim)
+	}
+
+	g.anomalies = anomaly.New(anomaly.Config{
+		Interval:    cfg.AlertInterval,
+		SpikeFactor: cfg.AlertSpikeFactor,
+		ErrorRate:   cfg.AlertErrorRate,
+		MinRequests: cfg.AlertMinRequests,
+		Cooldown:    cfg.AlertCooldown,
+		OnAnomaly:   g.notifyAnomaly,
+	})
+
+	aliases, err := newModelAliases(cfg.ModelAliases)
+	if err != nil {
+		return nil, fmt.Errorf("invalid model aliases: %w", err)
+	}
+	g.modelAliases = aliases
+	if len(aliases) > 0 {
+		log.Info("model aliases configured", "aliases", aliases)
+	}
+
+	fallbacks, err := newModelFallbacks(cfg.ModelFallbacks)
+	if err != nil {
+		return nil, fmt.Errorf("invalid model fallbacks: %w", err)
+	}
+	g.modelFallbacks = fallbacks
+	if len(fallbacks) > 0 {
+		log.Info("model fallbacks configured", "fallbacks", fallbacks)
+	}
+
+	modelPolicies, err := newModelPolicies(cfg.ModelPolicies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid model policies: %w", err)
+	}
+	g.modelPolicies = modelPolicies
+
+	policies, err := guardrails.Parse(cfg.GuardrailPolicies, cfg.Guardrails)
+	if err != nil {
+		return nil, fmt.Errorf("invalid guardrail policies: %w", err)
+	}
+	g.guardrails = policies
+	g.defaultGuardrails = cfg.DefaultGuardrailPolicy
+	if g.defaultGuardrails == "" {
+		g.defaultGuardrails = guardrails.PolicyNone
+	}
+	if _, ok := g.guardrails[g.defaultGuardrails]; !ok {
+		return nil, fmt.Errorf("unknown default guardrail policy %q", g.defaultGuardrails)
+	}
+	if len(cfg.GuardrailPolicies) > 0 {
+		log.Info("guardrails enabled", "policies", g.guardrails.Names(), "default", g.defaultGuardrails)
+	}
+
+	sessions, err := session.Open(cfg.SessionStore, cfg.RedisURL, cfg.Sessions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session store: %w", err)
+	}
+	g.sessions = sessions
+	if cfg.SessionStore == session.BackendRedis {
+		log.Info("redis session store enabled")
+	}
+
+	st, err := store.Open(cfg.Store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+	g.store = st
+	if err := g.restoreState(); err != nil {
+		st.Close()
+		return nil, fmt.Errorf("failed to restore state: %w", err)
+	}
+	if cfg.Store.Backend != "" && cfg.Store.Backend != store.BackendMemory {
+		log.Info("persistent store enabled", "backend", cfg.Store.Backend)
+	}
+	g.auditLog, err = openAuditLog(cfg.AuditLog, cfg.AuditLogFile, cfg.AuditLogKey, st)
+	if err != nil {
+		st.Close()
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if g.auditLog != nil {
+		log.Info("audit log enabled", "backend", cfg.AuditLog)
+	}
+	if g.historyRetention > 0 {
+		go g.runHistoryPruner()
+		log.Info("request history enabled", "retention", g.historyRetention, "bodies", g.historyContent != nil)
+	}
+	go g.runStateSaver()
+	if cfg.QuotaRequests > 0 || cfg.QuotaTokens > 0 {
+		log.Info("quotas enabled",
+			"requests", cfg.QuotaRequests,
+			"tokens", cfg.QuotaTokens,
+			"window", cfg.QuotaWindow,
+		)
 	}
 
 	// Initialize workers
-	for i, addr := range workerAddresses {
-		worker, err := g.createWorker(fmt.Sprintf("worker-%d", i), addr)
+	usedIDs := make(map[string]bool)
+	for _, addr := range cfg.WorkerAddresses {
+		worker, err := g.createWorker(addr, usedIDs)
 		if err != nil {
 			log.Warn("failed to connect to worker", "addr", addr, "error", err)
 			continue
@@ -117,6 +776,31 @@ func NewGateway(log *logger.Logger, workerAddresses []string, apiKeys []string)
 		return nil, fmt.Errorf("no workers available")
 	}
 
+	// The emergency worker is kept out of normal rotation
+	if cfg.EmergencyWorkerAddress != "" {
+		worker, err := g.createWorker(cfg.EmergencyWorkerAddress, usedIDs)
+		if err != nil {
+			log.Warn("failed to connect to emergency worker", "addr", cfg.EmergencyWorkerAddress, "error", err)
+		} else {
+			g.emergencyWorker = worker
+			log.Info("connected to emergency worker", "id", worker.ID, "addr", worker.Address, "model", cfg.EmergencyModel)
+		}
+	}
+
+	if err := g.setupCloud(cfg.Cloud); err != nil {
+		return nil, fmt.Errorf("invalid cloud config: %w", err)
+	}
+	if g.cloud != nil {
+		log.Info("cloud fallback enabled", "provider", cfg.Cloud.Provider, "model", cfg.Cloud.Model, "budget", cfg.Cloud.Budget, "max_queue_wait", cfg.Cloud.MaxQueueWait)
+	}
+
+	if err := g.setupModeration(cfg.Moderation, usedIDs); err != nil {
+		return nil, fmt.Errorf("invalid moderation config: %w", err)
+	}
+	if g.moderator != nil {
+		log.Info("moderation enabled", "model", cfg.Moderation.Model, "stages", cfg.Moderation.Stages, "categories", g.moderator.Categories(), "action", cfg.Moderation.Action)
+	}
+
 	// Register health check
 	h.Register("workers", func(ctx context.Context) *health.Check {
 		healthy := 0
@@ -148,84 +832,218 @@ func NewGateway(log *logger.Logger, workerAddresses []string, apiKeys []string)
 		}
 	})
 
-	// Start background health checker
-	go g.runHealthChecker()
+	// Clock skew breaks latency accounting and token expiry checks, so
+	// report skewed workers without taking them out of rotation
+	h.Register("clock_skew", func(ctx context.Context) *health.Check {
+		var skewed []string
+		for _, w := range g.workers {
+			if w.ClockSkewed.Load() {
+				skewed = append(skewed, fmt.Sprintf("%s (%dms)", w.ID, w.ClockSkewMs.Load()))
+			}
+		}
+
+		if len(skewed) > 0 {
+			return &health.Check{
+				Name:    "clock_skew",
+				Status:  health.StatusDegraded,
+				Message: "clock skew exceeds threshold: " + strings.Join(skewed, ", "),
+			}
+		}
+
+		return &health.Check{
+			Name:   "clock_skew",
+			Status: health.StatusHealthy,
+		}
+	})
+
+	h.SetThresholds(cfg.HealthFailureThreshold, cfg.HealthSuccessThreshold)
+	h.OnStatusChange(func(from, to health.Status) {
+		log.Warn("health status changed", "from", from, "to", to)
+	})
+
+	g.routes()
+
+	// Start background worker health probes, and share their findings with
+	// other gateways
+	g.startWorkerProbes()
+	if g.workerSync != nil {
+		go g.workerSync.run()
+	}
+
+	// Start async job runners
+	g.startJobRunners(cfg.JobWorkers)
 
 	return g, nil
 }
 
-// createWorker creates and connects to a worker
-func (g *Gateway) createWorker(id, addr string) (*Worker, error) {
-	conn, err := grpc.NewClient(addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+// createWorker creates and connects to a worker. usedIDs tracks identities
+// already assigned so that duplicates fall back to the address-derived ID.
+func (g *Gateway) createWorker(addr string, usedIDs map[string]bool) (*Worker, error) {
+	stats := &workerStats{}
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, g.workerDialOptions(stats)...)
+	opts = append(opts, g.workerConn.dialOptions()...)
+	conn, err := dialWorker(addr, g.workerConn.Connections, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect: %w", err)
+		return nil, err
+	}
+	client := llmv1.NewLLMServiceClient(conn)
+
+	id := workerID(addr, g.fetchInstanceID(client))
+	if usedIDs[id] {
+		fallback := workerID(addr, "")
+		g.log.Warn("duplicate worker instance id, using address-derived id",
+			"instance_id", id,
+			"addr", addr,
+			"id", fallback,
+		)
+		id = fallback
+	}
+	usedIDs[id] = true
+
+	var publicKey ed25519.PublicKey
+	if encoded, ok := g.workerPublicKeys[addr]; ok {
+		publicKey, err = signing.ParsePublicKey(encoded)
+	} else if encoded, ok := g.workerPublicKeys[id]; ok {
+		publicKey, err = signing.ParsePublicKey(encoded)
+	} else if g.requireSignatures {
+		err = fmt.Errorf("no public key configured for worker %s", id)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
 	}
 
 	worker := &Worker{
 		ID:      id,
 		Address: addr,
 		Conn:    conn,
-		Client:  llmv1.NewLLMServiceClient(conn),
-		CB: circuitbreaker.New(circuitbreaker.Config{
-			Name:             id,
-			FailureThreshold: 3,
-			SuccessThreshold: 1,
-			Timeout:          30 * time.Second,
-			OnStateChange: func(name string, from, to circuitbreaker.State) {
-				g.log.Info("circuit breaker state change",
-					"worker", name,
-					"from", from.String(),
-					"to", to.String(),
-				)
-				g.metrics.SetCircuitBreakerState(name, int(to))
-			},
-		}),
+		Client:  client,
+		Admin:   llmv1.NewModelAdminServiceClient(conn),
+		CB:      g.breakers.Get(id),
+		Stats:   stats,
 	}
+	worker.PublicKey = publicKey
 	worker.Healthy.Store(true)
 
 	return worker, nil
 }
 
-// runHealthChecker periodically checks worker health
-func (g *Gateway) runHealthChecker() {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
+// isWorkerFailure reports whether an error from a worker call reflects a
+// problem with the worker rather than with the request. Rejected input and
+// callers that went away don't count toward opening its circuit breaker.
+func isWorkerFailure(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.NotFound, codes.OutOfRange, codes.Canceled,
+		codes.Unauthenticated, codes.PermissionDenied:
+		return false
+	}
+	return true
+}
+
+// fetchInstanceID asks a worker for its self-reported instance ID. An empty
+// string is returned if the worker is unreachable or doesn't report one.
+func (g *Gateway) fetchInstanceID(client llmv1.LLMServiceClient) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
 
-	for range ticker.C {
-		g.checkWorkersHealth()
+	resp, err := client.HealthCheck(ctx, &llmv1.HealthCheckRequest{
+		Timestamp: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return ""
 	}
+	return resp.InstanceId
 }
 
-// checkWorkersHealth checks the health of all workers
-func (g *Gateway) checkWorkersHealth() {
-	g.mu.RLock()
-	workers := g.workers
-	g.mu.RUnlock()
+// workerID derives a stable worker identifier. A reported instance ID is used
+// as-is; otherwise the ID is a hash of the address, so metrics, breaker state
+// and history survive reordering of the configured worker list.
+func workerID(addr, instanceID string) string {
+	if instanceID != "" {
+		return instanceID
+	}
+	sum := sha256.Sum256([]byte(addr))
+	return "worker-" + hex.EncodeToString(sum[:4])
+}
 
-	for _, w := range workers {
-		go func(worker *Worker) {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
+// estimateClockSkew estimates a worker's clock offset by assuming its
+// timestamp was taken halfway through the health check round trip
+func estimateClockSkew(sent, received time.Time, workerMs int64) time.Duration {
+	midpoint := sent.Add(received.Sub(sent) / 2)
+	return time.UnixMilli(workerMs).Sub(midpoint).Truncate(time.Millisecond)
+}
 
-			resp, err := worker.Client.HealthCheck(ctx, &llmv1.HealthCheckRequest{
-				Timestamp: time.Now().UnixMilli(),
-			})
+// updateClockSkew records a worker's clock skew and logs when it crosses the
+// threshold in either direction
+func (g *Gateway) updateClockSkew(worker *Worker, skew time.Duration) {
+	worker.ClockSkewMs.Store(skew.Milliseconds())
+	g.metrics.SetWorkerClockSkew(worker.ID, skew.Seconds())
 
-			if err != nil {
-				worker.Healthy.Store(false)
-				g.log.Debug("worker health check failed", "worker", worker.ID, "error", err)
-				return
-			}
+	if g.clockSkewThreshold <= 0 {
+		return
+	}
+
+	exceeded := skew > g.clockSkewThreshold || skew < -g.clockSkewThreshold
+	if worker.ClockSkewed.Swap(exceeded) == exceeded {
+		return
+	}
+	if exceeded {
+		g.log.Warn("worker clock skew exceeds threshold",
+			"worker", worker.ID,
+			"skew", skew,
+			"threshold", g.clockSkewThreshold,
+		)
+	} else {
+		g.log.Info("worker clock skew back within threshold", "worker", worker.ID, "skew", skew)
+	}
+}
 
-			worker.Healthy.Store(resp.Healthy)
-		}(w)
+// tracer creates the gateway's own spans
+var tracer = otel.Tracer("github.com/hugovillarreal/neurogate/cmd/gateway")
+
+// selectWorker picks an available worker other than exclude (which may be
+// nil), recording the choice in a span. Requests with an affinity key go to
+// the key's preferred worker when possible, the rest round robin.
+func (g *Gateway) selectWorker(ctx context.Context, affinity string, exclude *Worker) (*Worker, error) {
+	_, span := tracer.Start(ctx, "selectWorker")
+	defer span.End()
+
+	var worker *Worker
+	var err error
+	if affinity != "" {
+		var preferred bool
+		worker, preferred, err = g.affinityWorker(affinity, exclude)
+		if err == nil {
+			result := "preferred"
+			if !preferred {
+				result = "fallback"
+			}
+			g.metrics.RecordAffinityRoute(result)
+			span.SetAttributes(attribute.String("worker.affinity", result))
+		}
+	} else {
+		worker, err = g.nextWorker(exclude)
+	}
+	if err == nil {
+		// The chosen worker has the shortest queue, so if the request would
+		// time out waiting on it, it would on any
+		err = checkDeadline(ctx, worker)
+	}
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+		return nil, err
 	}
+	span.SetAttributes(attribute.String("worker.id", worker.ID))
+	return worker, nil
 }
 
-// selectWorker implements Round Robin load balancing
-func (g *Gateway) selectWorker() (*Worker, error) {
+// nextWorker implements Round Robin load balancing, skipping exclude.
+// Workers with a free inference slot are preferred; if none has one, the
+// request queues on the worker with the shortest estimated wait.
+func (g *Gateway) nextWorker(exclude *Worker) (*Worker, error) {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
@@ -238,137 +1056,748 @@ func (g *Gateway) selectWorker() (*Worker, error) {
 	startIndex := g.workerIndex.Add(1) - 1
 	workerCount := uint32(len(g.workers))
 
+	var picker capacityPicker
 	for i := uint32(0); i < workerCount; i++ {
 		idx := (startIndex + i) % workerCount
 		worker := g.workers[idx]
 
-		// Check if worker is healthy and circuit is not open
-		if worker.Healthy.Load() && worker.CB.AllowRequest() {
+		// Check if worker is healthy and circuit is not open. The circuit is
+		// only checked here; Execute reserves a half-open probe slot.
+		if worker != exclude && worker.Healthy.Load() && worker.CB.Available() && picker.offer(worker) {
 			return worker, nil
 		}
 	}
 
+	if worker, err := picker.pick(); worker != nil || err != nil {
+		return worker, err
+	}
 	return nil, fmt.Errorf("all workers are unavailable")
 }
 
 // ServeHTTP implements the HTTP handler
 func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Enable CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	g.handler.ServeHTTP(w, r)
+}
 
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
+// apiError is an error that maps directly to an HTTP error response
+type apiError struct {
+	Status  int
+	Message string
+	Detail  string
+	Code    ErrorCode // Defaults to the status's code
+
+	// The worker failed in a way another worker might not, such as running
+	// out of memory or slots
+	Retryable bool
+
+	// The guardrail check the request or response failed, if any
+	Violation *guardrails.Violation
+
+	// The request body's invalid fields, if any
+	Fields []FieldError
+
+	// Sent as the Retry-After header, rounded up to whole seconds, if set
+	RetryAfter time.Duration
+}
+
+// response returns the error's response body
+func (e *apiError) response() ErrorResponse {
+	code := e.Code
+	if code == "" {
+		code = codeForStatus(e.Status)
 	}
+	return ErrorResponse{
+		Error:     e.Message,
+		Code:      e.Status,
+		Message:   e.Detail,
+		ErrorCode: code,
+		Retryable: code.retryable(),
+		Violation: e.Violation,
+		Fields:    e.Fields,
+	}
+}
 
-	// Route requests
-	switch {
-	case r.URL.Path == "/prompt" && r.Method == "POST":
-		g.handlePrompt(w, r)
-	case r.URL.Path == "/health":
-		g.healthChecker.HTTPHandler()(w, r)
-	case r.URL.Path == "/workers":
-		g.handleListWorkers(w, r)
-	default:
-		g.writeError(w, http.StatusNotFound, "not found", "")
+func (e *apiError) Error() string {
+	if e.Detail != "" {
+		return e.Message + ": " + e.Detail
 	}
+	return e.Message
 }
 
 // handlePrompt handles the /prompt endpoint
 func (g *Gateway) handlePrompt(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
-	g.metrics.ActiveRequests.Inc()
-	defer g.metrics.ActiveRequests.Dec()
-
-	// Validate API key
-	if len(g.apiKeys) > 0 {
-		authHeader := r.Header.Get("Authorization")
-		if !g.validateAPIKey(authHeader) {
-			g.writeError(w, http.StatusUnauthorized, "invalid or missing API key", "")
-			g.metrics.RecordRequest("POST", "/prompt", "401", time.Since(start).Seconds())
-			return
-		}
-	}
+	authHeader := r.Header.Get("Authorization")
 
-	// Parse request
 	var req PromptRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		g.writeError(w, http.StatusBadRequest, "invalid request body", err.Error())
-		g.metrics.RecordRequest("POST", "/prompt", "400", time.Since(start).Seconds())
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		g.writeAPIError(w, apiErr)
 		return
 	}
-
-	if req.Query == "" {
-		g.writeError(w, http.StatusBadRequest, "query is required", "")
-		g.metrics.RecordRequest("POST", "/prompt", "400", time.Since(start).Seconds())
+	if fields := g.limits.validatePrompt(&req); len(fields) > 0 {
+		g.writeAPIError(w, invalidRequest(fields...))
 		return
 	}
 
-	// Generate request ID
-	requestID := fmt.Sprintf("req-%d", time.Now().UnixNano())
-	requestLog := g.log.WithRequestID(requestID)
+	req.Model = g.modelAliases.resolve(req.Model)
 
-	// Select a worker
-	worker, err := g.selectWorker()
-	if err != nil {
-		requestLog.Error("no workers available", "error", err)
-		g.writeError(w, http.StatusServiceUnavailable, "no workers available", err.Error())
-		g.metrics.RecordRequest("POST", "/prompt", "503", time.Since(start).Seconds())
+	if err := req.validateContext(); err != nil {
+		g.writeAPIError(w, invalidRequest(FieldError{Field: "context", Message: err.Error()}))
 		return
 	}
 
-	requestLog.Info("forwarding request to worker",
-		"worker_id", worker.ID,
-		"query_length", len(req.Query),
-	)
+	if err := g.modelPolicies.apply(&req); err != nil {
+		apiErr := toAPIError(err)
+		g.writeAPIError(w, apiErr)
+		return
+	}
 
-	// Forward to worker with circuit breaker
-	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
-	defer cancel()
+	if err := g.checkPrompt(g.guardrailsFor(authHeader), &req); err != nil {
+		apiErr := toAPIError(err)
+		g.writeAPIError(w, apiErr)
+		return
+	}
 
-	var resp *llmv1.PromptResponse
-	err = worker.CB.Execute(func() error {
-		var callErr error
-		resp, callErr = worker.Client.GenerateText(ctx, &llmv1.PromptRequest{
-			RequestId:    requestID,
-			Prompt:       req.Query,
-			Model:        req.Model,
-			MaxTokens:    req.MaxTokens,
-			Temperature:  req.Temperature,
-			SystemPrompt: req.SystemPrompt,
-		})
-		return callErr
-	})
+	// Code handling the request logs through the context so every line
+	// carries its ID
+	requestID := requestid.FromContext(r.Context())
+	ctx := logger.ToContext(r.Context(), g.log.WithRequestID(requestID))
 
+	response, err := g.runPrompt(ctx, requestID, &req, authHeader, g.fallbackFor("/prompt", authHeader))
 	if err != nil {
-		if err == circuitbreaker.ErrCircuitOpen {
-			requestLog.Warn("circuit breaker open", "worker", worker.ID)
-			g.writeError(w, http.StatusServiceUnavailable, "worker temporarily unavailable", "")
-		} else {
-			requestLog.Error("worker request failed", "error", err)
-			g.writeError(w, http.StatusInternalServerError, "generation failed", err.Error())
-		}
-		g.metrics.RecordRequest("POST", "/prompt", "500", time.Since(start).Seconds())
+		apiErr := toAPIError(err)
+		g.writeAPIError(w, apiErr)
+		g.recordHistory(authHeader, HistoryRecord{
+			RequestID: requestID,
+			Endpoint:  "/prompt",
+			Model:     req.Model,
+			Status:    apiErr.Status,
+			Error:     apiErr.Message,
+			LatencyMs: time.Since(start).Milliseconds(),
+		}, req.Query, "")
 		return
 	}
 
-	// Build response
-	duration := time.Since(start)
-	response := PromptResponse{
-		RequestID: requestID,
-		Response:  resp.Response,
-		Model:     resp.Model,
-		Tokens:    resp.TotalTokens,
-		LatencyMs: duration.Milliseconds(),
-		WorkerID:  worker.ID,
+	g.recordQuota(w, authHeader, billableTokens(response))
+	g.recordCost(w, authHeader, response)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+
+	g.recordHistory(authHeader, promptHistory("/prompt", response), req.Query, response.Response)
+}
+
+// runPrompt serves a validated prompt request for the key in authHeader,
+// continuing its conversation if it names one, and applies the key's
+// guardrail post-checks to the response. Pre-checks are run by the handlers
+// so rejected prompts are never queued. Errors are returned as *apiError.
+func (g *Gateway) runPrompt(ctx context.Context, requestID string, req *PromptRequest, authHeader string, fallback []string) (*PromptResponse, error) {
+	ctx = g.workerContext(ctx, requestID, authHeader)
+	ticket := g.ticketFor(authHeader, req.Priority)
+	flagged, err := g.moderate(ctx, guardrails.StagePre, req.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := g.guardrailsFor(authHeader)
+	var resp *PromptResponse
+	if req.ConversationID != "" {
+		resp, err = g.runConversation(ctx, requestID, req, ticket, g.ownerOf(authHeader), policy, fallback)
+	} else {
+		resp, err = g.completePrompt(ctx, requestID, req, ticket, fallback)
+		if err == nil {
+			err = g.reviewResponse(ctx, policy, resp)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if flagged != nil {
+		if resp.Moderation == nil {
+			resp.Moderation = &ModerationReport{}
+		}
+		resp.Moderation.Prompt = flagged
+	}
+	return resp, nil
+}
+
+// completePrompt serves a prompt request from cache or a worker, falling
+// back to the given degradation strategies when no worker is available.
+// Requests that aren't cacheable bypass the caches, and cache misses wait
+// for the scheduler, if enabled, to admit ticket before going to a worker.
+// Errors are returned as *apiError.
+func (g *Gateway) completePrompt(ctx context.Context, requestID string, req *PromptRequest, ticket scheduler.Ticket, fallback []string) (*PromptResponse, error) {
+	start := time.Now()
+	requestLog := logger.FromContext(ctx)
+	cacheable := req.cacheable()
+
+	// Serve identical requests from cache when possible
+	cacheKey := cache.Key{
+		Model:        req.Model,
+		Prompt:       req.Query,
+		SystemPrompt: req.SystemPrompt,
+		Temperature:  req.Temperature,
+		MaxTokens:    req.MaxTokens,
+		Options:      req.Sampling.cacheKey(),
+		Format:       workerFormat(req.Format),
+	}
+	if g.cache != nil && cacheable {
+		cached, ok := g.cache.Get(cacheKey)
+		g.metrics.RecordCacheLookup("exact", ok)
+		if ok {
+			requestLog.Info("serving response from cache")
+			return cachedResponse(requestID, cached, start), nil
+		}
+	}
+
+	// Fall back to prompts that are semantically close to a cached one
+	var embedding []float32
+	if g.semanticCache != nil && cacheable {
+		embedding = g.embedPrompt(ctx, requestID, req.Query)
+		if embedding != nil {
+			cached, score, ok := g.semanticCache.Get(cacheKey, embedding)
+			g.metrics.RecordCacheLookup("semantic", ok)
+			if ok {
+				requestLog.Info("serving response from semantic cache", "similarity", score)
+				return cachedResponse(requestID, cached, start), nil
+			}
+		}
+	}
+
+	// Jobs that waited too long for a worker may go to the cloud instead
+	if g.overflowsToCloud(req, fallback) {
+		if resp, ok := g.degrade(ctx, requestID, req, cacheKey, []string{fallbackCloud}, start); ok {
+			return resp, nil
+		}
+	}
+
+	// Wait for the scheduler's turn, then select a worker, degrading
+	// gracefully when none are available
+	release, err := g.admit(ctx, ticket)
+	if err != nil {
+		requestLog.Warn("not admitted by scheduler", "priority", ticket.Priority.String(), "error", err)
+		if resp, ok := g.degrade(ctx, requestID, req, cacheKey, fallback, start); ok {
+			return resp, nil
+		}
+		return nil, err
 	}
+	defer release()
 
-	g.metrics.RecordRequest("POST", "/prompt", "200", duration.Seconds())
+	worker, err := g.selectWorker(ctx, g.affinityKey(req), nil)
+	if err != nil {
+		requestLog.Error("no workers available", "error", err)
+		if resp, ok := g.degrade(ctx, requestID, req, cacheKey, fallback, start); ok {
+			return resp, nil
+		}
+		return nil, g.noWorkerError(err)
+	}
+
+	resp, err := g.forward(ctx, worker, requestID, req)
+	retries := 0
+	if err != nil && toAPIError(err).Retryable {
+		if next, selErr := g.selectWorker(ctx, g.affinityKey(req), worker); selErr == nil {
+			requestLog.Warn("retrying on another worker", "failed_worker_id", worker.ID, "worker_id", next.ID, "error", err)
+			worker, retries = next, 1
+			resp, err = g.forward(ctx, worker, requestID, req)
+		}
+	}
+	var fallbackFrom string
+	if err != nil && len(g.modelFallbacks[req.Model]) > 0 {
+		var next *Worker
+		var attempts int
+		resp, next, attempts, err = g.fallBack(ctx, requestID, req, err)
+		retries += attempts
+		if err == nil {
+			worker, fallbackFrom = next, req.Model
+		}
+	}
+	if err != nil {
+		if apiErr := toAPIError(err); apiErr.Status == http.StatusServiceUnavailable || apiErr.Code == CodeOverloaded {
+			if resp, ok := g.degrade(ctx, requestID, req, cacheKey, fallback, start); ok {
+				if resp.Degraded == fallbackEmergency || resp.Degraded == fallbackCloud {
+					resp.Usage.Retries = retries + 1 // after the failed worker attempts
+				}
+				return resp, nil
+			}
+		}
+		return nil, err
+	}
+	g.latency.observe(time.Since(start))
+
+	cached := &cache.Response{
+		Text:             resp.Response,
+		Model:            resp.Model,
+		PromptTokens:     resp.PromptTokens,
+		CompletionTokens: resp.CompletionTokens,
+		TotalTokens:      resp.TotalTokens,
+		CreatedAt:        time.Now(),
+	}
+	parameters := generationParameters(resp.Parameters)
+	if parameters != nil {
+		cached.Parameters, _ = json.Marshal(parameters)
+	}
+	// Responses from fallback models aren't cached as answers of the
+	// requested model
+	if g.cache != nil && cacheable && fallbackFrom == "" {
+		g.cache.Set(cacheKey, cached)
+	}
+	if g.semanticCache != nil && embedding != nil && fallbackFrom == "" {
+		g.semanticCache.Set(cacheKey, embedding, cached)
+	}
+
+	return &PromptResponse{
+		RequestID: requestID,
+		Response:  resp.Response,
+		Model:     resp.Model,
+		Tokens:    resp.TotalTokens,
+		LatencyMs: time.Since(start).Milliseconds(),
+		WorkerID:  worker.ID,
+		Usage:     g.usage(resp, retries),
+		Context:   responseContext(req, resp),
+
+		FallbackFrom: fallbackFrom,
+		Parameters:   parameters,
+	}, nil
+}
+
+// workerContext returns ctx carrying the request's ID and caller, which the
+// worker client interceptors send with calls made with it
+func (g *Gateway) workerContext(ctx context.Context, requestID, authHeader string) context.Context {
+	meta := reqmeta.Meta{RequestID: requestID, Tenant: g.tenantOf(authHeader)}
+	if hash := g.keyHashOf(authHeader); hash != "" {
+		meta.KeyID = hashID(hash)
+	}
+	return reqmeta.NewContext(ctx, meta)
+}
+
+// forward sends a prompt to a worker through its circuit breaker. Errors are
+// returned as *apiError.
+func (g *Gateway) forward(ctx context.Context, worker *Worker, requestID string, req *PromptRequest) (*llmv1.PromptResponse, error) {
+	requestLog := logger.FromContext(ctx)
+
+	requestLog.Info("forwarding request to worker",
+		"worker_id", worker.ID,
+		"query_length", len(req.Query),
+		"prompt", req.Query,
+	)
+
+	inflight := g.inflight.start(requestID, worker.ID, req.Model)
+	defer g.inflight.end(inflight)
+	defer g.trackInflight(worker)()
+
+	resp, err := circuitbreaker.Do(worker.CB, func() (*llmv1.PromptResponse, error) {
+		return g.generate(ctx, worker, inflight, callTimeout{d: g.modelPolicies.timeout(req.Model)}, &llmv1.PromptRequest{
+			RequestId:     requestID,
+			Prompt:        req.Query,
+			Model:         req.Model,
+			MaxTokens:     req.MaxTokens,
+			Temperature:   req.Temperature,
+			SystemPrompt:  req.SystemPrompt,
+			Format:        workerFormat(req.Format),
+			TopP:          req.TopP,
+			TopK:          req.TopK,
+			RepeatPenalty: req.RepeatPenalty,
+			Seed:          req.Seed,
+			Stop:          req.Stop,
+			NumCtx:        req.NumCtx,
+			History:       chatHistory(req.history),
+			Context:       req.contextTokens(),
+			ReturnContext: req.ReturnContext,
+		})
+	})
+
+	if err != nil {
+		if err == circuitbreaker.ErrCircuitOpen {
+			requestLog.Warn("circuit breaker open", "worker", worker.ID)
+			return nil, &apiError{Status: http.StatusServiceUnavailable, Message: "worker temporarily unavailable", Code: CodeWorkerUnavailable}
+		}
+		if errors.Is(err, signing.ErrInvalidSignature) || errors.Is(err, signing.ErrMissingSignature) {
+			requestLog.Warn("worker result failed signature verification", "worker", worker.ID, "error", err)
+			return nil, &apiError{Status: http.StatusBadGateway, Message: "response signature verification failed", Detail: err.Error()}
+		}
+		if timedOut(ctx, err) {
+			requestLog.Warn("worker request timed out", "worker", worker.ID)
+			return nil, &apiError{Status: http.StatusGatewayTimeout, Message: "generation timed out"}
+		}
+		requestLog.Error("worker request failed", "worker", worker.ID, "error", err)
+		return nil, workerAPIError(err, "generation failed")
+	}
+
+	if g.validateFormat {
+		if err := checkFormat(req.Format, resp.Response); err != nil {
+			requestLog.Warn("response does not match format", "worker", worker.ID, "error", err)
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// workerAPIError maps a worker's gRPC error to a response. A missing model
+// fails fast, while a worker that is out of memory, at capacity or still
+// loading the model is worth retrying elsewhere.
+func workerAPIError(err error, message string) *apiError {
+	detail := err.Error()
+	if st, ok := status.FromError(err); ok {
+		detail = st.Message()
+	}
+
+	code := status.Code(err)
+	mapped, ok := grpcErrors[code]
+	if !ok {
+		return &apiError{Status: http.StatusInternalServerError, Message: message, Detail: detail, Code: CodeInternal}
+	}
+	return &apiError{
+		Status:    mapped.status,
+		Message:   mapped.message,
+		Detail:    detail,
+		Code:      mapped.code,
+		Retryable: code == codes.ResourceExhausted || code == codes.Unavailable,
+	}
+}
+
+// toAPIError converts any error into an *apiError, defaulting to 500
+func toAPIError(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return &apiError{Status: http.StatusInternalServerError, Message: "internal error", Detail: err.Error()}
+}
+
+// billableTokens returns the tokens charged against quotas for a response.
+// Cache hits cost no inference and are charged as a request only.
+func billableTokens(resp *PromptResponse) int32 {
+	if resp.Cached {
+		return 0
+	}
+	return resp.Tokens
+}
+
+// cachedResponse builds a response served from one of the caches
+func cachedResponse(requestID string, cached *cache.Response, start time.Time) *PromptResponse {
+	var parameters *GenerationParameters
+	if cached.Parameters != nil {
+		parameters = &GenerationParameters{}
+		if json.Unmarshal(cached.Parameters, parameters) != nil {
+			parameters = nil
+		}
+	}
+
+	return &PromptResponse{
+		RequestID: requestID,
+		Response:  cached.Text,
+		Model:     cached.Model,
+		Tokens:    cached.TotalTokens,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Cached:    true,
+		// Cache hits cost no inference
+		Usage: Usage{
+			PromptTokens:     cached.PromptTokens,
+			CompletionTokens: cached.CompletionTokens,
+			TotalTokens:      cached.TotalTokens,
+			CacheHit:         true,
+		},
+		Parameters: parameters,
+	}
+}
+
+// cacheFailed logs a failed response cache operation; lookups that fail are
+// misses
+func (g *Gateway) cacheFailed(err error) {
+	g.log.Warn("response cache unavailable", "error", err)
+}
+
+// embedPrompt computes the semantic cache embedding for a prompt. Failures are
+// logged and return nil so that caching never blocks generation.
+func (g *Gateway) embedPrompt(ctx context.Context, requestID, prompt string) []float32 {
+	worker, err := g.selectWorker(ctx, "", nil)
+	if err != nil {
+		return nil
+	}
+
+	defer g.trackInflight(worker)()
+	resp, err := circuitbreaker.Do(worker.CB, func() (*llmv1.EmbedResponse, error) {
+		return worker.Client.Embed(ctx, &llmv1.EmbedRequest{
+			RequestId: requestID,
+			Input:     []string{prompt},
+			Model:     g.embedModel,
+		}, callTimeout{d: 10 * time.Second})
+	})
+	if err != nil || len(resp.Embeddings) == 0 {
+		logger.FromContext(ctx).Warn("semantic cache embedding failed", "worker_id", worker.ID, "error", err)
+		return nil
+	}
+
+	return resp.Embeddings[0].Values
+}
+
+// generate streams a completion from the worker, publishing each chunk to
+// admin viewers attached to the in-flight request, and assembles the result
+func (g *Gateway) generate(ctx context.Context, worker *Worker, inflight *inflightRequest, timeout callTimeout, req *llmv1.PromptRequest) (*llmv1.PromptResponse, error) {
+	stream, err := worker.Client.StreamGenerateText(ctx, req, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil, fmt.Errorf("stream ended before completion")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if chunk.Token != "" {
+			text.WriteString(chunk.Token)
+			inflight.publish(chunk.Token)
+		}
+
+		if chunk.Done {
+			// Read the stream's end, which the client interceptors record
+			stream.Recv()
+
+			resp := &llmv1.PromptResponse{
+				RequestId:        req.RequestId,
+				Response:         text.String(),
+				PromptTokens:     chunk.PromptTokens,
+				CompletionTokens: chunk.TokensGenerated,
+				TotalTokens:      chunk.PromptTokens + chunk.TokensGenerated,
+				InferenceTimeMs:  chunk.InferenceTimeMs,
+				Model:            chunk.Model,
+				Signature:        chunk.Signature,
+				Parameters:       chunk.Parameters,
+				Context:          chunk.Context,
+			}
+			if err := g.verifyResult(worker, resp); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+	}
+}
+
+// EmbeddingsRequest is the REST API request body for /embeddings
+type EmbeddingsRequest struct {
+	Input []string `json:"input"`
+	Model string   `json:"model,omitempty"`
+}
+
+// EmbeddingsResponse is the REST API response body for /embeddings
+type EmbeddingsResponse struct {
+	RequestID  string      `json:"request_id"`
+	Embeddings [][]float32 `json:"embeddings"`
+	Model      string      `json:"model"`
+	Tokens     int32       `json:"tokens"`
+	LatencyMs  int64       `json:"latency_ms"`
+	WorkerID   string      `json:"worker_id"`
+}
+
+// handleEmbeddings handles the /embeddings endpoint
+func (g *Gateway) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	authHeader := r.Header.Get("Authorization")
+	var req EmbeddingsRequest
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		g.writeAPIError(w, apiErr)
+		return
+	}
+	if len(req.Input) == 0 {
+		g.writeAPIError(w, invalidRequest(FieldError{Field: "input", Message: "is required"}))
+		return
+	}
+	var fields []FieldError
+	for i, input := range req.Input {
+		fields = append(fields, g.limits.checkText(fmt.Sprintf("input[%d]", i), input, false)...)
+	}
+	if len(fields) > 0 {
+		g.writeAPIError(w, invalidRequest(fields...))
+		return
+	}
+
+	// The default alias names a generation model, so it doesn't apply here
+	if req.Model != "" {
+		req.Model = g.modelAliases.resolve(req.Model)
+	}
+
+	requestID := requestid.FromContext(r.Context())
+	// The deadline comes from the route timeout table
+	ctx := logger.ToContext(r.Context(), g.log.WithRequestID(requestID))
+	ctx = g.workerContext(ctx, requestID, authHeader)
+	requestLog := logger.FromContext(ctx)
+
+	worker, err := g.selectWorker(ctx, "", nil)
+	if err != nil {
+		requestLog.Error("no workers available", "error", err)
+		g.writeAPIError(w, g.noWorkerError(err))
+		return
+	}
+
+	defer g.trackInflight(worker)()
+	resp, err := circuitbreaker.Do(worker.CB, func() (*llmv1.EmbedResponse, error) {
+		return worker.Client.Embed(ctx, &llmv1.EmbedRequest{
+			RequestId: requestID,
+			Input:     req.Input,
+			Model:     req.Model,
+		})
+	})
+
+	if err != nil {
+		var apiErr *apiError
+		if err == circuitbreaker.ErrCircuitOpen {
+			apiErr = &apiError{Status: http.StatusServiceUnavailable, Message: "worker temporarily unavailable", Code: CodeWorkerUnavailable}
+		} else if timedOut(ctx, err) {
+			requestLog.Warn("worker embedding timed out", "worker", worker.ID)
+			apiErr = &apiError{Status: http.StatusGatewayTimeout, Message: "embedding timed out"}
+		} else {
+			requestLog.Error("worker embedding failed", "worker", worker.ID, "error", err)
+			apiErr = workerAPIError(err, "embedding failed")
+		}
+		g.writeAPIError(w, apiErr)
+		g.recordHistory(authHeader, HistoryRecord{
+			RequestID: requestID,
+			Endpoint:  "/embeddings",
+			Model:     req.Model,
+			WorkerID:  worker.ID,
+			Status:    apiErr.Status,
+			Error:     apiErr.Message,
+			LatencyMs: time.Since(start).Milliseconds(),
+		}, "", "")
+		return
+	}
+
+	embeddings := make([][]float32, len(resp.Embeddings))
+	for i, e := range resp.Embeddings {
+		embeddings[i] = e.Values
+	}
+
+	duration := time.Since(start)
+	g.recordQuota(w, authHeader, resp.PromptTokens)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(EmbeddingsResponse{
+		RequestID:  requestID,
+		Embeddings: embeddings,
+		Model:      resp.Model,
+		Tokens:     resp.PromptTokens,
+		LatencyMs:  duration.Milliseconds(),
+		WorkerID:   worker.ID,
+	})
+
+	g.recordHistory(authHeader, HistoryRecord{
+		RequestID:    requestID,
+		Endpoint:     "/embeddings",
+		Model:        resp.Model,
+		WorkerID:     worker.ID,
+		Status:       http.StatusOK,
+		PromptTokens: resp.PromptTokens,
+		LatencyMs:    duration.Milliseconds(),
+	}, "", "")
+}
+
+// verifyResult checks a worker's result signature when the worker has a
+// configured public key. Unsigned results from workers without a key are
+// accepted unless signatures are required.
+func (g *Gateway) verifyResult(worker *Worker, resp *llmv1.PromptResponse) error {
+	if worker.PublicKey == nil {
+		if g.requireSignatures {
+			return signing.ErrMissingSignature
+		}
+		return nil
+	}
+
+	return signing.Verify(worker.PublicKey, signing.Payload{
+		RequestID:        resp.RequestId,
+		Model:            resp.Model,
+		Response:         resp.Response,
+		PromptTokens:     resp.PromptTokens,
+		CompletionTokens: resp.CompletionTokens,
+	}, resp.Signature)
+}
+
+// handleListInflight returns the requests currently being generated
+func (g *Gateway) handleListInflight(w http.ResponseWriter, r *http.Request) {
+	type inflightStatus struct {
+		ID        string    `json:"id"`
+		WorkerID  string    `json:"worker_id"`
+		Model     string    `json:"model,omitempty"`
+		StartedAt time.Time `json:"started_at"`
+		ElapsedMs int64     `json:"elapsed_ms"`
+	}
+
+	reqs := g.inflight.list()
+	statuses := make([]inflightStatus, len(reqs))
+	for i, req := range reqs {
+		statuses[i] = inflightStatus{
+			ID:        req.ID,
+			WorkerID:  req.WorkerID,
+			Model:     req.Model,
+			StartedAt: req.StartedAt,
+			ElapsedMs: time.Since(req.StartedAt).Milliseconds(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"requests": statuses,
+		"count":    len(statuses),
+	})
+}
+
+// handleStreamInflight attaches a read-only viewer to an in-flight request's
+// token stream using Server-Sent Events. Chunks generated before the viewer
+// attached are replayed first.
+func (g *Gateway) handleStreamInflight(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	req, ok := g.inflight.get(id)
+	if !ok {
+		g.writeError(w, http.StatusNotFound, "request not found", "request is not in flight")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		g.writeError(w, http.StatusInternalServerError, "streaming unsupported", "")
+		return
+	}
+
+	history, tokens, unsubscribe := req.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, token := range history {
+		writeSSE(w, "token", map[string]string{"token": token})
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case token, ok := <-tokens:
+			if !ok {
+				writeSSE(w, "done", map[string]string{"request_id": id})
+				flusher.Flush()
+				return
+			}
+			writeSSE(w, "token", map[string]string{"token": token})
+			flusher.Flush()
+		}
+	}
+}
+
+// writeSSE writes a single Server-Sent Event with JSON-encoded data
+func writeSSE(w io.Writer, event string, data interface{}) {
+	payload, _ := json.Marshal(data)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
 }
 
 // handleListWorkers returns the list of workers and their status
@@ -376,20 +1805,82 @@ func (g *Gateway) handleListWorkers(w http.ResponseWriter, r *http.Request) {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
+	type loadedModel struct {
+		Name      string     `json:"name"`
+		Size      int64      `json:"size"`
+		SizeVRAM  int64      `json:"size_vram"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	}
+	type breakerStatus struct {
+		State        string  `json:"state"`
+		FailureCount int     `json:"failure_count"`
+		FailureRate  float64 `json:"failure_rate"` // Within the sliding window, if any
+	}
 	type workerStatus struct {
-		ID      string `json:"id"`
-		Address string `json:"address"`
-		Healthy bool   `json:"healthy"`
-		CBState string `json:"circuit_breaker_state"`
+		ID              string        `json:"id"`
+		Address         string        `json:"address"`
+		Healthy         bool          `json:"healthy"`
+		LastHealthCheck *time.Time    `json:"last_health_check,omitempty"`
+		CBState         string        `json:"circuit_breaker_state"`
+		CircuitBreaker  breakerStatus `json:"circuit_breaker"`
+		ClockSkewMs     int64         `json:"clock_skew_ms"`
+		ClockSkewed     bool          `json:"clock_skewed"`
+		Models          []string      `json:"models"`
+		LoadedModels    []loadedModel `json:"loaded_models"`
+
+		// Requests served by the worker since the gateway started
+		Stats workerStatsSnapshot `json:"stats"`
+
+		// Inference capacity as last reported, with the gateway's own
+		// requests in flight; omitted if the worker doesn't report it
+		Capacity        int32 `json:"capacity,omitempty"`
+		Running         int32 `json:"running"`
+		QueueDepth      int32 `json:"queue_depth"`
+		MaxQueueDepth   int32 `json:"max_queue_depth,omitempty"`
+		EstimatedWaitMs int64 `json:"estimated_wait_ms"`
+		Inflight        int32 `json:"inflight"`
 	}
 
 	workers := make([]workerStatus, len(g.workers))
 	for i, w := range g.workers {
+		cb := w.CB.Stats()
 		workers[i] = workerStatus{
-			ID:      w.ID,
-			Address: w.Address,
-			Healthy: w.Healthy.Load(),
-			CBState: w.CB.State().String(),
+			ID:              w.ID,
+			Address:         w.Address,
+			Healthy:         w.Healthy.Load(),
+			LastHealthCheck: w.LastHealthCheck.Load(),
+			CBState:         cb.State.String(),
+			CircuitBreaker: breakerStatus{
+				State:        cb.State.String(),
+				FailureCount: cb.FailureCount,
+				FailureRate:  cb.FailureRate,
+			},
+			ClockSkewMs:  w.ClockSkewMs.Load(),
+			ClockSkewed:  w.ClockSkewed.Load(),
+			Models:       []string{},
+			LoadedModels: []loadedModel{},
+			Stats:        w.Stats.snapshot(),
+			Inflight:     w.Inflight.Load(),
+		}
+		if models := w.Models.Load(); models != nil {
+			workers[i].Models = append(workers[i].Models, *models...)
+		}
+		if c := w.Capacity.Load(); c != nil {
+			workers[i].Capacity = c.Slots
+			workers[i].Running = c.Running
+			workers[i].QueueDepth = c.Queued
+			workers[i].MaxQueueDepth = c.MaxQueued
+			workers[i].EstimatedWaitMs = c.EstimatedWait.Milliseconds()
+		}
+		if loaded := w.LoadedModels.Load(); loaded != nil {
+			for _, m := range *loaded {
+				lm := loadedModel{Name: m.Name, Size: m.Size, SizeVRAM: m.SizeVram}
+				if m.ExpiresAt > 0 {
+					t := time.UnixMilli(m.ExpiresAt).UTC()
+					lm.ExpiresAt = &t
+				}
+				workers[i].LoadedModels = append(workers[i].LoadedModels, lm)
+			}
 		}
 	}
 
@@ -400,45 +1891,106 @@ func (g *Gateway) handleListWorkers(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// validateAPIKey checks if the provided API key is valid
+// validateAPIKey checks if the provided API key, or JWT, is valid
 func (g *Gateway) validateAPIKey(authHeader string) bool {
-	if authHeader == "" {
-		return false
+	if g.isJWT(bearerToken(authHeader)) {
+		_, ok := g.verifyJWT(authHeader)
+		return ok
 	}
+	return g.apiKeys.valid(authHeader)
+}
 
-	// Expect "Bearer <token>" format
-	parts := strings.SplitN(authHeader, " ", 2)
-	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-		return false
-	}
+// validateKey checks a "Bearer <token>" header against a set of keys
+func validateKey(authHeader string, keys map[string]bool) bool {
+	token := bearerToken(authHeader)
+	return token != "" && keys[token]
+}
 
-	return g.apiKeys[parts[1]]
+// parseKeys builds a lookup set from a list of keys, ignoring blanks
+func parseKeys(keys []string) map[string]bool {
+	keyMap := make(map[string]bool)
+	for _, key := range keys {
+		if key != "" {
+			keyMap[key] = true
+		}
+	}
+	return keyMap
 }
 
-// writeError writes an error response
-func (g *Gateway) writeError(w http.ResponseWriter, code int, message, detail string) {
+// writeAPIError writes an apiError, including any guardrail violation
+func (g *Gateway) writeAPIError(w http.ResponseWriter, e *apiError) {
+	if e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(ErrorResponse{
-		Error:   message,
-		Code:    code,
-		Message: detail,
-	})
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(e.response())
+}
+
+// writeError writes an error response with the status's error code
+func (g *Gateway) writeError(w http.ResponseWriter, status int, message, detail string) {
+	g.writeAPIError(w, &apiError{Status: status, Message: message, Detail: detail})
 }
 
 func main() {
+	// Prompt and response content is redacted in logs unless LOG_CONTENT
+	// allows more. An invalid mode keeps it redacted and is reported below.
+	contentMode, contentModeErr := redact.ParseMode(getEnv("LOG_CONTENT", string(redact.ModeRedact)))
+
 	// Initialize logger
 	log := logger.New(logger.Config{
-		Level:   getEnv("LOG_LEVEL", "info"),
-		Service: "gateway",
-		JSON:    getEnv("LOG_FORMAT", "text") == "json",
+		Level:      getEnv("LOG_LEVEL", "info"),
+		Service:    "gateway",
+		JSON:       getEnv("LOG_FORMAT", "text") == "json",
+		File:       getEnv("LOG_FILE", ""),
+		MaxSizeMB:  getEnvInt("LOG_FILE_MAX_SIZE_MB", 100),
+		MaxBackups: getEnvInt("LOG_FILE_MAX_BACKUPS", 5),
+		Compress:   getEnv("LOG_FILE_COMPRESS", "false") == "true",
+		Content: redact.Policy{
+			Mode:      contentMode,
+			MaxLength: getEnvInt("LOG_CONTENT_MAX_LENGTH", redact.DefaultMaxLength),
+			HashKey:   getEnv("LOG_CONTENT_HASH_KEY", ""),
+		},
+	})
+	if contentModeErr != nil {
+		log.Error("invalid LOG_CONTENT", "error", contentModeErr)
+		os.Exit(1)
+	}
+
+	// Request history keeps bodies only when REQUEST_HISTORY_CONTENT names a
+	// content mode; the length and hash key are shared with logs
+	historyContent, err := parseHistoryContent(getEnv("REQUEST_HISTORY_CONTENT", ""), redact.Policy{
+		MaxLength: getEnvInt("LOG_CONTENT_MAX_LENGTH", redact.DefaultMaxLength),
+		HashKey:   getEnv("LOG_CONTENT_HASH_KEY", ""),
 	})
+	if err != nil {
+		log.Error("invalid REQUEST_HISTORY_CONTENT", "error", err)
+		os.Exit(1)
+	}
+
+	// SIGUSR1 toggles debug logging without a restart
+	log.ToggleDebugOnSignal()
 
 	log.Info("starting neurogate gateway",
 		"version", version,
 		"http_port", getEnv("HTTP_PORT", defaultHTTPPort),
 	)
 
+	// Spans are only exported when an OTLP endpoint is configured, but trace
+	// context is always passed on to workers
+	shutdownTracing, err := tracing.Setup(tracing.Config{
+		ServiceName:    getEnv("OTEL_SERVICE_NAME", "neurogate-gateway"),
+		ServiceVersion: version,
+		Endpoint:       getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracesEndpoint: getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", ""),
+		Headers:        tracing.ParseHeaders(getEnv("OTEL_EXPORTER_OTLP_HEADERS", "")),
+		SampleRatio:    getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+	})
+	if err != nil {
+		log.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
 	// Get configuration
 	httpPort := getEnv("HTTP_PORT", defaultHTTPPort)
 	metricsPort := getEnv("METRICS_PORT", defaultMetricsPort)
@@ -449,13 +2001,206 @@ func main() {
 	// Parse API keys (comma-separated)
 	apiKeys := strings.Split(getEnv("API_KEYS", ""), ",")
 
+	// Parse admin API keys (comma-separated)
+	adminKeys := strings.Split(getEnv("ADMIN_API_KEYS", ""), ",")
+
 	// Create gateway
-	gateway, err := NewGateway(log, workerAddrs, apiKeys)
+	gateway, err := NewGateway(log, Config{
+		WorkerAddresses:    workerAddrs,
+		APIKeys:            apiKeys,
+		AdminKeys:          adminKeys,
+		CacheTTL:           getEnvDuration("CACHE_TTL", 0),
+		CacheMaxEntries:    getEnvInt("CACHE_MAX_ENTRIES", 1000),
+		CacheMaxEntryBytes: getEnvInt("CACHE_MAX_ENTRY_BYTES", 0),
+		CacheStore:         getEnv("CACHE_STORE", backendMemory),
+
+		SemanticCacheThreshold: getEnvFloat("SEMANTIC_CACHE_THRESHOLD", 0),
+		SemanticCacheModel:     getEnv("SEMANTIC_CACHE_MODEL", "nomic-embed-text"),
+
+		WorkerPublicKeys:  parseKeyValues(getEnv("WORKER_PUBLIC_KEYS", "")),
+		RequireSignatures: getEnv("REQUIRE_SIGNATURES", "false") == "true",
+
+		ValidateFormat: getEnv("VALIDATE_STRUCTURED_OUTPUT", "false") == "true",
+
+		JobWorkers:    getEnvInt("JOB_WORKERS", 4),
+		JobQueueSize:  getEnvInt("JOB_QUEUE_SIZE", 100),
+		JobRetention:  getEnvDuration("JOB_RETENTION", time.Hour),
+		WebhookSecret: getEnv("WEBHOOK_SECRET", ""),
+
+		WebhookAllowedHosts: strings.Split(getEnv("WEBHOOK_ALLOWED_HOSTS", ""), ","),
+
+		QuotaRequests:   int64(getEnvInt("QUOTA_REQUESTS", 0)),
+		QuotaTokens:     int64(getEnvInt("QUOTA_TOKENS", 0)),
+		QuotaWindow:     getEnvDuration("QUOTA_WINDOW", 24*time.Hour),
+		QuotaThresholds: parseThresholds(getEnv("QUOTA_WARN_THRESHOLDS", "0.8,0.95")),
+		QuotaWebhookURL: getEnv("QUOTA_WEBHOOK_URL", ""),
+
+		Tenants: TenantConfig{
+			JWTSecret:        getEnv("JWT_SECRET", ""),
+			JWTIssuer:        getEnv("JWT_ISSUER", ""),
+			JWTAudience:      getEnv("JWT_AUDIENCE", ""),
+			JWTClaim:         getEnv("JWT_TENANT_CLAIM", "tenant"),
+			Quotas:           parseKeyValues(getEnv("TENANT_QUOTAS", "")),
+			DefaultQuota:     getEnv("TENANT_DEFAULT_QUOTA", ""),
+			RateLimits:       parseKeyValues(getEnv("TENANT_RATE_LIMITS", "")),
+			DefaultRateLimit: getEnv("TENANT_DEFAULT_RATE_LIMIT", ""),
+		},
+
+		AlertInterval:    getEnvDuration("ALERT_INTERVAL", time.Minute),
+		AlertSpikeFactor: getEnvFloat("ALERT_SPIKE_FACTOR", 10),
+		AlertErrorRate:   getEnvFloat("ALERT_ERROR_RATE", 0.5),
+		AlertMinRequests: int64(getEnvInt("ALERT_MIN_REQUESTS", 20)),
+		AlertCooldown:    getEnvDuration("ALERT_COOLDOWN", 15*time.Minute),
+
+		UsageMetricsMaxConsumers: getEnvInt("USAGE_METRICS_MAX_CONSUMERS", metrics.DefaultConsumerLimit),
+
+		GuardrailPolicies:      parseKeyValues(getEnv("GUARDRAIL_POLICIES", "")),
+		DefaultGuardrailPolicy: getEnv("GUARDRAIL_DEFAULT_POLICY", guardrails.PolicyNone),
+		Guardrails: guardrails.Config{
+			BannedWords:    strings.Split(getEnv("GUARDRAIL_BANNED_WORDS", ""), ","),
+			MaxPromptChars: getEnvInt("GUARDRAIL_MAX_PROMPT_CHARS", 32000),
+			ProfanityWords: strings.Split(getEnv("GUARDRAIL_PROFANITY_WORDS", ""), ","),
+		},
+		Moderation: ModerationConfig{
+			Model:         getEnv("MODERATION_MODEL", ""),
+			WorkerAddress: getEnv("MODERATION_WORKER_ADDRESS", ""),
+			Stages:        strings.Split(getEnv("MODERATION_STAGES", "prompt"), ","),
+			Categories:    parseKeyValues(getEnv("MODERATION_CATEGORIES", "")),
+			Action:        getEnv("MODERATION_ACTION", "block"),
+			Timeout:       getEnvDuration("MODERATION_TIMEOUT", 10*time.Second),
+			FailOpen:      getEnv("MODERATION_FAIL_OPEN", "false") == "true",
+		},
+
+		SessionStore: getEnv("SESSION_STORE", session.BackendMemory),
+		Sessions: session.Config{
+			TTL:         getEnvDuration("SESSION_TTL", 24*time.Hour),
+			MaxMessages: getEnvInt("SESSION_MAX_MESSAGES", 50),
+		},
+		LimitsStore: getEnv("LIMITS_STORE", backendMemory),
+		RedisURL:    getEnv("REDIS_URL", "redis://localhost:6379/0"),
+
+		ConversationAffinity: getEnv("CONVERSATION_AFFINITY", "true") == "true",
+
+		Store: store.Config{
+			Backend: getEnv("STORE_BACKEND", store.BackendMemory),
+			DSN:     getEnv("STORE_DSN", ""),
+		},
+
+		AuditLog:     getEnv("AUDIT_LOG", ""),
+		AuditLogFile: getEnv("AUDIT_LOG_FILE", "audit.log"),
+		AuditLogKey:  getEnv("AUDIT_LOG_KEY", ""),
+
+		HistoryRetention: getEnvDuration("REQUEST_HISTORY_RETENTION", 0),
+		HistoryContent:   historyContent,
+
+		ClockSkewThreshold: getEnvDuration("CLOCK_SKEW_THRESHOLD", 2*time.Second),
+
+		WorkerHealth: WorkerHealthConfig{
+			Interval:           getEnvDuration("WORKER_HEALTH_INTERVAL", 10*time.Second),
+			Intervals:          parseKeyValues(getEnv("WORKER_HEALTH_INTERVALS", "")),
+			Timeout:            getEnvDuration("WORKER_HEALTH_TIMEOUT", 5*time.Second),
+			UnhealthyThreshold: getEnvInt("WORKER_UNHEALTHY_THRESHOLD", 1),
+			HealthyThreshold:   getEnvInt("WORKER_HEALTHY_THRESHOLD", 1),
+			Jitter:             getEnvFloat("WORKER_HEALTH_JITTER", 0.1),
+		},
+		WorkerState: WorkerStateConfig{
+			Store:        getEnv("WORKER_STATE_STORE", backendMemory),
+			SyncInterval: getEnvDuration("WORKER_STATE_SYNC_INTERVAL", time.Second),
+		},
+
+		WorkerConn: WorkerConnConfig{
+			Connections:         getEnvInt("WORKER_CONNECTIONS", 1),
+			KeepaliveTime:       getEnvDuration("WORKER_KEEPALIVE_TIME", 30*time.Second),
+			KeepaliveTimeout:    getEnvDuration("WORKER_KEEPALIVE_TIMEOUT", 10*time.Second),
+			PermitWithoutStream: getEnv("WORKER_KEEPALIVE_PERMIT_WITHOUT_STREAM", "true") == "true",
+			WaitForReady:        getEnv("WORKER_WAIT_FOR_READY", "false") == "true",
+		},
+		WorkerCalls: newWorkerCallConfig(
+			parseKeyValues(getEnv("WORKER_CALL_TIMEOUTS", "")),
+			getEnvInt("WORKER_CALL_RETRIES", 1),
+			getEnvDuration("WORKER_CALL_RETRY_BACKOFF", 100*time.Millisecond),
+		),
+		Limits: RequestLimits{
+			MaxBodySize:     int64(getEnvInt("MAX_REQUEST_BODY_SIZE", 4<<20)),
+			MaxPromptLength: getEnvInt("MAX_PROMPT_LENGTH", 131072),
+		},
+
+		Scheduler: SchedulerConfig{
+			Enabled:        getEnv("SCHEDULER_ENABLED", "false") == "true",
+			MaxConcurrency: getEnvInt("SCHEDULER_MAX_CONCURRENCY", 0),
+			MaxQueued:      getEnvInt("SCHEDULER_MAX_QUEUED", 1000),
+			JobMaxWait:     getEnvDuration("SCHEDULER_JOB_MAX_WAIT", 10*time.Minute),
+		},
+
+		CircuitBreaker: circuitbreaker.Config{
+			Timeout:           getEnvDuration("CIRCUIT_BREAKER_TIMEOUT", 30*time.Second),
+			BackoffMultiplier: getEnvFloat("CIRCUIT_BREAKER_BACKOFF_MULTIPLIER", 1),
+			MaxTimeout:        getEnvDuration("CIRCUIT_BREAKER_MAX_TIMEOUT", 5*time.Minute),
+			Jitter:            getEnvFloat("CIRCUIT_BREAKER_JITTER", 0),
+
+			WindowSize:      getEnvInt("CIRCUIT_BREAKER_WINDOW_SIZE", 0),
+			WindowDuration:  getEnvDuration("CIRCUIT_BREAKER_WINDOW_DURATION", 0),
+			FailureRate:     getEnvFloat("CIRCUIT_BREAKER_FAILURE_RATE", 0.5),
+			MinimumRequests: getEnvInt("CIRCUIT_BREAKER_MIN_REQUESTS", 10),
+
+			SlowCallThreshold: getEnvDuration("CIRCUIT_BREAKER_SLOW_CALL_THRESHOLD", 0),
+			SlowCallRate:      getEnvFloat("CIRCUIT_BREAKER_SLOW_CALL_RATE", 0.5),
+
+			HalfOpenMaxRequests: getEnvInt("CIRCUIT_BREAKER_HALF_OPEN_MAX_REQUESTS", 1),
+		},
+		PersistBreakers: getEnv("CIRCUIT_BREAKER_PERSIST", "false") == "true",
+
+		FallbackStrategies:     parseFallbackStrategies(getEnv("FALLBACK_STRATEGIES", "")),
+		FallbackEndpoints:      strings.Split(getEnv("FALLBACK_ENDPOINTS", "/prompt,/jobs"), ","),
+		FallbackStaleTTL:       getEnvDuration("FALLBACK_STALE_TTL", time.Hour),
+		EmergencyWorkerAddress: getEnv("EMERGENCY_WORKER_ADDRESS", ""),
+		EmergencyModel:         getEnv("EMERGENCY_MODEL", ""),
+		Cloud: CloudConfig{
+			Provider:     getEnv("CLOUD_PROVIDER", ""),
+			URL:          getEnv("CLOUD_URL", ""),
+			APIKey:       getEnv("CLOUD_API_KEY", ""),
+			Model:        getEnv("CLOUD_MODEL", ""),
+			MaxTokens:    int32(getEnvInt("CLOUD_MAX_TOKENS", 1024)),
+			Budget:       getEnvFloat("CLOUD_BUDGET", 0),
+			BudgetWindow: getEnvDuration("CLOUD_BUDGET_WINDOW", 24*time.Hour),
+			MaxQueueWait: getEnvDuration("CLOUD_MAX_QUEUE_WAIT", 0),
+			Timeout:      getEnvDuration("CLOUD_TIMEOUT", 60*time.Second),
+		},
+
+		ModelAliases:   parseKeyValues(getEnv("MODEL_ALIASES", "")),
+		ModelFallbacks: parseKeyValues(getEnv("MODEL_FALLBACKS", "")),
+		ModelPolicies: ModelPolicyConfig{
+			Timeouts:         parseKeyValues(getEnv("MODEL_TIMEOUTS", "")),
+			MaxTokens:        parseKeyValues(getEnv("MODEL_MAX_TOKENS", "")),
+			DefaultMaxTokens: parseKeyValues(getEnv("MODEL_DEFAULT_MAX_TOKENS", "")),
+			Temperatures:     parseKeyValues(getEnv("MODEL_TEMPERATURE_RANGES", "")),
+			Action:           getEnv("MODEL_POLICY_ACTION", policyClamp),
+		},
+
+		ModelPricing:          parseKeyValues(getEnv("MODEL_PRICING", "")),
+		ModelInferencePricing: parseKeyValues(getEnv("MODEL_INFERENCE_PRICING", "")),
+
+		HealthFailureThreshold: getEnvInt("HEALTH_FAILURE_THRESHOLD", 1),
+		HealthSuccessThreshold: getEnvInt("HEALTH_SUCCESS_THRESHOLD", 1),
+	})
 	if err != nil {
 		log.Error("failed to create gateway", "error", err)
 		os.Exit(1)
 	}
 
+	// With REUSE_PORT a new gateway binary can bind the same ports while
+	// the old one drains, allowing upgrades without refusing connections
+	reusePort := getEnv("REUSE_PORT", "false") == "true"
+	shutdownTimeout := getEnvDuration("SHUTDOWN_TIMEOUT", 10*time.Second)
+	drainDelay := getEnvDuration("DRAIN_DELAY", 0)
+
+	// Serve health probes from a periodically refreshed snapshot instead of
+	// running checks on every request
+	if interval := getEnvDuration("HEALTH_CHECK_INTERVAL", 0); interval > 0 {
+		gateway.healthChecker.Start(context.Background(), interval)
+		log.Info("background health checks enabled", "interval", interval)
+	}
+
 	// Create HTTP server for metrics
 	metricsMux := http.NewServeMux()
 	metricsMux.Handle("/metrics", metrics.Handler())
@@ -464,42 +2209,101 @@ func main() {
 		Handler: metricsMux,
 	}
 
+	metricsListener, err := listener.Listen(metricsServer.Addr, reusePort)
+	if err != nil {
+		log.Error("failed to listen for metrics", "addr", metricsServer.Addr, "error", err)
+		os.Exit(1)
+	}
+
 	go func() {
 		log.Info("metrics server started", "addr", metricsServer.Addr)
-		if err := metricsServer.ListenAndServe(); err != http.ErrServerClosed {
+		if err := metricsServer.Serve(metricsListener); err != http.ErrServerClosed {
 			log.Error("metrics server error", "error", err)
 		}
 	}()
 
-	// Create main HTTP server
+	// Gateways Prometheus can't scrape can push their metrics instead
+	pusher := metrics.StartPusherFromEnv(log.Logger, "neurogate-gateway", "")
+
+	// Create main HTTP server. Read and write deadlines are set per route
+	// by withRouteTimeouts so streaming endpoints can stay open.
+	routeTimeouts := newTimeoutTable(parseKeyValues(getEnv("ROUTE_TIMEOUTS", "")))
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%s", httpPort),
-		Handler:      gateway,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 3 * time.Minute, // Allow for long LLM responses
-		IdleTimeout:  60 * time.Second,
+		Addr: fmt.Sprintf(":%s", httpPort),
+		Handler: requestid.Handler(tracing.Handler(
+			gateway.withRouteTimeouts(routeTimeouts, getEnvDuration("MAX_REQUEST_TIMEOUT", 0)),
+			"/health", "/health/live", "/health/ready",
+		)),
+		ReadHeaderTimeout: getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+		IdleTimeout:       getEnvDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+	}
+
+	// Prefer a socket passed by systemd so the listening socket outlives
+	// gateway restarts
+	ln, err := httpListener(server.Addr, reusePort)
+	if err != nil {
+		log.Error("failed to listen", "addr", server.Addr, "error", err)
+		os.Exit(1)
 	}
 
-	// Graceful shutdown
+	// Graceful shutdown. In-flight requests, including long-lived streams,
+	// get SHUTDOWN_TIMEOUT to finish while a replacement takes new traffic.
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	done := make(chan struct{})
 	go func() {
 		<-sigChan
-		log.Info("shutting down gateway...")
+		log.Info("shutting down gateway...", "timeout", shutdownTimeout)
+
+		// Fail readiness first so load balancers stop routing new traffic
+		// here, then give them DRAIN_DELAY to notice before closing
+		gateway.healthChecker.SetDraining(true)
+		time.Sleep(drainDelay)
 
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer cancel()
 
-		server.Shutdown(ctx)
+		if err := server.Shutdown(ctx); err != nil {
+			log.Warn("shutdown timed out with requests in flight", "error", err)
+		}
 		metricsServer.Shutdown(ctx)
+		if pusher != nil {
+			if err := pusher.Stop(ctx); err != nil {
+				log.Warn("failed to push final metrics", "error", err)
+			}
+		}
+		if err := shutdownTracing(ctx); err != nil {
+			log.Warn("failed to flush traces", "error", err)
+		}
+		if err := gateway.Close(); err != nil {
+			log.Warn("failed to close store", "error", err)
+		}
+		close(done)
 	}()
 
-	log.Info("HTTP server listening", "addr", server.Addr)
-	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+	log.Info("HTTP server listening", "addr", ln.Addr().String(), "reuse_port", reusePort)
+	if err := server.Serve(ln); err != http.ErrServerClosed {
 		log.Error("HTTP server error", "error", err)
 		os.Exit(1)
 	}
+	<-done
+}
+
+// httpListener returns the socket-activated listener if systemd passed one,
+// otherwise binds addr
+func httpListener(addr string, reusePort bool) (net.Listener, error) {
+	activated, err := listener.Activated()
+	if err != nil {
+		return nil, err
+	}
+	if len(activated) > 0 {
+		for _, extra := range activated[1:] {
+			extra.Close()
+		}
+		return activated[0], nil
+	}
+	return listener.Listen(addr, reusePort)
 }
 
 func getEnv(key, defaultValue string) string {
@@ -508,3 +2312,43 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// parseKeyValues parses "k1=v1,k2=v2" into a map. Only the first "=" in each
+// pair separates key from value, so base64 values with padding are preserved.
+func parseKeyValues(s string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && k != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/requestid"
+)
+
+// middleware wraps a handler with behaviour shared by several routes
+type middleware func(http.Handler) http.Handler
+
+// chain wraps h in mws, the first outermost
+func chain(h http.Handler, mws ...middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// statusRecorder captures the status code sent by a handler: the first one
+// written, as later calls to WriteHeader have no effect, or 200 if the
+// handler only wrote a body
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers flush through the recorder
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// routeOf returns the path pattern of the route that matched r, e.g.
+// /jobs/{id}, which unlike the path has a bounded number of values.
+// Requests no route matched share one value.
+func routeOf(r *http.Request) string {
+	if i := strings.IndexByte(r.Pattern, '/'); i >= 0 {
+		return r.Pattern[i:]
+	}
+	return "unmatched"
+}
+
+// recoverPanics answers requests whose handler panicked with 500 instead of
+// dropping the connection, and logs the panic
+func (g *Gateway) recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			g.log.WithRequestID(requestid.FromContext(r.Context())).Error("handler panicked",
+				"method", r.Method, "path", r.URL.Path, "panic", v, "stack", string(debug.Stack()))
+			g.writeError(w, http.StatusInternalServerError, "internal error", "")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cors allows browsers to call the API from any origin, answering preflight
+// requests itself
+func cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, X-Request-Timeout")
+		w.Header().Set("Access-Control-Expose-Headers", requestid.Header)
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logRequests logs each request when it finishes
+func (g *Gateway) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		g.log.WithRequestID(requestid.FromContext(r.Context())).Info("http request",
+			"method", r.Method, "path", r.URL.Path, "status", rec.status, "duration_ms", time.Since(start).Milliseconds())
+	})
+}
+
+// instrument counts requests in flight and records each request's status
+// and duration by route. The status is the one sent: a handler that panics
+// before writing one is recorded as the 500 recoverPanics sends.
+func (g *Gateway) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		g.metrics.IncActiveRequests()
+		defer g.metrics.DecActiveRequests()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			v := recover()
+			status := rec.status
+			if v != nil && !rec.wroteHeader {
+				status = http.StatusInternalServerError
+			}
+			g.metrics.RecordRequest(r.Method, routeOf(r), strconv.Itoa(status), time.Since(start).Seconds())
+			if v != nil {
+				panic(v)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// recordUsage feeds each finished request into the caller's usage alerts
+// and per-consumer request counts
+func (g *Gateway) recordUsage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		authHeader := r.Header.Get("Authorization")
+		g.recordAlertUsage(authHeader, rec.status)
+		g.recordConsumerRequest(authHeader, rec.status)
+	})
+}
+
+// requireAPIKey rejects requests without a valid API key or JWT when
+// authentication is enabled
+func (g *Gateway) requireAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.authEnabled() && !g.validateAPIKey(r.Header.Get("Authorization")) {
+			g.auditAuthFailure(r, "api")
+			g.writeError(w, http.StatusUnauthorized, "invalid or missing API key", "")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// enforceQuota rejects requests over the caller's key quota or tenant rate
+// limit and quota with 429
+func (g *Gateway) enforceQuota(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !g.checkQuota(w, r.Header.Get("Authorization")) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAdminKey rejects requests without an admin key, and audits those
+// with one
+func (g *Gateway) requireAdminKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(g.adminKeys) == 0 {
+			g.writeError(w, http.StatusForbidden, "admin API disabled", "set ADMIN_API_KEYS to enable")
+			return
+		}
+		if !validateKey(r.Header.Get("Authorization"), g.adminKeys) {
+			g.auditAuthFailure(r, "admin")
+			g.writeError(w, http.StatusUnauthorized, "invalid or missing admin key", "")
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() { g.auditAdminCall(r, rec.status) }()
+		next.ServeHTTP(rec, r)
+	})
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultGenerationTimeout bounds a worker generation for models without a
+// timeout of their own
+const defaultGenerationTimeout = 2 * time.Minute
+
+// Model policy actions for requests outside a model's bounds
+const (
+	policyClamp  = "clamp"  // bring the value within bounds
+	policyReject = "reject" // fail the request with 400
+)
+
+// ModelPolicyConfig holds per-model limits as "model=value" entries; a
+// "default" entry applies to models without their own
+type ModelPolicyConfig struct {
+	Timeouts         map[string]string // Generation timeout, e.g. "5m"
+	MaxTokens        map[string]string // Ceiling on max_tokens
+	DefaultMaxTokens map[string]string // max_tokens of requests without one
+	Temperatures     map[string]string // Allowed temperature range, e.g. "0-1.2"
+	Action           string            // "clamp" or "reject" out-of-range values
+}
+
+// modelPolicy is the limits of one model. Zero values are unlimited.
+type modelPolicy struct {
+	Timeout          time.Duration
+	MaxTokens        int32
+	DefaultMaxTokens int32
+	MinTemperature   float32
+	MaxTemperature   float32
+}
+
+// modelPolicies holds the policies by model, and what to do with requests
+// outside them
+type modelPolicies struct {
+	models map[string]modelPolicy
+	reject bool
+}
+
+// newModelPolicies parses the per-model limits
+func newModelPolicies(cfg ModelPolicyConfig) (modelPolicies, error) {
+	p := modelPolicies{models: make(map[string]modelPolicy)}
+	switch cfg.Action {
+	case policyClamp, "":
+	case policyReject:
+		p.reject = true
+	default:
+		return p, fmt.Errorf("unknown action %q", cfg.Action)
+	}
+
+	for model, value := range cfg.Timeouts {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return p, fmt.Errorf("model %s: invalid timeout %q", model, value)
+		}
+		policy := p.models[model]
+		policy.Timeout = d
+		p.models[model] = policy
+	}
+	for model, value := range cfg.MaxTokens {
+		n, err := parseTokenLimit(value)
+		if err != nil {
+			return p, fmt.Errorf("model %s: invalid max tokens: %w", model, err)
+		}
+		policy := p.models[model]
+		policy.MaxTokens = n
+		p.models[model] = policy
+	}
+	for model, value := range cfg.DefaultMaxTokens {
+		n, err := parseTokenLimit(value)
+		if err != nil {
+			return p, fmt.Errorf("model %s: invalid default max tokens: %w", model, err)
+		}
+		policy := p.models[model]
+		policy.DefaultMaxTokens = n
+		p.models[model] = policy
+	}
+	for model, value := range cfg.Temperatures {
+		low, high, ok := strings.Cut(value, "-")
+		lo, err1 := strconv.ParseFloat(strings.TrimSpace(low), 32)
+		hi, err2 := strconv.ParseFloat(strings.TrimSpace(high), 32)
+		if !ok || err1 != nil || err2 != nil || lo < 0 || hi < lo {
+			return p, fmt.Errorf("model %s: invalid temperature range %q, expected min-max", model, value)
+		}
+		policy := p.models[model]
+		policy.MinTemperature, policy.MaxTemperature = float32(lo), float32(hi)
+		p.models[model] = policy
+	}
+
+	for model, policy := range p.models {
+		if policy.MaxTokens > 0 && policy.DefaultMaxTokens > policy.MaxTokens {
+			return p, fmt.Errorf("model %s: default max tokens %d is above the ceiling %d", model, policy.DefaultMaxTokens, policy.MaxTokens)
+		}
+	}
+	return p, nil
+}
+
+func parseTokenLimit(value string) (int32, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not a positive number", value)
+	}
+	return int32(n), nil
+}
+
+// lookup returns a model's policy, or the "default" policy
+func (p modelPolicies) lookup(model string) modelPolicy {
+	if policy, ok := p.models[model]; ok {
+		return policy
+	}
+	return p.models["default"]
+}
+
+// timeout returns the generation timeout of a model
+func (p modelPolicies) timeout(model string) time.Duration {
+	if d := p.lookup(model).Timeout; d > 0 {
+		return d
+	}
+	return defaultGenerationTimeout
+}
+
+// apply fills in a request's default max_tokens and clamps, or rejects with
+// an *apiError, values outside the model's bounds. Zero temperature uses the
+// model's own default and is left alone.
+func (p modelPolicies) apply(req *PromptRequest) error {
+	policy := p.lookup(req.Model)
+	model := req.Model
+	if model == "" {
+		model = "the default model"
+	}
+
+	if req.MaxTokens == 0 {
+		req.MaxTokens = policy.DefaultMaxTokens
+	}
+	if policy.MaxTokens > 0 && (req.MaxTokens == 0 || req.MaxTokens > policy.MaxTokens) {
+		if p.reject && req.MaxTokens != 0 {
+			return policyError("max_tokens must be at most %d for %s", policy.MaxTokens, model)
+		}
+		req.MaxTokens = policy.MaxTokens
+	}
+
+	if policy.MaxTemperature > 0 && req.Temperature != 0 &&
+		(req.Temperature < policy.MinTemperature || req.Temperature > policy.MaxTemperature) {
+		if p.reject {
+			return policyError("temperature must be between %g and %g for %s", policy.MinTemperature, policy.MaxTemperature, model)
+		}
+		req.Temperature = min(max(req.Temperature, policy.MinTemperature), policy.MaxTemperature)
+	}
+	return nil
+}
+
+func policyError(format string, args ...interface{}) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Message: "request outside model policy", Detail: fmt.Sprintf(format, args...)}
+}
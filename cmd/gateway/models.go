@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+
+	"google.golang.org/grpc/status"
+)
+
+// ModelPullProgress is one line of the NDJSON stream returned by
+// POST /admin/models/pull. Each worker's last line has status "success" or
+// an error.
+type ModelPullProgress struct {
+	WorkerID  string `json:"worker_id"`
+	Status    string `json:"status,omitempty"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// WorkerModelResult is the outcome of a model change on one worker
+type WorkerModelResult struct {
+	WorkerID string `json:"worker_id"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ModelInfo describes a model on a worker, for GET /admin/models/{name}
+type ModelInfo struct {
+	WorkerID          string     `json:"worker_id"`
+	Model             string     `json:"model"`
+	Family            string     `json:"family,omitempty"`
+	ParameterSize     string     `json:"parameter_size,omitempty"`
+	QuantizationLevel string     `json:"quantization_level,omitempty"`
+	Format            string     `json:"format,omitempty"`
+	Capabilities      []string   `json:"capabilities,omitempty"`
+	ModifiedAt        *time.Time `json:"modified_at,omitempty"`
+	Parameters        string     `json:"parameters,omitempty"`
+	Template          string     `json:"template,omitempty"`
+	Modelfile         string     `json:"modelfile,omitempty"`
+}
+
+// modelWorkers returns the workers a model operation applies to: the one
+// named by the worker query parameter, or every configured worker
+func (g *Gateway) modelWorkers(r *http.Request) ([]*Worker, error) {
+	if name := r.URL.Query().Get("worker"); name != "" {
+		worker := g.workerByName(name)
+		if worker == nil {
+			return nil, errors.New("worker not found")
+		}
+		return []*Worker{worker}, nil
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if len(g.workers) == 0 {
+		return nil, errors.New("no workers configured")
+	}
+	return append([]*Worker(nil), g.workers...), nil
+}
+
+// handlePullModel handles POST /admin/models/pull. The model is pulled on
+// the targeted workers in parallel and progress is streamed as NDJSON.
+func (g *Gateway) handlePullModel(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model    string `json:"model"`
+		Insecure bool   `json:"insecure"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		g.writeAPIError(w, apiErr)
+		return
+	}
+	if req.Model == "" {
+		g.writeAPIError(w, invalidRequest(FieldError{Field: "model", Message: "is required"}))
+		return
+	}
+	workers, err := g.modelWorkers(r)
+	if err != nil {
+		g.writeError(w, http.StatusNotFound, err.Error(), "")
+		return
+	}
+
+	g.log.Warn("pulling model", "model", req.Model, "workers", len(workers))
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	send := func(p ModelPullProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(p)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func(worker *Worker) {
+			defer wg.Done()
+			if err := pullModel(r.Context(), worker, req.Model, req.Insecure, send); err != nil {
+				g.log.Error("model pull failed", "model", req.Model, "worker", worker.ID, "error", err)
+				send(ModelPullProgress{WorkerID: worker.ID, Error: grpcMessage(err)})
+			}
+		}(worker)
+	}
+	wg.Wait()
+}
+
+// pullModel pulls a model on one worker, relaying its progress to send
+func pullModel(ctx context.Context, worker *Worker, model string, insecure bool, send func(ModelPullProgress)) error {
+	stream, err := worker.Admin.PullModel(ctx, &llmv1.PullModelRequest{Model: model, Insecure: insecure})
+	if err != nil {
+		return err
+	}
+	for {
+		p, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		send(ModelPullProgress{
+			WorkerID:  worker.ID,
+			Status:    p.Status,
+			Digest:    p.Digest,
+			Total:     p.Total,
+			Completed: p.Completed,
+		})
+	}
+}
+
+// handleCopyModel handles POST /admin/models/copy
+func (g *Gateway) handleCopyModel(w http.ResponseWriter, r *http.Request) {
+	var req llmv1.CopyModelRequest
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		g.writeAPIError(w, apiErr)
+		return
+	}
+	var fields []FieldError
+	if req.Source == "" {
+		fields = append(fields, FieldError{Field: "source", Message: "is required"})
+	}
+	if req.Destination == "" {
+		fields = append(fields, FieldError{Field: "destination", Message: "is required"})
+	}
+	if len(fields) > 0 {
+		g.writeAPIError(w, invalidRequest(fields...))
+		return
+	}
+
+	g.applyModelChange(w, r, func(ctx context.Context, worker *Worker) error {
+		_, err := worker.Admin.CopyModel(ctx, &req)
+		return err
+	})
+}
+
+// handleDeleteModel handles DELETE /admin/models/{name}
+func (g *Gateway) handleDeleteModel(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	g.applyModelChange(w, r, func(ctx context.Context, worker *Worker) error {
+		_, err := worker.Admin.DeleteModel(ctx, &llmv1.DeleteModelRequest{Model: name})
+		return err
+	})
+}
+
+// applyModelChange runs change on the targeted workers in parallel and
+// reports each worker's outcome
+func (g *Gateway) applyModelChange(w http.ResponseWriter, r *http.Request, change func(context.Context, *Worker) error) {
+	workers, err := g.modelWorkers(r)
+	if err != nil {
+		g.writeError(w, http.StatusNotFound, err.Error(), "")
+		return
+	}
+
+	results := make([]WorkerModelResult, len(workers))
+	var wg sync.WaitGroup
+	for i, worker := range workers {
+		wg.Add(1)
+		go func(i int, worker *Worker) {
+			defer wg.Done()
+			results[i].WorkerID = worker.ID
+			if err := change(r.Context(), worker); err != nil {
+				g.log.Error("model change failed", "path", r.URL.Path, "worker", worker.ID, "error", err)
+				results[i].Error = grpcMessage(err)
+			}
+		}(i, worker)
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"workers": results,
+	})
+}
+
+// handleShowModel handles GET /admin/models/{name}, describing the model as
+// seen by the first targeted worker that has it
+func (g *Gateway) handleShowModel(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	workers, err := g.modelWorkers(r)
+	if err != nil {
+		g.writeError(w, http.StatusNotFound, err.Error(), "")
+		return
+	}
+
+	var lastErr error
+	for _, worker := range workers {
+		if !worker.Healthy.Load() && len(workers) > 1 {
+			continue
+		}
+		resp, err := worker.Admin.ShowModel(r.Context(), &llmv1.ShowModelRequest{Model: name})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		info := ModelInfo{
+			WorkerID:          worker.ID,
+			Model:             resp.Model,
+			Family:            resp.Family,
+			ParameterSize:     resp.ParameterSize,
+			QuantizationLevel: resp.QuantizationLevel,
+			Format:            resp.Format,
+			Capabilities:      resp.Capabilities,
+			Parameters:        resp.Parameters,
+			Template:          resp.Template,
+			Modelfile:         resp.Modelfile,
+		}
+		if resp.ModifiedAt > 0 {
+			t := time.UnixMilli(resp.ModifiedAt).UTC()
+			info.ModifiedAt = &t
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+		return
+	}
+
+	detail := "no healthy worker"
+	if lastErr != nil {
+		detail = grpcMessage(lastErr)
+	}
+	g.writeError(w, http.StatusNotFound, "model not found", detail)
+}
+
+// grpcMessage returns the message of a gRPC error without its status prefix
+func grpcMessage(err error) string {
+	if st, ok := status.FromError(err); ok {
+		return st.Message()
+	}
+	return err.Error()
+}
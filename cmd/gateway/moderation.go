@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/guardrails"
+	"github.com/hugovillarreal/neurogate/pkg/logger"
+	"github.com/hugovillarreal/neurogate/pkg/moderation"
+)
+
+// Moderation actions
+const (
+	moderationBlock = "block" // reject flagged content
+	moderationFlag  = "flag"  // serve it, reporting the result in the response
+)
+
+// Moderation stages, as configured
+const (
+	moderationStagePrompt   = "prompt"
+	moderationStageResponse = "response"
+)
+
+// moderationPolicy names moderation in the violations it reports
+const moderationPolicy = "moderation"
+
+// moderationMaxTokens bounds the classifier's reply, which is a small JSON
+// object of scores
+const moderationMaxTokens = 256
+
+// ModerationConfig configures the moderation stage
+type ModerationConfig struct {
+	Model         string            // Classifier model; empty disables moderation
+	WorkerAddress string            // Worker running the classifier; empty uses the pool
+	Stages        []string          // "prompt" and/or "response"
+	Categories    map[string]string // Score thresholds by category; empty uses moderation.DefaultCategories
+	Action        string            // "block" or "flag"
+	Timeout       time.Duration     // Per classification. Default: 10s
+	FailOpen      bool              // Let content through when the classifier fails
+}
+
+// ModerationReport is the moderation result of a flagged prompt or response,
+// included in responses when MODERATION_ACTION is "flag"
+type ModerationReport struct {
+	Prompt   *moderation.Result `json:"prompt,omitempty"`
+	Response *moderation.Result `json:"response,omitempty"`
+}
+
+// setupModeration creates the moderator and, if configured, connects to the
+// classifier's worker, which is kept out of normal rotation
+func (g *Gateway) setupModeration(cfg ModerationConfig, usedIDs map[string]bool) error {
+	if cfg.Model == "" {
+		return nil
+	}
+
+	stages := make(map[guardrails.Stage]bool, len(cfg.Stages))
+	for _, s := range cfg.Stages {
+		switch s {
+		case moderationStagePrompt:
+			stages[guardrails.StagePre] = true
+		case moderationStageResponse:
+			stages[guardrails.StagePost] = true
+		case "":
+		default:
+			return fmt.Errorf("unknown moderation stage %q", s)
+		}
+	}
+	if cfg.Action != moderationBlock && cfg.Action != moderationFlag {
+		return fmt.Errorf("unknown moderation action %q", cfg.Action)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	thresholds := make(map[string]float64, len(cfg.Categories))
+	for category, v := range cfg.Categories {
+		threshold, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("category %s: invalid threshold %q", category, v)
+		}
+		thresholds[category] = threshold
+	}
+	moderator, err := moderation.New(thresholds, g.classify)
+	if err != nil {
+		return err
+	}
+	if cfg.WorkerAddress != "" {
+		worker, err := g.createWorker(cfg.WorkerAddress, usedIDs)
+		if err != nil {
+			return fmt.Errorf("connect to moderation worker: %w", err)
+		}
+		g.moderationWorker = worker
+	}
+
+	g.moderator = moderator
+	g.moderation = cfg
+	g.moderationStages = stages
+	return nil
+}
+
+// classify runs the classifier model, implementing moderation.GenerateFunc
+func (g *Gateway) classify(ctx context.Context, system, text string, format json.RawMessage) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.moderation.Timeout)
+	defer cancel()
+
+	worker := g.moderationWorker
+	if worker == nil {
+		var err error
+		if worker, err = g.selectWorker(ctx, "", nil); err != nil {
+			return "", err
+		}
+	}
+
+	// Greedy decoding with a fixed seed keeps scores repeatable
+	seed := int64(0)
+	resp, err := g.forward(ctx, worker, fmt.Sprintf("moderation-%d", time.Now().UnixNano()), &PromptRequest{
+		Query:        text,
+		Model:        g.moderation.Model,
+		MaxTokens:    moderationMaxTokens,
+		SystemPrompt: system,
+		Sampling:     Sampling{TopK: 1, Seed: &seed},
+		Format:       format,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Response, nil
+}
+
+// moderate classifies text at a stage. Flagged text is blocked with an
+// *apiError, or returned as a result to report when the action is "flag".
+// Text that isn't flagged, or isn't moderated at this stage, returns nil.
+func (g *Gateway) moderate(ctx context.Context, stage guardrails.Stage, text string) (*moderation.Result, error) {
+	if g.moderator == nil || !g.moderationStages[stage] {
+		return nil, nil
+	}
+
+	result, err := g.moderator.Check(ctx, text)
+	if err != nil {
+		logger.FromContext(ctx).Warn("moderation failed", "stage", stage, "error", err)
+		g.metrics.RecordModeration(string(stage), "error")
+		if g.moderation.FailOpen {
+			return nil, nil
+		}
+		return nil, &apiError{Status: http.StatusServiceUnavailable, Message: "moderation unavailable", Detail: err.Error()}
+	}
+	if !result.Flagged {
+		g.metrics.RecordModeration(string(stage), "allowed")
+		return nil, nil
+	}
+
+	logger.FromContext(ctx).Warn("content flagged by moderation", "stage", stage, "categories", result.Categories, "action", g.moderation.Action)
+	if g.moderation.Action == moderationFlag {
+		g.metrics.RecordModeration(string(stage), "flagged")
+		return result, nil
+	}
+
+	g.metrics.RecordModeration(string(stage), "blocked")
+	v := &guardrails.Violation{Policy: moderationPolicy, Check: moderationPolicy, Stage: stage, Reason: "flagged for " + result.Reason()}
+	if stage == guardrails.StagePre {
+		return nil, &apiError{Status: http.StatusBadRequest, Message: "prompt violates policy", Detail: v.Reason, Code: CodeContentBlocked, Violation: v}
+	}
+	return nil, &apiError{Status: http.StatusBadGateway, Message: "response violates policy", Detail: v.Reason, Code: CodeContentBlocked, Violation: v}
+}
+
+// reviewResponse runs a response through the post-checks of policy and
+// response moderation. Errors are returned as *apiError.
+func (g *Gateway) reviewResponse(ctx context.Context, policy *guardrails.Pipeline, resp *PromptResponse) error {
+	if err := g.checkResponse(policy, resp); err != nil {
+		return err
+	}
+
+	flagged, err := g.moderate(ctx, guardrails.StagePost, resp.Response)
+	if err != nil {
+		return err
+	}
+	if flagged != nil {
+		if resp.Moderation == nil {
+			resp.Moderation = &ModerationReport{}
+		}
+		resp.Moderation.Response = flagged
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/circuitbreaker"
+	"github.com/hugovillarreal/neurogate/pkg/quota"
+	"github.com/hugovillarreal/neurogate/pkg/store"
+)
+
+// Store collections used by the gateway
+const (
+	collectionKeys     = "keys"
+	collectionJobs     = "jobs"
+	collectionUsage    = "usage"
+	collectionBreakers = "breakers"
+	collectionHistory  = "history"
+)
+
+// stateSaveInterval is how often quota usage and circuit breaker counters
+// are written to the store
+const stateSaveInterval = 30 * time.Second
+
+// storeTimeout bounds a single store operation
+const storeTimeout = 5 * time.Second
+
+// storedJob is a job as persisted. The submitter's Authorization header is
+// not stored; only its owner hash is needed to serve the job back.
+type storedJob struct {
+	Job
+	Owner string `json:"owner"`
+}
+
+// restoreState loads keys, jobs and quota usage saved by a previous run
+func (g *Gateway) restoreState() error {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	keys, err := g.store.List(ctx, collectionKeys)
+	if err != nil {
+		return fmt.Errorf("load keys: %w", err)
+	}
+	if len(keys) > 0 {
+		doc := AccessConfig{Version: accessConfigVersion}
+		legacy := false
+		for id, value := range keys {
+			var policy KeyPolicy
+			if err := json.Unmarshal(value, &policy); err != nil {
+				g.log.Warn("skipping unreadable stored key", "key_id", storedKeyID(id), "error", err)
+				continue
+			}
+			legacy = legacy || policy.Key != ""
+			doc.Keys = append(doc.Keys, policy)
+		}
+		result := g.importKeys(doc, false, false)
+		g.log.Info("restored keys", "keys", len(doc.Keys), "created", result.Created, "updated", result.Updated)
+		if legacy {
+			// Rewrite keys saved in the clear by hash
+			g.saveKeys()
+		}
+	}
+
+	jobs, err := g.store.List(ctx, collectionJobs)
+	if err != nil {
+		return fmt.Errorf("load jobs: %w", err)
+	}
+	for id, value := range jobs {
+		var stored storedJob
+		if err := json.Unmarshal(value, &stored); err != nil {
+			g.log.Warn("skipping unreadable stored job", "job_id", id, "error", err)
+			continue
+		}
+		job := stored.Job
+		job.owner = stored.Owner
+
+		// Jobs that were queued or running when the gateway stopped can't be
+		// resumed, since their credentials aren't stored
+		if job.CompletedAt == nil {
+			now := time.Now()
+			job.Status = JobFailed
+			job.CompletedAt = &now
+			job.Error = &ErrorResponse{
+				Error:     "job interrupted",
+				Code:      http.StatusServiceUnavailable,
+				Message:   "the gateway restarted before the job finished",
+				ErrorCode: CodeUnavailable,
+				Retryable: true,
+			}
+		}
+		g.jobs.restore(&job)
+		g.saveJob(job.ID)
+	}
+	if len(jobs) > 0 {
+		g.log.Info("restored jobs", "jobs", len(jobs))
+	}
+
+	usage, err := g.store.List(ctx, collectionUsage)
+	if err != nil {
+		return fmt.Errorf("load usage: %w", err)
+	}
+	snapshot := make(map[string]quota.WindowUsage, len(usage))
+	tenants := make(map[string]quota.WindowUsage)
+	for id, value := range usage {
+		var u quota.WindowUsage
+		if err := json.Unmarshal(value, &u); err != nil {
+			continue
+		}
+		if tenant, ok := strings.CutPrefix(id, tenantUsagePrefix); ok {
+			tenants[tenant] = u
+			continue
+		}
+		hash := id
+		if g.apiKeys.has(keyHash(id)) {
+			// Usage saved in the clear under the key itself
+			hash = keyHash(id)
+			g.store.Delete(ctx, collectionUsage, id)
+		}
+		snapshot[hash] = u
+	}
+	g.quota.Restore(snapshot)
+	g.tenantQuota.Restore(tenants)
+
+	if g.persistBreakers {
+		breakers, err := g.store.List(ctx, collectionBreakers)
+		if err != nil {
+			return fmt.Errorf("load circuit breakers: %w", err)
+		}
+		states := make(map[string]circuitbreaker.SavedState, len(breakers))
+		for id, value := range breakers {
+			var state circuitbreaker.SavedState
+			if err := json.Unmarshal(value, &state); err == nil {
+				states[id] = state
+			}
+		}
+		g.breakers.Restore(states)
+	}
+
+	return nil
+}
+
+// saveKeys writes every key policy to the store by keyHash, without the key
+// itself, and removes deleted keys. Saves are serialized so an older
+// snapshot never overwrites a newer one.
+func (g *Gateway) saveKeys() {
+	g.keySaveMu.Lock()
+	defer g.keySaveMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	stored, err := g.store.List(ctx, collectionKeys)
+	if err != nil {
+		g.log.Warn("failed to save keys", "error", err)
+		return
+	}
+	for _, policy := range g.keyPolicies() {
+		delete(stored, policy.KeyHash)
+		if err := store.PutJSON(ctx, g.store, collectionKeys, policy.KeyHash, policy); err != nil {
+			g.log.Warn("failed to save key", "key_id", hashID(policy.KeyHash), "error", err)
+		}
+	}
+	for id := range stored {
+		if err := g.store.Delete(ctx, collectionKeys, id); err != nil {
+			g.log.Warn("failed to delete stored key", "key_id", storedKeyID(id), "error", err)
+		}
+	}
+}
+
+// storedKeyID returns the keyID of a stored key record: records are saved
+// by keyHash, but older ones by the key itself
+func storedKeyID(id string) string {
+	if isKeyHash(id) {
+		return hashID(id)
+	}
+	return keyID(id)
+}
+
+// saveJob writes the current snapshot of a job to the store
+func (g *Gateway) saveJob(id string) {
+	job, ok := g.jobs.get(id)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := store.PutJSON(ctx, g.store, collectionJobs, id, storedJob{Job: job, Owner: job.owner}); err != nil {
+		g.log.Warn("failed to save job", "job_id", id, "error", err)
+	}
+}
+
+// deleteJob removes an expired job from the store
+func (g *Gateway) deleteJob(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := g.store.Delete(ctx, collectionJobs, id); err != nil {
+		g.log.Warn("failed to delete stored job", "job_id", id, "error", err)
+	}
+}
+
+// saveUsage writes quota usage for the current windows to the store. Usage
+// kept in Redis needs no saving.
+func (g *Gateway) saveUsage() {
+	if g.limitsRedis != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	snapshot := g.quota.Snapshot()
+	for tenant, u := range g.tenantQuota.Snapshot() {
+		snapshot[tenantUsagePrefix+tenant] = u
+	}
+	stored, err := g.store.List(ctx, collectionUsage)
+	if err != nil {
+		g.log.Warn("failed to save usage", "error", err)
+		return
+	}
+	for id, u := range snapshot {
+		delete(stored, id)
+		if err := store.PutJSON(ctx, g.store, collectionUsage, id, u); err != nil {
+			g.log.Warn("failed to save usage", "id", id, "error", err)
+		}
+	}
+	for id := range stored {
+		g.store.Delete(ctx, collectionUsage, id)
+	}
+}
+
+// saveBreakers writes every worker's circuit breaker state to the store when
+// breaker persistence is enabled. Saves are serialized so an older snapshot
+// never overwrites a newer one.
+func (g *Gateway) saveBreakers() {
+	if !g.persistBreakers {
+		return
+	}
+	g.breakerSaveMu.Lock()
+	defer g.breakerSaveMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	for id, state := range g.breakers.Save() {
+		if err := store.PutJSON(ctx, g.store, collectionBreakers, id, state); err != nil {
+			g.log.Warn("failed to save circuit breaker", "worker", id, "error", err)
+		}
+	}
+}
+
+// runStateSaver periodically saves quota usage and circuit breaker counters
+func (g *Gateway) runStateSaver() {
+	ticker := time.NewTicker(stateSaveInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		g.saveUsage()
+		g.saveBreakers()
+	}
+}
+
+// Close saves quota usage and circuit breakers and closes the store
+func (g *Gateway) Close() error {
+	g.saveUsage()
+	g.saveBreakers()
+	if g.auditLog != nil {
+		g.auditLog.Close()
+	}
+	g.sessions.Close()
+	if g.limitsRedis != nil {
+		g.limitsRedis.Close()
+	}
+	if g.cacheRedis != nil {
+		g.cacheRedis.Close()
+	}
+	if g.workerSync != nil {
+		g.workerSync.client.Close()
+	}
+	return g.store.Close()
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/quota"
+)
+
+// QuotaWarning is the webhook payload sent when a key crosses a soft quota
+// threshold
+type QuotaWarning struct {
+	Event     string    `json:"event"`
+	KeyID     string    `json:"key_id"`
+	Resource  string    `json:"resource"`
+	Threshold float64   `json:"threshold"`
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`
+	ResetAt   time.Time `json:"reset_at"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// anonymousConsumer labels usage when API key authentication is disabled
+const anonymousConsumer = "anonymous"
+
+// quotaStore returns the store shared by replicas for quota usage, or nil
+// when usage is kept in memory
+func (g *Gateway) quotaStore() quota.Store {
+	if g.limitsRedis == nil {
+		return nil
+	}
+	return quota.NewRedis(g.limitsRedis)
+}
+
+// limitsStoreFailed logs a failed rate limit or quota lookup; the request
+// it was for is allowed
+func (g *Gateway) limitsStoreFailed(err error) {
+	g.log.Warn("limits store unavailable, allowing request", "error", err)
+}
+
+// checkQuota rejects the request with 429 when the caller's key has exhausted
+// its quota, or its tenant is over its limits. Requests without a key are
+// not subject to key quotas.
+func (g *Gateway) checkQuota(w http.ResponseWriter, authHeader string) bool {
+	hash := g.keyHashOf(authHeader)
+	if hash == "" {
+		return g.checkTenant(w, authHeader)
+	}
+
+	status, err := g.quota.Check(hash)
+	setQuotaHeaders(w, status)
+	if err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(status.ResetAt).Seconds())+1))
+		g.metrics.RecordShed("quota")
+		g.writeAPIError(w, &apiError{
+			Status:  http.StatusTooManyRequests,
+			Message: "quota exceeded",
+			Detail:  fmt.Sprintf("quota resets at %s", status.ResetAt.UTC().Format(time.RFC3339)),
+			Code:    CodeQuotaExceeded,
+		})
+		return false
+	}
+	return g.checkTenant(w, authHeader)
+}
+
+// recordQuota charges a completed request to the caller's key and tenant.
+// When w is non-nil the updated key usage is reported in the response
+// headers.
+func (g *Gateway) recordQuota(w http.ResponseWriter, authHeader string, tokens int32) {
+	if consumer, ok := g.consumerFor(authHeader); ok {
+		g.metrics.RecordConsumerTokens(consumer, int(tokens))
+	}
+	if tenant := g.tenantOf(authHeader); tenant != "" {
+		g.tenantQuota.Record(tenant, int64(tokens))
+	}
+
+	hash := g.keyHashOf(authHeader)
+	if hash == "" {
+		return
+	}
+
+	status := g.quota.Record(hash, int64(tokens))
+	if w != nil {
+		setQuotaHeaders(w, status)
+	}
+}
+
+// recordConsumerRequest counts a finished request towards the caller's usage
+// metrics
+func (g *Gateway) recordConsumerRequest(authHeader string, status int) {
+	if consumer, ok := g.consumerFor(authHeader); ok {
+		g.metrics.RecordConsumerRequest(consumer, strconv.Itoa(status))
+	}
+}
+
+// consumerFor returns the usage metrics label for a request's caller: its
+// tenant, else its hashed key. Without authentication every caller is
+// anonymous; with it, requests without a valid key or JWT are not counted,
+// so unknown callers can't create new series.
+func (g *Gateway) consumerFor(authHeader string) (string, bool) {
+	if !g.authEnabled() {
+		return anonymousConsumer, true
+	}
+	if !g.validateAPIKey(authHeader) {
+		return "", false
+	}
+	if tenant := g.tenantOf(authHeader); tenant != "" {
+		return tenant, true
+	}
+	return keyID(bearerToken(authHeader)), true
+}
+
+// notifyQuotaWarning logs a soft quota warning and delivers it to the quota
+// webhook and the key's alert webhook, if configured
+func (g *Gateway) notifyQuotaWarning(e quota.Event) {
+	go g.notifyQuotaAlert(e)
+
+	warning := QuotaWarning{
+		Event:     "quota.warning",
+		KeyID:     hashID(e.Key),
+		Resource:  string(e.Warning.Resource),
+		Threshold: e.Warning.Threshold,
+		Used:      e.Warning.Used,
+		Limit:     e.Warning.Limit,
+		ResetAt:   e.ResetAt,
+		Timestamp: time.Now(),
+	}
+
+	log := g.log.With("key_id", warning.KeyID, "resource", warning.Resource)
+	log.Warn("quota threshold crossed",
+		"threshold", warning.Threshold,
+		"used", warning.Used,
+		"limit", warning.Limit,
+		"reset_at", warning.ResetAt,
+	)
+
+	if g.quotaWebhookURL == "" {
+		return
+	}
+	body, err := json.Marshal(warning)
+	if err != nil {
+		return
+	}
+	g.postWebhook(g.quotaWebhookURL, body, log.With("webhook_url", g.quotaWebhookURL))
+}
+
+// setQuotaHeaders reports limits, remaining allowance and any crossed soft
+// thresholds. Unlimited resources are omitted.
+func setQuotaHeaders(w http.ResponseWriter, status quota.Status) {
+	h := w.Header()
+	if status.Limits.Requests > 0 {
+		h.Set("X-Quota-Limit-Requests", strconv.FormatInt(status.Limits.Requests, 10))
+		h.Set("X-Quota-Remaining-Requests", strconv.FormatInt(max(status.Limits.Requests-status.Requests, 0), 10))
+	}
+	if status.Limits.Tokens > 0 {
+		h.Set("X-Quota-Limit-Tokens", strconv.FormatInt(status.Limits.Tokens, 10))
+		h.Set("X-Quota-Remaining-Tokens", strconv.FormatInt(max(status.Limits.Tokens-status.Tokens, 0), 10))
+	}
+	if status.Limits.Requests > 0 || status.Limits.Tokens > 0 {
+		h.Set("X-Quota-Reset", strconv.FormatInt(status.ResetAt.Unix(), 10))
+	}
+
+	h.Del("X-Quota-Warning")
+	if len(status.Warnings) > 0 {
+		parts := make([]string, len(status.Warnings))
+		for i, warning := range status.Warnings {
+			parts[i] = fmt.Sprintf("%s=%d%%", warning.Resource, int(warning.Threshold*100))
+		}
+		h.Set("X-Quota-Warning", strings.Join(parts, ", "))
+	}
+}
+
+// bearerToken extracts the token from a "Bearer <token>" header
+func bearerToken(authHeader string) string {
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return ""
+	}
+	return parts[1]
+}
+
+// keyHash returns the hex SHA-256 of an API key. The gateway identifies
+// keys by their hash, so neither its state nor the store holds them in the
+// clear.
+func keyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// isKeyHash reports whether s has the form of a keyHash
+func isKeyHash(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil && strings.ToLower(s) == s
+}
+
+// keyID returns a short non-reversible identifier for an API key, safe to
+// include in logs and webhooks
+func keyID(key string) string {
+	return hashID(keyHash(key))
+}
+
+// hashID returns the keyID of the key with the given hash
+func hashID(hash string) string {
+	return "key-" + hash[:8]
+}
+
+// parseThresholds parses a comma-separated list of fractions, ignoring
+// values outside (0, 1]
+func parseThresholds(s string) []float64 {
+	var thresholds []float64
+	for _, part := range strings.Split(s, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err == nil && f > 0 && f <= 1 {
+			thresholds = append(thresholds, f)
+		}
+	}
+	return thresholds
+}
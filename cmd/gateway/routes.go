@@ -0,0 +1,96 @@
+package main
+
+import "net/http"
+
+// routes registers the gateway's endpoints, each wrapped in the middleware
+// it needs. Admin endpoints are on a mux of their own behind the admin key
+// check. Every request, including those no route matches, is logged and
+// instrumented once.
+func (g *Gateway) routes() {
+	// Middleware lists, outermost first. Usage is recorded outside the auth
+	// check so rejected requests count as errors.
+	observed := []middleware{g.logRequests, g.instrument}
+	authed := []middleware{g.logRequests, g.instrument, g.requireAPIKey}
+	metered := []middleware{g.logRequests, g.instrument, g.recordUsage, g.requireAPIKey, g.enforceQuota}
+
+	mux := http.NewServeMux()
+	handle := func(pattern string, h http.HandlerFunc, mws []middleware) {
+		mux.Handle(pattern, chain(h, mws...))
+	}
+	handle("POST /prompt", g.handlePrompt, metered)
+	handle("POST /embeddings", g.handleEmbeddings, metered)
+	handle("POST /tokenize", g.handleTokenize, authed)
+	handle("POST /jobs", g.handleCreateJob, metered)
+	handle("GET /jobs/{id}", g.handleGetJob, authed)
+	handle("GET /conversations/{id}", g.handleConversation, authed)
+	handle("DELETE /conversations/{id}", g.handleConversation, authed)
+	handle("GET /requests", g.handleListHistory, observed)
+	handle("PUT /alerts/webhook", g.handleSetAlertWebhook, observed)
+	handle("DELETE /alerts/webhook", g.handleDeleteAlertWebhook, observed)
+	handle("/health", g.healthChecker.HTTPHandler(), observed)
+	handle("/health/live", g.healthChecker.LiveHandler(), observed)
+	handle("/health/ready", g.healthChecker.ReadyHandler(), observed)
+	handle("GET /status", g.handleStatus, observed)
+	handle("GET /workers", g.handleListWorkers, observed)
+	handle("GET /autoscaling", g.handleAutoscaling, observed)
+
+	admin := http.NewServeMux()
+	admin.HandleFunc("GET /admin/requests", g.handleListInflight)
+	admin.HandleFunc("GET /admin/requests/{id}/stream", g.handleStreamInflight)
+	admin.HandleFunc("GET /admin/keys/export", g.handleExportKeys)
+	admin.HandleFunc("POST /admin/keys/import", g.handleImportKeys)
+	admin.HandleFunc("PUT /admin/status/incident", g.handleSetIncident)
+	admin.HandleFunc("DELETE /admin/status/incident", g.handleClearIncident)
+	admin.HandleFunc("GET /admin/audit/verify", g.handleVerifyAudit)
+	admin.HandleFunc("GET /admin/log-level", g.handleGetLogLevel)
+	admin.HandleFunc("PUT /admin/log-level", g.handleSetLogLevel)
+	admin.HandleFunc("POST /admin/workers/{id}/circuit-breaker", g.handleBreakerAction)
+	admin.HandleFunc("POST /admin/workers/{id}/breaker", g.handleBreakerAction) // Deprecated alias
+	admin.HandleFunc("POST /admin/models/pull", g.handlePullModel)
+	admin.HandleFunc("POST /admin/models/copy", g.handleCopyModel)
+	// Model names may contain slashes, e.g. hf.co/org/model
+	admin.HandleFunc("GET /admin/models/{name...}", g.handleShowModel)
+	admin.HandleFunc("DELETE /admin/models/{name...}", g.handleDeleteModel)
+	// Serving r with the admin mux sets r.Pattern to the admin route, so
+	// requests are instrumented by it rather than by /admin/
+	mux.Handle("/admin/", chain(g.serveMux(admin), g.logRequests, g.instrument, g.requireAdminKey))
+
+	g.handler = chain(g.serveMux(mux, observed...), g.recoverPanics, cors, g.limitBody)
+}
+
+// serveMux returns a handler serving requests with mux. Requests no route
+// matches go through unmatched, then get the gateway's JSON errors: 404, or
+// 405 with an Allow header when the path has routes for other methods.
+func (g *Gateway) serveMux(mux *http.ServeMux, unmatched ...middleware) http.Handler {
+	notFound := chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, _ := mux.Handler(r)
+		rec := &unmatchedRecorder{header: make(http.Header)}
+		h.ServeHTTP(rec, r)
+		if rec.status == http.StatusMethodNotAllowed {
+			w.Header().Set("Allow", rec.header.Get("Allow"))
+			g.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+			return
+		}
+		g.writeError(w, http.StatusNotFound, "not found", "")
+	}), unmatched...)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern == "" {
+			notFound.ServeHTTP(w, r)
+			return
+		}
+		// Only ServeHTTP sets the request's path values and pattern
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// unmatchedRecorder captures the mux's response to a request without a
+// route, discarding its plain text body
+type unmatchedRecorder struct {
+	header http.Header
+	status int
+}
+
+func (u *unmatchedRecorder) Header() http.Header         { return u.header }
+func (u *unmatchedRecorder) Write(b []byte) (int, error) { return len(b), nil }
+func (u *unmatchedRecorder) WriteHeader(status int)      { u.status = status }
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/scheduler"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SchedulerConfig controls the queue in front of worker dispatch
+type SchedulerConfig struct {
+	Enabled        bool
+	MaxConcurrency int           // Prompts sent to workers at once; 0 uses the slots of workers in rotation
+	MaxQueued      int           // Prompts waiting at once; 0 is unlimited
+	JobMaxWait     time.Duration // Longest a job waits to be admitted; 0 is unlimited
+}
+
+// newScheduler creates the gateway's scheduler, or returns nil when it's
+// disabled
+func (g *Gateway) newScheduler(cfg SchedulerConfig) *scheduler.Scheduler {
+	if !cfg.Enabled {
+		return nil
+	}
+	capacity := g.fleetSlots
+	if cfg.MaxConcurrency > 0 {
+		capacity = func() int { return cfg.MaxConcurrency }
+	}
+	return scheduler.New(scheduler.Config{Capacity: capacity, MaxQueued: cfg.MaxQueued})
+}
+
+// fleetSlots returns the concurrent generations of the workers in rotation,
+// 0 if none are, so requests queue until one returns, or
+// scheduler.Unlimited if any of them doesn't report its capacity
+func (g *Gateway) fleetSlots() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	slots := 0
+	for _, w := range g.workers {
+		// Breakers only half-open when a request is let through, so a worker
+		// whose open timeout has passed counts as in rotation again
+		if !w.Healthy.Load() || !w.CB.Available() {
+			continue
+		}
+		c := w.Capacity.Load()
+		if c == nil || c.Slots <= 0 {
+			return scheduler.Unlimited
+		}
+		slots += int(c.Slots)
+	}
+	return slots
+}
+
+// ticketFor returns how a prompt from the caller in authHeader is queued.
+// The requested priority can't exceed the key's, and requests are shared
+// out fairly between tenants, or keys without a tenant, by key weight.
+func (g *Gateway) ticketFor(authHeader, requested string) scheduler.Ticket {
+	t := scheduler.Ticket{Flow: g.tenantOf(authHeader), Weight: 1}
+	if p, err := scheduler.ParsePriority(requested); err == nil {
+		t.Priority = p
+	}
+
+	hash := g.keyHashOf(authHeader)
+	if hash == "" {
+		return t
+	}
+	if t.Flow == "" {
+		t.Flow = hashID(hash)
+	}
+	if name, ok := g.apiKeys.priorityFor(hash); ok {
+		// Lower classes have higher values
+		if p, err := scheduler.ParsePriority(name); err == nil && p > t.Priority {
+			t.Priority = p
+		}
+	}
+	if weight, ok := g.apiKeys.weightFor(hash); ok {
+		t.Weight = weight
+	}
+	return t
+}
+
+// admit waits for the scheduler to let a prompt go to a worker, returning
+// the function to call once it's done. Without a scheduler it returns
+// immediately. Prompts without a deadline of their own, such as jobs, wait
+// at most JobMaxWait. Errors are returned as *apiError.
+func (g *Gateway) admit(ctx context.Context, t scheduler.Ticket) (func(), error) {
+	if g.scheduler == nil {
+		return func() {}, nil
+	}
+	// With no worker in rotation nothing would admit the prompt before its
+	// deadline, so it fails at once and can degrade while there's time
+	if g.fleetSlots() == 0 {
+		return nil, g.noWorkerError(errors.New("no workers in rotation"))
+	}
+	if _, ok := ctx.Deadline(); !ok && g.schedulerJobWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.schedulerJobWait)
+		defer cancel()
+	}
+
+	start := time.Now()
+	release, err := g.scheduler.Acquire(ctx, t)
+	g.metrics.RecordSchedulerWait(t.Priority.String(), time.Since(start).Seconds())
+	switch {
+	case err == nil:
+		return release, nil
+	case errors.Is(err, scheduler.ErrQueueFull):
+		g.metrics.RecordShed("scheduler_queue_full")
+		return nil, &apiError{
+			Status:     http.StatusServiceUnavailable,
+			Message:    "scheduler queue full",
+			Detail:     "retry later",
+			Code:       CodeOverloaded,
+			RetryAfter: time.Second,
+		}
+	default:
+		g.metrics.RecordShed("scheduler_timeout")
+		return nil, &apiError{
+			Status:  http.StatusGatewayTimeout,
+			Message: "timed out waiting for a worker",
+			Detail:  err.Error(),
+			Code:    CodeTimeout,
+		}
+	}
+}
+
+// registerSchedulerMetrics exports the scheduler's queue as gauges
+func (g *Gateway) registerSchedulerMetrics() {
+	collectors := []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "neurogate_gateway",
+			Name:      "scheduler_running",
+			Help:      "Prompts admitted by the scheduler and not yet finished",
+		}, func() float64 { return float64(g.scheduler.Running()) }),
+	}
+	for _, p := range scheduler.Priorities() {
+		collectors = append(collectors, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   "neurogate_gateway",
+			Name:        "scheduler_queued",
+			Help:        "Prompts waiting for the scheduler to admit them, by priority",
+			ConstLabels: prometheus.Labels{"priority": p.String()},
+		}, func() float64 { return float64(g.scheduler.Queued(p)) }))
+	}
+	prometheus.MustRegister(collectors...)
+}
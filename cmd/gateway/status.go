@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/health"
+)
+
+const (
+	// statusLatencyWindow is how far back the public p95 latency looks
+	statusLatencyWindow = 5 * time.Minute
+
+	// maxLatencySamples bounds the memory used by the latency tracker
+	maxLatencySamples = 2048
+)
+
+// PublicStatus is the sanitized view served by /status. It deliberately
+// omits worker IDs, addresses and counts.
+type PublicStatus struct {
+	Status       string        `json:"status"` // operational, degraded or outage
+	UpdatedAt    time.Time     `json:"updated_at"`
+	Models       []ModelStatus `json:"models"`
+	LatencyP95Ms *int64        `json:"latency_p95_ms"` // null without recent traffic
+	Incident     *Incident     `json:"incident,omitempty"`
+}
+
+// ModelStatus reports whether a model can currently be served
+type ModelStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // available or unavailable
+}
+
+// Incident is an operator-provided note shown on the public status page
+type Incident struct {
+	Message   string    `json:"message"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// handleStatus handles GET /status
+func (g *Gateway) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := PublicStatus{
+		Status:    publicHealth(g.healthStatus(r.Context())),
+		UpdatedAt: time.Now().UTC(),
+		Models:    g.modelStatuses(),
+		Incident:  g.incident.Load(),
+	}
+	if p95, ok := g.latency.percentile(0.95); ok {
+		ms := p95.Milliseconds()
+		status.LatencyP95Ms = &ms
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=10")
+	json.NewEncoder(w).Encode(status)
+}
+
+// healthStatus returns the overall health, preferring the background snapshot
+func (g *Gateway) healthStatus(ctx context.Context) health.Status {
+	if latest := g.healthChecker.Latest(); latest != nil {
+		return latest.Status
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return g.healthChecker.Run(ctx).Status
+}
+
+// publicHealth maps internal health to status page terminology
+func publicHealth(s health.Status) string {
+	switch s {
+	case health.StatusHealthy:
+		return "operational"
+	case health.StatusDegraded:
+		return "degraded"
+	default:
+		return "outage"
+	}
+}
+
+// modelStatuses lists every model reported by any worker, marking it
+// available when at least one healthy worker with a closed circuit has it
+func (g *Gateway) modelStatuses() []ModelStatus {
+	g.mu.RLock()
+	available := make(map[string]bool)
+	for _, w := range g.workers {
+		models := w.Models.Load()
+		if models == nil {
+			continue
+		}
+		up := w.Healthy.Load() && breakerUp(w.CB.State())
+		for _, m := range *models {
+			available[m] = available[m] || up
+		}
+	}
+	g.mu.RUnlock()
+
+	statuses := make([]ModelStatus, 0, len(available))
+	for name, up := range available {
+		s := ModelStatus{Name: name, Status: "unavailable"}
+		if up {
+			s.Status = "available"
+		}
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// handleSetIncident handles PUT /admin/status/incident
+func (g *Gateway) handleSetIncident(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Message string `json:"message"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		g.writeAPIError(w, apiErr)
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		g.writeError(w, http.StatusBadRequest, "message is required", "use DELETE to clear the incident")
+		return
+	}
+
+	incident := &Incident{Message: req.Message, UpdatedAt: time.Now().UTC()}
+	g.incident.Store(incident)
+	g.log.Info("status incident set", "message", incident.Message)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(incident)
+}
+
+// handleClearIncident handles DELETE /admin/status/incident
+func (g *Gateway) handleClearIncident(w http.ResponseWriter, r *http.Request) {
+	if g.incident.Swap(nil) != nil {
+		g.log.Info("status incident cleared")
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// latencyTracker keeps recent request latencies in a ring buffer for
+// percentile estimates
+type latencyTracker struct {
+	mu      sync.Mutex
+	window  time.Duration
+	samples []latencySample
+	next    int
+}
+
+type latencySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+func newLatencyTracker(window time.Duration) *latencyTracker {
+	return &latencyTracker{window: window}
+}
+
+// observe records a request latency, overwriting the oldest sample when full
+func (t *latencyTracker) observe(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := latencySample{at: time.Now(), latency: latency}
+	if len(t.samples) < maxLatencySamples {
+		t.samples = append(t.samples, s)
+	} else {
+		t.samples[t.next] = s
+	}
+	t.next = (t.next + 1) % maxLatencySamples
+}
+
+// percentile returns the p-th percentile (0-1) of latencies observed within
+// the window, or false if there were none
+func (t *latencyTracker) percentile(p float64) (time.Duration, bool) {
+	cutoff := time.Now().Add(-t.window)
+
+	t.mu.Lock()
+	recent := make([]time.Duration, 0, len(t.samples))
+	for _, s := range t.samples {
+		if s.at.After(cutoff) {
+			recent = append(recent, s.latency)
+		}
+	}
+	t.mu.Unlock()
+
+	if len(recent) == 0 {
+		return 0, false
+	}
+	sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+	idx := int(math.Ceil(p*float64(len(recent)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return recent[idx], true
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/jwt"
+	"github.com/hugovillarreal/neurogate/pkg/quota"
+	"github.com/hugovillarreal/neurogate/pkg/ratelimit"
+)
+
+// tenantUsagePrefix distinguishes tenant quota usage from key usage in the
+// store
+const tenantUsagePrefix = "tenant:"
+
+// TenantConfig configures tenant authentication and limits. A caller's
+// tenant is the tenant of its API key, or a claim of its JWT.
+type TenantConfig struct {
+	JWTSecret   string // HS256 secret of accepted JWTs; empty disables JWT authentication
+	JWTIssuer   string // Required iss claim; optional
+	JWTAudience string // Required aud claim; optional
+	JWTClaim    string // Claim holding the tenant. Default: "tenant"
+
+	Quotas           map[string]string // "requests/tokens" per QUOTA_WINDOW by tenant
+	DefaultQuota     string            // Quota of tenants without their own; empty is unlimited
+	RateLimits       map[string]string // "rate/burst" in requests per second by tenant
+	DefaultRateLimit string            // Rate limit of tenants without their own; empty is unlimited
+}
+
+// setupTenants creates the JWT verifier and the per-tenant quotas and rate
+// limits
+func (g *Gateway) setupTenants(cfg TenantConfig, window time.Duration) error {
+	if cfg.JWTSecret != "" {
+		verifier, err := jwt.NewVerifier(jwt.Config{
+			Secret:   []byte(cfg.JWTSecret),
+			Issuer:   cfg.JWTIssuer,
+			Audience: cfg.JWTAudience,
+			Leeway:   time.Minute,
+		})
+		if err != nil {
+			return err
+		}
+		g.jwt = verifier
+		g.jwtClaim = cfg.JWTClaim
+		if g.jwtClaim == "" {
+			g.jwtClaim = "tenant"
+		}
+	}
+
+	defaultQuota, err := parseTenantQuota(cfg.DefaultQuota)
+	if err != nil {
+		return fmt.Errorf("default quota: %w", err)
+	}
+	g.tenantQuota = quota.New(quota.Config{
+		Window:       window,
+		Defaults:     defaultQuota,
+		Store:        g.quotaStore(),
+		OnStoreError: g.limitsStoreFailed,
+	})
+	for tenant, s := range cfg.Quotas {
+		limits, err := parseTenantQuota(s)
+		if err != nil {
+			return fmt.Errorf("tenant %s quota: %w", tenant, err)
+		}
+		g.tenantQuota.SetLimits(tenant, limits)
+	}
+
+	var defaultRate ratelimit.Limit
+	if cfg.DefaultRateLimit != "" {
+		if defaultRate, err = ratelimit.ParseLimit(cfg.DefaultRateLimit); err != nil {
+			return fmt.Errorf("default rate limit: %w", err)
+		}
+	}
+	if g.limitsRedis != nil {
+		g.tenantRates = ratelimit.NewWithStore(defaultRate, ratelimit.NewRedis(g.limitsRedis), g.limitsStoreFailed)
+	} else {
+		g.tenantRates = ratelimit.New(defaultRate)
+	}
+	for tenant, s := range cfg.RateLimits {
+		limit, err := ratelimit.ParseLimit(s)
+		if err != nil {
+			return fmt.Errorf("tenant %s rate limit: %w", tenant, err)
+		}
+		g.tenantRates.SetLimit(tenant, limit)
+	}
+	return nil
+}
+
+// parseTenantQuota parses "requests/tokens"; either may be 0 for unlimited
+func parseTenantQuota(s string) (quota.Limits, error) {
+	if s == "" {
+		return quota.Limits{}, nil
+	}
+	requests, tokens, ok := strings.Cut(s, "/")
+	r, err1 := strconv.ParseInt(strings.TrimSpace(requests), 10, 64)
+	t, err2 := strconv.ParseInt(strings.TrimSpace(tokens), 10, 64)
+	if !ok || err1 != nil || err2 != nil || r < 0 || t < 0 {
+		return quota.Limits{}, fmt.Errorf("invalid quota %q, expected requests/tokens", s)
+	}
+	return quota.Limits{Requests: r, Tokens: t}, nil
+}
+
+// authEnabled reports whether callers must authenticate, with an API key or
+// a JWT
+func (g *Gateway) authEnabled() bool {
+	return g.apiKeys.enabled() || g.jwt != nil
+}
+
+// isJWT reports whether a bearer token is handled as a JWT rather than an
+// API key
+func (g *Gateway) isJWT(token string) bool {
+	return g.jwt != nil && jwt.Looks(token)
+}
+
+// verifyJWT verifies the JWT in an Authorization header, returning its
+// claims. Tokens must carry a tenant.
+func (g *Gateway) verifyJWT(authHeader string) (jwt.Claims, bool) {
+	token := bearerToken(authHeader)
+	if !g.isJWT(token) {
+		return nil, false
+	}
+	claims, err := g.jwt.Verify(token)
+	if err != nil {
+		return nil, false
+	}
+	if tenant := claims.String(g.jwtClaim); tenant == "" || len(tenant) > maxTenantLength {
+		return nil, false
+	}
+	return claims, true
+}
+
+// keyHashOf returns the keyHash of the API key in an Authorization header,
+// or "" if the caller sends no key or uses a JWT
+func (g *Gateway) keyHashOf(authHeader string) string {
+	token := bearerToken(authHeader)
+	if token == "" || g.isJWT(token) {
+		return ""
+	}
+	return keyHash(token)
+}
+
+// tenantOf returns the caller's tenant, or "" if it has none
+func (g *Gateway) tenantOf(authHeader string) string {
+	if claims, ok := g.verifyJWT(authHeader); ok {
+		return claims.String(g.jwtClaim)
+	}
+	return g.apiKeys.tenantFor(g.keyHashOf(authHeader))
+}
+
+// ownerOf derives a non-reversible owner tag for jobs and conversations.
+// JWT callers are identified by tenant and subject, so they keep access as
+// their tokens are refreshed.
+func (g *Gateway) ownerOf(authHeader string) string {
+	if claims, ok := g.verifyJWT(authHeader); ok {
+		return hashOwner("jwt:" + claims.String(g.jwtClaim) + ":" + claims.String("sub"))
+	}
+	return hashOwner(authHeader)
+}
+
+// checkTenant rejects the request with 429 when the caller's tenant is over
+// its rate limit or has exhausted its quota
+func (g *Gateway) checkTenant(w http.ResponseWriter, authHeader string) bool {
+	tenant := g.tenantOf(authHeader)
+	if tenant == "" {
+		return true
+	}
+
+	if ok, wait := g.tenantRates.Allow(tenant); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		g.metrics.RecordShed("rate_limit")
+		g.writeError(w, http.StatusTooManyRequests, "rate limit exceeded", fmt.Sprintf("tenant %s is limited to %g requests per second", tenant, g.tenantRates.Limit(tenant).Rate))
+		return false
+	}
+
+	status, err := g.tenantQuota.Check(tenant)
+	if err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(status.ResetAt).Seconds())+1))
+		g.metrics.RecordShed("tenant_quota")
+		g.writeAPIError(w, &apiError{
+			Status:  http.StatusTooManyRequests,
+			Message: "tenant quota exceeded",
+			Detail:  fmt.Sprintf("quota resets at %s", status.ResetAt.UTC().Format(time.RFC3339)),
+			Code:    CodeQuotaExceeded,
+		})
+		return false
+	}
+	return true
+}
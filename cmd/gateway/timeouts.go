@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+)
+
+// timeoutGrace is extra write time after a request's deadline so handlers can
+// still send a 504 once their context expires
+const timeoutGrace = 5 * time.Second
+
+// requestTimeoutHeader lets a client set its request's timeout, in seconds
+// or as a duration such as 90s
+const requestTimeoutHeader = "X-Request-Timeout"
+
+// defaultRouteTimeouts is the built-in timeout table. Patterns use path.Match
+// syntax; a timeout of 0 exempts the route, which streaming endpoints need.
+var defaultRouteTimeouts = map[string]time.Duration{
+	"default":                  30 * time.Second,
+	"/prompt":                  2 * time.Minute,
+	"/embeddings":              30 * time.Second,
+	"/jobs":                    10 * time.Second,
+	"/jobs/*":                  10 * time.Second,
+	"/admin/requests/*/stream": 0,
+	"/admin/models/pull":       0,
+}
+
+// timeoutTable maps route patterns to request timeouts
+type timeoutTable map[string]time.Duration
+
+// newTimeoutTable merges overrides (e.g. from ROUTE_TIMEOUTS) over the
+// built-in defaults. Unparseable durations are ignored.
+func newTimeoutTable(overrides map[string]string) timeoutTable {
+	t := make(timeoutTable, len(defaultRouteTimeouts)+len(overrides))
+	for pattern, d := range defaultRouteTimeouts {
+		t[pattern] = d
+	}
+	for pattern, value := range overrides {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			t[pattern] = d
+		}
+	}
+	return t
+}
+
+// lookup returns the timeout for the most specific pattern matching p,
+// falling back to the "default" entry
+func (t timeoutTable) lookup(p string) time.Duration {
+	best := ""
+	for pattern := range t {
+		if pattern == "default" || len(pattern) <= len(best) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, p); ok {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return t["default"]
+	}
+	return t[best]
+}
+
+// parseRequestTimeout parses an X-Request-Timeout value
+func parseRequestTimeout(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if seconds, perr := strconv.ParseFloat(value, 64); perr == nil {
+		d, err = time.Duration(seconds*float64(time.Second)), nil
+	}
+	if err != nil || d <= 0 {
+		return 0, errors.New("must be a positive number of seconds or a duration such as 90s")
+	}
+	return d, nil
+}
+
+// withRouteTimeouts enforces per-route deadlines on the gateway's requests.
+// Matching requests get a context deadline plus connection read/write
+// deadlines; exempt routes have their connection deadlines cleared so
+// long-lived streams aren't cut off. Clients may choose another timeout
+// with X-Request-Timeout, up to maxTimeout, or the route's timeout when
+// maxTimeout is 0.
+func (g *Gateway) withRouteTimeouts(table timeoutTable, maxTimeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := table.lookup(r.URL.Path)
+		rc := http.NewResponseController(w)
+
+		if timeout == 0 {
+			rc.SetReadDeadline(time.Time{})
+			rc.SetWriteDeadline(time.Time{})
+			g.ServeHTTP(w, r)
+			return
+		}
+
+		if value := r.Header.Get(requestTimeoutHeader); value != "" {
+			requested, err := parseRequestTimeout(value)
+			if err != nil {
+				g.writeError(w, http.StatusBadRequest, "invalid "+requestTimeoutHeader+" header", err.Error())
+				return
+			}
+			limit := maxTimeout
+			if limit <= 0 {
+				limit = timeout
+			}
+			timeout = min(requested, limit)
+		}
+
+		now := time.Now()
+		rc.SetReadDeadline(now.Add(timeout))
+		rc.SetWriteDeadline(now.Add(timeout + timeoutGrace))
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		g.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+	"github.com/hugovillarreal/neurogate/pkg/circuitbreaker"
+	"github.com/hugovillarreal/neurogate/pkg/logger"
+	"github.com/hugovillarreal/neurogate/pkg/requestid"
+)
+
+// TokenizeRequest is the REST API request body for /tokenize
+type TokenizeRequest struct {
+	Query        string `json:"query"`
+	Model        string `json:"model,omitempty"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+}
+
+// TokenizeResponse is the REST API response body for /tokenize
+type TokenizeResponse struct {
+	RequestID string `json:"request_id"`
+	Model     string `json:"model"`
+	Tokens    int32  `json:"tokens"`
+
+	// Set when the worker's backend has no tokenizer API and tokens is
+	// estimated from the prompt's length
+	Estimated bool `json:"estimated,omitempty"`
+
+	// The model's context window as configured on the worker, if known
+	ContextLength int32 `json:"context_length,omitempty"`
+
+	WorkerID string `json:"worker_id"`
+}
+
+// handleTokenize handles the /tokenize endpoint, counting a prompt's tokens
+// on a worker without generating. It doesn't count against quotas.
+func (g *Gateway) handleTokenize(w http.ResponseWriter, r *http.Request) {
+	authHeader := r.Header.Get("Authorization")
+	var req TokenizeRequest
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		g.writeAPIError(w, apiErr)
+		return
+	}
+	fields := g.limits.checkText("query", req.Query, true)
+	fields = append(fields, g.limits.checkText("system_prompt", req.SystemPrompt, false)...)
+	if len(fields) > 0 {
+		g.writeAPIError(w, invalidRequest(fields...))
+		return
+	}
+	req.Model = g.modelAliases.resolve(req.Model)
+
+	requestID := requestid.FromContext(r.Context())
+	ctx := logger.ToContext(r.Context(), g.log.WithRequestID(requestID))
+	ctx = g.workerContext(ctx, requestID, authHeader)
+	requestLog := logger.FromContext(ctx)
+
+	worker, err := g.selectWorker(ctx, "", nil)
+	if err != nil {
+		requestLog.Error("no workers available", "error", err)
+		g.writeAPIError(w, g.noWorkerError(err))
+		return
+	}
+
+	defer g.trackInflight(worker)()
+	resp, err := circuitbreaker.Do(worker.CB, func() (*llmv1.CountTokensResponse, error) {
+		return worker.Client.CountTokens(ctx, &llmv1.CountTokensRequest{
+			RequestId:    requestID,
+			Model:        req.Model,
+			Prompt:       req.Query,
+			SystemPrompt: req.SystemPrompt,
+		})
+	})
+	if err != nil {
+		var apiErr *apiError
+		if err == circuitbreaker.ErrCircuitOpen {
+			apiErr = &apiError{Status: http.StatusServiceUnavailable, Message: "worker temporarily unavailable", Code: CodeWorkerUnavailable}
+		} else if timedOut(ctx, err) {
+			requestLog.Warn("worker tokenization timed out", "worker", worker.ID)
+			apiErr = &apiError{Status: http.StatusGatewayTimeout, Message: "tokenization timed out"}
+		} else {
+			requestLog.Error("worker tokenization failed", "worker", worker.ID, "error", err)
+			apiErr = workerAPIError(err, "tokenization failed")
+		}
+		g.writeAPIError(w, apiErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TokenizeResponse{
+		RequestID:     requestID,
+		Model:         resp.Model,
+		Tokens:        resp.Tokens,
+		Estimated:     resp.Estimated,
+		ContextLength: resp.ContextLength,
+		WorkerID:      worker.ID,
+	})
+}
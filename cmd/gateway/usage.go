@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+)
+
+// costHeader reports a response's estimated cost in USD
+const costHeader = "X-NeuroGate-Cost"
+
+// Usage summarizes the cost of a prompt request, mirroring OpenAI's usage block
+type Usage struct {
+	PromptTokens     int32   `json:"prompt_tokens"`
+	CompletionTokens int32   `json:"completion_tokens"`
+	TotalTokens      int32   `json:"total_tokens"`
+	EstimatedCost    float64 `json:"estimated_cost"` // USD, from MODEL_PRICING and MODEL_INFERENCE_PRICING
+	CacheHit         bool    `json:"cache_hit"`
+	Retries          int     `json:"retries"` // additional backend attempts
+}
+
+// modelPrice is a model's price in USD per million tokens
+type modelPrice struct {
+	Prompt     float64
+	Completion float64
+}
+
+// modelPricing maps model names to token prices and per-second inference
+// rates. In each table the "default" entry applies to models that aren't
+// listed; without one, unlisted models cost nothing.
+type modelPricing struct {
+	tokens    map[string]modelPrice
+	perSecond map[string]float64 // USD per second of worker inference time
+}
+
+// newModelPricing parses token prices of the form model=prompt/completion (or
+// a single price for both), e.g. "llama3.2=0.10/0.40", and per-second rates
+// of the form model=rate. Invalid entries are ignored.
+func newModelPricing(tokenEntries, secondEntries map[string]string) modelPricing {
+	p := modelPricing{
+		tokens:    make(map[string]modelPrice, len(tokenEntries)),
+		perSecond: make(map[string]float64, len(secondEntries)),
+	}
+	for model, value := range secondEntries {
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate < 0 {
+			continue
+		}
+		p.perSecond[model] = rate
+	}
+	for model, value := range tokenEntries {
+		promptPrice, completionPrice, split := strings.Cut(value, "/")
+		if !split {
+			completionPrice = promptPrice
+		}
+		prompt, err1 := strconv.ParseFloat(strings.TrimSpace(promptPrice), 64)
+		completion, err2 := strconv.ParseFloat(strings.TrimSpace(completionPrice), 64)
+		if err1 != nil || err2 != nil || prompt < 0 || completion < 0 {
+			continue
+		}
+		p.tokens[model] = modelPrice{Prompt: prompt, Completion: completion}
+	}
+	return p
+}
+
+// cost estimates the price of a request in USD, rounded to a billionth of a
+// dollar to keep float noise out of responses
+func (p modelPricing) cost(model string, promptTokens, completionTokens int32, inference time.Duration) float64 {
+	price, ok := p.tokens[model]
+	if !ok {
+		price = p.tokens["default"]
+	}
+	rate, ok := p.perSecond[model]
+	if !ok {
+		rate = p.perSecond["default"]
+	}
+	usd := (float64(promptTokens)*price.Prompt+float64(completionTokens)*price.Completion)/1e6 +
+		inference.Seconds()*rate
+	return math.Round(usd*1e9) / 1e9
+}
+
+// usage builds the usage summary for a generated (not cached) response
+func (g *Gateway) usage(resp *llmv1.PromptResponse, retries int) Usage {
+	inference := time.Duration(resp.InferenceTimeMs) * time.Millisecond
+	return Usage{
+		PromptTokens:     resp.PromptTokens,
+		CompletionTokens: resp.CompletionTokens,
+		TotalTokens:      resp.PromptTokens + resp.CompletionTokens,
+		EstimatedCost:    g.pricing.cost(resp.Model, resp.PromptTokens, resp.CompletionTokens, inference),
+		Retries:          retries,
+	}
+}
+
+// recordCost charges a response's estimated cost to the caller in the cost
+// metrics and, when w is non-nil, reports it in the response headers
+func (g *Gateway) recordCost(w http.ResponseWriter, authHeader string, resp *PromptResponse) {
+	if w != nil {
+		w.Header().Set(costHeader, strconv.FormatFloat(resp.Usage.EstimatedCost, 'f', -1, 64))
+	}
+	if consumer, ok := g.consumerFor(authHeader); ok {
+		g.metrics.RecordConsumerCost(consumer, resp.Model, resp.Usage.EstimatedCost)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hugovillarreal/neurogate/pkg/scheduler"
+)
+
+// maxTemperature bounds temperature whatever the model. Model policies may
+// narrow it.
+const maxTemperature = 2
+
+// RequestLimits bounds the request bodies the API accepts
+type RequestLimits struct {
+	MaxBodySize int64 // Bytes; larger bodies are rejected with 413. Default: 4 MiB
+
+	// Characters in a query, system prompt or embedding input; 0 is
+	// unlimited
+	MaxPromptLength int
+}
+
+// FieldError is a problem with one field of a request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// invalidRequest returns the error for a request body with invalid fields
+func invalidRequest(fields ...FieldError) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Message: "invalid request", Fields: fields}
+}
+
+// limitBody caps request bodies at the configured size
+func (g *Gateway) limitBody(next http.Handler) http.Handler {
+	limit := g.limits.MaxBodySize
+	if limit <= 0 {
+		limit = 4 << 20
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodeBody decodes the JSON request body into v. Unknown fields are
+// rejected so misspelled parameters aren't silently ignored.
+func decodeBody(r *http.Request, v any) *apiError {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil && dec.More() {
+		err = errors.New("unexpected data after the JSON object")
+	}
+	if err == nil {
+		return nil
+	}
+
+	var tooLarge *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &tooLarge):
+		return &apiError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: "request body too large",
+			Detail:  fmt.Sprintf("the limit is %d bytes", tooLarge.Limit),
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return invalidRequest(FieldError{Field: field, Message: "unknown field"})
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return invalidRequest(FieldError{Field: typeErr.Field, Message: "must be " + jsonType(typeErr.Type.String())})
+	}
+	return &apiError{Status: http.StatusBadRequest, Message: "invalid request body", Detail: err.Error()}
+}
+
+// jsonType names a Go type the way a JSON client would
+func jsonType(goType string) string {
+	switch {
+	case goType == "string":
+		return "a string"
+	case goType == "bool":
+		return "a boolean"
+	case strings.HasPrefix(goType, "int"), strings.HasPrefix(goType, "uint"):
+		return "an integer"
+	case strings.HasPrefix(goType, "float"):
+		return "a number"
+	case strings.HasPrefix(goType, "[]"):
+		return "an array"
+	}
+	return "an object"
+}
+
+// checkText validates a prompt-like field's length
+func (l RequestLimits) checkText(field, text string, required bool) []FieldError {
+	switch {
+	case text == "" && required:
+		return []FieldError{{Field: field, Message: "is required"}}
+	case l.MaxPromptLength > 0 && utf8.RuneCountInString(text) > l.MaxPromptLength:
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be at most %d characters", l.MaxPromptLength)}}
+	}
+	return nil
+}
+
+// validatePrompt checks the fields of a prompt request, returning every
+// problem found
+func (l RequestLimits) validatePrompt(req *PromptRequest) []FieldError {
+	var fields []FieldError
+	fields = append(fields, l.checkText("query", req.Query, true)...)
+	fields = append(fields, l.checkText("system_prompt", req.SystemPrompt, false)...)
+	if req.MaxTokens < 0 {
+		fields = append(fields, FieldError{Field: "max_tokens", Message: "must not be negative"})
+	}
+	if req.Temperature < 0 || req.Temperature > maxTemperature {
+		fields = append(fields, FieldError{Field: "temperature", Message: fmt.Sprintf("must be between 0 and %d", maxTemperature)})
+	}
+	fields = append(fields, req.Sampling.validate()...)
+	if _, err := parseFormat(req.Format); err != nil {
+		fields = append(fields, FieldError{Field: "format", Message: err.Error()})
+	}
+	if err := validateConversationID(req.ConversationID); err != nil {
+		fields = append(fields, FieldError{Field: "conversation_id", Message: err.Error()})
+	}
+	if req.Priority != "" {
+		if _, err := scheduler.ParsePriority(req.Priority); err != nil {
+			fields = append(fields, FieldError{Field: "priority", Message: "must be interactive or batch"})
+		}
+	}
+	return fields
+}
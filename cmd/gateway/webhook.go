@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"log/slog"
+)
+
+// webhookTimeout bounds each webhook delivery attempt
+const webhookTimeout = 10 * time.Second
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), internal
+// like the private ranges
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// newWebhookClient returns the client webhooks are delivered with. A
+// publicOnly client refuses to connect to internal addresses, so webhook URLs
+// chosen by API callers can't reach the gateway's own network.
+func newWebhookClient(publicOnly bool) *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if publicOnly {
+		dialer.Control = publicOnlyControl
+		// A proxy would connect on the gateway's behalf, unchecked
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: webhookTimeout, Transport: transport}
+}
+
+// publicOnlyControl rejects connections to loopback, private, link-local,
+// multicast and unspecified addresses. It runs on the resolved address of
+// every connection, so DNS names and redirects can't get around it.
+func publicOnlyControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if ip = ip.Unmap(); !ip.IsGlobalUnicast() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("webhook destination %s is not a public address", ip)
+	}
+	return nil
+}
+
+// postWebhook POSTs a JSON body to target, retrying with backoff. When
+// WEBHOOK_SECRET is set the body is signed with HMAC-SHA256 in the
+// X-NeuroGate-Signature header. Only hosts in WEBHOOK_ALLOWED_HOSTS may be
+// on internal addresses.
+func (g *Gateway) postWebhook(target string, body []byte, log *slog.Logger) {
+	client := g.webhookClient
+	if u, err := url.Parse(target); err == nil && g.webhookHosts[strings.ToLower(u.Hostname())] {
+		client = g.allowedWebhookClient
+	}
+	backoff := time.Second
+
+	const attempts = 3
+	for attempt := 1; attempt <= attempts; attempt++ {
+		req, err := http.NewRequest("POST", target, bytes.NewReader(body))
+		if err != nil {
+			log.Warn("invalid webhook request", "error", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if g.webhookSecret != "" {
+			mac := hmac.New(sha256.New, []byte(g.webhookSecret))
+			mac.Write(body)
+			req.Header.Set("X-NeuroGate-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				log.Debug("webhook delivered", "attempt", attempt)
+				return
+			}
+			err = fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		}
+
+		log.Warn("webhook delivery failed", "attempt", attempt, "error", err)
+		if attempt < attempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+// validWebhookURL reports whether target is an absolute http(s) URL. Whether
+// its address may be reached is checked when delivering.
+func validWebhookURL(target string) bool {
+	u, err := url.Parse(target)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Hostname() != ""
+}
+
+// webhookHostSet builds the set of hosts webhooks may reach on internal
+// addresses. The quota webhook is set by the operator, so its host is
+// always included.
+func webhookHostSet(hosts []string, quotaWebhookURL string) map[string]bool {
+	set := make(map[string]bool)
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			set[host] = true
+		}
+	}
+	if u, err := url.Parse(quotaWebhookURL); err == nil && u.Hostname() != "" {
+		set[strings.ToLower(u.Hostname())] = true
+	}
+	return set
+}
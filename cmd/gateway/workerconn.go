@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// WorkerConnConfig controls the gateway's gRPC connections to workers
+type WorkerConnConfig struct {
+	// Connections opened to each worker, with calls spread across them, for
+	// workers limiting concurrent streams per connection. Default: 1
+	Connections int
+
+	// The gateway pings a worker after this long without activity, so idle
+	// connections aren't silently dropped by NAT or firewalls, and
+	// reconnects if the ping isn't answered within KeepaliveTimeout. 0
+	// disables pings. Workers reject pings more often than their
+	// GRPC_KEEPALIVE_MIN_TIME.
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration // Default: 10 seconds
+
+	// Ping connections without requests in flight, which idle connections
+	// between requests need to stay open
+	PermitWithoutStream bool
+
+	// Calls to a worker that is reconnecting wait for the connection, up to
+	// the request's deadline, instead of failing at once
+	WaitForReady bool
+}
+
+// dialOptions returns the dial options of the config
+func (c WorkerConnConfig) dialOptions() []grpc.DialOption {
+	var opts []grpc.DialOption
+	if c.KeepaliveTime > 0 {
+		timeout := c.KeepaliveTimeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                c.KeepaliveTime,
+			Timeout:             timeout,
+			PermitWithoutStream: c.PermitWithoutStream,
+		}))
+	}
+	if c.WaitForReady {
+		opts = append(opts, grpc.WithDefaultCallOptions(grpc.WaitForReady(true)))
+	}
+	return opts
+}
+
+// workerConns spreads calls to a worker round robin over its connections
+type workerConns struct {
+	conns []*grpc.ClientConn
+	next  atomic.Uint32
+}
+
+var _ grpc.ClientConnInterface = (*workerConns)(nil)
+
+// dialWorker opens n connections to the worker at addr, at least one
+func dialWorker(addr string, n int, opts ...grpc.DialOption) (*workerConns, error) {
+	p := &workerConns{}
+	for range max(n, 1) {
+		conn, err := grpc.NewClient(addr, opts...)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("failed to connect: %w", err)
+		}
+		p.conns = append(p.conns, conn)
+	}
+	return p, nil
+}
+
+// pick returns the connection for the next call
+func (p *workerConns) pick() *grpc.ClientConn {
+	if len(p.conns) == 1 {
+		return p.conns[0]
+	}
+	return p.conns[int(p.next.Add(1)-1)%len(p.conns)]
+}
+
+// Invoke implements grpc.ClientConnInterface
+func (p *workerConns) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return p.pick().Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream implements grpc.ClientConnInterface
+func (p *workerConns) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return p.pick().NewStream(ctx, desc, method, opts...)
+}
+
+// Close closes every connection
+func (p *workerConns) Close() error {
+	var errs []error
+	for _, conn := range p.conns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+)
+
+// WorkerHealthConfig controls how the gateway probes its workers
+type WorkerHealthConfig struct {
+	Interval           time.Duration     // Time between probes of each worker
+	Intervals          map[string]string // Per-worker interval overrides by address or ID
+	Timeout            time.Duration     // Deadline for a single probe
+	UnhealthyThreshold int               // Consecutive failed probes before a worker is marked unhealthy
+	HealthyThreshold   int               // Consecutive successful probes before it is marked healthy again
+	Jitter             float64           // Fraction (0-1) of the interval by which each probe is randomly shifted
+}
+
+// withDefaults fills in unset or invalid values
+func (c WorkerHealthConfig) withDefaults() WorkerHealthConfig {
+	if c.Interval <= 0 {
+		c.Interval = 10 * time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 5 * time.Second
+	}
+	c.UnhealthyThreshold = max(c.UnhealthyThreshold, 1)
+	c.HealthyThreshold = max(c.HealthyThreshold, 1)
+	c.Jitter = min(max(c.Jitter, 0), 1)
+	return c
+}
+
+// intervalFor returns the probe interval for a worker. Unparseable overrides
+// are ignored.
+func (c WorkerHealthConfig) intervalFor(worker *Worker) time.Duration {
+	value, ok := c.Intervals[worker.Address]
+	if !ok {
+		value, ok = c.Intervals[worker.ID]
+	}
+	if ok {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return c.Interval
+}
+
+// jittered shifts interval by a random amount of up to ±Jitter of its length
+func (c WorkerHealthConfig) jittered(interval time.Duration) time.Duration {
+	spread := time.Duration(float64(interval) * c.Jitter)
+	if spread <= 0 {
+		return interval
+	}
+	return interval - spread + rand.N(2*spread+1)
+}
+
+// startWorkerProbes probes each worker on its own schedule. The first probe
+// lands at a random point within the first interval so that workers started
+// together are not probed at the same moment.
+func (g *Gateway) startWorkerProbes() {
+	g.mu.RLock()
+	workers := g.workers
+	g.mu.RUnlock()
+
+	for _, worker := range workers {
+		interval := g.workerHealth.intervalFor(worker)
+		g.log.Debug("probing worker", "worker", worker.ID, "interval", interval)
+
+		go func() {
+			timer := time.NewTimer(rand.N(interval) + 1)
+			defer timer.Stop()
+
+			for range timer.C {
+				g.probeWorker(worker)
+				timer.Reset(g.workerHealth.jittered(interval))
+			}
+		}()
+	}
+}
+
+// probeWorker runs one health check against a worker and applies the
+// healthy/unhealthy thresholds
+func (g *Gateway) probeWorker(worker *Worker) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.workerHealth.Timeout)
+	defer cancel()
+
+	sent := time.Now()
+	resp, err := worker.Client.HealthCheck(ctx, &llmv1.HealthCheckRequest{
+		Timestamp: sent.UnixMilli(),
+	})
+	checked := sent.UTC()
+	worker.LastHealthCheck.Store(&checked)
+	if err != nil {
+		g.log.Debug("worker health check failed", "worker", worker.ID, "error", err)
+		g.recordProbe(worker, false)
+		return
+	}
+
+	g.recordProbe(worker, resp.Healthy)
+	worker.Models.Store(&resp.Models)
+	worker.LoadedModels.Store(&resp.LoadedModels)
+	worker.Capacity.Store(newWorkerCapacity(resp))
+	if g.scheduler != nil {
+		// Slots may have been added
+		g.scheduler.Refresh()
+	}
+	if resp.Timestamp > 0 {
+		g.updateClockSkew(worker, estimateClockSkew(sent, time.Now(), resp.Timestamp))
+	}
+}
+
+// recordProbe counts consecutive probe results and flips the worker's health
+// once a threshold is reached. Only the worker's probe goroutine calls it.
+func (g *Gateway) recordProbe(worker *Worker, healthy bool) {
+	if healthy {
+		worker.probeFailures = 0
+		worker.probeSuccesses++
+		if worker.probeSuccesses >= g.workerHealth.HealthyThreshold && !worker.Healthy.Swap(true) {
+			g.log.Info("worker marked healthy", "worker", worker.ID, "successes", worker.probeSuccesses)
+			if g.workerSync != nil {
+				go g.workerSync.withdraw(worker.ID, reportUnhealthy)
+			}
+		}
+		return
+	}
+
+	worker.probeSuccesses = 0
+	worker.probeFailures++
+	if worker.probeFailures >= g.workerHealth.UnhealthyThreshold && worker.Healthy.Swap(false) {
+		g.log.Warn("worker marked unhealthy", "worker", worker.ID, "failures", worker.probeFailures)
+		// Kept long enough for every gateway to poll it
+		if g.workerSync != nil {
+			go g.workerSync.report(worker.ID, reportUnhealthy, 3*g.workerHealth.intervalFor(worker))
+		}
+	}
+}
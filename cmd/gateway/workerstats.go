@@ -0,0 +1,49 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// servingMethods are the worker calls counted in its stats: those serving
+// API requests, not health checks or model management
+var servingMethods = map[string]bool{
+	"GenerateText":       true,
+	"StreamGenerateText": true,
+	"Embed":              true,
+	"CountTokens":        true,
+}
+
+// workerStats counts the calls the gateway made to a worker since it
+// started
+type workerStats struct {
+	requests atomic.Int64
+	errors   atomic.Int64 // Failures of the worker, not of the request
+	latency  atomic.Int64 // Total, in nanoseconds
+}
+
+// record counts a finished call
+func (s *workerStats) record(d time.Duration, err error) {
+	s.requests.Add(1)
+	s.latency.Add(int64(d))
+	if err != nil && isWorkerFailure(err) {
+		s.errors.Add(1)
+	}
+}
+
+// workerStatsSnapshot is a worker's stats as reported by /workers
+type workerStatsSnapshot struct {
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+func (s *workerStats) snapshot() workerStatsSnapshot {
+	snap := workerStatsSnapshot{Requests: s.requests.Load(), Errors: s.errors.Load()}
+	if snap.Requests > 0 {
+		snap.ErrorRate = float64(snap.Errors) / float64(snap.Requests)
+		snap.AvgLatencyMs = float64(s.latency.Load()) / float64(snap.Requests) / float64(time.Millisecond)
+	}
+	return snap
+}
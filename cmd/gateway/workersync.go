@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/circuitbreaker"
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+)
+
+// workerReportPrefix namespaces worker failure reports in a shared Redis
+const workerReportPrefix = "neurogate:worker:"
+
+// Failures gateways report to each other
+const (
+	reportUnhealthy   = "unhealthy"
+	reportBreakerOpen = "circuit_open"
+)
+
+// WorkerStateConfig controls sharing of worker failures with other gateways
+type WorkerStateConfig struct {
+	Store        string        // "memory" keeps them to this gateway; "redis" shares them
+	SyncInterval time.Duration // How often other gateways' reports are read. Default: 1 second
+}
+
+// workerReport records that a gateway found a worker failing
+type workerReport struct {
+	Gateway string    `json:"gateway"`
+	At      time.Time `json:"at"`
+	Until   time.Time `json:"until"` // When the report expires
+}
+
+// workerSync shares worker failures between gateways in front of the same
+// workers. A gateway that marks a worker unhealthy or opens its circuit
+// breaker reports it, and the others apply the report on their next poll
+// instead of waiting for their own probes and failed requests. Each
+// gateway's own probes still decide when a worker is healthy again.
+type workerSync struct {
+	g        *Gateway
+	client   *redis.Client
+	id       string // Identifies this gateway's reports
+	interval time.Duration
+
+	// Time of the last report applied by key; only the poll loop uses it
+	applied map[string]time.Time
+}
+
+// newWorkerSync creates a sync on client, identified by the hostname
+func newWorkerSync(g *Gateway, client *redis.Client, interval time.Duration) *workerSync {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return &workerSync{
+		g:        g,
+		client:   client,
+		id:       fmt.Sprintf("%s-%s", host, hex.EncodeToString(suffix)),
+		interval: interval,
+		applied:  make(map[string]time.Time),
+	}
+}
+
+func reportKey(workerID, failure string) string {
+	return workerReportPrefix + workerID + ":" + failure
+}
+
+// report publishes a failure found by this gateway for ttl. A report of the
+// same failure by another gateway is kept, so gateways applying each
+// other's reports don't echo them back.
+func (s *workerSync) report(workerID, failure string, ttl time.Duration) {
+	now := time.Now()
+	data, _ := json.Marshal(workerReport{Gateway: s.id, At: now, Until: now.Add(ttl)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	_, err := s.client.Do(ctx, "SET", reportKey(workerID, failure), data, "NX", "PX", max(ttl.Milliseconds(), 1))
+	if err != nil && !errors.Is(err, redis.ErrNil) {
+		s.g.log.Warn("failed to report worker failure", "worker", workerID, "failure", failure, "error", err)
+	}
+}
+
+// withdraw removes this gateway's report of a failure once the worker has
+// recovered
+func (s *workerSync) withdraw(workerID, failure string) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	key := reportKey(workerID, failure)
+	data, err := redis.Bytes(s.client.Do(ctx, "GET", key))
+	if err != nil {
+		return
+	}
+	var r workerReport
+	if json.Unmarshal(data, &r) == nil && r.Gateway == s.id {
+		s.client.Do(ctx, "DEL", key)
+	}
+}
+
+// run applies other gateways' reports every interval
+func (s *workerSync) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.poll()
+	}
+}
+
+// poll reads the reports for every worker and applies those made by other
+// gateways since the last poll
+func (s *workerSync) poll() {
+	s.g.mu.RLock()
+	workers := s.g.workers
+	s.g.mu.RUnlock()
+	if len(workers) == 0 {
+		return
+	}
+
+	cmds := make([][]interface{}, 0, 2*len(workers))
+	for _, w := range workers {
+		cmds = append(cmds,
+			[]interface{}{"GET", reportKey(w.ID, reportUnhealthy)},
+			[]interface{}{"GET", reportKey(w.ID, reportBreakerOpen)},
+		)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	replies, err := s.client.Pipeline(ctx, cmds...)
+	if err != nil {
+		s.g.log.Warn("failed to read worker failure reports", "error", err)
+		return
+	}
+
+	for i, w := range workers {
+		for j, failure := range []string{reportUnhealthy, reportBreakerOpen} {
+			data, err := redis.Bytes(replies[2*i+j], nil)
+			if err != nil {
+				continue
+			}
+			var r workerReport
+			key := reportKey(w.ID, failure)
+			if json.Unmarshal(data, &r) != nil || r.Gateway == s.id || !r.At.After(s.applied[key]) {
+				continue
+			}
+			s.applied[key] = r.At
+			s.apply(w, failure, r)
+		}
+	}
+}
+
+// apply takes a worker out of rotation on another gateway's report
+func (s *workerSync) apply(w *Worker, failure string, r workerReport) {
+	switch failure {
+	case reportUnhealthy:
+		if w.Healthy.Swap(false) {
+			s.g.log.Warn("worker marked unhealthy by another gateway", "worker", w.ID, "gateway", r.Gateway)
+		}
+	case reportBreakerOpen:
+		if w.CB.State() == circuitbreaker.StateClosed {
+			// Stay open as long as the reporting gateway's breaker, which
+			// may have backed off beyond this one's timeout
+			if d := time.Until(r.Until); d > 0 {
+				w.CB.TripFor(d)
+			} else {
+				w.CB.Trip()
+			}
+			s.g.log.Warn("circuit breaker opened by another gateway", "worker", w.ID, "gateway", r.Gateway)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// neurobench - Load generator for capacity planning against a NeuroGate gateway
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	neurogate "github.com/hugovillarreal/neurogate/pkg/client"
+)
+
+const usage = `Usage: neurobench [flags]
+
+Sends prompts from a weighted mix to a running gateway at a fixed
+concurrency, or a fixed rate, and reports latency percentiles, throughput
+in requests and completion tokens per second, and error rates by prompt
+and error code. Requests aren't retried, so errors show the gateway's
+limits.
+
+Each request gets a random seed, so the response cache doesn't answer
+repeated prompts; -cache allows cached responses.
+
+The prompt mix (-prompts) has one JSON prompt per line:
+  {"name": "short", "query": "...", "model": "llama3.2", "max_tokens": 64, "weight": 3}
+
+Flags:
+`
+
+// bench sends the requests of a run
+type bench struct {
+	client      *neurogate.Client
+	mix         *mix
+	model       string
+	maxTokens   int32
+	concurrency int
+	rate        float64
+	requests    int64
+	timeout     time.Duration
+	cache       bool
+
+	sent, done, failed atomic.Int64
+
+	mu       sync.Mutex
+	results  []result
+	recordAt time.Time // Requests sent before this are warmup
+}
+
+func main() {
+	fs := flag.NewFlagSet("neurobench", flag.ExitOnError)
+	addr := fs.String("addr", getEnv("NEUROGATE_URL", "http://localhost:8080"), "Gateway base URL (NEUROGATE_URL)")
+	apiKey := fs.String("api-key", os.Getenv("NEUROGATE_API_KEY"), "API key (NEUROGATE_API_KEY)")
+	promptsFile := fs.String("prompts", "", "Prompt mix file; empty uses a built-in mix of short, medium and long prompts")
+	model := fs.String("model", "", "Model for prompts that don't name one; empty uses the gateway default")
+	maxTokens := fs.Int("max-tokens", 256, "Maximum tokens for prompts that don't set max_tokens")
+	concurrency := fs.Int("concurrency", 10, "Maximum requests in flight")
+	rate := fs.Float64("rate", 0, "Requests started per second; 0 sends the next request as soon as one finishes")
+	duration := fs.Duration("duration", 30*time.Second, "How long to send requests; 0 runs until -requests are sent")
+	requests := fs.Int64("requests", 0, "Stop after this many requests; 0 is unlimited")
+	warmup := fs.Duration("warmup", 0, "Send requests for this long before measuring, e.g. to load models")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Timeout for each request")
+	cache := fs.Bool("cache", false, "Allow responses from the gateway's cache")
+	jsonOut := fs.Bool("json", false, "Print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+
+	if *concurrency <= 0 {
+		fatal(fmt.Errorf("-concurrency must be positive"))
+	}
+	if *duration <= 0 && *requests <= 0 {
+		fatal(fmt.Errorf("set -duration or -requests"))
+	}
+
+	prompts := defaultMix
+	if *promptsFile != "" {
+		var err error
+		if prompts, err = loadPrompts(*promptsFile); err != nil {
+			fatal(err)
+		}
+	}
+	m, err := newMix(prompts)
+	if err != nil {
+		fatal(err)
+	}
+	client, err := neurogate.New(neurogate.Config{URL: *addr, APIKey: *apiKey, MaxRetries: -1})
+	if err != nil {
+		fatal(err)
+	}
+
+	b := &bench{
+		client:      client,
+		mix:         m,
+		model:       *model,
+		maxTokens:   int32(*maxTokens),
+		concurrency: *concurrency,
+		rate:        *rate,
+		requests:    *requests,
+		timeout:     *timeout,
+		cache:       *cache,
+		recordAt:    time.Now().Add(*warmup),
+	}
+
+	// Interrupting ends the run early, still reporting the requests so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *warmup+*duration)
+		defer cancel()
+	}
+
+	elapsed := b.run(ctx)
+	newReport(*addr, *concurrency, *rate, b.results, elapsed).print(os.Stdout, *jsonOut)
+}
+
+// run sends requests until ctx is done or the request limit is reached, and
+// returns how long the measured part of the run took
+func (b *bench) run(ctx context.Context) time.Duration {
+	var ticks <-chan time.Time
+	if b.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / b.rate))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	progressDone := make(chan struct{})
+	go b.progress(ctx, progressDone)
+
+	var wg sync.WaitGroup
+	for range b.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if ticks != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticks:
+					}
+				}
+				if ctx.Err() != nil || (b.requests > 0 && b.sent.Add(1) > b.requests) {
+					return
+				}
+				b.send(ctx)
+			}
+		}()
+	}
+	wg.Wait()
+	close(progressDone)
+
+	return time.Since(b.recordAt)
+}
+
+// send sends one prompt from the mix and records its outcome. Requests
+// still in flight when the run ends are allowed to finish.
+func (b *bench) send(runCtx context.Context) {
+	p := b.mix.pick()
+	req := &neurogate.PromptRequest{
+		Query:        p.Query,
+		Model:        p.Model,
+		SystemPrompt: p.SystemPrompt,
+		MaxTokens:    p.MaxTokens,
+	}
+	if req.Model == "" {
+		req.Model = b.model
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = b.maxTokens
+	}
+	if !b.cache {
+		seed := rand.Int64()
+		req.Seed = &seed
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(runCtx), b.timeout)
+	defer cancel()
+	start := time.Now()
+	resp, err := b.client.Prompt(ctx, req)
+	res := result{prompt: p.Name, latency: time.Since(start)}
+	if err != nil {
+		res.errCode = errorCode(err)
+		b.failed.Add(1)
+	} else {
+		res.completionTokens = resp.Usage.CompletionTokens
+		res.cached = resp.Cached
+	}
+	b.done.Add(1)
+
+	if start.Before(b.recordAt) {
+		return
+	}
+	b.mu.Lock()
+	b.results = append(b.results, res)
+	b.mu.Unlock()
+}
+
+// progress prints a line to stderr every few seconds until done is closed
+func (b *bench) progress(ctx context.Context, done <-chan struct{}) {
+	start := time.Now()
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			secs := time.Since(start).Seconds()
+			state := ""
+			if ctx.Err() != nil {
+				state = " (finishing in-flight requests)"
+			} else if time.Now().Before(b.recordAt) {
+				state = " (warming up)"
+			}
+			fmt.Fprintf(os.Stderr, "%4.0fs  %d requests  %d errors  %.1f req/s%s\n",
+				secs, b.done.Load(), b.failed.Load(), float64(b.done.Load())/secs, state)
+		}
+	}
+}
+
+// errorCode classifies a failed request by the gateway's error code, or
+// for requests the gateway didn't answer, why
+func errorCode(err error) string {
+	var apiErr *neurogate.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.Code != "":
+		return string(apiErr.Code)
+	case errors.As(err, &apiErr):
+		return fmt.Sprintf("HTTP_%d", apiErr.StatusCode)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "CLIENT_TIMEOUT"
+	default:
+		return "CONNECTION_ERROR"
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "neurobench:", err)
+	os.Exit(1)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
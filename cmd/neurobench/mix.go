@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+)
+
+// Prompt is one kind of request in the mix
+type Prompt struct {
+	Name         string  `json:"name"`
+	Query        string  `json:"query"`
+	Model        string  `json:"model"`
+	SystemPrompt string  `json:"system_prompt"`
+	MaxTokens    int32   `json:"max_tokens"`
+	Weight       float64 `json:"weight"` // Relative share of requests; default 1
+}
+
+// defaultMix is used without -prompts: mostly short prompts, some asking
+// for longer answers
+var defaultMix = []Prompt{
+	{Name: "short", Query: "In one sentence, what is a load balancer?", MaxTokens: 64, Weight: 6},
+	{Name: "medium", Query: "Explain how a circuit breaker protects a distributed system, with an example.", MaxTokens: 256, Weight: 3},
+	{Name: "long", Query: "Write a detailed guide to capacity planning for a service that serves language models, covering queueing, batching and autoscaling.", MaxTokens: 1024, Weight: 1},
+}
+
+// mix picks prompts at random in proportion to their weights
+type mix struct {
+	prompts []Prompt
+	total   float64
+}
+
+func newMix(prompts []Prompt) (*mix, error) {
+	m := &mix{prompts: prompts}
+	for i := range m.prompts {
+		p := &m.prompts[i]
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("prompt-%d", i+1)
+		}
+		if p.Weight == 0 {
+			p.Weight = 1
+		}
+		if p.Query == "" || p.Weight < 0 {
+			return nil, fmt.Errorf("prompt %s: query is required and weight must be positive", p.Name)
+		}
+		m.total += p.Weight
+	}
+	if len(m.prompts) == 0 {
+		return nil, fmt.Errorf("no prompts")
+	}
+	return m, nil
+}
+
+// pick returns a random prompt
+func (m *mix) pick() Prompt {
+	n := rand.Float64() * m.total
+	for _, p := range m.prompts {
+		if n < p.Weight {
+			return p
+		}
+		n -= p.Weight
+	}
+	return m.prompts[len(m.prompts)-1]
+}
+
+// loadPrompts reads a prompt mix with one JSON prompt per line. Blank lines
+// and lines starting with # are skipped.
+func loadPrompts(path string) ([]Prompt, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var prompts []Prompt
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var p Prompt
+		if err := json.Unmarshal([]byte(text), &p); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		prompts = append(prompts, p)
+	}
+	return prompts, scanner.Err()
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// result is the outcome of one request
+type result struct {
+	prompt           string
+	latency          time.Duration
+	completionTokens int32
+	cached           bool
+	errCode          string // Empty on success
+}
+
+// Latencies are percentiles of request latency in milliseconds
+type Latencies struct {
+	P50 float64 `json:"p50_ms"`
+	P90 float64 `json:"p90_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+	Max float64 `json:"max_ms"`
+}
+
+// Stats summarizes a set of requests. Latencies and tokens are of
+// successful requests only.
+type Stats struct {
+	Requests     int       `json:"requests"`
+	Errors       int       `json:"errors"`
+	ErrorRate    float64   `json:"error_rate"`
+	Cached       int       `json:"cached"`
+	RequestsPerS float64   `json:"requests_per_second"`
+	TokensPerS   float64   `json:"tokens_per_second"` // Completion tokens over the run
+	Latency      Latencies `json:"latency"`
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Target      string           `json:"target"`
+	Concurrency int              `json:"concurrency"`
+	Rate        float64          `json:"rate,omitempty"`
+	DurationS   float64          `json:"duration_seconds"`
+	Total       Stats            `json:"total"`
+	Prompts     map[string]Stats `json:"prompts"`
+	Errors      map[string]int   `json:"errors,omitempty"` // By error code
+}
+
+// summarize computes the stats of results over a run of length elapsed
+func summarize(results []result, elapsed time.Duration) Stats {
+	var s Stats
+	var latencies []time.Duration
+	var tokens int64
+	for _, r := range results {
+		s.Requests++
+		if r.errCode != "" {
+			s.Errors++
+			continue
+		}
+		if r.cached {
+			s.Cached++
+		}
+		latencies = append(latencies, r.latency)
+		tokens += int64(r.completionTokens)
+	}
+	if s.Requests > 0 {
+		s.ErrorRate = float64(s.Errors) / float64(s.Requests)
+	}
+	if secs := elapsed.Seconds(); secs > 0 {
+		s.RequestsPerS = float64(s.Requests) / secs
+		s.TokensPerS = float64(tokens) / secs
+	}
+
+	slices.Sort(latencies)
+	s.Latency = Latencies{
+		P50: percentile(latencies, 50),
+		P90: percentile(latencies, 90),
+		P95: percentile(latencies, 95),
+		P99: percentile(latencies, 99),
+		Max: percentile(latencies, 100),
+	}
+	return s
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies in
+// milliseconds
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted)) + 0.5)
+	rank = min(max(rank, 1), len(sorted))
+	return float64(sorted[rank-1].Microseconds()) / 1000
+}
+
+// newReport summarizes results in total, by prompt and by error code
+func newReport(target string, concurrency int, rate float64, results []result, elapsed time.Duration) *Report {
+	report := &Report{
+		Target:      target,
+		Concurrency: concurrency,
+		Rate:        rate,
+		DurationS:   elapsed.Seconds(),
+		Total:       summarize(results, elapsed),
+		Prompts:     make(map[string]Stats),
+		Errors:      make(map[string]int),
+	}
+
+	byPrompt := make(map[string][]result)
+	for _, r := range results {
+		byPrompt[r.prompt] = append(byPrompt[r.prompt], r)
+		if r.errCode != "" {
+			report.Errors[r.errCode]++
+		}
+	}
+	for name, rs := range byPrompt {
+		report.Prompts[name] = summarize(rs, elapsed)
+	}
+	return report
+}
+
+// print writes the report as text or JSON
+func (r *Report) print(w io.Writer, asJSON bool) {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(r)
+		return
+	}
+
+	load := fmt.Sprintf("concurrency %d", r.Concurrency)
+	if r.Rate > 0 {
+		load += fmt.Sprintf(", %.1f req/s", r.Rate)
+	}
+	t := r.Total
+	fmt.Fprintf(w, "Target:      %s (%s, %.1fs)\n", r.Target, load, r.DurationS)
+	fmt.Fprintf(w, "Requests:    %d (%d failed, %.2f%% errors, %d cached)\n", t.Requests, t.Errors, t.ErrorRate*100, t.Cached)
+	fmt.Fprintf(w, "Throughput:  %.2f req/s, %.1f tokens/s\n", t.RequestsPerS, t.TokensPerS)
+	fmt.Fprintf(w, "Latency:     p50 %s  p90 %s  p95 %s  p99 %s  max %s\n\n",
+		ms(t.Latency.P50), ms(t.Latency.P90), ms(t.Latency.P95), ms(t.Latency.P99), ms(t.Latency.Max))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROMPT\tREQUESTS\tERRORS\tREQ/S\tTOKENS/S\tP50\tP95\tP99")
+	names := make([]string, 0, len(r.Prompts))
+	for name := range r.Prompts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		s := r.Prompts[name]
+		fmt.Fprintf(tw, "%s\t%d\t%.2f%%\t%.2f\t%.1f\t%s\t%s\t%s\n", name, s.Requests, s.ErrorRate*100,
+			s.RequestsPerS, s.TokensPerS, ms(s.Latency.P50), ms(s.Latency.P95), ms(s.Latency.P99))
+	}
+	tw.Flush()
+
+	if len(r.Errors) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ERROR\tCOUNT")
+		codes := make([]string, 0, len(r.Errors))
+		for code := range r.Errors {
+			codes = append(codes, code)
+		}
+		slices.Sort(codes)
+		for _, code := range codes {
+			fmt.Fprintf(tw, "%s\t%d\n", code, r.Errors[code])
+		}
+		tw.Flush()
+	}
+}
+
+// ms formats milliseconds as a rounded duration, or "-" for prompts
+// without successful requests
+func ms(v float64) string {
+	if v == 0 {
+		return "-"
+	}
+	return time.Duration(v * float64(time.Millisecond)).Round(time.Millisecond).String()
+}
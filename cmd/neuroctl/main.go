@@ -0,0 +1,485 @@
+// neuroctl - Command line client for the NeuroGate API
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const usage = `Usage: neuroctl [flags] <command> [args]
+
+Commands:
+  prompt [flags] <query>                    Send a prompt ("-" reads stdin) and print the response
+  workers list                              List workers with their health, breaker state and stats
+  usage tail [-key id] [-model name]        Print requests as they complete, with running totals
+  keys export [-o file]                     Export API keys and policies as JSON
+  keys import [-replace] [-dry-run] <file>  Import API keys and policies ("-" reads stdin)
+  incident set <message>                    Show an incident note on the public status page
+  incident clear                            Remove the incident note
+  breaker trip <worker>                     Open a worker's circuit breaker until it recovers
+  breaker force-open <worker>               Keep a worker out of rotation until reset
+  breaker disable <worker>                  Bypass a worker's circuit breaker until reset
+  breaker reset <worker>                    Close a worker's circuit breaker and end overrides
+  log-level get                             Show the gateway's log level
+  log-level set <level>                     Change the gateway's log level (debug, info, warn, error)
+  audit verify                              Check the audit log's hash chain for tampering
+  models pull [-worker id] <model>          Pull a model on every worker, or one, showing progress
+  models delete [-worker id] <model>        Delete a model from every worker, or one
+  models copy [-worker id] <src> <dst>      Copy a model under a new name
+  models show [-worker id] <model>          Show a model's details
+
+Flags:
+`
+
+// client calls the gateway API
+type client struct {
+	baseURL  string
+	apiKey   string
+	adminKey string
+	http     *http.Client
+}
+
+func main() {
+	fs := flag.NewFlagSet("neuroctl", flag.ExitOnError)
+	addr := fs.String("addr", getEnv("NEUROGATE_URL", "http://localhost:8080"), "Gateway base URL (NEUROGATE_URL)")
+	apiKey := fs.String("api-key", os.Getenv("NEUROGATE_API_KEY"), "API key (NEUROGATE_API_KEY)")
+	adminKey := fs.String("admin-key", os.Getenv("NEUROGATE_ADMIN_KEY"), "Admin API key (NEUROGATE_ADMIN_KEY)")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+
+	c := &client{
+		baseURL:  strings.TrimSuffix(*addr, "/"),
+		apiKey:   *apiKey,
+		adminKey: *adminKey,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+
+	args := fs.Args()
+	if len(args) > 0 && args[0] == "prompt" {
+		if err := c.prompt(args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "neuroctl:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(args) < 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch args[0] + " " + args[1] {
+	case "workers list":
+		err = c.listWorkers()
+	case "usage tail":
+		err = c.tailUsage(args[2:])
+	case "keys export":
+		err = c.exportKeys(args[2:])
+	case "keys import":
+		err = c.importKeys(args[2:])
+	case "incident set":
+		err = c.setIncident(args[2:])
+	case "incident clear":
+		_, err = c.do("DELETE", "/admin/status/incident", nil)
+	case "breaker trip", "breaker force-open", "breaker disable", "breaker reset":
+		err = c.breakerAction(strings.ReplaceAll(args[1], "-", "_"), args[2:])
+	case "audit verify":
+		err = c.verifyAudit()
+	case "models pull":
+		err = c.pullModel(args[2:])
+	case "models delete", "models copy":
+		err = c.changeModel(args[1], args[2:])
+	case "models show":
+		err = c.showModel(args[2:])
+	case "log-level get":
+		err = c.logLevel("GET", nil)
+	case "log-level set":
+		if len(args) != 3 {
+			err = fmt.Errorf("log-level set requires a level")
+			break
+		}
+		body, _ := json.Marshal(map[string]string{"level": args[2]})
+		err = c.logLevel("PUT", body)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "neuroctl:", err)
+		os.Exit(1)
+	}
+}
+
+// exportKeys writes the gateway's access config to a file or stdout
+func (c *client) exportKeys(args []string) error {
+	fs := flag.NewFlagSet("keys export", flag.ExitOnError)
+	output := fs.String("o", "-", "Output file (\"-\" for stdout)")
+	fs.Parse(args)
+
+	body, err := c.do("GET", "/admin/keys/export", nil)
+	if err != nil {
+		return err
+	}
+
+	if *output == "-" {
+		_, err = os.Stdout.Write(body)
+		return err
+	}
+	// The export contains secrets, so keep it private to the current user
+	return os.WriteFile(*output, body, 0600)
+}
+
+// importKeys uploads an access config and prints the resulting changes
+func (c *client) importKeys(args []string) error {
+	fs := flag.NewFlagSet("keys import", flag.ExitOnError)
+	replace := fs.Bool("replace", false, "Remove keys that are not in the file")
+	dryRun := fs.Bool("dry-run", false, "Report changes without applying them")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("keys import requires exactly one file argument")
+	}
+
+	var doc []byte
+	var err error
+	if fs.Arg(0) == "-" {
+		doc, err = io.ReadAll(os.Stdin)
+	} else {
+		doc, err = os.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read access config: %w", err)
+	}
+
+	query := url.Values{}
+	if *replace {
+		query.Set("mode", "replace")
+	}
+	if *dryRun {
+		query.Set("dry_run", "true")
+	}
+	path := "/admin/keys/import"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	body, err := c.do("POST", path, doc)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Created   int  `json:"created"`
+		Updated   int  `json:"updated"`
+		Unchanged int  `json:"unchanged"`
+		Removed   int  `json:"removed"`
+		DryRun    bool `json:"dry_run"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	prefix := ""
+	if result.DryRun {
+		prefix = "(dry run) "
+	}
+	fmt.Printf("%screated: %d, updated: %d, unchanged: %d, removed: %d\n",
+		prefix, result.Created, result.Updated, result.Unchanged, result.Removed)
+	return nil
+}
+
+// setIncident sets the incident note shown by the public /status endpoint
+func (c *client) setIncident(args []string) error {
+	message := strings.TrimSpace(strings.Join(args, " "))
+	if message == "" {
+		return fmt.Errorf("incident set requires a message")
+	}
+
+	body, _ := json.Marshal(map[string]string{"message": message})
+	_, err := c.do("PUT", "/admin/status/incident", body)
+	return err
+}
+
+// breakerAction applies an operator override to a worker's circuit breaker
+func (c *client) breakerAction(action string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("breaker %s requires a worker ID or address", action)
+	}
+
+	body, _ := json.Marshal(map[string]string{"action": action})
+	resp, err := c.do("POST", "/admin/workers/"+url.PathEscape(args[0])+"/circuit-breaker", body)
+	if err != nil {
+		return err
+	}
+
+	var status struct {
+		WorkerID string `json:"worker_id"`
+		State    string `json:"state"`
+	}
+	if err := json.Unmarshal(resp, &status); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	fmt.Printf("%s: %s\n", status.WorkerID, status.State)
+	return nil
+}
+
+// verifyAudit checks the gateway's audit log and fails if the chain is broken
+func (c *client) verifyAudit() error {
+	resp, err := c.do("GET", "/admin/audit/verify", nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Valid    bool   `json:"valid"`
+		Records  int    `json:"records"`
+		LastHash string `json:"last_hash"`
+		Error    string `json:"error"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if !result.Valid {
+		return fmt.Errorf("audit log verification failed after %d records: %s", result.Records, result.Error)
+	}
+	fmt.Printf("ok: %d records, last hash %s\n", result.Records, result.LastHash)
+	return nil
+}
+
+// logLevel reads or changes the gateway's log level and prints the result
+func (c *client) logLevel(method string, body []byte) error {
+	resp, err := c.do(method, "/admin/log-level", body)
+	if err != nil {
+		return err
+	}
+
+	var status struct {
+		Level string `json:"level"`
+	}
+	if err := json.Unmarshal(resp, &status); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	fmt.Println(status.Level)
+	return nil
+}
+
+// modelFlags parses the flags shared by the models commands and returns the
+// query string selecting the target worker
+func modelFlags(name string, args []string, nargs int, extra func(*flag.FlagSet)) (*flag.FlagSet, string, error) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	worker := fs.String("worker", "", "Only this worker (ID or address)")
+	if extra != nil {
+		extra(fs)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != nargs {
+		return nil, "", fmt.Errorf("%s requires %d argument(s)", name, nargs)
+	}
+	query := ""
+	if *worker != "" {
+		query = "?worker=" + url.QueryEscape(*worker)
+	}
+	return fs, query, nil
+}
+
+// pullModel pulls a model and prints each worker's progress. Pulls can take
+// many minutes, so the request has no timeout.
+func (c *client) pullModel(args []string) error {
+	var insecure *bool
+	fs, query, err := modelFlags("models pull", args, 1, func(fs *flag.FlagSet) {
+		insecure = fs.Bool("insecure", false, "Allow registries without TLS")
+	})
+	if err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"model": fs.Arg(0), "insecure": *insecure})
+	resp, err := c.send(&http.Client{}, "POST", "/admin/models/pull"+query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	type progress struct {
+		WorkerID  string `json:"worker_id"`
+		Status    string `json:"status"`
+		Total     int64  `json:"total"`
+		Completed int64  `json:"completed"`
+		Error     string `json:"error"`
+	}
+	// Print status changes and every 10% of each layer
+	last := map[string]string{}
+	failed := 0
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var p progress
+		if err := dec.Decode(&p); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read progress: %w", err)
+		}
+
+		if p.Error != "" {
+			failed++
+			fmt.Printf("%s: error: %s\n", p.WorkerID, p.Error)
+			continue
+		}
+		line := p.Status
+		if p.Total > 0 {
+			line = fmt.Sprintf("%s %d%%", p.Status, p.Completed*100/p.Total/10*10)
+		}
+		if last[p.WorkerID] != line {
+			last[p.WorkerID] = line
+			fmt.Printf("%s: %s\n", p.WorkerID, line)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("pull failed on %d worker(s)", failed)
+	}
+	return nil
+}
+
+// changeModel deletes or copies a model and prints each worker's outcome
+func (c *client) changeModel(command string, args []string) error {
+	method, path, nargs := "DELETE", "", 1
+	if command == "copy" {
+		method, path, nargs = "POST", "/admin/models/copy", 2
+	}
+	fs, query, err := modelFlags("models "+command, args, nargs, nil)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if command == "copy" {
+		body, _ = json.Marshal(map[string]string{"source": fs.Arg(0), "destination": fs.Arg(1)})
+	} else {
+		path = "/admin/models/" + url.PathEscape(fs.Arg(0))
+	}
+	resp, err := c.do(method, path+query, body)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Workers []struct {
+			WorkerID string `json:"worker_id"`
+			Error    string `json:"error"`
+		} `json:"workers"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	failed := 0
+	for _, w := range result.Workers {
+		if w.Error != "" {
+			failed++
+			fmt.Printf("%s: error: %s\n", w.WorkerID, w.Error)
+		} else {
+			fmt.Printf("%s: ok\n", w.WorkerID)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s failed on %d worker(s)", command, failed)
+	}
+	return nil
+}
+
+// showModel prints a model's details as JSON
+func (c *client) showModel(args []string) error {
+	fs, query, err := modelFlags("models show", args, 1, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do("GET", "/admin/models/"+url.PathEscape(fs.Arg(0))+query, nil)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	json.Indent(&out, resp, "", "  ")
+	fmt.Print(out.String())
+	return nil
+}
+
+// do sends an authenticated request and returns the response body,
+// turning non-2xx responses into errors
+func (c *client) do(method, path string, body []byte) ([]byte, error) {
+	resp, err := c.send(c.http, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return respBody, nil
+}
+
+// send sends an authenticated request with hc: with the admin key, or
+// outside the admin API without one, the API key. Non-2xx responses are
+// turned into errors; otherwise the caller must close the response body.
+func (c *client) send(hc *http.Client, method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	key := c.adminKey
+	if key == "" && !strings.HasPrefix(path, "/admin/") {
+		key = c.apiKey
+	}
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	var apiErr struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error != "" {
+		if apiErr.Message != "" {
+			return nil, fmt.Errorf("%s: %s (status %d)", apiErr.Error, apiErr.Message, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("%s (status %d)", apiErr.Error, resp.StatusCode)
+	}
+	return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
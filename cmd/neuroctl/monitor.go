@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// listWorkers prints the gateway's workers with their health and request
+// stats
+func (c *client) listWorkers() error {
+	resp, err := c.do("GET", "/workers", nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Workers []struct {
+			ID       string   `json:"id"`
+			Address  string   `json:"address"`
+			Healthy  bool     `json:"healthy"`
+			CBState  string   `json:"circuit_breaker_state"`
+			Models   []string `json:"models"`
+			Inflight int32    `json:"inflight"`
+			Stats    struct {
+				Requests     int64   `json:"requests"`
+				ErrorRate    float64 `json:"error_rate"`
+				AvgLatencyMs float64 `json:"avg_latency_ms"`
+			} `json:"stats"`
+		} `json:"workers"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tADDRESS\tHEALTH\tBREAKER\tINFLIGHT\tREQUESTS\tERRORS\tAVG LATENCY\tMODELS")
+	for _, w := range result.Workers {
+		health := "unhealthy"
+		if w.Healthy {
+			health = "healthy"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%.1f%%\t%dms\t%s\n",
+			w.ID, w.Address, health, w.CBState, w.Inflight, w.Stats.Requests,
+			w.Stats.ErrorRate*100, int64(w.Stats.AvgLatencyMs), strings.Join(w.Models, ","))
+	}
+	return tw.Flush()
+}
+
+// historyRecord is a completed request from GET /requests
+type historyRecord struct {
+	RequestID        string    `json:"request_id"`
+	Endpoint         string    `json:"endpoint"`
+	KeyID            string    `json:"key_id"`
+	Model            string    `json:"model"`
+	Status           int       `json:"status"`
+	PromptTokens     int32     `json:"prompt_tokens"`
+	CompletionTokens int32     `json:"completion_tokens"`
+	EstimatedCost    float64   `json:"estimated_cost"`
+	LatencyMs        int64     `json:"latency_ms"`
+	CompletedAt      time.Time `json:"completed_at"`
+}
+
+// tailUsage prints requests as they complete, from the gateway's request
+// history, with running totals, until interrupted
+func (c *client) tailUsage(args []string) error {
+	fs := flag.NewFlagSet("usage tail", flag.ExitOnError)
+	interval := fs.Duration("interval", 2*time.Second, "Polling interval")
+	since := fs.String("since", "1m", "Start with requests completed within this duration")
+	key := fs.String("key", "", "Only this key ID")
+	model := fs.String("model", "", "Only this model")
+	fs.Parse(args)
+
+	query := url.Values{"since": {*since}, "limit": {"1000"}}
+	if *key != "" {
+		query.Set("key", *key)
+	}
+	if *model != "" {
+		query.Set("model", *model)
+	}
+
+	var requests, tokens int64
+	var cost float64
+	seen := map[string]bool{}
+	fmt.Println("COMPLETED  REQUEST ID                            KEY           MODEL         STATUS  TOKENS  LATENCY  COST")
+	for {
+		resp, err := c.do("GET", "/requests?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		var result struct {
+			Requests []historyRecord `json:"requests"`
+		}
+		if err := json.Unmarshal(resp, &result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		// Newest first; print oldest first, skipping those printed by the
+		// previous poll, which the inclusive since repeats
+		slices.Reverse(result.Requests)
+		latest := map[string]bool{}
+		printed := 0
+		for _, rec := range result.Requests {
+			latest[rec.RequestID] = true
+			if seen[rec.RequestID] {
+				continue
+			}
+			printed++
+			requests++
+			tokens += int64(rec.PromptTokens + rec.CompletionTokens)
+			cost += rec.EstimatedCost
+			fmt.Printf("%s  %-36s  %-12s  %-12s  %6d  %6d  %5dms  $%.6f\n",
+				rec.CompletedAt.Local().Format("15:04:05"), rec.RequestID, rec.KeyID, rec.Model,
+				rec.Status, rec.PromptTokens+rec.CompletionTokens, rec.LatencyMs, rec.EstimatedCost)
+			query.Set("since", rec.CompletedAt.Format(time.RFC3339Nano))
+		}
+		if printed > 0 {
+			fmt.Fprintf(os.Stderr, "-- %d requests, %d tokens, $%.6f\n", requests, tokens, cost)
+		}
+		seen = latest
+
+		time.Sleep(*interval)
+	}
+}
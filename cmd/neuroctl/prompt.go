@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	neurogate "github.com/hugovillarreal/neurogate/pkg/client"
+	"github.com/hugovillarreal/neurogate/pkg/requestid"
+)
+
+// attachInterval is how often a streaming prompt tries to attach to its
+// request until the gateway has sent it to a worker
+const attachInterval = 50 * time.Millisecond
+
+// prompt sends a prompt and prints the response, and its usage to stderr
+func (c *client) prompt(args []string) error {
+	fs := flag.NewFlagSet("prompt", flag.ExitOnError)
+	model := fs.String("model", "", "Model (the gateway's default if unset)")
+	system := fs.String("system", "", "System prompt")
+	maxTokens := fs.Int("max-tokens", 0, "Maximum tokens to generate")
+	temperature := fs.Float64("temperature", 0, "Sampling temperature")
+	stream := fs.Bool("stream", false, "Print tokens as they are generated (requires an admin key)")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Request timeout")
+	fs.Parse(args)
+
+	query := strings.Join(fs.Args(), " ")
+	if query == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt: %w", err)
+		}
+		query = string(data)
+	}
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("prompt requires a query (\"-\" reads stdin)")
+	}
+	if *stream && c.adminKey == "" {
+		return fmt.Errorf("-stream requires an admin key")
+	}
+
+	gw, err := c.sdk()
+	if err != nil {
+		return err
+	}
+	// The ID is chosen here so a streaming prompt can attach to its request
+	ctx, cancel := context.WithTimeout(requestid.NewContext(context.Background(), requestid.New()), *timeout)
+	defer cancel()
+	req := &neurogate.PromptRequest{
+		Query:        query,
+		Model:        *model,
+		SystemPrompt: *system,
+		MaxTokens:    int32(*maxTokens),
+		Temperature:  float32(*temperature),
+	}
+
+	var resp *neurogate.PromptResponse
+	streamed := false
+	if *stream {
+		resp, streamed, err = streamPrompt(ctx, gw, req)
+	} else {
+		resp, err = gw.Prompt(ctx, req)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !streamed {
+		fmt.Print(resp.Response)
+	}
+	fmt.Println()
+	source := resp.WorkerID
+	if resp.Cached {
+		source = "cache"
+	}
+	fmt.Fprintf(os.Stderr, "[%s] %s via %s: %d prompt + %d completion tokens, %dms, $%.6f\n",
+		resp.RequestID, resp.Model, source, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, resp.LatencyMs, resp.Usage.EstimatedCost)
+	return nil
+}
+
+// streamPrompt sends a prompt and prints its tokens as they are generated.
+// The gateway answers /prompt with the whole response, but lets admins
+// attach to an in-flight request's tokens, so the prompt attaches to its
+// own request once a worker has it. streamed is false if no tokens were
+// printed, e.g. for responses from the cache.
+func streamPrompt(ctx context.Context, gw *neurogate.Client, req *neurogate.PromptRequest) (resp *neurogate.PromptResponse, streamed bool, err error) {
+	type result struct {
+		resp *neurogate.PromptResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := gw.Prompt(ctx, req)
+		done <- result{resp, err}
+	}()
+
+	requestID := requestid.FromContext(ctx)
+	for {
+		attached := false
+		for token, err := range gw.StreamTokens(ctx, requestID) {
+			var apiErr *neurogate.APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				break // Not in flight yet
+			}
+			if err != nil {
+				// The response is still printed once it arrives
+				break
+			}
+			attached = true
+			streamed = streamed || token != ""
+			fmt.Print(token)
+		}
+
+		select {
+		case r := <-done:
+			return r.resp, streamed, r.err
+		case <-time.After(attachInterval):
+			if attached {
+				r := <-done
+				return r.resp, streamed, r.err
+			}
+		}
+	}
+}
+
+// sdk returns a client of the gateway's REST API
+func (c *client) sdk() (*neurogate.Client, error) {
+	return neurogate.New(neurogate.Config{URL: c.baseURL, APIKey: c.apiKey, AdminKey: c.adminKey})
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+	"github.com/hugovillarreal/neurogate/pkg/backend"
+)
+
+// Roles of conversation history messages
+const (
+	roleUser      = "user"
+	roleAssistant = "assistant"
+)
+
+// validateHistory checks that conversation history only holds user and
+// assistant turns; system prompts are sent separately
+func validateHistory(history []*llmv1.ChatMessage) error {
+	for i, m := range history {
+		if m.Role != roleUser && m.Role != roleAssistant {
+			return fmt.Errorf("history[%d]: role must be %q or %q", i, roleUser, roleAssistant)
+		}
+	}
+	return nil
+}
+
+// backendHistory converts conversation history for the backend
+func backendHistory(history []*llmv1.ChatMessage) []backend.Message {
+	if len(history) == 0 {
+		return nil
+	}
+	messages := make([]backend.Message, len(history))
+	for i, m := range history {
+		messages[i] = backend.Message{Role: m.Role, Content: m.Content}
+	}
+	return messages
+}
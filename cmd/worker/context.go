@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+)
+
+// validateContext checks that context tokens, which only Ollama's generate
+// API understands, aren't combined with conversation history
+func validateContext(req *llmv1.PromptRequest) error {
+	if len(req.History) > 0 && (len(req.Context) > 0 || req.ReturnContext) {
+		return fmt.Errorf("context can't be combined with history")
+	}
+	return nil
+}
+
+// backendContext converts a request's context tokens for the backend
+func backendContext(tokens []int32) []int {
+	if len(tokens) == 0 {
+		return nil
+	}
+	context := make([]int, len(tokens))
+	for i, t := range tokens {
+		context[i] = int(t)
+	}
+	return context
+}
+
+// responseContext converts the backend's context tokens for the response, if the
+// request asked for them
+func responseContext(req *llmv1.PromptRequest, context []int) []int32 {
+	if !req.ReturnContext || len(context) == 0 {
+		return nil
+	}
+	tokens := make([]int32, len(context))
+	for i, t := range context {
+		tokens[i] = int32(t)
+	}
+	return tokens
+}
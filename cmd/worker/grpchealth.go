@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+	"github.com/hugovillarreal/neurogate/pkg/health"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// grpcHealthWatchInterval is how often Watch streams re-evaluate health
+const grpcHealthWatchInterval = 5 * time.Second
+
+// grpcHealthServer implements the standard grpc.health.v1.Health service on
+// top of the worker's health.Checker, so gRPC probes see the same result as
+// /health/ready. The empty service name and the LLM service are both known.
+type grpcHealthServer struct {
+	healthpb.UnimplementedHealthServer
+
+	checker *health.Checker
+	done    chan struct{} // closed on shutdown to end Watch streams
+}
+
+func newGRPCHealthServer(checker *health.Checker) *grpcHealthServer {
+	return &grpcHealthServer{checker: checker, done: make(chan struct{})}
+}
+
+// shutdown ends open Watch streams so GracefulStop doesn't wait on them
+func (s *grpcHealthServer) shutdown() {
+	close(s.done)
+}
+
+// Check implements grpc.health.v1.Health/Check
+func (s *grpcHealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !knownService(req.Service) {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.Service)
+	}
+	return &healthpb.HealthCheckResponse{Status: s.servingStatus(ctx)}, nil
+}
+
+// List implements grpc.health.v1.Health/List
+func (s *grpcHealthServer) List(ctx context.Context, req *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	st := &healthpb.HealthCheckResponse{Status: s.servingStatus(ctx)}
+	return &healthpb.HealthListResponse{
+		Statuses: map[string]*healthpb.HealthCheckResponse{
+			"":                                       st,
+			llmv1.LLMService_ServiceDesc.ServiceName: st,
+		},
+	}, nil
+}
+
+// Watch implements grpc.health.v1.Health/Watch, sending the current status
+// and then every change until the client goes away or the server stops
+func (s *grpcHealthServer) Watch(req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse]) error {
+	if !knownService(req.Service) {
+		// Per the protocol, unknown services are reported rather than failed
+		return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN})
+	}
+
+	ticker := time.NewTicker(grpcHealthWatchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		current := s.servingStatus(stream.Context())
+		if current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-s.done:
+			if last == healthpb.HealthCheckResponse_NOT_SERVING {
+				return nil
+			}
+			return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING})
+		case <-ticker.C:
+		}
+	}
+}
+
+// servingStatus maps the checker result to a gRPC serving status using the
+// readiness rules: draining or unhealthy is NOT_SERVING, degraded still serves
+func (s *grpcHealthServer) servingStatus(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if s.checker.Draining() {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	result := s.checker.Latest()
+	if result == nil {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		result = s.checker.Run(ctx)
+	}
+
+	if result.Status == health.StatusUnhealthy {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+func knownService(name string) bool {
+	return name == "" || name == llmv1.LLMService_ServiceDesc.ServiceName
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// GRPCServerConfig configures the worker's gRPC server. Zero values use
+// gRPC's defaults, except the message sizes and keepalive enforcement,
+// which are loosened for long prompts and gateways that ping.
+type GRPCServerConfig struct {
+	MaxRecvMsgSize int // Largest request in bytes. Default: 16 MiB (gRPC's is 4 MiB)
+	MaxSendMsgSize int // Largest response in bytes. Default: 16 MiB
+
+	// Streams a client may have open on a connection at once; 0 is
+	// unlimited
+	MaxConcurrentStreams uint32
+
+	// Limit on a new connection's handshake. Default: 120 seconds
+	ConnectionTimeout time.Duration
+
+	// The server pings a client after this long without activity, and
+	// closes the connection if the ping isn't answered within
+	// KeepaliveTimeout. Defaults: 2 hours and 20 seconds.
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+
+	// Clients pinging more often than this, or without active streams
+	// unless PermitWithoutStream is set, are disconnected. Default: 10
+	// seconds (gRPC's is 5 minutes, which rejects most client keepalives).
+	KeepaliveMinTime    time.Duration
+	PermitWithoutStream bool
+
+	// Idle connections are closed after MaxConnectionIdle, and others after
+	// MaxConnectionAge plus MaxConnectionAgeGrace for requests to finish,
+	// so clients reconnect and rebalance. 0 never closes them.
+	MaxConnectionIdle     time.Duration
+	MaxConnectionAge      time.Duration
+	MaxConnectionAgeGrace time.Duration
+}
+
+// options returns the server options of the config
+func (c GRPCServerConfig) options() []grpc.ServerOption {
+	if c.MaxRecvMsgSize <= 0 {
+		c.MaxRecvMsgSize = 16 << 20
+	}
+	if c.MaxSendMsgSize <= 0 {
+		c.MaxSendMsgSize = 16 << 20
+	}
+	if c.KeepaliveMinTime <= 0 {
+		c.KeepaliveMinTime = 10 * time.Second
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(c.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(c.MaxSendMsgSize),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  c.KeepaliveTime,
+			Timeout:               c.KeepaliveTimeout,
+			MaxConnectionIdle:     c.MaxConnectionIdle,
+			MaxConnectionAge:      c.MaxConnectionAge,
+			MaxConnectionAgeGrace: c.MaxConnectionAgeGrace,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.KeepaliveMinTime,
+			PermitWithoutStream: c.PermitWithoutStream,
+		}),
+	}
+	if c.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(c.MaxConcurrentStreams))
+	}
+	if c.ConnectionTimeout > 0 {
+		opts = append(opts, grpc.ConnectionTimeout(c.ConnectionTimeout))
+	}
+	return opts
+}
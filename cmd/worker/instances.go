@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hugovillarreal/neurogate/pkg/backend"
+	"github.com/hugovillarreal/neurogate/pkg/ollama"
+)
+
+// ollamaInstances manages the models of several Ollama instances behind one
+// worker, so each can serve any model. Instances often share a model
+// directory, so an operation another instance already applied counts as
+// done.
+type ollamaInstances struct {
+	urls    []string
+	clients []*ollama.Client
+}
+
+var _ ollama.ModelManager = (*ollamaInstances)(nil)
+
+// newOllamaPool creates a backend dispatching requests across the Ollama
+// instances at urls, and a model manager applying changes to all of them
+func newOllamaPool(urls []string, dispatch string, opts ollama.ClientOptions, keepAlive *ollama.Duration) (*backend.Pool, *ollamaInstances, error) {
+	manager := &ollamaInstances{urls: urls}
+	instances := make([]backend.Instance, len(urls))
+	for i, url := range urls {
+		client := ollama.NewClientWithOptions(url, opts)
+		manager.clients = append(manager.clients, client)
+		instances[i] = backend.Instance{Name: url, Backend: backend.NewOllama(client, keepAlive)}
+	}
+	pool, err := backend.NewPool(dispatch, instances...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pool, manager, nil
+}
+
+// Pull implements ollama.ModelManager, pulling the model on each instance in
+// turn
+func (m *ollamaInstances) Pull(ctx context.Context, req *ollama.PullRequest, fn func(*ollama.ProgressResponse) error) error {
+	for i, client := range m.clients {
+		if err := client.Pull(ctx, req, fn); err != nil {
+			return fmt.Errorf("%s: %w", m.urls[i], err)
+		}
+	}
+	return nil
+}
+
+// Delete implements ollama.ModelManager. The model must have been installed
+// on at least one instance.
+func (m *ollamaInstances) Delete(ctx context.Context, req *ollama.DeleteRequest) error {
+	return m.each(func(client *ollama.Client) error {
+		return client.Delete(ctx, req)
+	})
+}
+
+// Copy implements ollama.ModelManager
+func (m *ollamaInstances) Copy(ctx context.Context, req *ollama.CopyRequest) error {
+	return m.each(func(client *ollama.Client) error {
+		return client.Copy(ctx, req)
+	})
+}
+
+// Show implements ollama.ModelManager, describing the model as installed on
+// the first instance that has it
+func (m *ollamaInstances) Show(ctx context.Context, req *ollama.ShowRequest) (*ollama.ShowResponse, error) {
+	var errs []string
+	var notFound error
+	for i, client := range m.clients {
+		resp, err := client.Show(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if errors.Is(err, ollama.ErrModelNotFound) {
+			notFound = err
+			continue
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", m.urls[i], err))
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return nil, notFound
+}
+
+// each applies op to every instance. Instances without the model are
+// skipped, failing only if none had it.
+func (m *ollamaInstances) each(op func(*ollama.Client) error) error {
+	var notFound error
+	applied := false
+	for i, client := range m.clients {
+		err := op(client)
+		switch {
+		case err == nil:
+			applied = true
+		case errors.Is(err, ollama.ErrModelNotFound):
+			notFound = err
+		default:
+			return fmt.Errorf("%s: %w", m.urls[i], err)
+		}
+	}
+	if !applied {
+		return notFound
+	}
+	return nil
+}
+
+// refreshInstances records the health of each instance of a multi-instance
+// backend, logging changes
+func (s *WorkerServer) refreshInstances() {
+	pool, ok := s.backend.(*backend.Pool)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.instanceHealthy == nil {
+		s.instanceHealthy = make(map[string]bool)
+	}
+	for _, inst := range pool.Instances() {
+		s.metrics.SetBackendInstanceUp(inst.Name, inst.Healthy)
+		was, seen := s.instanceHealthy[inst.Name]
+		s.instanceHealthy[inst.Name] = inst.Healthy
+		switch {
+		case inst.Healthy && seen && !was:
+			s.log.Info("backend instance recovered", "instance", inst.Name)
+		case !inst.Healthy && (!seen || was):
+			s.log.Warn("backend instance unhealthy", "instance", inst.Name, "error", inst.Err)
+		}
+	}
+}
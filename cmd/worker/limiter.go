@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/logger"
+	"github.com/hugovillarreal/neurogate/pkg/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// inferenceLimiter bounds how many inferences run at once. Requests beyond
+// the limit wait in a bounded queue for a slot, and are rejected with
+// ResourceExhausted, which the gateway retries on another worker, when the
+// queue is full, the wait times out or the estimated wait would outlast
+// the request's deadline.
+type inferenceLimiter struct {
+	slots        chan struct{}
+	maxQueue     int32
+	queueTimeout time.Duration // 0 waits until the request's deadline
+	queued       atomic.Int32
+	metrics      *metrics.Metrics
+
+	mu          sync.Mutex
+	avgDuration time.Duration // Moving average of how long inferences hold a slot
+}
+
+// durationWeight is the weight of each new inference in the average duration
+const durationWeight = 0.2
+
+func newInferenceLimiter(maxConcurrent, maxQueue int, queueTimeout time.Duration, m *metrics.Metrics) *inferenceLimiter {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 10
+	}
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+	return &inferenceLimiter{
+		slots:        make(chan struct{}, maxConcurrent),
+		maxQueue:     int32(maxQueue),
+		queueTimeout: queueTimeout,
+		metrics:      m,
+	}
+}
+
+// acquire takes a slot, waiting in the queue if none is free. The returned
+// function releases it.
+func (l *inferenceLimiter) acquire(ctx context.Context) (func(), error) {
+	release := func() { <-l.slots }
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if wait := l.estimatedWait(); wait > time.Until(deadline) {
+			l.metrics.RecordInferenceRejection("deadline")
+			return nil, status.Errorf(codes.ResourceExhausted, "worker at capacity: estimated wait %s exceeds the request's deadline", wait.Round(time.Millisecond))
+		}
+	}
+
+	if l.queued.Add(1) > l.maxQueue {
+		l.queued.Add(-1)
+		l.metrics.RecordInferenceRejection("queue_full")
+		return nil, status.Error(codes.ResourceExhausted, "worker at capacity: inference queue is full")
+	}
+	l.metrics.SetInferenceQueueDepth(int(l.queued.Load()))
+	defer func() {
+		l.metrics.SetInferenceQueueDepth(int(l.queued.Add(-1)))
+	}()
+
+	var timeout <-chan time.Time
+	if l.queueTimeout > 0 {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		l.metrics.RecordInferenceRejection("queue_timeout")
+		return nil, status.Error(codes.ResourceExhausted, "worker at capacity: timed out waiting for an inference slot")
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// observe adds how long an inference held its slot to the average
+func (l *inferenceLimiter) observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.avgDuration == 0 {
+		l.avgDuration = d
+		return
+	}
+	l.avgDuration += time.Duration(durationWeight * float64(d-l.avgDuration))
+}
+
+// capacity returns the number of slots
+func (l *inferenceLimiter) capacity() int {
+	return cap(l.slots)
+}
+
+// running returns the number of inferences holding a slot
+func (l *inferenceLimiter) running() int {
+	return len(l.slots)
+}
+
+// waiting returns the number of requests queued for a slot
+func (l *inferenceLimiter) waiting() int {
+	return int(l.queued.Load())
+}
+
+// load returns the share of slots in use, from 0 to 1
+func (l *inferenceLimiter) load() float64 {
+	return float64(len(l.slots)) / float64(cap(l.slots))
+}
+
+// estimatedWait estimates how long a new request would wait for a slot:
+// none if one is free, otherwise the queue ahead of it plus itself, spread
+// across the slots, at the average inference duration
+func (l *inferenceLimiter) estimatedWait() time.Duration {
+	if l.running() < l.capacity() {
+		return 0
+	}
+	l.mu.Lock()
+	avg := l.avgDuration
+	l.mu.Unlock()
+	return avg * time.Duration(l.waiting()+1) / time.Duration(l.capacity())
+}
+
+// startInference waits for an inference slot and tracks the inference as
+// active until the returned function is called. Inferences are refused
+// without waiting while the worker is under memory pressure.
+func (s *WorkerServer) startInference(ctx context.Context) (func(), error) {
+	if s.memoryGuard != nil {
+		if err := s.memoryGuard.admit(); err != nil {
+			logger.FromContext(ctx).Warn("inference rejected", "error", err)
+			return nil, err
+		}
+	}
+
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Warn("inference rejected", "error", err)
+		return nil, err
+	}
+
+	start := time.Now()
+	s.activeRequests.Add(1)
+	s.metrics.IncActiveInferences()
+	s.metrics.SetWorkerLoad(s.limiter.load())
+	return func() {
+		s.limiter.observe(time.Since(start))
+		s.activeRequests.Add(-1)
+		s.metrics.DecActiveInferences()
+		release()
+		s.metrics.SetWorkerLoad(s.limiter.load())
+	}, nil
+}
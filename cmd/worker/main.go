@@ -1,27 +1,39 @@
 // Worker Service - gRPC server for LLM inference
-// This service connects to Ollama and handles inference requests from the Gateway
+// This service connects to an inference backend, Ollama by default, and
+// handles inference requests from the Gateway
 package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
 
 	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+	"github.com/hugovillarreal/neurogate/pkg/backend"
 	"github.com/hugovillarreal/neurogate/pkg/health"
 	"github.com/hugovillarreal/neurogate/pkg/logger"
 	"github.com/hugovillarreal/neurogate/pkg/metrics"
 	"github.com/hugovillarreal/neurogate/pkg/ollama"
+	"github.com/hugovillarreal/neurogate/pkg/redact"
+	"github.com/hugovillarreal/neurogate/pkg/reqmeta"
+	"github.com/hugovillarreal/neurogate/pkg/signing"
+	"github.com/hugovillarreal/neurogate/pkg/tracing"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 )
@@ -31,6 +43,7 @@ const (
 	defaultMetricsPort = "9090"
 	defaultOllamaURL   = "http://localhost:11434"
 	defaultModel       = "llama3.2"
+	defaultEmbedModel  = "nomic-embed-text"
 	version            = "1.0.0"
 )
 
@@ -39,49 +52,133 @@ type WorkerServer struct {
 	llmv1.UnimplementedLLMServiceServer
 
 	log           *logger.Logger
-	ollamaClient  *ollama.Client
+	backend       backend.InferenceBackend
+	modelManager  ollama.ModelManager // nil unless the backend is Ollama
 	metrics       *metrics.Metrics
 	healthChecker *health.Checker
 
+	// Stable identity reported to the gateway
+	instanceID string
+
+	// Signs results when configured (nil otherwise)
+	signer *signing.Signer
+
+	// Recovers Ollama after repeated failed health checks (nil when disabled)
+	watchdog *watchdog
+
+	// Refuses inferences under memory pressure (nil when disabled)
+	memoryGuard *memoryGuard
+
+	// Generation defaults by model, merged under request values
+	modelDefaults modelDefaults
+
 	// State tracking
 	activeRequests atomic.Int32
+	limiter        *inferenceLimiter
 	mu             sync.RWMutex
-	ollamaHealthy  atomic.Bool
+	backendHealthy atomic.Bool
+	models         atomic.Pointer[[]string] // refreshed by the backend health check
+	loadedModels   atomic.Pointer[[]*llmv1.LoadedModel]
+
+	// Last known health of each instance of a multi-instance backend,
+	// guarded by mu
+	instanceHealthy map[string]bool
+}
+
+// Config holds worker configuration
+type Config struct {
+	OllamaURL  string
+	InstanceID string // Stable identity reported to the gateway; optional
+	Signer     *signing.Signer
+	Watchdog   WatchdogConfig    // Ollama recovery; disabled unless an action is set
+	Memory     MemoryGuardConfig // Memory-based admission; disabled unless a threshold is set
+	KeepAlive  *ollama.Duration
+	Ollama     ollama.ClientOptions // Timeouts and retries of calls to Ollama
+
+	// Generation defaults by model, merged under request values; the
+	// "default" entry applies to models without their own
+	ModelDefaults modelDefaults
+
+	// Used instead of Ollama at OllamaURL if set, with ModelManager serving
+	// the model admin service if it isn't nil
+	Backend      backend.InferenceBackend
+	ModelManager ollama.ModelManager
+
+	MaxConcurrentInferences int           // Inferences run at once. Default: 10
+	InferenceQueueSize      int           // Requests waiting for a slot beyond which more are rejected
+	InferenceQueueTimeout   time.Duration // Longest wait for a slot; 0 waits until the request's deadline
+
+	HealthFailureThreshold int // Consecutive unhealthy runs before /health reports it
+	HealthSuccessThreshold int // Consecutive healthy runs before /health recovers
 }
 
 // NewWorkerServer creates a new worker server
-func NewWorkerServer(log *logger.Logger, ollamaURL string) *WorkerServer {
+func NewWorkerServer(log *logger.Logger, cfg Config) *WorkerServer {
 	m := metrics.NewWorkerMetrics("neurogate_worker")
 	h := health.NewChecker(version)
+	h.SetThresholds(cfg.HealthFailureThreshold, cfg.HealthSuccessThreshold)
+	h.OnStatusChange(func(from, to health.Status) {
+		log.Warn("health status changed", "from", from, "to", to)
+	})
+
+	if cfg.Backend == nil {
+		cfg.Ollama.OnRetry = func(attempt int, err error) {
+			log.Warn("retrying ollama request", "attempt", attempt, "error", err)
+		}
+		client := ollama.NewClientWithOptions(cfg.OllamaURL, cfg.Ollama)
+		cfg.Backend = backend.NewOllama(client, cfg.KeepAlive)
+		cfg.ModelManager = client
+	}
 
 	server := &WorkerServer{
 		log:           log,
-		ollamaClient:  ollama.NewClient(ollamaURL),
+		backend:       cfg.Backend,
+		modelManager:  cfg.ModelManager,
 		metrics:       m,
 		healthChecker: h,
+		instanceID:    cfg.InstanceID,
+		signer:        cfg.Signer,
+		modelDefaults: cfg.ModelDefaults,
+		limiter:       newInferenceLimiter(cfg.MaxConcurrentInferences, cfg.InferenceQueueSize, cfg.InferenceQueueTimeout, m),
 	}
 
-	// Register Ollama health check
-	h.Register("ollama", func(ctx context.Context) *health.Check {
+	if cfg.Memory.enabled() {
+		server.memoryGuard = newMemoryGuard(cfg.Memory, log, m)
+		log.Info("memory guard enabled",
+			"system_threshold", cfg.Memory.SystemThreshold,
+			"gpu_threshold", cfg.Memory.GPUThreshold,
+		)
+	}
+
+	if cfg.Watchdog.enabled() {
+		server.watchdog = newWatchdog(cfg.Watchdog, log, m)
+		log.Info("ollama watchdog enabled",
+			"failures", server.watchdog.cfg.Failures,
+			"cooldown", server.watchdog.cfg.Cooldown,
+		)
+	}
+
+	// Register backend health check
+	h.Register("backend", func(ctx context.Context) *health.Check {
 		start := time.Now()
-		err := server.ollamaClient.Ping(ctx)
+		err := server.backend.Health(ctx)
 		latency := time.Since(start)
 
 		if err != nil {
-			server.ollamaHealthy.Store(false)
+			server.backendHealthy.Store(false)
 			server.metrics.SetOllamaConnected(false)
 			return &health.Check{
-				Name:    "ollama",
+				Name:    "backend",
 				Status:  health.StatusUnhealthy,
 				Message: err.Error(),
 				Latency: latency,
 			}
 		}
 
-		server.ollamaHealthy.Store(true)
+		server.backendHealthy.Store(true)
 		server.metrics.SetOllamaConnected(true)
 		return &health.Check{
-			Name:    "ollama",
+			Name:    "backend",
 			Status:  health.StatusHealthy,
 			Latency: latency,
 		}
@@ -90,10 +187,10 @@ func NewWorkerServer(log *logger.Logger, ollamaURL string) *WorkerServer {
 	return server
 }
 
-// StartHealthChecker starts a background goroutine to check Ollama health
+// StartHealthChecker starts a background goroutine to check backend health
 func (s *WorkerServer) StartHealthChecker(ctx context.Context) {
 	// Check immediately on startup
-	s.checkOllamaHealth()
+	s.checkBackendHealth()
 
 	// Then check periodically
 	go func() {
@@ -105,139 +202,525 @@ func (s *WorkerServer) StartHealthChecker(ctx context.Context) {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				s.checkOllamaHealth()
+				s.checkBackendHealth()
 			}
 		}
 	}()
 }
 
-// checkOllamaHealth checks if Ollama is reachable
-func (s *WorkerServer) checkOllamaHealth() {
+// checkBackendHealth checks if the backend is reachable
+func (s *WorkerServer) checkBackendHealth() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err := s.ollamaClient.Ping(ctx)
+	err := s.backend.Health(ctx)
 	if err != nil {
-		s.ollamaHealthy.Store(false)
+		s.backendHealthy.Store(false)
 		s.metrics.SetOllamaConnected(false)
-		s.log.Debug("ollama health check failed", "error", err)
+		s.log.Debug("backend health check failed", "error", err)
 	} else {
-		s.ollamaHealthy.Store(true)
+		s.backendHealthy.Store(true)
 		s.metrics.SetOllamaConnected(true)
-		s.log.Debug("ollama health check passed")
+		s.log.Debug("backend health check passed")
+		s.refreshModels(ctx)
+		s.refreshSlots(ctx)
+	}
+	s.refreshInstances()
+
+	if s.watchdog != nil {
+		s.watchdog.observe(err == nil)
+	}
+}
+
+// refreshModels caches the names of the models the backend serves and, if
+// it reports them, of those loaded into memory, reported to the gateway in
+// health checks. The previous lists are kept on failure.
+func (s *WorkerServer) refreshModels(ctx context.Context) {
+	models, err := s.backend.ListModels(ctx)
+	if err != nil {
+		s.log.Debug("failed to list backend models", "error", err)
+		return
+	}
+
+	names := make([]string, len(models))
+	for i, m := range models {
+		names[i] = m.Name
+	}
+	s.models.Store(&names)
+
+	lister, ok := s.backend.(backend.LoadedModelLister)
+	if !ok {
+		return
+	}
+	running, err := lister.ListLoaded(ctx)
+	if err != nil {
+		s.log.Debug("failed to list loaded backend models", "error", err)
+		return
+	}
+
+	loaded := make([]*llmv1.LoadedModel, len(running))
+	vram := make(map[string]int64, len(running))
+	for i, m := range running {
+		loaded[i] = &llmv1.LoadedModel{
+			Name:     m.Name,
+			Size:     m.Size,
+			SizeVram: m.SizeVRAM,
+		}
+		if !m.ExpiresAt.IsZero() {
+			loaded[i].ExpiresAt = m.ExpiresAt.UnixMilli()
+		}
+		vram[m.Name] = m.SizeVRAM
 	}
+	s.loadedModels.Store(&loaded)
+	s.metrics.SetOllamaLoadedModels(vram)
+}
+
+// refreshSlots records the request slots of backends that report them
+func (s *WorkerServer) refreshSlots(ctx context.Context) {
+	reporter, ok := s.backend.(backend.SlotReporter)
+	if !ok {
+		return
+	}
+	slots, err := reporter.Slots(ctx)
+	if err != nil {
+		s.log.Debug("failed to get backend slots", "error", err)
+		return
+	}
+	s.metrics.SetBackendSlots(slots.Idle, slots.Processing)
 }
 
 // GenerateText implements the LLMService.GenerateText RPC
 func (s *WorkerServer) GenerateText(ctx context.Context, req *llmv1.PromptRequest) (*llmv1.PromptResponse, error) {
-	requestLog := s.log.WithRequestID(req.RequestId)
+	requestLog := logger.FromContext(ctx)
 	requestLog.Info("received generate request",
 		"model", req.Model,
 		"prompt_length", len(req.Prompt),
+		"prompt", req.Prompt,
 	)
 
-	// Track active requests
-	s.activeRequests.Add(1)
-	s.metrics.ActiveInferences.Inc()
-	defer func() {
-		s.activeRequests.Add(-1)
-		s.metrics.ActiveInferences.Dec()
-	}()
-
-	// Update worker load metric
-	load := float64(s.activeRequests.Load()) / 10.0 // Assuming max 10 concurrent requests
-	if load > 1.0 {
-		load = 1.0
-	}
-	s.metrics.WorkerLoad.Set(load)
-
 	// Validate request
 	if req.Prompt == "" {
 		return nil, status.Error(codes.InvalidArgument, "prompt is required")
 	}
+	if err := validateHistory(req.History); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := validateContext(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	format, err := outputFormat(req.Format)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
 	model := req.Model
 	if model == "" {
 		model = defaultModel
 	}
 
-	// Build Ollama request
-	ollamaReq := &ollama.GenerateRequest{
-		Model:  model,
-		Prompt: req.Prompt,
-		System: req.SystemPrompt,
-		Options: &ollama.GenerateOptions{
-			Temperature: float64(req.Temperature),
-			NumPredict:  int(req.MaxTokens),
-		},
+	done, err := s.startInference(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer done()
 
-	// Call Ollama
+	// Build backend request
+	keepAlive := s.modelDefaults.apply(model, req)
+	params := generationParameters(req)
+	backendReq := &backend.GenerateRequest{
+		Model:     model,
+		Prompt:    req.Prompt,
+		System:    req.SystemPrompt,
+		History:   backendHistory(req.History),
+		Options:   generateOptions(params),
+		Format:    format,
+		Context:   backendContext(req.Context),
+		KeepAlive: keepAlive,
+	}
+
+	// Call the backend
 	start := time.Now()
-	resp, err := s.ollamaClient.Generate(ctx, ollamaReq)
+	resp, err := s.backend.Generate(ctx, backendReq)
 	duration := time.Since(start)
 
 	if err != nil {
-		requestLog.Error("ollama generation failed", "error", err)
-		s.metrics.OllamaRequestErrors.WithLabelValues(model, "generation_error").Inc()
-		return nil, status.Errorf(codes.Internal, "failed to generate text: %v", err)
+		requestLog.Error("backend generation failed", "error", err)
+		s.metrics.RecordOllamaError(model, backendErrorType(err, "generation_error"))
+		return nil, backendStatus(err, "failed to generate text")
 	}
 
 	// Record metrics
 	inferenceSeconds := duration.Seconds()
-	tokensGenerated := resp.EvalCount
+	tokensGenerated := resp.CompletionTokens
 	s.metrics.RecordInference(model, inferenceSeconds, tokensGenerated)
-	s.metrics.OllamaRequestsTotal.WithLabelValues(model, "success").Inc()
+	s.metrics.RecordOllamaRequest(model, "success")
 
 	requestLog.Info("generation complete",
 		"duration_ms", duration.Milliseconds(),
 		"tokens_generated", tokensGenerated,
+		"seed", params.Seed,
+		"response", resp.Text,
 	)
 
 	return &llmv1.PromptResponse{
 		RequestId:        req.RequestId,
-		Response:         resp.Response,
-		PromptTokens:     int32(resp.PromptEvalCount),
-		CompletionTokens: int32(resp.EvalCount),
-		TotalTokens:      int32(resp.PromptEvalCount + resp.EvalCount),
+		Response:         resp.Text,
+		PromptTokens:     int32(resp.PromptTokens),
+		CompletionTokens: int32(resp.CompletionTokens),
+		TotalTokens:      int32(resp.PromptTokens + resp.CompletionTokens),
 		InferenceTimeMs:  duration.Milliseconds(),
 		Model:            model,
+		Signature: s.sign(signing.Payload{
+			RequestID:        req.RequestId,
+			Model:            model,
+			Response:         resp.Text,
+			PromptTokens:     int32(resp.PromptTokens),
+			CompletionTokens: int32(resp.CompletionTokens),
+		}),
+		// Cost is estimated by the gateway, which owns model pricing
+		Usage: &llmv1.Usage{
+			PromptTokens:     int32(resp.PromptTokens),
+			CompletionTokens: int32(resp.CompletionTokens),
+			TotalTokens:      int32(resp.PromptTokens + resp.CompletionTokens),
+		},
+		Parameters: params,
+		Context:    responseContext(req, resp.Context),
 	}, nil
 }
 
 // StreamGenerateText implements streaming text generation
 func (s *WorkerServer) StreamGenerateText(req *llmv1.PromptRequest, stream grpc.ServerStreamingServer[llmv1.TokenResponse]) error {
-	// For now, we'll implement non-streaming and send in one chunk
-	// Full streaming implementation would require changes to the Ollama client
+	requestLog := logger.FromContext(stream.Context())
+	requestLog.Info("received stream request",
+		"model", req.Model,
+		"prompt_length", len(req.Prompt),
+		"prompt", req.Prompt,
+	)
+
+	// Validate request
+	if req.Prompt == "" {
+		return status.Error(codes.InvalidArgument, "prompt is required")
+	}
+	if err := validateHistory(req.History); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := validateContext(req); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	format, err := outputFormat(req.Format)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
 
-	resp, err := s.GenerateText(stream.Context(), req)
+	model := req.Model
+	if model == "" {
+		model = defaultModel
+	}
+
+	done, err := s.startInference(stream.Context())
 	if err != nil {
 		return err
 	}
+	defer done()
+
+	keepAlive := s.modelDefaults.apply(model, req)
+	params := generationParameters(req)
+	backendReq := &backend.GenerateRequest{
+		Model:     model,
+		Prompt:    req.Prompt,
+		System:    req.SystemPrompt,
+		History:   backendHistory(req.History),
+		Options:   generateOptions(params),
+		Format:    format,
+		Context:   backendContext(req.Context),
+		KeepAlive: keepAlive,
+	}
+
+	// Relay chunks from the backend as they arrive
+	start := time.Now()
+	lastToken := start
+	var tokensGenerated int32
+	var text strings.Builder
+	err = s.backend.Stream(stream.Context(), backendReq, func(chunk *backend.GenerateResponse) error {
+		text.WriteString(chunk.Text)
+		if !chunk.Done {
+			now := time.Now()
+			if tokensGenerated == 0 {
+				s.metrics.RecordFirstToken(model, now.Sub(start).Seconds())
+			} else {
+				s.metrics.RecordInterTokenLatency(model, now.Sub(lastToken).Seconds())
+			}
+			lastToken = now
+			tokensGenerated++
+			return stream.Send(&llmv1.TokenResponse{
+				RequestId:       req.RequestId,
+				Token:           chunk.Text,
+				TokensGenerated: tokensGenerated,
+			})
+		}
+
+		duration := time.Since(start)
+		s.metrics.RecordInference(model, duration.Seconds(), chunk.CompletionTokens)
+		s.metrics.RecordOllamaRequest(model, "success")
+
+		requestLog.Info("stream complete",
+			"duration_ms", duration.Milliseconds(),
+			"tokens_generated", chunk.CompletionTokens,
+			"seed", params.Seed,
+			"response", text.String(),
+		)
+
+		return stream.Send(&llmv1.TokenResponse{
+			RequestId:       req.RequestId,
+			Token:           chunk.Text,
+			Done:            true,
+			TokensGenerated: int32(chunk.CompletionTokens),
+			Model:           model,
+			PromptTokens:    int32(chunk.PromptTokens),
+			InferenceTimeMs: duration.Milliseconds(),
+			Signature: s.sign(signing.Payload{
+				RequestID:        req.RequestId,
+				Model:            model,
+				Response:         text.String(),
+				PromptTokens:     int32(chunk.PromptTokens),
+				CompletionTokens: int32(chunk.CompletionTokens),
+			}),
+			Parameters: params,
+			Context:    responseContext(req, chunk.Context),
+		})
+	})
+
+	if err != nil {
+		requestLog.Error("backend stream failed", "error", err)
+		s.metrics.RecordOllamaError(model, backendErrorType(err, "generation_error"))
+		if st, ok := status.FromError(err); ok {
+			return st.Err()
+		}
+		return backendStatus(err, "failed to generate text")
+	}
+
+	return nil
+}
+
+// sign signs a result payload if a signing key is configured
+func (s *WorkerServer) sign(p signing.Payload) []byte {
+	if s.signer == nil {
+		return nil
+	}
+	return s.signer.Sign(p)
+}
+
+// backendStatus converts a backend error into a gRPC status whose code
+// tells the gateway whether another attempt could succeed: a missing model
+// is NotFound, while a worker that is out of memory or still loading the
+// model is ResourceExhausted or Unavailable. A prompt longer than the
+// model's context is OutOfRange, and a request the backend can't serve is
+// InvalidArgument.
+func backendStatus(err error, msg string) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, backend.ErrModelNotFound):
+		code = codes.NotFound
+	case errors.Is(err, backend.ErrOutOfMemory):
+		code = codes.ResourceExhausted
+	case errors.Is(err, backend.ErrModelLoading):
+		code = codes.Unavailable
+	case errors.Is(err, backend.ErrContextTooLong):
+		code = codes.OutOfRange
+	case errors.Is(err, backend.ErrUnsupported):
+		code = codes.InvalidArgument
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	}
+	return status.Errorf(code, "%s: %v", msg, err)
+}
+
+// generationParameters returns the parameters to generate with, choosing a
+// random seed when the request has none so the result can be reproduced
+func generationParameters(req *llmv1.PromptRequest) *llmv1.GenerationParameters {
+	seed := rand.Int64N(1 << 31) // Ollama's seeds are 32-bit
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	return &llmv1.GenerationParameters{
+		Temperature:   req.Temperature,
+		MaxTokens:     req.MaxTokens,
+		TopP:          req.TopP,
+		TopK:          req.TopK,
+		RepeatPenalty: req.RepeatPenalty,
+		Seed:          seed,
+		Stop:          req.Stop,
+		NumCtx:        req.NumCtx,
+	}
+}
+
+// generateOptions converts generation parameters to backend options. Zero
+// values are left for the model's defaults.
+func generateOptions(p *llmv1.GenerationParameters) backend.Options {
+	seed := int(p.Seed)
+	return backend.Options{
+		Temperature:   float64(p.Temperature),
+		MaxTokens:     int(p.MaxTokens),
+		TopP:          float64(p.TopP),
+		TopK:          int(p.TopK),
+		RepeatPenalty: float64(p.RepeatPenalty),
+		Seed:          &seed,
+		Stop:          p.Stop,
+		NumCtx:        int(p.NumCtx),
+	}
+}
+
+// outputFormat converts a request's format for the backend: "json" for any
+// JSON, or a JSON schema object
+func outputFormat(format string) (json.RawMessage, error) {
+	switch format {
+	case "":
+		return nil, nil
+	case "json":
+		return backend.FormatJSON, nil
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(format), &schema); err != nil {
+		return nil, fmt.Errorf(`format must be "json" or a JSON schema object`)
+	}
+	return json.RawMessage(format), nil
+}
 
-	// Send the response as a single token
-	return stream.Send(&llmv1.TokenResponse{
-		RequestId:       req.RequestId,
-		Token:           resp.Response,
-		Done:            true,
-		TokensGenerated: resp.CompletionTokens,
+// backendErrorType labels a backend error for metrics, using fallback when
+// the cause isn't recognized
+func backendErrorType(err error, fallback string) string {
+	switch {
+	case errors.Is(err, backend.ErrModelNotFound):
+		return "model_not_found"
+	case errors.Is(err, backend.ErrOutOfMemory):
+		return "out_of_memory"
+	case errors.Is(err, backend.ErrModelLoading):
+		return "model_loading"
+	case errors.Is(err, backend.ErrUnsupported):
+		return "unsupported"
+	}
+	return fallback
+}
+
+// Embed implements the LLMService.Embed RPC
+func (s *WorkerServer) Embed(ctx context.Context, req *llmv1.EmbedRequest) (*llmv1.EmbedResponse, error) {
+	requestLog := logger.FromContext(ctx)
+
+	if len(req.Input) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "input is required")
+	}
+
+	model := req.Model
+	if model == "" {
+		model = defaultEmbedModel
+	}
+
+	resp, err := s.backend.Embed(ctx, &backend.EmbedRequest{
+		Model:     model,
+		Input:     req.Input,
+		KeepAlive: s.modelDefaults.options(model).keepAlive(),
 	})
+	if err != nil {
+		requestLog.Error("backend embedding failed", "error", err)
+		s.metrics.RecordOllamaError(model, backendErrorType(err, "embedding_error"))
+		return nil, backendStatus(err, "failed to compute embeddings")
+	}
+	s.metrics.RecordOllamaRequest(model, "success")
+
+	embeddings := make([]*llmv1.Embedding, len(resp.Embeddings))
+	for i, values := range resp.Embeddings {
+		embeddings[i] = &llmv1.Embedding{Values: values}
+	}
+
+	return &llmv1.EmbedResponse{
+		RequestId:    req.RequestId,
+		Embeddings:   embeddings,
+		Model:        model,
+		PromptTokens: int32(resp.PromptTokens),
+	}, nil
+}
+
+// CountTokens implements the LLMService.CountTokens RPC. Backends without a
+// tokenizer API get an estimate from the prompt's length.
+func (s *WorkerServer) CountTokens(ctx context.Context, req *llmv1.CountTokensRequest) (*llmv1.CountTokensResponse, error) {
+	if req.Prompt == "" {
+		return nil, status.Error(codes.InvalidArgument, "prompt is required")
+	}
+
+	model := req.Model
+	if model == "" {
+		model = defaultModel
+	}
+	backendReq := &backend.GenerateRequest{
+		Model:  model,
+		Prompt: req.Prompt,
+		System: req.SystemPrompt,
+	}
+
+	resp := &llmv1.CountTokensResponse{
+		RequestId:     req.RequestId,
+		Model:         model,
+		ContextLength: s.modelDefaults.options(model).NumCtx,
+	}
+	tokenizer, ok := s.backend.(backend.Tokenizer)
+	if !ok {
+		resp.Tokens, resp.Estimated = int32(backend.EstimateTokens(backendReq)), true
+		return resp, nil
+	}
+
+	tokens, err := tokenizer.CountTokens(ctx, backendReq)
+	if errors.Is(err, backend.ErrUnsupported) {
+		resp.Tokens, resp.Estimated = int32(backend.EstimateTokens(backendReq)), true
+		return resp, nil
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("backend tokenization failed", "error", err)
+		return nil, backendStatus(err, "failed to count tokens")
+	}
+	resp.Tokens = int32(tokens)
+	return resp, nil
 }
 
 // HealthCheck implements the health check RPC
 func (s *WorkerServer) HealthCheck(ctx context.Context, req *llmv1.HealthCheckRequest) (*llmv1.HealthCheckResponse, error) {
 	activeReqs := s.activeRequests.Load()
-	load := float64(activeReqs) / 10.0
-	if load > 1.0 {
-		load = 1.0
+	load := s.limiter.load()
+
+	var models []string
+	if m := s.models.Load(); m != nil {
+		models = *m
+	}
+	var loaded []*llmv1.LoadedModel
+	if m := s.loadedModels.Load(); m != nil {
+		loaded = *m
+	}
+
+	now := time.Now().UnixMilli()
+	var skew int64
+	if req.Timestamp > 0 {
+		skew = now - req.Timestamp
 	}
 
 	return &llmv1.HealthCheckResponse{
-		Healthy:         s.ollamaHealthy.Load(),
+		Healthy:         s.backendHealthy.Load() && !s.healthChecker.Draining(),
 		Load:            float32(load),
 		ActiveRequests:  activeReqs,
 		Version:         version,
-		OllamaConnected: s.ollamaHealthy.Load(),
+		OllamaConnected: s.backendHealthy.Load(),
+		InstanceId:      s.instanceID,
+		Timestamp:       now,
+		ClockSkewMs:     skew,
+		Models:          models,
+		LoadedModels:    loaded,
+		Capacity:        int32(s.limiter.capacity()),
+		QueueDepth:      int32(s.limiter.waiting()),
+		MaxQueueDepth:   s.limiter.maxQueue,
+		EstimatedWaitMs: s.limiter.estimatedWait().Milliseconds(),
 	}, nil
 }
 
@@ -246,7 +729,9 @@ func startMetricsServer(addr string, health *health.Checker) *http.Server {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", metrics.Handler())
 	mux.HandleFunc("/health", health.HTTPHandler())
-	mux.HandleFunc("/ready", health.HTTPHandler())
+	mux.HandleFunc("/health/live", health.LiveHandler())
+	mux.HandleFunc("/health/ready", health.ReadyHandler())
+	mux.HandleFunc("/ready", health.ReadyHandler())
 
 	server := &http.Server{
 		Addr:    addr,
@@ -263,41 +748,218 @@ func startMetricsServer(addr string, health *health.Checker) *http.Server {
 }
 
 func main() {
+	// Prompt and response content is redacted in logs unless LOG_CONTENT
+	// allows more. An invalid mode keeps it redacted and is reported below.
+	contentMode, contentModeErr := redact.ParseMode(getEnv("LOG_CONTENT", string(redact.ModeRedact)))
+
 	// Initialize logger
 	log := logger.New(logger.Config{
-		Level:   getEnv("LOG_LEVEL", "info"),
-		Service: "worker",
-		JSON:    getEnv("LOG_FORMAT", "text") == "json",
+		Level:      getEnv("LOG_LEVEL", "info"),
+		Service:    "worker",
+		JSON:       getEnv("LOG_FORMAT", "text") == "json",
+		File:       getEnv("LOG_FILE", ""),
+		MaxSizeMB:  getEnvInt("LOG_FILE_MAX_SIZE_MB", 100),
+		MaxBackups: getEnvInt("LOG_FILE_MAX_BACKUPS", 5),
+		Compress:   getEnv("LOG_FILE_COMPRESS", "false") == "true",
+		Content: redact.Policy{
+			Mode:      contentMode,
+			MaxLength: getEnvInt("LOG_CONTENT_MAX_LENGTH", redact.DefaultMaxLength),
+			HashKey:   getEnv("LOG_CONTENT_HASH_KEY", ""),
+		},
 	})
+	if contentModeErr != nil {
+		log.Error("invalid LOG_CONTENT", "error", contentModeErr)
+		os.Exit(1)
+	}
+
+	// SIGUSR1 toggles debug logging without a restart
+	log.ToggleDebugOnSignal()
 
 	log.Info("starting neurogate worker",
 		"version", version,
 		"grpc_port", getEnv("GRPC_PORT", defaultGRPCPort),
 	)
 
+	// Spans are only exported when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Setup(tracing.Config{
+		ServiceName:    getEnv("OTEL_SERVICE_NAME", "neurogate-worker"),
+		ServiceVersion: version,
+		Endpoint:       getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracesEndpoint: getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", ""),
+		Headers:        tracing.ParseHeaders(getEnv("OTEL_EXPORTER_OTLP_HEADERS", "")),
+		SampleRatio:    getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+	})
+	if err != nil {
+		log.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
 	// Get configuration from environment
 	grpcPort := getEnv("GRPC_PORT", defaultGRPCPort)
 	metricsPort := getEnv("METRICS_PORT", defaultMetricsPort)
 	ollamaURL := getEnv("OLLAMA_URL", defaultOllamaURL)
 
+	// Load optional result signing key
+	var signer *signing.Signer
+	if key := getEnv("SIGNING_KEY", ""); key != "" {
+		var err error
+		signer, err = signing.NewSigner(key)
+		if err != nil {
+			log.Error("invalid signing key", "error", err)
+			os.Exit(1)
+		}
+		log.Info("result signing enabled", "public_key", signer.PublicKey())
+	}
+
+	// Models stay loaded for Ollama's default time unless OLLAMA_KEEP_ALIVE
+	// is set
+	var keepAlive *ollama.Duration
+	if value := getEnv("OLLAMA_KEEP_ALIVE", ""); value != "" {
+		d, err := ollama.ParseDuration(value)
+		if err != nil {
+			log.Error("invalid OLLAMA_KEEP_ALIVE", "error", err)
+			os.Exit(1)
+		}
+		keepAlive = &d
+		log.Info("model keep-alive set", "keep_alive", d.String())
+	}
+
+	inference, err := newBackend(log)
+	if err != nil {
+		log.Error("invalid backend", "error", err)
+		os.Exit(1)
+	}
+
+	ollamaOptions := ollama.ClientOptions{
+		Timeout:      getEnvDuration("OLLAMA_TIMEOUT", 5*time.Minute),
+		PingTimeout:  getEnvDuration("OLLAMA_PING_TIMEOUT", 5*time.Second),
+		AdminTimeout: getEnvDuration("OLLAMA_ADMIN_TIMEOUT", 30*time.Second),
+		MaxRetries:   getEnvInt("OLLAMA_MAX_RETRIES", 2),
+		RetryBackoff: getEnvDuration("OLLAMA_RETRY_BACKOFF", 250*time.Millisecond),
+	}
+
+	// Several comma-separated Ollama URLs, e.g. one instance per GPU, are
+	// served as a pool
+	var modelManager ollama.ModelManager
+	if ollamaURLs := strings.Split(ollamaURL, ","); inference == nil && len(ollamaURLs) > 1 {
+		for i := range ollamaURLs {
+			ollamaURLs[i] = strings.TrimSpace(ollamaURLs[i])
+		}
+		poolOptions := ollamaOptions
+		poolOptions.OnRetry = func(attempt int, err error) {
+			log.Warn("retrying ollama request", "attempt", attempt, "error", err)
+		}
+		dispatch := getEnv("OLLAMA_DISPATCH", backend.DispatchLeastBusy)
+		pool, manager, err := newOllamaPool(ollamaURLs, dispatch, poolOptions, keepAlive)
+		if err != nil {
+			log.Error("invalid ollama instances", "error", err)
+			os.Exit(1)
+		}
+		inference, modelManager = pool, manager
+		log.Info("using ollama instances", "urls", ollamaURLs, "dispatch", dispatch)
+	}
+
+	modelDefaults, err := loadModelDefaults(
+		getEnv("MODEL_DEFAULTS_FILE", ""),
+		parseKeyValues(getEnv("MODEL_NUM_CTX", "")),
+		parseKeyValues(getEnv("MODEL_TEMPERATURE", "")),
+		parseKeyValues(getEnv("MODEL_KEEP_ALIVE", "")),
+		parseKeyValues(getEnv("MODEL_STOP", "")),
+	)
+	if err != nil {
+		log.Error("invalid model defaults", "error", err)
+		os.Exit(1)
+	}
+
 	// Create worker server
-	server := NewWorkerServer(log, ollamaURL)
+	instanceID := getEnv("INSTANCE_ID", "")
+	server := NewWorkerServer(log, Config{
+		OllamaURL:    ollamaURL,
+		Backend:      inference,
+		InstanceID:   instanceID,
+		Signer:       signer,
+		KeepAlive:    keepAlive,
+		Ollama:       ollamaOptions,
+		ModelManager: modelManager,
+		Watchdog: WatchdogConfig{
+			Command:  getEnv("OLLAMA_WATCHDOG_COMMAND", ""),
+			PIDFile:  getEnv("OLLAMA_WATCHDOG_PID_FILE", ""),
+			Signal:   parseSignal(getEnv("OLLAMA_WATCHDOG_SIGNAL", "TERM")),
+			Failures: getEnvInt("OLLAMA_WATCHDOG_FAILURES", 3),
+			Cooldown: getEnvDuration("OLLAMA_WATCHDOG_COOLDOWN", 2*time.Minute),
+		},
+		Memory: MemoryGuardConfig{
+			SystemThreshold: getEnvFloat("MEMORY_THRESHOLD", 0),
+			GPUThreshold:    getEnvFloat("GPU_MEMORY_THRESHOLD", 0),
+			Interval:        getEnvDuration("MEMORY_CHECK_INTERVAL", 5*time.Second),
+		},
+		ModelDefaults:           modelDefaults,
+		MaxConcurrentInferences: getEnvInt("MAX_CONCURRENT_INFERENCES", 10),
+		InferenceQueueSize:      getEnvInt("INFERENCE_QUEUE_SIZE", 100),
+		InferenceQueueTimeout:   getEnvDuration("INFERENCE_QUEUE_TIMEOUT", 30*time.Second),
+		HealthFailureThreshold:  getEnvInt("HEALTH_FAILURE_THRESHOLD", 1),
+		HealthSuccessThreshold:  getEnvInt("HEALTH_SUCCESS_THRESHOLD", 1),
+	})
 
-	// Start background health checker for Ollama
+	// Start background health checker for the backend
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	server.StartHealthChecker(ctx)
+	if server.memoryGuard != nil {
+		server.memoryGuard.start(ctx)
+	}
+
+	// Serve health probes from a periodically refreshed snapshot instead of
+	// pinging the backend on every request
+	if interval := getEnvDuration("HEALTH_CHECK_INTERVAL", 0); interval > 0 {
+		server.healthChecker.Start(ctx, interval)
+		log.Info("background health checks enabled", "interval", interval)
+	}
 
 	// Start metrics/health server
 	metricsAddr := fmt.Sprintf(":%s", metricsPort)
 	metricsServer := startMetricsServer(metricsAddr, server.healthChecker)
 	log.Info("metrics server started", "addr", metricsAddr)
 
+	// Workers Prometheus can't scrape can push their metrics instead
+	pusher := metrics.StartPusherFromEnv(log.Logger, "neurogate-worker", instanceID)
+
+	grpcConfig := GRPCServerConfig{
+		MaxRecvMsgSize:        getEnvInt("GRPC_MAX_RECV_MSG_SIZE", 16<<20),
+		MaxSendMsgSize:        getEnvInt("GRPC_MAX_SEND_MSG_SIZE", 16<<20),
+		MaxConcurrentStreams:  uint32(max(getEnvInt("GRPC_MAX_CONCURRENT_STREAMS", 0), 0)),
+		ConnectionTimeout:     getEnvDuration("GRPC_CONNECTION_TIMEOUT", 120*time.Second),
+		KeepaliveTime:         getEnvDuration("GRPC_KEEPALIVE_TIME", 2*time.Hour),
+		KeepaliveTimeout:      getEnvDuration("GRPC_KEEPALIVE_TIMEOUT", 20*time.Second),
+		KeepaliveMinTime:      getEnvDuration("GRPC_KEEPALIVE_MIN_TIME", 10*time.Second),
+		PermitWithoutStream:   getEnv("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM", "true") == "true",
+		MaxConnectionIdle:     getEnvDuration("GRPC_MAX_CONNECTION_IDLE", 0),
+		MaxConnectionAge:      getEnvDuration("GRPC_MAX_CONNECTION_AGE", 0),
+		MaxConnectionAgeGrace: getEnvDuration("GRPC_MAX_CONNECTION_AGE_GRACE", 0),
+	}
+
 	// Create gRPC server
-	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(unaryLoggingInterceptor(log)),
-	)
+	// The gateway probes HealthCheck every few seconds, so it isn't traced
+	grpcServer := grpc.NewServer(append(grpcConfig.options(),
+		grpc.ChainUnaryInterceptor(
+			tracing.UnaryServerInterceptor(llmv1.LLMService_HealthCheck_FullMethodName),
+			unaryLoggingInterceptor(log),
+			unaryUsageInterceptor(server.metrics),
+		),
+		grpc.ChainStreamInterceptor(
+			tracing.StreamServerInterceptor(),
+			streamLoggingInterceptor(log),
+			streamUsageInterceptor(server.metrics),
+		),
+	)...)
 	llmv1.RegisterLLMServiceServer(grpcServer, server)
+	if server.modelManager != nil {
+		llmv1.RegisterModelAdminServiceServer(grpcServer, &ModelAdminServer{worker: server})
+	}
+
+	// Standard gRPC health service for Kubernetes gRPC probes and grpc_health_probe
+	grpcHealth := newGRPCHealthServer(server.healthChecker)
+	healthpb.RegisterHealthServer(grpcServer, grpcHealth)
 	reflection.Register(grpcServer) // Enable reflection for debugging
 
 	// Start gRPC server
@@ -311,15 +973,29 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	// Closed once metrics and traces are flushed, so main doesn't exit first
+	done := make(chan struct{})
 	go func() {
 		<-sigChan
 		log.Info("shutting down worker...")
 
+		// Fail readiness and gateway health checks so no new work arrives
+		server.healthChecker.SetDraining(true)
+		grpcHealth.shutdown()
 		grpcServer.GracefulStop()
 
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		metricsServer.Shutdown(ctx)
+		if pusher != nil {
+			if err := pusher.Stop(ctx); err != nil {
+				log.Warn("failed to push final metrics", "error", err)
+			}
+		}
+		if err := shutdownTracing(ctx); err != nil {
+			log.Warn("failed to flush traces", "error", err)
+		}
+		close(done)
 	}()
 
 	log.Info("gRPC server listening", "addr", listener.Addr())
@@ -327,9 +1003,11 @@ func main() {
 		log.Error("gRPC server error", "error", err)
 		os.Exit(1)
 	}
+	<-done
 }
 
-// unaryLoggingInterceptor logs gRPC requests
+// unaryLoggingInterceptor logs gRPC requests and gives handlers a logger,
+// through the context, tagged with the request's ID
 func unaryLoggingInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
@@ -337,23 +1015,198 @@ func unaryLoggingInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
+		ctx = requestContext(ctx, log, req)
+
 		start := time.Now()
 		resp, err := handler(ctx, req)
-		duration := time.Since(start)
+		logRPC(logger.FromContext(ctx), "grpc request", info.FullMethod, time.Since(start), err)
 
-		log.Info("grpc request",
-			"method", info.FullMethod,
-			"duration_ms", duration.Milliseconds(),
-			"error", err,
-		)
+		return resp, err
+	}
+}
+
+// streamLoggingInterceptor is the streaming counterpart of
+// unaryLoggingInterceptor. The request ID is taken from the first message
+// the handler receives.
+func streamLoggingInterceptor(log *logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		stream := &loggingStream{ServerStream: ss, ctx: requestContext(ss.Context(), log, nil), log: log}
+
+		start := time.Now()
+		err := handler(srv, stream)
+		logRPC(logger.FromContext(stream.ctx), "grpc stream", info.FullMethod, time.Since(start), err)
+
+		return err
+	}
+}
 
+// logRPC logs a finished call. Probes call the health service every few
+// seconds, so those are only logged at debug level.
+func logRPC(log *logger.Logger, msg, method string, duration time.Duration, err error) {
+	if strings.HasPrefix(method, "/grpc.health.v1.Health/") {
+		log.Debug(msg, "method", method, "duration_ms", duration.Milliseconds(), "error", err)
+		return
+	}
+
+	log.Info(msg,
+		"method", method,
+		"duration_ms", duration.Milliseconds(),
+		"error", err,
+	)
+}
+
+// unaryUsageInterceptor counts calls made for gateway requests by the
+// consumer the gateway sent and status code
+func unaryUsageInterceptor(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		recordUsage(ctx, m, err)
 		return resp, err
 	}
 }
 
+// streamUsageInterceptor is the streaming counterpart of
+// unaryUsageInterceptor
+func streamUsageInterceptor(m *metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		recordUsage(ss.Context(), m, err)
+		return err
+	}
+}
+
+// recordUsage counts a finished call. Calls without a request ID, such as
+// the gateway's health checks, aren't made for a request and aren't counted.
+func recordUsage(ctx context.Context, m *metrics.Metrics, err error) {
+	meta := reqmeta.FromIncomingContext(ctx)
+	if meta.RequestID == "" {
+		return
+	}
+	m.RecordConsumerRequest(meta.Consumer(), status.Code(err).String())
+}
+
+// requestContext returns ctx carrying a logger tagged with the request ID of
+// msg, or else the one the gateway sent in the call's metadata, and the
+// caller the gateway sent
+func requestContext(ctx context.Context, log *logger.Logger, msg interface{}) context.Context {
+	meta := reqmeta.FromIncomingContext(ctx)
+	requestID := meta.RequestID
+	if req, ok := msg.(interface{ GetRequestId() string }); ok && req.GetRequestId() != "" {
+		requestID = req.GetRequestId()
+	}
+	if requestID != "" {
+		log = log.WithRequestID(requestID)
+	}
+	if meta.Tenant != "" || meta.KeyID != "" {
+		log = log.WithCaller(meta.Tenant, meta.KeyID)
+	}
+	return logger.ToContext(ctx, log)
+}
+
+// loggingStream tags the stream's context with the request ID once the
+// request message is received
+type loggingStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	log *logger.Logger
+}
+
+func (s *loggingStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *loggingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.ctx = requestContext(s.ctx, s.log, m)
+	return nil
+}
+
+// newBackend creates the backend selected by BACKEND, or returns nil for
+// Ollama, which NewWorkerServer sets up from the OLLAMA_* settings
+func newBackend(log *logger.Logger) (backend.InferenceBackend, error) {
+	switch kind := getEnv("BACKEND", "ollama"); kind {
+	case "ollama":
+		return nil, nil
+	case "openai":
+		url := getEnv("BACKEND_URL", "")
+		if url == "" {
+			return nil, fmt.Errorf("BACKEND_URL is required for the openai backend")
+		}
+		log.Info("using OpenAI-compatible backend", "url", url)
+		return backend.NewOpenAI(backend.OpenAIConfig{
+			URL:         url,
+			APIKey:      getEnv("BACKEND_API_KEY", ""),
+			Timeout:     getEnvDuration("BACKEND_TIMEOUT", 5*time.Minute),
+			PingTimeout: getEnvDuration("BACKEND_PING_TIMEOUT", 5*time.Second),
+		}), nil
+	case "llamacpp":
+		url := getEnv("BACKEND_URL", "")
+		if url == "" {
+			return nil, fmt.Errorf("BACKEND_URL is required for the llamacpp backend")
+		}
+		log.Info("using llama.cpp backend", "url", url)
+		return backend.NewLlamaCpp(backend.LlamaCppConfig{
+			URL:         url,
+			APIKey:      getEnv("BACKEND_API_KEY", ""),
+			Timeout:     getEnvDuration("BACKEND_TIMEOUT", 5*time.Minute),
+			PingTimeout: getEnvDuration("BACKEND_PING_TIMEOUT", 5*time.Second),
+			Model:       getEnv("BACKEND_MODEL", ""),
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", kind)
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// parseKeyValues parses "k1=v1,k2=v2" into a map. Only the first "=" in each
+// pair separates key from value, so stop tokens may contain it.
+func parseKeyValues(s string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && k != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/logger"
+	"github.com/hugovillarreal/neurogate/pkg/memory"
+	"github.com/hugovillarreal/neurogate/pkg/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MemoryGuardConfig configures refusing inferences under memory pressure.
+// The guard is disabled unless a threshold is set.
+type MemoryGuardConfig struct {
+	SystemThreshold float64       // Share of system memory in use (0-1) above which inferences are refused
+	GPUThreshold    float64       // Share of memory in use on the most used GPU, read with nvidia-smi
+	Interval        time.Duration // Time between memory readings. Default: 5 seconds
+}
+
+// enabled reports whether a threshold is configured
+func (c MemoryGuardConfig) enabled() bool {
+	return c.SystemThreshold > 0 || c.GPUThreshold > 0
+}
+
+// memoryGuard reads memory use in the background and refuses new
+// inferences while it is over a threshold, so the backend isn't pushed out
+// of memory mid-generation. Inferences already running are unaffected.
+type memoryGuard struct {
+	cfg     MemoryGuardConfig
+	log     *logger.Logger
+	metrics *metrics.Metrics
+
+	// Why inferences are refused, nil while memory is within the thresholds
+	pressure atomic.Pointer[string]
+}
+
+func newMemoryGuard(cfg MemoryGuardConfig, log *logger.Logger, m *metrics.Metrics) *memoryGuard {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	return &memoryGuard{cfg: cfg, log: log, metrics: m}
+}
+
+// start reads memory use now and then every interval until ctx is done
+func (g *memoryGuard) start(ctx context.Context) {
+	g.check(ctx)
+	go func() {
+		ticker := time.NewTicker(g.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				g.check(ctx)
+			}
+		}
+	}()
+}
+
+// check reads memory use and updates the pressure state. A failed reading
+// keeps the previous state.
+func (g *memoryGuard) check(ctx context.Context) {
+	var reasons []string
+	if g.cfg.SystemThreshold > 0 {
+		usage, err := memory.System()
+		if err != nil {
+			g.log.Debug("failed to read system memory", "error", err)
+			return
+		}
+		g.metrics.SetMemoryUsage("system", usage.Ratio())
+		if usage.Ratio() > g.cfg.SystemThreshold {
+			reasons = append(reasons, fmt.Sprintf("system memory %.0f%% used", usage.Ratio()*100))
+		}
+	}
+	if g.cfg.GPUThreshold > 0 {
+		readCtx, cancel := context.WithTimeout(ctx, g.cfg.Interval)
+		usage, err := memory.GPU(readCtx)
+		cancel()
+		if err != nil {
+			g.log.Debug("failed to read GPU memory", "error", err)
+			return
+		}
+		g.metrics.SetMemoryUsage("gpu", usage.Ratio())
+		if usage.Ratio() > g.cfg.GPUThreshold {
+			reasons = append(reasons, fmt.Sprintf("GPU memory %.0f%% used", usage.Ratio()*100))
+		}
+	}
+
+	if len(reasons) == 0 {
+		if g.pressure.Swap(nil) != nil {
+			g.log.Info("memory pressure relieved, admitting inferences")
+		}
+		return
+	}
+	reason := reasons[0]
+	if len(reasons) > 1 {
+		reason += ", " + reasons[1]
+	}
+	if g.pressure.Swap(&reason) == nil {
+		g.log.Warn("memory pressure, refusing new inferences", "reason", reason)
+	}
+}
+
+// admit returns a ResourceExhausted error while memory is over a threshold
+func (g *memoryGuard) admit() error {
+	if reason := g.pressure.Load(); reason != nil {
+		g.metrics.RecordInferenceRejection("memory_pressure")
+		return status.Errorf(codes.ResourceExhausted, "worker under memory pressure: %s", *reason)
+	}
+	return nil
+}
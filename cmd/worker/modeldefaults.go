@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+	"github.com/hugovillarreal/neurogate/pkg/ollama"
+)
+
+// defaultModelKey holds the defaults of models without their own
+const defaultModelKey = "default"
+
+// modelOptions are generation defaults for a model. Unset options are left
+// to the request or the backend.
+type modelOptions struct {
+	NumCtx      int32            `json:"num_ctx,omitempty"`
+	Temperature float32          `json:"temperature,omitempty"`
+	KeepAlive   *ollama.Duration `json:"keep_alive,omitempty"`
+	Stop        []string         `json:"stop,omitempty"`
+}
+
+// modelDefaults maps model names to their generation defaults. Options a
+// model's entry doesn't set come from the "default" entry.
+type modelDefaults map[string]modelOptions
+
+// loadModelDefaults reads defaults from a JSON file of entries by model, if
+// path is set, then applies the per-option env maps over them. Stop tokens
+// in env maps are separated by "|".
+func loadModelDefaults(path string, numCtx, temperature, keepAlive, stop map[string]string) (modelDefaults, error) {
+	defaults := make(modelDefaults)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var entries modelDefaults
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for model, opts := range entries {
+			defaults[modelKey(model)] = opts
+		}
+	}
+
+	for model, value := range numCtx {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("num_ctx of %s: must be a positive integer", model)
+		}
+		opts := defaults[modelKey(model)]
+		opts.NumCtx = int32(n)
+		defaults[modelKey(model)] = opts
+	}
+	for model, value := range temperature {
+		t, err := strconv.ParseFloat(value, 32)
+		if err != nil || t < 0 {
+			return nil, fmt.Errorf("temperature of %s: must be a non-negative number", model)
+		}
+		opts := defaults[modelKey(model)]
+		opts.Temperature = float32(t)
+		defaults[modelKey(model)] = opts
+	}
+	for model, value := range keepAlive {
+		d, err := ollama.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("keep_alive of %s: %w", model, err)
+		}
+		opts := defaults[modelKey(model)]
+		opts.KeepAlive = &d
+		defaults[modelKey(model)] = opts
+	}
+	for model, value := range stop {
+		opts := defaults[modelKey(model)]
+		opts.Stop = strings.Split(value, "|")
+		defaults[modelKey(model)] = opts
+	}
+	return defaults, nil
+}
+
+// modelKey drops the ":latest" tag, which Ollama applies to names without
+// one, so either form of a name finds the same entry
+func modelKey(model string) string {
+	return strings.TrimSuffix(model, ":latest")
+}
+
+// options returns the defaults of model
+func (d modelDefaults) options(model string) modelOptions {
+	opts := d[modelKey(model)]
+	fallback := d[defaultModelKey]
+	if opts.NumCtx == 0 {
+		opts.NumCtx = fallback.NumCtx
+	}
+	if opts.Temperature == 0 {
+		opts.Temperature = fallback.Temperature
+	}
+	if opts.KeepAlive == nil {
+		opts.KeepAlive = fallback.KeepAlive
+	}
+	if opts.Stop == nil {
+		opts.Stop = fallback.Stop
+	}
+	return opts
+}
+
+// apply fills the options req leaves unset with the defaults of model, and
+// returns the keep-alive to request, nil for the backend's
+func (d modelDefaults) apply(model string, req *llmv1.PromptRequest) *time.Duration {
+	opts := d.options(model)
+	if req.NumCtx == 0 {
+		req.NumCtx = opts.NumCtx
+	}
+	if req.Temperature == 0 {
+		req.Temperature = opts.Temperature
+	}
+	if len(req.Stop) == 0 {
+		req.Stop = opts.Stop
+	}
+	return opts.keepAlive()
+}
+
+// keepAlive returns the keep-alive as a duration, nil if unset
+func (o modelOptions) keepAlive() *time.Duration {
+	if o.KeepAlive == nil {
+		return nil
+	}
+	d := time.Duration(*o.KeepAlive)
+	return &d
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+	"github.com/hugovillarreal/neurogate/pkg/backend"
+	"github.com/hugovillarreal/neurogate/pkg/logger"
+	"github.com/hugovillarreal/neurogate/pkg/ollama"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ModelAdminServer implements the ModelAdminService gRPC interface on top of
+// the worker's Ollama client. It is only served with the Ollama backend.
+type ModelAdminServer struct {
+	llmv1.UnimplementedModelAdminServiceServer
+
+	worker *WorkerServer
+}
+
+// PullModel implements the ModelAdminService.PullModel RPC
+func (s *ModelAdminServer) PullModel(req *llmv1.PullModelRequest, stream grpc.ServerStreamingServer[llmv1.PullModelProgress]) error {
+	if req.Model == "" {
+		return status.Error(codes.InvalidArgument, "model is required")
+	}
+
+	log := logger.FromContext(stream.Context())
+	log.Warn("pulling model", "model", req.Model)
+
+	err := s.worker.modelManager.Pull(stream.Context(), &ollama.PullRequest{
+		Model:    req.Model,
+		Insecure: req.Insecure,
+	}, func(p *ollama.ProgressResponse) error {
+		return stream.Send(&llmv1.PullModelProgress{
+			Status:    p.Status,
+			Digest:    p.Digest,
+			Total:     p.Total,
+			Completed: p.Completed,
+		})
+	})
+	if err != nil {
+		log.Error("model pull failed", "model", req.Model, "error", err)
+		return backendStatus(backend.OllamaError(err), "failed to pull model")
+	}
+
+	log.Warn("model pulled", "model", req.Model)
+	s.refreshModels()
+	return nil
+}
+
+// DeleteModel implements the ModelAdminService.DeleteModel RPC
+func (s *ModelAdminServer) DeleteModel(ctx context.Context, req *llmv1.DeleteModelRequest) (*llmv1.DeleteModelResponse, error) {
+	if req.Model == "" {
+		return nil, status.Error(codes.InvalidArgument, "model is required")
+	}
+
+	if err := s.worker.modelManager.Delete(ctx, &ollama.DeleteRequest{Model: req.Model}); err != nil {
+		return nil, backendStatus(backend.OllamaError(err), "failed to delete model")
+	}
+
+	logger.FromContext(ctx).Warn("model deleted", "model", req.Model)
+	s.refreshModels()
+	return &llmv1.DeleteModelResponse{}, nil
+}
+
+// CopyModel implements the ModelAdminService.CopyModel RPC
+func (s *ModelAdminServer) CopyModel(ctx context.Context, req *llmv1.CopyModelRequest) (*llmv1.CopyModelResponse, error) {
+	if req.Source == "" || req.Destination == "" {
+		return nil, status.Error(codes.InvalidArgument, "source and destination are required")
+	}
+
+	err := s.worker.modelManager.Copy(ctx, &ollama.CopyRequest{
+		Source:      req.Source,
+		Destination: req.Destination,
+	})
+	if err != nil {
+		return nil, backendStatus(backend.OllamaError(err), "failed to copy model")
+	}
+
+	logger.FromContext(ctx).Warn("model copied", "source", req.Source, "destination", req.Destination)
+	s.refreshModels()
+	return &llmv1.CopyModelResponse{}, nil
+}
+
+// ShowModel implements the ModelAdminService.ShowModel RPC
+func (s *ModelAdminServer) ShowModel(ctx context.Context, req *llmv1.ShowModelRequest) (*llmv1.ShowModelResponse, error) {
+	if req.Model == "" {
+		return nil, status.Error(codes.InvalidArgument, "model is required")
+	}
+
+	resp, err := s.worker.modelManager.Show(ctx, &ollama.ShowRequest{Model: req.Model})
+	if err != nil {
+		return nil, backendStatus(backend.OllamaError(err), "failed to show model")
+	}
+
+	var modifiedAt int64
+	if !resp.ModifiedAt.IsZero() {
+		modifiedAt = resp.ModifiedAt.UnixMilli()
+	}
+	return &llmv1.ShowModelResponse{
+		Model:             req.Model,
+		Modelfile:         resp.Modelfile,
+		Parameters:        resp.Parameters,
+		Template:          resp.Template,
+		Family:            resp.Details.Family,
+		ParameterSize:     resp.Details.ParameterSize,
+		QuantizationLevel: resp.Details.QuantizationLevel,
+		Format:            resp.Details.Format,
+		Capabilities:      resp.Capabilities,
+		ModifiedAt:        modifiedAt,
+	}, nil
+}
+
+// refreshModels updates the model list reported in health checks right away
+// rather than at the next backend health check
+func (s *ModelAdminServer) refreshModels() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.worker.refreshModels(ctx)
+}
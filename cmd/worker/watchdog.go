@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/logger"
+	"github.com/hugovillarreal/neurogate/pkg/metrics"
+)
+
+// WatchdogConfig configures recovery of a co-located Ollama process. The
+// watchdog is disabled unless Command or PIDFile is set.
+type WatchdogConfig struct {
+	Command  string         // Shell command that restarts Ollama (e.g. "systemctl restart ollama")
+	PIDFile  string         // File holding Ollama's PID, signalled when Command is empty
+	Signal   syscall.Signal // Signal sent to the PID. Default: SIGTERM
+	Failures int            // Consecutive failed health checks before acting. Default: 3
+	Cooldown time.Duration  // Minimum time between recovery actions. Default: 2 minutes
+}
+
+// enabled reports whether a recovery action is configured
+func (c WatchdogConfig) enabled() bool {
+	return c.Command != "" || c.PIDFile != ""
+}
+
+// watchdog restarts or signals Ollama after repeated failed health checks
+type watchdog struct {
+	cfg     WatchdogConfig
+	log     *logger.Logger
+	metrics *metrics.Metrics
+
+	mu         sync.Mutex
+	failures   int
+	lastAction time.Time
+	recovering bool // an action was taken and Ollama hasn't come back yet
+}
+
+func newWatchdog(cfg WatchdogConfig, log *logger.Logger, m *metrics.Metrics) *watchdog {
+	if cfg.Signal == 0 {
+		cfg.Signal = syscall.SIGTERM
+	}
+	if cfg.Failures <= 0 {
+		cfg.Failures = 3
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 2 * time.Minute
+	}
+	return &watchdog{cfg: cfg, log: log, metrics: m}
+}
+
+// observe records a health check result and triggers recovery once the
+// consecutive failure threshold is reached, at most once per cooldown
+func (w *watchdog) observe(healthy bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if healthy {
+		if w.recovering {
+			w.log.Info("ollama recovered after watchdog action",
+				"downtime", time.Since(w.lastAction).Round(time.Second),
+			)
+			w.recovering = false
+		}
+		w.failures = 0
+		return
+	}
+
+	w.failures++
+	if w.failures < w.cfg.Failures {
+		return
+	}
+	if !w.lastAction.IsZero() && time.Since(w.lastAction) < w.cfg.Cooldown {
+		return
+	}
+
+	action, err := w.act()
+	w.metrics.RecordOllamaRecovery(action, err == nil)
+	w.lastAction = time.Now()
+	w.failures = 0
+
+	if err != nil {
+		w.log.Error("ollama watchdog action failed", "action", action, "error", err)
+		return
+	}
+	w.recovering = true
+	w.log.Warn("ollama watchdog action taken",
+		"action", action,
+		"consecutive_failures", w.cfg.Failures,
+	)
+}
+
+// act runs the configured recovery action and returns its name
+func (w *watchdog) act() (string, error) {
+	if w.cfg.Command != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		out, err := exec.CommandContext(ctx, "sh", "-c", w.cfg.Command).CombinedOutput()
+		if err != nil {
+			return "command", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return "command", nil
+	}
+
+	data, err := os.ReadFile(w.cfg.PIDFile)
+	if err != nil {
+		return "signal", fmt.Errorf("failed to read pid file: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return "signal", fmt.Errorf("invalid pid in %s: %w", w.cfg.PIDFile, err)
+	}
+
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return "signal", err
+	}
+	if err := proc.Signal(w.cfg.Signal); err != nil {
+		return "signal", fmt.Errorf("failed to signal pid %d: %w", pid, err)
+	}
+	return "signal", nil
+}
+
+// parseSignal converts a signal name such as "TERM" or "SIGHUP" to a signal,
+// returning 0 for unknown names
+func parseSignal(name string) syscall.Signal {
+	switch strings.TrimPrefix(strings.ToUpper(name), "SIG") {
+	case "TERM":
+		return syscall.SIGTERM
+	case "KILL":
+		return syscall.SIGKILL
+	case "HUP":
+		return syscall.SIGHUP
+	case "INT":
+		return syscall.SIGINT
+	}
+	return 0
+}
@@ -135,7 +135,7 @@ resource "kubernetes_deployment" "worker" {
 
           liveness_probe {
             http_get {
-              path = "/health"
+              path = "/health/live"
               port = 9090
             }
             initial_delay_seconds = 10
@@ -146,7 +146,7 @@ resource "kubernetes_deployment" "worker" {
 
           readiness_probe {
             http_get {
-              path = "/ready"
+              path = "/health/ready"
               port = 9090
             }
             initial_delay_seconds = 5
@@ -285,7 +285,7 @@ resource "kubernetes_deployment" "gateway" {
 
           liveness_probe {
             http_get {
-              path = "/health"
+              path = "/health/live"
               port = 8080
             }
             initial_delay_seconds = 10
@@ -296,7 +296,7 @@ resource "kubernetes_deployment" "gateway" {
 
           readiness_probe {
             http_get {
-              path = "/health"
+              path = "/health/ready"
               port = 8080
             }
             initial_delay_seconds = 5
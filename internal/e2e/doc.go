@@ -0,0 +1,8 @@
+// Package e2e holds end-to-end tests of the gateway. The tests build the
+// gateway binary, start it against fake workers serving the worker gRPC API
+// in the test process, and exercise routing, failover, circuit breaking,
+// worker state shared between gateways, scheduling, fallback while every
+// breaker is open, streaming, authentication, keys stored by hash, request
+// history in a SQLite store and webhook destinations and delivery through
+// the public HTTP API. They are skipped with -short.
+package e2e
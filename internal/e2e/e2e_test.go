@@ -0,0 +1,554 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	neurogate "github.com/hugovillarreal/neurogate/pkg/client"
+	"github.com/hugovillarreal/neurogate/pkg/jwt"
+	"github.com/hugovillarreal/neurogate/pkg/redis/redistest"
+	"github.com/hugovillarreal/neurogate/pkg/requestid"
+	"github.com/hugovillarreal/neurogate/pkg/store"
+)
+
+func TestRoundRobin(t *testing.T) {
+	workers := []*fakeWorker{startWorker(t, "worker-a"), startWorker(t, "worker-b"), startWorker(t, "worker-c")}
+	gw := startGateway(t, nil, workers...)
+	c := gw.client(t, "")
+
+	served := make(map[string]int)
+	for range 9 {
+		resp, err := prompt(t, c, context.Background())
+		if err != nil {
+			t.Fatalf("prompt failed: %v", err)
+		}
+		if resp.Response != "Hello from "+resp.WorkerID {
+			t.Errorf("unexpected response from %s: %q", resp.WorkerID, resp.Response)
+		}
+		served[resp.WorkerID]++
+	}
+	for _, w := range workers {
+		if served[w.id] != 3 || w.generations.Load() != 3 {
+			t.Errorf("expected 3 requests on %s, got %d (%d generations)", w.id, served[w.id], w.generations.Load())
+		}
+	}
+}
+
+func TestFailover(t *testing.T) {
+	failing, healthy := startWorker(t, "worker-a"), startWorker(t, "worker-b")
+	failing.fail(codes.Unavailable)
+	gw := startGateway(t, nil, failing, healthy)
+	c := gw.client(t, "")
+
+	retried := 0
+	for range 4 {
+		resp, err := prompt(t, c, context.Background())
+		if err != nil {
+			t.Fatalf("expected the request to fail over, got %v", err)
+		}
+		if resp.WorkerID != healthy.id {
+			t.Errorf("expected %s to serve the request, got %s", healthy.id, resp.WorkerID)
+		}
+		retried += resp.Usage.Retries
+	}
+	if retried == 0 || int32(retried) != failing.generations.Load() {
+		t.Errorf("expected a retry for each of the %d failed generations, got %d", failing.generations.Load(), retried)
+	}
+}
+
+func TestUnhealthyWorker(t *testing.T) {
+	down, up := startWorker(t, "worker-a"), startWorker(t, "worker-b")
+	gw := startGateway(t, nil, down, up)
+
+	down.unhealthy.Store(true)
+	eventually(t, 5*time.Second, "the worker to be marked unhealthy", func() bool {
+		return !gw.workers(t)[down.id].Healthy
+	})
+
+	c := gw.client(t, "")
+	for range 4 {
+		if resp, err := prompt(t, c, context.Background()); err != nil || resp.WorkerID != up.id {
+			t.Fatalf("expected %s to serve the request, got %+v, %v", up.id, resp, err)
+		}
+	}
+	if n := down.generations.Load(); n != 0 {
+		t.Errorf("expected no requests on the unhealthy worker, got %d", n)
+	}
+
+	// Recovered workers rejoin the rotation
+	down.unhealthy.Store(false)
+	eventually(t, 5*time.Second, "the worker to be marked healthy", func() bool {
+		return gw.workers(t)[down.id].Healthy
+	})
+	for range 2 {
+		prompt(t, c, context.Background())
+	}
+	if down.generations.Load() == 0 {
+		t.Error("expected the recovered worker to serve requests")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	broken, healthy := startWorker(t, "worker-a"), startWorker(t, "worker-b")
+	broken.fail(codes.Internal)
+	gw := startGateway(t, map[string]string{"CIRCUIT_BREAKER_TIMEOUT": "1m", "ADMIN_API_KEYS": testAdminKey}, broken, healthy)
+	c := gw.client(t, "")
+
+	// Internal errors aren't retried elsewhere, so clients see them until
+	// the breaker opens after 3 consecutive failures
+	var failures int
+	for i := 0; i < 20 && gw.workers(t)[broken.id].CBState != "open"; i++ {
+		_, err := prompt(t, c, context.Background())
+		var apiErr *neurogate.APIError
+		if errors.As(err, &apiErr) {
+			if apiErr.StatusCode != http.StatusInternalServerError || apiErr.Code != neurogate.CodeInternal {
+				t.Fatalf("expected a 500 INTERNAL error, got %+v", apiErr)
+			}
+			failures++
+		}
+	}
+	if state := gw.workers(t)[broken.id].CBState; state != "open" || failures != 3 {
+		t.Fatalf("expected the breaker to open after 3 failures, got %s after %d", state, failures)
+	}
+
+	before := broken.generations.Load()
+	for range 4 {
+		if resp, err := prompt(t, c, context.Background()); err != nil || resp.WorkerID != healthy.id {
+			t.Fatalf("expected %s to serve the request, got %+v, %v", healthy.id, resp, err)
+		}
+	}
+	if broken.generations.Load() != before {
+		t.Error("expected no requests through the open breaker")
+	}
+
+	// Operators can close it once the worker is fixed
+	broken.fail(codes.OK)
+	status, body := gw.request(t, http.MethodPost, "/admin/workers/"+broken.id+"/circuit-breaker", testAdminKey, map[string]string{"action": "reset"})
+	if status != http.StatusOK || body["state"] != "closed" {
+		t.Fatalf("expected the reset to close the breaker, got %d %v", status, body)
+	}
+	for range 2 {
+		prompt(t, c, context.Background())
+	}
+	if broken.generations.Load() == before {
+		t.Error("expected the reset worker to serve requests")
+	}
+}
+
+func TestSharedWorkerState(t *testing.T) {
+	down, broken := startWorker(t, "worker-a"), startWorker(t, "worker-b")
+	srv := redistest.NewServer(t)
+	shared := map[string]string{
+		"WORKER_STATE_STORE":         "redis",
+		"WORKER_STATE_SYNC_INTERVAL": "50ms",
+		"REDIS_URL":                  "redis://" + srv.Addr() + "/0",
+		"CIRCUIT_BREAKER_TIMEOUT":    "1m",
+	}
+	probing := startGateway(t, shared, down, broken)
+	// The other gateway doesn't probe its workers within the test, so it
+	// only learns of failures from the first
+	shared["WORKER_HEALTH_INTERVAL"] = "1h"
+	other := startGateway(t, shared, down, broken)
+
+	down.unhealthy.Store(true)
+	eventually(t, 5*time.Second, "the other gateway to mark the worker unhealthy", func() bool {
+		return !other.workers(t)[down.id].Healthy
+	})
+
+	broken.fail(codes.Internal)
+	c := probing.client(t, "")
+	for i := 0; i < 20 && probing.workers(t)[broken.id].CBState != "open"; i++ {
+		prompt(t, c, context.Background())
+	}
+	eventually(t, 5*time.Second, "the other gateway to open the breaker", func() bool {
+		return other.workers(t)[broken.id].CBState == "open"
+	})
+	if n := broken.generations.Load(); n != 3 {
+		t.Errorf("expected only the first gateway's 3 requests on the broken worker, got %d", n)
+	}
+}
+
+func TestAutoscalingSignal(t *testing.T) {
+	w := startWorker(t, "worker-a")
+	w.slots.Store(1)
+	w.maxQueued.Store(10)
+	w.chunkDelay = 300 * time.Millisecond
+	gw := startGateway(t, nil, w)
+	eventually(t, 5*time.Second, "the worker to report its capacity", func() bool {
+		_, signal := gw.request(t, http.MethodGet, "/autoscaling", "", nil)
+		return signal["slots"] == 1.0
+	})
+
+	c := gw.client(t, "")
+	done := make(chan error, 3)
+	for range 3 {
+		go func() {
+			_, err := prompt(t, c, context.Background())
+			done <- err
+		}()
+	}
+	// The fake worker reports nothing running, so the gateway counts its
+	// own requests in flight: one on the slot and two queued behind it
+	eventually(t, 5*time.Second, "the demand to show", func() bool {
+		_, signal := gw.request(t, http.MethodGet, "/autoscaling", "", nil)
+		return signal["demand"] == 3.0 && signal["running"] == 1.0 && signal["queued"] == 2.0 && signal["utilization"] == 3.0
+	})
+	for range 3 {
+		if err := <-done; err != nil {
+			t.Fatalf("prompt failed: %v", err)
+		}
+	}
+
+	if _, signal := gw.request(t, http.MethodGet, "/autoscaling", "", nil); signal["demand"] != 0.0 {
+		t.Errorf("expected no demand once requests finish, got %v", signal)
+	}
+	if _, signal := gw.request(t, http.MethodGet, "/autoscaling?model=other", "", nil); signal["workers"] != 0.0 {
+		t.Errorf("expected no workers serving another model, got %v", signal)
+	}
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	const bulkKey = "e2e-bulk-key"
+	w := startWorker(t, "worker-a")
+	w.slots.Store(1)
+	w.chunkDelay = 150 * time.Millisecond
+	gw := startGateway(t, map[string]string{
+		"SCHEDULER_ENABLED": "true",
+		"API_KEYS":          testAPIKey + "," + bulkKey,
+		"ADMIN_API_KEYS":    testAdminKey,
+	}, w)
+
+	// The bulk key's requests are batch whatever they ask for
+	status, resp := gw.request(t, http.MethodPost, "/admin/keys/import", testAdminKey, map[string]any{
+		"version": 1,
+		"keys":    []map[string]any{{"key": bulkKey, "priority": "batch"}},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("import failed with %d: %v", status, resp)
+	}
+	eventually(t, 5*time.Second, "the worker to report its capacity", func() bool {
+		_, signal := gw.request(t, http.MethodGet, "/autoscaling", "", nil)
+		return signal["slots"] == 1.0
+	})
+
+	// One bulk request takes the worker's slot and the rest queue behind it
+	bulk := gw.client(t, bulkKey)
+	finished := make(chan string, 6)
+	for range 5 {
+		go func() {
+			_, err := prompt(t, bulk, context.Background())
+			if err != nil {
+				t.Errorf("bulk prompt failed: %v", err)
+			}
+			finished <- "bulk"
+		}()
+	}
+	eventually(t, 5*time.Second, "the bulk requests to queue", func() bool {
+		_, signal := gw.request(t, http.MethodGet, "/autoscaling", "", nil)
+		return signal["scheduled"] == 4.0
+	})
+
+	go func() {
+		_, err := prompt(t, gw.client(t, testAPIKey), context.Background())
+		if err != nil {
+			t.Errorf("chat prompt failed: %v", err)
+		}
+		finished <- "chat"
+	}()
+
+	var order []string
+	for range 6 {
+		order = append(order, <-finished)
+	}
+	// The chat request waits only for the bulk request already running
+	if order[1] != "chat" {
+		t.Errorf("expected the chat request to finish second, got %v", order)
+	}
+}
+
+func TestSchedulerBreakersOpen(t *testing.T) {
+	broken, emergency := startWorker(t, "worker-a"), startWorker(t, "emergency")
+	broken.slots.Store(1)
+	broken.fail(codes.Internal)
+	gw := startGateway(t, map[string]string{
+		"SCHEDULER_ENABLED":        "true",
+		"CIRCUIT_BREAKER_TIMEOUT":  "2s",
+		"FALLBACK_STRATEGIES":      "emergency",
+		"EMERGENCY_WORKER_ADDRESS": emergency.addr,
+	}, broken)
+	eventually(t, 5*time.Second, "the worker to report its capacity", func() bool {
+		_, signal := gw.request(t, http.MethodGet, "/autoscaling", "", nil)
+		return signal["slots"] == 1.0
+	})
+
+	c := gw.client(t, "")
+	for i := 0; i < 20 && gw.workers(t)[broken.id].CBState != "open"; i++ {
+		prompt(t, c, context.Background())
+	}
+	if state := gw.workers(t)[broken.id].CBState; state != "open" {
+		t.Fatalf("expected the breaker to open, got %s", state)
+	}
+
+	// With no worker in rotation, prompts degrade at once instead of
+	// waiting in the scheduler until their deadline
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := prompt(t, c, ctx)
+	if err != nil || resp.Degraded != "emergency" || resp.WorkerID != emergency.id {
+		t.Fatalf("expected the emergency worker to serve the request, got %+v, %v", resp, err)
+	}
+
+	// Once the open timeout passes, a prompt is admitted to probe the worker
+	broken.fail(codes.OK)
+	eventually(t, 10*time.Second, "the worker to serve requests again", func() bool {
+		resp, err := prompt(t, c, context.Background())
+		return err == nil && resp.WorkerID == broken.id
+	})
+	if state := gw.workers(t)[broken.id].CBState; state != "closed" {
+		t.Errorf("expected the probe to close the breaker, got %s", state)
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	w := startWorker(t, "worker-a")
+	w.reply = strings.Fields("one two three four five six")
+	for i := 1; i < len(w.reply); i++ {
+		w.reply[i] = " " + w.reply[i]
+	}
+	w.chunkDelay = 50 * time.Millisecond
+	gw := startGateway(t, map[string]string{"ADMIN_API_KEYS": testAdminKey}, w)
+	c := gw.client(t, "")
+
+	// The prompt runs with a known request ID, so the stream can attach to it
+	ctx := requestid.NewContext(context.Background(), "e2e-stream-1")
+	done := make(chan error, 1)
+	go func() {
+		_, err := prompt(t, c, ctx)
+		done <- err
+	}()
+
+	var streamed string
+	eventually(t, 5*time.Second, "the request to be in flight", func() bool {
+		streamed = ""
+		for token, err := range c.StreamTokens(context.Background(), "e2e-stream-1") {
+			if err != nil {
+				return false
+			}
+			streamed += token
+		}
+		return true
+	})
+	if err := <-done; err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if want := strings.Join(w.reply, ""); streamed != want {
+		t.Errorf("expected the stream to carry %q, got %q", want, streamed)
+	}
+
+	// Streams are for admins only
+	if status, _ := gw.request(t, http.MethodGet, "/admin/requests/e2e-stream-1/stream", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin key, got %d", status)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	w := startWorker(t, "worker-a")
+	gw := startGateway(t, map[string]string{"API_KEYS": testAPIKey, "ADMIN_API_KEYS": testAdminKey}, w)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"prompt without key", http.MethodPost, "/prompt", "", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"prompt with invalid key", http.MethodPost, "/prompt", "wrong-key", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"prompt with API key", http.MethodPost, "/prompt", testAPIKey, http.StatusOK, ""},
+		{"admin with API key", http.MethodGet, "/admin/log-level", testAPIKey, http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"admin with admin key", http.MethodGet, "/admin/log-level", testAdminKey, http.StatusOK, ""},
+		{"health without key", http.MethodGet, "/health/live", "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body any
+			if tt.method == http.MethodPost {
+				body = map[string]any{"query": "Say hello", "model": testModel}
+			}
+			status, resp := gw.request(t, tt.method, tt.path, tt.token, body)
+			if status != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %v", tt.wantStatus, status, resp)
+			}
+			if tt.wantCode != "" && resp["error_code"] != tt.wantCode {
+				t.Errorf("expected error code %s, got %v", tt.wantCode, resp["error_code"])
+			}
+		})
+	}
+
+	// Rejected requests never reach a worker
+	if n := w.generations.Load(); n != 1 {
+		t.Errorf("expected 1 generation, got %d", n)
+	}
+}
+
+func TestSQLiteHistory(t *testing.T) {
+	w := startWorker(t, "worker-a")
+	env := map[string]string{
+		"API_KEYS":                  testAPIKey,
+		"STORE_BACKEND":             "sqlite",
+		"STORE_DSN":                 filepath.Join(t.TempDir(), "gateway.db"),
+		"REQUEST_HISTORY_RETENTION": "1h",
+		"JWT_SECRET":                "e2e-jwt-secret",
+	}
+	gw := startGateway(t, env, w)
+	resp, err := prompt(t, gw.client(t, testAPIKey), context.Background())
+	if err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	token := func(issuedAt int) string {
+		s, err := jwt.Sign([]byte(env["JWT_SECRET"]), jwt.Claims{"tenant": "team-a", "sub": "alice", "iat": issuedAt})
+		if err != nil {
+			t.Fatalf("signing a token: %v", err)
+		}
+		return s
+	}
+	jwtResp, err := prompt(t, gw.client(t, token(1)), context.Background())
+	if err != nil {
+		t.Fatalf("prompt with a JWT failed: %v", err)
+	}
+
+	// Another gateway on the same database sees the request
+	other := startGateway(t, env, w)
+	status, body := other.request(t, http.MethodGet, "/requests", testAPIKey, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %v", status, body)
+	}
+	records, _ := body["requests"].([]any)
+	if len(records) != 1 || records[0].(map[string]any)["request_id"] != resp.RequestID {
+		t.Errorf("expected the history of request %s, got %v", resp.RequestID, body)
+	}
+
+	// JWT callers see their requests with a refreshed token
+	status, body = other.request(t, http.MethodGet, "/requests", token(2), nil)
+	records, _ = body["requests"].([]any)
+	if status != http.StatusOK || len(records) != 1 || records[0].(map[string]any)["request_id"] != jwtResp.RequestID {
+		t.Errorf("expected the history of request %s, got %d %v", jwtResp.RequestID, status, body)
+	}
+}
+
+func TestKeysStoredHashed(t *testing.T) {
+	const importedKey = "e2e-imported-key"
+	w := startWorker(t, "worker-a")
+	path := filepath.Join(t.TempDir(), "gateway.json")
+	gw := startGateway(t, map[string]string{
+		"API_KEYS":       testAPIKey,
+		"ADMIN_API_KEYS": testAdminKey,
+		"STORE_BACKEND":  "file",
+		"STORE_DSN":      path,
+	}, w)
+
+	status, resp := gw.request(t, http.MethodPost, "/admin/keys/import", testAdminKey, map[string]any{
+		"version": 1,
+		"keys":    []map[string]any{{"key": importedKey, "tenant": "acme"}},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("import failed with %d: %v", status, resp)
+	}
+	if _, err := prompt(t, gw.client(t, importedKey), context.Background()); err != nil {
+		t.Fatalf("prompt with the imported key failed: %v", err)
+	}
+
+	stored, err := store.NewFile(path)
+	if err != nil {
+		t.Fatalf("opening the store: %v", err)
+	}
+	keys, _ := stored.List(context.Background(), "keys")
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 stored keys, got %d", len(keys))
+	}
+	for id, value := range keys {
+		for _, key := range []string{testAPIKey, importedKey} {
+			if strings.Contains(id, key) || strings.Contains(string(value), key) {
+				t.Errorf("expected key %s to be stored by hash, got %s: %s", key, id, value)
+			}
+		}
+	}
+
+	// The export re-imports unchanged
+	status, resp = gw.request(t, http.MethodGet, "/admin/keys/export", testAdminKey, nil)
+	if status != http.StatusOK {
+		t.Fatalf("export failed with %d: %v", status, resp)
+	}
+	status, resp = gw.request(t, http.MethodPost, "/admin/keys/import?mode=replace", testAdminKey, resp)
+	if status != http.StatusOK || resp["unchanged"] != 2.0 {
+		t.Errorf("expected the export to re-import unchanged, got %d: %v", status, resp)
+	}
+}
+
+func TestWebhookDestinations(t *testing.T) {
+	w := startWorker(t, "worker-a")
+	var delivered atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		delivered.Add(1)
+	}))
+	defer receiver.Close()
+	job := map[string]any{"query": "Say hello", "model": testModel, "webhook_url": receiver.URL}
+
+	// Loopback isn't a public address
+	gw := startGateway(t, nil, w)
+	if status, resp := gw.request(t, http.MethodPost, "/jobs", "", job); status != http.StatusAccepted {
+		t.Fatalf("job submission failed with %d: %v", status, resp)
+	}
+	eventually(t, 5*time.Second, "the delivery to be refused", func() bool {
+		return strings.Contains(gw.log.String(), "not a public address")
+	})
+	if n := delivered.Load(); n != 0 {
+		t.Fatalf("expected no delivery to a loopback webhook, got %d", n)
+	}
+
+	// Unless the operator allows the host
+	allowed := startGateway(t, map[string]string{"WEBHOOK_ALLOWED_HOSTS": "127.0.0.1"}, w)
+	if status, resp := allowed.request(t, http.MethodPost, "/jobs", "", job); status != http.StatusAccepted {
+		t.Fatalf("job submission failed with %d: %v", status, resp)
+	}
+	eventually(t, 5*time.Second, "the webhook to be delivered", func() bool {
+		return delivered.Load() == 1
+	})
+}
+
+func TestSlowWebhookDoesNotBlockJobs(t *testing.T) {
+	w := startWorker(t, "worker-a")
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer receiver.Close()
+	defer close(release)
+	gw := startGateway(t, map[string]string{"WEBHOOK_ALLOWED_HOSTS": "127.0.0.1", "JOB_WORKERS": "1"}, w)
+
+	status, resp := gw.request(t, http.MethodPost, "/jobs", "", map[string]any{"query": "Say hello", "model": testModel, "webhook_url": receiver.URL})
+	if status != http.StatusAccepted {
+		t.Fatalf("job submission failed with %d: %v", status, resp)
+	}
+	status, resp = gw.request(t, http.MethodPost, "/jobs", "", map[string]any{"query": "Say hello", "model": testModel})
+	if status != http.StatusAccepted {
+		t.Fatalf("job submission failed with %d: %v", status, resp)
+	}
+
+	// The only runner moves on while the first job's webhook hangs
+	id, _ := resp["id"].(string)
+	eventually(t, 5*time.Second, "the second job to complete", func() bool {
+		_, job := gw.request(t, http.MethodGet, "/jobs/"+id, "", nil)
+		return job["status"] == "completed"
+	})
+}
@@ -0,0 +1,323 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	llmv1 "github.com/hugovillarreal/neurogate/api/proto/llm/v1"
+	neurogate "github.com/hugovillarreal/neurogate/pkg/client"
+)
+
+const (
+	testModel    = "llama3.2"
+	testAPIKey   = "e2e-key"
+	testAdminKey = "e2e-admin-key"
+)
+
+// gatewayBinary is the gateway built by TestMain
+var gatewayBinary string
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		fmt.Println("skipping end-to-end tests in short mode")
+		os.Exit(0)
+	}
+
+	dir, err := os.MkdirTemp("", "neurogate-e2e")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "e2e:", err)
+		os.Exit(1)
+	}
+	gatewayBinary = filepath.Join(dir, "gateway")
+	build := exec.Command("go", "build", "-o", gatewayBinary, "github.com/hugovillarreal/neurogate/cmd/gateway")
+	if out, err := build.CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: building the gateway: %v\n%s", err, out)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// fakeWorker serves the worker gRPC API, streaming a fixed reply. Setting
+// a failure code makes generations fail with it.
+type fakeWorker struct {
+	llmv1.UnimplementedLLMServiceServer
+
+	id         string
+	addr       string
+	server     *grpc.Server
+	reply      []string
+	chunkDelay time.Duration
+
+	generations atomic.Int32
+	failCode    atomic.Uint32 // codes.OK serves the reply
+	unhealthy   atomic.Bool
+	slots       atomic.Int32 // Reported capacity; 0 reports none
+	maxQueued   atomic.Int32
+}
+
+// startWorker starts a fake worker on a free port, stopped when the test
+// ends
+func startWorker(t *testing.T, id string) *fakeWorker {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	w := &fakeWorker{
+		id:     id,
+		addr:   lis.Addr().String(),
+		server: grpc.NewServer(),
+		reply:  []string{"Hello", " from", " " + id},
+	}
+	llmv1.RegisterLLMServiceServer(w.server, w)
+	go w.server.Serve(lis)
+	t.Cleanup(w.server.Stop)
+	return w
+}
+
+// fail makes generations fail with code, or succeed with codes.OK
+func (w *fakeWorker) fail(code codes.Code) {
+	w.failCode.Store(uint32(code))
+}
+
+func (w *fakeWorker) HealthCheck(ctx context.Context, req *llmv1.HealthCheckRequest) (*llmv1.HealthCheckResponse, error) {
+	return &llmv1.HealthCheckResponse{
+		Healthy:         !w.unhealthy.Load(),
+		OllamaConnected: true,
+		InstanceId:      w.id,
+		Timestamp:       time.Now().UnixMilli(),
+		Models:          []string{testModel},
+		Capacity:        w.slots.Load(),
+		MaxQueueDepth:   w.maxQueued.Load(),
+	}, nil
+}
+
+func (w *fakeWorker) StreamGenerateText(req *llmv1.PromptRequest, stream grpc.ServerStreamingServer[llmv1.TokenResponse]) error {
+	w.generations.Add(1)
+	if code := codes.Code(w.failCode.Load()); code != codes.OK {
+		return status.Error(code, "injected failure")
+	}
+
+	start := time.Now()
+	for i, token := range w.reply {
+		if i > 0 && w.chunkDelay > 0 {
+			select {
+			case <-stream.Context().Done():
+				return stream.Context().Err()
+			case <-time.After(w.chunkDelay):
+			}
+		}
+		if err := stream.Send(&llmv1.TokenResponse{RequestId: req.RequestId, Token: token, TokensGenerated: int32(i + 1)}); err != nil {
+			return err
+		}
+	}
+	return stream.Send(&llmv1.TokenResponse{
+		RequestId:       req.RequestId,
+		Done:            true,
+		TokensGenerated: int32(len(w.reply)),
+		Model:           req.Model,
+		PromptTokens:    int32(len(strings.Fields(req.Prompt))),
+		InferenceTimeMs: time.Since(start).Milliseconds(),
+	})
+}
+
+// gateway is a gateway process under test
+type gateway struct {
+	url string
+	log *syncBuffer
+}
+
+// startGateway runs the gateway against workers with the given extra
+// environment, waiting until it serves requests. It is stopped when the
+// test ends, and its log is printed if the test failed.
+func startGateway(t *testing.T, env map[string]string, workers ...*fakeWorker) *gateway {
+	t.Helper()
+	addrs := make([]string, len(workers))
+	for i, w := range workers {
+		addrs[i] = w.addr
+	}
+	httpPort, metricsPort := freePort(t), freePort(t)
+
+	cmd := exec.Command(gatewayBinary)
+	cmd.Env = append(os.Environ(),
+		"HTTP_PORT="+httpPort,
+		"METRICS_PORT="+metricsPort,
+		"WORKER_ADDRESSES="+strings.Join(addrs, ","),
+		"WORKER_HEALTH_INTERVAL=100ms",
+		"WORKER_HEALTH_TIMEOUT=1s",
+		"LOG_LEVEL=debug",
+	)
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	g := &gateway{url: "http://127.0.0.1:" + httpPort, log: &syncBuffer{}}
+	cmd.Stdout, cmd.Stderr = g.log, g.log
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("starting the gateway: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Signal(syscall.SIGTERM)
+		done := make(chan struct{})
+		go func() { cmd.Wait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			cmd.Process.Kill()
+			<-done
+		}
+		if t.Failed() {
+			t.Logf("gateway log:\n%s", g.log.String())
+		}
+	})
+
+	eventually(t, 10*time.Second, "the gateway to start", func() bool {
+		resp, err := http.Get(g.url + "/health/live")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	})
+	return g
+}
+
+// client returns an SDK client for the gateway that doesn't retry, so
+// tests see each response the gateway sends
+func (g *gateway) client(t *testing.T, apiKey string) *neurogate.Client {
+	t.Helper()
+	c, err := neurogate.New(neurogate.Config{URL: g.url, APIKey: apiKey, AdminKey: testAdminKey, MaxRetries: -1})
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	return c
+}
+
+// workerStatus is a worker as reported by GET /workers
+type workerStatus struct {
+	ID      string `json:"id"`
+	Healthy bool   `json:"healthy"`
+	CBState string `json:"circuit_breaker_state"`
+}
+
+// workers returns the gateway's workers by ID
+func (g *gateway) workers(t *testing.T) map[string]workerStatus {
+	t.Helper()
+	resp, err := http.Get(g.url + "/workers")
+	if err != nil {
+		t.Fatalf("GET /workers: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Workers []workerStatus `json:"workers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding /workers: %v", err)
+	}
+	workers := make(map[string]workerStatus)
+	for _, w := range body.Workers {
+		workers[w.ID] = w
+	}
+	return workers
+}
+
+// request sends a request with an optional bearer token and returns the
+// status and decoded JSON body
+func (g *gateway) request(t *testing.T, method, path, token string, body any) (int, map[string]any) {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&payload).Encode(body)
+	}
+	req, _ := http.NewRequest(method, g.url+path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]any
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp.StatusCode, decoded
+}
+
+// prompt sends a prompt that the cache can't answer
+func prompt(t *testing.T, c *neurogate.Client, ctx context.Context) (*neurogate.PromptResponse, error) {
+	t.Helper()
+	seed := time.Now().UnixNano()
+	return c.Prompt(ctx, &neurogate.PromptRequest{
+		Query:    "Say hello",
+		Model:    testModel,
+		Sampling: neurogate.Sampling{Seed: &seed},
+	})
+}
+
+// eventually polls cond until it holds or the timeout passes
+func eventually(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// freePort returns a TCP port that was free when checked
+func freePort(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer lis.Close()
+	_, port, _ := net.SplitHostPort(lis.Addr().String())
+	return port
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
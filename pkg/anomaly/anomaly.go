@@ -0,0 +1,166 @@
+// Package anomaly flags unusual per-key traffic, such as sudden request
+// spikes or error-rate surges, from lightweight per-interval counters
+package anomaly
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Kind identifies the pattern that triggered an anomaly
+type Kind string
+
+const (
+	KindRequestSpike Kind = "request_spike"
+	KindErrorSurge   Kind = "error_surge"
+)
+
+// baselineAlpha weights the latest interval in the request baseline (EWMA)
+const baselineAlpha = 0.3
+
+// Event describes an anomaly detected for a key in the current interval
+type Event struct {
+	Key       string    `json:"key"`
+	Kind      Kind      `json:"kind"`
+	Requests  int64     `json:"requests"`
+	Errors    int64     `json:"errors"`
+	Baseline  float64   `json:"baseline"` // Typical requests per interval
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Config holds detector configuration
+type Config struct {
+	Interval    time.Duration // Length of a counting interval. Default: 1 minute
+	SpikeFactor float64       // Requests above this multiple of the baseline are a spike. Default: 10
+	ErrorRate   float64       // Error fraction (0-1) treated as a surge. Default: 0.5
+	MinRequests int64         // Requests in an interval before any rule applies. Default: 20
+	Warmup      int           // Intervals observed before spikes are reported. Default: 5
+	Cooldown    time.Duration // Minimum time between events of one kind for a key. Default: 15 minutes
+	OnAnomaly   func(Event)   // Called (in a new goroutine) when an anomaly is detected
+}
+
+// Detector tracks request and error counts for many keys
+type Detector struct {
+	mu   sync.Mutex
+	cfg  Config
+	keys map[string]*keyState
+	now  func() time.Time
+}
+
+type keyState struct {
+	intervalStart time.Time
+	requests      int64
+	errors        int64
+	baseline      float64
+	intervals     int // completed intervals observed
+	lastEvent     map[Kind]time.Time
+}
+
+// New creates a new detector
+func New(cfg Config) *Detector {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.SpikeFactor <= 0 {
+		cfg.SpikeFactor = 10
+	}
+	if cfg.ErrorRate <= 0 {
+		cfg.ErrorRate = 0.5
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 20
+	}
+	if cfg.Warmup <= 0 {
+		cfg.Warmup = 5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 15 * time.Minute
+	}
+
+	return &Detector{
+		cfg:  cfg,
+		keys: make(map[string]*keyState),
+		now:  time.Now,
+	}
+}
+
+// Record counts a completed request for key and reports any anomalies it
+// reveals through OnAnomaly
+func (d *Detector) Record(key string, failed bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	s, ok := d.keys[key]
+	if !ok {
+		s = &keyState{intervalStart: now, lastEvent: make(map[Kind]time.Time)}
+		d.keys[key] = s
+	}
+	d.rollLocked(s, now)
+
+	s.requests++
+	if failed {
+		s.errors++
+	}
+	if s.requests < d.cfg.MinRequests {
+		return
+	}
+
+	if s.intervals >= d.cfg.Warmup && float64(s.requests) >= d.cfg.SpikeFactor*math.Max(s.baseline, 1) {
+		d.emitLocked(key, s, KindRequestSpike, now)
+	}
+	if float64(s.errors)/float64(s.requests) >= d.cfg.ErrorRate {
+		d.emitLocked(key, s, KindErrorSurge, now)
+	}
+}
+
+// Interval returns the length of a counting interval
+func (d *Detector) Interval() time.Duration {
+	return d.cfg.Interval
+}
+
+// Forget drops the counters for key
+func (d *Detector) Forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.keys, key)
+}
+
+// rollLocked folds finished intervals into the baseline; intervals without
+// requests count as zero
+func (d *Detector) rollLocked(s *keyState, now time.Time) {
+	elapsed := int(now.Sub(s.intervalStart) / d.cfg.Interval)
+	if elapsed <= 0 {
+		return
+	}
+
+	if s.intervals == 0 {
+		s.baseline = float64(s.requests)
+	} else {
+		s.baseline += baselineAlpha * (float64(s.requests) - s.baseline)
+	}
+	s.baseline *= math.Pow(1-baselineAlpha, float64(elapsed-1))
+
+	s.intervals += elapsed
+	s.requests, s.errors = 0, 0
+	s.intervalStart = s.intervalStart.Add(time.Duration(elapsed) * d.cfg.Interval)
+}
+
+func (d *Detector) emitLocked(key string, s *keyState, kind Kind, now time.Time) {
+	if last, ok := s.lastEvent[kind]; ok && now.Sub(last) < d.cfg.Cooldown {
+		return
+	}
+	s.lastEvent[kind] = now
+
+	if d.cfg.OnAnomaly != nil {
+		go d.cfg.OnAnomaly(Event{
+			Key:       key,
+			Kind:      kind,
+			Requests:  s.requests,
+			Errors:    s.errors,
+			Baseline:  s.baseline,
+			Timestamp: now,
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock lets tests move a detector through intervals without sleeping
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestDetector(cfg Config) (*Detector, *fakeClock, chan Event) {
+	events := make(chan Event, 10)
+	cfg.OnAnomaly = func(e Event) { events <- e }
+	d := New(cfg)
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	d.now = clock.now
+	return d, clock, events
+}
+
+// expectEvents collects events until none arrive for a short while
+func expectEvents(t *testing.T, events chan Event) []Event {
+	t.Helper()
+	var got []Event
+	for {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(50 * time.Millisecond):
+			return got
+		}
+	}
+}
+
+func TestDetector_ReportsRequestSpike(t *testing.T) {
+	d, clock, events := newTestDetector(Config{Warmup: 3, MinRequests: 10})
+
+	// Steady traffic of 5 requests per interval
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 5; j++ {
+			d.Record("key", false)
+		}
+		clock.advance(time.Minute)
+	}
+	if got := expectEvents(t, events); len(got) != 0 {
+		t.Fatalf("expected no events for steady traffic, got %+v", got)
+	}
+
+	for j := 0; j < 60; j++ {
+		d.Record("key", false)
+	}
+	got := expectEvents(t, events)
+	if len(got) != 1 || got[0].Kind != KindRequestSpike || got[0].Key != "key" {
+		t.Fatalf("expected one request spike, got %+v", got)
+	}
+	if got[0].Requests != 50 || got[0].Baseline < 4.9 || got[0].Baseline > 5.1 {
+		t.Errorf("expected spike at 50 requests over a baseline of 5, got %+v", got[0])
+	}
+}
+
+func TestDetector_NoSpikeDuringWarmup(t *testing.T) {
+	d, _, events := newTestDetector(Config{})
+
+	for j := 0; j < 500; j++ {
+		d.Record("key", false)
+	}
+	if got := expectEvents(t, events); len(got) != 0 {
+		t.Errorf("expected no events before warmup, got %+v", got)
+	}
+}
+
+func TestDetector_IdleIntervalsLowerBaseline(t *testing.T) {
+	d, clock, events := newTestDetector(Config{Warmup: 1, MinRequests: 10})
+
+	for j := 0; j < 50; j++ {
+		d.Record("key", false)
+	}
+	clock.advance(time.Hour)
+
+	// After an hour of silence the old traffic no longer counts as normal
+	for j := 0; j < 10; j++ {
+		d.Record("key", false)
+	}
+	got := expectEvents(t, events)
+	if len(got) != 1 || got[0].Kind != KindRequestSpike {
+		t.Errorf("expected a spike against a decayed baseline, got %+v", got)
+	}
+}
+
+func TestDetector_ReportsErrorSurge(t *testing.T) {
+	d, _, events := newTestDetector(Config{MinRequests: 10, ErrorRate: 0.5})
+
+	for j := 0; j < 9; j++ {
+		d.Record("key", true)
+	}
+	if got := expectEvents(t, events); len(got) != 0 {
+		t.Fatalf("expected no events below the minimum requests, got %+v", got)
+	}
+
+	d.Record("key", true)
+	got := expectEvents(t, events)
+	if len(got) != 1 || got[0].Kind != KindErrorSurge || got[0].Errors != 10 {
+		t.Errorf("expected one error surge with 10 errors, got %+v", got)
+	}
+}
+
+func TestDetector_Cooldown(t *testing.T) {
+	d, clock, events := newTestDetector(Config{MinRequests: 1, Cooldown: 10 * time.Minute})
+
+	d.Record("key", true)
+	d.Record("key", true)
+	clock.advance(5 * time.Minute)
+	d.Record("key", true)
+	if got := expectEvents(t, events); len(got) != 1 {
+		t.Fatalf("expected one event within the cooldown, got %+v", got)
+	}
+
+	clock.advance(5 * time.Minute)
+	d.Record("key", true)
+	if got := expectEvents(t, events); len(got) != 1 {
+		t.Errorf("expected another event after the cooldown, got %+v", got)
+	}
+}
+
+func TestDetector_KeysAreIndependent(t *testing.T) {
+	d, _, events := newTestDetector(Config{MinRequests: 4})
+
+	for j := 0; j < 4; j++ {
+		d.Record("bad", true)
+		d.Record("good", false)
+	}
+	got := expectEvents(t, events)
+	if len(got) != 1 || got[0].Key != "bad" {
+		t.Errorf("expected an event only for the failing key, got %+v", got)
+	}
+
+	d.Forget("bad")
+	d.Record("bad", true)
+	if got := expectEvents(t, events); len(got) != 0 {
+		t.Errorf("expected forgotten key to start over, got %+v", got)
+	}
+}
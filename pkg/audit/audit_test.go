@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hugovillarreal/neurogate/pkg/store"
+)
+
+func openFileLog(t *testing.T, path, key string) *Log {
+	t.Helper()
+	backend, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	l, err := New(context.Background(), backend, key)
+	if err != nil {
+		t.Fatalf("new failed: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestLog_ChainContinuesAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	l := openFileLog(t, path, "")
+	l.Record(ctx, Event{Type: EventAuthFailure, Remote: "10.0.0.1:1234", Target: "/prompt"})
+	l.Record(ctx, Event{Type: EventKeyCreated, Actor: "key-admin", Target: "key-1a2b3c4d"})
+	l.Close()
+
+	l = openFileLog(t, path, "")
+	l.Record(ctx, Event{Type: EventBreakerOverride, Target: "worker-1", Details: map[string]string{"action": "trip"}})
+
+	n, last, err := l.Verify(ctx)
+	if err != nil {
+		t.Fatalf("expected a valid chain, got %v", err)
+	}
+	if n != 3 || last == "" {
+		t.Errorf("expected 3 records and a last hash, got %d, %q", n, last)
+	}
+
+	records, _ := ReadFile(path)
+	if records[2].Seq != 3 || records[2].PrevHash != records[1].Hash {
+		t.Errorf("expected the reopened log to extend the chain, got %+v", records[2])
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	l := openFileLog(t, path, "")
+	for _, target := range []string{"/admin/keys/import", "/admin/keys/export", "/admin/requests"} {
+		l.Record(ctx, Event{Type: EventAdminCall, Actor: "key-admin", Target: target})
+	}
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	edited := strings.Replace(string(data), "/admin/keys/export", "/admin/keys/exports", 1)
+	if err := verifyContents(t, edited, ""); err == nil || !strings.Contains(err.Error(), "record 2") {
+		t.Errorf("expected edited record 2 to be detected, got %v", err)
+	}
+
+	removed := lines[0] + "\n" + lines[2] + "\n"
+	if err := verifyContents(t, removed, ""); err == nil {
+		t.Error("expected a removed record to be detected")
+	}
+
+	if err := verifyContents(t, string(data), "other-key"); err == nil {
+		t.Error("expected verification with the wrong key to fail")
+	}
+}
+
+func verifyContents(t *testing.T, contents, key string) error {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "copy.log")
+	os.WriteFile(path, []byte(contents), 0o600)
+	records, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	return Verify(records, key)
+}
+
+func TestLog_KeyedHashes(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	l := openFileLog(t, path, "secret")
+	l.Record(ctx, Event{Type: EventKeyRevoked, Target: "key-1a2b3c4d"})
+
+	records, _ := ReadFile(path)
+	if err := Verify(records, "secret"); err != nil {
+		t.Errorf("expected keyed chain to verify, got %v", err)
+	}
+	if err := Verify(records, ""); err == nil {
+		t.Error("expected keyed chain not to verify without the key")
+	}
+}
+
+func TestLog_DetectsTruncation(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	l := openFileLog(t, path, "")
+	l.Record(ctx, Event{Type: EventAdminCall})
+	l.Record(ctx, Event{Type: EventAdminCall})
+
+	data, _ := os.ReadFile(path)
+	first := strings.SplitAfter(string(data), "\n")[0]
+	os.WriteFile(path, []byte(first), 0o600)
+
+	if _, _, err := l.Verify(ctx); err == nil {
+		t.Error("expected a truncated log to be detected")
+	}
+}
+
+func TestStoreBackend(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+
+	l, err := New(ctx, NewStoreBackend(st), "")
+	if err != nil {
+		t.Fatalf("new failed: %v", err)
+	}
+	for i := 0; i < 12; i++ {
+		l.Record(ctx, Event{Type: EventAdminCall})
+	}
+
+	// Reopening continues the chain in sequence order
+	l, _ = New(ctx, NewStoreBackend(st), "")
+	l.Record(ctx, Event{Type: EventAdminCall})
+	if n, _, err := l.Verify(ctx); err != nil || n != 13 {
+		t.Errorf("expected 13 valid records, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,128 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/hugovillarreal/neurogate/pkg/store"
+)
+
+// FileBackend appends records to a file as JSON lines. The file is only
+// ever opened for appending.
+type FileBackend struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenFile opens or creates an audit log file
+func OpenFile(path string) (*FileBackend, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileBackend{path: path, file: file}, nil
+}
+
+// Append implements Backend
+func (b *FileBackend) Append(ctx context.Context, r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, err = b.file.Write(append(line, '\n'))
+	return err
+}
+
+// Records implements Backend
+func (b *FileBackend) Records(ctx context.Context) ([]Record, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return ReadFile(b.path)
+}
+
+// Close implements Backend
+func (b *FileBackend) Close() error {
+	return b.file.Close()
+}
+
+// ReadFile reads the records of an audit log file
+func ReadFile(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// storeCollection is the store collection holding audit records
+const storeCollection = "audit"
+
+// StoreBackend keeps records in a store collection, for deployments that
+// already persist gateway state in a database
+type StoreBackend struct {
+	store store.Store
+}
+
+// NewStoreBackend creates a backend on s. Closing the backend leaves s open.
+func NewStoreBackend(s store.Store) *StoreBackend {
+	return &StoreBackend{store: s}
+}
+
+// Append implements Backend
+func (b *StoreBackend) Append(ctx context.Context, r Record) error {
+	// Zero-padded so keys sort in sequence order
+	return store.PutJSON(ctx, b.store, storeCollection, fmt.Sprintf("%020d", r.Seq), r)
+}
+
+// Records implements Backend
+func (b *StoreBackend) Records(ctx context.Context) ([]Record, error) {
+	values, err := b.store.List(ctx, storeCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(values))
+	for key, value := range values {
+		var r Record
+		if err := json.Unmarshal(value, &r); err != nil {
+			return nil, fmt.Errorf("audit record %s: %w", key, err)
+		}
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Seq < records[j].Seq })
+	return records, nil
+}
+
+// Close implements Backend
+func (b *StoreBackend) Close() error {
+	return nil
+}
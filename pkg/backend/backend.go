@@ -0,0 +1,197 @@
+// Package backend defines the inference servers a worker can serve requests
+// from. Ollama is one implementation; each speaks its server's API behind
+// the same interface, so the worker's gRPC service doesn't depend on it.
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+// Kinds of failure a backend recognizes. Errors returned by backends wrap
+// one of these when the cause is known; test with errors.Is.
+var (
+	ErrModelNotFound  = errors.New("model not found")
+	ErrOutOfMemory    = errors.New("out of memory")
+	ErrModelLoading   = errors.New("model is loading")
+	ErrUnsupported    = errors.New("not supported by this backend")
+	ErrContextTooLong = errors.New("prompt exceeds the context window")
+)
+
+// FormatJSON asks for output that is any valid JSON
+var FormatJSON = json.RawMessage(`"json"`)
+
+// InferenceBackend serves generation and embedding requests
+type InferenceBackend interface {
+	// Generate returns the whole response to req
+	Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error)
+
+	// Stream calls fn for each chunk of the response to req. The last chunk
+	// has Done set and carries the token counts.
+	Stream(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse) error) error
+
+	Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error)
+
+	// ListModels returns the models the backend can serve
+	ListModels(ctx context.Context) ([]Model, error)
+
+	// Health returns an error if the backend can't serve requests
+	Health(ctx context.Context) error
+}
+
+// LoadedModelLister is implemented by backends that can report which models
+// are loaded into memory
+type LoadedModelLister interface {
+	ListLoaded(ctx context.Context) ([]LoadedModel, error)
+}
+
+// SlotReporter is implemented by backends that process a fixed number of
+// requests at once and can report how many are busy
+type SlotReporter interface {
+	Slots(ctx context.Context) (Slots, error)
+}
+
+// Tokenizer is implemented by backends that can count tokens with the
+// model's tokenizer
+type Tokenizer interface {
+	// CountTokens returns the tokens of req's prompt as the model sees it,
+	// with its system prompt and history
+	CountTokens(ctx context.Context, req *GenerateRequest) (int, error)
+}
+
+// Slots counts a backend's request slots by state
+type Slots struct {
+	Idle       int
+	Processing int
+}
+
+// Message is an earlier turn of a conversation
+type Message struct {
+	Role    string // "user" or "assistant"
+	Content string
+}
+
+// Options are generation parameters. Zero values use the model's defaults.
+type Options struct {
+	Temperature   float64
+	MaxTokens     int
+	TopP          float64
+	TopK          int
+	RepeatPenalty float64
+	Seed          *int // Random if nil
+	Stop          []string
+	NumCtx        int // Context window size in tokens
+}
+
+// GenerateRequest is a prompt to complete
+type GenerateRequest struct {
+	Model   string
+	Prompt  string
+	System  string
+	History []Message // Earlier turns of the conversation, oldest first
+	Options Options
+
+	// Constrains the output to FormatJSON or a JSON schema (free text if
+	// nil)
+	Format json.RawMessage
+
+	// Context tokens of an earlier response, continuing it. Only backends
+	// that return Context support it.
+	Context []int
+
+	// How long to keep the model loaded after the request, negative for
+	// forever; nil for the backend's default. Backends that don't manage
+	// model loading ignore it.
+	KeepAlive *time.Duration
+}
+
+// GenerateResponse is a response, or a chunk of one when streaming
+type GenerateResponse struct {
+	Model            string
+	Text             string
+	Done             bool
+	PromptTokens     int
+	CompletionTokens int
+	Context          []int // Tokens continuing this response, if supported
+}
+
+// EmbedRequest asks for embedding vectors of the inputs
+type EmbedRequest struct {
+	Model     string
+	Input     []string
+	KeepAlive *time.Duration // As in GenerateRequest
+}
+
+// EmbedResponse holds one embedding per input
+type EmbedResponse struct {
+	Model        string
+	Embeddings   [][]float32
+	PromptTokens int
+}
+
+// Model is a model a backend can serve
+type Model struct {
+	Name string
+}
+
+// LoadedModel is a model loaded into memory
+type LoadedModel struct {
+	Name      string
+	Size      int64     // Total memory used, in bytes
+	SizeVRAM  int64     // GPU memory used, in bytes
+	ExpiresAt time.Time // When the model will be unloaded if idle; zero if unknown
+}
+
+// messageOverhead approximates the tokens a chat template adds around each
+// message
+const messageOverhead = 4
+
+// EstimateTokens approximates the tokens of req's prompt, with its system
+// prompt and history, for backends without a Tokenizer. ASCII text counts
+// as a token per four characters and other characters as a token each, so
+// the estimate errs high for most languages.
+func EstimateTokens(req *GenerateRequest) int {
+	tokens := estimateText(req.Prompt) + messageOverhead
+	if req.System != "" {
+		tokens += estimateText(req.System) + messageOverhead
+	}
+	for _, m := range req.History {
+		tokens += estimateText(m.Content) + messageOverhead
+	}
+	return tokens
+}
+
+func estimateText(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// errContextUnsupported rejects requests with context tokens on backends
+// that don't return them
+var errContextUnsupported = &Error{Kind: ErrUnsupported, Err: errors.New("context tokens are not supported by this backend")}
+
+// Error is a backend error of a recognized kind. Its message is the
+// backend's; errors.Is matches both the kind and the cause.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the kind and the cause
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
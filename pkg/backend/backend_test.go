@@ -0,0 +1,20 @@
+package backend
+
+import "testing"
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		req  GenerateRequest
+		want int
+	}{
+		{GenerateRequest{Prompt: "abcdefgh"}, 2 + messageOverhead},
+		{GenerateRequest{Prompt: "abcde"}, 2 + messageOverhead},
+		{GenerateRequest{Prompt: "日本語"}, 3 + messageOverhead},
+		{GenerateRequest{Prompt: "abcd", System: "abcd", History: []Message{{Role: "user", Content: "abcd"}}}, 3 + 3*messageOverhead},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(&tt.req); got != tt.want {
+			t.Errorf("EstimateTokens(%+v) = %d, want %d", tt.req, got, tt.want)
+		}
+	}
+}
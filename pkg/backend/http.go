@@ -0,0 +1,171 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/tracing"
+)
+
+// apiClient sends JSON requests to a backend's HTTP API
+type apiClient struct {
+	url        string
+	apiKey     string // Sent as a bearer token if set
+	httpClient *http.Client
+}
+
+func newAPIClient(url, apiKey string) *apiClient {
+	return &apiClient{
+		url:    strings.TrimSuffix(url, "/"),
+		apiKey: apiKey,
+		httpClient: &http.Client{
+			Transport: tracing.Transport(http.DefaultTransport),
+		},
+	}
+}
+
+// do sends body, if not nil, as JSON and decodes the response into result
+func (c *apiClient) do(ctx context.Context, timeout time.Duration, method, path string, body, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp, err := c.send(ctx, 0, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send sends body, if not nil, as JSON to path. The call is bounded by
+// timeout (0 for none) until the response body is closed. A non-2xx status
+// is returned as an error; otherwise the caller must close the body.
+func (c *apiClient) send(ctx context.Context, timeout time.Duration, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.url+path, reader)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer cancel()
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, apiError(resp.StatusCode, data)
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's context when its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// openAIError builds an error from a response, which these servers send as
+// {"error": {"message": "..."}} or, in some versions, {"message": "..."}
+func apiError(statusCode int, body []byte) error {
+	message := strings.TrimSpace(string(body))
+	var parsed struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		var nested struct {
+			Message string `json:"message"`
+		}
+		var flat string
+		switch {
+		case json.Unmarshal(parsed.Error, &nested) == nil && nested.Message != "":
+			message = nested.Message
+		case json.Unmarshal(parsed.Error, &flat) == nil && flat != "":
+			message = flat
+		case parsed.Message != "":
+			message = parsed.Message
+		}
+	}
+
+	err := fmt.Errorf("backend returned status %d: %s", statusCode, message)
+	switch {
+	case statusCode == http.StatusNotFound:
+		return &Error{Kind: ErrModelNotFound, Err: err}
+	case statusCode == http.StatusServiceUnavailable:
+		// Servers answer 503 while they load the model
+		return &Error{Kind: ErrModelLoading, Err: err}
+	case strings.Contains(strings.ToLower(message), "out of memory"):
+		return &Error{Kind: ErrOutOfMemory, Err: err}
+	case contextTooLong(message):
+		return &Error{Kind: ErrContextTooLong, Err: err}
+	}
+	return err
+}
+
+// contextTooLong recognizes the messages of servers rejecting a prompt
+// longer than the context: llama.cpp's "exceeds the available context
+// size" and vLLM's and OpenAI's "maximum context length"
+func contextTooLong(message string) bool {
+	msg := strings.ToLower(message)
+	return strings.Contains(msg, "context size") || strings.Contains(msg, "context length")
+}
+
+// readEvents calls fn with the data of each server-sent event in r until fn
+// returns done or r ends
+func readEvents(r io.Reader, fn func(data string) (done bool, err error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		done, err := fn(strings.TrimSpace(data))
+		if err != nil || done {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+	return nil
+}
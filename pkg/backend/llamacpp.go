@@ -0,0 +1,295 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// LlamaCppConfig configures a LlamaCpp backend
+type LlamaCppConfig struct {
+	URL     string        // Server URL, e.g. http://localhost:8080
+	APIKey  string        // Sent as a bearer token if set (the server's --api-key)
+	Timeout time.Duration // Generate and embed calls, including streaming. Default: 5 minutes
+
+	// Health checks, slots and model listing. Default: 5 seconds
+	PingTimeout time.Duration
+
+	// Name requests use for the served model; requests for others are
+	// rejected as not found. Empty accepts any name.
+	Model string
+}
+
+// LlamaCpp is a backend for llama.cpp's server using its native API.
+// Prompts go to /completion as-is; requests with a system prompt or history
+// are first rendered with the model's chat template. The server serves one
+// model, so request model names only select it when a Model is configured.
+// Embeddings use the server's OpenAI-compatible API, which needs it started
+// with --embeddings.
+type LlamaCpp struct {
+	cfg    LlamaCppConfig
+	api    *apiClient
+	openAI *OpenAI
+}
+
+var (
+	_ InferenceBackend = (*LlamaCpp)(nil)
+	_ SlotReporter     = (*LlamaCpp)(nil)
+	_ Tokenizer        = (*LlamaCpp)(nil)
+)
+
+// NewLlamaCpp creates a backend for the server at cfg.URL
+func NewLlamaCpp(cfg LlamaCppConfig) *LlamaCpp {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+	if cfg.PingTimeout <= 0 {
+		cfg.PingTimeout = 5 * time.Second
+	}
+	api := newAPIClient(cfg.URL, cfg.APIKey)
+	return &LlamaCpp{
+		cfg: cfg,
+		api: api,
+		openAI: NewOpenAI(OpenAIConfig{
+			URL:         api.url + "/v1",
+			APIKey:      cfg.APIKey,
+			Timeout:     cfg.Timeout,
+			PingTimeout: cfg.PingTimeout,
+		}),
+	}
+}
+
+type llamaCppCompletionRequest struct {
+	Prompt        string          `json:"prompt"`
+	NPredict      int             `json:"n_predict,omitempty"`
+	Temperature   float64         `json:"temperature,omitempty"`
+	TopP          float64         `json:"top_p,omitempty"`
+	TopK          int             `json:"top_k,omitempty"`
+	RepeatPenalty float64         `json:"repeat_penalty,omitempty"`
+	Seed          *int            `json:"seed,omitempty"`
+	Stop          []string        `json:"stop,omitempty"`
+	JSONSchema    json.RawMessage `json:"json_schema,omitempty"`
+	Stream        bool            `json:"stream,omitempty"`
+
+	// Reuses the evaluated prompt of the slot's last request, which saves
+	// most of the work for the next turn of a conversation
+	CachePrompt bool `json:"cache_prompt"`
+}
+
+type llamaCppCompletionResponse struct {
+	Content         string `json:"content"`
+	Stop            bool   `json:"stop"`
+	TokensPredicted int    `json:"tokens_predicted"`
+	TokensEvaluated int    `json:"tokens_evaluated"`
+}
+
+// Generate implements InferenceBackend
+func (l *LlamaCpp) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	body, err := l.completion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result llamaCppCompletionResponse
+	if err := l.api.do(ctx, l.cfg.Timeout, "POST", "/completion", body, &result); err != nil {
+		return nil, err
+	}
+	return &GenerateResponse{
+		Model:            req.Model,
+		Text:             result.Content,
+		Done:             true,
+		PromptTokens:     result.TokensEvaluated,
+		CompletionTokens: result.TokensPredicted,
+	}, nil
+}
+
+// Stream implements InferenceBackend
+func (l *LlamaCpp) Stream(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse) error) error {
+	body, err := l.completion(ctx, req)
+	if err != nil {
+		return err
+	}
+	body.Stream = true
+
+	resp, err := l.api.send(ctx, l.cfg.Timeout, "POST", "/completion", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	done := false
+	err = readEvents(resp.Body, func(data string) (bool, error) {
+		var chunk llamaCppCompletionResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.Content != "" {
+			if err := fn(&GenerateResponse{Model: req.Model, Text: chunk.Content}); err != nil {
+				return false, err
+			}
+		}
+		if !chunk.Stop {
+			return false, nil
+		}
+		done = true
+		return true, fn(&GenerateResponse{
+			Model:            req.Model,
+			Done:             true,
+			PromptTokens:     chunk.TokensEvaluated,
+			CompletionTokens: chunk.TokensPredicted,
+		})
+	})
+	if err == nil && !done {
+		err = fmt.Errorf("stream ended before the response was complete")
+	}
+	return err
+}
+
+// Embed implements InferenceBackend
+func (l *LlamaCpp) Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error) {
+	if err := l.checkModel(req.Model); err != nil {
+		return nil, err
+	}
+	resp, err := l.openAI.Embed(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Model = req.Model
+	return resp, nil
+}
+
+// ListModels implements InferenceBackend, returning the configured model
+// name or, without one, the name the server reports
+func (l *LlamaCpp) ListModels(ctx context.Context) ([]Model, error) {
+	if l.cfg.Model != "" {
+		return []Model{{Name: l.cfg.Model}}, nil
+	}
+	return l.openAI.ListModels(ctx)
+}
+
+// Health implements InferenceBackend. The server answers 503 until the
+// model is loaded.
+func (l *LlamaCpp) Health(ctx context.Context) error {
+	var result struct {
+		Status string `json:"status"`
+	}
+	return l.api.do(ctx, l.cfg.PingTimeout, "GET", "/health", nil, &result)
+}
+
+// Slots implements SlotReporter. It needs the server's /slots endpoint,
+// which --no-slots disables.
+func (l *LlamaCpp) Slots(ctx context.Context) (Slots, error) {
+	var result []struct {
+		IsProcessing *bool `json:"is_processing"`
+		State        *int  `json:"state"` // Older servers: 0 idle, 1 processing
+	}
+	if err := l.api.do(ctx, l.cfg.PingTimeout, "GET", "/slots", nil, &result); err != nil {
+		return Slots{}, err
+	}
+
+	var slots Slots
+	for _, s := range result {
+		if (s.IsProcessing != nil && *s.IsProcessing) || (s.State != nil && *s.State != 0) {
+			slots.Processing++
+		} else {
+			slots.Idle++
+		}
+	}
+	return slots, nil
+}
+
+// CountTokens implements Tokenizer with the server's /tokenize endpoint,
+// counting the prompt as rendered for /completion
+func (l *LlamaCpp) CountTokens(ctx context.Context, req *GenerateRequest) (int, error) {
+	if err := l.checkModel(req.Model); err != nil {
+		return 0, err
+	}
+
+	prompt := req.Prompt
+	if req.System != "" || len(req.History) > 0 {
+		var err error
+		if prompt, err = l.applyTemplate(ctx, req); err != nil {
+			return 0, err
+		}
+	}
+
+	var result struct {
+		Tokens []json.RawMessage `json:"tokens"`
+	}
+	body := map[string]interface{}{"content": prompt, "add_special": true}
+	if err := l.api.do(ctx, l.cfg.PingTimeout, "POST", "/tokenize", body, &result); err != nil {
+		return 0, err
+	}
+	return len(result.Tokens), nil
+}
+
+// completion converts req to a completion request
+func (l *LlamaCpp) completion(ctx context.Context, req *GenerateRequest) (*llamaCppCompletionRequest, error) {
+	if err := l.checkModel(req.Model); err != nil {
+		return nil, err
+	}
+	if len(req.Context) > 0 {
+		return nil, errContextUnsupported
+	}
+
+	prompt := req.Prompt
+	if req.System != "" || len(req.History) > 0 {
+		var err error
+		if prompt, err = l.applyTemplate(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	body := &llamaCppCompletionRequest{
+		Prompt:        prompt,
+		NPredict:      req.Options.MaxTokens,
+		Temperature:   req.Options.Temperature,
+		TopP:          req.Options.TopP,
+		TopK:          req.Options.TopK,
+		RepeatPenalty: req.Options.RepeatPenalty,
+		Seed:          req.Options.Seed,
+		Stop:          req.Options.Stop,
+		CachePrompt:   true,
+	}
+	switch {
+	case req.Format == nil:
+	case bytes.Equal(req.Format, FormatJSON):
+		// An empty schema accepts any JSON
+		body.JSONSchema = json.RawMessage(`{}`)
+	default:
+		body.JSONSchema = req.Format
+	}
+	return body, nil
+}
+
+// applyTemplate renders the conversation of req, ending with its prompt as
+// the newest user message, with the model's chat template
+func (l *LlamaCpp) applyTemplate(ctx context.Context, req *GenerateRequest) (string, error) {
+	messages := make([]openAIMessage, 0, len(req.History)+2)
+	if req.System != "" {
+		messages = append(messages, openAIMessage{Role: "system", Content: req.System})
+	}
+	for _, m := range req.History {
+		messages = append(messages, openAIMessage{Role: m.Role, Content: m.Content})
+	}
+	messages = append(messages, openAIMessage{Role: "user", Content: req.Prompt})
+
+	var result struct {
+		Prompt string `json:"prompt"`
+	}
+	body := map[string]interface{}{"messages": messages}
+	if err := l.api.do(ctx, l.cfg.PingTimeout, "POST", "/apply-template", body, &result); err != nil {
+		return "", fmt.Errorf("failed to apply chat template: %w", err)
+	}
+	return result.Prompt, nil
+}
+
+// checkModel rejects names other than the configured model's
+func (l *LlamaCpp) checkModel(model string) error {
+	if l.cfg.Model == "" || model == l.cfg.Model {
+		return nil
+	}
+	return &Error{Kind: ErrModelNotFound, Err: fmt.Errorf("model %q not found; this server serves %q", model, l.cfg.Model)}
+}
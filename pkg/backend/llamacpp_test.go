@@ -0,0 +1,209 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// llamaCppServer fakes llama.cpp's server, recording the last completion
+// request. It reports loading until loaded is set.
+func llamaCppServer(t *testing.T, last *map[string]interface{}, loaded *bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			if !*loaded {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprint(w, `{"error": {"code": 503, "message": "Loading model", "type": "unavailable_error"}}`)
+				return
+			}
+			fmt.Fprint(w, `{"status": "ok"}`)
+		case "/slots":
+			fmt.Fprint(w, `[{"id": 0, "is_processing": true}, {"id": 1, "is_processing": false}, {"id": 2, "state": 1}]`)
+		case "/v1/models":
+			fmt.Fprint(w, `{"data": [{"id": "models/llama-3.2-1b.gguf"}]}`)
+		case "/v1/embeddings":
+			fmt.Fprint(w, `{"model": "models/llama-3.2-1b.gguf", "data": [{"index": 0, "embedding": [0.5]}]}`)
+		case "/apply-template":
+			var req struct {
+				Messages []map[string]string `json:"messages"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("invalid request: %v", err)
+			}
+			var prompt strings.Builder
+			for _, m := range req.Messages {
+				fmt.Fprintf(&prompt, "<%s>%s", m["role"], m["content"])
+			}
+			json.NewEncoder(w).Encode(map[string]string{"prompt": prompt.String()})
+		case "/tokenize":
+			// A token per word
+			var req struct {
+				Content string `json:"content"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("invalid request: %v", err)
+			}
+			tokens := make([]int, len(strings.Fields(req.Content)))
+			json.NewEncoder(w).Encode(map[string][]int{"tokens": tokens})
+		case "/completion":
+			var req map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("invalid request: %v", err)
+			}
+			*last = req
+			if req["stream"] == true {
+				for _, word := range []string{"one ", "two"} {
+					fmt.Fprintf(w, "data: {\"content\": %q, \"stop\": false}\n\n", word)
+				}
+				fmt.Fprint(w, "data: {\"content\": \"\", \"stop\": true, \"tokens_predicted\": 2, \"tokens_evaluated\": 6}\n\n")
+				return
+			}
+			fmt.Fprint(w, `{"content": "hello", "stop": true, "tokens_predicted": 1, "tokens_evaluated": 4}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLlamaCpp_Generate(t *testing.T) {
+	var last map[string]interface{}
+	loaded := true
+	server := llamaCppServer(t, &last, &loaded)
+	b := NewLlamaCpp(LlamaCppConfig{URL: server.URL + "/"})
+	ctx := context.Background()
+
+	seed := 7
+	resp, err := b.Generate(ctx, &GenerateRequest{
+		Model:   "llama3.2",
+		Prompt:  "hi",
+		Options: Options{MaxTokens: 10, TopK: 40, RepeatPenalty: 1.1, Seed: &seed},
+		Format:  FormatJSON,
+	})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if resp.Text != "hello" || resp.Model != "llama3.2" || resp.PromptTokens != 4 || resp.CompletionTokens != 1 || !resp.Done {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if last["prompt"] != "hi" || last["n_predict"] != 10.0 || last["top_k"] != 40.0 || last["repeat_penalty"] != 1.1 || last["seed"] != 7.0 {
+		t.Errorf("unexpected request: %v", last)
+	}
+	if schema, ok := last["json_schema"].(map[string]interface{}); !ok || len(schema) != 0 {
+		t.Errorf("expected an empty JSON schema, got %v", last["json_schema"])
+	}
+
+	// Conversations are rendered with the chat template
+	_, err = b.Generate(ctx, &GenerateRequest{
+		Model:   "llama3.2",
+		Prompt:  "and you?",
+		System:  "be brief",
+		History: []Message{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}},
+	})
+	if err != nil {
+		t.Fatalf("generate with history failed: %v", err)
+	}
+	if want := "<system>be brief<user>hi<assistant>hello<user>and you?"; last["prompt"] != want {
+		t.Errorf("expected prompt %q, got %v", want, last["prompt"])
+	}
+}
+
+func TestLlamaCpp_Stream(t *testing.T) {
+	var last map[string]interface{}
+	loaded := true
+	server := llamaCppServer(t, &last, &loaded)
+	b := NewLlamaCpp(LlamaCppConfig{URL: server.URL})
+
+	var text strings.Builder
+	var final *GenerateResponse
+	err := b.Stream(context.Background(), &GenerateRequest{Model: "llama3.2", Prompt: "count"}, func(chunk *GenerateResponse) error {
+		text.WriteString(chunk.Text)
+		final = chunk
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if text.String() != "one two" || !final.Done || final.PromptTokens != 6 || final.CompletionTokens != 2 {
+		t.Errorf("unexpected stream: %q, final %+v", text.String(), final)
+	}
+}
+
+func TestLlamaCpp_Model(t *testing.T) {
+	var last map[string]interface{}
+	loaded := true
+	server := llamaCppServer(t, &last, &loaded)
+	ctx := context.Background()
+
+	models, err := NewLlamaCpp(LlamaCppConfig{URL: server.URL}).ListModels(ctx)
+	if err != nil || len(models) != 1 || models[0].Name != "models/llama-3.2-1b.gguf" {
+		t.Errorf("expected the server's model, got %+v, %v", models, err)
+	}
+
+	b := NewLlamaCpp(LlamaCppConfig{URL: server.URL, Model: "llama3.2"})
+	models, err = b.ListModels(ctx)
+	if err != nil || len(models) != 1 || models[0].Name != "llama3.2" {
+		t.Errorf("expected the configured model, got %+v, %v", models, err)
+	}
+	if _, err := b.Generate(ctx, &GenerateRequest{Model: "mistral", Prompt: "hi"}); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("expected other models not to be found, got %v", err)
+	}
+
+	resp, err := b.Embed(ctx, &EmbedRequest{Model: "llama3.2", Input: []string{"a"}})
+	if err != nil || resp.Model != "llama3.2" || len(resp.Embeddings) != 1 {
+		t.Errorf("unexpected embeddings: %+v, %v", resp, err)
+	}
+}
+
+func TestLlamaCpp_HealthAndSlots(t *testing.T) {
+	var last map[string]interface{}
+	loaded := false
+	server := llamaCppServer(t, &last, &loaded)
+	b := NewLlamaCpp(LlamaCppConfig{URL: server.URL})
+	ctx := context.Background()
+
+	if err := b.Health(ctx); !errors.Is(err, ErrModelLoading) {
+		t.Errorf("expected a loading error, got %v", err)
+	}
+	loaded = true
+	if err := b.Health(ctx); err != nil {
+		t.Errorf("expected healthy backend, got %v", err)
+	}
+
+	slots, err := b.Slots(ctx)
+	if err != nil || slots.Idle != 1 || slots.Processing != 2 {
+		t.Errorf("unexpected slots: %+v, %v", slots, err)
+	}
+
+	_, err = b.Generate(ctx, &GenerateRequest{Model: "llama3.2", Prompt: "hi", Context: []int{1}})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected context to be unsupported, got %v", err)
+	}
+}
+
+func TestLlamaCpp_CountTokens(t *testing.T) {
+	var last map[string]interface{}
+	loaded := true
+	server := llamaCppServer(t, &last, &loaded)
+	b := NewLlamaCpp(LlamaCppConfig{URL: server.URL})
+	ctx := context.Background()
+
+	tokens, err := b.CountTokens(ctx, &GenerateRequest{Model: "llama3.2", Prompt: "how are you"})
+	if err != nil || tokens != 3 {
+		t.Errorf("expected 3 tokens, got %d, %v", tokens, err)
+	}
+
+	// The system prompt is counted as rendered by the chat template
+	tokens, err = b.CountTokens(ctx, &GenerateRequest{Model: "llama3.2", Prompt: "how are you", System: "be brief"})
+	if err != nil || tokens != 4 {
+		t.Errorf("expected 4 tokens, got %d, %v", tokens, err)
+	}
+}
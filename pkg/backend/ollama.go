@@ -0,0 +1,210 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/ollama"
+)
+
+// Ollama is a backend serving requests from Ollama. Requests continuing a
+// conversation use its chat API, others its generate API.
+type Ollama struct {
+	client    ollama.Generator
+	keepAlive *ollama.Duration
+}
+
+var (
+	_ InferenceBackend  = (*Ollama)(nil)
+	_ LoadedModelLister = (*Ollama)(nil)
+)
+
+// NewOllama creates a backend on client. keepAlive is how long Ollama keeps
+// models loaded after a request, or nil for its default.
+func NewOllama(client ollama.Generator, keepAlive *ollama.Duration) *Ollama {
+	return &Ollama{client: client, keepAlive: keepAlive}
+}
+
+// Generate implements InferenceBackend
+func (o *Ollama) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	if len(req.History) == 0 {
+		resp, err := o.client.Generate(ctx, o.generateRequest(req))
+		if err != nil {
+			return nil, OllamaError(err)
+		}
+		return fromGenerate(resp), nil
+	}
+
+	resp, err := o.client.Chat(ctx, o.chatRequest(req))
+	if err != nil {
+		return nil, OllamaError(err)
+	}
+	return fromChat(resp), nil
+}
+
+// Stream implements InferenceBackend
+func (o *Ollama) Stream(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse) error) error {
+	var err error
+	if len(req.History) == 0 {
+		err = o.client.GenerateStream(ctx, o.generateRequest(req), func(chunk *ollama.GenerateResponse) error {
+			return fn(fromGenerate(chunk))
+		})
+	} else {
+		err = o.client.ChatStream(ctx, o.chatRequest(req), func(chunk *ollama.ChatResponse) error {
+			return fn(fromChat(chunk))
+		})
+	}
+	return OllamaError(err)
+}
+
+// Embed implements InferenceBackend
+func (o *Ollama) Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error) {
+	resp, err := o.client.Embed(ctx, &ollama.EmbedRequest{
+		Model:     req.Model,
+		Input:     req.Input,
+		KeepAlive: o.keepAliveFor(req.KeepAlive),
+	})
+	if err != nil {
+		return nil, OllamaError(err)
+	}
+	return &EmbedResponse{
+		Model:        resp.Model,
+		Embeddings:   resp.Embeddings,
+		PromptTokens: resp.PromptEvalCount,
+	}, nil
+}
+
+// ListModels implements InferenceBackend. The ":latest" tag is dropped, as
+// Ollama applies it to names without one.
+func (o *Ollama) ListModels(ctx context.Context) ([]Model, error) {
+	models, err := o.client.ListModels(ctx)
+	if err != nil {
+		return nil, OllamaError(err)
+	}
+	result := make([]Model, len(models))
+	for i, m := range models {
+		result[i] = Model{Name: strings.TrimSuffix(m.Name, ":latest")}
+	}
+	return result, nil
+}
+
+// ListLoaded implements LoadedModelLister
+func (o *Ollama) ListLoaded(ctx context.Context) ([]LoadedModel, error) {
+	running, err := o.client.ListRunning(ctx)
+	if err != nil {
+		return nil, OllamaError(err)
+	}
+	loaded := make([]LoadedModel, len(running))
+	for i, m := range running {
+		loaded[i] = LoadedModel{
+			Name:      strings.TrimSuffix(m.Name, ":latest"),
+			Size:      m.Size,
+			SizeVRAM:  m.SizeVRAM,
+			ExpiresAt: m.ExpiresAt,
+		}
+	}
+	return loaded, nil
+}
+
+// Health implements InferenceBackend
+func (o *Ollama) Health(ctx context.Context) error {
+	return OllamaError(o.client.Ping(ctx))
+}
+
+// generateRequest converts req for Ollama's generate API
+func (o *Ollama) generateRequest(req *GenerateRequest) *ollama.GenerateRequest {
+	return &ollama.GenerateRequest{
+		Model:     req.Model,
+		Prompt:    req.Prompt,
+		System:    req.System,
+		Options:   ollamaOptions(req.Options),
+		Format:    req.Format,
+		KeepAlive: o.keepAliveFor(req.KeepAlive),
+		Context:   req.Context,
+	}
+}
+
+// chatRequest converts req for Ollama's chat API, with the prompt as the
+// newest user message
+func (o *Ollama) chatRequest(req *GenerateRequest) *ollama.ChatRequest {
+	messages := make([]ollama.ChatMessage, 0, len(req.History)+2)
+	if req.System != "" {
+		messages = append(messages, ollama.ChatMessage{Role: ollama.RoleSystem, Content: req.System})
+	}
+	for _, m := range req.History {
+		messages = append(messages, ollama.ChatMessage{Role: m.Role, Content: m.Content})
+	}
+	messages = append(messages, ollama.ChatMessage{Role: ollama.RoleUser, Content: req.Prompt})
+
+	return &ollama.ChatRequest{
+		Model:     req.Model,
+		Messages:  messages,
+		Options:   ollamaOptions(req.Options),
+		Format:    req.Format,
+		KeepAlive: o.keepAliveFor(req.KeepAlive),
+	}
+}
+
+// keepAliveFor returns a request's keep-alive, or the backend's if it has
+// none
+func (o *Ollama) keepAliveFor(keepAlive *time.Duration) *ollama.Duration {
+	if keepAlive == nil {
+		return o.keepAlive
+	}
+	return ollama.NewDuration(*keepAlive)
+}
+
+// ollamaOptions converts generation options to Ollama's
+func ollamaOptions(opts Options) *ollama.GenerateOptions {
+	return &ollama.GenerateOptions{
+		Temperature:   opts.Temperature,
+		NumPredict:    opts.MaxTokens,
+		TopP:          opts.TopP,
+		TopK:          opts.TopK,
+		RepeatPenalty: opts.RepeatPenalty,
+		Seed:          opts.Seed,
+		Stop:          opts.Stop,
+		NumCtx:        opts.NumCtx,
+	}
+}
+
+func fromGenerate(resp *ollama.GenerateResponse) *GenerateResponse {
+	return &GenerateResponse{
+		Model:            resp.Model,
+		Text:             resp.Response,
+		Done:             resp.Done,
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+		Context:          resp.Context,
+	}
+}
+
+func fromChat(resp *ollama.ChatResponse) *GenerateResponse {
+	return &GenerateResponse{
+		Model:            resp.Model,
+		Text:             resp.Message.Content,
+		Done:             resp.Done,
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+	}
+}
+
+// OllamaError tags an error of the Ollama client with its kind, if
+// recognized, for callers using the client directly
+func OllamaError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ollama.ErrModelNotFound):
+		return &Error{Kind: ErrModelNotFound, Err: err}
+	case errors.Is(err, ollama.ErrOutOfMemory):
+		return &Error{Kind: ErrOutOfMemory, Err: err}
+	case errors.Is(err, ollama.ErrModelLoading):
+		return &Error{Kind: ErrModelLoading, Err: err}
+	case errors.Is(err, ollama.ErrContextTooLong):
+		return &Error{Kind: ErrContextTooLong, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/ollama"
+	"github.com/hugovillarreal/neurogate/pkg/ollama/fake"
+)
+
+func TestOllama_Generate(t *testing.T) {
+	client := fake.New("llama3.2")
+	client.SetDefault(fake.Response{Text: "hello there", PromptTokens: 3, CompletionTokens: 2})
+	b := NewOllama(client, nil)
+	ctx := context.Background()
+
+	resp, err := b.Generate(ctx, &GenerateRequest{Model: "llama3.2", Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if resp.Text != "hello there" || resp.PromptTokens != 3 || resp.CompletionTokens != 2 || !resp.Done {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	// Conversations use the chat API
+	_, err = b.Generate(ctx, &GenerateRequest{
+		Model:   "llama3.2",
+		Prompt:  "and you?",
+		History: []Message{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}},
+	})
+	if err != nil {
+		t.Fatalf("generate with history failed: %v", err)
+	}
+	calls := client.Calls()
+	if len(calls) != 2 || calls[0].Method != "Generate" || calls[1].Method != "Chat" || calls[1].Prompt != "and you?" {
+		t.Errorf("unexpected calls: %+v", calls)
+	}
+}
+
+func TestOllama_Stream(t *testing.T) {
+	client := fake.New("llama3.2")
+	client.SetDefault(fake.Response{Text: "one two three", CompletionTokens: 3})
+	b := NewOllama(client, nil)
+
+	var text strings.Builder
+	var last *GenerateResponse
+	err := b.Stream(context.Background(), &GenerateRequest{Model: "llama3.2", Prompt: "count"}, func(chunk *GenerateResponse) error {
+		text.WriteString(chunk.Text)
+		last = chunk
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if strings.TrimSpace(text.String()) != "one two three" || !last.Done || last.CompletionTokens != 3 {
+		t.Errorf("unexpected stream: %q, last %+v", text.String(), last)
+	}
+}
+
+func TestOllama_Errors(t *testing.T) {
+	b := NewOllama(fake.New("llama3.2"), nil)
+
+	_, err := b.Generate(context.Background(), &GenerateRequest{Model: "missing", Prompt: "hi"})
+	if !errors.Is(err, ErrModelNotFound) || !errors.Is(err, ollama.ErrModelNotFound) {
+		t.Errorf("expected a model not found error matching both kinds, got %v", err)
+	}
+
+	err = b.Stream(context.Background(), &GenerateRequest{Model: "missing", Prompt: "hi"}, func(*GenerateResponse) error { return nil })
+	if !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("expected a model not found error from stream, got %v", err)
+	}
+}
+
+func TestOllama_Models(t *testing.T) {
+	client := fake.New("llama3.2:latest", "mistral:7b")
+	expires := time.Now().Add(time.Minute)
+	client.SetRunning(ollama.RunningModel{Name: "llama3.2:latest", Size: 100, SizeVRAM: 80, ExpiresAt: expires})
+	b := NewOllama(client, nil)
+	ctx := context.Background()
+
+	models, err := b.ListModels(ctx)
+	if err != nil || len(models) != 2 || models[0].Name != "llama3.2" || models[1].Name != "mistral:7b" {
+		t.Errorf("unexpected models: %+v, %v", models, err)
+	}
+
+	loaded, err := b.ListLoaded(ctx)
+	if err != nil || len(loaded) != 1 || loaded[0].Name != "llama3.2" || loaded[0].SizeVRAM != 80 || !loaded[0].ExpiresAt.Equal(expires) {
+		t.Errorf("unexpected loaded models: %+v, %v", loaded, err)
+	}
+
+	if err := b.Health(ctx); err != nil {
+		t.Errorf("expected healthy backend, got %v", err)
+	}
+	client.SetPingError(errors.New("connection refused"))
+	if err := b.Health(ctx); err == nil {
+		t.Error("expected health check to fail")
+	}
+}
+
+func TestOllama_Embed(t *testing.T) {
+	b := NewOllama(fake.New("nomic-embed-text"), nil)
+
+	resp, err := b.Embed(context.Background(), &EmbedRequest{Model: "nomic-embed-text", Input: []string{"a", "b"}})
+	if err != nil || len(resp.Embeddings) != 2 || resp.Model != "nomic-embed-text" {
+		t.Errorf("unexpected embeddings: %+v, %v", resp, err)
+	}
+}
+
+func TestOllama_KeepAlive(t *testing.T) {
+	b := NewOllama(fake.New("llama3.2"), ollama.NewDuration(5*time.Minute))
+
+	if got := b.keepAliveFor(nil); got == nil || *got != ollama.Duration(5*time.Minute) {
+		t.Errorf("expected the backend's keep-alive, got %v", got)
+	}
+	hour := time.Hour
+	if got := b.generateRequest(&GenerateRequest{Model: "llama3.2", KeepAlive: &hour}).KeepAlive; got == nil || *got != ollama.Duration(time.Hour) {
+		t.Errorf("expected the request's keep-alive, got %v", got)
+	}
+	forever := time.Duration(-1)
+	if got := b.keepAliveFor(&forever); got == nil || *got >= 0 {
+		t.Errorf("expected forever, got %v", got)
+	}
+}
@@ -0,0 +1,264 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OpenAIConfig configures an OpenAI backend
+type OpenAIConfig struct {
+	URL     string        // API base URL including the version, e.g. http://vllm:8000/v1
+	APIKey  string        // Sent as a bearer token if set
+	Timeout time.Duration // Generate and embed calls, including streaming. Default: 5 minutes
+
+	// Health checks and model listing. Default: 5 seconds
+	PingTimeout time.Duration
+}
+
+// OpenAI is a backend for servers exposing the OpenAI-compatible API, such
+// as vLLM, TGI and llama.cpp's server. Requests use the chat completions
+// API, with the prompt as the newest user message. top_k and
+// repetition_penalty are sent as the extensions these servers accept.
+type OpenAI struct {
+	cfg OpenAIConfig
+	api *apiClient
+}
+
+var _ InferenceBackend = (*OpenAI)(nil)
+
+// NewOpenAI creates a backend for the API at cfg.URL
+func NewOpenAI(cfg OpenAIConfig) *OpenAI {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+	if cfg.PingTimeout <= 0 {
+		cfg.PingTimeout = 5 * time.Second
+	}
+	return &OpenAI{cfg: cfg, api: newAPIClient(cfg.URL, cfg.APIKey)}
+}
+
+type openAIChatRequest struct {
+	Model             string          `json:"model"`
+	Messages          []openAIMessage `json:"messages"`
+	MaxTokens         int             `json:"max_tokens,omitempty"`
+	Temperature       float64         `json:"temperature,omitempty"`
+	TopP              float64         `json:"top_p,omitempty"`
+	TopK              int             `json:"top_k,omitempty"`
+	RepetitionPenalty float64         `json:"repetition_penalty,omitempty"`
+	Seed              *int            `json:"seed,omitempty"`
+	Stop              []string        `json:"stop,omitempty"`
+	ResponseFormat    *openAIFormat   `json:"response_format,omitempty"`
+	Stream            bool            `json:"stream,omitempty"`
+	StreamOptions     map[string]bool `json:"stream_options,omitempty"`
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIFormat struct {
+	Type       string        `json:"type"`
+	JSONSchema *openAISchema `json:"json_schema,omitempty"`
+}
+
+type openAISchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		Delta        openAIMessage `json:"delta"`
+		FinishReason *string       `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// Generate implements InferenceBackend
+func (o *OpenAI) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	body, err := chatCompletion(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result openAIChatResponse
+	if err := o.api.do(ctx, o.cfg.Timeout, "POST", "/chat/completions", body, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("response has no choices")
+	}
+
+	resp := &GenerateResponse{
+		Model: result.Model,
+		Text:  result.Choices[0].Message.Content,
+		Done:  true,
+	}
+	if result.Usage != nil {
+		resp.PromptTokens = result.Usage.PromptTokens
+		resp.CompletionTokens = result.Usage.CompletionTokens
+	}
+	return resp, nil
+}
+
+// Stream implements InferenceBackend. Token counts come from the usage
+// chunk servers send when asked with stream_options; servers that don't
+// report usage get the number of chunks as the completion tokens.
+func (o *OpenAI) Stream(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse) error) error {
+	body, err := chatCompletion(req)
+	if err != nil {
+		return err
+	}
+	body.Stream = true
+	body.StreamOptions = map[string]bool{"include_usage": true}
+
+	resp, err := o.api.send(ctx, o.cfg.Timeout, "POST", "/chat/completions", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	final := &GenerateResponse{Model: req.Model, Done: true}
+	chunks := 0
+	err = readEvents(resp.Body, func(data string) (bool, error) {
+		if data == "[DONE]" {
+			return true, nil
+		}
+
+		var chunk openAIChatResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.Model != "" {
+			final.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			final.PromptTokens = chunk.Usage.PromptTokens
+			final.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			return false, nil
+		}
+		chunks++
+		return false, fn(&GenerateResponse{Model: final.Model, Text: chunk.Choices[0].Delta.Content})
+	})
+	if err != nil {
+		return err
+	}
+
+	if final.CompletionTokens == 0 {
+		final.CompletionTokens = chunks
+	}
+	return fn(final)
+}
+
+type openAIEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAIEmbedResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// Embed implements InferenceBackend
+func (o *OpenAI) Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error) {
+	var result openAIEmbedResponse
+	err := o.api.do(ctx, o.cfg.Timeout, "POST", "/embeddings", openAIEmbedRequest{Model: req.Model, Input: req.Input}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	embeddings := make([][]float32, len(req.Input))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(embeddings) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	resp := &EmbedResponse{Model: result.Model, Embeddings: embeddings}
+	if result.Usage != nil {
+		resp.PromptTokens = result.Usage.PromptTokens
+	}
+	return resp, nil
+}
+
+// ListModels implements InferenceBackend
+func (o *OpenAI) ListModels(ctx context.Context) ([]Model, error) {
+	var result struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := o.api.do(ctx, o.cfg.PingTimeout, "GET", "/models", nil, &result); err != nil {
+		return nil, err
+	}
+
+	models := make([]Model, len(result.Data))
+	for i, m := range result.Data {
+		models[i] = Model{Name: m.ID}
+	}
+	return models, nil
+}
+
+// Health implements InferenceBackend. The model list is the one endpoint
+// every compatible server has.
+func (o *OpenAI) Health(ctx context.Context) error {
+	_, err := o.ListModels(ctx)
+	return err
+}
+
+// chatCompletion converts req to a chat completion request
+func chatCompletion(req *GenerateRequest) (*openAIChatRequest, error) {
+	if len(req.Context) > 0 {
+		return nil, errContextUnsupported
+	}
+
+	messages := make([]openAIMessage, 0, len(req.History)+2)
+	if req.System != "" {
+		messages = append(messages, openAIMessage{Role: "system", Content: req.System})
+	}
+	for _, m := range req.History {
+		messages = append(messages, openAIMessage{Role: m.Role, Content: m.Content})
+	}
+	messages = append(messages, openAIMessage{Role: "user", Content: req.Prompt})
+
+	body := &openAIChatRequest{
+		Model:             req.Model,
+		Messages:          messages,
+		MaxTokens:         req.Options.MaxTokens,
+		Temperature:       req.Options.Temperature,
+		TopP:              req.Options.TopP,
+		TopK:              req.Options.TopK,
+		RepetitionPenalty: req.Options.RepeatPenalty,
+		Seed:              req.Options.Seed,
+		Stop:              req.Options.Stop,
+	}
+	switch {
+	case req.Format == nil:
+	case bytes.Equal(req.Format, FormatJSON):
+		body.ResponseFormat = &openAIFormat{Type: "json_object"}
+	default:
+		body.ResponseFormat = &openAIFormat{
+			Type:       "json_schema",
+			JSONSchema: &openAISchema{Name: "response", Schema: req.Format},
+		}
+	}
+	return body, nil
+}
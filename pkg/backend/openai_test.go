@@ -0,0 +1,208 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// openAIServer fakes the chat completion, embedding and model APIs,
+// recording the last chat request
+func openAIServer(t *testing.T, last *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/models":
+			fmt.Fprint(w, `{"data": [{"id": "meta-llama/Llama-3.1-8B-Instruct"}]}`)
+		case "/v1/embeddings":
+			fmt.Fprint(w, `{"model": "bge", "data": [{"index": 1, "embedding": [0.2]}, {"index": 0, "embedding": [0.1]}], "usage": {"prompt_tokens": 4}}`)
+		case "/v1/chat/completions":
+			var req map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("invalid request: %v", err)
+			}
+			*last = req
+			if req["model"] == "missing" {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error": {"message": "The model missing does not exist."}}`)
+				return
+			}
+			if req["stream"] == true {
+				for _, word := range []string{"one ", "two"} {
+					fmt.Fprintf(w, "data: {\"model\": \"llama\", \"choices\": [{\"delta\": {\"content\": %q}}]}\n\n", word)
+				}
+				fmt.Fprint(w, "data: {\"model\": \"llama\", \"choices\": [], \"usage\": {\"prompt_tokens\": 5, \"completion_tokens\": 2}}\n\n")
+				fmt.Fprint(w, "data: [DONE]\n\n")
+				return
+			}
+			fmt.Fprint(w, `{"model": "llama", "choices": [{"message": {"role": "assistant", "content": "hello"}}], "usage": {"prompt_tokens": 7, "completion_tokens": 1}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAI_Generate(t *testing.T) {
+	var last map[string]interface{}
+	server := openAIServer(t, &last)
+	b := NewOpenAI(OpenAIConfig{URL: server.URL + "/v1/", APIKey: "secret"})
+
+	seed := 42
+	resp, err := b.Generate(context.Background(), &GenerateRequest{
+		Model:   "llama",
+		Prompt:  "and you?",
+		System:  "be brief",
+		History: []Message{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}},
+		Options: Options{MaxTokens: 10, Temperature: 0.5, TopK: 40, Seed: &seed, Stop: []string{"\n"}},
+		Format:  FormatJSON,
+	})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if resp.Text != "hello" || resp.PromptTokens != 7 || resp.CompletionTokens != 1 || !resp.Done {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	messages, _ := last["messages"].([]interface{})
+	if len(messages) != 4 {
+		t.Fatalf("expected system, history and prompt messages, got %v", last["messages"])
+	}
+	if first := messages[0].(map[string]interface{}); first["role"] != "system" || first["content"] != "be brief" {
+		t.Errorf("unexpected system message: %v", first)
+	}
+	if newest := messages[3].(map[string]interface{}); newest["role"] != "user" || newest["content"] != "and you?" {
+		t.Errorf("unexpected newest message: %v", newest)
+	}
+	if last["max_tokens"] != 10.0 || last["temperature"] != 0.5 || last["top_k"] != 40.0 || last["seed"] != 42.0 {
+		t.Errorf("unexpected options: %v", last)
+	}
+	if format, _ := last["response_format"].(map[string]interface{}); format["type"] != "json_object" {
+		t.Errorf("expected a JSON object response format, got %v", last["response_format"])
+	}
+}
+
+func TestOpenAI_SchemaFormat(t *testing.T) {
+	var last map[string]interface{}
+	server := openAIServer(t, &last)
+	b := NewOpenAI(OpenAIConfig{URL: server.URL + "/v1", APIKey: "secret"})
+
+	schema := json.RawMessage(`{"type": "object"}`)
+	if _, err := b.Generate(context.Background(), &GenerateRequest{Model: "llama", Prompt: "hi", Format: schema}); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	format, _ := last["response_format"].(map[string]interface{})
+	jsonSchema, _ := format["json_schema"].(map[string]interface{})
+	if format["type"] != "json_schema" || jsonSchema["schema"] == nil {
+		t.Errorf("expected a JSON schema response format, got %v", last["response_format"])
+	}
+}
+
+func TestOpenAI_Stream(t *testing.T) {
+	var last map[string]interface{}
+	server := openAIServer(t, &last)
+	b := NewOpenAI(OpenAIConfig{URL: server.URL + "/v1", APIKey: "secret"})
+
+	var text strings.Builder
+	var final *GenerateResponse
+	err := b.Stream(context.Background(), &GenerateRequest{Model: "llama", Prompt: "count"}, func(chunk *GenerateResponse) error {
+		text.WriteString(chunk.Text)
+		final = chunk
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if text.String() != "one two" || !final.Done || final.PromptTokens != 5 || final.CompletionTokens != 2 {
+		t.Errorf("unexpected stream: %q, final %+v", text.String(), final)
+	}
+	if options, _ := last["stream_options"].(map[string]interface{}); options["include_usage"] != true {
+		t.Errorf("expected usage to be requested, got %v", last["stream_options"])
+	}
+}
+
+func TestOpenAI_Errors(t *testing.T) {
+	var last map[string]interface{}
+	server := openAIServer(t, &last)
+	b := NewOpenAI(OpenAIConfig{URL: server.URL + "/v1", APIKey: "secret"})
+	ctx := context.Background()
+
+	_, err := b.Generate(ctx, &GenerateRequest{Model: "missing", Prompt: "hi"})
+	if !errors.Is(err, ErrModelNotFound) || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected a model not found error, got %v", err)
+	}
+
+	err = b.Stream(ctx, &GenerateRequest{Model: "missing", Prompt: "hi"}, func(*GenerateResponse) error { return nil })
+	if !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("expected a model not found error from stream, got %v", err)
+	}
+
+	_, err = b.Generate(ctx, &GenerateRequest{Model: "llama", Prompt: "hi", Context: []int{1, 2}})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected context to be unsupported, got %v", err)
+	}
+
+	unauthorized := NewOpenAI(OpenAIConfig{URL: server.URL + "/v1"})
+	if err := unauthorized.Health(ctx); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected an unauthorized health check, got %v", err)
+	}
+}
+
+func TestOpenAI_ModelsAndEmbed(t *testing.T) {
+	var last map[string]interface{}
+	server := openAIServer(t, &last)
+	b := NewOpenAI(OpenAIConfig{URL: server.URL + "/v1", APIKey: "secret"})
+	ctx := context.Background()
+
+	models, err := b.ListModels(ctx)
+	if err != nil || len(models) != 1 || models[0].Name != "meta-llama/Llama-3.1-8B-Instruct" {
+		t.Errorf("unexpected models: %+v, %v", models, err)
+	}
+	if err := b.Health(ctx); err != nil {
+		t.Errorf("expected healthy backend, got %v", err)
+	}
+
+	resp, err := b.Embed(ctx, &EmbedRequest{Model: "bge", Input: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	if len(resp.Embeddings) != 2 || resp.Embeddings[0][0] != 0.1 || resp.Embeddings[1][0] != 0.2 || resp.PromptTokens != 4 {
+		t.Errorf("expected embeddings in input order, got %+v", resp)
+	}
+}
+
+func TestAPIError_Kinds(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"not found", http.StatusNotFound, `{"error":{"message":"The model does not exist"}}`, ErrModelNotFound},
+		{"loading", http.StatusServiceUnavailable, `{"error":{"message":"Loading model"}}`, ErrModelLoading},
+		{"out of memory", http.StatusInternalServerError, `{"message":"CUDA out of memory"}`, ErrOutOfMemory},
+		{"llama.cpp context", http.StatusBadRequest, `{"error":{"message":"the request exceeds the available context size, try increasing it"}}`, ErrContextTooLong},
+		{"vLLM context", http.StatusBadRequest, `{"message":"This model's maximum context length is 4096 tokens. However, you requested 5000 tokens"}`, ErrContextTooLong},
+		{"other", http.StatusBadRequest, `{"error":{"message":"invalid temperature"}}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := apiError(tt.status, []byte(tt.body))
+			for _, kind := range []error{ErrModelNotFound, ErrModelLoading, ErrOutOfMemory, ErrContextTooLong} {
+				if errors.Is(err, kind) != (kind == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", err, kind, !(kind == tt.want))
+				}
+			}
+		})
+	}
+}
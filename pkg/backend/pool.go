@@ -0,0 +1,275 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+)
+
+// Dispatch strategies of a Pool
+const (
+	DispatchLeastBusy  = "least_busy"  // The instance with the fewest requests in flight
+	DispatchRoundRobin = "round_robin" // Each instance in turn
+)
+
+// Instance is a backend in a Pool
+type Instance struct {
+	Name    string // Identifies the instance in logs and metrics, e.g. its URL
+	Backend InferenceBackend
+}
+
+// InstanceStatus is an instance's state as of the pool's last health check
+type InstanceStatus struct {
+	Name     string
+	Healthy  bool
+	Inflight int
+	Err      error // Why the instance is unhealthy
+}
+
+// Pool serves requests from several backends of the same models, such as one
+// Ollama per GPU on a single machine. Each request goes to one healthy
+// instance; instances are marked unhealthy by a failed health check or a
+// request that couldn't reach them, and healthy again by a passing check.
+type Pool struct {
+	instances []*poolInstance
+	dispatch  string
+	next      atomic.Uint64
+}
+
+type poolInstance struct {
+	Instance
+	inflight atomic.Int32
+
+	mu      sync.Mutex
+	healthy bool
+	err     error
+}
+
+var (
+	_ InferenceBackend  = (*Pool)(nil)
+	_ LoadedModelLister = (*Pool)(nil)
+)
+
+// NewPool creates a pool of instances dispatching requests with dispatch,
+// DispatchLeastBusy if empty. Instances start healthy.
+func NewPool(dispatch string, instances ...Instance) (*Pool, error) {
+	switch dispatch {
+	case "":
+		dispatch = DispatchLeastBusy
+	case DispatchLeastBusy, DispatchRoundRobin:
+	default:
+		return nil, fmt.Errorf("unknown dispatch strategy %q", dispatch)
+	}
+	if len(instances) == 0 {
+		return nil, errors.New("a pool needs at least one instance")
+	}
+
+	p := &Pool{dispatch: dispatch}
+	for _, inst := range instances {
+		p.instances = append(p.instances, &poolInstance{Instance: inst, healthy: true})
+	}
+	return p, nil
+}
+
+// Generate implements InferenceBackend
+func (p *Pool) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	inst, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer inst.inflight.Add(-1)
+
+	resp, err := inst.Backend.Generate(ctx, req)
+	inst.observe(ctx, err)
+	return resp, err
+}
+
+// Stream implements InferenceBackend
+func (p *Pool) Stream(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse) error) error {
+	inst, err := p.acquire()
+	if err != nil {
+		return err
+	}
+	defer inst.inflight.Add(-1)
+
+	err = inst.Backend.Stream(ctx, req, fn)
+	inst.observe(ctx, err)
+	return err
+}
+
+// Embed implements InferenceBackend
+func (p *Pool) Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error) {
+	inst, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer inst.inflight.Add(-1)
+
+	resp, err := inst.Backend.Embed(ctx, req)
+	inst.observe(ctx, err)
+	return resp, err
+}
+
+// ListModels implements InferenceBackend, listing the models of every
+// healthy instance once
+func (p *Pool) ListModels(ctx context.Context) ([]Model, error) {
+	var models []Model
+	seen := make(map[string]bool)
+	listed := false
+	var lastErr error
+	for _, inst := range p.healthy() {
+		list, err := inst.Backend.ListModels(ctx)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		listed = true
+		for _, m := range list {
+			if !seen[m.Name] {
+				seen[m.Name] = true
+				models = append(models, m)
+			}
+		}
+	}
+	if !listed {
+		return nil, p.unavailable(lastErr)
+	}
+	return models, nil
+}
+
+// ListLoaded implements LoadedModelLister, listing the models loaded on
+// every healthy instance that reports them. A model loaded on several
+// instances is listed once per instance.
+func (p *Pool) ListLoaded(ctx context.Context) ([]LoadedModel, error) {
+	var loaded []LoadedModel
+	var lastErr error
+	for _, inst := range p.healthy() {
+		lister, ok := inst.Backend.(LoadedModelLister)
+		if !ok {
+			continue
+		}
+		list, err := lister.ListLoaded(ctx)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		loaded = append(loaded, list...)
+	}
+	if loaded == nil && lastErr != nil {
+		return nil, lastErr
+	}
+	return loaded, nil
+}
+
+// Health implements InferenceBackend. It checks every instance, updating
+// which are healthy, and fails only if none is.
+func (p *Pool) Health(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, inst := range p.instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inst.setHealth(inst.Backend.Health(ctx))
+		}()
+	}
+	wg.Wait()
+
+	if len(p.healthy()) == 0 {
+		return p.unavailable(nil)
+	}
+	return nil
+}
+
+// Instances returns the state of each instance, in configuration order
+func (p *Pool) Instances() []InstanceStatus {
+	statuses := make([]InstanceStatus, len(p.instances))
+	for i, inst := range p.instances {
+		inst.mu.Lock()
+		statuses[i] = InstanceStatus{
+			Name:     inst.Name,
+			Healthy:  inst.healthy,
+			Inflight: int(inst.inflight.Load()),
+			Err:      inst.err,
+		}
+		inst.mu.Unlock()
+	}
+	return statuses
+}
+
+// acquire picks a healthy instance by the pool's dispatch strategy and
+// counts a request in flight on it
+func (p *Pool) acquire() (*poolInstance, error) {
+	healthy := p.healthy()
+	if len(healthy) == 0 {
+		return nil, p.unavailable(nil)
+	}
+
+	start := int(p.next.Add(1) % uint64(len(healthy)))
+	inst := healthy[start]
+	if p.dispatch == DispatchLeastBusy {
+		// Ties go to the instance after the last one picked, spreading
+		// requests on an idle pool
+		for i := 1; i < len(healthy); i++ {
+			candidate := healthy[(start+i)%len(healthy)]
+			if candidate.inflight.Load() < inst.inflight.Load() {
+				inst = candidate
+			}
+		}
+	}
+	inst.inflight.Add(1)
+	return inst, nil
+}
+
+// healthy returns the instances currently marked healthy
+func (p *Pool) healthy() []*poolInstance {
+	healthy := make([]*poolInstance, 0, len(p.instances))
+	for _, inst := range p.instances {
+		inst.mu.Lock()
+		if inst.healthy {
+			healthy = append(healthy, inst)
+		}
+		inst.mu.Unlock()
+	}
+	return healthy
+}
+
+// unavailable returns the error for a pool without a usable instance,
+// naming the first instance's failure
+func (p *Pool) unavailable(err error) error {
+	if err == nil {
+		for _, inst := range p.instances {
+			inst.mu.Lock()
+			err = inst.err
+			inst.mu.Unlock()
+			if err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		return errors.New("no healthy backend instance")
+	}
+	return fmt.Errorf("no healthy backend instance: %w", err)
+}
+
+// setHealth records a health check result
+func (inst *poolInstance) setHealth(err error) {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	inst.healthy = err == nil
+	inst.err = err
+}
+
+// observe marks the instance unhealthy if a request failed to reach it,
+// until a health check passes again. Errors of requests the caller canceled
+// are ignored.
+func (inst *poolInstance) observe(ctx context.Context, err error) {
+	var urlErr *url.Error
+	if err == nil || ctx.Err() != nil || !errors.As(err, &urlErr) {
+		return
+	}
+	inst.setHealth(err)
+}
@@ -0,0 +1,166 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/hugovillarreal/neurogate/pkg/ollama/fake"
+)
+
+// unreachable is a backend whose requests fail to connect
+type unreachable struct{ InferenceBackend }
+
+func (unreachable) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	return nil, &url.Error{Op: "Post", URL: "http://gpu1", Err: errors.New("connection refused")}
+}
+
+func (unreachable) Health(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func newTestPool(t *testing.T, dispatch string, clients ...*fake.Client) *Pool {
+	t.Helper()
+	instances := make([]Instance, len(clients))
+	for i, c := range clients {
+		instances[i] = Instance{Name: string(rune('a' + i)), Backend: NewOllama(c, nil)}
+	}
+	p, err := NewPool(dispatch, instances...)
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	return p
+}
+
+func TestPool_RoundRobin(t *testing.T) {
+	a, b := fake.New("llama3.2"), fake.New("llama3.2")
+	p := newTestPool(t, DispatchRoundRobin, a, b)
+
+	for range 4 {
+		if _, err := p.Generate(context.Background(), &GenerateRequest{Model: "llama3.2", Prompt: "hi"}); err != nil {
+			t.Fatalf("generate failed: %v", err)
+		}
+	}
+	if len(a.Calls()) != 2 || len(b.Calls()) != 2 {
+		t.Errorf("expected requests spread evenly, got %d and %d", len(a.Calls()), len(b.Calls()))
+	}
+}
+
+func TestPool_LeastBusy(t *testing.T) {
+	a, b := fake.New("llama3.2"), fake.New("llama3.2")
+	p := newTestPool(t, DispatchLeastBusy, a, b)
+	ctx := context.Background()
+
+	// Hold a stream open on one instance; requests meanwhile go to the other
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- p.Stream(ctx, &GenerateRequest{Model: "llama3.2", Prompt: "long"}, func(*GenerateResponse) error {
+			select {
+			case <-started:
+			default:
+				close(started)
+				<-release
+			}
+			return nil
+		})
+	}()
+	<-started
+
+	busy, idle := a, b
+	if len(b.Calls()) == 1 {
+		busy, idle = b, a
+	}
+	for range 3 {
+		if _, err := p.Generate(ctx, &GenerateRequest{Model: "llama3.2", Prompt: "hi"}); err != nil {
+			t.Fatalf("generate failed: %v", err)
+		}
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if len(busy.Calls()) != 1 || len(idle.Calls()) != 3 {
+		t.Errorf("expected requests on the idle instance, got %d busy and %d idle", len(busy.Calls()), len(idle.Calls()))
+	}
+}
+
+func TestPool_Health(t *testing.T) {
+	a, b := fake.New("llama3.2"), fake.New("llama3.2")
+	p := newTestPool(t, DispatchRoundRobin, a, b)
+	ctx := context.Background()
+
+	a.SetPingError(errors.New("connection refused"))
+	if err := p.Health(ctx); err != nil {
+		t.Fatalf("expected the pool healthy with one instance up: %v", err)
+	}
+	for range 2 {
+		if _, err := p.Generate(ctx, &GenerateRequest{Model: "llama3.2", Prompt: "hi"}); err != nil {
+			t.Fatalf("generate failed: %v", err)
+		}
+	}
+	if len(a.Calls()) != 0 || len(b.Calls()) != 2 {
+		t.Errorf("expected requests only on the healthy instance, got %d and %d", len(a.Calls()), len(b.Calls()))
+	}
+	if st := p.Instances(); st[0].Healthy || st[0].Err == nil || !st[1].Healthy {
+		t.Errorf("unexpected instance states: %+v", st)
+	}
+
+	b.SetPingError(errors.New("connection refused"))
+	if err := p.Health(ctx); err == nil {
+		t.Error("expected the pool unhealthy with every instance down")
+	}
+	if _, err := p.Generate(ctx, &GenerateRequest{Model: "llama3.2", Prompt: "hi"}); err == nil {
+		t.Error("expected generate to fail without a healthy instance")
+	}
+
+	a.SetPingError(nil)
+	if err := p.Health(ctx); err != nil {
+		t.Errorf("expected the pool healthy again: %v", err)
+	}
+}
+
+func TestPool_UnreachableInstance(t *testing.T) {
+	b := fake.New("llama3.2")
+	p, err := NewPool(DispatchRoundRobin,
+		Instance{Name: "a", Backend: unreachable{}},
+		Instance{Name: "b", Backend: NewOllama(b, nil)},
+	)
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	ctx := context.Background()
+
+	// A request that can't reach its instance marks it unhealthy
+	failed := 0
+	for range 4 {
+		if _, err := p.Generate(ctx, &GenerateRequest{Model: "llama3.2", Prompt: "hi"}); err != nil {
+			failed++
+		}
+	}
+	if failed != 1 || len(b.Calls()) != 3 {
+		t.Errorf("expected one failure then requests on the reachable instance, got %d failures and %d calls", failed, len(b.Calls()))
+	}
+}
+
+func TestPool_ListModels(t *testing.T) {
+	p := newTestPool(t, "", fake.New("llama3.2", "mistral"), fake.New("llama3.2", "qwen2.5"))
+
+	models, err := p.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(models) != 3 {
+		t.Errorf("expected each model once, got %+v", models)
+	}
+}
+
+func TestNewPool_Invalid(t *testing.T) {
+	if _, err := NewPool("random", Instance{Name: "a", Backend: NewOllama(fake.New(), nil)}); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+	if _, err := NewPool(""); err == nil {
+		t.Error("expected an error without instances")
+	}
+}
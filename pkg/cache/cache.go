@@ -0,0 +1,211 @@
+// Package cache provides exact-match and semantic response caches with TTL
+// expiry, kept in memory or in Redis to share them between gateway replicas
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+)
+
+// Key identifies a cacheable generation request
+type Key struct {
+	Model        string
+	Prompt       string
+	SystemPrompt string
+	Temperature  float32
+	MaxTokens    int32
+	Options      string // Other sampling parameters, encoded by the caller
+	Format       string // Output constraint: "json", a JSON schema, or empty
+}
+
+// Hash returns a stable digest of the key suitable for map lookups
+func (k Key) Hash() string {
+	h := sha256.New()
+	for _, s := range []string{k.Model, k.Prompt, k.SystemPrompt, k.Options, k.Format} {
+		// Length-prefix each field so ("ab", "c") and ("a", "bc") differ
+		binary.Write(h, binary.BigEndian, uint64(len(s)))
+		h.Write([]byte(s))
+	}
+	binary.Write(h, binary.BigEndian, math.Float32bits(k.Temperature))
+	binary.Write(h, binary.BigEndian, k.MaxTokens)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Response is a cached generation result
+type Response struct {
+	Text             string    `json:"text"`
+	Model            string    `json:"model"`
+	PromptTokens     int32     `json:"prompt_tokens"`
+	CompletionTokens int32     `json:"completion_tokens"`
+	TotalTokens      int32     `json:"total_tokens"`
+	CreatedAt        time.Time `json:"created_at"`
+
+	// Parameters the response was generated with, encoded by the caller
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+}
+
+// size is the part of a response counted against MaxEntryBytes
+func (r *Response) size() int {
+	return len(r.Text) + len(r.Parameters)
+}
+
+// Store is an exact-match response cache
+type Store interface {
+	// Get returns the cached response for key if present and not expired
+	Get(key Key) (*Response, bool)
+	// GetStale also returns expired responses within the stale TTL,
+	// reporting whether the response had expired
+	GetStale(key Key) (resp *Response, stale bool, ok bool)
+	// Set stores a response under key
+	Set(key Key, resp *Response)
+}
+
+// Config holds cache configuration
+type Config struct {
+	TTL           time.Duration // How long entries stay fresh. Default: 5 minutes
+	MaxEntries    int           // Maximum number of entries before eviction. Default: 1000
+	MaxEntryBytes int           // Responses with more text and parameters aren't cached. Default: unlimited
+	StaleTTL      time.Duration // How long expired entries remain available to GetStale. Default: 0
+	OnError       func(error)   // Called when a Redis cache fails; the lookup is a miss
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 5 * time.Minute
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 1000
+	}
+	return cfg
+}
+
+// Cache is a concurrency-safe in-memory LRU Store with per-entry TTL
+type Cache struct {
+	mu            sync.Mutex
+	ttl           time.Duration
+	staleTTL      time.Duration
+	maxEntries    int
+	maxEntryBytes int
+	entries       map[string]*list.Element
+	lru           *list.List
+}
+
+type entry struct {
+	key       string
+	response  *Response
+	expiresAt time.Time
+}
+
+// New creates a new response cache
+func New(cfg Config) *Cache {
+	cfg = cfg.withDefaults()
+	return &Cache{
+		ttl:           cfg.TTL,
+		staleTTL:      cfg.StaleTTL,
+		maxEntries:    cfg.MaxEntries,
+		maxEntryBytes: cfg.MaxEntryBytes,
+		entries:       make(map[string]*list.Element),
+		lru:           list.New(),
+	}
+}
+
+// Get returns the cached response for key if present and not expired
+func (c *Cache) Get(key Key) (*Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key.Hash()]
+	if !ok {
+		return nil, false
+	}
+
+	e := elem.Value.(*entry)
+	now := time.Now()
+	if now.After(e.expiresAt) {
+		// Keep expired entries around while they may still be served stale
+		if now.After(e.expiresAt.Add(c.staleTTL)) {
+			c.removeElement(elem)
+		}
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	return e.response, true
+}
+
+// GetStale returns the cached response for key even if it has expired, as
+// long as it expired less than StaleTTL ago. The boolean result reports
+// whether an entry was found; stale reports whether it had expired.
+func (c *Cache) GetStale(key Key) (resp *Response, stale bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, found := c.entries[key.Hash()]
+	if !found {
+		return nil, false, false
+	}
+
+	e := elem.Value.(*entry)
+	now := time.Now()
+	if now.After(e.expiresAt.Add(c.staleTTL)) {
+		c.removeElement(elem)
+		return nil, false, false
+	}
+
+	return e.response, now.After(e.expiresAt), true
+}
+
+// Set stores a response under key, evicting the least recently used entry if full
+func (c *Cache) Set(key Key, resp *Response) {
+	if c.maxEntryBytes > 0 && resp.size() > c.maxEntryBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hash := key.Hash()
+	expiresAt := time.Now().Add(c.ttl)
+
+	if elem, ok := c.entries[hash]; ok {
+		e := elem.Value.(*entry)
+		e.response = resp
+		e.expiresAt = expiresAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	elem := c.lru.PushFront(&entry{key: hash, response: resp, expiresAt: expiresAt})
+	c.entries[hash] = elem
+
+	for c.lru.Len() > c.maxEntries {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// Len returns the number of entries currently held, including expired ones not yet evicted
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Purge removes all entries
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+func (c *Cache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*entry).key)
+}
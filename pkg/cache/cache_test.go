@@ -0,0 +1,272 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+	"github.com/hugovillarreal/neurogate/pkg/redis/redistest"
+)
+
+func TestCache_GetMiss(t *testing.T) {
+	c := New(Config{})
+
+	if _, ok := c.Get(Key{Model: "llama3.2", Prompt: "hello"}); ok {
+		t.Error("expected miss on empty cache")
+	}
+}
+
+func TestCache_SetAndGet(t *testing.T) {
+	c := New(Config{TTL: time.Minute})
+	key := Key{Model: "llama3.2", Prompt: "hello", Temperature: 0.7, MaxTokens: 100}
+
+	c.Set(key, &Response{Text: "hi there", Model: "llama3.2", TotalTokens: 5})
+
+	resp, ok := c.Get(key)
+	if !ok {
+		t.Fatal("expected hit after set")
+	}
+	if resp.Text != "hi there" {
+		t.Errorf("expected cached text %q, got %q", "hi there", resp.Text)
+	}
+}
+
+func TestCache_KeyFieldsDistinguishEntries(t *testing.T) {
+	c := New(Config{TTL: time.Minute})
+	base := Key{Model: "llama3.2", Prompt: "hello", SystemPrompt: "be nice", Temperature: 0.7, MaxTokens: 100}
+	c.Set(base, &Response{Text: "base"})
+
+	variants := []Key{
+		{Model: "mistral", Prompt: "hello", SystemPrompt: "be nice", Temperature: 0.7, MaxTokens: 100},
+		{Model: "llama3.2", Prompt: "hello!", SystemPrompt: "be nice", Temperature: 0.7, MaxTokens: 100},
+		{Model: "llama3.2", Prompt: "hello", SystemPrompt: "be mean", Temperature: 0.7, MaxTokens: 100},
+		{Model: "llama3.2", Prompt: "hello", SystemPrompt: "be nice", Temperature: 0.8, MaxTokens: 100},
+		{Model: "llama3.2", Prompt: "hello", SystemPrompt: "be nice", Temperature: 0.7, MaxTokens: 200},
+		{Model: "llama3.2", Prompt: "hellob", SystemPrompt: "e nice", Temperature: 0.7, MaxTokens: 100},
+		{Model: "llama3.2", Prompt: "hello", SystemPrompt: "be nice", Temperature: 0.7, MaxTokens: 100, Format: "json"},
+		{Model: "llama3.2", Prompt: "hello", SystemPrompt: "be nice", Temperature: 0.7, MaxTokens: 100, Options: `{"seed":1}`},
+	}
+
+	for _, k := range variants {
+		if _, ok := c.Get(k); ok {
+			t.Errorf("expected miss for %+v", k)
+		}
+	}
+}
+
+func TestCache_ExpiresAfterTTL(t *testing.T) {
+	c := New(Config{TTL: 50 * time.Millisecond})
+	key := Key{Model: "llama3.2", Prompt: "hello"}
+	c.Set(key, &Response{Text: "hi"})
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, ok := c.Get(key); ok {
+		t.Error("expected entry to expire after TTL")
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected expired entry to be removed, got len %d", c.Len())
+	}
+}
+
+func TestCache_GetStale(t *testing.T) {
+	c := New(Config{TTL: 50 * time.Millisecond, StaleTTL: 100 * time.Millisecond})
+	key := Key{Model: "llama3.2", Prompt: "hello"}
+	c.Set(key, &Response{Text: "hi"})
+
+	resp, stale, ok := c.GetStale(key)
+	if !ok || stale || resp.Text != "hi" {
+		t.Errorf("expected fresh entry, got ok=%v stale=%v", ok, stale)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, ok := c.Get(key); ok {
+		t.Error("expected Get to miss after TTL")
+	}
+	resp, stale, ok = c.GetStale(key)
+	if !ok || !stale || resp.Text != "hi" {
+		t.Errorf("expected stale entry within StaleTTL, got ok=%v stale=%v", ok, stale)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if _, _, ok := c.GetStale(key); ok {
+		t.Error("expected entry to be gone after StaleTTL")
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected entry to be removed, got len %d", c.Len())
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := New(Config{TTL: time.Minute, MaxEntries: 2})
+	a := Key{Prompt: "a"}
+	b := Key{Prompt: "b"}
+	d := Key{Prompt: "d"}
+
+	c.Set(a, &Response{Text: "a"})
+	c.Set(b, &Response{Text: "b"})
+	c.Get(a) // a is now most recently used
+	c.Set(d, &Response{Text: "d"})
+
+	if _, ok := c.Get(b); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := c.Get(a); !ok {
+		t.Error("expected a to survive eviction")
+	}
+	if _, ok := c.Get(d); !ok {
+		t.Error("expected d to be present")
+	}
+}
+
+func TestCache_Purge(t *testing.T) {
+	c := New(Config{})
+	c.Set(Key{Prompt: "a"}, &Response{Text: "a"})
+	c.Purge()
+
+	if c.Len() != 0 {
+		t.Errorf("expected empty cache after purge, got %d", c.Len())
+	}
+}
+
+func TestCache_ConcurrentAccess(t *testing.T) {
+	c := New(Config{MaxEntries: 10})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := Key{Prompt: string(rune('a' + i%26))}
+			c.Set(key, &Response{Text: "x"})
+			c.Get(key)
+			c.Len()
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func TestCache_SkipsLargeEntries(t *testing.T) {
+	c := New(Config{MaxEntryBytes: 5})
+
+	c.Set(Key{Prompt: "short"}, &Response{Text: "hi"})
+	c.Set(Key{Prompt: "long"}, &Response{Text: "a longer answer"})
+
+	if _, ok := c.Get(Key{Prompt: "short"}); !ok {
+		t.Error("expected small entry to be cached")
+	}
+	if _, ok := c.Get(Key{Prompt: "long"}); ok {
+		t.Error("expected entry over MaxEntryBytes to be skipped")
+	}
+}
+
+func TestRedis_SharedBetweenReplicas(t *testing.T) {
+	srv := redistest.NewServer(t)
+	replica := func() *Redis {
+		return NewRedis(redis.New(redis.Config{Addr: srv.Addr()}), Config{TTL: time.Minute})
+	}
+	c1, c2 := replica(), replica()
+	key := Key{Model: "llama3.2", Prompt: "hello"}
+
+	if _, ok := c2.Get(key); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+	c1.Set(key, &Response{Text: "hi there", Model: "llama3.2", TotalTokens: 5})
+
+	resp, ok := c2.Get(key)
+	if !ok {
+		t.Fatal("expected hit on the other replica")
+	}
+	if resp.Text != "hi there" || resp.TotalTokens != 5 {
+		t.Errorf("unexpected cached response %+v", resp)
+	}
+}
+
+func TestRedis_GetStale(t *testing.T) {
+	srv := redistest.NewServer(t)
+	c := NewRedis(redis.New(redis.Config{Addr: srv.Addr()}), Config{TTL: time.Minute, StaleTTL: time.Hour})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	key := Key{Prompt: "hello"}
+	c.Set(key, &Response{Text: "hi"})
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get(key); ok {
+		t.Error("expected expired entry to miss")
+	}
+	if resp, stale, ok := c.GetStale(key); !ok || !stale || resp.Text != "hi" {
+		t.Errorf("expected stale hit, got %+v, %v, %v", resp, stale, ok)
+	}
+
+	// Redis drops the entry once the stale TTL has passed too
+	srv.FastForward(time.Minute + time.Hour)
+	if _, _, ok := c.GetStale(key); ok {
+		t.Error("expected entry to be gone after the stale TTL")
+	}
+}
+
+func TestRedis_EvictsOldest(t *testing.T) {
+	srv := redistest.NewServer(t)
+	c := NewRedis(redis.New(redis.Config{Addr: srv.Addr()}), Config{TTL: time.Minute, MaxEntries: 2, MaxEntryBytes: 10})
+
+	c.Set(Key{Prompt: "a"}, &Response{Text: "A"})
+	c.Set(Key{Prompt: "b"}, &Response{Text: "B"})
+	c.Set(Key{Prompt: "a"}, &Response{Text: "A2"})
+	c.Set(Key{Prompt: "c"}, &Response{Text: "C"})
+	c.Set(Key{Prompt: "d"}, &Response{Text: "a longer answer"})
+
+	if _, ok := c.Get(Key{Prompt: "b"}); ok {
+		t.Error("expected oldest entry to be evicted")
+	}
+	for _, prompt := range []string{"a", "c"} {
+		if _, ok := c.Get(Key{Prompt: prompt}); !ok {
+			t.Errorf("expected %q to be cached", prompt)
+		}
+	}
+	if _, ok := c.Get(Key{Prompt: "d"}); ok {
+		t.Error("expected entry over MaxEntryBytes to be skipped")
+	}
+}
+
+func TestRedis_RecachedKeyIsNewest(t *testing.T) {
+	srv := redistest.NewServer(t)
+	c := NewRedis(redis.New(redis.Config{Addr: srv.Addr()}), Config{TTL: time.Minute, MaxEntries: 3})
+	now := time.Now()
+	c.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	c.Set(Key{Prompt: "a"}, &Response{Text: "A"})
+	c.Set(Key{Prompt: "b"}, &Response{Text: "B"})
+	c.Set(Key{Prompt: "a"}, &Response{Text: "A2"})
+	c.Set(Key{Prompt: "c"}, &Response{Text: "C"})
+	c.Set(Key{Prompt: "d"}, &Response{Text: "D"})
+
+	if _, ok := c.Get(Key{Prompt: "b"}); ok {
+		t.Error("expected the least recently written entry to be evicted")
+	}
+	for _, prompt := range []string{"a", "c", "d"} {
+		if _, ok := c.Get(Key{Prompt: prompt}); !ok {
+			t.Errorf("expected %q to be cached", prompt)
+		}
+	}
+}
+
+func TestRedis_FailuresAreMisses(t *testing.T) {
+	var failures int
+	client := redis.New(redis.Config{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond})
+	c := NewRedis(client, Config{OnError: func(error) { failures++ }})
+
+	c.Set(Key{Prompt: "hello"}, &Response{Text: "hi"})
+	if _, ok := c.Get(Key{Prompt: "hello"}); ok {
+		t.Error("expected miss while Redis is unreachable")
+	}
+	if failures != 2 {
+		t.Errorf("expected 2 reported failures, got %d", failures)
+	}
+}
@@ -0,0 +1,242 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+)
+
+// keyPrefix namespaces cached responses in a shared Redis
+const keyPrefix = "neurogate:cache:"
+
+// redisTimeout bounds each cache operation, so a slow Redis delays requests
+// by at most this long
+const redisTimeout = time.Second
+
+// redisEntry is a response as stored in Redis
+type redisEntry struct {
+	Response  *Response `json:"response"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Redis is an exact-match Store shared by every gateway replica using the
+// same Redis. Entries expire after TTL plus StaleTTL. Beyond MaxEntries the
+// oldest stored entries are evicted; Redis's own maxmemory policy applies
+// on top. Failures are reported to OnError and treated as misses.
+type Redis struct {
+	client *redis.Client
+	cfg    Config
+	now    func() time.Time
+}
+
+// NewRedis creates a cache on client
+func NewRedis(client *redis.Client, cfg Config) *Redis {
+	return &Redis{client: client, cfg: cfg.withDefaults(), now: time.Now}
+}
+
+// Get implements Store
+func (c *Redis) Get(key Key) (*Response, bool) {
+	resp, stale, ok := c.GetStale(key)
+	if !ok || stale {
+		return nil, false
+	}
+	return resp, true
+}
+
+// GetStale implements Store
+func (c *Redis) GetStale(key Key) (*Response, bool, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	data, err := redis.Bytes(c.client.Do(ctx, "GET", keyPrefix+"response:"+key.Hash()))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, false, false
+	}
+	if err != nil {
+		c.failed(fmt.Errorf("get cached response: %w", err))
+		return nil, false, false
+	}
+	var e redisEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		c.failed(fmt.Errorf("decode cached response: %w", err))
+		return nil, false, false
+	}
+
+	now := c.now()
+	if e.Response == nil || now.After(e.ExpiresAt.Add(c.cfg.StaleTTL)) {
+		return nil, false, false
+	}
+	return e.Response, now.After(e.ExpiresAt), true
+}
+
+// Set implements Store. Stored entries are indexed in a sorted set scored by
+// write time, so a re-cached entry moves to the newest end, and the
+// transaction adding an entry trims the index to MaxEntries, returning the
+// evicted entries to delete.
+func (c *Redis) Set(key Key, resp *Response) {
+	if c.cfg.MaxEntryBytes > 0 && resp.size() > c.cfg.MaxEntryBytes {
+		return
+	}
+	data, err := json.Marshal(redisEntry{Response: resp, ExpiresAt: c.now().Add(c.cfg.TTL)})
+	if err != nil {
+		c.failed(fmt.Errorf("encode cached response: %w", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	entryKey := keyPrefix + "response:" + key.Hash()
+	// Not "index", which held a list before, so a Redis shared with older
+	// gateways doesn't fail with WRONGTYPE
+	index := keyPrefix + "written"
+	ttl := (c.cfg.TTL + c.cfg.StaleTTL).Milliseconds()
+	replies, err := c.client.Tx(ctx,
+		[]interface{}{"SET", entryKey, data, "PX", ttl},
+		[]interface{}{"ZADD", index, c.now().UnixNano(), entryKey},
+		[]interface{}{"PEXPIRE", index, ttl},
+		[]interface{}{"ZRANGE", index, 0, -c.cfg.MaxEntries - 1},
+		[]interface{}{"ZREMRANGEBYRANK", index, 0, -c.cfg.MaxEntries - 1},
+	)
+	if err != nil {
+		c.failed(fmt.Errorf("set cached response: %w", err))
+		return
+	}
+	evicted, err := redis.ByteSlices(replies[3], nil)
+	if err != nil || len(evicted) == 0 {
+		return
+	}
+	del := []interface{}{"DEL"}
+	for _, k := range evicted {
+		del = append(del, k)
+	}
+	if _, err := c.client.Do(ctx, del...); err != nil {
+		c.failed(fmt.Errorf("evict cached responses: %w", err))
+	}
+}
+
+func (c *Redis) failed(err error) {
+	if c.cfg.OnError != nil {
+		c.cfg.OnError(err)
+	}
+}
+
+// redisSemanticEntry is a semantic cache entry as stored in Redis
+type redisSemanticEntry struct {
+	Vector    []byte    `json:"vector"` // Normalized, as little-endian float32s
+	Response  *Response `json:"response"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RedisSemantic is a SemanticStore shared by every gateway replica using the
+// same Redis. Each scope's entries are kept in one list of up to MaxEntries,
+// which lookups read and compare in full, so lookups cost more than in
+// memory as the list grows. Failures are reported to OnError and treated as
+// misses.
+type RedisSemantic struct {
+	client *redis.Client
+	cfg    SemanticConfig
+	now    func() time.Time
+}
+
+// NewRedisSemantic creates a semantic cache on client
+func NewRedisSemantic(client *redis.Client, cfg SemanticConfig) *RedisSemantic {
+	return &RedisSemantic{client: client, cfg: cfg.withDefaults(), now: time.Now}
+}
+
+// Get implements SemanticStore
+func (c *RedisSemantic) Get(key Key, embedding []float32) (*Response, float64, bool) {
+	query := normalize(embedding)
+	if query == nil {
+		return nil, 0, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	items, err := redis.ByteSlices(c.client.Do(ctx, "LRANGE", keyPrefix+"semantic:"+scopeOf(key), 0, -1))
+	if err != nil {
+		c.failed(fmt.Errorf("get semantic cache entries: %w", err))
+		return nil, 0, false
+	}
+
+	now := c.now()
+	var best *Response
+	bestScore := -1.0
+	for _, item := range items {
+		var e redisSemanticEntry
+		if err := json.Unmarshal(item, &e); err != nil || e.Response == nil || now.After(e.ExpiresAt) {
+			continue
+		}
+		vector := decodeVector(e.Vector)
+		if len(vector) != len(query) {
+			continue
+		}
+		if score := dot(vector, query); score > bestScore {
+			best, bestScore = e.Response, score
+		}
+	}
+
+	if best == nil || bestScore < c.cfg.Threshold {
+		return nil, bestScore, false
+	}
+	return best, bestScore, true
+}
+
+// Set implements SemanticStore
+func (c *RedisSemantic) Set(key Key, embedding []float32, resp *Response) {
+	vector := normalize(embedding)
+	if vector == nil || (c.cfg.MaxEntryBytes > 0 && resp.size() > c.cfg.MaxEntryBytes) {
+		return
+	}
+	data, err := json.Marshal(redisSemanticEntry{
+		Vector:    encodeVector(vector),
+		Response:  resp,
+		ExpiresAt: c.now().Add(c.cfg.TTL),
+	})
+	if err != nil {
+		c.failed(fmt.Errorf("encode semantic cache entry: %w", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	// Entries share a TTL, so the list expires with its newest entry
+	list := keyPrefix + "semantic:" + scopeOf(key)
+	if _, err := c.client.Tx(ctx,
+		[]interface{}{"RPUSH", list, data},
+		[]interface{}{"LTRIM", list, -c.cfg.MaxEntries, -1},
+		[]interface{}{"PEXPIRE", list, c.cfg.TTL.Milliseconds()},
+	); err != nil {
+		c.failed(fmt.Errorf("set semantic cache entry: %w", err))
+	}
+}
+
+func (c *RedisSemantic) failed(err error) {
+	if c.cfg.OnError != nil {
+		c.cfg.OnError(err)
+	}
+}
+
+func encodeVector(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(x))
+	}
+	return b
+}
+
+func decodeVector(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// SemanticStore is a cache matching prompts by embedding similarity
+type SemanticStore interface {
+	// Get returns the most similar cached response within key's scope and
+	// its similarity, if that similarity meets the threshold
+	Get(key Key, embedding []float32) (*Response, float64, bool)
+	// Set stores a response for a prompt embedding
+	Set(key Key, embedding []float32, resp *Response)
+}
+
+// SemanticConfig holds semantic cache configuration
+type SemanticConfig struct {
+	TTL           time.Duration // How long entries stay fresh. Default: 5 minutes
+	MaxEntries    int           // Maximum number of vectors kept. Default: 1000
+	MaxEntryBytes int           // Responses with more text and parameters aren't cached. Default: unlimited
+	Threshold     float64       // Minimum cosine similarity for a hit. Default: 0.95
+	OnError       func(error)   // Called when a Redis cache fails; the lookup is a miss
+}
+
+func (cfg SemanticConfig) withDefaults() SemanticConfig {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 5 * time.Minute
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 1000
+	}
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 0.95
+	}
+	return cfg
+}
+
+// SemanticCache serves responses for prompts whose embeddings are close to a
+// previously answered prompt. Entries are only compared within the same
+// scope (model, system prompt and sampling parameters), so a cached answer is
+// never returned for a differently configured request.
+type SemanticCache struct {
+	mu            sync.Mutex
+	ttl           time.Duration
+	maxEntries    int
+	maxEntryBytes int
+	threshold     float64
+	entries       []*semanticEntry
+}
+
+type semanticEntry struct {
+	scope     string
+	vector    []float32 // normalized to unit length
+	response  *Response
+	expiresAt time.Time
+}
+
+// NewSemantic creates a new semantic cache
+func NewSemantic(cfg SemanticConfig) *SemanticCache {
+	cfg = cfg.withDefaults()
+	return &SemanticCache{
+		ttl:           cfg.TTL,
+		maxEntries:    cfg.MaxEntries,
+		maxEntryBytes: cfg.MaxEntryBytes,
+		threshold:     cfg.Threshold,
+	}
+}
+
+// Get returns the most similar cached response within key's scope, along with
+// its similarity, if that similarity meets the threshold. key.Prompt is ignored.
+func (c *SemanticCache) Get(key Key, embedding []float32) (*Response, float64, bool) {
+	query := normalize(embedding)
+	if query == nil {
+		return nil, 0, false
+	}
+	scope := scopeOf(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeExpired()
+
+	var best *semanticEntry
+	bestScore := -1.0
+	for _, e := range c.entries {
+		if e.scope != scope || len(e.vector) != len(query) {
+			continue
+		}
+		if score := dot(e.vector, query); score > bestScore {
+			best, bestScore = e, score
+		}
+	}
+
+	if best == nil || bestScore < c.threshold {
+		return nil, bestScore, false
+	}
+	return best.response, bestScore, true
+}
+
+// Set stores a response for a prompt embedding, evicting the oldest entry if full
+func (c *SemanticCache) Set(key Key, embedding []float32, resp *Response) {
+	vector := normalize(embedding)
+	if vector == nil || (c.maxEntryBytes > 0 && resp.size() > c.maxEntryBytes) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeExpired()
+
+	c.entries = append(c.entries, &semanticEntry{
+		scope:     scopeOf(key),
+		vector:    vector,
+		response:  resp,
+		expiresAt: time.Now().Add(c.ttl),
+	})
+
+	if overflow := len(c.entries) - c.maxEntries; overflow > 0 {
+		c.entries = append(c.entries[:0], c.entries[overflow:]...)
+	}
+}
+
+// Len returns the number of entries currently held
+func (c *SemanticCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// removeExpired drops expired entries. Entries are kept in insertion order
+// and share a TTL, so expired entries always form a prefix.
+func (c *SemanticCache) removeExpired() {
+	now := time.Now()
+	i := 0
+	for i < len(c.entries) && now.After(c.entries[i].expiresAt) {
+		i++
+	}
+	if i > 0 {
+		c.entries = append(c.entries[:0], c.entries[i:]...)
+	}
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors, or 0 if they
+// differ in length or either has zero magnitude
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	na, nb := normalize(a), normalize(b)
+	if na == nil || nb == nil {
+		return 0
+	}
+	return dot(na, nb)
+}
+
+func scopeOf(key Key) string {
+	key.Prompt = ""
+	return key.Hash()
+}
+
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return nil
+	}
+
+	norm := math.Sqrt(sum)
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+	"github.com/hugovillarreal/neurogate/pkg/redis/redistest"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []float32
+		expected float64
+	}{
+		{"identical", []float32{1, 2, 3}, []float32{1, 2, 3}, 1},
+		{"scaled", []float32{1, 2, 3}, []float32{2, 4, 6}, 1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"opposite", []float32{1, 0}, []float32{-1, 0}, -1},
+		{"length mismatch", []float32{1, 0}, []float32{1, 0, 0}, 0},
+		{"zero vector", []float32{0, 0}, []float32{1, 0}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := CosineSimilarity(tt.a, tt.b); math.Abs(got-tt.expected) > 1e-6 {
+			t.Errorf("%s: CosineSimilarity = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestSemanticCache_HitAboveThreshold(t *testing.T) {
+	c := NewSemantic(SemanticConfig{Threshold: 0.9})
+	key := Key{Model: "llama3.2", Prompt: "What color is the sky?"}
+
+	c.Set(key, []float32{1, 0.1, 0}, &Response{Text: "blue"})
+
+	similar := Key{Model: "llama3.2", Prompt: "What colour is the sky?"}
+	resp, score, ok := c.Get(similar, []float32{1, 0.12, 0})
+	if !ok {
+		t.Fatalf("expected hit, got miss with score %v", score)
+	}
+	if resp.Text != "blue" {
+		t.Errorf("expected cached text %q, got %q", "blue", resp.Text)
+	}
+}
+
+func TestSemanticCache_MissBelowThreshold(t *testing.T) {
+	c := NewSemantic(SemanticConfig{Threshold: 0.9})
+	key := Key{Model: "llama3.2", Prompt: "What color is the sky?"}
+
+	c.Set(key, []float32{1, 0, 0}, &Response{Text: "blue"})
+
+	if _, _, ok := c.Get(key, []float32{0, 1, 0}); ok {
+		t.Error("expected miss for dissimilar embedding")
+	}
+}
+
+func TestSemanticCache_ScopedBySamplingParameters(t *testing.T) {
+	c := NewSemantic(SemanticConfig{Threshold: 0.9})
+
+	c.Set(Key{Model: "llama3.2", Temperature: 0.2}, []float32{1, 0}, &Response{Text: "cold"})
+
+	if _, _, ok := c.Get(Key{Model: "llama3.2", Temperature: 0.9}, []float32{1, 0}); ok {
+		t.Error("expected miss for different temperature")
+	}
+	if _, _, ok := c.Get(Key{Model: "mistral", Temperature: 0.2}, []float32{1, 0}); ok {
+		t.Error("expected miss for different model")
+	}
+}
+
+func TestSemanticCache_ReturnsBestMatch(t *testing.T) {
+	c := NewSemantic(SemanticConfig{Threshold: 0.5})
+	key := Key{Model: "llama3.2"}
+
+	c.Set(key, []float32{1, 1, 0}, &Response{Text: "close"})
+	c.Set(key, []float32{1, 0, 0}, &Response{Text: "closest"})
+
+	resp, _, ok := c.Get(key, []float32{1, 0.05, 0})
+	if !ok || resp.Text != "closest" {
+		t.Errorf("expected best match %q, got %+v", "closest", resp)
+	}
+}
+
+func TestSemanticCache_Expires(t *testing.T) {
+	c := NewSemantic(SemanticConfig{TTL: 50 * time.Millisecond})
+	key := Key{Model: "llama3.2"}
+
+	c.Set(key, []float32{1, 0}, &Response{Text: "x"})
+	time.Sleep(60 * time.Millisecond)
+
+	if _, _, ok := c.Get(key, []float32{1, 0}); ok {
+		t.Error("expected entry to expire after TTL")
+	}
+}
+
+func TestSemanticCache_EvictsOldest(t *testing.T) {
+	c := NewSemantic(SemanticConfig{MaxEntries: 2, Threshold: 0.99})
+	key := Key{Model: "llama3.2"}
+
+	c.Set(key, []float32{1, 0, 0}, &Response{Text: "a"})
+	c.Set(key, []float32{0, 1, 0}, &Response{Text: "b"})
+	c.Set(key, []float32{0, 0, 1}, &Response{Text: "c"})
+
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+	if _, _, ok := c.Get(key, []float32{1, 0, 0}); ok {
+		t.Error("expected oldest entry to be evicted")
+	}
+}
+
+func TestRedisSemantic_SharedBetweenReplicas(t *testing.T) {
+	srv := redistest.NewServer(t)
+	replica := func() *RedisSemantic {
+		return NewRedisSemantic(redis.New(redis.Config{Addr: srv.Addr()}), SemanticConfig{Threshold: 0.9, MaxEntries: 2})
+	}
+	c1, c2 := replica(), replica()
+	key := Key{Model: "llama3.2", Prompt: "What color is the sky?"}
+
+	c1.Set(key, []float32{1, 0.1, 0}, &Response{Text: "blue"})
+	c1.Set(key, []float32{0, 1, 0}, &Response{Text: "green"})
+
+	resp, score, ok := c2.Get(Key{Model: "llama3.2", Prompt: "What colour is the sky?"}, []float32{1, 0.12, 0})
+	if !ok || resp.Text != "blue" {
+		t.Fatalf("expected hit for blue on the other replica, got %+v with score %v", resp, score)
+	}
+	if _, _, ok := c2.Get(Key{Model: "mistral"}, []float32{1, 0.1, 0}); ok {
+		t.Error("expected miss in another scope")
+	}
+
+	// Each scope keeps its newest MaxEntries
+	c2.Set(key, []float32{0, 0, 1}, &Response{Text: "grey"})
+	if _, _, ok := c1.Get(key, []float32{1, 0.1, 0}); ok {
+		t.Error("expected oldest entry to be evicted")
+	}
+	if resp, _, ok := c1.Get(key, []float32{0, 0, 1}); !ok || resp.Text != "grey" {
+		t.Errorf("expected hit for grey, got %+v", resp)
+	}
+}
+
+func TestRedisSemantic_Expires(t *testing.T) {
+	srv := redistest.NewServer(t)
+	c := NewRedisSemantic(redis.New(redis.Config{Addr: srv.Addr()}), SemanticConfig{TTL: time.Minute})
+	key := Key{Model: "llama3.2"}
+
+	c.Set(key, []float32{1, 0}, &Response{Text: "old"})
+	srv.FastForward(time.Minute)
+	if _, _, ok := c.Get(key, []float32{1, 0}); ok {
+		t.Error("expected expired entry to miss")
+	}
+}
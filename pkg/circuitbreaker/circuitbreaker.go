@@ -2,7 +2,10 @@
 package circuitbreaker
 
 import (
+	"context"
 	"errors"
+	"math"
+	"math/rand/v2"
 	"sync"
 	"time"
 )
@@ -17,6 +20,12 @@ const (
 	StateOpen
 	// StateHalfOpen means the circuit is testing if the service recovered
 	StateHalfOpen
+	// StateForcedOpen means an operator opened the circuit; it rejects
+	// requests until Reset
+	StateForcedOpen
+	// StateDisabled means an operator bypassed the circuit; it allows all
+	// requests and records nothing until Reset
+	StateDisabled
 )
 
 func (s State) String() string {
@@ -27,6 +36,10 @@ func (s State) String() string {
 		return "open"
 	case StateHalfOpen:
 		return "half-open"
+	case StateForcedOpen:
+		return "forced-open"
+	case StateDisabled:
+		return "disabled"
 	default:
 		return "unknown"
 	}
@@ -43,6 +56,8 @@ type CircuitBreaker struct {
 	state           State
 	failureCount    int
 	successCount    int
+	probes          int    // Half-open requests awaiting a result
+	generation      uint64 // Incremented on every state change
 	lastFailure     time.Time
 	lastStateChange time.Time
 
@@ -50,18 +65,63 @@ type CircuitBreaker struct {
 	failureThreshold int           // Number of failures before opening
 	successThreshold int           // Number of successes in half-open before closing
 	timeout          time.Duration // How long to wait before trying again
+	multiplier       float64       // Growth of the timeout per failed half-open probe
+	maxTimeout       time.Duration
+	jitter           float64
+	openTimeout      time.Duration // Timeout for the current open period
+	reopens          int           // Consecutive failed half-open probes
+	halfOpenMax      int           // Concurrent requests allowed while half-open
+	isFailure        func(error) bool
+
+	// Failure-rate tripping; nil uses consecutive failures
+	window          slidingWindow
+	failureRate     float64
+	minimumRequests int
+	slowThreshold   time.Duration // 0 disables slow-call detection
+	slowRate        float64
 
 	// Callbacks
 	onStateChange func(name string, from, to State)
 }
 
-// Config holds circuit breaker configuration
+// Config holds circuit breaker configuration. By default the breaker opens
+// after FailureThreshold consecutive failures. Setting WindowSize or
+// WindowDuration instead opens it when the failure rate over a sliding
+// window of recent calls reaches FailureRate.
 type Config struct {
 	Name             string
 	FailureThreshold int           // Default: 3
 	SuccessThreshold int           // Default: 1
 	Timeout          time.Duration // Default: 30 seconds
 	OnStateChange    func(name string, from, to State)
+
+	// Each time a half-open probe fails the open timeout is multiplied by
+	// BackoffMultiplier, up to MaxTimeout, and shifted randomly by up to
+	// ±Jitter of its length. Defaults: 1 (fixed timeout), 10×Timeout, 0
+	BackoffMultiplier float64
+	MaxTimeout        time.Duration
+	Jitter            float64
+
+	// Requests let through at once while half-open; further requests are
+	// rejected until one of them succeeds or fails. Default: SuccessThreshold
+	HalfOpenMaxRequests int
+
+	// IsFailure reports whether an error returned to Execute counts against
+	// the breaker. Errors it rejects, such as a caller's invalid input,
+	// neither trip nor close the breaker. Default: every error is a failure
+	IsFailure func(error) bool
+
+	WindowSize      int           // Count-based window over the last N calls
+	WindowDuration  time.Duration // Time-based window; takes precedence over WindowSize
+	FailureRate     float64       // Failure fraction (0-1) that opens the breaker. Default: 0.5
+	MinimumRequests int           // Calls in the window before the rate applies. Default: 10, at most WindowSize
+
+	// Calls through Execute taking at least SlowCallThreshold count as slow,
+	// even if they succeed. The breaker opens when the fraction of slow calls
+	// in the window reaches SlowCallRate, and a slow half-open probe counts as
+	// failed. Requires a sliding window. Default rate: 0.5
+	SlowCallThreshold time.Duration
+	SlowCallRate      float64
 }
 
 // New creates a new circuit breaker
@@ -75,52 +135,207 @@ func New(cfg Config) *CircuitBreaker {
 	if cfg.Timeout <= 0 {
 		cfg.Timeout = 30 * time.Second
 	}
+	if cfg.BackoffMultiplier < 1 {
+		cfg.BackoffMultiplier = 1
+	}
+	if cfg.MaxTimeout <= 0 {
+		cfg.MaxTimeout = 10 * cfg.Timeout
+	}
+	cfg.Jitter = min(max(cfg.Jitter, 0), 1)
+	if cfg.HalfOpenMaxRequests <= 0 {
+		cfg.HalfOpenMaxRequests = cfg.SuccessThreshold
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = func(error) bool { return true }
+	}
 
-	return &CircuitBreaker{
+	cb := &CircuitBreaker{
 		name:             cfg.Name,
 		state:            StateClosed,
 		failureThreshold: cfg.FailureThreshold,
 		successThreshold: cfg.SuccessThreshold,
 		timeout:          cfg.Timeout,
+		multiplier:       cfg.BackoffMultiplier,
+		maxTimeout:       max(cfg.MaxTimeout, cfg.Timeout),
+		jitter:           cfg.Jitter,
+		openTimeout:      cfg.Timeout,
+		halfOpenMax:      cfg.HalfOpenMaxRequests,
+		isFailure:        cfg.IsFailure,
 		onStateChange:    cfg.OnStateChange,
 		lastStateChange:  time.Now(),
 	}
+
+	switch {
+	case cfg.WindowDuration > 0:
+		cb.window = newTimeWindow(cfg.WindowDuration)
+	case cfg.WindowSize > 0:
+		cb.window = newCountWindow(cfg.WindowSize)
+	default:
+		return cb
+	}
+
+	if cfg.FailureRate <= 0 || cfg.FailureRate > 1 {
+		cfg.FailureRate = 0.5
+	}
+	if cfg.MinimumRequests <= 0 {
+		cfg.MinimumRequests = 10
+	}
+	if cfg.WindowDuration <= 0 && cfg.MinimumRequests > cfg.WindowSize {
+		cfg.MinimumRequests = cfg.WindowSize
+	}
+	if cfg.SlowCallRate <= 0 || cfg.SlowCallRate > 1 {
+		cfg.SlowCallRate = 0.5
+	}
+	cb.failureRate = cfg.FailureRate
+	cb.minimumRequests = cfg.MinimumRequests
+	cb.slowThreshold = max(cfg.SlowCallThreshold, 0)
+	cb.slowRate = cfg.SlowCallRate
+	return cb
 }
 
 // Execute runs the given function with circuit breaker protection
 func (cb *CircuitBreaker) Execute(fn func() error) error {
-	if !cb.AllowRequest() {
+	generation, ok := cb.allow()
+	if !ok {
 		return ErrCircuitOpen
 	}
 
+	start := time.Now()
 	err := fn()
 
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	// The outcome of a call admitted under an earlier state is discarded
+	if generation != cb.generation {
+		return err
+	}
+
+	slow := cb.slowThreshold > 0 && time.Since(start) >= cb.slowThreshold
 	if err != nil {
-		cb.RecordFailure()
+		if cb.isFailure(err) {
+			cb.recordFailure(slow)
+		} else if cb.state == StateHalfOpen {
+			// Ignored errors free the probe slot without recording an outcome
+			cb.probes = max(cb.probes-1, 0)
+		}
 		return err
 	}
 
-	cb.RecordSuccess()
+	cb.recordSuccess(slow)
 	return nil
 }
 
-// AllowRequest checks if a request should be allowed through
+// ExecuteContext is like Execute but passes ctx to fn. If ctx is already
+// done, fn isn't called and ctx's error is returned without being recorded.
+func (cb *CircuitBreaker) ExecuteContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return cb.Execute(func() error {
+		return fn(ctx)
+	})
+}
+
+// Do runs fn with circuit breaker protection and returns its result
+func Do[T any](cb *CircuitBreaker, fn func() (T, error)) (T, error) {
+	var result T
+	err := cb.Execute(func() error {
+		var err error
+		result, err = fn()
+		return err
+	})
+	return result, err
+}
+
+// Allow checks if a request should be allowed through and, if so, returns a
+// function that records its outcome. Unlike AllowRequest and RecordSuccess or
+// RecordFailure, the outcome is only recorded if the breaker hasn't changed
+// state since the request was admitted, so late results can't skew the
+// accounting of a newer state. done must be called once; further calls are
+// ignored.
+func (cb *CircuitBreaker) Allow() (done func(success bool), err error) {
+	generation, ok := cb.allow()
+	if !ok {
+		return nil, ErrCircuitOpen
+	}
+
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() {
+			cb.mu.Lock()
+			defer cb.mu.Unlock()
+
+			if generation != cb.generation {
+				return
+			}
+			if success {
+				cb.recordSuccess(false)
+			} else {
+				cb.recordFailure(false)
+			}
+		})
+	}, nil
+}
+
+// allow admits a request and returns the generation it was admitted under
+func (cb *CircuitBreaker) allow() (uint64, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	ok := cb.allowRequest()
+	return cb.generation, ok
+}
+
+// AllowRequest checks if a request should be allowed through. A request
+// allowed while half-open holds a probe slot until RecordSuccess or
+// RecordFailure is called.
 func (cb *CircuitBreaker) AllowRequest() bool {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
+	return cb.allowRequest()
+}
 
+func (cb *CircuitBreaker) allowRequest() bool {
 	switch cb.state {
 	case StateClosed:
 		return true
 	case StateOpen:
 		// Check if timeout has elapsed
-		if time.Since(cb.lastFailure) >= cb.timeout {
+		if time.Since(cb.lastFailure) >= cb.openTimeout {
 			cb.transitionTo(StateHalfOpen)
+			cb.probes++
 			return true
 		}
 		return false
 	case StateHalfOpen:
 		// Allow limited requests in half-open state
+		if cb.probes < cb.halfOpenMax {
+			cb.probes++
+			return true
+		}
+		return false
+	case StateDisabled:
+		return true
+	default:
+		return false
+	}
+}
+
+// Available reports whether AllowRequest would currently let a request
+// through, without changing state or reserving a half-open slot
+func (cb *CircuitBreaker) Available() bool {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	switch cb.state {
+	case StateClosed:
+		return true
+	case StateOpen:
+		return time.Since(cb.lastFailure) >= cb.openTimeout
+	case StateHalfOpen:
+		return cb.probes < cb.halfOpenMax
+	case StateDisabled:
 		return true
 	default:
 		return false
@@ -131,12 +346,35 @@ func (cb *CircuitBreaker) AllowRequest() bool {
 func (cb *CircuitBreaker) RecordSuccess() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
+	cb.recordSuccess(false)
+}
+
+// RecordFailure records a failed request
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.recordFailure(false)
+}
 
+func (cb *CircuitBreaker) recordSuccess(slow bool) {
 	switch cb.state {
 	case StateClosed:
 		// Reset failure count on success
 		cb.failureCount = 0
+		if cb.window != nil {
+			now := time.Now()
+			cb.window.record(outcome{slow: slow}, now)
+			if slow && cb.rateExceeded(now) {
+				cb.transitionTo(StateOpen)
+			}
+		}
 	case StateHalfOpen:
+		if slow {
+			// The worker answers but is still overloaded
+			cb.recordFailure(true)
+			return
+		}
+		cb.probes = max(cb.probes-1, 0)
 		cb.successCount++
 		if cb.successCount >= cb.successThreshold {
 			cb.transitionTo(StateClosed)
@@ -144,25 +382,59 @@ func (cb *CircuitBreaker) RecordSuccess() {
 	}
 }
 
-// RecordFailure records a failed request
-func (cb *CircuitBreaker) RecordFailure() {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+func (cb *CircuitBreaker) recordFailure(slow bool) {
+	if cb.state == StateForcedOpen || cb.state == StateDisabled {
+		return
+	}
 
 	cb.failureCount++
 	cb.lastFailure = time.Now()
 
 	switch cb.state {
 	case StateClosed:
+		if cb.window != nil {
+			cb.window.record(outcome{failed: true, slow: slow}, cb.lastFailure)
+			if cb.rateExceeded(cb.lastFailure) {
+				cb.transitionTo(StateOpen)
+			}
+			return
+		}
 		if cb.failureCount >= cb.failureThreshold {
 			cb.transitionTo(StateOpen)
 		}
 	case StateHalfOpen:
-		// Any failure in half-open goes back to open
+		// Any failure in half-open goes back to open, for longer
+		cb.reopens++
 		cb.transitionTo(StateOpen)
 	}
 }
 
+// backoff returns the open timeout after the current number of failed
+// half-open probes
+func (cb *CircuitBreaker) backoff() time.Duration {
+	timeout := float64(cb.timeout) * math.Pow(cb.multiplier, float64(cb.reopens))
+	d := time.Duration(min(timeout, float64(cb.maxTimeout)))
+
+	spread := time.Duration(float64(d) * cb.jitter)
+	if spread <= 0 {
+		return d
+	}
+	return d - spread + rand.N(2*spread+1)
+}
+
+// rateExceeded reports whether the window holds enough calls and its failure
+// or slow-call rate has reached the threshold
+func (cb *CircuitBreaker) rateExceeded(now time.Time) bool {
+	c := cb.window.counts(now)
+	if c.total < cb.minimumRequests {
+		return false
+	}
+	if float64(c.failures)/float64(c.total) >= cb.failureRate {
+		return true
+	}
+	return cb.slowThreshold > 0 && float64(c.slow)/float64(c.total) >= cb.slowRate
+}
+
 // State returns the current state of the circuit breaker
 func (cb *CircuitBreaker) State() State {
 	cb.mu.RLock()
@@ -170,7 +442,8 @@ func (cb *CircuitBreaker) State() State {
 	return cb.state
 }
 
-// Reset resets the circuit breaker to closed state
+// Reset resets the circuit breaker to closed state, also ending a forced or
+// disabled state
 func (cb *CircuitBreaker) Reset() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
@@ -180,19 +453,136 @@ func (cb *CircuitBreaker) Reset() {
 	cb.successCount = 0
 }
 
+// Trip opens the circuit immediately. It recovers through half-open after
+// the open timeout, as if it had tripped on failures.
+func (cb *CircuitBreaker) Trip() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.transitionTo(StateOpen)
+	cb.lastFailure = time.Now()
+}
+
+// TripFor opens the circuit immediately for d instead of the open timeout,
+// after which it recovers through half-open like Trip. A circuit that is
+// already open is kept open for at least d.
+func (cb *CircuitBreaker) TripFor(d time.Duration) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == StateOpen && cb.openRemaining() >= d {
+		return
+	}
+	cb.transitionTo(StateOpen)
+	cb.lastFailure = time.Now()
+	cb.openTimeout = d
+}
+
+// OpenRemaining returns how long the circuit stays open before letting a
+// probe through, or 0 if it isn't open
+func (cb *CircuitBreaker) OpenRemaining() time.Duration {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.openRemaining()
+}
+
+func (cb *CircuitBreaker) openRemaining() time.Duration {
+	if cb.state != StateOpen {
+		return 0
+	}
+	return max(cb.openTimeout-time.Since(cb.lastFailure), 0)
+}
+
+// ForceOpen opens the circuit until Reset is called
+func (cb *CircuitBreaker) ForceOpen() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.transitionTo(StateForcedOpen)
+}
+
+// Disable lets every request through without tracking outcomes until Reset
+// is called
+func (cb *CircuitBreaker) Disable() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.transitionTo(StateDisabled)
+}
+
+// SavedState is the part of a breaker's state that can be saved and
+// restored across restarts. Sliding window contents are not included.
+type SavedState struct {
+	State        State         `json:"state"`
+	FailureCount int           `json:"failure_count"`
+	LastFailure  time.Time     `json:"last_failure"`
+	OpenTimeout  time.Duration `json:"open_timeout"`
+	Reopens      int           `json:"reopens"`
+}
+
+// Save returns the breaker's state for a later Restore
+func (cb *CircuitBreaker) Save() SavedState {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	return SavedState{
+		State:        cb.state,
+		FailureCount: cb.failureCount,
+		LastFailure:  cb.lastFailure,
+		OpenTimeout:  cb.openTimeout,
+		Reopens:      cb.reopens,
+	}
+}
+
+// Restore applies a saved state. An open breaker stays open for whatever
+// remains of its timeout; one saved half-open is restored open, since its
+// probes were lost, and probes again on the next request.
+func (cb *CircuitBreaker) Restore(s SavedState) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	state := s.State
+	if state == StateHalfOpen {
+		state = StateOpen
+	}
+	if state < StateClosed || state > StateDisabled {
+		return
+	}
+
+	cb.transitionTo(state)
+	cb.lastFailure = s.LastFailure
+	cb.reopens = max(s.Reopens, 0)
+	switch state {
+	case StateClosed:
+		cb.failureCount = max(s.FailureCount, 0)
+	case StateOpen:
+		if s.OpenTimeout > 0 {
+			cb.openTimeout = s.OpenTimeout
+		}
+	}
+}
+
 // Stats returns current circuit breaker statistics
 func (cb *CircuitBreaker) Stats() Stats {
 	cb.mu.RLock()
 	defer cb.mu.RUnlock()
 
-	return Stats{
+	stats := Stats{
 		Name:            cb.name,
 		State:           cb.state,
 		FailureCount:    cb.failureCount,
 		SuccessCount:    cb.successCount,
 		LastFailure:     cb.lastFailure,
 		LastStateChange: cb.lastStateChange,
+		OpenTimeout:     cb.openTimeout,
+	}
+	if cb.window != nil {
+		c := cb.window.counts(time.Now())
+		stats.WindowRequests = c.total
+		if c.total > 0 {
+			stats.FailureRate = float64(c.failures) / float64(c.total)
+			stats.SlowCallRate = float64(c.slow) / float64(c.total)
+		}
 	}
+	return stats
 }
 
 // Stats holds circuit breaker statistics
@@ -203,6 +593,12 @@ type Stats struct {
 	SuccessCount    int
 	LastFailure     time.Time
 	LastStateChange time.Time
+	OpenTimeout     time.Duration // How long the breaker stays open after tripping
+
+	// Sliding window contents; zero when the breaker counts consecutive failures
+	WindowRequests int
+	FailureRate    float64
+	SlowCallRate   float64
 }
 
 func (cb *CircuitBreaker) transitionTo(newState State) {
@@ -212,9 +608,20 @@ func (cb *CircuitBreaker) transitionTo(newState State) {
 
 	oldState := cb.state
 	cb.state = newState
+	cb.generation++
 	cb.lastStateChange = time.Now()
 	cb.failureCount = 0
 	cb.successCount = 0
+	cb.probes = 0
+	if cb.window != nil {
+		cb.window.reset()
+	}
+	switch newState {
+	case StateOpen:
+		cb.openTimeout = cb.backoff()
+	case StateClosed:
+		cb.reopens = 0
+	}
 
 	if cb.onStateChange != nil {
 		go cb.onStateChange(cb.name, oldState, newState)
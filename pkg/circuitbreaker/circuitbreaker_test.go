@@ -1,6 +1,7 @@
 package circuitbreaker
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
@@ -233,6 +234,8 @@ func TestState_String(t *testing.T) {
 		{StateClosed, "closed"},
 		{StateOpen, "open"},
 		{StateHalfOpen, "half-open"},
+		{StateForcedOpen, "forced-open"},
+		{StateDisabled, "disabled"},
 		{State(99), "unknown"},
 	}
 
@@ -242,3 +245,503 @@ func TestState_String(t *testing.T) {
 		}
 	}
 }
+
+func TestCircuitBreaker_FailureRateWindow(t *testing.T) {
+	cb := New(Config{
+		Name:            "test",
+		WindowSize:      10,
+		FailureRate:     0.5,
+		MinimumRequests: 4,
+	})
+
+	// Alternating outcomes never produce enough consecutive failures, but
+	// the rate reaches 50% once the minimum volume is met
+	cb.RecordFailure()
+	cb.RecordSuccess()
+	cb.RecordFailure()
+	if cb.State() != StateClosed {
+		t.Fatalf("expected circuit to stay closed below minimum requests, got %v", cb.State())
+	}
+
+	cb.RecordSuccess()
+	if cb.State() != StateClosed {
+		t.Fatalf("expected circuit to stay closed until a failure is recorded, got %v", cb.State())
+	}
+
+	cb.RecordFailure()
+	if cb.State() != StateOpen {
+		t.Errorf("expected circuit to open at 60%% failure rate, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreaker_FailureRateBelowThreshold(t *testing.T) {
+	cb := New(Config{
+		Name:            "test",
+		WindowSize:      10,
+		FailureRate:     0.5,
+		MinimumRequests: 5,
+	})
+
+	for i := 0; i < 20; i++ {
+		cb.RecordSuccess()
+		cb.RecordSuccess()
+		cb.RecordFailure()
+	}
+
+	if cb.State() != StateClosed {
+		t.Errorf("expected circuit to stay closed at 33%% failure rate, got %v", cb.State())
+	}
+
+	stats := cb.Stats()
+	if stats.WindowRequests != 10 {
+		t.Errorf("expected 10 requests in window, got %d", stats.WindowRequests)
+	}
+	if stats.FailureRate < 0.2 || stats.FailureRate > 0.4 {
+		t.Errorf("expected failure rate around 0.3, got %v", stats.FailureRate)
+	}
+}
+
+func TestCircuitBreaker_WindowResetOnTransition(t *testing.T) {
+	cb := New(Config{
+		Name:             "test",
+		SuccessThreshold: 1,
+		Timeout:          50 * time.Millisecond,
+		WindowSize:       4,
+		FailureRate:      0.5,
+	})
+
+	for i := 0; i < 4; i++ {
+		cb.RecordFailure()
+	}
+	if cb.State() != StateOpen {
+		t.Fatalf("expected circuit to be open, got %v", cb.State())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	cb.AllowRequest()
+	cb.RecordSuccess()
+	if cb.State() != StateClosed {
+		t.Fatalf("expected circuit to be closed, got %v", cb.State())
+	}
+
+	// The failures that opened the circuit no longer count
+	cb.RecordFailure()
+	if cb.State() != StateClosed {
+		t.Errorf("expected circuit to stay closed after window reset, got %v", cb.State())
+	}
+	if stats := cb.Stats(); stats.WindowRequests != 1 {
+		t.Errorf("expected 1 request in window, got %d", stats.WindowRequests)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenLimitsProbes(t *testing.T) {
+	cb := New(Config{
+		Name:                "test",
+		FailureThreshold:    1,
+		SuccessThreshold:    2,
+		Timeout:             10 * time.Millisecond,
+		HalfOpenMaxRequests: 2,
+	})
+
+	cb.RecordFailure() // Open
+	time.Sleep(20 * time.Millisecond)
+
+	if !cb.AllowRequest() || !cb.AllowRequest() {
+		t.Fatal("expected two probes to be allowed")
+	}
+	if cb.Available() || cb.AllowRequest() {
+		t.Fatal("expected third concurrent probe to be rejected")
+	}
+
+	// A finished probe frees its slot
+	cb.RecordSuccess()
+	if cb.State() != StateHalfOpen {
+		t.Fatalf("expected circuit to stay half-open, got %v", cb.State())
+	}
+	if !cb.AllowRequest() {
+		t.Error("expected probe to be allowed after one resolved")
+	}
+
+	cb.RecordSuccess()
+	if cb.State() != StateClosed {
+		t.Errorf("expected circuit to close, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreaker_HalfOpenDefaultsToSuccessThreshold(t *testing.T) {
+	cb := New(Config{
+		Name:             "test",
+		FailureThreshold: 1,
+		Timeout:          10 * time.Millisecond,
+	})
+
+	cb.RecordFailure() // Open
+	time.Sleep(20 * time.Millisecond)
+
+	if !cb.Available() {
+		t.Fatal("expected circuit to be available once the timeout elapsed")
+	}
+	if !cb.AllowRequest() {
+		t.Fatal("expected first probe to be allowed")
+	}
+	if cb.AllowRequest() {
+		t.Error("expected second concurrent probe to be rejected")
+	}
+
+	cb.RecordFailure()
+	if cb.State() != StateOpen {
+		t.Errorf("expected circuit to reopen, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreaker_IsFailure(t *testing.T) {
+	errBadInput := errors.New("bad input")
+	cb := New(Config{
+		Name:             "test",
+		FailureThreshold: 2,
+		Timeout:          10 * time.Millisecond,
+		IsFailure: func(err error) bool {
+			return !errors.Is(err, errBadInput)
+		},
+	})
+
+	for i := 0; i < 5; i++ {
+		if err := cb.Execute(func() error { return errBadInput }); err != errBadInput {
+			t.Fatalf("expected the function's error, got %v", err)
+		}
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("expected ignored errors not to open the circuit, got %v", cb.State())
+	}
+
+	cb.Execute(func() error { return errors.New("worker down") })
+	cb.Execute(func() error { return errors.New("worker down") })
+	if cb.State() != StateOpen {
+		t.Fatalf("expected failures to open the circuit, got %v", cb.State())
+	}
+
+	// An ignored error while half-open frees the probe slot but doesn't close
+	time.Sleep(20 * time.Millisecond)
+	cb.Execute(func() error { return errBadInput })
+	if cb.State() != StateHalfOpen {
+		t.Fatalf("expected circuit to stay half-open, got %v", cb.State())
+	}
+	if !cb.Available() {
+		t.Error("expected probe slot to be released")
+	}
+}
+
+func TestCircuitBreaker_ExecuteContext(t *testing.T) {
+	cb := New(Config{Name: "test", FailureThreshold: 1})
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	err := cb.ExecuteContext(ctx, func(ctx context.Context) error {
+		if ctx.Value(key{}) != "value" {
+			t.Error("expected caller's context to be passed through")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A context that is already done doesn't call fn or count as a failure
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err = cb.ExecuteContext(cancelled, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if called {
+		t.Error("expected fn not to be called")
+	}
+	if cb.State() != StateClosed {
+		t.Errorf("expected circuit to stay closed, got %v", cb.State())
+	}
+}
+
+func TestDo(t *testing.T) {
+	cb := New(Config{Name: "test", FailureThreshold: 1})
+
+	n, err := Do(cb, func() (int, error) { return 42, nil })
+	if err != nil || n != 42 {
+		t.Fatalf("expected 42, got %d, %v", n, err)
+	}
+
+	errWorker := errors.New("worker down")
+	if _, err := Do(cb, func() (int, error) { return 0, errWorker }); err != errWorker {
+		t.Fatalf("expected the function's error, got %v", err)
+	}
+
+	s, err := Do(cb, func() (string, error) { return "unreachable", nil })
+	if err != ErrCircuitOpen {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if s != "" {
+		t.Errorf("expected zero value when rejected, got %q", s)
+	}
+}
+
+func TestCircuitBreaker_OpenTimeoutBackoff(t *testing.T) {
+	cb := New(Config{
+		Name:              "test",
+		FailureThreshold:  1,
+		Timeout:           10 * time.Millisecond,
+		BackoffMultiplier: 2,
+		MaxTimeout:        30 * time.Millisecond,
+	})
+
+	reopen := func() {
+		t.Helper()
+		time.Sleep(cb.Stats().OpenTimeout + 5*time.Millisecond)
+		if !cb.AllowRequest() {
+			t.Fatal("expected probe to be allowed after the open timeout")
+		}
+		cb.RecordFailure()
+	}
+
+	cb.RecordFailure() // Open
+	if got := cb.Stats().OpenTimeout; got != 10*time.Millisecond {
+		t.Fatalf("expected base timeout, got %v", got)
+	}
+
+	reopen()
+	if got := cb.Stats().OpenTimeout; got != 20*time.Millisecond {
+		t.Fatalf("expected doubled timeout, got %v", got)
+	}
+	if cb.Available() {
+		t.Error("expected circuit to stay open for the longer timeout")
+	}
+
+	reopen()
+	if got := cb.Stats().OpenTimeout; got != 30*time.Millisecond {
+		t.Fatalf("expected timeout capped at MaxTimeout, got %v", got)
+	}
+
+	// Closing the circuit starts over from the base timeout
+	time.Sleep(35 * time.Millisecond)
+	cb.AllowRequest()
+	cb.RecordSuccess()
+	cb.RecordFailure()
+	if got := cb.Stats().OpenTimeout; got != 10*time.Millisecond {
+		t.Errorf("expected base timeout after recovery, got %v", got)
+	}
+}
+
+func TestCircuitBreaker_OpenTimeoutJitter(t *testing.T) {
+	cb := New(Config{
+		Name:             "test",
+		FailureThreshold: 1,
+		Timeout:          time.Second,
+		Jitter:           0.2,
+	})
+
+	for i := 0; i < 20; i++ {
+		cb.Reset()
+		cb.RecordFailure()
+		if got := cb.Stats().OpenTimeout; got < 800*time.Millisecond || got > 1200*time.Millisecond {
+			t.Fatalf("expected timeout within ±20%% of 1s, got %v", got)
+		}
+	}
+}
+
+func TestCircuitBreaker_SlowCalls(t *testing.T) {
+	cb := New(Config{
+		Name:              "test",
+		Timeout:           10 * time.Millisecond,
+		WindowSize:        4,
+		MinimumRequests:   4,
+		SlowCallThreshold: 20 * time.Millisecond,
+		SlowCallRate:      0.5,
+	})
+
+	fast := func() error { return nil }
+	slowCall := func() error {
+		time.Sleep(25 * time.Millisecond)
+		return nil
+	}
+
+	cb.Execute(fast)
+	cb.Execute(slowCall)
+	cb.Execute(fast)
+	if cb.State() != StateClosed {
+		t.Fatalf("expected circuit to stay closed below minimum requests, got %v", cb.State())
+	}
+	if err := cb.Execute(slowCall); err != nil {
+		t.Fatalf("expected slow call to succeed, got %v", err)
+	}
+	if cb.State() != StateOpen {
+		t.Fatalf("expected circuit to open at 50%% slow calls, got %v", cb.State())
+	}
+
+	// A slow probe keeps the circuit open
+	time.Sleep(15 * time.Millisecond)
+	cb.Execute(slowCall)
+	if cb.State() != StateOpen {
+		t.Errorf("expected slow probe to reopen the circuit, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreaker_Trip(t *testing.T) {
+	cb := New(Config{Name: "test", Timeout: 10 * time.Millisecond})
+
+	cb.Trip()
+	if cb.State() != StateOpen || cb.AllowRequest() {
+		t.Fatalf("expected tripped circuit to reject requests, got %v", cb.State())
+	}
+
+	// A tripped circuit recovers like one opened by failures
+	time.Sleep(20 * time.Millisecond)
+	if !cb.AllowRequest() {
+		t.Fatal("expected probe to be allowed after the open timeout")
+	}
+	cb.RecordSuccess()
+	if cb.State() != StateClosed {
+		t.Errorf("expected circuit to close, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreaker_TripFor(t *testing.T) {
+	cb := New(Config{Name: "test", Timeout: 10 * time.Millisecond})
+
+	cb.TripFor(50 * time.Millisecond)
+	if got := cb.OpenRemaining(); got <= 10*time.Millisecond || got > 50*time.Millisecond {
+		t.Fatalf("expected the circuit to stay open for the given duration, got %v", got)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if cb.Available() {
+		t.Fatal("expected the circuit to stay open past its own timeout")
+	}
+
+	// A shorter trip doesn't cut the open period short
+	cb.TripFor(time.Millisecond)
+	if got := cb.OpenRemaining(); got <= 10*time.Millisecond {
+		t.Errorf("expected the longer open period to be kept, got %v", got)
+	}
+
+	time.Sleep(35 * time.Millisecond)
+	if !cb.AllowRequest() {
+		t.Fatal("expected probe to be allowed after the open period")
+	}
+	if got := cb.OpenRemaining(); got != 0 {
+		t.Errorf("expected nothing remaining once half-open, got %v", got)
+	}
+}
+
+func TestCircuitBreaker_OpenRemainingFollowsBackoff(t *testing.T) {
+	cb := New(Config{Name: "test", FailureThreshold: 1, Timeout: 10 * time.Millisecond, BackoffMultiplier: 4})
+
+	if got := cb.OpenRemaining(); got != 0 {
+		t.Fatalf("expected nothing remaining while closed, got %v", got)
+	}
+	cb.RecordFailure()
+	time.Sleep(15 * time.Millisecond)
+	cb.AllowRequest()
+	cb.RecordFailure() // The failed probe reopens it for 40ms
+	if got := cb.OpenRemaining(); got <= 30*time.Millisecond || got > 40*time.Millisecond {
+		t.Errorf("expected the backed-off timeout to remain, got %v", got)
+	}
+}
+
+func TestCircuitBreaker_ForceOpen(t *testing.T) {
+	cb := New(Config{Name: "test", Timeout: 10 * time.Millisecond})
+
+	cb.ForceOpen()
+	time.Sleep(20 * time.Millisecond)
+	if cb.Available() || cb.AllowRequest() {
+		t.Fatal("expected forced-open circuit to reject requests past the timeout")
+	}
+	if cb.State() != StateForcedOpen {
+		t.Fatalf("expected forced-open, got %v", cb.State())
+	}
+
+	cb.Reset()
+	if !cb.AllowRequest() {
+		t.Error("expected reset circuit to allow requests")
+	}
+}
+
+func TestCircuitBreaker_Disable(t *testing.T) {
+	cb := New(Config{Name: "test", FailureThreshold: 1})
+
+	cb.Disable()
+	for i := 0; i < 5; i++ {
+		if !cb.AllowRequest() {
+			t.Fatal("expected disabled circuit to allow requests")
+		}
+		cb.RecordFailure()
+	}
+	if cb.State() != StateDisabled {
+		t.Fatalf("expected failures not to open a disabled circuit, got %v", cb.State())
+	}
+	if stats := cb.Stats(); stats.FailureCount != 0 {
+		t.Errorf("expected no failures to be recorded, got %d", stats.FailureCount)
+	}
+
+	cb.Reset()
+	cb.RecordFailure()
+	if cb.State() != StateOpen {
+		t.Errorf("expected reset circuit to trip again, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreaker_Allow(t *testing.T) {
+	cb := New(Config{Name: "test", FailureThreshold: 2})
+
+	done, err := cb.Allow()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	done(false)
+	done(false) // Ignored
+	if stats := cb.Stats(); stats.FailureCount != 1 {
+		t.Fatalf("expected one failure to be recorded, got %d", stats.FailureCount)
+	}
+
+	done, _ = cb.Allow()
+	done(false)
+	if cb.State() != StateOpen {
+		t.Fatalf("expected circuit to open, got %v", cb.State())
+	}
+	if _, err := cb.Allow(); err != ErrCircuitOpen {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_AllowDiscardsStaleOutcomes(t *testing.T) {
+	cb := New(Config{
+		Name:             "test",
+		FailureThreshold: 1,
+		Timeout:          10 * time.Millisecond,
+	})
+
+	// A slow request admitted while closed...
+	slowDone, _ := cb.Allow()
+
+	// ...outlives a trip and the start of recovery
+	failDone, _ := cb.Allow()
+	failDone(false)
+	time.Sleep(20 * time.Millisecond)
+	probeDone, err := cb.Allow()
+	if err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+
+	slowDone(true)
+	if cb.State() != StateHalfOpen {
+		t.Fatalf("expected stale success not to close the circuit, got %v", cb.State())
+	}
+	if cb.Available() {
+		t.Error("expected the probe to still hold its slot")
+	}
+
+	probeDone(true)
+	if cb.State() != StateClosed {
+		t.Errorf("expected probe success to close the circuit, got %v", cb.State())
+	}
+}
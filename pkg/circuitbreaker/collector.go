@@ -0,0 +1,50 @@
+package circuitbreaker
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// collector exports the stats of every breaker in a registry
+type collector struct {
+	registry *Registry
+
+	state          *prometheus.Desc
+	openTimeout    *prometheus.Desc
+	windowRequests *prometheus.Desc
+	failureRate    *prometheus.Desc
+	slowCallRate   *prometheus.Desc
+}
+
+// Collector returns a Prometheus collector reporting the breakers in the
+// registry under namespace, with each breaker's name in the given label
+func (r *Registry) Collector(namespace, label string) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "circuit_breaker", name), help, []string{label}, nil)
+	}
+	return &collector{
+		registry:       r,
+		state:          desc("state", "Circuit breaker state (0=closed, 1=open, 2=half-open, 3=forced-open, 4=disabled)"),
+		openTimeout:    desc("open_timeout_seconds", "How long the circuit breaker stays open after tripping"),
+		windowRequests: desc("window_requests", "Calls in the circuit breaker's sliding window"),
+		failureRate:    desc("failure_rate", "Fraction of calls in the sliding window that failed"),
+		slowCallRate:   desc("slow_call_rate", "Fraction of calls in the sliding window that were slow"),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.state
+	ch <- c.openTimeout
+	ch <- c.windowRequests
+	ch <- c.failureRate
+	ch <- c.slowCallRate
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.registry.Snapshot() {
+		ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, float64(s.State), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.openTimeout, prometheus.GaugeValue, s.OpenTimeout.Seconds(), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.windowRequests, prometheus.GaugeValue, float64(s.WindowRequests), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.failureRate, prometheus.GaugeValue, s.FailureRate, s.Name)
+		ch <- prometheus.MustNewConstMetric(c.slowCallRate, prometheus.GaugeValue, s.SlowCallRate, s.Name)
+	}
+}
@@ -0,0 +1,104 @@
+package circuitbreaker
+
+import (
+	"sort"
+	"sync"
+)
+
+// Registry creates and looks up circuit breakers by name, sharing one
+// configuration between them
+type Registry struct {
+	mu       sync.RWMutex
+	defaults Config
+	breakers map[string]*CircuitBreaker
+	pending  map[string]SavedState // Restored states for breakers not yet created
+}
+
+// NewRegistry creates a registry whose breakers are configured with
+// defaults. The Name field is ignored; each breaker takes its own name.
+func NewRegistry(defaults Config) *Registry {
+	return &Registry{
+		defaults: defaults,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Get returns the breaker with the given name, creating it if needed
+func (r *Registry) Get(name string) *CircuitBreaker {
+	r.mu.RLock()
+	cb, ok := r.breakers[name]
+	r.mu.RUnlock()
+	if ok {
+		return cb
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cb, ok := r.breakers[name]; ok {
+		return cb
+	}
+	cfg := r.defaults
+	cfg.Name = name
+	cb = New(cfg)
+	if saved, ok := r.pending[name]; ok {
+		cb.Restore(saved)
+		delete(r.pending, name)
+	}
+	r.breakers[name] = cb
+	return cb
+}
+
+// Remove drops the breaker with the given name from the registry
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.breakers, name)
+}
+
+// Snapshot returns the stats of every breaker, sorted by name
+func (r *Registry) Snapshot() []Stats {
+	r.mu.RLock()
+	breakers := make([]*CircuitBreaker, 0, len(r.breakers))
+	for _, cb := range r.breakers {
+		breakers = append(breakers, cb)
+	}
+	r.mu.RUnlock()
+
+	stats := make([]Stats, len(breakers))
+	for i, cb := range breakers {
+		stats[i] = cb.Stats()
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// Save returns the saved state of every breaker by name
+func (r *Registry) Save() map[string]SavedState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	states := make(map[string]SavedState, len(r.breakers))
+	for name, cb := range r.breakers {
+		states[name] = cb.Save()
+	}
+	return states
+}
+
+// Restore applies saved states by name. States for breakers that don't exist
+// yet are applied when Get first creates them.
+func (r *Registry) Restore(states map[string]SavedState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, saved := range states {
+		if cb, ok := r.breakers[name]; ok {
+			cb.Restore(saved)
+			continue
+		}
+		if r.pending == nil {
+			r.pending = make(map[string]SavedState)
+		}
+		r.pending[name] = saved
+	}
+}
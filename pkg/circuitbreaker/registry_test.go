@@ -0,0 +1,131 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegistry_Get(t *testing.T) {
+	r := NewRegistry(Config{Name: "ignored", FailureThreshold: 1})
+
+	a := r.Get("worker-a")
+	if r.Get("worker-a") != a {
+		t.Fatal("expected the same breaker for the same name")
+	}
+	if r.Get("worker-b") == a {
+		t.Fatal("expected a different breaker for a different name")
+	}
+
+	// Breakers share the default configuration
+	a.RecordFailure()
+	if a.State() != StateOpen {
+		t.Errorf("expected default FailureThreshold to apply, got %v", a.State())
+	}
+	if a.Stats().Name != "worker-a" {
+		t.Errorf("expected breaker to be named worker-a, got %q", a.Stats().Name)
+	}
+
+	r.Remove("worker-a")
+	if r.Get("worker-a") == a {
+		t.Error("expected a new breaker after removal")
+	}
+}
+
+func TestRegistry_Snapshot(t *testing.T) {
+	r := NewRegistry(Config{FailureThreshold: 1})
+	r.Get("worker-b").RecordFailure()
+	r.Get("worker-a")
+
+	stats := r.Snapshot()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 breakers, got %d", len(stats))
+	}
+	if stats[0].Name != "worker-a" || stats[1].Name != "worker-b" {
+		t.Errorf("expected stats sorted by name, got %s, %s", stats[0].Name, stats[1].Name)
+	}
+	if stats[0].State != StateClosed || stats[1].State != StateOpen {
+		t.Errorf("expected closed and open, got %v and %v", stats[0].State, stats[1].State)
+	}
+}
+
+func TestRegistry_Collector(t *testing.T) {
+	r := NewRegistry(Config{FailureThreshold: 1})
+	r.Get("worker-a").RecordFailure()
+	r.Get("worker-b")
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(r.Collector("test", "worker"))
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+	if len(families) != 5 {
+		t.Errorf("expected 5 metric families, got %d", len(families))
+	}
+
+	states := make(map[string]float64)
+	for _, f := range families {
+		if f.GetName() != "test_circuit_breaker_state" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			states[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+		}
+	}
+	if states["worker-a"] != 1 || states["worker-b"] != 0 || len(states) != 2 {
+		t.Errorf("expected worker-a open and worker-b closed, got %v", states)
+	}
+}
+
+func TestRegistry_SaveRestore(t *testing.T) {
+	cfg := Config{FailureThreshold: 3, Timeout: time.Minute}
+	r := NewRegistry(cfg)
+	r.Get("worker-a").Trip()
+	r.Get("worker-b").RecordFailure()
+	r.Get("worker-c").ForceOpen()
+
+	// Round-trip through JSON as a store would
+	data, err := json.Marshal(r.Save())
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var states map[string]SavedState
+	if err := json.Unmarshal(data, &states); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	restored := NewRegistry(cfg)
+	restored.Restore(states)
+
+	a := restored.Get("worker-a")
+	if a.State() != StateOpen || a.Available() {
+		t.Errorf("expected worker-a to stay open, got %v", a.State())
+	}
+	b := restored.Get("worker-b")
+	if b.State() != StateClosed || b.Stats().FailureCount != 1 {
+		t.Errorf("expected worker-b closed with 1 failure, got %v with %d", b.State(), b.Stats().FailureCount)
+	}
+	if c := restored.Get("worker-c"); c.State() != StateForcedOpen {
+		t.Errorf("expected worker-c to stay forced open, got %v", c.State())
+	}
+}
+
+func TestCircuitBreaker_RestoreHalfOpen(t *testing.T) {
+	cb := New(Config{Timeout: time.Minute})
+	cb.Restore(SavedState{
+		State:       StateHalfOpen,
+		LastFailure: time.Now().Add(-2 * time.Minute),
+		OpenTimeout: time.Minute,
+	})
+
+	if cb.State() != StateOpen {
+		t.Fatalf("expected half-open to be restored as open, got %v", cb.State())
+	}
+	if !cb.AllowRequest() || cb.State() != StateHalfOpen {
+		t.Errorf("expected an immediate probe once the timeout has passed, got %v", cb.State())
+	}
+}
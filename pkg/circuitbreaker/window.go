@@ -0,0 +1,108 @@
+package circuitbreaker
+
+import "time"
+
+// timeWindowBuckets is how many buckets a time-based window is split into.
+// Outcomes age out one bucket at a time.
+const timeWindowBuckets = 10
+
+// slidingWindow aggregates recent call outcomes for rate-based tripping
+type slidingWindow interface {
+	record(o outcome, now time.Time)
+	counts(now time.Time) windowCounts
+	reset()
+}
+
+// outcome is the result of a single call
+type outcome struct {
+	failed bool
+	slow   bool
+}
+
+// windowCounts tallies the calls in a window
+type windowCounts struct {
+	total    int
+	failures int
+	slow     int
+}
+
+func (c *windowCounts) add(o outcome, n int) {
+	c.total += n
+	if o.failed {
+		c.failures += n
+	}
+	if o.slow {
+		c.slow += n
+	}
+}
+
+// countWindow holds the outcomes of the last len(outcomes) calls
+type countWindow struct {
+	outcomes []outcome
+	next     int
+	totals   windowCounts
+}
+
+func newCountWindow(size int) *countWindow {
+	return &countWindow{outcomes: make([]outcome, size)}
+}
+
+func (w *countWindow) record(o outcome, now time.Time) {
+	if w.totals.total == len(w.outcomes) {
+		w.totals.add(w.outcomes[w.next], -1)
+	}
+
+	w.outcomes[w.next] = o
+	w.totals.add(o, 1)
+	w.next = (w.next + 1) % len(w.outcomes)
+}
+
+func (w *countWindow) counts(now time.Time) windowCounts {
+	return w.totals
+}
+
+func (w *countWindow) reset() {
+	clear(w.outcomes)
+	w.next, w.totals = 0, windowCounts{}
+}
+
+// timeWindow holds the outcomes of calls made within the last duration
+type timeWindow struct {
+	bucketSize time.Duration
+	buckets    [timeWindowBuckets]windowBucket
+}
+
+type windowBucket struct {
+	start time.Time
+	windowCounts
+}
+
+func newTimeWindow(duration time.Duration) *timeWindow {
+	return &timeWindow{bucketSize: max(duration/timeWindowBuckets, time.Millisecond)}
+}
+
+func (w *timeWindow) record(o outcome, now time.Time) {
+	start := now.Truncate(w.bucketSize)
+	b := &w.buckets[(start.UnixNano()/int64(w.bucketSize))%timeWindowBuckets]
+	if !b.start.Equal(start) {
+		*b = windowBucket{start: start}
+	}
+	b.add(o, 1)
+}
+
+func (w *timeWindow) counts(now time.Time) windowCounts {
+	var c windowCounts
+	cutoff := now.Truncate(w.bucketSize).Add(-w.bucketSize * (timeWindowBuckets - 1))
+	for _, b := range w.buckets {
+		if !b.start.Before(cutoff) {
+			c.total += b.total
+			c.failures += b.failures
+			c.slow += b.slow
+		}
+	}
+	return c
+}
+
+func (w *timeWindow) reset() {
+	w.buckets = [timeWindowBuckets]windowBucket{}
+}
@@ -0,0 +1,59 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	succeeded = outcome{}
+	failed    = outcome{failed: true}
+	slow      = outcome{slow: true}
+)
+
+func TestCountWindow(t *testing.T) {
+	w := newCountWindow(3)
+	now := time.Now()
+
+	w.record(failed, now)
+	w.record(slow, now)
+	if c := w.counts(now); c.total != 2 || c.failures != 1 || c.slow != 1 {
+		t.Fatalf("expected 2 calls with 1 failure and 1 slow, got %+v", c)
+	}
+
+	// The oldest outcome (a failure) is evicted
+	w.record(succeeded, now)
+	w.record(succeeded, now)
+	if c := w.counts(now); c.total != 3 || c.failures != 0 || c.slow != 1 {
+		t.Errorf("expected 3 calls with 0 failures and 1 slow, got %+v", c)
+	}
+
+	w.reset()
+	if c := w.counts(now); c != (windowCounts{}) {
+		t.Errorf("expected empty window after reset, got %+v", c)
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	w := newTimeWindow(10 * time.Second)
+	start := time.Unix(1000, 0)
+
+	w.record(failed, start)
+	w.record(failed, start.Add(500*time.Millisecond))
+	w.record(slow, start.Add(5*time.Second))
+
+	if c := w.counts(start.Add(5 * time.Second)); c.total != 3 || c.failures != 2 || c.slow != 1 {
+		t.Fatalf("expected 3 calls with 2 failures and 1 slow, got %+v", c)
+	}
+
+	// The first bucket has aged out
+	if c := w.counts(start.Add(10 * time.Second)); c.total != 1 || c.failures != 0 {
+		t.Errorf("expected 1 call with 0 failures, got %+v", c)
+	}
+
+	// A bucket reused after a full rotation starts empty
+	w.record(succeeded, start.Add(20*time.Second))
+	if c := w.counts(start.Add(20 * time.Second)); c.total != 1 {
+		t.Errorf("expected 1 call after rotation, got %d", c.total)
+	}
+}
@@ -0,0 +1,261 @@
+// Package client is a Go client for the NeuroGate gateway's REST API, with
+// typed requests, retries of errors the gateway reports as retryable, and
+// request IDs and deadlines passed on from the caller's context
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/requestid"
+	"github.com/hugovillarreal/neurogate/pkg/tracing"
+)
+
+// requestTimeoutHeader tells the gateway how long the caller will wait
+const requestTimeoutHeader = "X-Request-Timeout"
+
+// Config configures a Client
+type Config struct {
+	URL      string // Gateway base URL, e.g. http://localhost:8080
+	APIKey   string // Sent as a bearer token
+	AdminKey string // Sent instead of APIKey to admin endpoints
+
+	// Requests failing with a retryable error, or that couldn't reach the
+	// gateway, are sent again up to MaxRetries times, after the gateway's
+	// Retry-After or RetryBackoff doubling with each attempt. Default: 2
+	// retries 500ms apart; a negative MaxRetries disables retries.
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	HTTPClient *http.Client // Default: one propagating trace context
+}
+
+// Client calls a gateway. It is safe for concurrent use.
+type Client struct {
+	cfg     Config
+	baseURL *url.URL
+	http    *http.Client
+}
+
+// New creates a client for the gateway at cfg.URL
+func New(cfg Config) (*Client, error) {
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		return nil, fmt.Errorf("invalid gateway URL %q", cfg.URL)
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 2
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: tracing.Transport(http.DefaultTransport)}
+	}
+	return &Client{cfg: cfg, baseURL: baseURL, http: httpClient}, nil
+}
+
+// Prompt generates a response to req
+func (c *Client) Prompt(ctx context.Context, req *PromptRequest) (*PromptResponse, error) {
+	var resp PromptResponse
+	if err := c.do(ctx, http.MethodPost, "/prompt", req, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Embeddings computes an embedding of each input
+func (c *Client) Embeddings(ctx context.Context, req *EmbeddingsRequest) (*EmbeddingsResponse, error) {
+	var resp EmbeddingsResponse
+	if err := c.do(ctx, http.MethodPost, "/embeddings", req, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Tokenize counts the tokens of a prompt
+func (c *Client) Tokenize(ctx context.Context, req *TokenizeRequest) (*TokenizeResponse, error) {
+	var resp TokenizeResponse
+	if err := c.do(ctx, http.MethodPost, "/tokenize", req, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateJob queues a prompt to run asynchronously. Unlike other calls, it
+// isn't retried if the gateway can't be reached, as the job may have been
+// created.
+func (c *Client) CreateJob(ctx context.Context, req *JobRequest) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodPost, "/jobs", req, &job, false); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJob returns a job's current state
+func (c *Client) GetJob(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id), nil, &job, true); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// WaitJob polls a job every interval until it finishes or ctx is done. A
+// failed job is returned with its error.
+func (c *Client) WaitJob(ctx context.Context, id string, interval time.Duration) (*Job, error) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		job, err := c.GetJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job.Done() {
+			return job, job.Err()
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// do sends a request, retrying retryable failures. Requests that couldn't
+// reach the gateway are only retried if idempotent.
+func (c *Client) do(ctx context.Context, method, path string, body, out any, idempotent bool) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	// Every attempt carries the same ID, so the gateway's logs tie them
+	// together
+	ctx = requestid.NewContext(ctx, requestid.FromContext(ctx))
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, method, path, payload, out)
+		delay, retry := c.retryDelay(ctx, err, attempt, idempotent)
+		if !retry {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryDelay returns how long to wait before retrying a failed attempt, and
+// whether to retry at all
+func (c *Client) retryDelay(ctx context.Context, err error, attempt int, idempotent bool) (time.Duration, bool) {
+	if err == nil || ctx.Err() != nil || attempt >= c.cfg.MaxRetries {
+		return 0, false
+	}
+
+	delay := c.cfg.RetryBackoff << attempt
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr):
+		if !apiErr.Retryable {
+			return 0, false
+		}
+		if apiErr.RetryAfter > 0 {
+			delay = apiErr.RetryAfter
+		}
+	case !idempotent:
+		return 0, false
+	}
+
+	// Don't wait for a retry that couldn't finish in time
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+		return 0, false
+	}
+	return delay, true
+}
+
+// send makes one attempt at a request, decoding a successful response into
+// out and an error response into an *APIError
+func (c *Client) send(ctx context.Context, method, path string, payload []byte, out any) error {
+	resp, err := c.open(ctx, method, path, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s response: %w", path, err)
+	}
+	return nil
+}
+
+// open sends a request, returning the response if it succeeded
+func (c *Client) open(ctx context.Context, method, path string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.String()+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	key := c.cfg.APIKey
+	if strings.HasPrefix(path, "/admin/") {
+		key = c.cfg.AdminKey
+	}
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	requestID := requestid.FromContext(ctx)
+	req.Header.Set(requestid.Header, requestID)
+	// Lets the gateway shed requests it couldn't answer in time
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining >= time.Millisecond {
+			req.Header.Set(requestTimeoutHeader, strconv.FormatFloat(remaining.Seconds(), 'f', 3, 64))
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	var errResp errorResponse
+	if json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&errResp) != nil || errResp.Error == "" {
+		errResp = errorResponse{Error: http.StatusText(resp.StatusCode)}
+	}
+	apiErr := errResp.apiError(resp.StatusCode, requestID)
+	apiErr.RetryAfter = retryAfter(resp.Header)
+	return nil, apiErr
+}
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/requestid"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, cfg Config) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg.URL = server.URL + "/"
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = time.Millisecond
+	}
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return c
+}
+
+func TestNew(t *testing.T) {
+	c, err := New(Config{URL: "http://localhost:8080/"})
+	if err != nil || c.baseURL.String() != "http://localhost:8080" || c.cfg.MaxRetries != 2 || c.cfg.RetryBackoff != 500*time.Millisecond {
+		t.Errorf("unexpected defaults: %+v, %v", c, err)
+	}
+	for _, u := range []string{"", "localhost:8080", "ftp://example.com", "http://"} {
+		if _, err := New(Config{URL: u}); err == nil {
+			t.Errorf("expected an error for URL %q", u)
+		}
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/prompt" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer key-1" {
+			t.Errorf("unexpected authorization: %q", got)
+		}
+		if got := r.Header.Get(requestid.Header); got != "req-1" {
+			t.Errorf("expected the context's request ID, got %q", got)
+		}
+		timeout, err := strconv.ParseFloat(r.Header.Get(requestTimeoutHeader), 64)
+		if err != nil || timeout <= 0 || timeout > 30 {
+			t.Errorf("expected the context's remaining time, got %q", r.Header.Get(requestTimeoutHeader))
+		}
+
+		var req PromptRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Query != "hi" || req.Model != "llama3.2" || req.TopP != 0.9 {
+			t.Errorf("unexpected body: %+v", req)
+		}
+		w.Write([]byte(`{"request_id":"req-1","response":"hello","model":"llama3.2","tokens":2,"usage":{"total_tokens":5}}`))
+	}, Config{APIKey: "key-1"})
+
+	ctx, cancel := context.WithTimeout(requestid.NewContext(context.Background(), "req-1"), 30*time.Second)
+	defer cancel()
+	resp, err := c.Prompt(ctx, &PromptRequest{Query: "hi", Model: "llama3.2", Sampling: Sampling{TopP: 0.9}})
+	if err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+	if resp.Response != "hello" || resp.Usage.TotalTokens != 5 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		status    int
+		wantCalls int32
+	}{
+		{"retryable", `{"error":"worker unavailable","code":503,"error_code":"WORKER_UNAVAILABLE","retryable":true}`, 503, 3},
+		{"not retryable", `{"error":"invalid request","code":400,"error_code":"INVALID_REQUEST","retryable":false,"fields":[{"field":"query","message":"is required"}]}`, 400, 1},
+		{"no body", ``, 502, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			ids := make(chan string, 3)
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				ids <- r.Header.Get(requestid.Header)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}, Config{})
+
+			_, err := c.Prompt(context.Background(), &PromptRequest{Query: "hi"})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Fatalf("expected an APIError with status %d, got %v", tt.status, err)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls.Load())
+			}
+			close(ids)
+			first := <-ids
+			for id := range ids {
+				if id != first {
+					t.Errorf("expected retries to keep request ID %s, got %s", first, id)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"quota exceeded","code":429,"message":"quota resets at 2024-01-08T00:00:00Z","error_code":"QUOTA_EXCEEDED","retryable":false}`))
+	}, Config{})
+
+	_, err := c.Embeddings(context.Background(), &EmbeddingsRequest{Input: []string{"a"}})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	if apiErr.Code != CodeQuotaExceeded || apiErr.Retryable || apiErr.RetryAfter != 7*time.Second || apiErr.RequestID == "" {
+		t.Errorf("unexpected error: %+v", apiErr)
+	}
+}
+
+func TestCreateJob_NotRetriedWhenUnreachable(t *testing.T) {
+	c, _ := New(Config{URL: "http://127.0.0.1:1", RetryBackoff: time.Millisecond})
+	if _, err := c.CreateJob(context.Background(), &JobRequest{PromptRequest: PromptRequest{Query: "hi"}}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestWaitJob(t *testing.T) {
+	var polls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs/job-1" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if polls.Add(1) < 3 {
+			w.Write([]byte(`{"id":"job-1","status":"running"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"job-1","status":"failed","error":{"error":"generation failed","code":500,"error_code":"INTERNAL"}}`))
+	}, Config{})
+
+	job, err := c.WaitJob(context.Background(), "job-1", time.Millisecond)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 || apiErr.Code != CodeInternal {
+		t.Fatalf("expected the job's error, got %v", err)
+	}
+	if job.Status != JobFailed || polls.Load() != 3 {
+		t.Errorf("expected a failed job after 3 polls, got %s after %d", job.Status, polls.Load())
+	}
+}
+
+func TestStreamTokens(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin/requests/req-1/stream" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer admin-1" {
+			t.Errorf("expected the admin key, got %q", got)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, token := range []string{"Hel", "lo"} {
+			fmt.Fprintf(w, "event: token\ndata: {\"token\":%q}\n\n", token)
+		}
+		fmt.Fprint(w, "event: done\ndata: {\"request_id\":\"req-1\"}\n\n")
+	}, Config{APIKey: "key-1", AdminKey: "admin-1"})
+
+	var text string
+	for token, err := range c.StreamTokens(context.Background(), "req-1") {
+		if err != nil {
+			t.Fatalf("stream failed: %v", err)
+		}
+		text += token
+	}
+	if text != "Hello" {
+		t.Errorf("expected Hello, got %q", text)
+	}
+}
+
+func TestStreamTokens_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"not in flight", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"request not found","code":404,"error_code":"NOT_FOUND"}`))
+		}},
+		{"cut off", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "event: token\ndata: {\"token\":\"Hel\"}\n\n")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.handler, Config{})
+			var gotErr error
+			for _, err := range c.StreamTokens(context.Background(), "req-1") {
+				gotErr = err
+			}
+			if gotErr == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Error codes the gateway reports in APIError.Code. Codes are stable
+// across releases, unlike messages.
+const (
+	CodeInvalidRequest    = "INVALID_REQUEST"
+	CodeUnauthorized      = "UNAUTHORIZED"
+	CodeForbidden         = "FORBIDDEN"
+	CodeNotFound          = "NOT_FOUND"
+	CodeModelNotFound     = "MODEL_NOT_FOUND"
+	CodeContextTooLong    = "CONTEXT_TOO_LONG"
+	CodeContentBlocked    = "CONTENT_BLOCKED"
+	CodeRequestTooLarge   = "REQUEST_TOO_LARGE"
+	CodeRateLimited       = "RATE_LIMITED"
+	CodeQuotaExceeded     = "QUOTA_EXCEEDED"
+	CodeOverloaded        = "OVERLOADED"
+	CodeWorkerUnavailable = "WORKER_UNAVAILABLE"
+	CodeUnavailable       = "UNAVAILABLE"
+	CodeTimeout           = "TIMEOUT"
+	CodeInternal          = "INTERNAL"
+)
+
+// APIError is an error response from the gateway
+type APIError struct {
+	StatusCode int
+	Message    string // e.g. "invalid request"
+	Detail     string
+	Code       string // One of the Code constants
+	Retryable  bool   // Sending the same request again may succeed
+	RetryAfter time.Duration
+	RequestID  string
+	Fields     []FieldError // The invalid fields of a rejected request
+}
+
+// FieldError is a problem with one field of a request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("neurogate: %d %s", e.StatusCode, e.Message)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	for _, f := range e.Fields {
+		msg += fmt.Sprintf("; %s %s", f.Field, f.Message)
+	}
+	return msg
+}
+
+// errorResponse is the gateway's JSON error body
+type errorResponse struct {
+	Error     string       `json:"error"`
+	Code      int          `json:"code"`
+	Message   string       `json:"message,omitempty"`
+	ErrorCode string       `json:"error_code"`
+	Retryable bool         `json:"retryable"`
+	Fields    []FieldError `json:"fields,omitempty"`
+}
+
+// apiError converts the body to an APIError. status is used when the body
+// has none.
+func (r *errorResponse) apiError(status int, requestID string) *APIError {
+	if r.Code != 0 {
+		status = r.Code
+	}
+	return &APIError{
+		StatusCode: status,
+		Message:    r.Error,
+		Detail:     r.Message,
+		Code:       r.ErrorCode,
+		Retryable:  r.Retryable,
+		RequestID:  requestID,
+		Fields:     r.Fields,
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(h http.Header) time.Duration {
+	seconds, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// StreamTokens attaches to a request the gateway is generating and yields
+// its tokens as they are generated, starting with those generated before
+// attaching, until the generation ends. It uses the admin API, so needs
+// Config.AdminKey. The request is identified by its request ID, which
+// callers can choose by putting it in the context of the call that sends it
+// (see requestid.NewContext).
+//
+// Iteration stops at the first error, which is yielded with an empty token.
+func (c *Client) StreamTokens(ctx context.Context, requestID string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		resp, err := c.open(ctx, http.MethodGet, "/admin/requests/"+url.PathEscape(requestID)+"/stream", nil)
+		if err != nil {
+			yield("", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		events := newEventReader(resp.Body)
+		for {
+			event, data, err := events.next()
+			if err != nil {
+				yield("", err)
+				return
+			}
+			switch event {
+			case "done":
+				return
+			case "token":
+				var payload struct {
+					Token string `json:"token"`
+				}
+				if err := json.Unmarshal([]byte(data), &payload); err != nil {
+					yield("", fmt.Errorf("decoding token event: %w", err))
+					return
+				}
+				if !yield(payload.Token, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// eventReader reads Server-Sent Events
+type eventReader struct {
+	scanner *bufio.Scanner
+}
+
+func newEventReader(r io.Reader) *eventReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	return &eventReader{scanner: scanner}
+}
+
+// next returns the next event's type and data. A stream ending without a
+// final event is an error, as the gateway always ends with "done".
+func (r *eventReader) next() (event, data string, err error) {
+	var lines []string
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		switch {
+		case line == "":
+			if event != "" || len(lines) > 0 {
+				return event, strings.Join(lines, "\n"), nil
+			}
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			lines = append(lines, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", "", err
+	}
+	return "", "", fmt.Errorf("event stream ended unexpectedly")
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PromptRequest is the body of POST /prompt
+type PromptRequest struct {
+	Query        string  `json:"query"`
+	Model        string  `json:"model,omitempty"`
+	MaxTokens    int32   `json:"max_tokens,omitempty"`
+	Temperature  float32 `json:"temperature,omitempty"`
+	SystemPrompt string  `json:"system_prompt,omitempty"`
+	Sampling
+
+	// "json" for any JSON output, or a JSON schema object it must follow
+	Format json.RawMessage `json:"format,omitempty"`
+
+	// Continues a conversation the gateway keeps
+	ConversationID string `json:"conversation_id,omitempty"`
+
+	// Continues from the context handle of an earlier response.
+	// ReturnContext asks for the response's handle.
+	Context       string `json:"context,omitempty"`
+	ReturnContext bool   `json:"return_context,omitempty"`
+
+	// "interactive" or "batch"; empty is interactive for prompts and batch
+	// for jobs
+	Priority string `json:"priority,omitempty"`
+}
+
+// Sampling holds the generation parameters beyond temperature and max
+// tokens. Zero values use the model's defaults.
+type Sampling struct {
+	TopP          float32  `json:"top_p,omitempty"`
+	TopK          int32    `json:"top_k,omitempty"`
+	RepeatPenalty float32  `json:"repeat_penalty,omitempty"`
+	Seed          *int64   `json:"seed,omitempty"` // Random if unset
+	Stop          []string `json:"stop,omitempty"`
+	NumCtx        int32    `json:"num_ctx,omitempty"` // Context window size in tokens
+}
+
+// PromptResponse is a generated response
+type PromptResponse struct {
+	RequestID string `json:"request_id"`
+	Response  string `json:"response"`
+	Model     string `json:"model"`
+	Tokens    int32  `json:"tokens"`
+	LatencyMs int64  `json:"latency_ms"`
+	WorkerID  string `json:"worker_id"`
+	Cached    bool   `json:"cached"`
+	Degraded  string `json:"degraded,omitempty"` // "stale", "emergency" or "cloud" when served by a fallback
+	Cloud     bool   `json:"cloud,omitempty"`
+	Usage     Usage  `json:"usage"`
+
+	FallbackFrom string `json:"fallback_from,omitempty"` // Requested model, when a fallback model served the request
+
+	ConversationID string `json:"conversation_id,omitempty"`
+	Context        string `json:"context,omitempty"` // With ReturnContext
+
+	Parameters *GenerationParameters `json:"parameters,omitempty"`
+
+	// Flagged content, when the gateway's moderation only flags it
+	Moderation json.RawMessage `json:"moderation,omitempty"`
+}
+
+// Usage is the cost of a prompt request
+type Usage struct {
+	PromptTokens     int32   `json:"prompt_tokens"`
+	CompletionTokens int32   `json:"completion_tokens"`
+	TotalTokens      int32   `json:"total_tokens"`
+	EstimatedCost    float64 `json:"estimated_cost"` // USD
+	CacheHit         bool    `json:"cache_hit"`
+	Retries          int     `json:"retries"` // Additional backend attempts by the gateway
+}
+
+// GenerationParameters are the parameters a response was generated with.
+// Sending them with the same query and model reproduces the response.
+type GenerationParameters struct {
+	Temperature float32 `json:"temperature,omitempty"`
+	MaxTokens   int32   `json:"max_tokens,omitempty"`
+	Sampling
+}
+
+// EmbeddingsRequest is the body of POST /embeddings
+type EmbeddingsRequest struct {
+	Input []string `json:"input"`
+	Model string   `json:"model,omitempty"`
+}
+
+// EmbeddingsResponse holds one embedding per input
+type EmbeddingsResponse struct {
+	RequestID  string      `json:"request_id"`
+	Embeddings [][]float32 `json:"embeddings"`
+	Model      string      `json:"model"`
+	Tokens     int32       `json:"tokens"`
+	LatencyMs  int64       `json:"latency_ms"`
+	WorkerID   string      `json:"worker_id"`
+}
+
+// TokenizeRequest is the body of POST /tokenize
+type TokenizeRequest struct {
+	Query        string `json:"query"`
+	Model        string `json:"model,omitempty"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+}
+
+// TokenizeResponse is a prompt's token count
+type TokenizeResponse struct {
+	RequestID     string `json:"request_id"`
+	Model         string `json:"model"`
+	Tokens        int32  `json:"tokens"`
+	Estimated     bool   `json:"estimated,omitempty"` // Estimated from the prompt's length
+	ContextLength int32  `json:"context_length,omitempty"`
+	WorkerID      string `json:"worker_id"`
+}
+
+// JobRequest is the body of POST /jobs
+type JobRequest struct {
+	PromptRequest
+	WebhookURL string `json:"webhook_url,omitempty"`
+}
+
+// JobStatus is the lifecycle state of an async job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is an asynchronously executed prompt request
+type Job struct {
+	ID          string          `json:"id"`
+	Status      JobStatus       `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	Result      *PromptResponse `json:"result,omitempty"`
+	Error       *errorResponse  `json:"error,omitempty"`
+}
+
+// Done reports whether the job has finished
+func (j *Job) Done() bool {
+	return j.Status == JobCompleted || j.Status == JobFailed
+}
+
+// Err returns the error a failed job ended with, or nil
+func (j *Job) Err() error {
+	if j.Status != JobFailed || j.Error == nil {
+		return nil
+	}
+	return j.Error.apiError(0, "")
+}
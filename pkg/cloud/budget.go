@@ -0,0 +1,61 @@
+package cloud
+
+import (
+	"sync"
+	"time"
+)
+
+// Budget caps spending in USD over a fixed window. Requests are admitted
+// while the window's spend is under the limit, so the last one admitted can
+// overshoot it by its own cost.
+type Budget struct {
+	limit  float64
+	window time.Duration
+
+	mu      sync.Mutex
+	spent   float64
+	resetAt time.Time
+	now     func() time.Time
+}
+
+// NewBudget creates a budget of limit USD per window. A limit of 0 is
+// unlimited.
+func NewBudget(limit float64, window time.Duration) *Budget {
+	return &Budget{limit: limit, window: window, now: time.Now}
+}
+
+// Allow reports whether the current window has budget left
+func (b *Budget) Allow() bool {
+	if b.limit <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	return b.spent < b.limit
+}
+
+// Spend charges usd to the current window
+func (b *Budget) Spend(usd float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	b.spent += usd
+}
+
+// Spent returns the current window's spend
+func (b *Budget) Spent() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	return b.spent
+}
+
+// roll starts a new window once the current one has passed. The first
+// window starts on first use.
+func (b *Budget) roll() {
+	if now := b.now(); !now.Before(b.resetAt) {
+		b.spent = 0
+		b.resetAt = now.Add(b.window)
+	}
+}
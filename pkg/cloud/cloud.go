@@ -0,0 +1,274 @@
+// Package cloud provides a minimal client for hosted LLM APIs that speak
+// the OpenAI chat completions or Anthropic messages protocol
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/tracing"
+)
+
+// Supported API protocols
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+// anthropicVersion is the Anthropic API version requests are written for
+const anthropicVersion = "2023-06-01"
+
+// defaultMaxTokens is sent to Anthropic, which requires max_tokens, for
+// requests without one
+const defaultMaxTokens = 1024
+
+// Config configures a Client
+type Config struct {
+	Provider string        // ProviderOpenAI or ProviderAnthropic
+	URL      string        // API base URL. Default: the provider's public API
+	APIKey   string        // Sent as a bearer token (OpenAI) or x-api-key (Anthropic)
+	Timeout  time.Duration // Per request. Default: 60 seconds
+}
+
+// Message is one turn of a conversation
+type Message struct {
+	Role    string `json:"role"` // "user" or "assistant"
+	Content string `json:"content"`
+}
+
+// Request is a completion request
+type Request struct {
+	Model       string
+	System      string
+	Messages    []Message // Ending with the prompt as a user message
+	MaxTokens   int32
+	Temperature float32
+	TopP        float32
+	Stop        []string
+}
+
+// Response is a completed response
+type Response struct {
+	Text             string
+	Model            string
+	PromptTokens     int32
+	CompletionTokens int32
+}
+
+// StatusError is a non-2xx response from the API
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("cloud API returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// Client sends completion requests to a hosted API
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+}
+
+// New creates a client, rejecting unknown providers
+func New(cfg Config) (*Client, error) {
+	switch cfg.Provider {
+	case ProviderOpenAI:
+		if cfg.URL == "" {
+			cfg.URL = "https://api.openai.com/v1"
+		}
+	case ProviderAnthropic:
+		if cfg.URL == "" {
+			cfg.URL = "https://api.anthropic.com/v1"
+		}
+	default:
+		return nil, fmt.Errorf("unknown provider %q (want %q or %q)", cfg.Provider, ProviderOpenAI, ProviderAnthropic)
+	}
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 60 * time.Second
+	}
+
+	return &Client{
+		cfg: cfg,
+		httpClient: &http.Client{
+			Transport: tracing.Transport(http.DefaultTransport),
+		},
+	}, nil
+}
+
+// Provider returns the API protocol the client speaks
+func (c *Client) Provider() string {
+	return c.cfg.Provider
+}
+
+// Complete sends req and returns the completion
+func (c *Client) Complete(ctx context.Context, req *Request) (*Response, error) {
+	if c.cfg.Provider == ProviderAnthropic {
+		return c.completeAnthropic(ctx, req)
+	}
+	return c.completeOpenAI(ctx, req)
+}
+
+type openAIRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int32     `json:"max_tokens,omitempty"`
+	Temperature float32   `json:"temperature,omitempty"`
+	TopP        float32   `json:"top_p,omitempty"`
+	Stop        []string  `json:"stop,omitempty"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int32 `json:"prompt_tokens"`
+		CompletionTokens int32 `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (c *Client) completeOpenAI(ctx context.Context, req *Request) (*Response, error) {
+	messages := req.Messages
+	if req.System != "" {
+		messages = append([]Message{{Role: "system", Content: req.System}}, messages...)
+	}
+	body := openAIRequest{
+		Model:       req.Model,
+		Messages:    messages,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stop:        req.Stop,
+	}
+
+	var result openAIResponse
+	headers := map[string]string{"Authorization": "Bearer " + c.cfg.APIKey}
+	if err := c.post(ctx, "/chat/completions", headers, body, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("cloud API returned no choices")
+	}
+	return &Response{
+		Text:             result.Choices[0].Message.Content,
+		Model:            result.Model,
+		PromptTokens:     result.Usage.PromptTokens,
+		CompletionTokens: result.Usage.CompletionTokens,
+	}, nil
+}
+
+type anthropicRequest struct {
+	Model         string    `json:"model"`
+	System        string    `json:"system,omitempty"`
+	Messages      []Message `json:"messages"`
+	MaxTokens     int32     `json:"max_tokens"`
+	Temperature   float32   `json:"temperature,omitempty"`
+	TopP          float32   `json:"top_p,omitempty"`
+	StopSequences []string  `json:"stop_sequences,omitempty"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int32 `json:"input_tokens"`
+		OutputTokens int32 `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func (c *Client) completeAnthropic(ctx context.Context, req *Request) (*Response, error) {
+	body := anthropicRequest{
+		Model:         req.Model,
+		System:        req.System,
+		Messages:      req.Messages,
+		MaxTokens:     req.MaxTokens,
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		StopSequences: req.Stop,
+	}
+	if body.MaxTokens <= 0 {
+		body.MaxTokens = defaultMaxTokens
+	}
+
+	var result anthropicResponse
+	headers := map[string]string{"x-api-key": c.cfg.APIKey, "anthropic-version": anthropicVersion}
+	if err := c.post(ctx, "/messages", headers, body, &result); err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return &Response{
+		Text:             text.String(),
+		Model:            result.Model,
+		PromptTokens:     result.Usage.InputTokens,
+		CompletionTokens: result.Usage.OutputTokens,
+	}, nil
+}
+
+// post sends body as JSON to path and decodes the response into result. A
+// non-2xx status is returned as a *StatusError.
+func (c *Client) post(ctx context.Context, path string, headers map[string]string, body, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.cfg.URL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		httpReq.Header.Set(name, value)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newStatusError(resp.StatusCode, resp.Body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// newStatusError reads the error message from a response body. Both APIs
+// send {"error": {"message": "..."}}.
+func newStatusError(statusCode int, body io.Reader) *StatusError {
+	data, _ := io.ReadAll(io.LimitReader(body, 64<<10))
+	message := strings.TrimSpace(string(data))
+	var parsed struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &parsed) == nil && parsed.Error.Message != "" {
+		message = parsed.Error.Message
+	}
+	return &StatusError{StatusCode: statusCode, Message: message}
+}
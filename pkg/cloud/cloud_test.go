@@ -0,0 +1,132 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	c, err := New(Config{Provider: ProviderOpenAI})
+	if err != nil || c.cfg.URL != "https://api.openai.com/v1" || c.cfg.Timeout != 60*time.Second {
+		t.Errorf("unexpected defaults: %+v, %v", c, err)
+	}
+	c, err = New(Config{Provider: ProviderAnthropic, URL: "http://localhost:8080/v1/"})
+	if err != nil || c.cfg.URL != "http://localhost:8080/v1" {
+		t.Errorf("expected trailing slash trimmed, got %+v, %v", c, err)
+	}
+	if _, err := New(Config{Provider: "gemini"}); err == nil {
+		t.Error("expected error for an unknown provider")
+	}
+}
+
+func TestComplete_OpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("unexpected authorization: %q", got)
+		}
+		var req openAIRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "gpt-4o-mini" || req.MaxTokens != 100 || len(req.Messages) != 3 ||
+			req.Messages[0].Role != "system" || req.Messages[2].Content != "hi" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		w.Write([]byte(`{"model":"gpt-4o-mini-2024","choices":[{"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`))
+	}))
+	defer server.Close()
+
+	c, _ := New(Config{Provider: ProviderOpenAI, URL: server.URL + "/v1", APIKey: "sk-test"})
+	resp, err := c.Complete(context.Background(), &Request{
+		Model:     "gpt-4o-mini",
+		System:    "be brief",
+		Messages:  []Message{{Role: "assistant", Content: "earlier"}, {Role: "user", Content: "hi"}},
+		MaxTokens: 100,
+	})
+	if err != nil {
+		t.Fatalf("complete failed: %v", err)
+	}
+	if resp.Text != "hello" || resp.Model != "gpt-4o-mini-2024" || resp.PromptTokens != 12 || resp.CompletionTokens != 3 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestComplete_Anthropic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "key" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		var req anthropicRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.System != "be brief" || req.MaxTokens != defaultMaxTokens || len(req.Messages) != 1 || len(req.StopSequences) != 1 {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		w.Write([]byte(`{"model":"claude-x","content":[{"type":"text","text":"hel"},{"type":"text","text":"lo"}],"usage":{"input_tokens":9,"output_tokens":2}}`))
+	}))
+	defer server.Close()
+
+	c, _ := New(Config{Provider: ProviderAnthropic, URL: server.URL + "/v1", APIKey: "key"})
+	resp, err := c.Complete(context.Background(), &Request{
+		Model:    "claude-x",
+		System:   "be brief",
+		Messages: []Message{{Role: "user", Content: "hi"}},
+		Stop:     []string{"\n"},
+	})
+	if err != nil {
+		t.Fatalf("complete failed: %v", err)
+	}
+	if resp.Text != "hello" || resp.PromptTokens != 9 || resp.CompletionTokens != 2 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestComplete_StatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"type":"rate_limit_error","message":"slow down"}}`))
+	}))
+	defer server.Close()
+
+	c, _ := New(Config{Provider: ProviderOpenAI, URL: server.URL})
+	_, err := c.Complete(context.Background(), &Request{Model: "m"})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests || statusErr.Message != "slow down" {
+		t.Errorf("expected status error, got %v", err)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	now := time.Now()
+	b := NewBudget(1, time.Hour)
+	b.now = func() time.Time { return now }
+
+	if !b.Allow() {
+		t.Fatal("expected a fresh budget to allow requests")
+	}
+	b.Spend(0.6)
+	if !b.Allow() {
+		t.Error("expected budget left after spending 0.6 of 1")
+	}
+	b.Spend(0.6)
+	if b.Allow() {
+		t.Error("expected an exhausted budget to refuse requests")
+	}
+
+	now = now.Add(time.Hour)
+	if !b.Allow() || b.Spent() != 0 {
+		t.Errorf("expected budget to reset with the window, spent %v", b.Spent())
+	}
+
+	if unlimited := NewBudget(0, time.Hour); !unlimited.Allow() {
+		t.Error("expected a zero limit to be unlimited")
+	}
+}
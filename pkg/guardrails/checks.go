@@ -0,0 +1,144 @@
+package guardrails
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// check adapts a function to the Check interface
+type check struct {
+	name  string
+	stage Stage
+	apply func(string) (string, error)
+}
+
+func (c *check) Name() string                         { return c.name }
+func (c *check) Stage() Stage                         { return c.stage }
+func (c *check) Apply(content string) (string, error) { return c.apply(content) }
+
+// wordPattern matches any of words as whole words, ignoring case. With
+// prefix, words followed by more letters match too ("darn" matches
+// "darned").
+func wordPattern(words []string, prefix bool) *regexp.Regexp {
+	quoted := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	suffix := `\b`
+	if prefix {
+		suffix = `\w*`
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)` + suffix)
+}
+
+// BannedWords rejects prompts containing any of words
+func BannedWords(words []string) Check {
+	pattern := wordPattern(words, false)
+	return &check{name: CheckBannedWords, stage: StagePre, apply: func(content string) (string, error) {
+		if pattern != nil && pattern.MatchString(content) {
+			return "", &Violation{Reason: "prompt contains a banned word"}
+		}
+		return content, nil
+	}}
+}
+
+// injectionPatterns are phrasings typical of attempts to override the
+// system prompt or smuggle in chat template tokens
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override)\s+(?:all\s+|any\s+)?(?:of\s+)?(?:the\s+|your\s+)?(?:previous|prior|above|earlier|preceding|system|original)\s+(?:instructions|prompts?|rules|directions|guidelines)`),
+	regexp.MustCompile(`(?i)\b(?:reveal|print|show|repeat|output|leak)\s+(?:me\s+)?(?:your|the)\s+(?:system\s+prompt|hidden\s+instructions|initial\s+instructions|instructions\s+above)`),
+	regexp.MustCompile(`(?i)\byou\s+are\s+now\s+(?:in\s+)?(?:DAN|developer\s+mode|jailbroken|unrestricted|unfiltered)\b`),
+	regexp.MustCompile(`(?i)<\|(?:im_start|im_end|system|endoftext|start_header_id|end_header_id|eot_id)\|>|\[/?INST\]|<</?SYS>>`),
+}
+
+// PromptInjection rejects prompts matching common prompt injection
+// phrasings. Heuristics catch careless attempts, not determined ones.
+func PromptInjection() Check {
+	return &check{name: CheckPromptInjection, stage: StagePre, apply: func(content string) (string, error) {
+		for _, p := range injectionPatterns {
+			if p.MatchString(content) {
+				return "", &Violation{Reason: "prompt looks like an attempt to override the model's instructions"}
+			}
+		}
+		return content, nil
+	}}
+}
+
+// MaxPromptSize rejects prompts longer than limit characters
+func MaxPromptSize(limit int) Check {
+	return &check{name: CheckMaxPromptSize, stage: StagePre, apply: func(content string) (string, error) {
+		if n := utf8.RuneCountInString(content); n > limit {
+			return "", &Violation{Reason: fmt.Sprintf("prompt is %d characters, more than the limit of %d", n, limit)}
+		}
+		return content, nil
+	}}
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	cardPattern  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	ssnPattern   = regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{3}\)|\b\d{3})[\s.-]?\d{3}[\s.-]?\d{4}\b`)
+)
+
+// PII replaces email addresses, payment card numbers, US social security
+// numbers and phone numbers in responses with placeholders
+func PII() Check {
+	return &check{name: CheckPII, stage: StagePost, apply: func(content string) (string, error) {
+		content = emailPattern.ReplaceAllString(content, "[email]")
+		content = cardPattern.ReplaceAllStringFunc(content, func(s string) string {
+			if luhn(s) {
+				return "[card]"
+			}
+			return s
+		})
+		content = ssnPattern.ReplaceAllString(content, "[ssn]")
+		content = phonePattern.ReplaceAllString(content, "[phone]")
+		return content, nil
+	}}
+}
+
+// luhn reports whether the digits in s pass the Luhn checksum used by
+// payment cards, so other long numbers are left alone
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// profanity is the built-in list masked by the profanity check; words
+// starting with these are masked too
+var profanity = []string{"fuck", "shit", "bitch", "asshole", "bastard", "cunt", "motherfuck", "bullshit"}
+
+// Profanity masks profane words in responses, keeping their first letter
+func Profanity(extra []string) Check {
+	pattern := wordPattern(append(append([]string{}, profanity...), extra...), true)
+	return &check{name: CheckProfanity, stage: StagePost, apply: func(content string) (string, error) {
+		return pattern.ReplaceAllStringFunc(content, mask), nil
+	}}
+}
+
+func mask(word string) string {
+	_, size := utf8.DecodeRuneInString(word)
+	return word[:size] + strings.Repeat("*", utf8.RuneCountInString(word[size:]))
+}
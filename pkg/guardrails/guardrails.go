@@ -0,0 +1,176 @@
+// Package guardrails checks prompts before they reach a model and responses
+// before they reach the client. Checks are grouped into named policies, each
+// a pipeline of pre-checks run on the prompt and post-checks run on the
+// response. A check either rejects content with a *Violation or rewrites it,
+// for example to scrub personal data.
+package guardrails
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Stage is when a check runs
+type Stage string
+
+const (
+	StagePre  Stage = "pre"  // On the prompt, before generation
+	StagePost Stage = "post" // On the response, before it is returned
+)
+
+// Check names
+const (
+	CheckBannedWords     = "banned_words"
+	CheckPromptInjection = "prompt_injection"
+	CheckMaxPromptSize   = "max_prompt_size"
+	CheckPII             = "pii"
+	CheckProfanity       = "profanity"
+)
+
+// PolicyNone is always defined and runs no checks
+const PolicyNone = "none"
+
+// Violation is the error returned when content breaks a policy
+type Violation struct {
+	Policy string `json:"policy"`
+	Check  string `json:"check"`
+	Stage  Stage  `json:"stage"`
+	Reason string `json:"reason"`
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%s check of policy %s failed: %s", v.Check, v.Policy, v.Reason)
+}
+
+// Check inspects content. It returns the content to continue with, which
+// may be rewritten, or a *Violation to reject it.
+type Check interface {
+	Name() string
+	Stage() Stage
+	Apply(content string) (string, error)
+}
+
+// Pipeline is a named policy's checks. A nil Pipeline runs no checks.
+type Pipeline struct {
+	name string
+	pre  []Check
+	post []Check
+}
+
+// NewPipeline creates a policy running checks in order within their stage
+func NewPipeline(name string, checks ...Check) *Pipeline {
+	p := &Pipeline{name: name}
+	for _, c := range checks {
+		if c.Stage() == StagePre {
+			p.pre = append(p.pre, c)
+		} else {
+			p.post = append(p.post, c)
+		}
+	}
+	return p
+}
+
+// Name returns the policy name
+func (p *Pipeline) Name() string {
+	if p == nil {
+		return PolicyNone
+	}
+	return p.name
+}
+
+// CheckPrompt runs the pre-checks on a prompt
+func (p *Pipeline) CheckPrompt(prompt string) (string, error) {
+	if p == nil {
+		return prompt, nil
+	}
+	return p.run(p.pre, prompt)
+}
+
+// CheckResponse runs the post-checks on a response
+func (p *Pipeline) CheckResponse(response string) (string, error) {
+	if p == nil {
+		return response, nil
+	}
+	return p.run(p.post, response)
+}
+
+func (p *Pipeline) run(checks []Check, content string) (string, error) {
+	for _, c := range checks {
+		var err error
+		content, err = c.Apply(content)
+		if err != nil {
+			if v, ok := err.(*Violation); ok {
+				v.Policy = p.name
+				v.Check = c.Name()
+				v.Stage = c.Stage()
+			}
+			return "", err
+		}
+	}
+	return content, nil
+}
+
+// Config holds the settings of the built-in checks
+type Config struct {
+	BannedWords    []string // Words rejected by banned_words
+	MaxPromptChars int      // Limit enforced by max_prompt_size. Default: 32000
+	ProfanityWords []string // Words masked by profanity in addition to the built-in list
+}
+
+// Policies holds the configured policies by name
+type Policies map[string]*Pipeline
+
+// Parse builds policies from definitions mapping each policy name to its
+// check names separated by "+", e.g. "strict" to
+// "prompt_injection+banned_words+pii"
+func Parse(defs map[string]string, cfg Config) (Policies, error) {
+	policies := Policies{PolicyNone: nil}
+	for name, def := range defs {
+		if name == PolicyNone {
+			return nil, fmt.Errorf("policy name %q is reserved", PolicyNone)
+		}
+		var checks []Check
+		for _, checkName := range strings.Split(def, "+") {
+			c, err := NewCheck(strings.TrimSpace(checkName), cfg)
+			if err != nil {
+				return nil, fmt.Errorf("policy %s: %w", name, err)
+			}
+			checks = append(checks, c)
+		}
+		policies[name] = NewPipeline(name, checks...)
+	}
+	return policies, nil
+}
+
+// Names returns the policy names in sorted order
+func (p Policies) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewCheck creates a built-in check by name
+func NewCheck(name string, cfg Config) (Check, error) {
+	switch name {
+	case CheckBannedWords:
+		return BannedWords(cfg.BannedWords), nil
+	case CheckPromptInjection:
+		return PromptInjection(), nil
+	case CheckMaxPromptSize:
+		limit := cfg.MaxPromptChars
+		if limit <= 0 {
+			limit = 32000
+		}
+		return MaxPromptSize(limit), nil
+	case CheckPII:
+		return PII(), nil
+	case CheckProfanity:
+		return Profanity(cfg.ProfanityWords), nil
+	default:
+		return nil, fmt.Errorf("unknown check %q", name)
+	}
+}
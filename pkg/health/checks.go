@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// TCPCheck creates a health check that succeeds when addr accepts a TCP
+// connection within timeout
+func TCPCheck(name string, addr string, timeout time.Duration) CheckFunc {
+	return func(ctx context.Context) *Check {
+		start := time.Now()
+
+		dialer := &net.Dialer{Timeout: timeout}
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return &Check{
+				Name:    name,
+				Status:  StatusUnhealthy,
+				Message: err.Error(),
+				Latency: time.Since(start),
+			}
+		}
+		conn.Close()
+
+		return &Check{
+			Name:    name,
+			Status:  StatusHealthy,
+			Latency: time.Since(start),
+		}
+	}
+}
+
+// GRPCCheck creates a health check from a client connection's state. Ready
+// and idle connections are healthy (an idle connection is asked to
+// reconnect), connecting is degraded, and failed or closed is unhealthy.
+func GRPCCheck(name string, conn *grpc.ClientConn) CheckFunc {
+	return func(ctx context.Context) *Check {
+		state := conn.GetState()
+		check := &Check{Name: name, Message: "connection " + state.String()}
+
+		switch state {
+		case connectivity.Ready:
+			check.Status = StatusHealthy
+			check.Message = ""
+		case connectivity.Idle:
+			conn.Connect()
+			check.Status = StatusHealthy
+		case connectivity.Connecting:
+			check.Status = StatusDegraded
+		default:
+			check.Status = StatusUnhealthy
+		}
+		return check
+	}
+}
+
+// DiskSpaceCheck creates a health check that fails when the filesystem
+// holding path has less than minFree (0-1) of its space available
+func DiskSpaceCheck(name string, path string, minFree float64) CheckFunc {
+	return func(ctx context.Context) *Check {
+		free, total, err := diskUsage(path)
+		if err != nil {
+			return &Check{Name: name, Status: StatusUnhealthy, Message: err.Error()}
+		}
+
+		ratio := 0.0
+		if total > 0 {
+			ratio = float64(free) / float64(total)
+		}
+		check := &Check{
+			Name:    name,
+			Status:  StatusHealthy,
+			Message: fmt.Sprintf("%.1f%% free (%d MiB)", ratio*100, free>>20),
+		}
+		if ratio < minFree {
+			check.Status = StatusUnhealthy
+		}
+		return check
+	}
+}
+
+// MemoryCheck creates a health check that fails when the memory the Go
+// runtime holds from the OS exceeds maxBytes
+func MemoryCheck(name string, maxBytes uint64) CheckFunc {
+	return func(ctx context.Context) *Check {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		used := stats.Sys - stats.HeapReleased
+
+		check := &Check{
+			Name:    name,
+			Status:  StatusHealthy,
+			Message: fmt.Sprintf("%d MiB of %d MiB", used>>20, maxBytes>>20),
+		}
+		if used > maxBytes {
+			check.Status = StatusUnhealthy
+		}
+		return check
+	}
+}
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestTCPCheck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	check := TCPCheck("tcp", addr, time.Second)(context.Background())
+	if check.Status != StatusHealthy {
+		t.Errorf("expected healthy, got %s: %s", check.Status, check.Message)
+	}
+
+	ln.Close()
+	check = TCPCheck("tcp", addr, time.Second)(context.Background())
+	if check.Status != StatusUnhealthy {
+		t.Errorf("expected unhealthy after close, got %s", check.Status)
+	}
+}
+
+func TestGRPCCheck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	go server.Serve(ln)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := GRPCCheck("grpc", conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The first call moves an idle connection to connecting
+	for {
+		result := check(ctx)
+		if result.Status == StatusHealthy && result.Message == "" {
+			break
+		}
+		if result.Status == StatusUnhealthy {
+			t.Fatalf("expected connection to become ready, got %s", result.Message)
+		}
+		if ctx.Err() != nil {
+			t.Fatal("timed out waiting for ready connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	conn.Close()
+	if result := check(ctx); result.Status != StatusUnhealthy {
+		t.Errorf("expected unhealthy after close, got %s", result.Status)
+	}
+}
+
+func TestDiskSpaceCheck(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("disk space checks are not supported on " + runtime.GOOS)
+	}
+	dir := t.TempDir()
+
+	if check := DiskSpaceCheck("disk", dir, 0)(context.Background()); check.Status != StatusHealthy {
+		t.Errorf("expected healthy with no minimum, got %s: %s", check.Status, check.Message)
+	}
+	if check := DiskSpaceCheck("disk", dir, 1.01)(context.Background()); check.Status != StatusUnhealthy {
+		t.Errorf("expected unhealthy above 100%% minimum, got %s", check.Status)
+	}
+	if check := DiskSpaceCheck("disk", dir+"/missing", 0)(context.Background()); check.Status != StatusUnhealthy {
+		t.Errorf("expected unhealthy for missing path, got %s", check.Status)
+	}
+}
+
+func TestMemoryCheck(t *testing.T) {
+	if check := MemoryCheck("memory", 1<<40)(context.Background()); check.Status != StatusHealthy {
+		t.Errorf("expected healthy under a 1 TiB limit, got %s: %s", check.Status, check.Message)
+	}
+	if check := MemoryCheck("memory", 1)(context.Background()); check.Status != StatusUnhealthy {
+		t.Errorf("expected unhealthy over a 1 byte limit, got %s", check.Status)
+	}
+}
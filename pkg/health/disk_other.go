@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package health
+
+import "errors"
+
+// diskUsage reports that disk space checks are unavailable on this platform
+func diskUsage(path string) (free, total uint64, err error) {
+	return 0, 0, errors.New("disk space checks are not supported on this platform")
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin
+
+package health
+
+import "golang.org/x/sys/unix"
+
+// diskUsage returns the bytes available to unprivileged users and the total
+// size of the filesystem holding path
+func diskUsage(path string) (free, total uint64, err error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return st.Bavail * uint64(st.Bsize), st.Blocks * uint64(st.Bsize), nil
+}
@@ -3,8 +3,10 @@ package health
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,6 +27,21 @@ type Check struct {
 	Latency time.Duration `json:"latency_ms,omitempty"`
 }
 
+// MarshalJSON encodes Latency in milliseconds rather than nanoseconds
+func (c *Check) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name    string  `json:"name"`
+		Status  Status  `json:"status"`
+		Message string  `json:"message,omitempty"`
+		Latency float64 `json:"latency_ms,omitempty"`
+	}{
+		Name:    c.Name,
+		Status:  c.Status,
+		Message: c.Message,
+		Latency: float64(c.Latency) / float64(time.Millisecond),
+	})
+}
+
 // Response represents the health check response
 type Response struct {
 	Status    Status            `json:"status"`
@@ -33,51 +50,121 @@ type Response struct {
 	Checks    map[string]*Check `json:"checks,omitempty"`
 }
 
+// DefaultCheckTimeout bounds each check registered without its own timeout
+const DefaultCheckTimeout = 3 * time.Second
+
 // Checker manages health checks for a service
 type Checker struct {
-	mu      sync.RWMutex
-	version string
-	checks  map[string]CheckFunc
-	results map[string]*Check
+	mu       sync.RWMutex
+	version  string
+	checks   map[string]registeredCheck
+	latest   atomic.Pointer[Response] // set while the background runner is active
+	draining atomic.Bool
+
+	// Flap suppression: the reported status only changes after enough
+	// consecutive runs agree on a new one
+	failureThreshold int
+	successThreshold int
+	listeners        []func(from, to Status)
+
+	stateMu   sync.Mutex
+	status    Status // reported overall status, empty before the first run
+	candidate Status // status observed in the current streak
+	streak    int
 }
 
 // CheckFunc is a function that performs a health check
 type CheckFunc func(ctx context.Context) *Check
 
+type registeredCheck struct {
+	fn      CheckFunc
+	timeout time.Duration
+}
+
 // NewChecker creates a new health checker
 func NewChecker(version string) *Checker {
 	return &Checker{
-		version: version,
-		checks:  make(map[string]CheckFunc),
-		results: make(map[string]*Check),
+		version:          version,
+		checks:           make(map[string]registeredCheck),
+		failureThreshold: 1,
+		successThreshold: 1,
 	}
 }
 
-// Register adds a health check
-func (h *Checker) Register(name string, check CheckFunc) {
+// SetThresholds sets how many consecutive runs must observe a new overall
+// status before it is reported: failures for a worse status, successes for
+// a better one. Values below 1 are treated as 1, which disables suppression.
+func (h *Checker) SetThresholds(failures, successes int) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.checks[name] = check
+	h.failureThreshold = max(failures, 1)
+	h.successThreshold = max(successes, 1)
 }
 
-// Run executes all health checks
-func (h *Checker) Run(ctx context.Context) *Response {
+// OnStatusChange registers a callback run when the reported overall status
+// changes. Callbacks run synchronously at the end of Run and should be quick.
+func (h *Checker) OnStatusChange(fn func(from, to Status)) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.listeners = append(h.listeners, fn)
+}
+
+// Status returns the reported overall status, or "" before the first run
+func (h *Checker) Status() Status {
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+	return h.status
+}
+
+// Register adds a health check bounded by DefaultCheckTimeout
+func (h *Checker) Register(name string, check CheckFunc) {
+	h.RegisterWithTimeout(name, DefaultCheckTimeout, check)
+}
+
+// RegisterWithTimeout adds a health check that is reported unhealthy if it
+// doesn't finish within timeout
+func (h *Checker) RegisterWithTimeout(name string, timeout time.Duration, check CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = registeredCheck{fn: check, timeout: timeout}
+}
+
+// Run executes all health checks concurrently. The lock is only held while
+// copying the registered checks, so a slow check never blocks other callers.
+func (h *Checker) Run(ctx context.Context) *Response {
+	h.mu.RLock()
+	checks := make(map[string]registeredCheck, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	failures, successes := h.failureThreshold, h.successThreshold
+	listeners := h.listeners
+	h.mu.RUnlock()
 
 	response := &Response{
 		Status:    StatusHealthy,
 		Timestamp: time.Now(),
 		Version:   h.version,
-		Checks:    make(map[string]*Check),
+		Checks:    make(map[string]*Check, len(checks)),
+	}
+
+	type namedResult struct {
+		name   string
+		result *Check
+	}
+	results := make(chan namedResult, len(checks))
+	for name, check := range checks {
+		go func() {
+			results <- namedResult{name, runCheck(ctx, name, check)}
+		}()
 	}
 
-	for name, checkFn := range h.checks {
-		result := checkFn(ctx)
-		response.Checks[name] = result
+	for range checks {
+		r := <-results
+		response.Checks[r.name] = r.result
 
 		// Update overall status based on individual checks
-		switch result.Status {
+		switch r.result.Status {
 		case StatusUnhealthy:
 			response.Status = StatusUnhealthy
 		case StatusDegraded:
@@ -87,16 +174,130 @@ func (h *Checker) Run(ctx context.Context) *Response {
 		}
 	}
 
+	from, to, changed := h.settle(response.Status, failures, successes)
+	response.Status = to
+	if changed {
+		for _, fn := range listeners {
+			fn(from, to)
+		}
+	}
+
 	return response
 }
 
+// settle records an observed overall status and returns the status to
+// report, flipping only once the observation has held for the threshold
+func (h *Checker) settle(observed Status, failures, successes int) (from, to Status, changed bool) {
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+
+	if h.status == "" || observed == h.status {
+		h.status = observed
+		h.candidate, h.streak = "", 0
+		return h.status, h.status, false
+	}
+
+	if observed != h.candidate {
+		h.candidate, h.streak = observed, 0
+	}
+	h.streak++
+
+	threshold := successes
+	if severity(observed) > severity(h.status) {
+		threshold = failures
+	}
+	if h.streak < threshold {
+		return h.status, h.status, false
+	}
+
+	from = h.status
+	h.status = observed
+	h.candidate, h.streak = "", 0
+	return from, observed, true
+}
+
+// severity orders statuses from best to worst
+func severity(s Status) int {
+	switch s {
+	case StatusHealthy:
+		return 0
+	case StatusDegraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Start runs all checks every interval in the background until ctx is
+// cancelled. While running, the HTTP handlers serve the latest snapshot
+// instead of calling dependencies on every probe. The first run completes
+// before Start returns.
+func (h *Checker) Start(ctx context.Context, interval time.Duration) {
+	h.latest.Store(h.Run(ctx))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		// Fall back to live checks once stopped so results never go stale
+		defer h.latest.Store(nil)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.latest.Store(h.Run(ctx))
+			}
+		}
+	}()
+}
+
+// Latest returns the most recent background snapshot, or nil if the
+// background runner isn't active
+func (h *Checker) Latest() *Response {
+	return h.latest.Load()
+}
+
+// runCheck runs a single check with its timeout. A check that ignores its
+// context is abandoned at the deadline and reported unhealthy.
+func runCheck(ctx context.Context, name string, check registeredCheck) *Check {
+	ctx, cancel := context.WithTimeout(ctx, check.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan *Check, 1)
+	go func() {
+		done <- check.fn(ctx)
+	}()
+
+	select {
+	case result := <-done:
+		if result == nil {
+			result = &Check{Name: name, Status: StatusUnhealthy, Message: "check returned no result"}
+		}
+		if result.Latency == 0 {
+			result.Latency = time.Since(start)
+		}
+		return result
+	case <-ctx.Done():
+		return &Check{
+			Name:    name,
+			Status:  StatusUnhealthy,
+			Message: "check timed out after " + time.Since(start).Round(time.Millisecond).String(),
+			Latency: time.Since(start),
+		}
+	}
+}
+
 // HTTPHandler returns an HTTP handler for health checks
 func (h *Checker) HTTPHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-		defer cancel()
-
-		response := h.Run(ctx)
+		response := h.latest.Load()
+		if response == nil {
+			ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+			defer cancel()
+			response = h.Run(ctx)
+		}
 
 		statusCode := http.StatusOK
 		if response.Status == StatusUnhealthy {
@@ -105,9 +306,51 @@ func (h *Checker) HTTPHandler() http.HandlerFunc {
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// SetDraining marks the service as shutting down so readiness fails and
+// load balancers stop sending new traffic, while liveness still passes
+func (h *Checker) SetDraining(draining bool) {
+	h.draining.Store(draining)
+}
+
+// Draining reports whether the service is shutting down
+func (h *Checker) Draining() bool {
+	return h.draining.Load()
+}
+
+// LiveHandler returns an HTTP handler for liveness probes. It only reports
+// that the process is up and serving HTTP; dependency failures must not
+// cause a restart, so no checks are run.
+func (h *Checker) LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "alive",
+			"timestamp": time.Now(),
+			"version":   h.version,
+		})
+	}
+}
+
+// ReadyHandler returns an HTTP handler for readiness probes. It returns 503
+// while draining or when any check is unhealthy; degraded is still ready.
+func (h *Checker) ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.draining.Load() {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"status":    "draining",
+				"timestamp": time.Now(),
+				"version":   h.version,
+			})
+			return
+		}
 
-		// Simple JSON encoding
-		w.Write([]byte(`{"status":"` + string(response.Status) + `","version":"` + response.Version + `"}`))
+		h.HTTPHandler()(w, r)
 	}
 }
 
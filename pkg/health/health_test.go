@@ -0,0 +1,357 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRun_AggregatesStatus(t *testing.T) {
+	h := NewChecker("1.0.0")
+	h.Register("ok", func(ctx context.Context) *Check {
+		return &Check{Name: "ok", Status: StatusHealthy}
+	})
+	h.Register("slow", func(ctx context.Context) *Check {
+		return &Check{Name: "slow", Status: StatusDegraded, Message: "slow"}
+	})
+
+	resp := h.Run(context.Background())
+	if resp.Status != StatusDegraded {
+		t.Errorf("expected degraded, got %s", resp.Status)
+	}
+
+	h.Register("down", func(ctx context.Context) *Check {
+		return &Check{Name: "down", Status: StatusUnhealthy}
+	})
+
+	resp = h.Run(context.Background())
+	if resp.Status != StatusUnhealthy {
+		t.Errorf("expected unhealthy, got %s", resp.Status)
+	}
+}
+
+func TestRun_ChecksRunConcurrently(t *testing.T) {
+	h := NewChecker("1.0.0")
+	for _, name := range []string{"a", "b", "c"} {
+		h.Register(name, func(ctx context.Context) *Check {
+			time.Sleep(100 * time.Millisecond)
+			return &Check{Name: name, Status: StatusHealthy}
+		})
+	}
+
+	start := time.Now()
+	resp := h.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("expected checks to run concurrently, took %v", elapsed)
+	}
+	if len(resp.Checks) != 3 || resp.Status != StatusHealthy {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestRun_PerCheckTimeout(t *testing.T) {
+	h := NewChecker("1.0.0")
+	block := make(chan struct{})
+	defer close(block)
+
+	h.RegisterWithTimeout("stuck", 50*time.Millisecond, func(ctx context.Context) *Check {
+		<-block // ignores its context
+		return &Check{Name: "stuck", Status: StatusHealthy}
+	})
+	h.Register("ok", func(ctx context.Context) *Check {
+		return &Check{Name: "ok", Status: StatusHealthy}
+	})
+
+	start := time.Now()
+	resp := h.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected stuck check to be abandoned, took %v", elapsed)
+	}
+
+	if resp.Status != StatusUnhealthy {
+		t.Errorf("expected unhealthy, got %s", resp.Status)
+	}
+	if c := resp.Checks["stuck"]; c == nil || c.Status != StatusUnhealthy {
+		t.Errorf("expected stuck check to be unhealthy, got %+v", c)
+	}
+	if c := resp.Checks["ok"]; c == nil || c.Status != StatusHealthy {
+		t.Errorf("expected ok check to be healthy, got %+v", c)
+	}
+}
+
+func TestRun_DoesNotHoldLock(t *testing.T) {
+	h := NewChecker("1.0.0")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h.Register("slow", func(ctx context.Context) *Check {
+		close(started)
+		<-release
+		return &Check{Name: "slow", Status: StatusHealthy}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		h.Run(context.Background())
+		close(done)
+	}()
+	<-started
+
+	registered := make(chan struct{})
+	go func() {
+		h.Register("other", func(ctx context.Context) *Check {
+			return &Check{Name: "other", Status: StatusHealthy}
+		})
+		close(registered)
+	}()
+
+	select {
+	case <-registered:
+	case <-time.After(time.Second):
+		t.Error("Register blocked while a check was running")
+	}
+	close(release)
+	<-done
+}
+
+func TestRun_FlapSuppression(t *testing.T) {
+	var status atomic.Value
+	status.Store(StatusHealthy)
+	h := NewChecker("1.0.0")
+	h.Register("dep", func(ctx context.Context) *Check {
+		return &Check{Name: "dep", Status: status.Load().(Status)}
+	})
+	h.SetThresholds(3, 2)
+
+	type change struct{ from, to Status }
+	var changes []change
+	h.OnStatusChange(func(from, to Status) {
+		changes = append(changes, change{from, to})
+	})
+
+	steps := []struct {
+		observed Status
+		want     Status
+	}{
+		{StatusHealthy, StatusHealthy},
+		{StatusUnhealthy, StatusHealthy}, // blip is suppressed
+		{StatusHealthy, StatusHealthy},   // and resets the streak
+		{StatusUnhealthy, StatusHealthy},
+		{StatusUnhealthy, StatusHealthy},
+		{StatusUnhealthy, StatusUnhealthy}, // third consecutive failure flips
+		{StatusHealthy, StatusUnhealthy},
+		{StatusHealthy, StatusHealthy}, // second consecutive success recovers
+	}
+
+	for i, step := range steps {
+		status.Store(step.observed)
+		if got := h.Run(context.Background()).Status; got != step.want {
+			t.Errorf("step %d: observed %s, expected reported %s, got %s", i, step.observed, step.want, got)
+		}
+	}
+
+	want := []change{{StatusHealthy, StatusUnhealthy}, {StatusUnhealthy, StatusHealthy}}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d status changes, got %v", len(want), changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d: expected %v, got %v", i, want[i], changes[i])
+		}
+	}
+	if h.Status() != StatusHealthy {
+		t.Errorf("expected Status() healthy, got %s", h.Status())
+	}
+}
+
+func TestRun_DefaultThresholdsReportImmediately(t *testing.T) {
+	var status atomic.Value
+	status.Store(StatusHealthy)
+	h := NewChecker("1.0.0")
+	h.Register("dep", func(ctx context.Context) *Check {
+		return &Check{Name: "dep", Status: status.Load().(Status)}
+	})
+
+	var changed int
+	h.OnStatusChange(func(from, to Status) { changed++ })
+
+	h.Run(context.Background())
+	status.Store(StatusDegraded)
+	if got := h.Run(context.Background()).Status; got != StatusDegraded {
+		t.Errorf("expected degraded, got %s", got)
+	}
+	if changed != 1 {
+		t.Errorf("expected 1 status change, got %d", changed)
+	}
+}
+
+func TestStart_ServesCachedSnapshot(t *testing.T) {
+	var calls atomic.Int32
+	h := NewChecker("1.0.0")
+	h.Register("dep", func(ctx context.Context) *Check {
+		calls.Add(1)
+		return &Check{Name: "dep", Status: StatusHealthy}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.Start(ctx, time.Hour)
+	if calls.Load() != 1 {
+		t.Fatalf("expected Start to run checks once, got %d", calls.Load())
+	}
+
+	for i := 0; i < 5; i++ {
+		rec := httptest.NewRecorder()
+		h.HTTPHandler()(rec, httptest.NewRequest("GET", "/health", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d", rec.Code)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected probes to use the cached snapshot, got %d check runs", calls.Load())
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for h.Latest() != nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if h.Latest() != nil {
+		t.Fatal("expected snapshot to be cleared after stopping")
+	}
+
+	h.HTTPHandler()(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	if calls.Load() != 2 {
+		t.Errorf("expected live check after stopping, got %d check runs", calls.Load())
+	}
+}
+
+func TestStart_RefreshesPeriodically(t *testing.T) {
+	var status atomic.Value
+	status.Store(StatusHealthy)
+	h := NewChecker("1.0.0")
+	h.Register("dep", func(ctx context.Context) *Check {
+		return &Check{Name: "dep", Status: status.Load().(Status)}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.Start(ctx, 10*time.Millisecond)
+
+	status.Store(StatusUnhealthy)
+	deadline := time.Now().Add(time.Second)
+	for h.Latest().Status != StatusUnhealthy && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := h.Latest().Status; got != StatusUnhealthy {
+		t.Errorf("expected refreshed snapshot to be unhealthy, got %s", got)
+	}
+}
+
+func TestHTTPHandler_ReturnsFullResponse(t *testing.T) {
+	h := NewChecker("1.2.3")
+	h.Register("db", func(ctx context.Context) *Check {
+		return &Check{Name: "db", Status: StatusDegraded, Message: "replica lag", Latency: 1500 * time.Microsecond}
+	})
+
+	rec := httptest.NewRecorder()
+	h.HTTPHandler()(rec, httptest.NewRequest("GET", "/health", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for degraded, got %d", rec.Code)
+	}
+
+	var body struct {
+		Status    Status    `json:"status"`
+		Version   string    `json:"version"`
+		Timestamp time.Time `json:"timestamp"`
+		Checks    map[string]struct {
+			Name      string  `json:"name"`
+			Status    Status  `json:"status"`
+			Message   string  `json:"message"`
+			LatencyMs float64 `json:"latency_ms"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if body.Status != StatusDegraded || body.Version != "1.2.3" || body.Timestamp.IsZero() {
+		t.Errorf("unexpected top-level fields: %+v", body)
+	}
+
+	db, ok := body.Checks["db"]
+	if !ok {
+		t.Fatal("expected db check in response")
+	}
+	if db.Message != "replica lag" {
+		t.Errorf("expected message, got %q", db.Message)
+	}
+	if db.LatencyMs != 1.5 {
+		t.Errorf("expected latency 1.5ms, got %v", db.LatencyMs)
+	}
+}
+
+func TestHTTPHandler_UnhealthyReturns503(t *testing.T) {
+	h := NewChecker("1.0.0")
+	h.Register("down", func(ctx context.Context) *Check {
+		return &Check{Name: "down", Status: StatusUnhealthy}
+	})
+
+	rec := httptest.NewRecorder()
+	h.HTTPHandler()(rec, httptest.NewRequest("GET", "/health", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+}
+
+func TestLiveHandler_IgnoresChecks(t *testing.T) {
+	h := NewChecker("1.0.0")
+	h.Register("down", func(ctx context.Context) *Check {
+		t.Error("liveness must not run checks")
+		return &Check{Name: "down", Status: StatusUnhealthy}
+	})
+
+	rec := httptest.NewRecorder()
+	h.LiveHandler()(rec, httptest.NewRequest("GET", "/health/live", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	status := StatusDegraded
+	h := NewChecker("1.0.0")
+	h.Register("dep", func(ctx context.Context) *Check {
+		return &Check{Name: "dep", Status: status}
+	})
+
+	tests := []struct {
+		name     string
+		status   Status
+		draining bool
+		want     int
+	}{
+		{"degraded is ready", StatusDegraded, false, http.StatusOK},
+		{"unhealthy is not ready", StatusUnhealthy, false, http.StatusServiceUnavailable},
+		{"draining is not ready", StatusHealthy, true, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status = tt.status
+			h.SetDraining(tt.draining)
+
+			rec := httptest.NewRecorder()
+			h.ReadyHandler()(rec, httptest.NewRequest("GET", "/health/ready", nil))
+
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
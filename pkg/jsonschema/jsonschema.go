@@ -0,0 +1,341 @@
+// Package jsonschema validates JSON documents against the subset of JSON
+// Schema that models are asked to follow for structured output
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a compiled JSON Schema. The supported keywords are type, enum,
+// const, properties, required, additionalProperties, items, minItems,
+// maxItems, minLength, maxLength, minimum, maximum, anyOf, oneOf and allOf;
+// others, such as $ref and format, are ignored.
+type Schema struct {
+	Types                []string
+	Enum                 []interface{}
+	Const                *interface{}
+	Properties           map[string]*Schema
+	Required             []string
+	AdditionalProperties *Schema // nil allows any; a schema with Never set allows none
+	Items                *Schema
+	MinItems, MaxItems   *int
+	MinLength, MaxLength *int
+	Minimum, Maximum     *float64
+	AnyOf, OneOf, AllOf  []*Schema
+
+	// Never is set for the schema false, which no value matches
+	Never bool
+}
+
+// Compile parses a schema document
+func Compile(data []byte) (*Schema, error) {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return compile(raw, "")
+}
+
+func compile(raw interface{}, path string) (*Schema, error) {
+	switch v := raw.(type) {
+	case bool:
+		return &Schema{Never: !v}, nil
+	case map[string]interface{}:
+		return compileObject(v, path)
+	}
+	return nil, fmt.Errorf("invalid schema at %s: expected an object or boolean", pointer(path))
+}
+
+func compileObject(m map[string]interface{}, path string) (*Schema, error) {
+	s := &Schema{}
+	var err error
+
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		s.Types = []string{t}
+	case []interface{}:
+		for _, name := range t {
+			str, ok := name.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid schema at %s: type must be a string or array of strings", pointer(path))
+			}
+			s.Types = append(s.Types, str)
+		}
+	default:
+		return nil, fmt.Errorf("invalid schema at %s: type must be a string or array of strings", pointer(path))
+	}
+
+	if enum, ok := m["enum"].([]interface{}); ok {
+		s.Enum = enum
+	}
+	if c, ok := m["const"]; ok {
+		s.Const = &c
+	}
+
+	if props, ok := m["properties"].(map[string]interface{}); ok {
+		s.Properties = make(map[string]*Schema, len(props))
+		for name, raw := range props {
+			if s.Properties[name], err = compile(raw, path+"/properties/"+name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if required, ok := m["required"].([]interface{}); ok {
+		for _, name := range required {
+			if str, ok := name.(string); ok {
+				s.Required = append(s.Required, str)
+			}
+		}
+	}
+	if raw, ok := m["additionalProperties"]; ok {
+		if s.AdditionalProperties, err = compile(raw, path+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+	if raw, ok := m["items"]; ok {
+		if s.Items, err = compile(raw, path+"/items"); err != nil {
+			return nil, err
+		}
+	}
+
+	s.MinItems = intKeyword(m, "minItems")
+	s.MaxItems = intKeyword(m, "maxItems")
+	s.MinLength = intKeyword(m, "minLength")
+	s.MaxLength = intKeyword(m, "maxLength")
+	s.Minimum = numberKeyword(m, "minimum")
+	s.Maximum = numberKeyword(m, "maximum")
+
+	for _, keyword := range []string{"anyOf", "oneOf", "allOf"} {
+		list, ok := m[keyword].([]interface{})
+		if !ok {
+			continue
+		}
+		schemas := make([]*Schema, len(list))
+		for i, raw := range list {
+			if schemas[i], err = compile(raw, fmt.Sprintf("%s/%s/%d", path, keyword, i)); err != nil {
+				return nil, err
+			}
+		}
+		switch keyword {
+		case "anyOf":
+			s.AnyOf = schemas
+		case "oneOf":
+			s.OneOf = schemas
+		case "allOf":
+			s.AllOf = schemas
+		}
+	}
+
+	return s, nil
+}
+
+// ValidationError describes where a document doesn't match its schema
+type ValidationError struct {
+	Path    string // JSON Pointer to the offending value, "/" for the root
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks that a JSON document matches the schema, returning a
+// *ValidationError for the first mismatch found
+func (s *Schema) Validate(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return &ValidationError{Path: "/", Message: "invalid JSON: " + err.Error()}
+	}
+	if dec.More() {
+		return &ValidationError{Path: "/", Message: "invalid JSON: unexpected data after the document"}
+	}
+	return s.validate(doc, "")
+}
+
+func (s *Schema) validate(v interface{}, path string) error {
+	fail := func(format string, args ...interface{}) error {
+		return &ValidationError{Path: pointer(path), Message: fmt.Sprintf(format, args...)}
+	}
+
+	if s.Never {
+		return fail("no value is allowed")
+	}
+	if len(s.Types) > 0 && !slices.ContainsFunc(s.Types, func(t string) bool { return hasType(v, t) }) {
+		return fail("expected %s, got %s", strings.Join(s.Types, " or "), typeOf(v))
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e interface{}) bool { return equal(v, e) }) {
+		return fail("value is not one of the allowed values")
+	}
+	if s.Const != nil && !equal(v, *s.Const) {
+		return fail("value does not match the constant")
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fail("missing required property %q", name)
+			}
+		}
+		// Check properties in a stable order so the same error is reported
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			if prop == nil {
+				continue
+			}
+			if prop.Never && !ok {
+				return fail("unexpected property %q", name)
+			}
+			if err := prop.validate(v[name], path+"/"+escape(name)); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fail("expected at least %d items, got %d", *s.MinItems, len(v))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return fail("expected at most %d items, got %d", *s.MaxItems, len(v))
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			return fail("expected at least %d characters, got %d", *s.MinLength, n)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return fail("expected at most %d characters, got %d", *s.MaxLength, n)
+		}
+
+	case json.Number:
+		f, _ := v.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			return fail("expected at least %v, got %v", *s.Minimum, v)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fail("expected at most %v, got %v", *s.Maximum, v)
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		if err := sub.validate(v, path); err != nil {
+			return err
+		}
+	}
+	if len(s.AnyOf) > 0 && !slices.ContainsFunc(s.AnyOf, func(sub *Schema) bool { return sub.validate(v, path) == nil }) {
+		return fail("value matches none of the anyOf schemas")
+	}
+	if len(s.OneOf) > 0 {
+		matches := 0
+		for _, sub := range s.OneOf {
+			if sub.validate(v, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fail("value matches %d of the oneOf schemas, expected exactly 1", matches)
+		}
+	}
+
+	return nil
+}
+
+// hasType reports whether v is of the named JSON Schema type
+func hasType(v interface{}, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == float64(int64(f))
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	}
+	return typeOf(v) == t
+}
+
+// typeOf returns the JSON Schema type name of a decoded value
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// equal compares a decoded document value with a schema value. Numbers in
+// documents are json.Number while those in schemas are float64.
+func equal(doc, schema interface{}) bool {
+	if n, ok := doc.(json.Number); ok {
+		f, err := n.Float64()
+		return err == nil && schema == f
+	}
+	a, _ := json.Marshal(doc)
+	b, _ := json.Marshal(schema)
+	return bytes.Equal(a, b)
+}
+
+func intKeyword(m map[string]interface{}, name string) *int {
+	if f, ok := m[name].(float64); ok {
+		n := int(f)
+		return &n
+	}
+	return nil
+}
+
+func numberKeyword(m map[string]interface{}, name string) *float64 {
+	if f, ok := m[name].(float64); ok {
+		return &f
+	}
+	return nil
+}
+
+// pointer formats a path as a JSON Pointer, using "/" for the root
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// escape escapes a property name for use in a JSON Pointer
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
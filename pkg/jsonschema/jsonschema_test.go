@@ -0,0 +1,113 @@
+package jsonschema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const personSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0, "maximum": 150},
+		"role": {"enum": ["admin", "user"]},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+		"email": {"type": ["string", "null"]}
+	},
+	"required": ["name", "age"],
+	"additionalProperties": false
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := Compile([]byte(personSchema))
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		path string // Expected error path, empty if valid
+	}{
+		{"valid", `{"name": "Ada", "age": 36, "role": "admin", "tags": ["x"], "email": null}`, ""},
+		{"minimal", `{"name": "Ada", "age": 36}`, ""},
+		{"missing required", `{"name": "Ada"}`, "/"},
+		{"wrong type", `{"name": "Ada", "age": "36"}`, "/age"},
+		{"not an integer", `{"name": "Ada", "age": 36.5}`, "/age"},
+		{"below minimum", `{"name": "Ada", "age": -1}`, "/age"},
+		{"too short", `{"name": "", "age": 1}`, "/name"},
+		{"not in enum", `{"name": "Ada", "age": 1, "role": "root"}`, "/role"},
+		{"bad item", `{"name": "Ada", "age": 1, "tags": [1]}`, "/tags/0"},
+		{"too many items", `{"name": "Ada", "age": 1, "tags": ["a", "b", "c"]}`, "/tags"},
+		{"additional property", `{"name": "Ada", "age": 1, "extra": true}`, "/"},
+		{"invalid JSON", `{"name": "Ada",`, "/"},
+		{"trailing data", `{"name": "Ada", "age": 1} {}`, "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate([]byte(tt.doc))
+			if tt.path == "" {
+				if err != nil {
+					t.Errorf("expected valid, got %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected a ValidationError, got %v", err)
+			}
+			if verr.Path != tt.path {
+				t.Errorf("expected error at %s, got %v", tt.path, verr)
+			}
+		})
+	}
+}
+
+func TestSchema_Combinators(t *testing.T) {
+	schema, err := Compile([]byte(`{
+		"anyOf": [{"type": "string"}, {"type": "number"}],
+		"oneOf": [{"type": "integer"}, {"type": "string", "maxLength": 3}, {"type": "number", "maximum": 1}]
+	}`))
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+
+	for doc, valid := range map[string]bool{
+		`"abc"`:  true,
+		`"abcd"`: false, // Matches no oneOf schema
+		`5`:      true,
+		`0`:      false, // Matches two oneOf schemas
+		`true`:   false, // Matches no anyOf schema
+	} {
+		if err := schema.Validate([]byte(doc)); (err == nil) != valid {
+			t.Errorf("%s: expected valid=%v, got %v", doc, valid, err)
+		}
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, schema := range []string{`not json`, `"string"`, `{"type": 5}`, `{"properties": {"a": 1}}`} {
+		if _, err := Compile([]byte(schema)); err == nil {
+			t.Errorf("%s: expected error", schema)
+		}
+	}
+}
+
+func TestSchema_EmptyAllowsAnything(t *testing.T) {
+	schema, err := Compile([]byte(`{}`))
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+
+	for _, doc := range []string{`null`, `1`, `"x"`, `[1, "a"]`, `{"a": {"b": []}}`} {
+		if err := schema.Validate([]byte(doc)); err != nil {
+			t.Errorf("%s: expected valid, got %v", doc, err)
+		}
+	}
+	if err := schema.Validate([]byte(`{`)); err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Errorf("expected invalid JSON error, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var secret = []byte("test-secret")
+
+func TestVerify(t *testing.T) {
+	v, err := NewVerifier(Config{Secret: secret, Issuer: "idp", Audience: "neurogate"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	token, _ := Sign(secret, Claims{
+		"iss":    "idp",
+		"aud":    []string{"other", "neurogate"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"tenant": "search",
+	})
+	if !Looks(token) {
+		t.Errorf("expected %q to look like a JWT", token)
+	}
+	claims, err := v.Verify(token)
+	if err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+	if claims.String("tenant") != "search" || claims.String("exp") != "" {
+		t.Errorf("unexpected claims %v", claims)
+	}
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	v, _ := NewVerifier(Config{Secret: secret, Issuer: "idp", Audience: "neurogate"})
+	valid := Claims{"iss": "idp", "aud": "neurogate"}
+	with := func(name string, value interface{}) Claims {
+		c := Claims{}
+		for k, v := range valid {
+			c[k] = v
+		}
+		c[name] = value
+		return c
+	}
+	sign := func(secret []byte, claims Claims) string {
+		token, _ := Sign(secret, claims)
+		return token
+	}
+
+	good := sign(secret, valid)
+	parts := strings.Split(good, ".")
+	none := "eyJhbGciOiJub25lIn0." + parts[1] + "."
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"wrong secret", sign([]byte("other"), valid), ErrInvalidSignature},
+		{"tampered payload", parts[0] + "." + strings.Split(sign(secret, with("tenant", "x")), ".")[1] + "." + parts[2], ErrInvalidSignature},
+		{"alg none", none, ErrMalformed},
+		{"not a JWT", "sk-123", ErrMalformed},
+		{"expired", sign(secret, with("exp", time.Now().Add(-time.Hour).Unix())), ErrExpired},
+		{"not yet valid", sign(secret, with("nbf", time.Now().Add(time.Hour).Unix())), ErrExpired},
+		{"wrong issuer", sign(secret, with("iss", "other")), nil},
+		{"wrong audience", sign(secret, with("aud", []string{"other"})), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(tt.token)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestVerify_Leeway(t *testing.T) {
+	v, _ := NewVerifier(Config{Secret: secret, Leeway: time.Minute})
+	token, _ := Sign(secret, Claims{"exp": time.Now().Add(-30 * time.Second).Unix()})
+	if _, err := v.Verify(token); err != nil {
+		t.Errorf("expected token within leeway to verify, got %v", err)
+	}
+
+	if _, err := NewVerifier(Config{}); err == nil {
+		t.Error("expected error without a secret")
+	}
+}
@@ -0,0 +1,62 @@
+// Package listener creates network listeners that support zero-downtime
+// binary upgrades through systemd socket activation or SO_REUSEPORT
+package listener
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd
+const listenFDsStart = 3
+
+// Activated returns the listeners passed to this process by systemd socket
+// activation, in the order they are configured in the socket unit. It
+// returns nil when the process was not socket-activated. The activation
+// environment is cleared so child processes don't inherit it.
+func Activated() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, n)
+	for fd := listenFDsStart; fd < listenFDsStart+n; fd++ {
+		f := os.NewFile(uintptr(fd), "listen-fd-"+strconv.Itoa(fd))
+		ln, err := net.FileListener(f)
+		// FileListener dups the descriptor, so the original can be closed
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("fd %d is not a listening socket: %w", fd, err)
+		}
+		listeners = append(listeners, ln)
+	}
+
+	return listeners, nil
+}
+
+// Listen binds a TCP listener on addr. With reusePort set the socket uses
+// SO_REUSEPORT, so a newly started process can bind the same address while
+// the old one drains its connections.
+func Listen(addr string, reusePort bool) (net.Listener, error) {
+	lc := net.ListenConfig{}
+	if reusePort {
+		lc.Control = setReusePort
+	}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
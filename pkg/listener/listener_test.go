@@ -0,0 +1,59 @@
+package listener
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestListen_ReusePortAllowsSecondBind(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("SO_REUSEPORT not supported on " + runtime.GOOS)
+	}
+
+	first, err := Listen("127.0.0.1:0", true)
+	if err != nil {
+		t.Fatalf("first listen failed: %v", err)
+	}
+	defer first.Close()
+
+	second, err := Listen(first.Addr().String(), true)
+	if err != nil {
+		t.Fatalf("expected second bind with SO_REUSEPORT to succeed, got %v", err)
+	}
+	second.Close()
+}
+
+func TestListen_WithoutReusePortConflicts(t *testing.T) {
+	first, err := Listen("127.0.0.1:0", false)
+	if err != nil {
+		t.Fatalf("first listen failed: %v", err)
+	}
+	defer first.Close()
+
+	if second, err := Listen(first.Addr().String(), false); err == nil {
+		second.Close()
+		t.Error("expected second bind without SO_REUSEPORT to fail")
+	}
+}
+
+func TestActivated_NotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	t.Setenv("LISTEN_FDS", "")
+
+	listeners, err := Activated()
+	if err != nil || listeners != nil {
+		t.Errorf("expected no listeners, got %v (err=%v)", listeners, err)
+	}
+}
+
+func TestActivated_IgnoresOtherProcess(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	listeners, err := Activated()
+	if err != nil || listeners != nil {
+		t.Errorf("expected no listeners for another PID, got %v (err=%v)", listeners, err)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package listener
+
+import (
+	"errors"
+	"syscall"
+)
+
+// setReusePort reports that SO_REUSEPORT is unavailable on this platform
+func setReusePort(network, address string, c syscall.RawConn) error {
+	return errors.New("SO_REUSEPORT is not supported on this platform")
+}
@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// ToContext returns a copy of ctx carrying l, typically a logger that already
+// has request context such as a request ID
+func ToContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or one writing to the slog
+// default logger if there is none. When ctx is part of a trace, the trace_id
+// and span_id of its current span are added so log lines can be matched to
+// traces.
+func FromContext(ctx context.Context) *Logger {
+	l, ok := ctx.Value(contextKey{}).(*Logger)
+	if !ok {
+		level := &slog.LevelVar{}
+		l = &Logger{Logger: slog.Default(), level: level, base: level.Level()}
+	}
+
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return l
+	}
+	return &Logger{
+		Logger: l.Logger.With(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		),
+		level: l.level,
+		base:  l.base,
+	}
+}
@@ -2,14 +2,26 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
+
+	"github.com/hugovillarreal/neurogate/pkg/redact"
 )
 
+// defaultMaxSizeMB is the log file size that triggers rotation by default
+const defaultMaxSizeMB = 100
+
 // Logger wraps slog.Logger with service-specific context
 type Logger struct {
 	*slog.Logger
+
+	// Shared by loggers derived with the With* methods so a level change
+	// applies to all of them
+	level *slog.LevelVar
+	base  slog.Level // Configured level, restored by ToggleDebug
 }
 
 // Config holds logger configuration
@@ -17,29 +29,65 @@ type Config struct {
 	Level   string // debug, info, warn, error
 	Service string // Service name for tagging logs
 	JSON    bool   // Whether to output JSON format
+
+	// File also writes logs to this path, rotating it once it reaches
+	// MaxSizeMB. Empty logs to stdout only.
+	File       string
+	MaxSizeMB  int  // Size in megabytes that triggers rotation; 0 uses 100
+	MaxBackups int  // Rotated files to keep; 0 keeps all
+	Compress   bool // Gzip rotated files
+
+	// Content is applied to prompt and response attributes (see
+	// redact.ContentKeys). The zero value redacts them.
+	Content redact.Policy
 }
 
 // New creates a new structured logger
 func New(cfg Config) *Logger {
-	level := parseLevel(cfg.Level)
+	level := &slog.LevelVar{}
+	level.Set(parseLevel(cfg.Level))
+
+	// A log file that can't be opened leaves stdout logging in place
+	var out io.Writer = os.Stdout
+	var fileErr error
+	if cfg.File != "" {
+		maxSize := cfg.MaxSizeMB
+		if maxSize <= 0 {
+			maxSize = defaultMaxSizeMB
+		}
+		var file *rotatingFile
+		file, fileErr = openRotatingFile(cfg.File, int64(maxSize)<<20, cfg.MaxBackups, cfg.Compress)
+		if fileErr == nil {
+			out = io.MultiWriter(os.Stdout, file)
+		}
+	}
 
 	var handler slog.Handler
 	opts := &slog.HandlerOptions{
 		Level:     level,
-		AddSource: level == slog.LevelDebug,
+		AddSource: level.Level() == slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if redact.IsContentKey(a.Key) && a.Value.Kind() == slog.KindString {
+				a.Value = slog.StringValue(cfg.Content.Apply(a.Value.String()))
+			}
+			return a
+		},
 	}
 
 	if cfg.JSON {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+		handler = slog.NewJSONHandler(out, opts)
 	} else {
-		handler = slog.NewTextHandler(os.Stdout, opts)
+		handler = slog.NewTextHandler(out, opts)
 	}
 
 	logger := slog.New(handler).With(
 		slog.String("service", cfg.Service),
 	)
+	if fileErr != nil {
+		logger.Error("failed to open log file, logging to stdout only", "file", cfg.File, "error", fileErr)
+	}
 
-	return &Logger{Logger: logger}
+	return &Logger{Logger: logger, level: level, base: level.Level()}
 }
 
 // Default creates a logger with default settings
@@ -55,6 +103,25 @@ func Default(service string) *Logger {
 func (l *Logger) WithRequestID(requestID string) *Logger {
 	return &Logger{
 		Logger: l.Logger.With(slog.String("request_id", requestID)),
+		level:  l.level,
+		base:   l.base,
+	}
+}
+
+// WithCaller returns a logger with the tenant and hashed API key a request
+// is served for, leaving out empty ones
+func (l *Logger) WithCaller(tenant, keyID string) *Logger {
+	var attrs []any
+	if tenant != "" {
+		attrs = append(attrs, slog.String("tenant", tenant))
+	}
+	if keyID != "" {
+		attrs = append(attrs, slog.String("key_id", keyID))
+	}
+	return &Logger{
+		Logger: l.Logger.With(attrs...),
+		level:  l.level,
+		base:   l.base,
 	}
 }
 
@@ -65,6 +132,8 @@ func (l *Logger) WithWorker(workerID string, addr string) *Logger {
 			slog.String("worker_id", workerID),
 			slog.String("worker_addr", addr),
 		),
+		level: l.level,
+		base:  l.base,
 	}
 }
 
@@ -72,6 +141,47 @@ func (l *Logger) WithWorker(workerID string, addr string) *Logger {
 func (l *Logger) WithError(err error) *Logger {
 	return &Logger{
 		Logger: l.Logger.With(slog.String("error", err.Error())),
+		level:  l.level,
+		base:   l.base,
+	}
+}
+
+// Level returns the current log level
+func (l *Logger) Level() slog.Level {
+	return l.level.Level()
+}
+
+// SetLevel changes the log level at runtime
+func (l *Logger) SetLevel(level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.Set(parsed)
+	return nil
+}
+
+// ToggleDebug switches to debug logging, or back to the configured level (info
+// if that was debug) when debug is already on, and returns the new level
+func (l *Logger) ToggleDebug() slog.Level {
+	next := slog.LevelDebug
+	if l.level.Level() == slog.LevelDebug {
+		next = l.base
+		if next == slog.LevelDebug {
+			next = slog.LevelInfo
+		}
+	}
+	l.level.Set(next)
+	return next
+}
+
+// ParseLevel parses a level name, rejecting unknown names
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug", "info", "warn", "warning", "error":
+		return parseLevel(level), nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", level)
 	}
 }
 
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLogger_SetLevel(t *testing.T) {
+	log := New(Config{Level: "info", Service: "test"})
+	derived := log.WithRequestID("req-1").WithError(errors.New("boom"))
+
+	if derived.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("expected debug to be disabled at info level")
+	}
+	if err := log.SetLevel("debug"); err != nil {
+		t.Fatalf("SetLevel failed: %v", err)
+	}
+	if log.Level() != slog.LevelDebug {
+		t.Errorf("expected debug level, got %v", log.Level())
+	}
+	// Loggers derived before the change follow it
+	if !derived.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("expected derived logger to enable debug")
+	}
+
+	if err := log.SetLevel("verbose"); err == nil {
+		t.Error("expected unknown level to be rejected")
+	}
+	if log.Level() != slog.LevelDebug {
+		t.Errorf("expected a rejected level to leave debug in place, got %v", log.Level())
+	}
+}
+
+func TestLogger_ToggleDebug(t *testing.T) {
+	log := New(Config{Level: "warn", Service: "test"})
+
+	if level := log.ToggleDebug(); level != slog.LevelDebug {
+		t.Errorf("expected toggle to enable debug, got %v", level)
+	}
+	if level := log.ToggleDebug(); level != slog.LevelWarn {
+		t.Errorf("expected toggle to restore the configured level, got %v", level)
+	}
+
+	log = New(Config{Level: "debug", Service: "test"})
+	if level := log.ToggleDebug(); level != slog.LevelInfo {
+		t.Errorf("expected toggle from a configured debug level to go to info, got %v", level)
+	}
+}
+
+func TestNew_AppliesContentPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.log")
+	log := New(Config{Level: "info", Service: "gateway", JSON: true, File: path})
+	log.WithRequestID("req-1").Info("forwarding request", "prompt", "my card is 4111 1111 1111 1111", "query_length", 30)
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "4111") {
+		t.Errorf("expected prompt to be redacted, got %q", data)
+	}
+	if !strings.Contains(string(data), `"prompt":"[redacted 30 chars]"`) || !strings.Contains(string(data), `"request_id":"req-1"`) {
+		t.Errorf("unexpected log output %q", data)
+	}
+}
+
+func TestFromContext_AddsRequestAndTraceIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worker.log")
+	log := New(Config{Level: "info", Service: "worker", JSON: true, File: path})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = ToContext(ctx, log.WithRequestID("req-1"))
+	FromContext(ctx).Info("generation complete")
+
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{`"request_id":"req-1"`, `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`, `"span_id":"00f067aa0ba902b7"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %s in %q", want, data)
+		}
+	}
+}
+
+func TestFromContext_WithoutLogger(t *testing.T) {
+	log := FromContext(context.Background())
+	if log == nil || log.Logger == nil {
+		t.Fatal("expected a fallback logger")
+	}
+	log.Info("falls back to the slog default")
+}
@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp inserted into rotated file names. It
+// sorts lexically in time order.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotateRetryInterval is how long after a failed rotation the next is tried,
+// unless another maxSize bytes are written first
+const rotateRetryInterval = time.Minute
+
+// rotatingFile is an io.Writer that appends to a file and rotates it once it
+// reaches maxSize. Rotated files are renamed to name-<timestamp>.ext,
+// optionally gzipped, and the oldest are removed beyond maxBackups.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int // 0 keeps every backup
+	compress   bool
+
+	file   *os.File
+	size   int64
+	rename func(oldpath, newpath string) error
+
+	// Set after a failed rotation, so a file that can't be moved isn't
+	// retried on every write
+	retryAt   time.Time
+	retrySize int64
+
+	// Serializes compression and cleanup, which run in the background so
+	// logging isn't blocked while a large file is gzipped
+	millMu sync.Mutex
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int, compress bool) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f := &rotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		compress:   compress,
+		rename:     os.Rename,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize && f.rotateDue(len(p)) {
+		if err := f.rotate(); err != nil {
+			// Keep appending to the current file rather than dropping logs
+			fmt.Fprintf(os.Stderr, "logger: failed to rotate %s: %v\n", f.path, err)
+			f.retryAt = time.Now().Add(rotateRetryInterval)
+			f.retrySize = f.size + f.maxSize
+		} else {
+			f.retryAt = time.Time{}
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotateDue reports whether a full file should be rotated before writing n
+// bytes, backing off after a failed rotation
+func (f *rotatingFile) rotateDue(n int) bool {
+	return f.retryAt.IsZero() || !time.Now().Before(f.retryAt) || f.size+int64(n) > f.retrySize
+}
+
+// Close closes the current file
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// rotate moves the current file aside and starts a new one. If the file
+// can't be moved, it is reopened so that writes carry on.
+func (f *rotatingFile) rotate() error {
+	backup := f.backupName(time.Now())
+	err := f.file.Close()
+	if err == nil {
+		err = f.rename(f.path, backup)
+	}
+	if openErr := f.open(); openErr != nil {
+		return openErr
+	}
+	if err != nil {
+		return err
+	}
+
+	go f.mill(backup)
+	return nil
+}
+
+func (f *rotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(f.path, ext), t.UTC().Format(backupTimeFormat), ext)
+}
+
+// mill compresses a freshly rotated file and removes old backups
+func (f *rotatingFile) mill(backup string) {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	if f.compress {
+		if err := gzipFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "logger: failed to compress %s: %v\n", backup, err)
+		}
+	}
+	if f.maxBackups > 0 {
+		backups := f.backups()
+		for _, old := range backups[:max(len(backups)-f.maxBackups, 0)] {
+			os.Remove(old)
+		}
+	}
+}
+
+// backups returns the rotated files, oldest first
+func (f *rotatingFile) backups() []string {
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil
+	}
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(f.path), name))
+	}
+	sort.Strings(backups)
+	return backups
+}
+
+// gzipFile replaces path with path.gz
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
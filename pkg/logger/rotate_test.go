@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForMill waits for background compression and cleanup to finish
+func waitForMill(f *rotatingFile) {
+	f.millMu.Lock()
+	f.millMu.Unlock()
+}
+
+func TestRotatingFile_RotatesAtMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.log")
+	f, err := openRotatingFile(path, 10, 0, false)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("0123456789"))
+	f.Write([]byte("abc"))
+	time.Sleep(10 * time.Millisecond)
+	waitForMill(f)
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "abc" {
+		t.Errorf("expected the new file to hold the latest write, got %q", current)
+	}
+	backups := f.backups()
+	if len(backups) != 1 {
+		t.Fatalf("expected 1 backup, got %v", backups)
+	}
+	if !strings.HasSuffix(backups[0], ".log") || !strings.Contains(filepath.Base(backups[0]), "gateway-") {
+		t.Errorf("unexpected backup name %s", backups[0])
+	}
+	rotated, _ := os.ReadFile(backups[0])
+	if string(rotated) != "0123456789" {
+		t.Errorf("expected the backup to hold the earlier write, got %q", rotated)
+	}
+}
+
+func TestRotatingFile_ReopensWhenRenameFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.log")
+	f, err := openRotatingFile(path, 10, 0, false)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("0123456789"))
+	// The file can't be moved aside once it has been removed
+	os.Remove(path)
+	for _, p := range []string{"abc", "def"} {
+		if _, err := f.Write([]byte(p)); err != nil {
+			t.Fatalf("expected writes to carry on after a failed rotation, got %v", err)
+		}
+	}
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "abcdef" {
+		t.Errorf("expected the reopened file to hold the later writes, got %q", current)
+	}
+	if backups := f.backups(); len(backups) != 0 {
+		t.Errorf("expected no backups, got %v", backups)
+	}
+}
+
+func TestRotatingFile_BacksOffWhileRenameFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.log")
+	f, err := openRotatingFile(path, 10, 0, false)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer f.Close()
+	renames := 0
+	f.rename = func(string, string) error {
+		renames++
+		return os.ErrPermission
+	}
+
+	f.Write([]byte("0123456789"))
+	for range 9 {
+		if _, err := f.Write([]byte("a")); err != nil {
+			t.Fatalf("expected writes to carry on while rotation fails, got %v", err)
+		}
+	}
+	if renames != 1 {
+		t.Fatalf("expected one rotation attempt until another 10 bytes are written, got %d", renames)
+	}
+
+	// Another maxSize bytes are written
+	f.Write([]byte("bc"))
+	if renames != 2 {
+		t.Fatalf("expected a second attempt after another 10 bytes, got %d", renames)
+	}
+
+	// The retry interval passes
+	f.retryAt = time.Now().Add(-time.Second)
+	f.Write([]byte("d"))
+	if renames != 3 {
+		t.Fatalf("expected a third attempt once the retry interval passed, got %d", renames)
+	}
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "0123456789aaaaaaaaabcd" {
+		t.Errorf("expected every write in the file, got %q", current)
+	}
+}
+
+func TestRotatingFile_AppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worker.log")
+	os.WriteFile(path, []byte("12345678"), 0o644)
+
+	f, err := openRotatingFile(path, 10, 0, false)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer f.Close()
+
+	// The existing contents count towards the size limit
+	f.Write([]byte("abc"))
+	if backups := f.backups(); len(backups) != 1 {
+		t.Errorf("expected the existing file to be rotated, got backups %v", backups)
+	}
+}
+
+func TestRotatingFile_PrunesAndCompressesBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gateway.log")
+	f, err := openRotatingFile(path, 5, 2, true)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer f.Close()
+
+	for _, line := range []string{"aaaaa", "bbbbb", "ccccc", "ddddd"} {
+		f.Write([]byte(line))
+		time.Sleep(5 * time.Millisecond) // distinct backup timestamps
+	}
+	time.Sleep(10 * time.Millisecond)
+	waitForMill(f)
+
+	backups := f.backups()
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups to be kept, got %v", backups)
+	}
+	for _, b := range backups {
+		if !strings.HasSuffix(b, ".log.gz") {
+			t.Errorf("expected compressed backup, got %s", b)
+		}
+	}
+
+	// The newest backup holds the write before the current one
+	file, err := os.Open(backups[1])
+	if err != nil {
+		t.Fatalf("open backup failed: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("invalid gzip: %v", err)
+	}
+	data, _ := io.ReadAll(gz)
+	if string(data) != "ccccc" {
+		t.Errorf("expected newest backup to hold ccccc, got %q", data)
+	}
+}
+
+func TestNew_WritesToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "gateway.log")
+	log := New(Config{Level: "info", Service: "gateway", JSON: true, File: path})
+	log.Info("hello from file")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected log file to be created: %v", err)
+	}
+	if !strings.Contains(string(data), "hello from file") || !strings.Contains(string(data), `"service":"gateway"`) {
+		t.Errorf("unexpected log file contents %q", data)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !linux && !darwin
+
+package logger
+
+// ToggleDebugOnSignal does nothing on platforms without SIGUSR1
+func (l *Logger) ToggleDebugOnSignal() {}
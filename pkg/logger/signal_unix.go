@@ -0,0 +1,23 @@
+//go:build linux || darwin
+
+package logger
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// ToggleDebugOnSignal toggles debug logging each time the process receives
+// SIGUSR1, for turning on debug output during an incident without a restart
+func (l *Logger) ToggleDebugOnSignal() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1)
+	go func() {
+		for range sigChan {
+			level := l.ToggleDebug()
+			l.Warn("log level changed", "level", strings.ToLower(level.String()), "trigger", "SIGUSR1")
+		}
+	}()
+}
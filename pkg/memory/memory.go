@@ -0,0 +1,110 @@
+// Package memory reads how much system and GPU memory is in use, so a
+// worker can stop admitting work before the inference server runs out
+package memory
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Usage is the memory in use out of the total, in bytes
+type Usage struct {
+	Used  uint64
+	Total uint64
+}
+
+// Ratio returns the share of memory in use, from 0 to 1
+func (u Usage) Ratio() float64 {
+	if u.Total == 0 {
+		return 0
+	}
+	return float64(u.Used) / float64(u.Total)
+}
+
+// System returns the system memory in use, counting memory the kernel can
+// reclaim (such as the page cache) as free. It reads /proc/meminfo, so it
+// is only supported on Linux.
+func System() (Usage, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return Usage{}, err
+	}
+	defer f.Close()
+	return parseMeminfo(f)
+}
+
+// parseMeminfo reads MemTotal and MemAvailable, which are in kB
+func parseMeminfo(r io.Reader) (Usage, error) {
+	var total, available uint64
+	var haveTotal, haveAvailable bool
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total, haveTotal = value*1024, true
+		case "MemAvailable:":
+			available, haveAvailable = value*1024, true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Usage{}, err
+	}
+	if !haveTotal || !haveAvailable || available > total {
+		return Usage{}, fmt.Errorf("meminfo lacks MemTotal or MemAvailable")
+	}
+	return Usage{Used: total - available, Total: total}, nil
+}
+
+// GPU returns the memory in use on the most used NVIDIA GPU, by running
+// nvidia-smi
+func GPU(ctx context.Context) (Usage, error) {
+	out, err := exec.CommandContext(ctx, "nvidia-smi",
+		"--query-gpu=memory.used,memory.total",
+		"--format=csv,noheader,nounits",
+	).Output()
+	if err != nil {
+		return Usage{}, fmt.Errorf("nvidia-smi failed: %w", err)
+	}
+	return parseNvidiaSMI(string(out))
+}
+
+// parseNvidiaSMI reads "used, total" lines in MiB, one per GPU, returning
+// the GPU with the highest ratio
+func parseNvidiaSMI(out string) (Usage, error) {
+	var most Usage
+	found := false
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		usedField, totalField, ok := strings.Cut(line, ",")
+		if !ok {
+			continue
+		}
+		used, err1 := strconv.ParseUint(strings.TrimSpace(usedField), 10, 64)
+		total, err2 := strconv.ParseUint(strings.TrimSpace(totalField), 10, 64)
+		if err1 != nil || err2 != nil || total == 0 {
+			continue
+		}
+		gpu := Usage{Used: used << 20, Total: total << 20}
+		if !found || gpu.Ratio() > most.Ratio() {
+			most, found = gpu, true
+		}
+	}
+	if !found {
+		return Usage{}, fmt.Errorf("no GPUs in nvidia-smi output")
+	}
+	return most, nil
+}
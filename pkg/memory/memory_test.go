@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMeminfo(t *testing.T) {
+	usage, err := parseMeminfo(strings.NewReader(`MemTotal:       16000000 kB
+MemFree:         1000000 kB
+MemAvailable:    4000000 kB
+Buffers:          200000 kB
+`))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if usage.Total != 16000000*1024 || usage.Used != 12000000*1024 || usage.Ratio() != 0.75 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+
+	if _, err := parseMeminfo(strings.NewReader("MemTotal: 16000000 kB\n")); err == nil {
+		t.Error("expected an error without MemAvailable")
+	}
+}
+
+func TestParseNvidiaSMI(t *testing.T) {
+	usage, err := parseNvidiaSMI("2048, 24576\n20000, 24576\n")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if usage.Used != 20000<<20 || usage.Total != 24576<<20 {
+		t.Errorf("expected the most used GPU, got %+v", usage)
+	}
+
+	if _, err := parseNvidiaSMI("No devices were found\n"); err == nil {
+		t.Error("expected an error without GPUs")
+	}
+}
+
+func TestUsage_Ratio(t *testing.T) {
+	if r := (Usage{}).Ratio(); r != 0 {
+		t.Errorf("expected 0 for unknown total, got %v", r)
+	}
+}
+
+func TestSystem(t *testing.T) {
+	usage, err := System()
+	if err != nil {
+		t.Skipf("system memory unavailable: %v", err)
+	}
+	if usage.Total == 0 || usage.Used > usage.Total {
+		t.Errorf("implausible usage: %+v", usage)
+	}
+}
@@ -3,73 +3,333 @@ package metrics
 
 import (
 	"net/http"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// Metrics holds all Prometheus metrics for the service
+// Metrics holds all Prometheus metrics for the service. Only the collectors
+// of the components it was built with are set; the rest are nil. All methods
+// are safe to call on a nil *Metrics or with missing components, so shared
+// code can record metrics regardless of which service hosts it.
 type Metrics struct {
-	// Gateway metrics
-	RequestsTotal       *prometheus.CounterVec
-	RequestDuration     *prometheus.HistogramVec
-	ActiveRequests      prometheus.Gauge
-	CircuitBreakerState *prometheus.GaugeVec
-
-	// Worker metrics
-	InferenceDuration   *prometheus.HistogramVec
-	TokensGenerated     *prometheus.CounterVec
-	TokensPerSecond     *prometheus.GaugeVec
+	// HTTP metrics
+	RequestsTotal   *prometheus.CounterVec
+	RequestDuration *prometheus.HistogramVec
+	ActiveRequests  prometheus.Gauge
+
+	// Routing metrics
+	WorkerClockSkew   *prometheus.GaugeVec
+	FallbackResponses *prometheus.CounterVec
+	AffinityRoutes    *prometheus.CounterVec
+	ModelFallbacks    *prometheus.CounterVec
+	CloudRequests     *prometheus.CounterVec
+	WorkerCalls       *prometheus.CounterVec
+	WorkerCallTime    *prometheus.HistogramVec
+
+	// Cache metrics
+	CacheLookups *prometheus.CounterVec
+
+	// Admission metrics
+	QueueDepth     prometheus.Gauge
+	QueueWait      prometheus.Histogram
+	SchedulerWait  *prometheus.HistogramVec
+	RequestsShed   *prometheus.CounterVec
+	WorkerInflight *prometheus.GaugeVec
+
+	GuardrailViolations *prometheus.CounterVec
+	ModerationChecks    *prometheus.CounterVec
+
+	// Usage metrics by consumer (API key or tenant). At most consumerLimit
+	// distinct consumers get their own series; the rest share "other".
+	ConsumerRequests *prometheus.CounterVec
+	ConsumerTokens   *prometheus.CounterVec
+	ConsumerCost     *prometheus.CounterVec
+	consumerLimit    int
+	consumersMu      sync.Mutex
+	consumers        map[string]bool
+
+	// Inference metrics
+	InferenceDuration      *prometheus.HistogramVec
+	TokensGenerated        *prometheus.CounterVec
+	TokensPerSecond        *prometheus.GaugeVec
+	RequestTokensPerSecond *prometheus.HistogramVec
+	TimeToFirstToken       *prometheus.HistogramVec
+	InterTokenLatency      *prometheus.HistogramVec
+	WorkerLoad             prometheus.Gauge
+	ActiveInferences       prometheus.Gauge
+	InferenceQueueDepth    prometheus.Gauge
+	InferenceRejections    *prometheus.CounterVec
+	MemoryUsage            *prometheus.GaugeVec
+
+	// Ollama metrics
 	OllamaRequestsTotal *prometheus.CounterVec
 	OllamaRequestErrors *prometheus.CounterVec
 	OllamaConnected     prometheus.Gauge
-	WorkerLoad          prometheus.Gauge
-	ActiveInferences    prometheus.Gauge
+	OllamaRecoveries    *prometheus.CounterVec
+	OllamaModelVRAM     *prometheus.GaugeVec
+	BackendSlots        *prometheus.GaugeVec
+	BackendInstanceUp   *prometheus.GaugeVec
 }
 
-// NewGatewayMetrics creates metrics for the Gateway service
-func NewGatewayMetrics(namespace string) *Metrics {
-	return &Metrics{
-		RequestsTotal: promauto.NewCounterVec(
+// Component is a group of related metrics that can be enabled independently
+type Component int
+
+const (
+	ComponentHTTP      Component = iota // Request counts, durations and in-flight requests
+	ComponentRouting                    // Worker calls and clock skew, fallbacks, model fallbacks, cloud requests and conversation affinity
+	ComponentCache                      // Response cache lookups
+	ComponentInference                  // Inference duration, token throughput and latency, worker load, inference slots and memory use
+	ComponentOllama                     // Ollama requests, connectivity and recovery, and backend slots
+	ComponentUsage                      // Requests, tokens and estimated cost by consumer
+	ComponentAdmission                  // Queue depth and wait, scheduler wait, shed requests, per-worker in-flight requests, guardrail violations and moderation checks
+)
+
+// DefaultConsumerLimit is how many consumers get their own usage series
+// unless the builder sets a different limit
+const DefaultConsumerLimit = 100
+
+// OtherConsumer labels usage from consumers beyond the limit
+const OtherConsumer = "other"
+
+// Builder creates a Metrics with a chosen set of components
+type Builder struct {
+	namespace     string
+	registerer    prometheus.Registerer
+	components    map[Component]bool
+	consumerLimit int
+}
+
+// NewBuilder creates a builder that registers metrics under namespace with
+// the default Prometheus registry
+func NewBuilder(namespace string) *Builder {
+	return &Builder{
+		namespace:     namespace,
+		registerer:    prometheus.DefaultRegisterer,
+		components:    make(map[Component]bool),
+		consumerLimit: DefaultConsumerLimit,
+	}
+}
+
+// With enables the given components
+func (b *Builder) With(components ...Component) *Builder {
+	for _, c := range components {
+		b.components[c] = true
+	}
+	return b
+}
+
+// Registerer sets the registry metrics are registered with. Tests use a
+// fresh registry so they can build the same metrics more than once.
+func (b *Builder) Registerer(r prometheus.Registerer) *Builder {
+	b.registerer = r
+	return b
+}
+
+// ConsumerLimit caps how many distinct consumers get their own usage series
+func (b *Builder) ConsumerLimit(n int) *Builder {
+	if n > 0 {
+		b.consumerLimit = n
+	}
+	return b
+}
+
+// Build creates and registers the metrics of the enabled components
+func (b *Builder) Build() *Metrics {
+	m := &Metrics{}
+	factory := promauto.With(b.registerer)
+	namespace := b.namespace
+
+	if b.components[ComponentHTTP] {
+		m.RequestsTotal = factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
 				Name:      "requests_total",
 				Help:      "Total number of requests received by the gateway",
 			},
 			[]string{"method", "path", "status"},
-		),
-		RequestDuration: promauto.NewHistogramVec(
+		)
+		m.RequestDuration = factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Namespace: namespace,
 				Name:      "request_duration_seconds",
 				Help:      "Request duration in seconds",
 				Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60},
 			},
-			[]string{"method", "path"},
-		),
-		ActiveRequests: promauto.NewGauge(
+			[]string{"method", "path", "status"},
+		)
+		m.ActiveRequests = factory.NewGauge(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
 				Name:      "active_requests",
 				Help:      "Number of requests currently being processed",
 			},
-		),
-		CircuitBreakerState: promauto.NewGaugeVec(
+		)
+	}
+
+	if b.components[ComponentRouting] {
+		m.WorkerCalls = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "worker_calls_total",
+				Help:      "Total number of gRPC calls to workers, by method and status code",
+			},
+			[]string{"method", "code"},
+		)
+		m.WorkerCallTime = factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "worker_call_duration_seconds",
+				Help:      "Duration of gRPC calls to workers, streams until they end, by method",
+				Buckets:   []float64{.005, .01, .05, .1, .5, 1, 5, 10, 30, 60, 120},
+			},
+			[]string{"method"},
+		)
+		m.WorkerClockSkew = factory.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
-				Name:      "circuit_breaker_state",
-				Help:      "Circuit breaker state (0=closed, 1=open, 2=half-open)",
+				Name:      "worker_clock_skew_seconds",
+				Help:      "Estimated worker clock offset from the gateway clock in seconds",
 			},
 			[]string{"worker"},
-		),
+		)
+		m.FallbackResponses = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "fallback_responses_total",
+				Help:      "Total number of degraded responses served while no workers were available, by strategy",
+			},
+			[]string{"strategy"},
+		)
+		m.AffinityRoutes = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "affinity_routes_total",
+				Help:      "Total number of conversation requests routed by affinity, by whether they reached the conversation's preferred worker",
+			},
+			[]string{"result"},
+		)
+		m.ModelFallbacks = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "model_fallbacks_total",
+				Help:      "Total number of requests retried on a fallback model after their model failed, by model and fallback model",
+			},
+			[]string{"model", "fallback_model"},
+		)
+		m.CloudRequests = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "cloud_requests_total",
+				Help:      "Total number of requests sent to the cloud fallback, by result (success, error, budget_exhausted)",
+			},
+			[]string{"result"},
+		)
 	}
-}
 
-// NewWorkerMetrics creates metrics for the Worker service
-func NewWorkerMetrics(namespace string) *Metrics {
-	return &Metrics{
-		InferenceDuration: promauto.NewHistogramVec(
+	if b.components[ComponentCache] {
+		m.CacheLookups = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "cache_lookups_total",
+				Help:      "Total number of response cache lookups by cache (exact, semantic) and result (hit, miss)",
+			},
+			[]string{"cache", "result"},
+		)
+	}
+
+	if b.components[ComponentAdmission] {
+		m.QueueDepth = factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "queue_depth",
+				Help:      "Number of requests waiting in the queue",
+			},
+		)
+		m.QueueWait = factory.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "queue_wait_seconds",
+				Help:      "Time requests spent queued before running",
+				Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+			},
+		)
+		m.SchedulerWait = factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "scheduler_wait_seconds",
+				Help:      "Time requests waited for the scheduler to admit them, by priority",
+				Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+			},
+			[]string{"priority"},
+		)
+		m.RequestsShed = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "requests_shed_total",
+				Help:      "Total number of requests rejected by admission control, by reason",
+			},
+			[]string{"reason"},
+		)
+		m.WorkerInflight = factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "worker_inflight_requests",
+				Help:      "Number of requests currently sent to each worker",
+			},
+			[]string{"worker"},
+		)
+		m.GuardrailViolations = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "guardrail_violations_total",
+				Help:      "Total number of prompts and responses rejected by a guardrail policy, by policy, check and stage",
+			},
+			[]string{"policy", "check", "stage"},
+		)
+		m.ModerationChecks = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "moderation_checks_total",
+				Help:      "Total number of classifier moderation checks, by stage and result (allowed, flagged, blocked, error)",
+			},
+			[]string{"stage", "result"},
+		)
+	}
+
+	if b.components[ComponentUsage] {
+		m.ConsumerRequests = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "consumer_requests_total",
+				Help:      "Total number of requests by consumer (hashed API key or tenant) and status",
+			},
+			[]string{"consumer", "status"},
+		)
+		m.ConsumerTokens = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "consumer_tokens_total",
+				Help:      "Total number of tokens charged by consumer (hashed API key or tenant)",
+			},
+			[]string{"consumer"},
+		)
+		m.ConsumerCost = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "cost_usd_total",
+				Help:      "Estimated cost in USD by consumer (hashed API key or tenant) and model",
+			},
+			[]string{"consumer", "model"},
+		)
+		m.consumerLimit = b.consumerLimit
+		m.consumers = make(map[string]bool)
+	}
+
+	if b.components[ComponentInference] {
+		m.InferenceDuration = factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Namespace: namespace,
 				Name:      "inference_duration_seconds",
@@ -77,61 +337,158 @@ func NewWorkerMetrics(namespace string) *Metrics {
 				Buckets:   []float64{0.5, 1, 2, 5, 10, 30, 60, 120, 300},
 			},
 			[]string{"model"},
-		),
-		TokensGenerated: promauto.NewCounterVec(
+		)
+		m.TokensGenerated = factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
 				Name:      "tokens_generated_total",
 				Help:      "Total number of tokens generated",
 			},
 			[]string{"model"},
-		),
-		TokensPerSecond: promauto.NewGaugeVec(
+		)
+		m.TokensPerSecond = factory.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
 				Name:      "tokens_per_second",
 				Help:      "Current tokens per second generation rate",
 			},
 			[]string{"model"},
-		),
-		OllamaRequestsTotal: promauto.NewCounterVec(
+		)
+		m.RequestTokensPerSecond = factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "request_tokens_per_second",
+				Help:      "Generation rate of each request in tokens per second",
+				Buckets:   []float64{1, 5, 10, 20, 35, 50, 75, 100, 200, 500},
+			},
+			[]string{"model"},
+		)
+		m.TimeToFirstToken = factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "time_to_first_token_seconds",
+				Help:      "Time from the start of a streamed generation to its first token in seconds",
+				Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30},
+			},
+			[]string{"model"},
+		)
+		m.InterTokenLatency = factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "inter_token_latency_seconds",
+				Help:      "Time between consecutive streamed tokens in seconds",
+				Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+			},
+			[]string{"model"},
+		)
+		m.WorkerLoad = factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "worker_load",
+				Help:      "Current load on the worker (0.0 to 1.0)",
+			},
+		)
+		m.ActiveInferences = factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "active_inferences",
+				Help:      "Number of inferences currently in progress",
+			},
+		)
+		m.InferenceQueueDepth = factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "inference_queue_depth",
+				Help:      "Number of requests waiting for an inference slot",
+			},
+		)
+		m.InferenceRejections = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "inference_rejections_total",
+				Help:      "Total number of requests rejected for lack of an inference slot or under memory pressure",
+			},
+			[]string{"reason"},
+		)
+		m.MemoryUsage = factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "memory_used_ratio",
+				Help:      "Share of memory in use as read by the memory guard, by kind (system or gpu)",
+			},
+			[]string{"kind"},
+		)
+	}
+
+	if b.components[ComponentOllama] {
+		m.OllamaRequestsTotal = factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
 				Name:      "ollama_requests_total",
 				Help:      "Total number of requests made to Ollama",
 			},
 			[]string{"model", "status"},
-		),
-		OllamaRequestErrors: promauto.NewCounterVec(
+		)
+		m.OllamaRequestErrors = factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
 				Name:      "ollama_request_errors_total",
 				Help:      "Total number of Ollama request errors",
 			},
 			[]string{"model", "error_type"},
-		),
-		OllamaConnected: promauto.NewGauge(
+		)
+		m.OllamaConnected = factory.NewGauge(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
 				Name:      "ollama_connected",
 				Help:      "Whether the worker is connected to Ollama (1=yes, 0=no)",
 			},
-		),
-		WorkerLoad: promauto.NewGauge(
+		)
+		m.OllamaRecoveries = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "ollama_recovery_actions_total",
+				Help:      "Total number of watchdog recovery actions taken against Ollama",
+			},
+			[]string{"action", "result"},
+		)
+		m.OllamaModelVRAM = factory.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
-				Name:      "worker_load",
-				Help:      "Current load on the worker (0.0 to 1.0)",
+				Name:      "ollama_loaded_model_vram_bytes",
+				Help:      "GPU memory used by each model Ollama has loaded",
 			},
-		),
-		ActiveInferences: promauto.NewGauge(
+			[]string{"model"},
+		)
+		m.BackendSlots = factory.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
-				Name:      "active_inferences",
-				Help:      "Number of inferences currently in progress",
+				Name:      "backend_slots",
+				Help:      "Request slots of backends with a fixed number, by state (idle or processing)",
 			},
-		),
+			[]string{"state"},
+		)
+		m.BackendInstanceUp = factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "backend_instance_up",
+				Help:      "Whether each instance of a multi-instance backend is healthy (1) or not (0)",
+			},
+			[]string{"instance"},
+		)
 	}
+
+	return m
+}
+
+// NewGatewayMetrics creates metrics for the Gateway service
+func NewGatewayMetrics(namespace string) *Metrics {
+	return NewBuilder(namespace).With(ComponentHTTP, ComponentRouting, ComponentCache, ComponentAdmission, ComponentUsage).Build()
+}
+
+// NewWorkerMetrics creates metrics for the Worker service
+func NewWorkerMetrics(namespace string) *Metrics {
+	return NewBuilder(namespace).With(ComponentInference, ComponentOllama, ComponentUsage).Build()
 }
 
 // Handler returns the Prometheus HTTP handler for metrics endpoint
@@ -141,31 +498,353 @@ func Handler() http.Handler {
 
 // RecordRequest records a completed request with its status
 func (m *Metrics) RecordRequest(method, path, status string, durationSeconds float64) {
+	if m == nil || m.RequestsTotal == nil {
+		return
+	}
 	m.RequestsTotal.WithLabelValues(method, path, status).Inc()
-	m.RequestDuration.WithLabelValues(method, path).Observe(durationSeconds)
+	m.RequestDuration.WithLabelValues(method, path, status).Observe(durationSeconds)
+}
+
+// IncActiveRequests marks a request as started
+func (m *Metrics) IncActiveRequests() {
+	if m == nil || m.ActiveRequests == nil {
+		return
+	}
+	m.ActiveRequests.Inc()
+}
+
+// DecActiveRequests marks a request as finished
+func (m *Metrics) DecActiveRequests() {
+	if m == nil || m.ActiveRequests == nil {
+		return
+	}
+	m.ActiveRequests.Dec()
 }
 
 // RecordInference records a completed inference
 func (m *Metrics) RecordInference(model string, durationSeconds float64, tokensGenerated int) {
+	if m == nil || m.InferenceDuration == nil {
+		return
+	}
 	m.InferenceDuration.WithLabelValues(model).Observe(durationSeconds)
 	m.TokensGenerated.WithLabelValues(model).Add(float64(tokensGenerated))
 
 	if durationSeconds > 0 {
 		tps := float64(tokensGenerated) / durationSeconds
 		m.TokensPerSecond.WithLabelValues(model).Set(tps)
+		m.RequestTokensPerSecond.WithLabelValues(model).Observe(tps)
+	}
+}
+
+// RecordFirstToken records the time a streamed generation took to produce
+// its first token
+func (m *Metrics) RecordFirstToken(model string, seconds float64) {
+	if m == nil || m.TimeToFirstToken == nil {
+		return
+	}
+	m.TimeToFirstToken.WithLabelValues(model).Observe(seconds)
+}
+
+// RecordInterTokenLatency records the gap between two consecutive streamed
+// tokens
+func (m *Metrics) RecordInterTokenLatency(model string, seconds float64) {
+	if m == nil || m.InterTokenLatency == nil {
+		return
+	}
+	m.InterTokenLatency.WithLabelValues(model).Observe(seconds)
+}
+
+// IncActiveInferences marks an inference as started
+func (m *Metrics) IncActiveInferences() {
+	if m == nil || m.ActiveInferences == nil {
+		return
+	}
+	m.ActiveInferences.Inc()
+}
+
+// DecActiveInferences marks an inference as finished
+func (m *Metrics) DecActiveInferences() {
+	if m == nil || m.ActiveInferences == nil {
+		return
+	}
+	m.ActiveInferences.Dec()
+}
+
+// SetInferenceQueueDepth sets how many requests wait for an inference slot
+func (m *Metrics) SetInferenceQueueDepth(depth int) {
+	if m == nil || m.InferenceQueueDepth == nil {
+		return
+	}
+	m.InferenceQueueDepth.Set(float64(depth))
+}
+
+// RecordInferenceRejection records a request rejected for lack of an
+// inference slot (queue_full or queue_timeout) or under memory pressure
+// (memory_pressure)
+func (m *Metrics) RecordInferenceRejection(reason string) {
+	if m == nil || m.InferenceRejections == nil {
+		return
+	}
+	m.InferenceRejections.WithLabelValues(reason).Inc()
+}
+
+// SetMemoryUsage sets the share of memory of a kind (system or gpu) in use
+func (m *Metrics) SetMemoryUsage(kind string, ratio float64) {
+	if m == nil || m.MemoryUsage == nil {
+		return
+	}
+	m.MemoryUsage.WithLabelValues(kind).Set(ratio)
+}
+
+// SetWorkerLoad sets the worker's current load (0.0 to 1.0)
+func (m *Metrics) SetWorkerLoad(load float64) {
+	if m == nil || m.WorkerLoad == nil {
+		return
+	}
+	m.WorkerLoad.Set(load)
+}
+
+// RecordWorkerCall records a gRPC call to a worker and how long it took
+func (m *Metrics) RecordWorkerCall(method, code string, seconds float64) {
+	if m == nil || m.WorkerCalls == nil {
+		return
+	}
+	m.WorkerCalls.WithLabelValues(method, code).Inc()
+	m.WorkerCallTime.WithLabelValues(method).Observe(seconds)
+}
+
+// SetWorkerClockSkew sets the estimated clock skew for a worker
+func (m *Metrics) SetWorkerClockSkew(worker string, seconds float64) {
+	if m == nil || m.WorkerClockSkew == nil {
+		return
+	}
+	m.WorkerClockSkew.WithLabelValues(worker).Set(seconds)
+}
+
+// RecordCacheLookup records a response cache hit or miss
+func (m *Metrics) RecordCacheLookup(cache string, hit bool) {
+	if m == nil || m.CacheLookups == nil {
+		return
+	}
+	if hit {
+		m.CacheLookups.WithLabelValues(cache, "hit").Inc()
+	} else {
+		m.CacheLookups.WithLabelValues(cache, "miss").Inc()
+	}
+}
+
+// SetQueueDepth sets the number of queued requests
+func (m *Metrics) SetQueueDepth(n int) {
+	if m == nil || m.QueueDepth == nil {
+		return
+	}
+	m.QueueDepth.Set(float64(n))
+}
+
+// RecordQueueWait records how long a request waited in the queue
+func (m *Metrics) RecordQueueWait(seconds float64) {
+	if m == nil || m.QueueWait == nil {
+		return
+	}
+	m.QueueWait.Observe(seconds)
+}
+
+// RecordSchedulerWait records how long a request waited for the scheduler
+func (m *Metrics) RecordSchedulerWait(priority string, seconds float64) {
+	if m == nil || m.SchedulerWait == nil {
+		return
+	}
+	m.SchedulerWait.WithLabelValues(priority).Observe(seconds)
+}
+
+// RecordShed records a request rejected by admission control
+func (m *Metrics) RecordShed(reason string) {
+	if m == nil || m.RequestsShed == nil {
+		return
 	}
+	m.RequestsShed.WithLabelValues(reason).Inc()
 }
 
-// SetCircuitBreakerState sets the circuit breaker state for a worker
-func (m *Metrics) SetCircuitBreakerState(worker string, state int) {
-	m.CircuitBreakerState.WithLabelValues(worker).Set(float64(state))
+// RecordGuardrailViolation records content rejected by a guardrail check
+func (m *Metrics) RecordGuardrailViolation(policy, check, stage string) {
+	if m == nil || m.GuardrailViolations == nil {
+		return
+	}
+	m.GuardrailViolations.WithLabelValues(policy, check, stage).Inc()
+}
+
+// RecordModeration records the result of a moderation check
+func (m *Metrics) RecordModeration(stage, result string) {
+	if m == nil || m.ModerationChecks == nil {
+		return
+	}
+	m.ModerationChecks.WithLabelValues(stage, result).Inc()
+}
+
+// IncWorkerInflight increments a worker's in-flight requests
+func (m *Metrics) IncWorkerInflight(worker string) {
+	if m == nil || m.WorkerInflight == nil {
+		return
+	}
+	m.WorkerInflight.WithLabelValues(worker).Inc()
+}
+
+// DecWorkerInflight decrements a worker's in-flight requests
+func (m *Metrics) DecWorkerInflight(worker string) {
+	if m == nil || m.WorkerInflight == nil {
+		return
+	}
+	m.WorkerInflight.WithLabelValues(worker).Dec()
+}
+
+// RecordConsumerRequest records a finished request for a consumer
+func (m *Metrics) RecordConsumerRequest(consumer, status string) {
+	if m == nil || m.ConsumerRequests == nil {
+		return
+	}
+	m.ConsumerRequests.WithLabelValues(m.consumerLabel(consumer), status).Inc()
+}
+
+// RecordConsumerTokens records tokens charged to a consumer
+func (m *Metrics) RecordConsumerTokens(consumer string, tokens int) {
+	if m == nil || m.ConsumerTokens == nil {
+		return
+	}
+	m.ConsumerTokens.WithLabelValues(m.consumerLabel(consumer)).Add(float64(tokens))
+}
+
+// RecordConsumerCost records the estimated cost of a consumer's request
+func (m *Metrics) RecordConsumerCost(consumer, model string, usd float64) {
+	if m == nil || m.ConsumerCost == nil || usd <= 0 {
+		return
+	}
+	m.ConsumerCost.WithLabelValues(m.consumerLabel(consumer), model).Add(usd)
+}
+
+// consumerLabel returns the consumer itself while under the limit, and
+// OtherConsumer once the limit is reached by other consumers
+func (m *Metrics) consumerLabel(consumer string) string {
+	m.consumersMu.Lock()
+	defer m.consumersMu.Unlock()
+
+	if m.consumers[consumer] {
+		return consumer
+	}
+	if len(m.consumers) >= m.consumerLimit {
+		return OtherConsumer
+	}
+	m.consumers[consumer] = true
+	return consumer
+}
+
+// RecordFallback records a degraded response served by the given strategy
+func (m *Metrics) RecordFallback(strategy string) {
+	if m == nil || m.FallbackResponses == nil {
+		return
+	}
+	m.FallbackResponses.WithLabelValues(strategy).Inc()
+}
+
+// RecordAffinityRoute records a conversation request routed to its
+// preferred worker ("preferred") or, when that was unavailable, another
+// ("fallback")
+func (m *Metrics) RecordAffinityRoute(result string) {
+	if m == nil || m.AffinityRoutes == nil {
+		return
+	}
+	m.AffinityRoutes.WithLabelValues(result).Inc()
+}
+
+// RecordModelFallback records a request retried on fallback after model
+// failed
+func (m *Metrics) RecordModelFallback(model, fallback string) {
+	if m == nil || m.ModelFallbacks == nil {
+		return
+	}
+	m.ModelFallbacks.WithLabelValues(model, fallback).Inc()
+}
+
+// RecordCloudRequest records a request sent to the cloud fallback, or
+// refused by its budget
+func (m *Metrics) RecordCloudRequest(result string) {
+	if m == nil || m.CloudRequests == nil {
+		return
+	}
+	m.CloudRequests.WithLabelValues(result).Inc()
+}
+
+// RecordOllamaRequest records a completed Ollama request
+func (m *Metrics) RecordOllamaRequest(model, status string) {
+	if m == nil || m.OllamaRequestsTotal == nil {
+		return
+	}
+	m.OllamaRequestsTotal.WithLabelValues(model, status).Inc()
+}
+
+// RecordOllamaError records a failed Ollama request
+func (m *Metrics) RecordOllamaError(model, errorType string) {
+	if m == nil || m.OllamaRequestErrors == nil {
+		return
+	}
+	m.OllamaRequestErrors.WithLabelValues(model, errorType).Inc()
+}
+
+// RecordOllamaRecovery records a watchdog recovery action and whether it ran
+// successfully
+func (m *Metrics) RecordOllamaRecovery(action string, success bool) {
+	if m == nil || m.OllamaRecoveries == nil {
+		return
+	}
+	if success {
+		m.OllamaRecoveries.WithLabelValues(action, "success").Inc()
+	} else {
+		m.OllamaRecoveries.WithLabelValues(action, "failure").Inc()
+	}
 }
 
 // SetOllamaConnected sets the Ollama connection status
 func (m *Metrics) SetOllamaConnected(connected bool) {
+	if m == nil || m.OllamaConnected == nil {
+		return
+	}
 	if connected {
 		m.OllamaConnected.Set(1)
 	} else {
 		m.OllamaConnected.Set(0)
 	}
 }
+
+// SetOllamaLoadedModels replaces the per-model VRAM usage of loaded models.
+// Models no longer loaded are removed.
+func (m *Metrics) SetOllamaLoadedModels(vram map[string]int64) {
+	if m == nil || m.OllamaModelVRAM == nil {
+		return
+	}
+	m.OllamaModelVRAM.Reset()
+	for model, bytes := range vram {
+		m.OllamaModelVRAM.WithLabelValues(model).Set(float64(bytes))
+	}
+}
+
+// SetBackendInstanceUp sets whether an instance of a multi-instance backend
+// is healthy
+func (m *Metrics) SetBackendInstanceUp(instance string, up bool) {
+	if m == nil || m.BackendInstanceUp == nil {
+		return
+	}
+	if up {
+		m.BackendInstanceUp.WithLabelValues(instance).Set(1)
+	} else {
+		m.BackendInstanceUp.WithLabelValues(instance).Set(0)
+	}
+}
+
+// SetBackendSlots sets how many of the backend's request slots are idle and
+// processing
+func (m *Metrics) SetBackendSlots(idle, processing int) {
+	if m == nil || m.BackendSlots == nil {
+		return
+	}
+	m.BackendSlots.WithLabelValues("idle").Set(float64(idle))
+	m.BackendSlots.WithLabelValues("processing").Set(float64(processing))
+}
@@ -0,0 +1,291 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// recordAll calls every recording method once
+func recordAll(m *Metrics) {
+	m.RecordRequest("POST", "/prompt", "200", 0.5)
+	m.IncActiveRequests()
+	m.DecActiveRequests()
+	m.RecordInference("llama3.2", 2, 40)
+	m.RecordFirstToken("llama3.2", 0.2)
+	m.RecordInterTokenLatency("llama3.2", 0.05)
+	m.IncActiveInferences()
+	m.DecActiveInferences()
+	m.SetWorkerLoad(0.3)
+	m.SetWorkerClockSkew("worker-1", 0.25)
+	m.RecordWorkerCall("Embed", "OK", 0.1)
+	m.RecordCacheLookup("exact", true)
+	m.RecordFallback("stale")
+	m.RecordAffinityRoute("preferred")
+	m.RecordModelFallback("llama3.3:70b", "llama3.2")
+	m.RecordCloudRequest("success")
+	m.SetQueueDepth(3)
+	m.RecordQueueWait(0.5)
+	m.RecordSchedulerWait("batch", 0.5)
+	m.RecordShed("quota")
+	m.RecordGuardrailViolation("strict", "prompt_injection", "pre")
+	m.RecordModeration("pre", "blocked")
+	m.IncWorkerInflight("worker-1")
+	m.IncWorkerInflight("worker-1")
+	m.DecWorkerInflight("worker-1")
+	m.RecordConsumerRequest("key-1234abcd", "200")
+	m.RecordConsumerTokens("key-1234abcd", 25)
+	m.RecordConsumerCost("key-1234abcd", "llama3.2", 0.25)
+	m.RecordOllamaRequest("llama3.2", "success")
+	m.RecordOllamaError("llama3.2", "generation_error")
+	m.RecordOllamaRecovery("command", true)
+	m.SetOllamaConnected(true)
+	m.SetOllamaLoadedModels(map[string]int64{"llama3.2": 3 << 30})
+	m.SetBackendSlots(3, 1)
+	m.SetBackendInstanceUp("http://localhost:11434", true)
+	m.SetInferenceQueueDepth(2)
+	m.RecordInferenceRejection("queue_full")
+	m.SetMemoryUsage("system", 0.5)
+}
+
+// gather returns the registered metric families by name with the value of
+// their first sample (counters and gauges only)
+func gather(t *testing.T, reg *prometheus.Registry) map[string]float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+
+	values := make(map[string]float64, len(families))
+	for _, f := range families {
+		m := f.GetMetric()[0]
+		switch {
+		case m.GetCounter() != nil:
+			values[f.GetName()] = m.GetCounter().GetValue()
+		case m.GetGauge() != nil:
+			values[f.GetName()] = m.GetGauge().GetValue()
+		default:
+			values[f.GetName()] = 0
+		}
+	}
+	return values
+}
+
+func TestMetrics_NilReceiver(t *testing.T) {
+	var m *Metrics
+	recordAll(m) // must not panic
+}
+
+func TestMetrics_MissingComponents(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewBuilder("test").Registerer(reg).Build()
+	recordAll(m) // must not panic
+
+	if values := gather(t, reg); len(values) != 0 {
+		t.Errorf("expected no metrics without components, got %v", values)
+	}
+}
+
+func TestBuilder_RegistersOnlyEnabledComponents(t *testing.T) {
+	tests := []struct {
+		component Component
+		want      []string
+		notWant   []string
+	}{
+		{ComponentHTTP, []string{"test_requests_total", "test_active_requests"}, []string{"test_cache_lookups_total"}},
+		{ComponentRouting, []string{"test_worker_clock_skew_seconds", "test_fallback_responses_total", "test_affinity_routes_total", "test_model_fallbacks_total", "test_cloud_requests_total"}, []string{"test_requests_total"}},
+		{ComponentCache, []string{"test_cache_lookups_total"}, []string{"test_worker_load"}},
+		{ComponentInference, []string{"test_tokens_generated_total", "test_time_to_first_token_seconds", "test_worker_load"}, []string{"test_ollama_connected"}},
+		{ComponentOllama, []string{"test_ollama_requests_total", "test_ollama_connected"}, []string{"test_tokens_generated_total"}},
+		{ComponentAdmission, []string{"test_queue_depth", "test_queue_wait_seconds", "test_scheduler_wait_seconds", "test_requests_shed_total", "test_worker_inflight_requests", "test_guardrail_violations_total", "test_moderation_checks_total"}, []string{"test_active_requests"}},
+		{ComponentUsage, []string{"test_consumer_requests_total", "test_consumer_tokens_total", "test_cost_usd_total"}, []string{"test_requests_total"}},
+	}
+
+	for _, tt := range tests {
+		reg := prometheus.NewRegistry()
+		m := NewBuilder("test").Registerer(reg).With(tt.component).Build()
+		recordAll(m)
+
+		values := gather(t, reg)
+		for _, name := range tt.want {
+			if _, ok := values[name]; !ok {
+				t.Errorf("component %d: expected %s to be registered", tt.component, name)
+			}
+		}
+		for _, name := range tt.notWant {
+			if _, ok := values[name]; ok {
+				t.Errorf("component %d: expected %s not to be registered", tt.component, name)
+			}
+		}
+	}
+}
+
+func TestMetrics_RecordsValues(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewBuilder("test").Registerer(reg).
+		With(ComponentHTTP, ComponentRouting, ComponentCache, ComponentInference, ComponentOllama, ComponentAdmission, ComponentUsage).
+		Build()
+	recordAll(m)
+
+	values := gather(t, reg)
+	want := map[string]float64{
+		"test_requests_total":                1,
+		"test_active_requests":               0,
+		"test_tokens_generated_total":        40,
+		"test_tokens_per_second":             20,
+		"test_worker_load":                   0.3,
+		"test_worker_clock_skew_seconds":     0.25,
+		"test_cache_lookups_total":           1,
+		"test_fallback_responses_total":      1,
+		"test_affinity_routes_total":         1,
+		"test_ollama_requests_total":         1,
+		"test_ollama_request_errors_total":   1,
+		"test_ollama_recovery_actions_total": 1,
+		"test_ollama_connected":              1,
+		"test_queue_depth":                   3,
+		"test_requests_shed_total":           1,
+		"test_guardrail_violations_total":    1,
+		"test_moderation_checks_total":       1,
+		"test_worker_inflight_requests":      1,
+		"test_consumer_requests_total":       1,
+		"test_consumer_tokens_total":         25,
+		"test_cost_usd_total":                0.25,
+	}
+	for name, v := range want {
+		if got, ok := values[name]; !ok || got != v {
+			t.Errorf("%s: expected %v, got %v (registered: %v)", name, v, got, ok)
+		}
+	}
+}
+
+func TestMetrics_StreamingHistograms(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewBuilder("test").Registerer(reg).With(ComponentInference).Build()
+
+	m.RecordFirstToken("llama3.2", 0.3)
+	m.RecordInterTokenLatency("llama3.2", 0.02)
+	m.RecordInterTokenLatency("llama3.2", 0.04)
+	m.RecordInference("llama3.2", 2, 40)
+	m.RecordInference("llama3.2", 1, 10)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+	counts := make(map[string]uint64)
+	for _, f := range families {
+		if h := f.GetMetric()[0].GetHistogram(); h != nil {
+			counts[f.GetName()] = h.GetSampleCount()
+		}
+	}
+
+	want := map[string]uint64{
+		"test_time_to_first_token_seconds": 1,
+		"test_inter_token_latency_seconds": 2,
+		"test_request_tokens_per_second":   2,
+	}
+	for name, n := range want {
+		if counts[name] != n {
+			t.Errorf("%s: expected %d samples, got %d", name, n, counts[name])
+		}
+	}
+}
+
+func TestMetrics_RequestDurationByStatus(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewBuilder("test").Registerer(reg).With(ComponentHTTP).Build()
+
+	m.RecordRequest("POST", "/prompt", "200", 2)
+	m.RecordRequest("POST", "/prompt", "503", 0.01)
+	m.RecordRequest("POST", "/prompt", "503", 0.02)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+	counts := make(map[string]uint64)
+	for _, f := range families {
+		if f.GetName() != "test_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range f.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "status" {
+					counts[label.GetValue()] = metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+
+	if counts["200"] != 1 || counts["503"] != 2 {
+		t.Errorf("expected 1 success and 2 failure samples, got %v", counts)
+	}
+}
+
+func TestMetrics_ConsumerLimit(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewBuilder("test").Registerer(reg).With(ComponentUsage).ConsumerLimit(2).Build()
+
+	for _, consumer := range []string{"a", "b", "c", "d", "a"} {
+		m.RecordConsumerRequest(consumer, "200")
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+	counts := make(map[string]float64)
+	for _, f := range families {
+		if f.GetName() != "test_consumer_requests_total" {
+			continue
+		}
+		for _, metric := range f.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "consumer" {
+					counts[label.GetValue()] = metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	want := map[string]float64{"a": 2, "b": 1, OtherConsumer: 2}
+	if len(counts) != len(want) {
+		t.Fatalf("expected series %v, got %v", want, counts)
+	}
+	for consumer, n := range want {
+		if counts[consumer] != n {
+			t.Errorf("%s: expected %v requests, got %v", consumer, n, counts[consumer])
+		}
+	}
+}
+
+func TestMetrics_ConcurrentUse(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewBuilder("test").Registerer(reg).With(ComponentHTTP, ComponentCache).Build()
+
+	const goroutines, iterations = 8, 100
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				m.IncActiveRequests()
+				m.RecordCacheLookup("exact", true)
+				m.RecordRequest("POST", "/prompt", "200", 0.1)
+				m.DecActiveRequests()
+			}
+		}()
+	}
+	wg.Wait()
+
+	values := gather(t, reg)
+	if got := values["test_requests_total"]; got != goroutines*iterations {
+		t.Errorf("expected %d requests, got %v", goroutines*iterations, got)
+	}
+	if got := values["test_active_requests"]; got != 0 {
+		t.Errorf("expected 0 active requests, got %v", got)
+	}
+}
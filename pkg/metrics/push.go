@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// PushConfig configures pushing metrics to a Prometheus Pushgateway
+type PushConfig struct {
+	URL      string            // Pushgateway base URL
+	Job      string            // Value of the job grouping label
+	Grouping map[string]string // Additional grouping labels, e.g. instance
+	Interval time.Duration     // Time between pushes; defaults to 15s
+
+	// Gatherer supplies the pushed metrics; defaults to the registry served
+	// by Handler
+	Gatherer prometheus.Gatherer
+
+	OnError func(error) // Called when a push fails; optional
+}
+
+// Pusher periodically pushes metrics to a Pushgateway, for processes that
+// are short-lived or can't be scraped. Each push replaces the metrics of
+// the pusher's group.
+type Pusher struct {
+	pusher   *push.Pusher
+	interval time.Duration
+	onError  func(error)
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewPusher creates a Pusher; call Start to begin pushing
+func NewPusher(cfg PushConfig) *Pusher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 15 * time.Second
+	}
+	if cfg.Gatherer == nil {
+		cfg.Gatherer = prometheus.DefaultGatherer
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+
+	p := push.New(cfg.URL, cfg.Job).Gatherer(cfg.Gatherer)
+	for name, value := range cfg.Grouping {
+		p = p.Grouping(name, value)
+	}
+
+	return &Pusher{
+		pusher:   p,
+		interval: cfg.Interval,
+		onError:  cfg.OnError,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// StartPusherFromEnv starts pushing to the Pushgateway at METRICS_PUSH_URL
+// every METRICS_PUSH_INTERVAL, as METRICS_PUSH_JOB or else job, grouped by
+// instance (the hostname when instance is empty). It returns nil when
+// pushing isn't configured.
+func StartPusherFromEnv(log *slog.Logger, job, instance string) *Pusher {
+	url := os.Getenv("METRICS_PUSH_URL")
+	if url == "" {
+		return nil
+	}
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+	if v := os.Getenv("METRICS_PUSH_JOB"); v != "" {
+		job = v
+	}
+	interval := 15 * time.Second
+	if d, err := time.ParseDuration(os.Getenv("METRICS_PUSH_INTERVAL")); err == nil {
+		interval = d
+	}
+
+	pusher := NewPusher(PushConfig{
+		URL:      url,
+		Job:      job,
+		Grouping: map[string]string{"instance": instance},
+		Interval: interval,
+		OnError: func(err error) {
+			log.Warn("failed to push metrics", "error", err)
+		},
+	})
+	pusher.Start()
+	log.Info("pushing metrics", "url", url, "instance", instance, "interval", interval)
+	return pusher
+}
+
+// Start pushes immediately and then on every interval until Stop is called
+func (p *Pusher) Start() {
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+			if err := p.pusher.PushContext(ctx); err != nil {
+				p.onError(err)
+			}
+			cancel()
+
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic pushes and pushes one last time so the final
+// values are recorded
+func (p *Pusher) Stop(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.pusher.PushContext(ctx)
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestPusher_PushesToGroup(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var lastBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		lastBody = string(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	m := NewBuilder("test").Registerer(reg).With(ComponentInference).Build()
+	m.SetWorkerLoad(0.5)
+
+	p := NewPusher(PushConfig{
+		URL:      srv.URL,
+		Job:      "neurogate-worker",
+		Grouping: map[string]string{"instance": "worker-1"},
+		Interval: 10 * time.Millisecond,
+		Gatherer: reg,
+		OnError:  func(err error) { t.Errorf("unexpected push error: %v", err) },
+	})
+	p.Start()
+	time.Sleep(35 * time.Millisecond)
+
+	m.SetWorkerLoad(0.75)
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("final push failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) < 3 {
+		t.Fatalf("expected periodic pushes and a final push, got %d", len(paths))
+	}
+	for _, path := range paths {
+		if path != "PUT /metrics/job/neurogate-worker/instance/worker-1" {
+			t.Errorf("unexpected push %s", path)
+		}
+	}
+	// The body is protobuf-encoded, so only check the final value's metric
+	// name is present
+	if !strings.Contains(lastBody, "test_worker_load") {
+		t.Error("expected the final push to include the worker load")
+	}
+}
+
+func TestStartPusherFromEnv(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Setenv("METRICS_PUSH_URL", "")
+	if p := StartPusherFromEnv(log, "neurogate-worker", "worker-1"); p != nil {
+		t.Fatal("expected no pusher without METRICS_PUSH_URL")
+	}
+
+	pushed := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushed <- r.URL.Path
+	}))
+	defer srv.Close()
+	t.Setenv("METRICS_PUSH_URL", srv.URL)
+	t.Setenv("METRICS_PUSH_JOB", "edge-workers")
+	t.Setenv("METRICS_PUSH_INTERVAL", "1h")
+
+	p := StartPusherFromEnv(log, "neurogate-worker", "worker-1")
+	if p == nil {
+		t.Fatal("expected a pusher")
+	}
+	defer p.Stop(context.Background())
+	select {
+	case path := <-pushed:
+		if path != "/metrics/job/edge-workers/instance/worker-1" {
+			t.Errorf("unexpected push to %s", path)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected an immediate push")
+	}
+}
+
+func TestPusher_ReportsErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	errs := make(chan error, 10)
+	p := NewPusher(PushConfig{
+		URL:      srv.URL,
+		Job:      "neurogate-worker",
+		Interval: time.Hour,
+		Gatherer: prometheus.NewRegistry(),
+		OnError:  func(err error) { errs <- err },
+	})
+	p.Start()
+
+	select {
+	case <-errs:
+	case <-time.After(time.Second):
+		t.Fatal("expected the failed push to be reported")
+	}
+	if err := p.Stop(context.Background()); err == nil {
+		t.Error("expected the final push to fail")
+	}
+}
@@ -0,0 +1,100 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hugovillarreal/neurogate/pkg/jsonschema"
+)
+
+// reply returns a GenerateFunc answering with s, recording its inputs
+func reply(s string, system, text *string, format *json.RawMessage) GenerateFunc {
+	return func(ctx context.Context, sys, t string, f json.RawMessage) (string, error) {
+		if system != nil {
+			*system, *text, *format = sys, t, f
+		}
+		return s, nil
+	}
+}
+
+func TestModerator_Check(t *testing.T) {
+	var system, text string
+	var format json.RawMessage
+	m, err := New(map[string]float64{"violence": 0.8, "hate": 0.5}, reply(`{"violence": 0.93, "hate": 0.5, "other": 1}`, &system, &text, &format))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	result, err := m.Check(context.Background(), "some text")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !result.Flagged || strings.Join(result.Categories, ",") != "hate,violence" {
+		t.Errorf("expected hate and violence flagged, got %+v", result)
+	}
+	if len(result.Scores) != 2 {
+		t.Errorf("expected scores for configured categories only, got %v", result.Scores)
+	}
+	if reason := result.Reason(); reason != "hate 0.50, violence 0.93" {
+		t.Errorf("unexpected reason %q", reason)
+	}
+
+	if text != "some text" || !strings.Contains(system, "hate, violence") {
+		t.Errorf("unexpected classifier input: %q, %q", system, text)
+	}
+	schema, err := jsonschema.Compile(format)
+	if err != nil {
+		t.Fatalf("expected valid schema, got %v", err)
+	}
+	if err := schema.Validate([]byte(`{"hate": 0.1, "violence": 0.2}`)); err != nil {
+		t.Errorf("expected scores to match schema, got %v", err)
+	}
+	if err := schema.Validate([]byte(`{"hate": 0.1}`)); err == nil {
+		t.Error("expected schema to require every category")
+	}
+}
+
+func TestModerator_NotFlagged(t *testing.T) {
+	m, _ := New(nil, reply(`{"violence": 0.2, "self_harm": 7}`, nil, nil, nil))
+
+	result, err := m.Check(context.Background(), "text")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// Scores are clamped to 0-1, so self_harm is flagged at its default 0.5
+	if !result.Flagged || strings.Join(result.Categories, ",") != "self_harm" || result.Scores["self_harm"] != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(m.Categories()) != len(DefaultCategories) {
+		t.Errorf("expected default categories, got %v", m.Categories())
+	}
+
+	m, _ = New(map[string]float64{"violence": 0.8}, reply(`{}`, nil, nil, nil))
+	if result, _ := m.Check(context.Background(), "text"); result.Flagged || result.Scores["violence"] != 0 {
+		t.Errorf("expected missing scores to count as 0, got %+v", result)
+	}
+}
+
+func TestModerator_Errors(t *testing.T) {
+	m, _ := New(nil, reply("not json", nil, nil, nil))
+	if _, err := m.Check(context.Background(), "text"); err == nil {
+		t.Error("expected error for an unparseable reply")
+	}
+
+	failure := errors.New("worker down")
+	m, _ = New(nil, func(context.Context, string, string, json.RawMessage) (string, error) {
+		return "", failure
+	})
+	if _, err := m.Check(context.Background(), "text"); !errors.Is(err, failure) {
+		t.Errorf("expected generate error, got %v", err)
+	}
+
+	for _, thresholds := range []map[string]float64{{"violence": 0}, {"violence": 1.5}, {"": 0.5}} {
+		if _, err := New(thresholds, nil); err == nil {
+			t.Errorf("expected error for thresholds %v", thresholds)
+		}
+	}
+}
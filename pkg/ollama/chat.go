@@ -0,0 +1,130 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Chat message roles
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// ChatRequest represents a request to continue a conversation
+type ChatRequest struct {
+	Model    string           `json:"model"`
+	Messages []ChatMessage    `json:"messages"`
+	Tools    []Tool           `json:"tools,omitempty"`
+	Stream   bool             `json:"stream"`
+	Options  *GenerateOptions `json:"options,omitempty"`
+
+	// Constrains the reply to FormatJSON or a JSON schema (free text if nil)
+	Format json.RawMessage `json:"format,omitempty"`
+
+	// How long the model stays loaded after the request (Ollama's default if nil)
+	KeepAlive *Duration `json:"keep_alive,omitempty"`
+}
+
+// ChatMessage is one turn of a conversation
+type ChatMessage struct {
+	Role      string      `json:"role"`
+	Content   string      `json:"content"`
+	Images    []ImageData `json:"images,omitempty"`     // For multimodal models
+	ToolCalls []ToolCall  `json:"tool_calls,omitempty"` // Set on assistant messages
+	ToolName  string      `json:"tool_name,omitempty"`  // Set on tool results
+}
+
+// ImageData is raw image bytes, sent to Ollama base64-encoded
+type ImageData []byte
+
+// Tool describes a function the model may call
+type Tool struct {
+	Type     string       `json:"type"` // Always "function"
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction is a callable function and its JSON Schema parameters
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// NewFunctionTool creates a function tool definition
+func NewFunctionTool(name, description string, parameters json.RawMessage) Tool {
+	return Tool{
+		Type:     "function",
+		Function: ToolFunction{Name: name, Description: description, Parameters: parameters},
+	}
+}
+
+// ToolCall is a function call requested by the model
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction names the function to call and its arguments
+type ToolCallFunction struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// ChatResponse represents a chat response from Ollama. When streaming, each
+// chunk carries part of Message.Content.
+type ChatResponse struct {
+	Model              string      `json:"model"`
+	CreatedAt          time.Time   `json:"created_at"`
+	Message            ChatMessage `json:"message"`
+	Done               bool        `json:"done"`
+	DoneReason         string      `json:"done_reason,omitempty"`
+	TotalDuration      int64       `json:"total_duration,omitempty"`
+	LoadDuration       int64       `json:"load_duration,omitempty"`
+	PromptEvalCount    int         `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration int64       `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int         `json:"eval_count,omitempty"`
+	EvalDuration       int64       `json:"eval_duration,omitempty"`
+}
+
+// Chat sends a conversation to Ollama and returns the assistant's reply
+func (c *Client) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	req.Stream = false
+
+	resp, err := c.send(ctx, c.opts.Timeout, "POST", "/api/chat", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ChatStream sends a conversation to Ollama and calls fn for each streamed
+// chunk. Tool calls usually arrive in a single chunk; the final chunk has
+// Done set and carries the token counts and timings.
+func (c *Client) ChatStream(ctx context.Context, req *ChatRequest, fn func(*ChatResponse) error) error {
+	req.Stream = true
+
+	resp, err := c.send(ctx, c.opts.Timeout, "POST", "/api/chat", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return readStream(resp.Body, func(line []byte) (bool, error) {
+		var chunk ChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return false, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		return chunk.Done, fn(&chunk)
+	})
+}
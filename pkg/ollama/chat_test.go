@@ -0,0 +1,141 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_Chat_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		// Images are sent base64-encoded
+		if !strings.Contains(string(body), `"images":["iVBORw=="]`) {
+			t.Errorf("expected base64 image in request, got %s", body)
+		}
+
+		var req ChatRequest
+		json.Unmarshal(body, &req)
+		if req.Stream {
+			t.Error("expected stream to be disabled")
+		}
+		if len(req.Messages) != 3 || req.Messages[0].Role != RoleSystem {
+			t.Errorf("expected 3 messages starting with the system prompt, got %+v", req.Messages)
+		}
+		if len(req.Tools) != 1 || req.Tools[0].Type != "function" || req.Tools[0].Function.Name != "get_weather" {
+			t.Errorf("unexpected tools: %+v", req.Tools)
+		}
+
+		json.NewEncoder(w).Encode(ChatResponse{
+			Model:   req.Model,
+			Message: ChatMessage{Role: RoleAssistant, Content: "It's a cat."},
+			Done:    true,
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	resp, err := client.Chat(context.Background(), &ChatRequest{
+		Model: "llava",
+		Messages: []ChatMessage{
+			{Role: RoleSystem, Content: "Be brief."},
+			{Role: RoleUser, Content: "Hi"},
+			{Role: RoleUser, Content: "What is this?", Images: []ImageData{{0x89, 'P', 'N', 'G'}}},
+		},
+		Tools: []Tool{NewFunctionTool("get_weather", "Current weather", json.RawMessage(`{"type":"object"}`))},
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.Message.Role != RoleAssistant || resp.Message.Content != "It's a cat." {
+		t.Errorf("unexpected message: %+v", resp.Message)
+	}
+}
+
+func TestClient_Chat_ToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model":"llama3.2","message":{"role":"assistant","content":"",` +
+			`"tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Austin"}}}]},"done":true}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	resp, err := client.Chat(context.Background(), &ChatRequest{
+		Model:    "llama3.2",
+		Messages: []ChatMessage{{Role: RoleUser, Content: "Weather in Austin?"}},
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	calls := resp.Message.ToolCalls
+	if len(calls) != 1 || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments["city"] != "Austin" {
+		t.Errorf("unexpected tool calls: %+v", calls)
+	}
+}
+
+func TestClient_ChatStream_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if !req.Stream {
+			t.Error("expected stream to be enabled")
+		}
+
+		enc := json.NewEncoder(w)
+		enc.Encode(ChatResponse{Message: ChatMessage{Role: RoleAssistant, Content: "Hello"}})
+		enc.Encode(ChatResponse{Message: ChatMessage{Role: RoleAssistant, Content: ", world!"}})
+		enc.Encode(ChatResponse{Done: true, DoneReason: "stop", EvalCount: 2})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	var text string
+	var final *ChatResponse
+	err := client.ChatStream(context.Background(), &ChatRequest{
+		Model:    "llama3.2",
+		Messages: []ChatMessage{{Role: RoleUser, Content: "Say hello"}},
+	}, func(chunk *ChatResponse) error {
+		text += chunk.Message.Content
+		if chunk.Done {
+			final = chunk
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if text != "Hello, world!" {
+		t.Errorf("expected concatenated text %q, got %q", "Hello, world!", text)
+	}
+	if final == nil || final.DoneReason != "stop" || final.EvalCount != 2 {
+		t.Errorf("expected final chunk with done reason and eval count, got %+v", final)
+	}
+}
+
+func TestClient_ChatStream_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model not found"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	err := client.ChatStream(context.Background(), &ChatRequest{Model: "missing"}, func(*ChatResponse) error { return nil })
+
+	if err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Errorf("expected error with Ollama's message, got %v", err)
+	}
+}
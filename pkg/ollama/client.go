@@ -2,19 +2,52 @@
 package ollama
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"syscall"
 	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/tracing"
 )
 
 // Client provides access to the Ollama API
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	opts       ClientOptions
+}
+
+// ClientOptions configures a Client's timeouts and retries. Timeouts cover
+// the whole call, including reading a streamed response and any retries.
+type ClientOptions struct {
+	Timeout      time.Duration // Generate, chat and embed calls. Default: 5 minutes
+	PingTimeout  time.Duration // Ping, ListModels and ListRunning. Default: 5 seconds
+	AdminTimeout time.Duration // Show, Copy and Delete; Pull has none, as downloads can take hours. Default: 30 seconds
+
+	MaxRetries      int           // Retries after a transient connection error, 0 for none
+	RetryBackoff    time.Duration // Delay before the first retry, doubled for each one after. Default: 250ms
+	MaxRetryBackoff time.Duration // Upper bound on the delay between retries. Default: 5 seconds
+
+	OnRetry func(attempt int, err error) // Called before each retry; optional
+}
+
+// DefaultClientOptions returns the options used by NewClient
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		Timeout:         5 * time.Minute, // LLM inference can take a while
+		PingTimeout:     5 * time.Second,
+		AdminTimeout:    30 * time.Second,
+		MaxRetries:      2,
+		RetryBackoff:    250 * time.Millisecond,
+		MaxRetryBackoff: 5 * time.Second,
+	}
 }
 
 // GenerateRequest represents a request to generate text
@@ -24,15 +57,30 @@ type GenerateRequest struct {
 	System  string           `json:"system,omitempty"`
 	Stream  bool             `json:"stream"`
 	Options *GenerateOptions `json:"options,omitempty"`
+
+	// Constrains the output to FormatJSON or a JSON schema (free text if nil)
+	Format json.RawMessage `json:"format,omitempty"`
+
+	// How long the model stays loaded after the request (Ollama's default if nil)
+	KeepAlive *Duration `json:"keep_alive,omitempty"`
+
+	// Context returned by an earlier response, continuing that conversation
+	Context []int `json:"context,omitempty"`
 }
 
+// FormatJSON asks for output that is any valid JSON
+var FormatJSON = json.RawMessage(`"json"`)
+
 // GenerateOptions contains generation parameters
 type GenerateOptions struct {
-	Temperature   float64 `json:"temperature,omitempty"`
-	NumPredict    int     `json:"num_predict,omitempty"`
-	TopP          float64 `json:"top_p,omitempty"`
-	TopK          int     `json:"top_k,omitempty"`
-	RepeatPenalty float64 `json:"repeat_penalty,omitempty"`
+	Temperature   float64  `json:"temperature,omitempty"`
+	NumPredict    int      `json:"num_predict,omitempty"`
+	TopP          float64  `json:"top_p,omitempty"`
+	TopK          int      `json:"top_k,omitempty"`
+	RepeatPenalty float64  `json:"repeat_penalty,omitempty"`
+	Seed          *int     `json:"seed,omitempty"` // Random if nil
+	Stop          []string `json:"stop,omitempty"`
+	NumCtx        int      `json:"num_ctx,omitempty"` // Context window size in tokens
 }
 
 // GenerateResponse represents a response from Ollama
@@ -50,6 +98,22 @@ type GenerateResponse struct {
 	EvalDuration       int64     `json:"eval_duration,omitempty"`
 }
 
+// EmbedRequest represents a request to compute embeddings
+type EmbedRequest struct {
+	Model     string    `json:"model"`
+	Input     []string  `json:"input"`
+	KeepAlive *Duration `json:"keep_alive,omitempty"`
+}
+
+// EmbedResponse represents the embeddings returned by Ollama
+type EmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float32 `json:"embeddings"`
+	TotalDuration   int64       `json:"total_duration,omitempty"`
+	LoadDuration    int64       `json:"load_duration,omitempty"`
+	PromptEvalCount int         `json:"prompt_eval_count,omitempty"`
+}
+
 // ModelsResponse represents the list of available models
 type ModelsResponse struct {
 	Models []Model `json:"models"`
@@ -63,18 +127,50 @@ type Model struct {
 	Digest     string    `json:"digest"`
 }
 
-// NewClient creates a new Ollama client
+// NewClient creates a new Ollama client with the default options
 func NewClient(baseURL string) *Client {
+	return NewClientWithOptions(baseURL, DefaultClientOptions())
+}
+
+// NewClientWithOptions creates a new Ollama client. Timeouts and backoffs
+// that aren't positive use their defaults.
+func NewClientWithOptions(baseURL string, opts ClientOptions) *Client {
 	if baseURL == "" {
 		// Default to localhost, but use host.docker.internal for Docker
 		baseURL = "http://localhost:11434"
 	}
 
+	defaults := DefaultClientOptions()
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaults.Timeout
+	}
+	if opts.PingTimeout <= 0 {
+		opts.PingTimeout = defaults.PingTimeout
+	}
+	if opts.AdminTimeout <= 0 {
+		opts.AdminTimeout = defaults.AdminTimeout
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaults.RetryBackoff
+	}
+	if opts.MaxRetryBackoff <= 0 {
+		opts.MaxRetryBackoff = defaults.MaxRetryBackoff
+	}
+	if opts.OnRetry == nil {
+		opts.OnRetry = func(int, error) {}
+	}
+
 	return &Client{
 		baseURL: baseURL,
+		// Calls are bounded by their context rather than a client timeout,
+		// which would also cut off long pulls
 		httpClient: &http.Client{
-			Timeout: 5 * time.Minute, // LLM inference can take a while
+			Transport: tracing.Transport(http.DefaultTransport),
 		},
+		opts: opts,
 	}
 }
 
@@ -82,29 +178,49 @@ func NewClient(baseURL string) *Client {
 func (c *Client) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
 	req.Stream = false // Use non-streaming for simplicity
 
-	body, err := json.Marshal(req)
+	resp, err := c.send(ctx, c.opts.Timeout, "POST", "/api/generate", req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/generate", bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	var result GenerateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	return &result, nil
+}
+
+// GenerateStream sends a prompt to Ollama and calls fn for each streamed chunk.
+// The final chunk has Done set and carries the token counts and timings.
+func (c *Client) GenerateStream(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse) error) error {
+	req.Stream = true
+
+	resp, err := c.send(ctx, c.opts.Timeout, "POST", "/api/generate", req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	return readStream(resp.Body, func(line []byte) (bool, error) {
+		var chunk GenerateResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return false, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		return chunk.Done, fn(&chunk)
+	})
+}
+
+// Embed computes embedding vectors for the given inputs
+func (c *Client) Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error) {
+	resp, err := c.send(ctx, c.opts.Timeout, "POST", "/api/embed", req)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	var result GenerateResponse
+	var result EmbedResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
@@ -112,43 +228,149 @@ func (c *Client) Generate(ctx context.Context, req *GenerateRequest) (*GenerateR
 	return &result, nil
 }
 
-// Ping checks if Ollama is reachable
-func (c *Client) Ping(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+// send sends req, if not nil, as JSON to path, retrying transient connection
+// errors. The call is bounded by timeout (0 for none) until the response body
+// is closed. A non-200 status is returned as a *StatusError; otherwise the
+// caller must close the response body.
+func (c *Client) send(ctx context.Context, timeout time.Duration, method, path string, req interface{}) (*http.Response, error) {
+	var data []byte
+	if req != nil {
+		var err error
+		data, err = json.Marshal(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
 	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to connect to Ollama: %w", err)
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ollama returned status %d", resp.StatusCode)
+	backoff := c.opts.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		var body io.Reader
+		if data != nil {
+			body = bytes.NewReader(data)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if body != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err == nil {
+			if resp.StatusCode != http.StatusOK {
+				defer cancel()
+				defer resp.Body.Close()
+				bodyBytes, _ := io.ReadAll(resp.Body)
+				return nil, newStatusError(resp.StatusCode, bodyBytes)
+			}
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		if attempt > c.opts.MaxRetries || ctx.Err() != nil || !transient(err) {
+			cancel()
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+
+		c.opts.OnRetry(attempt, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			cancel()
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+		backoff = min(backoff*2, c.opts.MaxRetryBackoff)
 	}
+}
 
-	return nil
+// transient reports whether err, from sending a request, is a connection
+// failure that may succeed if retried, such as Ollama restarting
+func transient(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
 }
 
-// ListModels returns the list of available models
-func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
+// cancelOnClose releases a call's timeout once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// readStream calls fn for each line of a newline-delimited JSON stream until
+// fn reports the final chunk. A stream that ends before then is an error, as
+// is an error line, which Ollama sends when it fails after responding 200.
+func readStream(r io.Reader, fn func(line []byte) (done bool, err error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(line, &failure) == nil && failure.Error != "" {
+			return newStatusError(http.StatusOK, line)
+		}
+
+		done, err := fn(line)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return fmt.Errorf("stream ended before completion")
+}
+
+// Ping checks if Ollama is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.send(ctx, c.opts.PingTimeout, "GET", "/api/tags", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to connect to Ollama: %w", err)
 	}
+	return resp.Body.Close()
+}
 
-	resp, err := c.httpClient.Do(req)
+// ListModels returns the list of available models
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	resp, err := c.send(ctx, c.opts.PingTimeout, "GET", "/api/tags", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list models: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ollama returned status %d", resp.StatusCode)
-	}
-
 	var result ModelsResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
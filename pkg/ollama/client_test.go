@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -118,6 +119,102 @@ func TestClient_Generate_Error(t *testing.T) {
 	}
 }
 
+func TestClient_GenerateStream_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if !req.Stream {
+			t.Error("expected stream to be enabled")
+		}
+
+		enc := json.NewEncoder(w)
+		enc.Encode(GenerateResponse{Model: "llama3.2", Response: "Hello"})
+		enc.Encode(GenerateResponse{Model: "llama3.2", Response: ", world!"})
+		enc.Encode(GenerateResponse{Model: "llama3.2", Done: true, EvalCount: 2, PromptEvalCount: 3})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	var text string
+	var final *GenerateResponse
+	err := client.GenerateStream(context.Background(), &GenerateRequest{
+		Model:  "llama3.2",
+		Prompt: "Say hello",
+	}, func(chunk *GenerateResponse) error {
+		text += chunk.Response
+		if chunk.Done {
+			final = chunk
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if text != "Hello, world!" {
+		t.Errorf("expected concatenated text %q, got %q", "Hello, world!", text)
+	}
+
+	if final == nil || final.EvalCount != 2 {
+		t.Errorf("expected final chunk with eval count 2, got %+v", final)
+	}
+}
+
+func TestClient_GenerateStream_Incomplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GenerateResponse{Model: "llama3.2", Response: "Hel"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	err := client.GenerateStream(context.Background(), &GenerateRequest{
+		Model:  "llama3.2",
+		Prompt: "Say hello",
+	}, func(chunk *GenerateResponse) error { return nil })
+
+	if err == nil {
+		t.Error("expected error for stream without a done chunk")
+	}
+}
+
+func TestClient_Embed_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		var req EmbedRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if len(req.Input) != 2 {
+			t.Errorf("expected 2 inputs, got %d", len(req.Input))
+		}
+
+		json.NewEncoder(w).Encode(EmbedResponse{
+			Model:      req.Model,
+			Embeddings: [][]float32{{0.1, 0.2}, {0.3, 0.4}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	resp, err := client.Embed(context.Background(), &EmbedRequest{
+		Model: "nomic-embed-text",
+		Input: []string{"hello", "world"},
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(resp.Embeddings) != 2 || resp.Embeddings[1][0] != 0.3 {
+		t.Errorf("unexpected embeddings: %v", resp.Embeddings)
+	}
+}
+
 func TestClient_ListModels(t *testing.T) {
 	expectedModels := []Model{
 		{Name: "llama3.2", Size: 1000000},
@@ -165,3 +262,150 @@ func TestClient_ContextCancellation(t *testing.T) {
 		t.Error("expected error for cancelled context")
 	}
 }
+
+func TestNewClientWithOptions_Defaults(t *testing.T) {
+	client := NewClientWithOptions("", ClientOptions{PingTimeout: time.Second, MaxRetries: -1})
+
+	if client.opts.PingTimeout != time.Second {
+		t.Errorf("expected ping timeout to be kept, got %v", client.opts.PingTimeout)
+	}
+	if client.opts.Timeout != 5*time.Minute || client.opts.RetryBackoff != 250*time.Millisecond {
+		t.Errorf("expected defaults for unset options, got %+v", client.opts)
+	}
+	if client.opts.MaxRetries != 0 {
+		t.Errorf("expected negative retries to disable them, got %d", client.opts.MaxRetries)
+	}
+}
+
+// dropConnections returns a handler that closes the connection without a
+// response for the first n requests, then responds with a generation
+func dropConnections(t *testing.T, n int32, calls *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= n {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Fatalf("hijack failed: %v", err)
+			}
+			conn.Close()
+			return
+		}
+		json.NewEncoder(w).Encode(GenerateResponse{Response: "ok", Done: true})
+	}
+}
+
+func TestClient_RetriesConnectionErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(dropConnections(t, 2, &calls))
+	defer server.Close()
+
+	var retries []int
+	client := NewClientWithOptions(server.URL, ClientOptions{
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		OnRetry:      func(attempt int, err error) { retries = append(retries, attempt) },
+	})
+	resp, err := client.Generate(context.Background(), &GenerateRequest{Model: "llama3.2", Prompt: "hi"})
+
+	if err != nil {
+		t.Fatalf("expected retries to succeed, got %v", err)
+	}
+	if resp.Response != "ok" || calls.Load() != 3 {
+		t.Errorf("expected 3 calls and a response, got %d calls and %+v", calls.Load(), resp)
+	}
+	if len(retries) != 2 || retries[1] != 2 {
+		t.Errorf("expected OnRetry for attempts 1 and 2, got %v", retries)
+	}
+}
+
+func TestClient_RetriesExhausted(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(dropConnections(t, 5, &calls))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, ClientOptions{MaxRetries: 1, RetryBackoff: time.Millisecond})
+	_, err := client.Generate(context.Background(), &GenerateRequest{Model: "llama3.2"})
+
+	if err == nil {
+		t.Fatal("expected error after retries are exhausted")
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 calls, got %d", calls.Load())
+	}
+}
+
+func TestClient_NoRetryOnStatusError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, ClientOptions{MaxRetries: 3, RetryBackoff: time.Millisecond})
+	if _, err := client.Generate(context.Background(), &GenerateRequest{Model: "llama3.2"}); err == nil {
+		t.Fatal("expected error for 500 response")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected Ollama's errors not to be retried, got %d calls", calls.Load())
+	}
+}
+
+func TestClient_PerCallTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		json.NewEncoder(w).Encode(GenerateResponse{Response: "ok", Done: true})
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, ClientOptions{PingTimeout: 20 * time.Millisecond, Timeout: time.Second})
+
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("expected ping to time out")
+	}
+	if _, err := client.Generate(context.Background(), &GenerateRequest{Model: "llama3.2"}); err != nil {
+		t.Errorf("expected generate to outlast the ping timeout, got %v", err)
+	}
+}
+
+func TestClient_TimeoutCoversStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GenerateResponse{Response: "a"})
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+		json.NewEncoder(w).Encode(GenerateResponse{Done: true})
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, ClientOptions{Timeout: 50 * time.Millisecond})
+	err := client.GenerateStream(context.Background(), &GenerateRequest{Model: "llama3.2"}, func(*GenerateResponse) error {
+		return nil
+	})
+
+	if err == nil {
+		t.Error("expected the timeout to cut off the stream")
+	}
+}
+
+func TestClient_Generate_Format(t *testing.T) {
+	schema := json.RawMessage(`{"type":"object","properties":{"answer":{"type":"string"}}}`)
+
+	var formats []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]json.RawMessage
+		json.NewDecoder(r.Body).Decode(&body)
+		formats = append(formats, string(body["format"]))
+		json.NewEncoder(w).Encode(GenerateResponse{Response: `{"answer":"blue"}`, Done: true})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	for _, format := range []json.RawMessage{nil, FormatJSON, schema} {
+		if _, err := client.Generate(context.Background(), &GenerateRequest{Model: "llama3.2", Format: format}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if formats[0] != "" || formats[1] != `"json"` || formats[2] != string(schema) {
+		t.Errorf("unexpected formats sent: %q", formats)
+	}
+}
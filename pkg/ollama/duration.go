@@ -0,0 +1,76 @@
+package ollama
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Duration is a time.Duration sent to Ollama as a number of seconds, as used
+// by keep_alive. A negative duration means forever and zero means none.
+type Duration time.Duration
+
+// Forever keeps a model loaded until Ollama restarts or it is evicted
+const Forever = Duration(-1)
+
+// NewDuration returns a pointer to d, for optional request fields
+func NewDuration(d time.Duration) *Duration {
+	v := Duration(d)
+	return &v
+}
+
+// ParseDuration parses a duration the way Ollama does: a Go duration such as
+// "10m", or a number of seconds such as "300". Negative values mean forever.
+func ParseDuration(s string) (Duration, error) {
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		if n < 0 {
+			return Forever, nil
+		}
+		return Duration(n * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: use a duration such as 10m or a number of seconds", s)
+	}
+	if d < 0 {
+		return Forever, nil
+	}
+	return Duration(d), nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	if d < 0 {
+		return []byte("-1"), nil
+	}
+	return json.Marshal(time.Duration(d).Seconds())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting seconds or a Go
+// duration string
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		parsed, err := ParseDuration(strconv.FormatFloat(v, 'f', -1, 64))
+		*d = parsed
+		return err
+	case string:
+		parsed, err := ParseDuration(v)
+		*d = parsed
+		return err
+	}
+	return fmt.Errorf("invalid duration %s", b)
+}
+
+// String formats the duration like time.Duration, or "forever"
+func (d Duration) String() string {
+	if d < 0 {
+		return "forever"
+	}
+	return time.Duration(d).String()
+}
@@ -0,0 +1,57 @@
+package ollama
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Duration
+	}{
+		{"10m", Duration(10 * time.Minute)},
+		{"300", Duration(5 * time.Minute)},
+		{"0", 0},
+		{"-1", Forever},
+		{"-5m", Forever},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	if _, err := ParseDuration("soon"); err == nil {
+		t.Error("expected error for invalid duration")
+	}
+}
+
+func TestDuration_JSON(t *testing.T) {
+	req := GenerateRequest{Model: "llama3.2", KeepAlive: NewDuration(90 * time.Second)}
+	body, _ := json.Marshal(req)
+	if !strings.Contains(string(body), `"keep_alive":90`) {
+		t.Errorf("expected keep_alive in seconds, got %s", body)
+	}
+
+	body, _ = json.Marshal(ChatRequest{Model: "llama3.2", KeepAlive: NewDuration(0)})
+	if !strings.Contains(string(body), `"keep_alive":0`) {
+		t.Errorf("expected zero keep_alive to be sent, got %s", body)
+	}
+
+	body, _ = json.Marshal(GenerateRequest{Model: "llama3.2"})
+	if strings.Contains(string(body), "keep_alive") {
+		t.Errorf("expected keep_alive to be omitted, got %s", body)
+	}
+
+	var d Duration
+	if err := json.Unmarshal([]byte(`"5m"`), &d); err != nil || d != Duration(5*time.Minute) {
+		t.Errorf("expected 5m from a string, got %v, %v", d, err)
+	}
+	if err := json.Unmarshal([]byte(`-1`), &d); err != nil || d != Forever {
+		t.Errorf("expected forever from -1, got %v, %v", d, err)
+	}
+}
@@ -0,0 +1,81 @@
+package ollama
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Kinds of failure reported by Ollama. Errors returned by the client wrap
+// one of these when the cause is recognized; test with errors.Is.
+var (
+	ErrModelNotFound  = errors.New("model not found")
+	ErrOutOfMemory    = errors.New("out of memory")
+	ErrModelLoading   = errors.New("model is loading")
+	ErrContextTooLong = errors.New("input exceeds the context length")
+)
+
+// StatusError is an error reported by Ollama, either as a non-200 response
+// or as an error line in a stream (StatusCode 200)
+type StatusError struct {
+	StatusCode int
+	Message    string
+
+	kind error
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ollama returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap returns the kind of failure, if recognized
+func (e *StatusError) Unwrap() error {
+	return e.kind
+}
+
+// newStatusError builds a StatusError from a response body, which Ollama
+// sends as {"error": "..."}
+func newStatusError(statusCode int, body []byte) *StatusError {
+	message := strings.TrimSpace(string(body))
+	var parsed struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && parsed.Error != "" {
+		message = parsed.Error
+	}
+
+	return &StatusError{
+		StatusCode: statusCode,
+		Message:    message,
+		kind:       classify(statusCode, message),
+	}
+}
+
+// classify recognizes a failure from its status and message. Ollama's
+// messages aren't a stable API, so matching is on the phrases it has used
+// across releases.
+func classify(statusCode int, message string) error {
+	msg := strings.ToLower(message)
+	switch {
+	case strings.Contains(msg, "page not found"):
+		// An endpoint this Ollama version doesn't have
+		return nil
+	case statusCode == http.StatusNotFound,
+		strings.Contains(msg, "not found"):
+		return ErrModelNotFound
+	case strings.Contains(msg, "out of memory"),
+		strings.Contains(msg, "requires more system memory"),
+		strings.Contains(msg, "insufficient memory"),
+		strings.Contains(msg, "cudamalloc failed"):
+		return ErrOutOfMemory
+	case strings.Contains(msg, "loading model"),
+		strings.Contains(msg, "waiting for llama runner to start"):
+		return ErrModelLoading
+	case strings.Contains(msg, "context length"),
+		strings.Contains(msg, "context window"):
+		return ErrContextTooLong
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_TypedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"missing model", http.StatusNotFound, `{"error":"model \"llama9\" not found, try pulling it first"}`, ErrModelNotFound},
+		{"out of memory", http.StatusInternalServerError, `{"error":"model requires more system memory (12.0 GiB) than is available (7.5 GiB)"}`, ErrOutOfMemory},
+		{"cuda out of memory", http.StatusInternalServerError, `{"error":"llama runner process has terminated: CUDA error: out of memory"}`, ErrOutOfMemory},
+		{"loading", http.StatusInternalServerError, `{"error":"timed out waiting for llama runner to start"}`, ErrModelLoading},
+		{"context too long", http.StatusBadRequest, `{"error":"the input length exceeds the context length"}`, ErrContextTooLong},
+		{"unknown endpoint", http.StatusNotFound, "404 page not found", nil},
+		{"other", http.StatusInternalServerError, `{"error":"unexpected EOF"}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := NewClient(server.URL).Generate(context.Background(), &GenerateRequest{Model: "llama9", Prompt: "hi"})
+
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status {
+				t.Fatalf("expected a *StatusError with status %d, got %v", tt.status, err)
+			}
+			if statusErr.Message == "" || statusErr.Message[0] == '{' {
+				t.Errorf("expected the message to be extracted from the body, got %q", statusErr.Message)
+			}
+			if got := errors.Unwrap(err); got != tt.want {
+				t.Errorf("expected kind %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_StreamErrorLine(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{\"model\":\"llama3.2\",\"response\":\"Hel\"}\n{\"error\":\"CUDA error: out of memory\"}\n"))
+	}))
+	defer server.Close()
+
+	var chunks int
+	err := NewClient(server.URL).GenerateStream(context.Background(), &GenerateRequest{Model: "llama3.2"}, func(*GenerateResponse) error {
+		chunks++
+		return nil
+	})
+
+	if !errors.Is(err, ErrOutOfMemory) {
+		t.Errorf("expected ErrOutOfMemory from the stream, got %v", err)
+	}
+	if chunks != 1 {
+		t.Errorf("expected 1 chunk before the error, got %d", chunks)
+	}
+}
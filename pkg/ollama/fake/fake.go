@@ -0,0 +1,402 @@
+// Package fake provides a scripted, in-memory Ollama for tests that shouldn't
+// depend on a running Ollama
+package fake
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/ollama"
+)
+
+// Response is a scripted reply to a generate, chat or embed request
+type Response struct {
+	Text      string
+	ToolCalls []ollama.ToolCall // Returned by chat requests
+	Err       error             // Returned instead of a reply
+
+	Latency    time.Duration // Delay before replying, or before the first chunk when streaming
+	ChunkDelay time.Duration // Delay between streamed chunks
+
+	// Token counts; the number of words in the prompt and reply if zero
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Call records a request the fake received
+type Call struct {
+	Method string // The Client method, e.g. "GenerateStream"
+	Model  string
+	Prompt string // The prompt, the last chat message, or the embed inputs joined by newlines
+}
+
+// Client is a fake Ollama implementing ollama.Generator and
+// ollama.ModelManager. It is safe for concurrent use.
+type Client struct {
+	mu       sync.Mutex
+	models   []string
+	running  []ollama.RunningModel
+	scripts  map[string][]Response
+	fallback Response
+	pingErr  error
+	calls    []Call
+}
+
+var (
+	_ ollama.Generator    = (*Client)(nil)
+	_ ollama.ModelManager = (*Client)(nil)
+)
+
+// New creates a fake Ollama with the given models installed. Requests for
+// other models fail with ollama.ErrModelNotFound.
+func New(models ...string) *Client {
+	return &Client{
+		models:   models,
+		scripts:  make(map[string][]Response),
+		fallback: Response{Text: "This is a fake response."},
+	}
+}
+
+// Script queues responses for a model, used in order. The last one repeats
+// once the others have been used.
+func (c *Client) Script(model string, responses ...Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scripts[model] = append(c.scripts[model], responses...)
+}
+
+// SetDefault sets the response for models without a script
+func (c *Client) SetDefault(r Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fallback = r
+}
+
+// SetPingError makes Ping and ListModels fail with err, or succeed if nil
+func (c *Client) SetPingError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pingErr = err
+}
+
+// SetRunning sets the models reported as loaded into memory
+func (c *Client) SetRunning(models ...ollama.RunningModel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running = models
+}
+
+// Calls returns the requests received so far, in order
+func (c *Client) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.calls)
+}
+
+// Ping fails with the error set by SetPingError
+func (c *Client) Ping(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pingErr
+}
+
+// ListModels returns the installed models
+func (c *Client) ListModels(ctx context.Context) ([]ollama.Model, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pingErr != nil {
+		return nil, c.pingErr
+	}
+
+	models := make([]ollama.Model, len(c.models))
+	for i, name := range c.models {
+		models[i] = ollama.Model{Name: name}
+	}
+	return models, nil
+}
+
+// ListRunning returns the models set by SetRunning
+func (c *Client) ListRunning(ctx context.Context) ([]ollama.RunningModel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.running), nil
+}
+
+// Generate replies with the model's next scripted response
+func (c *Client) Generate(ctx context.Context, req *ollama.GenerateRequest) (*ollama.GenerateResponse, error) {
+	r, err := c.respond(ctx, "Generate", req.Model, req.Prompt)
+	if err != nil {
+		return nil, err
+	}
+	return &ollama.GenerateResponse{
+		Model:           req.Model,
+		CreatedAt:       time.Now(),
+		Response:        r.Text,
+		Done:            true,
+		PromptEvalCount: r.PromptTokens,
+		EvalCount:       r.CompletionTokens,
+		Context:         extendContext(req.Context, req.Prompt, r.Text),
+	}, nil
+}
+
+// GenerateStream streams the model's next scripted response a word at a time
+func (c *Client) GenerateStream(ctx context.Context, req *ollama.GenerateRequest, fn func(*ollama.GenerateResponse) error) error {
+	r, err := c.respond(ctx, "GenerateStream", req.Model, req.Prompt)
+	if err != nil {
+		return err
+	}
+
+	err = stream(ctx, r, func(chunk string) error {
+		return fn(&ollama.GenerateResponse{Model: req.Model, CreatedAt: time.Now(), Response: chunk})
+	})
+	if err != nil {
+		return err
+	}
+	return fn(&ollama.GenerateResponse{
+		Model:           req.Model,
+		CreatedAt:       time.Now(),
+		Done:            true,
+		PromptEvalCount: r.PromptTokens,
+		EvalCount:       r.CompletionTokens,
+		Context:         extendContext(req.Context, req.Prompt, r.Text),
+	})
+}
+
+// Chat replies with the model's next scripted response
+func (c *Client) Chat(ctx context.Context, req *ollama.ChatRequest) (*ollama.ChatResponse, error) {
+	r, err := c.respond(ctx, "Chat", req.Model, lastMessage(req.Messages))
+	if err != nil {
+		return nil, err
+	}
+	return &ollama.ChatResponse{
+		Model:     req.Model,
+		CreatedAt: time.Now(),
+		Message: ollama.ChatMessage{
+			Role:      ollama.RoleAssistant,
+			Content:   r.Text,
+			ToolCalls: r.ToolCalls,
+		},
+		Done:            true,
+		DoneReason:      "stop",
+		PromptEvalCount: r.PromptTokens,
+		EvalCount:       r.CompletionTokens,
+	}, nil
+}
+
+// ChatStream streams the model's next scripted response a word at a time,
+// with any tool calls in the final chunk
+func (c *Client) ChatStream(ctx context.Context, req *ollama.ChatRequest, fn func(*ollama.ChatResponse) error) error {
+	r, err := c.respond(ctx, "ChatStream", req.Model, lastMessage(req.Messages))
+	if err != nil {
+		return err
+	}
+
+	err = stream(ctx, r, func(chunk string) error {
+		return fn(&ollama.ChatResponse{
+			Model:     req.Model,
+			CreatedAt: time.Now(),
+			Message:   ollama.ChatMessage{Role: ollama.RoleAssistant, Content: chunk},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return fn(&ollama.ChatResponse{
+		Model:           req.Model,
+		CreatedAt:       time.Now(),
+		Message:         ollama.ChatMessage{Role: ollama.RoleAssistant, ToolCalls: r.ToolCalls},
+		Done:            true,
+		DoneReason:      "stop",
+		PromptEvalCount: r.PromptTokens,
+		EvalCount:       r.CompletionTokens,
+	})
+}
+
+// Embed returns a vector derived from each input's hash, so equal inputs
+// have equal embeddings. The scripted response's text is ignored.
+func (c *Client) Embed(ctx context.Context, req *ollama.EmbedRequest) (*ollama.EmbedResponse, error) {
+	r, err := c.respond(ctx, "Embed", req.Model, strings.Join(req.Input, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	embeddings := make([][]float32, len(req.Input))
+	for i, input := range req.Input {
+		sum := sha256.Sum256([]byte(input))
+		vector := make([]float32, 8)
+		for j := range vector {
+			vector[j] = float32(sum[j])/255 - 0.5
+		}
+		embeddings[i] = vector
+	}
+	return &ollama.EmbedResponse{Model: req.Model, Embeddings: embeddings, PromptEvalCount: r.PromptTokens}, nil
+}
+
+// Pull installs a model, reporting a single progress update
+func (c *Client) Pull(ctx context.Context, req *ollama.PullRequest, fn func(*ollama.ProgressResponse) error) error {
+	c.mu.Lock()
+	c.calls = append(c.calls, Call{Method: "Pull", Model: req.Model})
+	if c.find(req.Model) < 0 {
+		c.models = append(c.models, req.Model)
+	}
+	c.mu.Unlock()
+
+	if fn != nil {
+		return fn(&ollama.ProgressResponse{Status: "success"})
+	}
+	return nil
+}
+
+// Delete removes an installed model
+func (c *Client) Delete(ctx context.Context, req *ollama.DeleteRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, Call{Method: "Delete", Model: req.Model})
+
+	i := c.find(req.Model)
+	if i < 0 {
+		return notFound(req.Model)
+	}
+	c.models = slices.Delete(c.models, i, i+1)
+	return nil
+}
+
+// Copy installs an installed model under a new name
+func (c *Client) Copy(ctx context.Context, req *ollama.CopyRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, Call{Method: "Copy", Model: req.Source})
+
+	if c.find(req.Source) < 0 {
+		return notFound(req.Source)
+	}
+	if c.find(req.Destination) < 0 {
+		c.models = append(c.models, req.Destination)
+	}
+	return nil
+}
+
+// Show describes an installed model
+func (c *Client) Show(ctx context.Context, req *ollama.ShowRequest) (*ollama.ShowResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, Call{Method: "Show", Model: req.Model})
+
+	if c.find(req.Model) < 0 {
+		return nil, notFound(req.Model)
+	}
+	return &ollama.ShowResponse{
+		Modelfile:    "FROM " + req.Model,
+		Details:      ollama.ModelDetails{Format: "gguf"},
+		Capabilities: []string{"completion"},
+	}, nil
+}
+
+// respond records a call and returns the model's next response, after its
+// latency, with token counts filled in
+func (c *Client) respond(ctx context.Context, method, model, prompt string) (Response, error) {
+	c.mu.Lock()
+	c.calls = append(c.calls, Call{Method: method, Model: model, Prompt: prompt})
+	installed := c.find(model) >= 0
+	r := c.next(model)
+	c.mu.Unlock()
+
+	if !installed {
+		return r, notFound(model)
+	}
+	if err := sleep(ctx, r.Latency); err != nil {
+		return r, err
+	}
+	if r.Err != nil {
+		return r, r.Err
+	}
+
+	if r.PromptTokens == 0 {
+		r.PromptTokens = len(strings.Fields(prompt))
+	}
+	if r.CompletionTokens == 0 {
+		r.CompletionTokens = len(strings.Fields(r.Text))
+	}
+	return r, nil
+}
+
+// next pops the model's next scripted response; c.mu must be held
+func (c *Client) next(model string) Response {
+	script := c.scripts[model]
+	if len(script) == 0 {
+		return c.fallback
+	}
+	if len(script) > 1 {
+		c.scripts[model] = script[1:]
+	}
+	return script[0]
+}
+
+// find returns the index of an installed model, matching Ollama's implicit
+// ":latest" tag, or -1; c.mu must be held
+func (c *Client) find(model string) int {
+	return slices.IndexFunc(c.models, func(name string) bool {
+		return name == model || name == model+":latest" || name+":latest" == model
+	})
+}
+
+// stream calls fn with each word of the response's text, keeping the
+// separating spaces so the chunks join to the full text
+func stream(ctx context.Context, r Response, fn func(chunk string) error) error {
+	if r.Text == "" {
+		return nil
+	}
+	for i, chunk := range strings.SplitAfter(r.Text, " ") {
+		if i > 0 {
+			if err := sleep(ctx, r.ChunkDelay); err != nil {
+				return err
+			}
+		}
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// extendContext appends a token per word of the prompt and response to
+// context, standing in for the tokens Ollama returns
+func extendContext(context []int, prompt, response string) []int {
+	extended := append([]int(nil), context...)
+	for _, word := range strings.Fields(prompt + " " + response) {
+		sum := sha256.Sum256([]byte(word))
+		extended = append(extended, int(sum[0])<<8|int(sum[1]))
+	}
+	return extended
+}
+
+func lastMessage(messages []ollama.ChatMessage) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	return messages[len(messages)-1].Content
+}
+
+func notFound(model string) error {
+	return fmt.Errorf("model %q not found: %w", model, ollama.ErrModelNotFound)
+}
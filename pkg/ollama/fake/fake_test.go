@@ -0,0 +1,191 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/ollama"
+)
+
+func TestClient_ScriptedResponses(t *testing.T) {
+	client := New("llama3.2:latest")
+	client.Script("llama3.2",
+		Response{Text: "first"},
+		Response{Err: ollama.ErrOutOfMemory},
+		Response{Text: "last", CompletionTokens: 7},
+	)
+	ctx := context.Background()
+	req := &ollama.GenerateRequest{Model: "llama3.2", Prompt: "why is the sky blue"}
+
+	resp, err := client.Generate(ctx, req)
+	if err != nil || resp.Response != "first" || resp.PromptEvalCount != 5 {
+		t.Errorf("unexpected first response: %+v, %v", resp, err)
+	}
+	if _, err := client.Generate(ctx, req); !errors.Is(err, ollama.ErrOutOfMemory) {
+		t.Errorf("expected scripted error, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Generate(ctx, req)
+		if err != nil || resp.Response != "last" || resp.EvalCount != 7 {
+			t.Errorf("expected the last response to repeat, got %+v, %v", resp, err)
+		}
+	}
+
+	if calls := client.Calls(); len(calls) != 4 || calls[0].Prompt != "why is the sky blue" {
+		t.Errorf("unexpected calls: %+v", calls)
+	}
+}
+
+func TestClient_UnknownModel(t *testing.T) {
+	client := New("llama3.2")
+
+	_, err := client.Chat(context.Background(), &ollama.ChatRequest{Model: "mistral"})
+	if !errors.Is(err, ollama.ErrModelNotFound) {
+		t.Errorf("expected ErrModelNotFound, got %v", err)
+	}
+}
+
+func TestClient_Stream(t *testing.T) {
+	client := New("llama3.2")
+	client.SetDefault(Response{Text: "Hello there friend"})
+
+	var chunks []string
+	var final *ollama.GenerateResponse
+	err := client.GenerateStream(context.Background(), &ollama.GenerateRequest{Model: "llama3.2"}, func(r *ollama.GenerateResponse) error {
+		if r.Done {
+			final = r
+		} else {
+			chunks = append(chunks, r.Response)
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(chunks) != 3 || strings.Join(chunks, "") != "Hello there friend" {
+		t.Errorf("unexpected chunks: %q", chunks)
+	}
+	if final == nil || final.EvalCount != 3 {
+		t.Errorf("expected a final chunk with token counts, got %+v", final)
+	}
+}
+
+func TestClient_ChatToolCalls(t *testing.T) {
+	client := New("llama3.2")
+	call := ollama.ToolCall{Function: ollama.ToolCallFunction{Name: "get_weather"}}
+	client.Script("llama3.2", Response{ToolCalls: []ollama.ToolCall{call}})
+
+	resp, err := client.Chat(context.Background(), &ollama.ChatRequest{
+		Model:    "llama3.2",
+		Messages: []ollama.ChatMessage{{Role: ollama.RoleUser, Content: "weather?"}},
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(resp.Message.ToolCalls) != 1 || resp.Message.ToolCalls[0].Function.Name != "get_weather" {
+		t.Errorf("unexpected tool calls: %+v", resp.Message)
+	}
+}
+
+func TestClient_LatencyRespectsContext(t *testing.T) {
+	client := New("llama3.2")
+	client.SetDefault(Response{Text: "slow", Latency: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Generate(ctx, &ollama.GenerateRequest{Model: "llama3.2"})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("expected the latency to be cut short")
+	}
+}
+
+func TestClient_Context(t *testing.T) {
+	client := New("llama3.2")
+	client.SetDefault(Response{Text: "hello there"})
+	ctx := context.Background()
+
+	first, err := client.Generate(ctx, &ollama.GenerateRequest{Model: "llama3.2", Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(first.Context) != 3 {
+		t.Fatalf("expected a token per word, got %v", first.Context)
+	}
+
+	var last *ollama.GenerateResponse
+	err = client.GenerateStream(ctx, &ollama.GenerateRequest{Model: "llama3.2", Prompt: "again", Context: first.Context}, func(chunk *ollama.GenerateResponse) error {
+		last = chunk
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(last.Context) != 6 || last.Context[0] != first.Context[0] {
+		t.Errorf("expected context to extend the previous one, got %v", last.Context)
+	}
+}
+
+func TestClient_Embed(t *testing.T) {
+	client := New("nomic-embed-text")
+
+	resp, err := client.Embed(context.Background(), &ollama.EmbedRequest{
+		Model: "nomic-embed-text",
+		Input: []string{"a", "b", "a"},
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(resp.Embeddings) != 3 || len(resp.Embeddings[0]) != 8 {
+		t.Fatalf("unexpected embeddings: %v", resp.Embeddings)
+	}
+	if resp.Embeddings[0][0] != resp.Embeddings[2][0] || resp.Embeddings[0][0] == resp.Embeddings[1][0] {
+		t.Error("expected equal inputs, and only those, to have equal embeddings")
+	}
+}
+
+func TestClient_ModelManagement(t *testing.T) {
+	client := New("llama3.2")
+	ctx := context.Background()
+
+	if err := client.Pull(ctx, &ollama.PullRequest{Model: "mistral"}, nil); err != nil {
+		t.Fatalf("pull failed: %v", err)
+	}
+	if err := client.Copy(ctx, &ollama.CopyRequest{Source: "mistral", Destination: "backup"}); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	if err := client.Delete(ctx, &ollama.DeleteRequest{Model: "llama3.2"}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := client.Show(ctx, &ollama.ShowRequest{Model: "llama3.2"}); !errors.Is(err, ollama.ErrModelNotFound) {
+		t.Errorf("expected deleted model to be missing, got %v", err)
+	}
+
+	models, _ := client.ListModels(ctx)
+	if len(models) != 2 || models[0].Name != "mistral" || models[1].Name != "backup" {
+		t.Errorf("unexpected models: %+v", models)
+	}
+}
+
+func TestClient_PingError(t *testing.T) {
+	client := New("llama3.2")
+	client.SetPingError(errors.New("connection refused"))
+
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("expected ping error")
+	}
+	if _, err := client.ListModels(context.Background()); err == nil {
+		t.Error("expected list error while unreachable")
+	}
+}
@@ -0,0 +1,31 @@
+package ollama
+
+import "context"
+
+// Generator is the part of the Ollama API used to serve requests. Client
+// implements it, as does the scripted fake in package fake for tests that
+// shouldn't need a running Ollama.
+type Generator interface {
+	Ping(ctx context.Context) error
+	ListModels(ctx context.Context) ([]Model, error)
+	ListRunning(ctx context.Context) ([]RunningModel, error)
+
+	Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error)
+	GenerateStream(ctx context.Context, req *GenerateRequest, fn func(*GenerateResponse) error) error
+	Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error)
+	ChatStream(ctx context.Context, req *ChatRequest, fn func(*ChatResponse) error) error
+	Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error)
+}
+
+// ModelManager is the part of the Ollama API that downloads and manages models
+type ModelManager interface {
+	Pull(ctx context.Context, req *PullRequest, fn func(*ProgressResponse) error) error
+	Delete(ctx context.Context, req *DeleteRequest) error
+	Copy(ctx context.Context, req *CopyRequest) error
+	Show(ctx context.Context, req *ShowRequest) (*ShowResponse, error)
+}
+
+var (
+	_ Generator    = (*Client)(nil)
+	_ ModelManager = (*Client)(nil)
+)
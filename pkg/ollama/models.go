@@ -0,0 +1,156 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PullRequest represents a request to download a model from a registry
+type PullRequest struct {
+	Model    string `json:"model"`
+	Insecure bool   `json:"insecure,omitempty"` // Allow registries without TLS
+	Stream   bool   `json:"stream"`
+}
+
+// ProgressResponse reports the progress of a pull. Total and Completed are
+// in bytes and only set while a layer is downloading.
+type ProgressResponse struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// pullSucceeded is the status of the final progress update of a pull
+const pullSucceeded = "success"
+
+// DeleteRequest represents a request to remove a model
+type DeleteRequest struct {
+	Model string `json:"model"`
+}
+
+// CopyRequest represents a request to copy a model under a new name
+type CopyRequest struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// ShowRequest represents a request for a model's details
+type ShowRequest struct {
+	Model string `json:"model"`
+}
+
+// ShowResponse describes a model
+type ShowResponse struct {
+	Modelfile    string       `json:"modelfile,omitempty"`
+	Parameters   string       `json:"parameters,omitempty"`
+	Template     string       `json:"template,omitempty"`
+	System       string       `json:"system,omitempty"`
+	License      string       `json:"license,omitempty"`
+	Details      ModelDetails `json:"details"`
+	Capabilities []string     `json:"capabilities,omitempty"`
+	ModifiedAt   time.Time    `json:"modified_at"`
+}
+
+// ModelDetails describes a model's architecture and quantization
+type ModelDetails struct {
+	ParentModel       string   `json:"parent_model,omitempty"`
+	Format            string   `json:"format,omitempty"`
+	Family            string   `json:"family,omitempty"`
+	Families          []string `json:"families,omitempty"`
+	ParameterSize     string   `json:"parameter_size,omitempty"`
+	QuantizationLevel string   `json:"quantization_level,omitempty"`
+}
+
+// RunningModel is a model currently loaded into memory
+type RunningModel struct {
+	Name      string       `json:"name"`
+	Model     string       `json:"model"`
+	Size      int64        `json:"size"`      // Total memory used, in bytes
+	SizeVRAM  int64        `json:"size_vram"` // GPU memory used, in bytes
+	Digest    string       `json:"digest"`
+	Details   ModelDetails `json:"details"`
+	ExpiresAt time.Time    `json:"expires_at"` // When the model will be unloaded if idle
+}
+
+// runningResponse is the response of /api/ps
+type runningResponse struct {
+	Models []RunningModel `json:"models"`
+}
+
+// Pull downloads a model, calling fn (if not nil) with each progress update.
+// Pulling a model that is already up to date only verifies it.
+func (c *Client) Pull(ctx context.Context, req *PullRequest, fn func(*ProgressResponse) error) error {
+	req.Stream = true
+
+	resp, err := c.send(ctx, 0, "POST", "/api/pull", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return readStream(resp.Body, func(line []byte) (bool, error) {
+		var progress ProgressResponse
+		if err := json.Unmarshal(line, &progress); err != nil {
+			return false, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if fn != nil {
+			if err := fn(&progress); err != nil {
+				return false, err
+			}
+		}
+		return progress.Status == pullSucceeded, nil
+	})
+}
+
+// Delete removes a model and any data not shared with other models
+func (c *Client) Delete(ctx context.Context, req *DeleteRequest) error {
+	resp, err := c.send(ctx, c.opts.AdminTimeout, "DELETE", "/api/delete", req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Copy creates a model under a new name from an existing one
+func (c *Client) Copy(ctx context.Context, req *CopyRequest) error {
+	resp, err := c.send(ctx, c.opts.AdminTimeout, "POST", "/api/copy", req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Show returns a model's details, Modelfile and template
+func (c *Client) Show(ctx context.Context, req *ShowRequest) (*ShowResponse, error) {
+	resp, err := c.send(ctx, c.opts.AdminTimeout, "POST", "/api/show", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result ShowResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ListRunning returns the models currently loaded into memory
+func (c *Client) ListRunning(ctx context.Context) ([]RunningModel, error) {
+	resp, err := c.send(ctx, c.opts.PingTimeout, "GET", "/api/ps", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result runningResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Models, nil
+}
@@ -0,0 +1,152 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_Pull_Progress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/pull" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		var req PullRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "llama3.2" || !req.Stream {
+			t.Errorf("unexpected request: %+v", req)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.Encode(ProgressResponse{Status: "pulling manifest"})
+		enc.Encode(ProgressResponse{Status: "pulling abc123", Digest: "sha256:abc123", Total: 100, Completed: 40})
+		enc.Encode(ProgressResponse{Status: "pulling abc123", Digest: "sha256:abc123", Total: 100, Completed: 100})
+		enc.Encode(ProgressResponse{Status: "success"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	var updates []ProgressResponse
+	err := client.Pull(context.Background(), &PullRequest{Model: "llama3.2"}, func(p *ProgressResponse) error {
+		updates = append(updates, *p)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(updates) != 4 || updates[1].Completed != 40 || updates[3].Status != "success" {
+		t.Errorf("unexpected progress updates: %+v", updates)
+	}
+}
+
+func TestClient_Pull_StreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{\"status\":\"pulling manifest\"}\n{\"error\":\"pull model manifest: file does not exist\"}\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	err := client.Pull(context.Background(), &PullRequest{Model: "nonexistent"}, nil)
+
+	if err == nil || !strings.Contains(err.Error(), "file does not exist") {
+		t.Errorf("expected the streamed error, got %v", err)
+	}
+}
+
+func TestClient_Delete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Path != "/api/delete" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		var req DeleteRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "mistral" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	if err := client.Delete(context.Background(), &DeleteRequest{Model: "mistral"}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := client.Delete(context.Background(), &DeleteRequest{Model: "missing"}); err == nil {
+		t.Error("expected error for missing model")
+	}
+}
+
+func TestClient_Copy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/copy" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		var req CopyRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Source != "llama3.2" || req.Destination != "llama3.2-backup" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	err := client.Copy(context.Background(), &CopyRequest{Source: "llama3.2", Destination: "llama3.2-backup"})
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestClient_Show(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/show" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		w.Write([]byte(`{"modelfile":"FROM llama3.2","parameters":"stop \"<|eot_id|>\"",` +
+			`"details":{"format":"gguf","family":"llama","parameter_size":"3.2B","quantization_level":"Q4_K_M"},` +
+			`"capabilities":["completion","tools"],"model_info":{"general.architecture":"llama"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	resp, err := client.Show(context.Background(), &ShowRequest{Model: "llama3.2"})
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.Details.Family != "llama" || resp.Details.QuantizationLevel != "Q4_K_M" || len(resp.Capabilities) != 2 {
+		t.Errorf("unexpected details: %+v", resp)
+	}
+}
+
+func TestClient_ListRunning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/api/ps" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		w.Write([]byte(`{"models":[{"name":"llama3.2:latest","model":"llama3.2:latest","size":3400000000,` +
+			`"size_vram":3100000000,"expires_at":"2026-10-15T12:05:00Z","details":{"family":"llama"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	models, err := client.ListRunning(context.Background())
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(models) != 1 || models[0].SizeVRAM != 3100000000 || models[0].ExpiresAt.IsZero() {
+		t.Errorf("unexpected running models: %+v", models)
+	}
+}
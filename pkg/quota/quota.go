@@ -0,0 +1,377 @@
+// Package quota tracks per-key request and token usage against fixed-window
+// limits, with soft warning thresholds ahead of hard enforcement. Usage is
+// kept in memory, or in a Store shared by several gateway replicas.
+package quota
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when a key has exhausted its quota
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Resource identifies what a quota limits
+type Resource string
+
+const (
+	ResourceRequests Resource = "requests"
+	ResourceTokens   Resource = "tokens"
+)
+
+// Limits holds the quota for a single window. Zero means unlimited.
+type Limits struct {
+	Requests int64 `json:"requests"`
+	Tokens   int64 `json:"tokens"`
+}
+
+// Status describes a key's usage within the current window
+type Status struct {
+	Limits   Limits
+	Requests int64
+	Tokens   int64
+	ResetAt  time.Time
+	Warnings []Warning // Thresholds currently crossed, highest first per resource
+}
+
+// Warning reports that usage of a resource has crossed a soft threshold
+type Warning struct {
+	Resource  Resource `json:"resource"`
+	Threshold float64  `json:"threshold"`
+	Used      int64    `json:"used"`
+	Limit     int64    `json:"limit"`
+}
+
+// Event is emitted the first time a key crosses a threshold within a window
+type Event struct {
+	Key     string    `json:"key"`
+	Warning Warning   `json:"warning"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// WindowUsage is a key's usage in its current window, as saved by Snapshot
+type WindowUsage struct {
+	Requests    int64     `json:"requests"`
+	Tokens      int64     `json:"tokens"`
+	WindowStart time.Time `json:"window_start"`
+}
+
+// storeTimeout bounds each call to a Store
+const storeTimeout = time.Second
+
+// Store keeps quota usage outside the process, so that managers sharing it
+// enforce each quota together. Implementations are safe for concurrent use.
+type Store interface {
+	// Add adds requests and tokens to key's usage in its current window,
+	// starting a window at now if none is open, and returns the usage after
+	Add(ctx context.Context, key string, requests, tokens int64, now time.Time, window time.Duration) (WindowUsage, error)
+	// Notify claims the warning about a threshold crossed in the window
+	// starting at windowStart, returning false if it was already claimed
+	Notify(ctx context.Context, key string, windowStart time.Time, window time.Duration, resource Resource, threshold float64) (bool, error)
+}
+
+// Config holds quota configuration
+type Config struct {
+	Window     time.Duration // Length of a quota window. Default: 24 hours
+	Defaults   Limits        // Limits applied to keys without an override
+	Thresholds []float64     // Soft warning thresholds as fractions. Default: 0.8, 0.95
+	OnWarning  func(Event)   // Called (in a new goroutine) when a threshold is first crossed
+
+	// Store keeps usage instead of memory. When it fails, requests are
+	// allowed and OnStoreError, if set, is called with the error.
+	Store        Store
+	OnStoreError func(error)
+}
+
+// Manager enforces quotas for many keys
+type Manager struct {
+	mu         sync.Mutex
+	window     time.Duration
+	defaults   Limits
+	overrides  map[string]Limits
+	usage      map[string]*usage
+	thresholds []float64
+	onWarning  func(Event)
+
+	store        Store
+	onStoreError func(error)
+}
+
+type usage struct {
+	requests    int64
+	tokens      int64
+	windowStart time.Time
+	notified    map[Resource]float64 // highest threshold already reported
+}
+
+// New creates a new quota manager
+func New(cfg Config) *Manager {
+	if cfg.Window <= 0 {
+		cfg.Window = 24 * time.Hour
+	}
+	if len(cfg.Thresholds) == 0 {
+		cfg.Thresholds = []float64{0.8, 0.95}
+	}
+	thresholds := append([]float64(nil), cfg.Thresholds...)
+	sort.Float64s(thresholds)
+
+	return &Manager{
+		window:     cfg.Window,
+		defaults:   cfg.Defaults,
+		overrides:  make(map[string]Limits),
+		usage:      make(map[string]*usage),
+		thresholds: thresholds,
+		onWarning:  cfg.OnWarning,
+
+		store:        cfg.Store,
+		onStoreError: cfg.OnStoreError,
+	}
+}
+
+// SetLimits overrides the default limits for a key
+func (m *Manager) SetLimits(key string, limits Limits) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.overrides[key] = limits
+}
+
+// ClearLimits removes a key's override so the defaults apply again
+func (m *Manager) ClearLimits(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.overrides, key)
+}
+
+// Override returns the limits set for a key with SetLimits, if any
+func (m *Manager) Override(key string) (Limits, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.overrides[key]
+	return l, ok
+}
+
+// Limits returns the effective limits for a key
+func (m *Manager) Limits(key string) Limits {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.limitsLocked(key)
+}
+
+// Check reports whether key may make another request. It returns
+// ErrQuotaExceeded once either limit has been reached.
+func (m *Manager) Check(key string) (Status, error) {
+	if m.store != nil {
+		status, ok := m.addShared(key, 0, 0)
+		if ok && status.exceeded() {
+			return status, ErrQuotaExceeded
+		}
+		return status, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := m.statusLocked(m.usageLocked(key), m.limitsLocked(key))
+	if status.exceeded() {
+		return status, ErrQuotaExceeded
+	}
+	return status, nil
+}
+
+// Record adds a completed request and its token usage to key's window
+func (m *Manager) Record(key string, tokens int64) Status {
+	if m.store != nil {
+		return m.recordShared(key, tokens)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u := m.usageLocked(key)
+	u.requests++
+	u.tokens += tokens
+
+	limits := m.limitsLocked(key)
+	status := m.statusLocked(u, limits)
+
+	// Notify once per threshold per window
+	for _, w := range status.Warnings {
+		if w.Threshold > u.notified[w.Resource] {
+			u.notified[w.Resource] = w.Threshold
+			if m.onWarning != nil {
+				go m.onWarning(Event{Key: key, Warning: w, ResetAt: status.ResetAt})
+			}
+		}
+	}
+
+	return status
+}
+
+// Usage returns the current status for a key without modifying it
+func (m *Manager) Usage(key string) Status {
+	if m.store != nil {
+		status, _ := m.addShared(key, 0, 0)
+		return status
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.statusLocked(m.usageLocked(key), m.limitsLocked(key))
+}
+
+// Snapshot returns the usage of every key whose window hasn't elapsed, so
+// it can be persisted and later passed to Restore. Usage kept in a Store
+// isn't included.
+func (m *Manager) Snapshot() map[string]WindowUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	snapshot := make(map[string]WindowUsage, len(m.usage))
+	for key, u := range m.usage {
+		if now.Sub(u.windowStart) < m.window {
+			snapshot[key] = WindowUsage{Requests: u.requests, Tokens: u.tokens, WindowStart: u.windowStart}
+		}
+	}
+	return snapshot
+}
+
+// Restore loads usage saved by Snapshot. Elapsed windows are skipped, and
+// thresholds already crossed are not reported again. Managers with a Store
+// ignore it.
+func (m *Manager) Restore(snapshot map[string]WindowUsage) {
+	if m.store != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for key, saved := range snapshot {
+		if now.Sub(saved.WindowStart) >= m.window {
+			continue
+		}
+		u := &usage{
+			requests:    saved.Requests,
+			tokens:      saved.Tokens,
+			windowStart: saved.WindowStart,
+			notified:    make(map[Resource]float64),
+		}
+		for _, w := range m.statusLocked(u, m.limitsLocked(key)).Warnings {
+			u.notified[w.Resource] = w.Threshold
+		}
+		m.usage[key] = u
+	}
+}
+
+// addShared adds to key's usage in the store and returns its status. When
+// the store fails it returns an empty status and false, so the request is
+// allowed.
+func (m *Manager) addShared(key string, requests, tokens int64) (Status, bool) {
+	limits := m.Limits(key)
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	u, err := m.store.Add(ctx, key, requests, tokens, time.Now(), m.window)
+	if err != nil {
+		m.storeFailed(err)
+		return Status{}, false
+	}
+	return m.statusLocked(&usage{requests: u.Requests, tokens: u.Tokens, windowStart: u.WindowStart}, limits), true
+}
+
+// recordShared records a request in the store. Each threshold is reported
+// by the one manager that claims it.
+func (m *Manager) recordShared(key string, tokens int64) Status {
+	status, ok := m.addShared(key, 1, tokens)
+	if !ok {
+		return status
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	windowStart := status.ResetAt.Add(-m.window)
+	for _, w := range status.Warnings {
+		claimed, err := m.store.Notify(ctx, key, windowStart, m.window, w.Resource, w.Threshold)
+		if err != nil {
+			m.storeFailed(err)
+			continue
+		}
+		if claimed && m.onWarning != nil {
+			go m.onWarning(Event{Key: key, Warning: w, ResetAt: status.ResetAt})
+		}
+	}
+	return status
+}
+
+func (m *Manager) storeFailed(err error) {
+	if m.onStoreError != nil {
+		m.onStoreError(err)
+	}
+}
+
+func (m *Manager) limitsLocked(key string) Limits {
+	if l, ok := m.overrides[key]; ok {
+		return l
+	}
+	return m.defaults
+}
+
+// usageLocked returns the usage for key, starting a new window if the
+// previous one has elapsed
+func (m *Manager) usageLocked(key string) *usage {
+	now := time.Now()
+	u, ok := m.usage[key]
+	if !ok || now.Sub(u.windowStart) >= m.window {
+		u = &usage{windowStart: now, notified: make(map[Resource]float64)}
+		m.usage[key] = u
+	}
+	return u
+}
+
+// exceeded reports whether either limit has been reached
+func (s Status) exceeded() bool {
+	return (s.Limits.Requests > 0 && s.Requests >= s.Limits.Requests) ||
+		(s.Limits.Tokens > 0 && s.Tokens >= s.Limits.Tokens)
+}
+
+func (m *Manager) statusLocked(u *usage, limits Limits) Status {
+	status := Status{
+		Limits:   limits,
+		Requests: u.requests,
+		Tokens:   u.tokens,
+		ResetAt:  u.windowStart.Add(m.window),
+	}
+
+	for _, r := range []struct {
+		resource Resource
+		used     int64
+		limit    int64
+	}{
+		{ResourceRequests, u.requests, limits.Requests},
+		{ResourceTokens, u.tokens, limits.Tokens},
+	} {
+		if r.limit <= 0 {
+			continue
+		}
+		fraction := float64(r.used) / float64(r.limit)
+		// Report only the highest crossed threshold for each resource
+		for i := len(m.thresholds) - 1; i >= 0; i-- {
+			if fraction >= m.thresholds[i] {
+				status.Warnings = append(status.Warnings, Warning{
+					Resource:  r.resource,
+					Threshold: m.thresholds[i],
+					Used:      r.used,
+					Limit:     r.limit,
+				})
+				break
+			}
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,270 @@
+package quota
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+	"github.com/hugovillarreal/neurogate/pkg/redis/redistest"
+)
+
+func TestManager_UnlimitedByDefault(t *testing.T) {
+	m := New(Config{})
+
+	for i := 0; i < 100; i++ {
+		m.Record("key", 1000)
+	}
+
+	if _, err := m.Check("key"); err != nil {
+		t.Errorf("expected no error without limits, got %v", err)
+	}
+}
+
+func TestManager_EnforcesRequestLimit(t *testing.T) {
+	m := New(Config{Defaults: Limits{Requests: 2}})
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.Check("key"); err != nil {
+			t.Fatalf("request %d: unexpected error %v", i, err)
+		}
+		m.Record("key", 0)
+	}
+
+	if _, err := m.Check("key"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+}
+
+func TestManager_EnforcesTokenLimit(t *testing.T) {
+	m := New(Config{Defaults: Limits{Tokens: 100}})
+
+	m.Record("key", 100)
+
+	if _, err := m.Check("key"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+}
+
+func TestManager_KeysAreIndependent(t *testing.T) {
+	m := New(Config{Defaults: Limits{Requests: 1}})
+
+	m.Record("a", 0)
+
+	if _, err := m.Check("b"); err != nil {
+		t.Errorf("expected key b to be unaffected, got %v", err)
+	}
+}
+
+func TestManager_OverridesDefaults(t *testing.T) {
+	m := New(Config{Defaults: Limits{Requests: 1}})
+	m.SetLimits("vip", Limits{Requests: 10})
+
+	m.Record("vip", 0)
+
+	if _, err := m.Check("vip"); err != nil {
+		t.Errorf("expected override to allow more requests, got %v", err)
+	}
+
+	if l, ok := m.Override("vip"); !ok || l.Requests != 10 {
+		t.Errorf("expected override of 10 requests, got %+v (set=%v)", l, ok)
+	}
+
+	m.ClearLimits("vip")
+	if _, ok := m.Override("vip"); ok {
+		t.Error("expected override to be cleared")
+	}
+	if _, err := m.Check("vip"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected defaults after clearing override, got %v", err)
+	}
+}
+
+func TestManager_WindowResets(t *testing.T) {
+	m := New(Config{Window: 50 * time.Millisecond, Defaults: Limits{Requests: 1}})
+
+	m.Record("key", 0)
+	if _, err := m.Check("key"); err == nil {
+		t.Fatal("expected quota to be exhausted")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, err := m.Check("key"); err != nil {
+		t.Errorf("expected quota to reset after window, got %v", err)
+	}
+}
+
+func TestManager_WarningsReportHighestThreshold(t *testing.T) {
+	m := New(Config{Defaults: Limits{Tokens: 100}})
+
+	status := m.Record("key", 50)
+	if len(status.Warnings) != 0 {
+		t.Errorf("expected no warnings at 50%%, got %v", status.Warnings)
+	}
+
+	status = m.Record("key", 30)
+	if len(status.Warnings) != 1 || status.Warnings[0].Threshold != 0.8 {
+		t.Errorf("expected 80%% warning, got %v", status.Warnings)
+	}
+
+	status = m.Record("key", 16)
+	if len(status.Warnings) != 1 || status.Warnings[0].Threshold != 0.95 {
+		t.Errorf("expected 95%% warning, got %v", status.Warnings)
+	}
+}
+
+func TestManager_OnWarningFiresOncePerThreshold(t *testing.T) {
+	events := make(chan Event, 10)
+	m := New(Config{
+		Defaults:  Limits{Requests: 10},
+		OnWarning: func(e Event) { events <- e },
+	})
+
+	for i := 0; i < 10; i++ {
+		m.Record("key", 0)
+	}
+
+	var got []float64
+	timeout := time.After(100 * time.Millisecond)
+	for len(got) < 2 {
+		select {
+		case e := <-events:
+			if e.Key != "key" || e.Warning.Resource != ResourceRequests {
+				t.Errorf("unexpected event %+v", e)
+			}
+			got = append(got, e.Warning.Threshold)
+		case <-timeout:
+			t.Fatalf("expected 2 events, got %v", got)
+		}
+	}
+
+	select {
+	case e := <-events:
+		t.Errorf("unexpected extra event %+v", e)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestManager_SnapshotRestore(t *testing.T) {
+	events := make(chan Event, 10)
+	m := New(Config{Defaults: Limits{Requests: 10}})
+	for i := 0; i < 9; i++ {
+		m.Record("key", 5)
+	}
+
+	snapshot := m.Snapshot()
+	if got := snapshot["key"]; got.Requests != 9 || got.Tokens != 45 {
+		t.Fatalf("unexpected snapshot %+v", got)
+	}
+
+	restored := New(Config{
+		Defaults:  Limits{Requests: 10},
+		OnWarning: func(e Event) { events <- e },
+	})
+	restored.Restore(snapshot)
+
+	status := restored.Usage("key")
+	if status.Requests != 9 || status.Tokens != 45 || !status.ResetAt.Equal(snapshot["key"].WindowStart.Add(24*time.Hour)) {
+		t.Errorf("expected restored usage and window, got %+v", status)
+	}
+
+	// The 80% threshold was already crossed before the snapshot
+	restored.Record("key", 0)
+	select {
+	case e := <-events:
+		if e.Warning.Threshold != 0.95 {
+			t.Errorf("expected only the 95%% warning, got %+v", e)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("expected a 95% warning")
+	}
+	if _, err := restored.Check("key"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected restored usage to count against the quota, got %v", err)
+	}
+}
+
+func TestManager_RestoreSkipsElapsedWindows(t *testing.T) {
+	m := New(Config{Window: time.Minute, Defaults: Limits{Requests: 1}})
+	m.Restore(map[string]WindowUsage{
+		"key": {Requests: 1, WindowStart: time.Now().Add(-2 * time.Minute)},
+	})
+
+	if _, err := m.Check("key"); err != nil {
+		t.Errorf("expected elapsed usage to be ignored, got %v", err)
+	}
+	if len(m.Snapshot()) != 1 {
+		t.Errorf("expected only the fresh window in the snapshot")
+	}
+}
+
+func TestManager_RedisSharedBetweenReplicas(t *testing.T) {
+	srv := redistest.NewServer(t)
+	events := make(chan Event, 10)
+	replica := func() *Manager {
+		return New(Config{
+			Window:     time.Hour,
+			Defaults:   Limits{Requests: 4, Tokens: 1000},
+			Thresholds: []float64{0.5},
+			Store:      NewRedis(redis.New(redis.Config{Addr: srv.Addr()})),
+			OnWarning:  func(e Event) { events <- e },
+		})
+	}
+	m1, m2 := replica(), replica()
+
+	m1.Record("key", 100)
+	m2.Record("key", 200)
+	m1.Record("key", 50)
+	status := m2.Usage("key")
+	if status.Requests != 3 || status.Tokens != 350 {
+		t.Errorf("expected usage from both replicas, got %+v", status)
+	}
+	if _, err := m2.Check("key"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	m2.Record("key", 0)
+	if _, err := m1.Check("key"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded on the other replica, got %v", err)
+	}
+	if status := m1.Usage("other"); status.Requests != 0 {
+		t.Errorf("expected other key to be unused, got %+v", status)
+	}
+
+	// The requests threshold was crossed on m2 and is reported once, though
+	// both replicas have seen it since
+	select {
+	case e := <-events:
+		if e.Warning.Resource != ResourceRequests || e.Warning.Threshold != 0.5 {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("expected a warning")
+	}
+	select {
+	case e := <-events:
+		t.Errorf("unexpected extra event %+v", e)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	srv.FastForward(time.Hour)
+	if _, err := m1.Check("key"); err != nil {
+		t.Errorf("expected the quota to reset with the window, got %v", err)
+	}
+}
+
+func TestManager_RedisFailsOpen(t *testing.T) {
+	var failures int
+	client := redis.New(redis.Config{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond})
+	m := New(Config{
+		Defaults:     Limits{Requests: 1},
+		Store:        NewRedis(client),
+		OnStoreError: func(error) { failures++ },
+	})
+
+	m.Record("key", 0)
+	if _, err := m.Check("key"); err != nil {
+		t.Errorf("expected requests to be allowed while the store is unreachable, got %v", err)
+	}
+	if failures != 2 {
+		t.Errorf("expected 2 reported failures, got %d", failures)
+	}
+}
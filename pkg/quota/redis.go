@@ -0,0 +1,92 @@
+package quota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+)
+
+// keyPrefix namespaces quota usage in a shared Redis
+const keyPrefix = "neurogate:quota:"
+
+// Redis is a Store keeping each key's window start and counters in Redis,
+// shared by every gateway replica using the same Redis. Keys are hashed,
+// so API keys aren't stored in the clear. Everything expires with the
+// window.
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis creates a store on client
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+// Add implements Store
+func (r *Redis) Add(ctx context.Context, key string, requests, tokens int64, now time.Time, window time.Duration) (WindowUsage, error) {
+	// The first replica to see a key after its window ends starts the next
+	windowKey := redisKey(key) + ":window"
+	replies, err := r.client.Tx(ctx,
+		[]interface{}{"SET", windowKey, now.UnixMilli(), "NX", "PX", window.Milliseconds()},
+		[]interface{}{"GET", windowKey},
+	)
+	if err != nil {
+		return WindowUsage{}, fmt.Errorf("start quota window: %w", err)
+	}
+	data, err := redis.Bytes(replies[1], nil)
+	if err != nil {
+		return WindowUsage{}, fmt.Errorf("read quota window: %w", err)
+	}
+	startMs, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return WindowUsage{}, fmt.Errorf("parse quota window: %w", err)
+	}
+	start := time.UnixMilli(startMs)
+
+	// Counters are per window, so a late write can't leak into the next one
+	counters := fmt.Sprintf("%s:%d:", redisKey(key), startMs)
+	ttl := max(start.Add(window).Sub(now).Milliseconds(), 1)
+	replies, err = r.client.Pipeline(ctx,
+		[]interface{}{"INCRBY", counters + "requests", requests},
+		[]interface{}{"PEXPIRE", counters + "requests", ttl},
+		[]interface{}{"INCRBY", counters + "tokens", tokens},
+		[]interface{}{"PEXPIRE", counters + "tokens", ttl},
+	)
+	if err != nil {
+		return WindowUsage{}, fmt.Errorf("record quota usage: %w", err)
+	}
+	usage := WindowUsage{WindowStart: start}
+	if usage.Requests, err = redis.Int(replies[0], nil); err != nil {
+		return WindowUsage{}, fmt.Errorf("record quota usage: %w", err)
+	}
+	if usage.Tokens, err = redis.Int(replies[2], nil); err != nil {
+		return WindowUsage{}, fmt.Errorf("record quota usage: %w", err)
+	}
+	return usage, nil
+}
+
+// Notify implements Store
+func (r *Redis) Notify(ctx context.Context, key string, windowStart time.Time, window time.Duration, resource Resource, threshold float64) (bool, error) {
+	claim := fmt.Sprintf("%s:%d:notified:%s:%g", redisKey(key), windowStart.UnixMilli(), resource, threshold)
+	ttl := max(time.Until(windowStart.Add(window)).Milliseconds(), 1)
+	_, err := r.client.Do(ctx, "SET", claim, 1, "NX", "PX", ttl)
+	if errors.Is(err, redis.ErrNil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("claim quota warning: %w", err)
+	}
+	return true, nil
+}
+
+// redisKey returns the prefix of a quota key's entries
+func redisKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return keyPrefix + hex.EncodeToString(sum[:16])
+}
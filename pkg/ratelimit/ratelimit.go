@@ -0,0 +1,167 @@
+// Package ratelimit enforces per-key request rates with token buckets, or
+// with counters in a Store shared by several gateway replicas
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit is a sustained rate with a burst allowance. A zero Rate is unlimited.
+type Limit struct {
+	Rate  float64 `json:"rate"`  // Requests per second
+	Burst int     `json:"burst"` // Requests allowed at once. Default: the rate, rounded up
+}
+
+// ParseLimit parses "rate" or "rate/burst", e.g. "5" or "0.5/10"
+func ParseLimit(s string) (Limit, error) {
+	rate, burst, hasBurst := strings.Cut(strings.TrimSpace(s), "/")
+	var l Limit
+	var err error
+	if l.Rate, err = strconv.ParseFloat(rate, 64); err != nil || l.Rate < 0 {
+		return Limit{}, fmt.Errorf("invalid rate %q", rate)
+	}
+	if hasBurst {
+		if l.Burst, err = strconv.Atoi(burst); err != nil || l.Burst < 0 {
+			return Limit{}, fmt.Errorf("invalid burst %q", burst)
+		}
+	}
+	return l, nil
+}
+
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+// storeTimeout bounds each call to a Store
+const storeTimeout = time.Second
+
+// Store keeps rate limit state outside the process, so that limiters
+// sharing it enforce each limit together. Implementations are safe for
+// concurrent use.
+type Store interface {
+	// Take counts a request against key's limit at now. When the limit is
+	// reached it returns false and how long until a request would be
+	// allowed; rejected requests aren't counted.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error)
+}
+
+// Limiter holds a token bucket per key, or counts requests in a Store
+type Limiter struct {
+	mu        sync.Mutex
+	defaults  Limit
+	overrides map[string]Limit
+	buckets   map[string]*bucket
+	now       func() time.Time
+
+	store   Store
+	onError func(error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New creates a limiter applying defaults to keys without an override
+func New(defaults Limit) *Limiter {
+	return &Limiter{
+		defaults:  defaults,
+		overrides: make(map[string]Limit),
+		buckets:   make(map[string]*bucket),
+		now:       time.Now,
+	}
+}
+
+// NewWithStore creates a limiter counting requests in store. When the
+// store fails, requests are allowed and onError, if set, is called with the
+// error.
+func NewWithStore(defaults Limit, store Store, onError func(error)) *Limiter {
+	l := New(defaults)
+	l.store = store
+	l.onError = onError
+	return l
+}
+
+// SetLimit overrides the default limit for a key
+func (l *Limiter) SetLimit(key string, limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overrides[key] = limit
+	delete(l.buckets, key)
+}
+
+// Limit returns the effective limit for a key
+func (l *Limiter) Limit(key string) Limit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limitLocked(key)
+}
+
+func (l *Limiter) limitLocked(key string) Limit {
+	if limit, ok := l.overrides[key]; ok {
+		return limit
+	}
+	return l.defaults
+}
+
+// Allow takes a token from key's bucket. When the bucket is empty it
+// returns false and how long until a token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l.store != nil {
+		return l.allowShared(key)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limitLocked(key)
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+
+	now := l.now()
+	capacity := limit.burst()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// allowShared counts a request in the store, failing open so that an
+// unreachable store doesn't reject every request
+func (l *Limiter) allowShared(key string) (bool, time.Duration) {
+	limit := l.Limit(key)
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	ok, wait, err := l.store.Take(ctx, key, limit, l.now())
+	if err != nil {
+		if l.onError != nil {
+			l.onError(err)
+		}
+		return true, 0
+	}
+	return ok, wait
+}
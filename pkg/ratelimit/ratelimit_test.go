@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+	"github.com/hugovillarreal/neurogate/pkg/redis/redistest"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Now()
+	l := New(Limit{Rate: 2, Burst: 3})
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("expected request %d within burst to be allowed", i+1)
+		}
+	}
+	ok, wait := l.Allow("a")
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("expected rejection with 500ms wait, got %v, %v", ok, wait)
+	}
+
+	// Keys have their own buckets
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("expected other key to be allowed")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("expected a token to refill after 500ms")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("expected bucket to be empty again")
+	}
+
+	// Refill is capped at the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		l.Allow("a")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("expected refill to be capped at the burst")
+	}
+}
+
+func TestLimiter_Overrides(t *testing.T) {
+	l := New(Limit{})
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("expected zero rate to be unlimited")
+	}
+
+	l.SetLimit("a", Limit{Rate: 0.5})
+	if got := l.Limit("a"); got.Rate != 0.5 {
+		t.Errorf("expected override, got %+v", got)
+	}
+	// The burst defaults to the rate rounded up, at least 1
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("expected first request to be allowed")
+	}
+	if ok, wait := l.Allow("a"); ok || wait <= time.Second {
+		t.Errorf("expected rejection with a wait near 2s, got %v, %v", ok, wait)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("expected key without override to use the unlimited default")
+	}
+}
+
+func TestLimiter_RedisSharedBetweenReplicas(t *testing.T) {
+	srv := redistest.NewServer(t)
+	// A 3s window, starting at now
+	now := time.Unix(1699999998, 0)
+	replica := func() *Limiter {
+		l := NewWithStore(Limit{Rate: 1, Burst: 3}, NewRedis(redis.New(redis.Config{Addr: srv.Addr()})), nil)
+		l.now = func() time.Time { return now }
+		return l
+	}
+	l1, l2 := replica(), replica()
+
+	for i, l := range []*Limiter{l1, l2, l1} {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("expected request %d within burst to be allowed", i+1)
+		}
+	}
+	// The next window starts at 3s, and the previous one's weight has
+	// decayed enough at 4s
+	if ok, wait := l2.Allow("a"); ok || wait != 4*time.Second {
+		t.Errorf("expected rejection with a 4s wait, got %v, %v", ok, wait)
+	}
+	if ok, _ := l2.Allow("b"); !ok {
+		t.Error("expected other key to be allowed")
+	}
+
+	now = now.Add(4 * time.Second)
+	if ok, _ := l1.Allow("a"); !ok {
+		t.Error("expected a request once the previous window decayed")
+	}
+	if ok, wait := l2.Allow("a"); ok || wait != time.Second {
+		t.Errorf("expected rejection with a 1s wait, got %v, %v", ok, wait)
+	}
+}
+
+func TestLimiter_RedisFailsOpen(t *testing.T) {
+	var failures int
+	client := redis.New(redis.Config{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond})
+	l := NewWithStore(Limit{Rate: 1, Burst: 1}, NewRedis(client), func(error) { failures++ })
+
+	for range 3 {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatal("expected requests to be allowed while the store is unreachable")
+		}
+	}
+	if failures != 3 {
+		t.Errorf("expected 3 reported failures, got %d", failures)
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{"5", Limit{Rate: 5}, false},
+		{"0.5/10", Limit{Rate: 0.5, Burst: 10}, false},
+		{" 2/3 ", Limit{Rate: 2, Burst: 3}, false},
+		{"fast", Limit{}, true},
+		{"-1", Limit{}, true},
+		{"1/x", Limit{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, %v", tt.in, got, err)
+		}
+	}
+}
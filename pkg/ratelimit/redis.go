@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+)
+
+// keyPrefix namespaces rate limit counters in a shared Redis
+const keyPrefix = "neurogate:ratelimit:"
+
+// Redis is a Store counting requests in Redis over a sliding window: the
+// count of the current fixed window plus that of the previous one,
+// weighted by how much of it the sliding window still covers. A limit
+// allows its burst of requests per window of burst/rate seconds, which
+// keeps the sustained rate of the token bucket it replaces. Replicas should
+// keep their clocks in sync, as windows follow the caller's clock.
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis creates a store on client
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+// Take implements Store
+func (r *Redis) Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	burst := limit.burst()
+	window := time.Duration(burst / limit.Rate * float64(time.Second))
+	index := now.UnixNano() / int64(window)
+	elapsed := float64(now.UnixNano()%int64(window)) / float64(window)
+
+	// The window length is part of the key, so a changed limit starts
+	// counting afresh
+	prefix := fmt.Sprintf("%s%s:%d:", keyPrefix, key, window.Milliseconds())
+	current := fmt.Sprintf("%s%d", prefix, index)
+	replies, err := r.client.Pipeline(ctx,
+		[]interface{}{"INCR", current},
+		[]interface{}{"PEXPIRE", current, (2 * window).Milliseconds()},
+		[]interface{}{"GET", fmt.Sprintf("%s%d", prefix, index-1)},
+	)
+	if err != nil {
+		return false, 0, fmt.Errorf("count request: %w", err)
+	}
+	count, err := redis.Int(replies[0], nil)
+	if err != nil {
+		return false, 0, fmt.Errorf("count request: %w", err)
+	}
+	previous, err := redis.Int(replies[2], nil)
+	if err != nil && !errors.Is(err, redis.ErrNil) {
+		return false, 0, fmt.Errorf("read previous window: %w", err)
+	}
+
+	if float64(previous)*(1-elapsed)+float64(count) <= burst {
+		return true, 0, nil
+	}
+	// A failed decrement only makes the limit stricter until the window ends
+	r.client.Do(ctx, "INCRBY", current, -1)
+	return false, retryAfter(float64(previous), float64(count-1), burst, elapsed, window), nil
+}
+
+// retryAfter estimates when a request rejected with count others in the
+// current window would be allowed: once the previous window's weight has
+// decayed enough, or if the current window alone is over the burst, during
+// the next window
+func retryAfter(previous, count, burst, elapsed float64, window time.Duration) time.Duration {
+	// In windows since the current one started
+	var at float64
+	if count+1 <= burst {
+		at = 1 - (burst-count-1)/previous
+	} else {
+		at = 1 + max(0, 1-(burst-1)/count)
+	}
+	return time.Duration((at - elapsed) * float64(window)).Round(time.Millisecond)
+}
@@ -0,0 +1,100 @@
+// Package redact applies a deployment's privacy policy to prompt and response
+// content before it is written to logs or other records
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Mode selects how content is treated
+type Mode string
+
+const (
+	ModeRedact   Mode = "redact"   // Replace content with a placeholder
+	ModeHash     Mode = "hash"     // Replace content with a digest, so equal content can be correlated
+	ModeTruncate Mode = "truncate" // Keep only the first MaxLength characters
+	ModeNone     Mode = "none"     // Keep content as is
+)
+
+// DefaultMaxLength is the number of characters ModeTruncate keeps by default
+const DefaultMaxLength = 64
+
+// ContentKeys are the log attribute keys that carry prompt or response
+// content and have the policy applied
+var ContentKeys = []string{"prompt", "system_prompt", "response"}
+
+// Policy describes how prompt and response content is recorded. The zero
+// value redacts everything.
+type Policy struct {
+	Mode      Mode
+	MaxLength int    // Characters kept by ModeTruncate; 0 uses DefaultMaxLength
+	HashKey   string // Keys the ModeHash digest (HMAC-SHA256) so short content can't be guessed; optional
+}
+
+// ParseMode parses a mode name. Empty selects ModeRedact.
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return ModeRedact, nil
+	case ModeRedact, ModeHash, ModeTruncate, ModeNone:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown content mode %q (use redact, hash, truncate or none)", s)
+	}
+}
+
+// Apply returns content as the policy allows it to be recorded
+func (p Policy) Apply(content string) string {
+	if content == "" {
+		return ""
+	}
+
+	switch p.Mode {
+	case ModeNone:
+		return content
+	case ModeHash:
+		return "sha256:" + p.digest(content)
+	case ModeTruncate:
+		limit := p.MaxLength
+		if limit <= 0 {
+			limit = DefaultMaxLength
+		}
+		n := utf8.RuneCountInString(content)
+		if n <= limit {
+			return content
+		}
+		cut := 0
+		for i := 0; i < limit; i++ {
+			_, size := utf8.DecodeRuneInString(content[cut:])
+			cut += size
+		}
+		return fmt.Sprintf("%s…[%d more chars]", content[:cut], n-limit)
+	default:
+		return fmt.Sprintf("[redacted %d chars]", utf8.RuneCountInString(content))
+	}
+}
+
+// IsContentKey reports whether a log attribute key carries content
+func IsContentKey(key string) bool {
+	for _, k := range ContentKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Policy) digest(content string) string {
+	if p.HashKey == "" {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, []byte(p.HashKey))
+	mac.Write([]byte(content))
+	return hex.EncodeToString(mac.Sum(nil))
+}
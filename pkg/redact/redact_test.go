@@ -0,0 +1,70 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicy_Apply(t *testing.T) {
+	content := "My SSN is 123-45-6789"
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   string
+	}{
+		{"zero value redacts", Policy{}, "[redacted 21 chars]"},
+		{"redact", Policy{Mode: ModeRedact}, "[redacted 21 chars]"},
+		{"none", Policy{Mode: ModeNone}, content},
+		{"truncate", Policy{Mode: ModeTruncate, MaxLength: 6}, "My SSN…[15 more chars]"},
+		{"truncate short content", Policy{Mode: ModeTruncate, MaxLength: 100}, content},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Apply(content); got != tt.want {
+				t.Errorf("Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_ApplyEmpty(t *testing.T) {
+	if got := (Policy{}).Apply(""); got != "" {
+		t.Errorf("expected empty content to stay empty, got %q", got)
+	}
+}
+
+func TestPolicy_TruncateKeepsRunesWhole(t *testing.T) {
+	got := Policy{Mode: ModeTruncate, MaxLength: 2}.Apply("héllo")
+	if got != "hé…[3 more chars]" {
+		t.Errorf("unexpected truncation %q", got)
+	}
+}
+
+func TestPolicy_Hash(t *testing.T) {
+	p := Policy{Mode: ModeHash}
+	a, b := p.Apply("hello"), p.Apply("hello")
+	if a != b || !strings.HasPrefix(a, "sha256:") || strings.Contains(a, "hello") {
+		t.Errorf("expected a stable digest, got %q and %q", a, b)
+	}
+	if p.Apply("hello!") == a {
+		t.Error("expected different content to hash differently")
+	}
+
+	keyed := Policy{Mode: ModeHash, HashKey: "secret"}.Apply("hello")
+	if keyed == a {
+		t.Error("expected the hash key to change the digest")
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]Mode{"": ModeRedact, "HASH": ModeHash, " truncate ": ModeTruncate, "none": ModeNone} {
+		got, err := ParseMode(in)
+		if err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseMode("scramble"); err == nil {
+		t.Error("expected unknown mode to be rejected")
+	}
+}
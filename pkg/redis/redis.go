@@ -0,0 +1,404 @@
+// Package redis is a minimal Redis client speaking RESP2, covering what the
+// gateway needs to share state between replicas: commands, pipelines and
+// transactions over a small connection pool
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNil is returned for a nil reply, such as GET of a missing key
+var ErrNil = errors.New("redis: nil reply")
+
+// Error is an error reply from the server
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Config configures a Client
+type Config struct {
+	Addr        string        // host:port. Default: localhost:6379
+	Password    string        // Sent with AUTH when set
+	DB          int           // Database selected on each connection
+	PoolSize    int           // Maximum idle connections kept. Default: 10
+	DialTimeout time.Duration // Default: 5 seconds
+}
+
+// ParseURL parses a URL of the form redis://[:password@]host[:port][/db]
+func ParseURL(s string) (Config, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return Config{}, fmt.Errorf("invalid redis URL %q: scheme must be redis", s)
+	}
+
+	cfg := Config{Addr: u.Host}
+	if u.Port() == "" && u.Host != "" {
+		cfg.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if password, ok := u.User.Password(); ok {
+		cfg.Password = password
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if cfg.DB, err = strconv.Atoi(db); err != nil {
+			return Config{}, fmt.Errorf("invalid redis URL %q: database must be a number", s)
+		}
+	}
+	return cfg, nil
+}
+
+// Client is a pooled Redis client, safe for concurrent use
+type Client struct {
+	cfg  Config
+	idle chan *conn
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// New creates a client. Connections are opened on first use.
+func New(cfg Config) *Client {
+	if cfg.Addr == "" {
+		cfg.Addr = "localhost:6379"
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 10
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+
+	return &Client{
+		cfg:    cfg,
+		idle:   make(chan *conn, cfg.PoolSize),
+		closed: make(chan struct{}),
+	}
+}
+
+// Do sends a command and returns its reply: a string for status replies,
+// int64 for integers, []byte for bulk strings and []interface{} for arrays.
+// Error replies are returned as Error and nil replies as ErrNil.
+func (c *Client) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	replies, err := c.Pipeline(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := replies[0].(error); ok {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// Pipeline sends several commands in one round trip and returns their
+// replies in order. Error and nil replies are returned in place as Error
+// and ErrNil values; the error result is only for connection failures.
+func (c *Client) Pipeline(ctx context.Context, cmds ...[]interface{}) ([]interface{}, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		cn.SetDeadline(deadline)
+	} else {
+		cn.SetDeadline(time.Time{})
+	}
+
+	for _, cmd := range cmds {
+		if err := writeCommand(cn.w, cmd); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	if err := cn.w.Flush(); err != nil {
+		cn.Close()
+		return nil, fmt.Errorf("redis: write failed: %w", err)
+	}
+
+	replies := make([]interface{}, len(cmds))
+	for i := range cmds {
+		reply, err := readReply(cn.r)
+		if err != nil && !isReplyError(err) {
+			cn.Close()
+			return nil, fmt.Errorf("redis: read failed: %w", err)
+		}
+		if err != nil {
+			replies[i] = err
+		} else {
+			replies[i] = reply
+		}
+	}
+
+	c.put(cn)
+	return replies, nil
+}
+
+// Tx runs commands in a MULTI/EXEC transaction and returns their replies
+func (c *Client) Tx(ctx context.Context, cmds ...[]interface{}) ([]interface{}, error) {
+	wrapped := make([][]interface{}, 0, len(cmds)+2)
+	wrapped = append(wrapped, []interface{}{"MULTI"})
+	wrapped = append(wrapped, cmds...)
+	wrapped = append(wrapped, []interface{}{"EXEC"})
+
+	replies, err := c.Pipeline(ctx, wrapped...)
+	if err != nil {
+		return nil, err
+	}
+	// A command rejected while queuing aborts the transaction
+	for _, reply := range replies[:len(replies)-1] {
+		if err, ok := reply.(Error); ok {
+			return nil, err
+		}
+	}
+
+	exec := replies[len(replies)-1]
+	if err, ok := exec.(error); ok {
+		return nil, err
+	}
+	results, ok := exec.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected EXEC reply %T", exec)
+	}
+	return results, nil
+}
+
+// Ping checks that the server is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Close closes idle connections; connections in use are closed when
+// returned
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		for {
+			select {
+			case cn := <-c.idle:
+				cn.Close()
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// get returns an idle connection or dials a new one
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case <-c.closed:
+		return nil, errors.New("redis: client closed")
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: c.cfg.DialTimeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: dial failed: %w", err)
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	var setup [][]interface{}
+	if c.cfg.Password != "" {
+		setup = append(setup, []interface{}{"AUTH", c.cfg.Password})
+	}
+	if c.cfg.DB != 0 {
+		setup = append(setup, []interface{}{"SELECT", c.cfg.DB})
+	}
+	for _, cmd := range setup {
+		if err := writeCommand(cn.w, cmd); err != nil {
+			cn.Close()
+			return nil, err
+		}
+		if err := cn.w.Flush(); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("redis: write failed: %w", err)
+		}
+		if _, err := readReply(cn.r); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("redis: %s failed: %w", cmd[0], err)
+		}
+	}
+	return cn, nil
+}
+
+// put returns a healthy connection to the pool, closing it if full
+func (c *Client) put(cn *conn) {
+	select {
+	case <-c.closed:
+		cn.Close()
+		return
+	default:
+	}
+
+	select {
+	case c.idle <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+// writeCommand encodes a command as a RESP array of bulk strings
+func writeCommand(w *bufio.Writer, args []interface{}) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		case int:
+			s = strconv.Itoa(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return fmt.Errorf("redis: unsupported argument type %T", arg)
+		}
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+	}
+	return nil
+}
+
+// readReply decodes one RESP reply. Error replies are returned as Error and
+// nil replies as ErrNil.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, Error(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("malformed array length %q", body)
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := readReply(r)
+			if err != nil && !isReplyError(err) {
+				return nil, err
+			}
+			if err != nil {
+				items[i] = err
+			} else {
+				items[i] = item
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unknown reply type %q", kind)
+}
+
+// isReplyError reports whether err is a reply rather than a connection
+// failure
+func isReplyError(err error) bool {
+	var replyErr Error
+	return errors.As(err, &replyErr) || errors.Is(err, ErrNil)
+}
+
+// Int converts a reply to an int64
+func Int(reply interface{}, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	switch v := reply.(type) {
+	case int64:
+		return v, nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case error:
+		return 0, v
+	}
+	return 0, fmt.Errorf("redis: unexpected reply %T for an integer", reply)
+}
+
+// Bytes converts a reply to a byte slice
+func Bytes(reply interface{}, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	switch v := reply.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	case error:
+		return nil, v
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %T for a string", reply)
+}
+
+// ByteSlices converts an array reply to a slice of byte slices
+func ByteSlices(reply interface{}, err error) ([][]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := reply.(error); ok {
+		return nil, e
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected reply %T for an array", reply)
+	}
+	result := make([][]byte, len(items))
+	for i, item := range items {
+		if result[i], err = Bytes(item, nil); err != nil && !errors.Is(err, ErrNil) {
+			return nil, err
+		}
+	}
+	return result, nil
+}
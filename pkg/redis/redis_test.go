@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/redis/redistest"
+)
+
+func newClient(t *testing.T) (*Client, *redistest.Server) {
+	server := redistest.NewServer(t)
+	client := New(Config{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client, server
+}
+
+func TestClient_Do(t *testing.T) {
+	client, _ := newClient(t)
+	ctx := context.Background()
+
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+	if _, err := client.Do(ctx, "SET", "greeting", "hello"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	value, err := Bytes(client.Do(ctx, "GET", "greeting"))
+	if err != nil || string(value) != "hello" {
+		t.Errorf("expected hello, got %q, %v", value, err)
+	}
+
+	if _, err := client.Do(ctx, "GET", "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("expected ErrNil for a missing key, got %v", err)
+	}
+
+	n, err := Int(client.Do(ctx, "INCRBY", "counter", 5))
+	if err != nil || n != 5 {
+		t.Errorf("expected 5, got %d, %v", n, err)
+	}
+}
+
+func TestClient_ErrorReply(t *testing.T) {
+	client, _ := newClient(t)
+	ctx := context.Background()
+
+	client.Do(ctx, "RPUSH", "list", "a")
+	_, err := client.Do(ctx, "GET", "list")
+
+	var replyErr Error
+	if !errors.As(err, &replyErr) {
+		t.Fatalf("expected an error reply, got %v", err)
+	}
+
+	// The connection stays usable after an error reply
+	if err := client.Ping(ctx); err != nil {
+		t.Errorf("expected ping to succeed, got %v", err)
+	}
+}
+
+func TestClient_Pipeline(t *testing.T) {
+	client, _ := newClient(t)
+
+	replies, err := client.Pipeline(context.Background(),
+		[]interface{}{"RPUSH", "list", "a", "b", "c"},
+		[]interface{}{"GET", "missing"},
+		[]interface{}{"LRANGE", "list", 0, -1},
+	)
+	if err != nil {
+		t.Fatalf("pipeline failed: %v", err)
+	}
+
+	if n, _ := Int(replies[0], nil); n != 3 {
+		t.Errorf("expected 3 items pushed, got %v", replies[0])
+	}
+	if replies[1] != ErrNil {
+		t.Errorf("expected ErrNil in place, got %v", replies[1])
+	}
+	items, err := ByteSlices(replies[2], nil)
+	if err != nil || len(items) != 3 || string(items[2]) != "c" {
+		t.Errorf("unexpected range: %q, %v", items, err)
+	}
+}
+
+func TestClient_Tx(t *testing.T) {
+	client, server := newClient(t)
+	ctx := context.Background()
+
+	replies, err := client.Tx(ctx,
+		[]interface{}{"RPUSH", "list", "a", "b", "c"},
+		[]interface{}{"LTRIM", "list", -2, -1},
+		[]interface{}{"PEXPIRE", "list", 1000},
+	)
+	if err != nil || len(replies) != 3 {
+		t.Fatalf("transaction failed: %v, %v", replies, err)
+	}
+
+	items, _ := ByteSlices(client.Do(ctx, "LRANGE", "list", 0, -1))
+	if len(items) != 2 || string(items[0]) != "b" {
+		t.Errorf("expected list trimmed to b, c, got %q", items)
+	}
+
+	server.FastForward(2 * time.Second)
+	if n, _ := Int(client.Do(ctx, "LLEN", "list")); n != 0 {
+		t.Errorf("expected list to expire, got %d items", n)
+	}
+}
+
+func TestClient_DialFailure(t *testing.T) {
+	client := New(Config{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond})
+	defer client.Close()
+
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("expected dial error")
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Config
+		err  bool
+	}{
+		{url: "redis://localhost", want: Config{Addr: "localhost:6379"}},
+		{url: "redis://:secret@cache:6380/2", want: Config{Addr: "cache:6380", Password: "secret", DB: 2}},
+		{url: "http://localhost", err: true},
+		{url: "redis://localhost/db", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseURL(tt.url)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: unexpected error %v", tt.url, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.url, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+// Package redistest provides an in-memory Redis server for tests, supporting
+// the commands used by the gateway's Redis backends
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Server is an in-memory Redis server listening on a local port
+type Server struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	data   map[string]*entry
+	offset time.Duration // Added to the clock by FastForward
+}
+
+type entry struct {
+	str     []byte
+	list    [][]byte
+	isList  bool
+	zset    map[string]float64 // Scores of a sorted set's members
+	expires time.Time          // Zero if the key doesn't expire
+}
+
+// NewServer starts a server that is stopped when the test ends
+func NewServer(t testing.TB) *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("redistest: listen failed: %v", err)
+	}
+	s := &Server{listener: l, data: make(map[string]*entry)}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+// Addr returns the server's host:port
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// FastForward advances the server's clock, expiring keys whose TTL elapses
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset += d
+}
+
+// Keys returns the names of the keys that haven't expired
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.data {
+		if s.lookup(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (s *Server) serve() {
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(c)
+	}
+}
+
+func (s *Server) handle(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+
+		switch {
+		case name == "MULTI":
+			inMulti, queued = true, nil
+			w.WriteString("+OK\r\n")
+		case name == "EXEC":
+			if !inMulti {
+				w.WriteString("-ERR EXEC without MULTI\r\n")
+				break
+			}
+			s.mu.Lock()
+			fmt.Fprintf(w, "*%d\r\n", len(queued))
+			for _, cmd := range queued {
+				w.WriteString(s.exec(cmd))
+			}
+			s.mu.Unlock()
+			inMulti = false
+		case inMulti:
+			queued = append(queued, args)
+			w.WriteString("+QUEUED\r\n")
+		default:
+			s.mu.Lock()
+			w.WriteString(s.exec(args))
+			s.mu.Unlock()
+		}
+
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// exec runs one command and returns its encoded reply; s.mu must be held
+func (s *Server) exec(args []string) string {
+	name, args := strings.ToUpper(args[0]), args[1:]
+	now := s.now()
+
+	switch name {
+	case "PING":
+		return "+PONG\r\n"
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+
+	case "GET":
+		e := s.lookup(args[0])
+		if e == nil {
+			return "$-1\r\n"
+		}
+		if e.isList || e.zset != nil {
+			return wrongType
+		}
+		return bulk(e.str)
+
+	case "SET":
+		e := &entry{str: []byte(args[1])}
+		for i := 2; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "EX", "PX":
+				n, _ := strconv.ParseInt(args[i+1], 10, 64)
+				unit := time.Second
+				if strings.ToUpper(args[i]) == "PX" {
+					unit = time.Millisecond
+				}
+				e.expires = now.Add(time.Duration(n) * unit)
+				i++
+			case "NX":
+				if s.lookup(args[0]) != nil {
+					return "$-1\r\n"
+				}
+			}
+		}
+		s.data[args[0]] = e
+		return "+OK\r\n"
+
+	case "DEL":
+		deleted := 0
+		for _, key := range args {
+			if s.lookup(key) != nil {
+				delete(s.data, key)
+				deleted++
+			}
+		}
+		return integer(int64(deleted))
+
+	case "INCR", "INCRBY":
+		by := int64(1)
+		if name == "INCRBY" {
+			by, _ = strconv.ParseInt(args[1], 10, 64)
+		}
+		e := s.lookup(args[0])
+		if e == nil {
+			e = &entry{}
+			s.data[args[0]] = e
+		}
+		n, _ := strconv.ParseInt(string(e.str), 10, 64)
+		n += by
+		e.str = []byte(strconv.FormatInt(n, 10))
+		return integer(n)
+
+	case "EXPIRE", "PEXPIRE":
+		e := s.lookup(args[0])
+		if e == nil {
+			return integer(0)
+		}
+		n, _ := strconv.ParseInt(args[1], 10, 64)
+		unit := time.Second
+		if name == "PEXPIRE" {
+			unit = time.Millisecond
+		}
+		e.expires = now.Add(time.Duration(n) * unit)
+		return integer(1)
+
+	case "PTTL":
+		e := s.lookup(args[0])
+		switch {
+		case e == nil:
+			return integer(-2)
+		case e.expires.IsZero():
+			return integer(-1)
+		}
+		return integer(e.expires.Sub(now).Milliseconds())
+
+	case "RPUSH":
+		e := s.lookup(args[0])
+		if e == nil {
+			e = &entry{isList: true}
+			s.data[args[0]] = e
+		}
+		if !e.isList {
+			return wrongType
+		}
+		for _, v := range args[1:] {
+			e.list = append(e.list, []byte(v))
+		}
+		return integer(int64(len(e.list)))
+
+	case "LLEN":
+		e := s.lookup(args[0])
+		if e == nil {
+			return integer(0)
+		}
+		return integer(int64(len(e.list)))
+
+	case "LRANGE":
+		e := s.lookup(args[0])
+		if e == nil {
+			return "*0\r\n"
+		}
+		start, stop := listRange(args[1], args[2], len(e.list))
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", max(stop-start, 0))
+		for _, v := range e.list[start:max(stop, start)] {
+			b.WriteString(bulk(v))
+		}
+		return b.String()
+
+	case "LTRIM":
+		e := s.lookup(args[0])
+		if e == nil {
+			return "+OK\r\n"
+		}
+		start, stop := listRange(args[1], args[2], len(e.list))
+		e.list = append([][]byte(nil), e.list[start:max(stop, start)]...)
+		return "+OK\r\n"
+
+	case "ZADD":
+		e := s.lookup(args[0])
+		if e == nil {
+			e = &entry{zset: make(map[string]float64)}
+			s.data[args[0]] = e
+		}
+		if e.zset == nil {
+			return wrongType
+		}
+		added := 0
+		for i := 1; i+1 < len(args); i += 2 {
+			score, err := strconv.ParseFloat(args[i], 64)
+			if err != nil {
+				return "-ERR value is not a valid float\r\n"
+			}
+			if _, ok := e.zset[args[i+1]]; !ok {
+				added++
+			}
+			e.zset[args[i+1]] = score
+		}
+		return integer(int64(added))
+
+	case "ZRANGE":
+		e := s.lookup(args[0])
+		if e == nil {
+			return "*0\r\n"
+		}
+		members := e.members()
+		start, stop := listRange(args[1], args[2], len(members))
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", max(stop-start, 0))
+		for _, m := range members[start:max(stop, start)] {
+			b.WriteString(bulk([]byte(m)))
+		}
+		return b.String()
+
+	case "ZREMRANGEBYRANK":
+		e := s.lookup(args[0])
+		if e == nil {
+			return integer(0)
+		}
+		members := e.members()
+		start, stop := listRange(args[1], args[2], len(members))
+		for _, m := range members[start:max(stop, start)] {
+			delete(e.zset, m)
+		}
+		return integer(int64(max(stop-start, 0)))
+	}
+
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", strings.ToLower(name))
+}
+
+const wrongType = "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// lookup returns a key's entry, deleting it if expired; s.mu must be held
+func (s *Server) lookup(key string) *entry {
+	e, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+	if !e.expires.IsZero() && !s.now().Before(e.expires) {
+		delete(s.data, key)
+		return nil
+	}
+	return e
+}
+
+// members returns a sorted set's members by score, then lexically
+func (e *entry) members() []string {
+	members := make([]string, 0, len(e.zset))
+	for m := range e.zset {
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if e.zset[members[i]] != e.zset[members[j]] {
+			return e.zset[members[i]] < e.zset[members[j]]
+		}
+		return members[i] < members[j]
+	})
+	return members
+}
+
+// listRange converts inclusive, possibly negative LRANGE indexes to a slice
+// range
+func listRange(startArg, stopArg string, n int) (int, int) {
+	start, _ := strconv.Atoi(startArg)
+	stop, _ := strconv.Atoi(stopArg)
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	start = min(max(start, 0), n)
+	stop = min(stop+1, n)
+	return start, stop
+}
+
+func bulk(b []byte) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(b), b)
+}
+
+func integer(n int64) string {
+	return fmt.Sprintf(":%d\r\n", n)
+}
+
+// readCommand reads a command sent as a RESP array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("expected array, got %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid array length %q", line)
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length %q", line)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
@@ -0,0 +1,45 @@
+// Package rendezvous implements rendezvous (highest random weight) hashing,
+// a consistent hashing scheme that maps keys to nodes so that adding or
+// removing a node only moves the keys that node owns
+package rendezvous
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// Score is the weight of node for key. A key belongs to the node with the
+// highest score.
+func Score(key, node string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(node))
+	return mix(h.Sum64())
+}
+
+// Rank returns the indexes of nodes in order of preference for key, so
+// callers can fall back to the next node when the owner is unavailable
+func Rank(key string, nodes []string) []int {
+	scores := make([]uint64, len(nodes))
+	order := make([]int, len(nodes))
+	for i, node := range nodes {
+		scores[i] = Score(key, node)
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+	return order
+}
+
+// mix is the splitmix64 finalizer, spreading FNV's output so scores for
+// similar node names are independent
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
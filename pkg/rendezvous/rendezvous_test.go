@@ -0,0 +1,66 @@
+package rendezvous
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRank_Stable(t *testing.T) {
+	nodes := []string{"worker-a", "worker-b", "worker-c"}
+
+	first := Rank("conversation-1", nodes)
+	if len(first) != len(nodes) {
+		t.Fatalf("expected %d nodes, got %v", len(nodes), first)
+	}
+	for i := 0; i < 10; i++ {
+		if got := Rank("conversation-1", nodes); fmt.Sprint(got) != fmt.Sprint(first) {
+			t.Fatalf("expected stable ranking %v, got %v", first, got)
+		}
+	}
+
+	// Node order doesn't matter
+	reversed := []string{"worker-c", "worker-b", "worker-a"}
+	if owner := reversed[Rank("conversation-1", reversed)[0]]; owner != nodes[first[0]] {
+		t.Errorf("expected owner %s regardless of order, got %s", nodes[first[0]], owner)
+	}
+}
+
+func TestRank_Distribution(t *testing.T) {
+	nodes := []string{"worker-a", "worker-b", "worker-c", "worker-d"}
+	counts := make(map[int]int)
+	for i := 0; i < 4000; i++ {
+		counts[Rank(fmt.Sprintf("conversation-%d", i), nodes)[0]]++
+	}
+	for i := range nodes {
+		if counts[i] < 800 || counts[i] > 1200 {
+			t.Errorf("expected roughly even distribution, got %v", counts)
+			break
+		}
+	}
+}
+
+func TestRank_MinimalDisruption(t *testing.T) {
+	nodes := []string{"worker-a", "worker-b", "worker-c", "worker-d"}
+	removed := []string{"worker-a", "worker-b", "worker-d"}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("conversation-%d", i)
+		before := nodes[Rank(key, nodes)[0]]
+		after := removed[Rank(key, removed)[0]]
+
+		// Only keys owned by the removed node move, and they move to their
+		// second choice
+		if before != "worker-c" && after != before {
+			t.Fatalf("key %s moved from %s to %s", key, before, after)
+		}
+		if before == "worker-c" && after != nodes[Rank(key, nodes)[1]] {
+			t.Fatalf("key %s did not move to its second choice", key)
+		}
+	}
+}
+
+func TestRank_Empty(t *testing.T) {
+	if got := Rank("key", nil); len(got) != 0 {
+		t.Errorf("expected no nodes, got %v", got)
+	}
+}
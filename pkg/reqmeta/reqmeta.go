@@ -0,0 +1,100 @@
+// Package reqmeta carries the attributes of a gateway request to workers in
+// gRPC metadata, alongside the trace context propagated by package tracing,
+// so worker logs and metrics can be matched to the request that caused them
+package reqmeta
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys
+const (
+	RequestIDKey = "x-request-id"
+	TenantKey    = "x-neurogate-tenant"
+	KeyIDKey     = "x-neurogate-key-id"
+)
+
+// Meta is what the gateway knows about a request's caller
+type Meta struct {
+	RequestID string
+	Tenant    string // Empty if the caller has none
+	KeyID     string // Hashed API key; empty for JWT and anonymous callers
+}
+
+// Consumer returns the label usage is recorded under: the tenant, else the
+// key ID, else "anonymous"
+func (m Meta) Consumer() string {
+	switch {
+	case m.Tenant != "":
+		return m.Tenant
+	case m.KeyID != "":
+		return m.KeyID
+	}
+	return "anonymous"
+}
+
+// NewOutgoingContext returns ctx with m added to the metadata of calls made
+// with it. Empty fields are left out.
+func NewOutgoingContext(ctx context.Context, m Meta) context.Context {
+	var kv []string
+	for _, pair := range [][2]string{{RequestIDKey, m.RequestID}, {TenantKey, m.Tenant}, {KeyIDKey, m.KeyID}} {
+		if pair[1] != "" {
+			kv = append(kv, pair[0], pair[1])
+		}
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying m, which calls made with it through the
+// client interceptors send as metadata
+func NewContext(ctx context.Context, m Meta) context.Context {
+	return context.WithValue(ctx, contextKey{}, m)
+}
+
+// FromContext returns the Meta carried by ctx, if any
+func FromContext(ctx context.Context) (Meta, bool) {
+	m, ok := ctx.Value(contextKey{}).(Meta)
+	return m, ok
+}
+
+// UnaryClientInterceptor sends the Meta carried by a call's context as
+// metadata
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if m, ok := FromContext(ctx); ok {
+			ctx = NewOutgoingContext(ctx, m)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor sends the Meta carried by a stream's context as
+// metadata
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if m, ok := FromContext(ctx); ok {
+			ctx = NewOutgoingContext(ctx, m)
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// FromIncomingContext returns the Meta sent with a call
+func FromIncomingContext(ctx context.Context) Meta {
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	return Meta{RequestID: get(RequestIDKey), Tenant: get(TenantKey), KeyID: get(KeyIDKey)}
+}
@@ -0,0 +1,83 @@
+package reqmeta
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// incoming turns the metadata of an outgoing context into a server's
+// incoming context
+func incoming(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestRoundTrip(t *testing.T) {
+	want := Meta{RequestID: "req-1", Tenant: "search", KeyID: "key-1234abcd"}
+	ctx := NewOutgoingContext(context.Background(), want)
+
+	if got := FromIncomingContext(incoming(ctx)); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestNewOutgoingContext_SkipsEmpty(t *testing.T) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", "00-abc")
+	ctx = NewOutgoingContext(ctx, Meta{RequestID: "req-1"})
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if len(md.Get(TenantKey)) != 0 || len(md.Get(KeyIDKey)) != 0 {
+		t.Errorf("expected empty fields to be left out, got %v", md)
+	}
+	if len(md.Get("traceparent")) != 1 {
+		t.Errorf("expected existing metadata to be kept, got %v", md)
+	}
+
+	if got := FromIncomingContext(context.Background()); got != (Meta{}) {
+		t.Errorf("expected empty meta without metadata, got %+v", got)
+	}
+}
+
+func TestMeta_Consumer(t *testing.T) {
+	tests := []struct {
+		meta Meta
+		want string
+	}{
+		{Meta{Tenant: "search", KeyID: "key-1"}, "search"},
+		{Meta{KeyID: "key-1"}, "key-1"},
+		{Meta{}, "anonymous"},
+	}
+	for _, tt := range tests {
+		if got := tt.meta.Consumer(); got != tt.want {
+			t.Errorf("%+v: expected %q, got %q", tt.meta, tt.want, got)
+		}
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	want := Meta{RequestID: "req-1", Tenant: "search"}
+	interceptor := UnaryClientInterceptor()
+
+	var got Meta
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		got = FromIncomingContext(incoming(ctx))
+		return nil
+	}
+	if err := interceptor(NewContext(context.Background(), want), "/llm.v1.LLMService/Embed", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("expected %+v sent, got %+v", want, got)
+	}
+
+	// Calls without a Meta send none
+	if err := interceptor(context.Background(), "/llm.v1.LLMService/Embed", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if got != (Meta{}) {
+		t.Errorf("expected no metadata, got %+v", got)
+	}
+}
@@ -0,0 +1,93 @@
+// Package requestid assigns the IDs that identify requests across the
+// gateway, its workers and the proxies in front of it
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// Header is the HTTP header request IDs are read from and returned in
+const Header = "X-Request-ID"
+
+// MaxLength is the longest client-supplied ID accepted
+const MaxLength = 128
+
+// New returns a UUIDv7: a millisecond Unix timestamp followed by random
+// bits, so IDs are unique across gateways and sort by creation time
+func New() string {
+	var b [16]byte
+	rand.Read(b[6:])
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(b[:6], ts[2:])
+	b[6] = 0x70 | b[6]&0x0f // Version 7
+	b[8] = 0x80 | b[8]&0x3f // RFC 9562 variant
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+// Valid reports whether a client-supplied ID can be used as is: 1 to
+// MaxLength letters, digits and "-_.:", so it is safe in logs, headers and
+// gRPC metadata
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying the request ID id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, or a new one if it
+// has none
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(contextKey{}).(string); ok {
+		return id
+	}
+	return New()
+}
+
+// Handler assigns each request an ID: the client's X-Request-ID if it is
+// valid, else a new one. The ID is set on the response before next runs, so
+// every response carries it, errors included, and is available to next
+// through FromContext.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !Valid(id) {
+			id = New()
+		}
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
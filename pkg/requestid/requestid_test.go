@@ -0,0 +1,91 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var uuidV7 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNew(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 1000 {
+		id := New()
+		if !uuidV7.MatchString(id) {
+			t.Fatalf("New() = %q, not a UUIDv7", id)
+		}
+		if seen[id] {
+			t.Fatalf("New() returned %q twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestNew_SortsByTime(t *testing.T) {
+	first := New()
+	time.Sleep(2 * time.Millisecond)
+	if second := New(); second <= first {
+		t.Errorf("later ID %q sorts before %q", second, first)
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"0190b5d2-7c1e-7a3b-8f00-1234567890ab", true},
+		{"req_42.edge:1", true},
+		{"", false},
+		{strings.Repeat("a", MaxLength), true},
+		{strings.Repeat("a", MaxLength+1), false},
+		{"has space", false},
+		{"new\nline", false},
+		{"ünïcode", false},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.id); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var got string
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+
+	t.Run("honors client ID", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/prompt", nil)
+		req.Header.Set(Header, "client-123")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got != "client-123" {
+			t.Errorf("context ID = %q, want client-123", got)
+		}
+		if h := rec.Header().Get(Header); h != "client-123" {
+			t.Errorf("response header = %q, want client-123", h)
+		}
+	})
+
+	t.Run("replaces invalid ID", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/prompt", nil)
+		req.Header.Set(Header, "bad id")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if !uuidV7.MatchString(got) {
+			t.Errorf("context ID = %q, want a new UUIDv7", got)
+		}
+		if h := rec.Header().Get(Header); h != got {
+			t.Errorf("response header = %q, want %q", h, got)
+		}
+	})
+}
@@ -0,0 +1,264 @@
+// Package scheduler admits requests up to a capacity, queuing the rest by
+// priority class and, within a class, fairly between flows such as API keys
+// or tenants, so that one flow's backlog can't starve the others.
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrQueueFull is returned when a request would wait but MaxQueued requests
+// already are
+var ErrQueueFull = errors.New("scheduler queue is full")
+
+// Unlimited is a capacity admitting every request
+const Unlimited = -1
+
+// Priority is a request's class. Waiting requests of a higher class are
+// always admitted before any of a lower one.
+type Priority int
+
+const (
+	Interactive Priority = iota // Requests a user is waiting on
+	Batch                       // Bulk work that can wait behind interactive traffic
+
+	numPriorities = iota
+)
+
+var priorityNames = [numPriorities]string{"interactive", "batch"}
+
+// Priorities lists the classes from highest to lowest
+func Priorities() []Priority {
+	return []Priority{Interactive, Batch}
+}
+
+func (p Priority) String() string {
+	if p < 0 || p >= numPriorities {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+// ParsePriority parses a class name, "interactive" or "batch"
+func ParsePriority(s string) (Priority, error) {
+	for p, name := range priorityNames {
+		if s == name {
+			return Priority(p), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority %q", s)
+}
+
+// Ticket describes a request to admit
+type Ticket struct {
+	Priority Priority
+	Flow     string  // Requests of one flow share its place in the queue
+	Weight   float64 // The flow's share relative to other flows. Default: 1
+}
+
+// Config holds scheduler configuration
+type Config struct {
+	Capacity  func() int // Requests admitted at once; 0 queues every request, and Unlimited or a nil func admits them all
+	MaxQueued int        // Requests waiting at once; 0 is unlimited
+}
+
+// Scheduler admits requests while fewer than Capacity are running. Within a
+// class, waiting requests are ordered by start-time fair queuing: each
+// request of a flow starts 1/weight of virtual time after the flow's
+// previous one, so backlogged flows are admitted in proportion to their
+// weights however many requests each has queued.
+type Scheduler struct {
+	mu        sync.Mutex
+	capacity  func() int
+	maxQueued int
+	running   int
+	queued    int
+	seq       uint64
+	classes   [numPriorities]class
+}
+
+// class holds the waiting requests of one priority
+type class struct {
+	waiting waitQueue
+	virtual float64            // Start tag of the last admitted request
+	finish  map[string]float64 // Finish tag of each flow's last queued request
+}
+
+type waiter struct {
+	start float64
+	seq   uint64 // Breaks ties in arrival order
+	ready chan struct{}
+	index int // In the wait queue; -1 once admitted
+}
+
+// New creates a scheduler
+func New(cfg Config) *Scheduler {
+	s := &Scheduler{capacity: cfg.Capacity, maxQueued: cfg.MaxQueued}
+	for i := range s.classes {
+		s.classes[i].finish = make(map[string]float64)
+	}
+	return s
+}
+
+// Acquire waits until the request may run and returns the function to call
+// when it has finished. It returns ErrQueueFull if the request would wait
+// and the queue is full, or ctx's error if ctx ends first.
+func (s *Scheduler) Acquire(ctx context.Context, t Ticket) (release func(), err error) {
+	if t.Priority < 0 || t.Priority >= numPriorities {
+		return nil, fmt.Errorf("unknown priority %d", int(t.Priority))
+	}
+
+	s.mu.Lock()
+	if s.queued == 0 && s.freeLocked() {
+		s.running++
+		s.mu.Unlock()
+		return s.releaser(), nil
+	}
+	if s.maxQueued > 0 && s.queued >= s.maxQueued {
+		s.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	w := s.enqueueLocked(t)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return s.releaser(), nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w.index < 0 {
+		// Admitted while ctx ended, so give the slot to the next request
+		s.running--
+	} else {
+		heap.Remove(&s.classes[t.Priority].waiting, w.index)
+		s.queued--
+	}
+	s.dispatchLocked()
+	return nil, ctx.Err()
+}
+
+// Refresh admits waiting requests after Capacity has grown
+func (s *Scheduler) Refresh() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dispatchLocked()
+}
+
+// Running returns the number of admitted requests that haven't finished
+func (s *Scheduler) Running() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// Queued returns the number of requests of a class waiting to be admitted
+func (s *Scheduler) Queued(p Priority) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p < 0 || p >= numPriorities {
+		return 0
+	}
+	return s.classes[p].waiting.Len()
+}
+
+// releaser returns a function freeing a request's slot once
+func (s *Scheduler) releaser() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.running--
+			s.dispatchLocked()
+		})
+	}
+}
+
+func (s *Scheduler) freeLocked() bool {
+	if s.capacity == nil {
+		return true
+	}
+	c := s.capacity()
+	return c < 0 || s.running < c
+}
+
+// enqueueLocked queues a request, tagging it with its flow's next start
+func (s *Scheduler) enqueueLocked(t Ticket) *waiter {
+	weight := t.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+	c := &s.classes[t.Priority]
+	start := max(c.virtual, c.finish[t.Flow])
+	c.finish[t.Flow] = start + 1/weight
+
+	s.seq++
+	w := &waiter{start: start, seq: s.seq, ready: make(chan struct{})}
+	heap.Push(&c.waiting, w)
+	s.queued++
+	return w
+}
+
+// dispatchLocked admits waiting requests, highest class first, while there
+// is capacity
+func (s *Scheduler) dispatchLocked() {
+	for s.queued > 0 && s.freeLocked() {
+		for i := range s.classes {
+			c := &s.classes[i]
+			if c.waiting.Len() == 0 {
+				continue
+			}
+			w := heap.Pop(&c.waiting).(*waiter)
+			c.virtual = w.start
+			if c.waiting.Len() == 0 {
+				// With no backlog, past shares no longer matter
+				c.virtual = 0
+				clear(c.finish)
+			}
+			s.queued--
+			s.running++
+			close(w.ready)
+			break
+		}
+	}
+}
+
+// waitQueue is a min-heap of waiters by start tag, then arrival
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].start != q[j].start {
+		return q[i].start < q[j].start
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() interface{} {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
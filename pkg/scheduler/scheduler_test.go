@@ -0,0 +1,225 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fixed returns a capacity of n
+func fixed(n int) func() int {
+	return func() int { return n }
+}
+
+// acquireAsync starts acquiring t, sending the ticket's flow on admitted
+// once it is admitted
+func acquireAsync(t *testing.T, s *Scheduler, ticket Ticket, admitted chan<- string) {
+	t.Helper()
+	go func() {
+		release, err := s.Acquire(context.Background(), ticket)
+		if err != nil {
+			t.Errorf("acquire failed: %v", err)
+			return
+		}
+		admitted <- ticket.Flow
+		release()
+	}()
+}
+
+// waitQueued waits until n requests are queued
+func waitQueued(t *testing.T, s *Scheduler, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for s.Queued(Interactive)+s.Queued(Batch) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued requests", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	for _, p := range Priorities() {
+		if got, err := ParsePriority(p.String()); err != nil || got != p {
+			t.Errorf("%s: expected round trip, got %v, %v", p, got, err)
+		}
+	}
+	if _, err := ParsePriority("urgent"); err == nil {
+		t.Error("expected an error for an unknown priority")
+	}
+}
+
+func TestAcquire_AdmitsUpToCapacity(t *testing.T) {
+	s := New(Config{Capacity: fixed(2)})
+
+	first, err := s.Acquire(context.Background(), Ticket{Flow: "a"})
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	if _, err := s.Acquire(context.Background(), Ticket{Flow: "a"}); err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(ctx, Ticket{Flow: "a"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the third request to wait until its deadline, got %v", err)
+	}
+	if s.Queued(Interactive) != 0 {
+		t.Errorf("expected the expired request to leave the queue, %d queued", s.Queued(Interactive))
+	}
+
+	first()
+	first() // Releasing twice frees one slot
+	if s.Running() != 1 {
+		t.Errorf("expected 1 running, got %d", s.Running())
+	}
+}
+
+func TestAcquire_UnlimitedCapacity(t *testing.T) {
+	s := New(Config{Capacity: fixed(Unlimited)})
+	for i := 0; i < 100; i++ {
+		if _, err := s.Acquire(context.Background(), Ticket{Flow: "a"}); err != nil {
+			t.Fatalf("acquire failed: %v", err)
+		}
+	}
+}
+
+func TestAcquire_ZeroCapacityQueues(t *testing.T) {
+	capacity := 0
+	s := New(Config{Capacity: func() int { return capacity }, MaxQueued: 1})
+
+	admitted := make(chan string, 1)
+	acquireAsync(t, s, Ticket{Flow: "a"}, admitted)
+	waitQueued(t, s, 1)
+	if _, err := s.Acquire(context.Background(), Ticket{Flow: "b"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull without capacity, got %v", err)
+	}
+
+	s.mu.Lock()
+	capacity = 1
+	s.mu.Unlock()
+	s.Refresh()
+	select {
+	case <-admitted:
+	case <-time.After(time.Second):
+		t.Fatal("expected the waiting request to be admitted once capacity returns")
+	}
+}
+
+func TestAcquire_QueueFull(t *testing.T) {
+	s := New(Config{Capacity: fixed(1), MaxQueued: 1})
+	release, _ := s.Acquire(context.Background(), Ticket{Flow: "a"})
+
+	admitted := make(chan string, 1)
+	acquireAsync(t, s, Ticket{Flow: "b"}, admitted)
+	waitQueued(t, s, 1)
+
+	if _, err := s.Acquire(context.Background(), Ticket{Flow: "c"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	release()
+	if flow := <-admitted; flow != "b" {
+		t.Errorf("expected b to be admitted, got %s", flow)
+	}
+}
+
+func TestAcquire_InteractiveBeforeBatch(t *testing.T) {
+	s := New(Config{Capacity: fixed(1)})
+	release, _ := s.Acquire(context.Background(), Ticket{Flow: "busy"})
+
+	admitted := make(chan string, 10)
+	for i := 0; i < 5; i++ {
+		acquireAsync(t, s, Ticket{Priority: Batch, Flow: "batch"}, admitted)
+	}
+	waitQueued(t, s, 5)
+	acquireAsync(t, s, Ticket{Priority: Interactive, Flow: "chat"}, admitted)
+	waitQueued(t, s, 6)
+
+	release()
+	if flow := <-admitted; flow != "chat" {
+		t.Errorf("expected the interactive request to be admitted first, got %s", flow)
+	}
+	for i := 0; i < 5; i++ {
+		if flow := <-admitted; flow != "batch" {
+			t.Errorf("expected batch, got %s", flow)
+		}
+	}
+}
+
+func TestAcquire_FairBetweenFlows(t *testing.T) {
+	s := New(Config{Capacity: fixed(1)})
+	release, _ := s.Acquire(context.Background(), Ticket{Flow: "busy"})
+
+	// A backlog from one flow queued ahead of another's requests
+	admitted := make(chan string, 20)
+	for i := 0; i < 8; i++ {
+		acquireAsync(t, s, Ticket{Flow: "bulk"}, admitted)
+		waitQueued(t, s, i+1)
+	}
+	for i := 0; i < 4; i++ {
+		acquireAsync(t, s, Ticket{Flow: "chat"}, admitted)
+		waitQueued(t, s, 9+i)
+	}
+
+	release()
+	var order []string
+	for i := 0; i < 12; i++ {
+		order = append(order, <-admitted)
+	}
+	// The flows alternate until chat's requests run out
+	chat := 0
+	for _, flow := range order[:8] {
+		if flow == "chat" {
+			chat++
+		}
+	}
+	if chat != 4 {
+		t.Errorf("expected chat's 4 requests among the first 8 admitted, got order %v", order)
+	}
+}
+
+func TestAcquire_Weights(t *testing.T) {
+	s := New(Config{Capacity: fixed(1)})
+	release, _ := s.Acquire(context.Background(), Ticket{Flow: "busy"})
+
+	admitted := make(chan string, 20)
+	for i := 0; i < 6; i++ {
+		acquireAsync(t, s, Ticket{Flow: "light", Weight: 1}, admitted)
+		waitQueued(t, s, 2*i+1)
+		acquireAsync(t, s, Ticket{Flow: "heavy", Weight: 3}, admitted)
+		waitQueued(t, s, 2*i+2)
+	}
+
+	release()
+	counts := make(map[string]int)
+	for i := 0; i < 8; i++ {
+		counts[<-admitted]++
+	}
+	if counts["heavy"] != 6 || counts["light"] != 2 {
+		t.Errorf("expected 6 heavy and 2 light among the first 8 admitted, got %v", counts)
+	}
+}
+
+func TestRefresh_AdmitsAfterCapacityGrows(t *testing.T) {
+	capacity := 1
+	s := New(Config{Capacity: func() int { return capacity }})
+	s.Acquire(context.Background(), Ticket{Flow: "a"})
+
+	admitted := make(chan string, 1)
+	acquireAsync(t, s, Ticket{Flow: "b"}, admitted)
+	waitQueued(t, s, 1)
+
+	s.mu.Lock()
+	capacity = 2
+	s.mu.Unlock()
+	s.Refresh()
+
+	select {
+	case <-admitted:
+	case <-time.After(time.Second):
+		t.Fatal("expected the waiting request to be admitted")
+	}
+}
@@ -0,0 +1,99 @@
+package session
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Append removes expired conversations
+const sweepInterval = time.Minute
+
+// Memory is an in-process Store, lost on restart and not shared between
+// gateway replicas
+type Memory struct {
+	cfg Config
+
+	mu            sync.Mutex
+	conversations map[string]*conversation
+	nextSweep     time.Time
+
+	now func() time.Time // Replaced in tests
+}
+
+type conversation struct {
+	messages  []Message
+	expiresAt time.Time
+}
+
+// NewMemory creates an in-memory store
+func NewMemory(cfg Config) *Memory {
+	return &Memory{
+		cfg:           cfg.withDefaults(),
+		conversations: make(map[string]*conversation),
+		now:           time.Now,
+	}
+}
+
+// Get implements Store
+func (m *Memory) Get(ctx context.Context, id string) ([]Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.conversations[id]
+	if !ok {
+		return nil, nil
+	}
+	if !m.now().Before(c.expiresAt) {
+		delete(m.conversations, id)
+		return nil, nil
+	}
+	return slices.Clone(c.messages), nil
+}
+
+// Append implements Store
+func (m *Memory) Append(ctx context.Context, id string, messages ...Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.After(m.nextSweep) {
+		m.sweep(now)
+		m.nextSweep = now.Add(sweepInterval)
+	}
+
+	c, ok := m.conversations[id]
+	if !ok || !now.Before(c.expiresAt) {
+		c = &conversation{}
+		m.conversations[id] = c
+	}
+	c.messages = append(c.messages, messages...)
+	if excess := len(c.messages) - m.cfg.MaxMessages; excess > 0 {
+		c.messages = slices.Clone(c.messages[excess:])
+	}
+	c.expiresAt = now.Add(m.cfg.TTL)
+	return nil
+}
+
+// Delete implements Store
+func (m *Memory) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.conversations, id)
+	return nil
+}
+
+// Close implements Store
+func (m *Memory) Close() error {
+	return nil
+}
+
+// sweep removes expired conversations; m.mu must be held
+func (m *Memory) sweep(now time.Time) {
+	for id, c := range m.conversations {
+		if !now.Before(c.expiresAt) {
+			delete(m.conversations, id)
+		}
+	}
+}
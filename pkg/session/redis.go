@@ -0,0 +1,83 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+)
+
+// keyPrefix namespaces conversation keys in a shared Redis
+const keyPrefix = "neurogate:session:"
+
+// Redis is a Store keeping each conversation as a list of JSON messages,
+// shared by every gateway replica using the same Redis
+type Redis struct {
+	client *redis.Client
+	cfg    Config
+}
+
+// NewRedis creates a store on client. Closing the store closes the client.
+func NewRedis(client *redis.Client, cfg Config) *Redis {
+	return &Redis{client: client, cfg: cfg.withDefaults()}
+}
+
+// Get implements Store
+func (r *Redis) Get(ctx context.Context, id string) ([]Message, error) {
+	items, err := redis.ByteSlices(r.client.Do(ctx, "LRANGE", keyPrefix+id, 0, -1))
+	if err != nil {
+		return nil, fmt.Errorf("get conversation: %w", err)
+	}
+
+	messages := make([]Message, 0, len(items))
+	for _, item := range items {
+		var m Message
+		if err := json.Unmarshal(item, &m); err != nil {
+			return nil, fmt.Errorf("decode conversation message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+// Append implements Store. The push, trim and expiry run in one transaction
+// so concurrent appends can't leave a conversation over its limit.
+func (r *Redis) Append(ctx context.Context, id string, messages ...Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	key := keyPrefix + id
+	push := []interface{}{"RPUSH", key}
+	for _, m := range messages {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("encode conversation message: %w", err)
+		}
+		push = append(push, data)
+	}
+
+	_, err := r.client.Tx(ctx,
+		push,
+		[]interface{}{"LTRIM", key, -r.cfg.MaxMessages, -1},
+		[]interface{}{"PEXPIRE", key, r.cfg.TTL.Milliseconds()},
+	)
+	if err != nil {
+		return fmt.Errorf("append to conversation: %w", err)
+	}
+	return nil
+}
+
+// Delete implements Store
+func (r *Redis) Delete(ctx context.Context, id string) error {
+	if _, err := r.client.Do(ctx, "DEL", keyPrefix+id); err != nil {
+		return fmt.Errorf("delete conversation: %w", err)
+	}
+	return nil
+}
+
+// Close implements Store
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
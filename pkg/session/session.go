@@ -0,0 +1,73 @@
+// Package session keeps the message history of conversations, so clients can
+// send only their newest message and have the gateway supply the context.
+// Histories live in memory or in Redis, where gateway replicas share them.
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+)
+
+// Message is one turn of a conversation
+type Message struct {
+	Role      string    `json:"role"` // "user" or "assistant"
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store keeps conversation histories by ID. Implementations are safe for
+// concurrent use.
+type Store interface {
+	// Get returns a conversation's messages, oldest first. Unknown and
+	// expired conversations have none.
+	Get(ctx context.Context, id string) ([]Message, error)
+	// Append adds messages to a conversation, dropping the oldest beyond
+	// MaxMessages, and restarts its TTL
+	Append(ctx context.Context, id string, messages ...Message) error
+	// Delete removes a conversation; deleting an unknown one is not an error
+	Delete(ctx context.Context, id string) error
+	// Close releases the backend's resources
+	Close() error
+}
+
+// Backend names
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// Config holds the retention limits shared by the backends
+type Config struct {
+	TTL         time.Duration // How long an idle conversation is kept. Default: 24 hours
+	MaxMessages int           // Messages kept per conversation. Default: 50
+}
+
+func (c Config) withDefaults() Config {
+	if c.TTL <= 0 {
+		c.TTL = 24 * time.Hour
+	}
+	if c.MaxMessages <= 0 {
+		c.MaxMessages = 50
+	}
+	return c
+}
+
+// Open creates the named backend. redisURL is only used by the Redis
+// backend.
+func Open(backend, redisURL string, cfg Config) (Store, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemory(cfg), nil
+	case BackendRedis:
+		redisCfg, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedis(redis.New(redisCfg), cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown session store %q", backend)
+	}
+}
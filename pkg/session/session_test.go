@@ -0,0 +1,161 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hugovillarreal/neurogate/pkg/redis"
+	"github.com/hugovillarreal/neurogate/pkg/redis/redistest"
+)
+
+// testStore exercises the Store contract against any backend created with
+// MaxMessages 3
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	messages, err := s.Get(ctx, "unknown")
+	if err != nil || len(messages) != 0 {
+		t.Errorf("expected no messages for an unknown conversation, got %v, %v", messages, err)
+	}
+
+	err = s.Append(ctx, "c1",
+		Message{Role: "user", Content: "hi"},
+		Message{Role: "assistant", Content: "hello"},
+	)
+	if err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := s.Append(ctx, "c2", Message{Role: "user", Content: "other"}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+
+	messages, err = s.Get(ctx, "c1")
+	if err != nil || len(messages) != 2 || messages[0].Content != "hi" || messages[1].Role != "assistant" {
+		t.Errorf("unexpected history: %+v, %v", messages, err)
+	}
+
+	// Beyond MaxMessages the oldest are dropped
+	err = s.Append(ctx, "c1",
+		Message{Role: "user", Content: "how are you?"},
+		Message{Role: "assistant", Content: "fine"},
+	)
+	if err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	messages, _ = s.Get(ctx, "c1")
+	if len(messages) != 3 || messages[0].Content != "hello" || messages[2].Content != "fine" {
+		t.Errorf("expected history trimmed to the newest 3, got %+v", messages)
+	}
+
+	if err := s.Delete(ctx, "c1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if messages, _ := s.Get(ctx, "c1"); len(messages) != 0 {
+		t.Errorf("expected deleted conversation to be empty, got %+v", messages)
+	}
+	if messages, _ := s.Get(ctx, "c2"); len(messages) != 1 {
+		t.Errorf("expected other conversation to be kept, got %+v", messages)
+	}
+	if err := s.Delete(ctx, "unknown"); err != nil {
+		t.Errorf("expected deleting an unknown conversation to succeed, got %v", err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory(Config{MaxMessages: 3}))
+}
+
+func TestMemory_Expiry(t *testing.T) {
+	now := time.Now()
+	m := NewMemory(Config{TTL: time.Hour})
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	m.Append(ctx, "c1", Message{Role: "user", Content: "hi"})
+
+	now = now.Add(59 * time.Minute)
+	if messages, _ := m.Get(ctx, "c1"); len(messages) != 1 {
+		t.Fatalf("expected conversation before its TTL, got %+v", messages)
+	}
+
+	// Appending restarts the TTL
+	m.Append(ctx, "c1", Message{Role: "assistant", Content: "hello"})
+	now = now.Add(59 * time.Minute)
+	if messages, _ := m.Get(ctx, "c1"); len(messages) != 2 {
+		t.Fatalf("expected TTL to restart on append, got %+v", messages)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if messages, _ := m.Get(ctx, "c1"); len(messages) != 0 {
+		t.Errorf("expected conversation to expire, got %+v", messages)
+	}
+}
+
+func TestMemory_SweepsExpired(t *testing.T) {
+	now := time.Now()
+	m := NewMemory(Config{TTL: time.Minute})
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	m.Append(ctx, "old", Message{Role: "user", Content: "hi"})
+	now = now.Add(time.Hour)
+	m.Append(ctx, "new", Message{Role: "user", Content: "hi"})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.conversations["old"]; ok {
+		t.Error("expected expired conversation to be swept")
+	}
+}
+
+func TestRedis(t *testing.T) {
+	server := redistest.NewServer(t)
+	s := NewRedis(redis.New(redis.Config{Addr: server.Addr()}), Config{MaxMessages: 3})
+	defer s.Close()
+
+	testStore(t, s)
+}
+
+func TestRedis_Expiry(t *testing.T) {
+	server := redistest.NewServer(t)
+	s := NewRedis(redis.New(redis.Config{Addr: server.Addr()}), Config{TTL: time.Hour})
+	defer s.Close()
+	ctx := context.Background()
+
+	s.Append(ctx, "c1", Message{Role: "user", Content: "hi"})
+	if keys := server.Keys(); len(keys) != 1 || keys[0] != "neurogate:session:c1" {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+
+	server.FastForward(2 * time.Hour)
+	if messages, _ := s.Get(ctx, "c1"); len(messages) != 0 {
+		t.Errorf("expected conversation to expire, got %+v", messages)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if s, err := Open("", "", Config{}); err != nil {
+		t.Errorf("expected default backend, got %v", err)
+	} else if _, ok := s.(*Memory); !ok {
+		t.Errorf("expected memory store by default, got %T", s)
+	}
+
+	server := redistest.NewServer(t)
+	s, err := Open(BackendRedis, "redis://"+server.Addr(), Config{})
+	if err != nil {
+		t.Fatalf("open redis failed: %v", err)
+	}
+	defer s.Close()
+	if err := s.Append(context.Background(), "c1", Message{Role: "user", Content: "hi"}); err != nil {
+		t.Errorf("append failed: %v", err)
+	}
+
+	if _, err := Open(BackendRedis, "http://localhost", Config{}); err == nil {
+		t.Error("expected error for an invalid Redis URL")
+	}
+	if _, err := Open("mongo", "", Config{}); err == nil {
+		t.Error("expected error for an unknown backend")
+	}
+}
@@ -0,0 +1,99 @@
+// Package signing provides Ed25519 signatures over inference results so the
+// gateway can detect tampered or misrouted worker responses
+package signing
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// domain separates NeuroGate result signatures from any other use of the key
+const domain = "neurogate-result-v1"
+
+var (
+	// ErrMissingSignature is returned when a signature is required but absent
+	ErrMissingSignature = errors.New("response signature missing")
+	// ErrInvalidSignature is returned when a signature does not verify
+	ErrInvalidSignature = errors.New("response signature invalid")
+)
+
+// Payload is the signed portion of an inference result
+type Payload struct {
+	RequestID        string
+	Model            string
+	Response         string
+	PromptTokens     int32
+	CompletionTokens int32
+}
+
+// Bytes returns the canonical encoding of the payload that is signed
+func (p Payload) Bytes() []byte {
+	var buf []byte
+	for _, s := range []string{domain, p.RequestID, p.Model, p.Response} {
+		buf = binary.BigEndian.AppendUint64(buf, uint64(len(s)))
+		buf = append(buf, s...)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, uint32(p.PromptTokens))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(p.CompletionTokens))
+	return buf
+}
+
+// Signer signs payloads with a worker's private key
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewSigner creates a signer from a base64-encoded 32-byte seed or 64-byte
+// private key
+func NewSigner(encoded string) (*Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signing key: %w", err)
+	}
+
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return &Signer{key: ed25519.NewKeyFromSeed(raw)}, nil
+	case ed25519.PrivateKeySize:
+		return &Signer{key: ed25519.PrivateKey(raw)}, nil
+	default:
+		return nil, fmt.Errorf("signing key must be %d or %d bytes, got %d",
+			ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+	}
+}
+
+// Sign returns the signature for a payload
+func (s *Signer) Sign(p Payload) []byte {
+	return ed25519.Sign(s.key, p.Bytes())
+}
+
+// PublicKey returns the base64-encoded public key for configuring verifiers
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// ParsePublicKey decodes a base64-encoded Ed25519 public key
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes, got %d", ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// Verify checks a payload signature against a public key
+func Verify(pub ed25519.PublicKey, p Payload, signature []byte) error {
+	if len(signature) == 0 {
+		return ErrMissingSignature
+	}
+	if !ed25519.Verify(pub, p.Bytes(), signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
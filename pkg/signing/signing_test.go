@@ -0,0 +1,96 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func newTestSigner(t *testing.T) *Signer {
+	t.Helper()
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	s, err := NewSigner(base64.StdEncoding.EncodeToString(seed))
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	return s
+}
+
+func TestSignAndVerify(t *testing.T) {
+	s := newTestSigner(t)
+	pub, err := ParsePublicKey(s.PublicKey())
+	if err != nil {
+		t.Fatalf("failed to parse public key: %v", err)
+	}
+
+	p := Payload{RequestID: "req-1", Model: "llama3.2", Response: "hello", PromptTokens: 3, CompletionTokens: 1}
+	sig := s.Sign(p)
+
+	if err := Verify(pub, p, sig); err != nil {
+		t.Errorf("expected valid signature, got %v", err)
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	s := newTestSigner(t)
+	pub, _ := ParsePublicKey(s.PublicKey())
+
+	p := Payload{RequestID: "req-1", Model: "llama3.2", Response: "hello", PromptTokens: 3, CompletionTokens: 1}
+	sig := s.Sign(p)
+
+	tampered := []Payload{
+		{RequestID: "req-2", Model: "llama3.2", Response: "hello", PromptTokens: 3, CompletionTokens: 1},
+		{RequestID: "req-1", Model: "mistral", Response: "hello", PromptTokens: 3, CompletionTokens: 1},
+		{RequestID: "req-1", Model: "llama3.2", Response: "hellO", PromptTokens: 3, CompletionTokens: 1},
+		{RequestID: "req-1", Model: "llama3.2", Response: "hello", PromptTokens: 4, CompletionTokens: 1},
+	}
+
+	for _, tp := range tampered {
+		if err := Verify(pub, tp, sig); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature for %+v, got %v", tp, err)
+		}
+	}
+}
+
+func TestVerify_MissingSignature(t *testing.T) {
+	s := newTestSigner(t)
+	pub, _ := ParsePublicKey(s.PublicKey())
+
+	if err := Verify(pub, Payload{}, nil); !errors.Is(err, ErrMissingSignature) {
+		t.Errorf("expected ErrMissingSignature, got %v", err)
+	}
+}
+
+func TestNewSigner_AcceptsFullPrivateKey(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewSigner(base64.StdEncoding.EncodeToString(priv))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if s.PublicKey() != base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey)) {
+		t.Error("public key does not match private key")
+	}
+}
+
+func TestNewSigner_InvalidKeys(t *testing.T) {
+	for _, key := range []string{"not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := NewSigner(key); err == nil {
+			t.Errorf("expected error for key %q", key)
+		}
+	}
+}
+
+func TestParsePublicKey_WrongLength(t *testing.T) {
+	if _, err := ParsePublicKey(base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Error("expected error for short public key")
+	}
+}
@@ -0,0 +1,7 @@
+package store
+
+// The database/sql drivers Open uses by default for the SQL backends
+import (
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File is a Store kept in memory and written through to a single JSON file
+// on every change. It needs no database but rewrites the whole file on each
+// write, so it suits single-instance deployments with modest write volume.
+type File struct {
+	*Memory
+
+	path    string
+	flushMu sync.Mutex
+}
+
+// NewFile opens the store saved at path, starting empty if it doesn't exist
+func NewFile(path string) (*File, error) {
+	f := &File{Memory: NewMemory(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read file store: %w", err)
+	}
+	if err := json.Unmarshal(data, &f.collections); err != nil {
+		return nil, fmt.Errorf("parse file store %s: %w", path, err)
+	}
+	if f.collections == nil {
+		f.collections = make(map[string]map[string][]byte)
+	}
+	return f, nil
+}
+
+// Put implements Store
+func (f *File) Put(ctx context.Context, collection, key string, value []byte) error {
+	if err := f.Memory.Put(ctx, collection, key, value); err != nil {
+		return err
+	}
+	return f.flush()
+}
+
+// Delete implements Store
+func (f *File) Delete(ctx context.Context, collection, key string) error {
+	if err := f.Memory.Delete(ctx, collection, key); err != nil {
+		return err
+	}
+	return f.flush()
+}
+
+// flush writes the current contents to a temporary file and renames it over
+// the store file, so a crash never leaves a partial write behind
+func (f *File) flush() error {
+	f.flushMu.Lock()
+	defer f.flushMu.Unlock()
+
+	f.mu.RLock()
+	data, err := json.Marshal(f.collections)
+	f.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write file store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write file store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write file store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("write file store: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory is a Store that keeps values in process memory. Nothing survives a
+// restart; it is the default when no persistent backend is configured.
+type Memory struct {
+	mu          sync.RWMutex
+	collections map[string]map[string][]byte
+}
+
+// NewMemory creates an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{collections: make(map[string]map[string][]byte)}
+}
+
+// Get implements Store
+func (m *Memory) Get(ctx context.Context, collection, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.collections[collection][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// Put implements Store
+func (m *Memory) Put(ctx context.Context, collection, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.collections[collection]
+	if !ok {
+		c = make(map[string][]byte)
+		m.collections[collection] = c
+	}
+	c[key] = append([]byte(nil), value...)
+	return nil
+}
+
+// Delete implements Store
+func (m *Memory) Delete(ctx context.Context, collection, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.collections[collection], key)
+	return nil
+}
+
+// List implements Store
+func (m *Memory) List(ctx context.Context, collection string) (map[string][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	values := make(map[string][]byte, len(m.collections[collection]))
+	for key, value := range m.collections[collection] {
+		values[key] = append([]byte(nil), value...)
+	}
+	return values, nil
+}
+
+// Close implements Store
+func (m *Memory) Close() error {
+	return nil
+}
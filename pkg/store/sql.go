@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect holds the SQL differences between supported databases
+type Dialect struct {
+	Name     string
+	Driver   string // Default database/sql driver name
+	BlobType string
+
+	numbered bool // $1, $2, ... placeholders instead of ?
+}
+
+// Supported SQL dialects
+var (
+	SQLite   = Dialect{Name: BackendSQLite, Driver: "sqlite", BlobType: "BLOB"}
+	Postgres = Dialect{Name: BackendPostgres, Driver: "pgx", BlobType: "BYTEA", numbered: true}
+)
+
+// rebind rewrites ? placeholders for the dialect
+func (d Dialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SQL is a Store backed by a single table in a SQL database
+type SQL struct {
+	db      *sql.DB
+	dialect Dialect
+
+	getQuery    string
+	putQuery    string
+	deleteQuery string
+	listQuery   string
+}
+
+// NewSQL creates the store table if needed and returns a store using db
+func NewSQL(ctx context.Context, db *sql.DB, dialect Dialect) (*SQL, error) {
+	create := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS neurogate_store (
+	collection TEXT NOT NULL,
+	id TEXT NOT NULL,
+	value %s NOT NULL,
+	updated_at BIGINT NOT NULL,
+	PRIMARY KEY (collection, id)
+)`, dialect.BlobType)
+	if _, err := db.ExecContext(ctx, create); err != nil {
+		return nil, fmt.Errorf("create %s store table: %w", dialect.Name, err)
+	}
+
+	return &SQL{
+		db:          db,
+		dialect:     dialect,
+		getQuery:    dialect.rebind("SELECT value FROM neurogate_store WHERE collection = ? AND id = ?"),
+		putQuery:    dialect.rebind("INSERT INTO neurogate_store (collection, id, value, updated_at) VALUES (?, ?, ?, ?) ON CONFLICT (collection, id) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at"),
+		deleteQuery: dialect.rebind("DELETE FROM neurogate_store WHERE collection = ? AND id = ?"),
+		listQuery:   dialect.rebind("SELECT id, value FROM neurogate_store WHERE collection = ?"),
+	}, nil
+}
+
+// Get implements Store
+func (s *SQL) Get(ctx context.Context, collection, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.QueryRowContext(ctx, s.getQuery, collection, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+// Put implements Store
+func (s *SQL) Put(ctx context.Context, collection, key string, value []byte) error {
+	_, err := s.db.ExecContext(ctx, s.putQuery, collection, key, value, time.Now().UnixMilli())
+	return err
+}
+
+// Delete implements Store
+func (s *SQL) Delete(ctx context.Context, collection, key string) error {
+	_, err := s.db.ExecContext(ctx, s.deleteQuery, collection, key)
+	return err
+}
+
+// List implements Store
+func (s *SQL) List(ctx context.Context, collection string) (map[string][]byte, error) {
+	rows, err := s.db.QueryContext(ctx, s.listQuery, collection)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[string][]byte)
+	for rows.Next() {
+		var key string
+		var value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, rows.Err()
+}
+
+// Close implements Store
+func (s *SQL) Close() error {
+	return s.db.Close()
+}
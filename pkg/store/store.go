@@ -0,0 +1,112 @@
+// Package store is a small key-value persistence layer shared by gateway
+// subsystems. Values are opaque bytes grouped into named collections, and
+// the backend (memory, file, SQLite or Postgres) is chosen once at startup.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned by Get when a key doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// Store persists values by collection and key. Implementations are safe
+// for concurrent use.
+type Store interface {
+	// Get returns the value stored under key, or ErrNotFound
+	Get(ctx context.Context, collection, key string) ([]byte, error)
+	// Put creates or replaces the value stored under key
+	Put(ctx context.Context, collection, key string, value []byte) error
+	// Delete removes key; deleting a missing key is not an error
+	Delete(ctx context.Context, collection, key string) error
+	// List returns every value in a collection by key
+	List(ctx context.Context, collection string) (map[string][]byte, error)
+	// Close releases the backend's resources
+	Close() error
+}
+
+// Backend names accepted by Open
+const (
+	BackendMemory   = "memory"
+	BackendFile     = "file"
+	BackendSQLite   = "sqlite"
+	BackendPostgres = "postgres"
+)
+
+// Config selects and configures a backend
+type Config struct {
+	Backend string // "memory", "file", "sqlite" or "postgres". Default: memory
+	DSN     string // File path for the file backend, data source name for SQL backends
+	Driver  string // database/sql driver name. Default: the built-in "sqlite" or "pgx"
+}
+
+// Open creates the configured backend
+func Open(cfg Config) (Store, error) {
+	var dialect Dialect
+	switch cfg.Backend {
+	case "", BackendMemory:
+		return NewMemory(), nil
+	case BackendFile:
+		if cfg.DSN == "" {
+			return nil, fmt.Errorf("file store requires a DSN")
+		}
+		return NewFile(cfg.DSN)
+	case BackendSQLite:
+		dialect = SQLite
+	case BackendPostgres:
+		dialect = Postgres
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", cfg.Backend)
+	}
+
+	if cfg.DSN == "" {
+		return nil, fmt.Errorf("%s store requires a DSN", cfg.Backend)
+	}
+	driver := cfg.Driver
+	if driver == "" {
+		driver = dialect.Driver
+	}
+
+	db, err := sql.Open(driver, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("open %s store: %w", cfg.Backend, err)
+	}
+	if dialect.Name == BackendSQLite {
+		// SQLite allows one writer at a time, so the gateway's writes are
+		// serialized on one connection, which waits for other processes'
+		// locks rather than failing with "database is locked"
+		db.SetMaxOpenConns(1)
+		if _, err := db.Exec("PRAGMA busy_timeout = 5000"); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("open %s store: %w", cfg.Backend, err)
+		}
+	}
+	s, err := NewSQL(context.Background(), db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetJSON decodes the value stored under key into v
+func GetJSON(ctx context.Context, s Store, collection, key string, v any) error {
+	value, err := s.Get(ctx, collection, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(value, v)
+}
+
+// PutJSON stores v encoded as JSON under key
+func PutJSON(ctx context.Context, s Store, collection, key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, collection, key, value)
+}
@@ -0,0 +1,310 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testStore exercises the Store contract against any backend
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, "jobs", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for missing key, got %v", err)
+	}
+
+	if err := s.Put(ctx, "jobs", "a", []byte("one")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	if err := s.Put(ctx, "jobs", "a", []byte("two")); err != nil {
+		t.Fatalf("overwrite failed: %v", err)
+	}
+	if err := s.Put(ctx, "keys", "a", []byte("other")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+
+	value, err := s.Get(ctx, "jobs", "a")
+	if err != nil || string(value) != "two" {
+		t.Errorf("expected overwritten value, got %q, %v", value, err)
+	}
+
+	if err := s.Put(ctx, "jobs", "b", []byte("three")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	values, err := s.List(ctx, "jobs")
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "a,b" || string(values["b"]) != "three" {
+		t.Errorf("expected collection to hold a and b only, got %v", values)
+	}
+
+	if err := s.Delete(ctx, "jobs", "a"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := s.Delete(ctx, "jobs", "a"); err != nil {
+		t.Errorf("expected deleting a missing key to succeed, got %v", err)
+	}
+	if _, err := s.Get(ctx, "jobs", "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if value, _ := s.Get(ctx, "keys", "a"); string(value) != "other" {
+		t.Errorf("expected other collections to be untouched, got %q", value)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory())
+}
+
+func TestMemory_CopiesValues(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	value := []byte("abc")
+	m.Put(ctx, "c", "k", value)
+	value[0] = 'x'
+
+	got, _ := m.Get(ctx, "c", "k")
+	if string(got) != "abc" {
+		t.Errorf("expected stored value to be unaffected by caller changes, got %q", got)
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	s, err := Open(Config{Backend: BackendFile, DSN: path})
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	testStore(t, s)
+
+	// Contents survive reopening
+	reopened, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if value, err := reopened.Get(context.Background(), "jobs", "b"); err != nil || string(value) != "three" {
+		t.Errorf("expected value to be persisted, got %q, %v", value, err)
+	}
+	if _, err := reopened.Get(context.Background(), "jobs", "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deletion to be persisted, got %v", err)
+	}
+}
+
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	s, err := Open(Config{Backend: BackendSQLite, DSN: path})
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	testStore(t, s)
+
+	// Concurrent writers don't fail on SQLite's lock
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.Put(context.Background(), "history", strconv.Itoa(i), []byte("x"))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent put failed: %v", err)
+		}
+	}
+	s.Close()
+
+	// Contents survive reopening
+	reopened, err := Open(Config{Backend: BackendSQLite, DSN: path})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	if value, err := reopened.Get(context.Background(), "jobs", "b"); err != nil || string(value) != "three" {
+		t.Errorf("expected value to be persisted, got %q, %v", value, err)
+	}
+	if values, err := reopened.List(context.Background(), "history"); err != nil || len(values) != 20 {
+		t.Errorf("expected 20 values written concurrently, got %d, %v", len(values), err)
+	}
+}
+
+// TestPostgres runs against the database in STORE_TEST_POSTGRES_DSN, whose
+// jobs and keys collections it clears
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("STORE_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("STORE_TEST_POSTGRES_DSN not set")
+	}
+	s, err := Open(Config{Backend: BackendPostgres, DSN: dsn})
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+	for _, collection := range []string{"jobs", "keys"} {
+		values, err := s.List(ctx, collection)
+		if err != nil {
+			t.Fatalf("list failed: %v", err)
+		}
+		for key := range values {
+			s.Delete(ctx, collection, key)
+		}
+	}
+	testStore(t, s)
+}
+
+// TestSQL checks both dialects' queries against a fake driver
+func TestSQL(t *testing.T) {
+	for _, dialect := range []Dialect{SQLite, Postgres} {
+		t.Run(dialect.Name, func(t *testing.T) {
+			s, err := Open(Config{Backend: dialect.Name, DSN: t.Name(), Driver: "storetest"})
+			if err != nil {
+				t.Fatalf("open failed: %v", err)
+			}
+			defer s.Close()
+			testStore(t, s)
+		})
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	tests := []Config{
+		{Backend: "redis"},
+		{Backend: BackendSQLite},
+		{Backend: BackendFile},
+		{Backend: BackendPostgres, DSN: "postgres://localhost/neurogate", Driver: "unregistered"},
+	}
+	for _, cfg := range tests {
+		if s, err := Open(cfg); err == nil {
+			s.Close()
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestDialect_Rebind(t *testing.T) {
+	query := "SELECT value FROM t WHERE a = ? AND b = ?"
+	if got := SQLite.rebind(query); got != query {
+		t.Errorf("expected sqlite query unchanged, got %q", got)
+	}
+	if got := Postgres.rebind(query); got != "SELECT value FROM t WHERE a = $1 AND b = $2" {
+		t.Errorf("expected numbered placeholders, got %q", got)
+	}
+}
+
+// fakeDriver is a minimal database/sql driver that understands the store's
+// queries, so the SQL backend can be tested without a database
+type fakeDriver struct {
+	mu  sync.Mutex
+	dbs map[string]map[[2]string][]byte
+}
+
+func init() {
+	sql.Register("storetest", &fakeDriver{dbs: make(map[string]map[[2]string][]byte)})
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dbs[dsn] == nil {
+		d.dbs[dsn] = make(map[[2]string][]byte)
+	}
+	return &fakeConn{driver: d, rows: d.dbs[dsn]}, nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+	rows   map[[2]string][]byte
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("transactions not supported") }
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.conn.driver.mu.Lock()
+	defer s.conn.driver.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE"), strings.HasPrefix(s.query, "PRAGMA"):
+	case strings.HasPrefix(s.query, "INSERT"):
+		s.conn.rows[[2]string{args[0].(string), args[1].(string)}] = append([]byte(nil), args[2].([]byte)...)
+	case strings.HasPrefix(s.query, "DELETE"):
+		delete(s.conn.rows, [2]string{args[0].(string), args[1].(string)})
+	default:
+		return nil, errors.New("unexpected exec: " + s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.conn.driver.mu.Lock()
+	defer s.conn.driver.mu.Unlock()
+
+	rows := &fakeRows{}
+	switch {
+	case strings.HasPrefix(s.query, "SELECT value"):
+		rows.columns = []string{"value"}
+		if value, ok := s.conn.rows[[2]string{args[0].(string), args[1].(string)}]; ok {
+			rows.values = append(rows.values, []driver.Value{value})
+		}
+	case strings.HasPrefix(s.query, "SELECT id, value"):
+		rows.columns = []string{"id", "value"}
+		for k, value := range s.conn.rows {
+			if k[0] == args[0].(string) {
+				rows.values = append(rows.values, []driver.Value{k[1], value})
+			}
+		}
+	default:
+		return nil, errors.New("unexpected query: " + s.query)
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
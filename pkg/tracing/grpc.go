@@ -0,0 +1,202 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// healthService is left untraced; probes call it every few seconds
+const healthService = "/grpc.health.v1.Health/"
+
+// UnaryServerInterceptor starts a server span for each unary call,
+// continuing the caller's trace from the request metadata. The standard
+// health service and methods in skip are not traced.
+func UnaryServerInterceptor(skip ...string) grpc.UnaryServerInterceptor {
+	untraced := untracedMethods(skip)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if untraced(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, span := startServerSpan(ctx, info.FullMethod)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		setRPCStatus(span, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor starts a server span for each streaming call,
+// continuing the caller's trace from the request metadata. The standard
+// health service and methods in skip are not traced.
+func StreamServerInterceptor(skip ...string) grpc.StreamServerInterceptor {
+	untraced := untracedMethods(skip)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if untraced(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, span := startServerSpan(ss.Context(), info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		setRPCStatus(span, err)
+		return err
+	}
+}
+
+// UnaryClientInterceptor starts a client span for each unary call made as
+// part of a trace and sends the trace context in the request metadata.
+// Calls made outside a trace, such as background health probes, are not
+// traced.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		ctx, span := startClientSpan(ctx, method, cc.Target())
+		defer span.End()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		setRPCStatus(span, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor starts a client span for each streaming call made
+// as part of a trace. The span ends when the stream finishes or its context
+// is done.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		ctx, span := startClientSpan(ctx, method, cc.Target())
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			setRPCStatus(span, err)
+			span.End()
+			return nil, err
+		}
+
+		context.AfterFunc(ctx, func() { span.End() })
+		return &clientStream{ClientStream: cs, span: span}, nil
+	}
+}
+
+// untracedMethods reports whether a method is skipped by a server interceptor
+func untracedMethods(skip []string) func(method string) bool {
+	skipped := make(map[string]bool, len(skip))
+	for _, method := range skip {
+		skipped[method] = true
+	}
+	return func(method string) bool {
+		return skipped[method] || strings.HasPrefix(method, healthService)
+	}
+}
+
+func startServerSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	return tracer().Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(rpcAttributes(method)...),
+	)
+}
+
+func startClientSpan(ctx context.Context, method, target string) (context.Context, trace.Span) {
+	ctx, span := tracer().Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(rpcAttributes(method), attribute.String("server.address", target))...),
+	)
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md), span
+}
+
+// rpcAttributes describes a call by its "/package.Service/Method" name
+func rpcAttributes(method string) []attribute.KeyValue {
+	service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	return []attribute.KeyValue{
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", name),
+	}
+}
+
+func setRPCStatus(span trace.Span, err error) {
+	s := status.Convert(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(s.Code())))
+	if err != nil {
+		span.SetStatus(codes.Error, s.Message())
+	}
+}
+
+// serverStream overrides the stream's context with one carrying the span
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// clientStream ends its span when the server closes the stream
+type clientStream struct {
+	grpc.ClientStream
+	span trace.Span
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			setRPCStatus(s.span, err)
+		}
+		s.span.End()
+	}
+	return err
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,256 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// exporter sends spans to a collector using OTLP/HTTP with JSON encoding
+type exporter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newExporter(url string, headers map[string]string) *exporter {
+	return &exporter{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ExportSpans implements sdktrace.SpanExporter
+func (e *exporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(encodeSpans(spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("export spans: collector returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Shutdown implements sdktrace.SpanExporter
+func (e *exporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// The types below mirror the JSON encoding of an OTLP
+// ExportTraceServiceRequest. IDs are hex strings and 64-bit integers are
+// decimal strings, as the OTLP spec requires.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	SchemaURL  string           `json:"schemaUrl,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	Name         string         `json:"name"`
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+// OTLP status codes, which are numbered differently from codes.Code
+const (
+	otlpStatusOK    = 1
+	otlpStatusError = 2
+)
+
+// encodeSpans groups spans by resource and instrumentation scope
+func encodeSpans(spans []sdktrace.ReadOnlySpan) otlpRequest {
+	var req otlpRequest
+	resourceIndex := make(map[attribute.Distinct]int)
+	scopeIndex := make(map[attribute.Distinct]map[string]int)
+
+	for _, s := range spans {
+		res := s.Resource()
+		key := res.Equivalent()
+		ri, ok := resourceIndex[key]
+		if !ok {
+			ri = len(req.ResourceSpans)
+			resourceIndex[key] = ri
+			scopeIndex[key] = make(map[string]int)
+			req.ResourceSpans = append(req.ResourceSpans, otlpResourceSpans{
+				Resource:  otlpResource{Attributes: encodeAttributes(res.Attributes())},
+				SchemaURL: res.SchemaURL(),
+			})
+		}
+		rs := &req.ResourceSpans[ri]
+
+		scope := s.InstrumentationScope()
+		si, ok := scopeIndex[key][scope.Name+"@"+scope.Version]
+		if !ok {
+			si = len(rs.ScopeSpans)
+			scopeIndex[key][scope.Name+"@"+scope.Version] = si
+			rs.ScopeSpans = append(rs.ScopeSpans, otlpScopeSpans{
+				Scope: otlpScope{Name: scope.Name, Version: scope.Version},
+			})
+		}
+		rs.ScopeSpans[si].Spans = append(rs.ScopeSpans[si].Spans, encodeSpan(s))
+	}
+	return req
+}
+
+func encodeSpan(s sdktrace.ReadOnlySpan) otlpSpan {
+	span := otlpSpan{
+		TraceID:           s.SpanContext().TraceID().String(),
+		SpanID:            s.SpanContext().SpanID().String(),
+		Name:              s.Name(),
+		Kind:              int(s.SpanKind()),
+		StartTimeUnixNano: unixNano(s.StartTime()),
+		EndTimeUnixNano:   unixNano(s.EndTime()),
+		Attributes:        encodeAttributes(s.Attributes()),
+	}
+	if s.Parent().HasSpanID() {
+		span.ParentSpanID = s.Parent().SpanID().String()
+	}
+	for _, e := range s.Events() {
+		span.Events = append(span.Events, otlpEvent{
+			Name:         e.Name,
+			TimeUnixNano: unixNano(e.Time),
+			Attributes:   encodeAttributes(e.Attributes),
+		})
+	}
+	switch s.Status().Code {
+	case codes.Ok:
+		span.Status.Code = otlpStatusOK
+	case codes.Error:
+		span.Status = otlpStatus{Code: otlpStatusError, Message: s.Status().Description}
+	}
+	return span
+}
+
+func encodeAttributes(attrs []attribute.KeyValue) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		kvs = append(kvs, otlpKeyValue{Key: string(kv.Key), Value: encodeValue(kv.Value)})
+	}
+	return kvs
+}
+
+func encodeValue(v attribute.Value) otlpAnyValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		b := v.AsBool()
+		return otlpAnyValue{BoolValue: &b}
+	case attribute.INT64:
+		i := strconv.FormatInt(v.AsInt64(), 10)
+		return otlpAnyValue{IntValue: &i}
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		return otlpAnyValue{DoubleValue: &f}
+	case attribute.BOOLSLICE:
+		return encodeArray(v.AsBoolSlice(), attribute.BoolValue)
+	case attribute.INT64SLICE:
+		return encodeArray(v.AsInt64Slice(), attribute.Int64Value)
+	case attribute.FLOAT64SLICE:
+		return encodeArray(v.AsFloat64Slice(), attribute.Float64Value)
+	case attribute.STRINGSLICE:
+		return encodeArray(v.AsStringSlice(), attribute.StringValue)
+	default:
+		s := v.Emit()
+		return otlpAnyValue{StringValue: &s}
+	}
+}
+
+func encodeArray[T any](values []T, toValue func(T) attribute.Value) otlpAnyValue {
+	array := &otlpArrayValue{Values: make([]otlpAnyValue, 0, len(values))}
+	for _, v := range values {
+		array.Values = append(array.Values, encodeValue(toValue(v)))
+	}
+	return otlpAnyValue{ArrayValue: array}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,85 @@
+// Package tracing sets up OpenTelemetry tracing for the gateway and worker.
+// Trace context is propagated with W3C traceparent headers over HTTP and
+// gRPC metadata, and spans are exported to an OTLP/HTTP collector.
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer used by this package's middleware
+const instrumentationName = "github.com/hugovillarreal/neurogate/pkg/tracing"
+
+// Config configures tracing
+type Config struct {
+	ServiceName    string
+	ServiceVersion string
+
+	// Endpoint is the collector's OTLP/HTTP base URL; "/v1/traces" is
+	// appended. TracesEndpoint is the full traces URL and takes precedence.
+	// With neither set, spans are not exported but trace context is still
+	// propagated.
+	Endpoint       string
+	TracesEndpoint string
+	Headers        map[string]string // Sent with every export, e.g. for authentication
+
+	SampleRatio float64 // Fraction of new traces sampled; requests with a sampled parent always are
+}
+
+// Setup installs the global tracer provider and propagator. The returned
+// function flushes pending spans and must be called on shutdown.
+func Setup(cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	url := cfg.TracesEndpoint
+	if url == "" && cfg.Endpoint != "" {
+		url = strings.TrimSuffix(cfg.Endpoint, "/") + "/v1/traces"
+	}
+	if url == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(newExporter(url, cfg.Headers), sdktrace.WithBatchTimeout(5*time.Second)),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// ParseHeaders parses OTEL_EXPORTER_OTLP_HEADERS style "key=value,..." pairs
+func ParseHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && k != "" {
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return headers
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
@@ -0,0 +1,214 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// useRecorder installs a global provider that records spans in memory
+func useRecorder(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return exp
+}
+
+func TestExporter_EncodesOTLP(t *testing.T) {
+	var body []byte
+	var token string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		token = r.Header.Get("X-Token")
+	}))
+	defer srv.Close()
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(newExporter(srv.URL, map[string]string{"X-Token": "secret"})))
+	defer provider.Shutdown(context.Background())
+	tr := provider.Tracer("test")
+
+	ctx, parent := tr.Start(context.Background(), "parent")
+	_, child := tr.Start(ctx, "child", trace.WithAttributes(
+		attribute.Int("tokens", 42),
+		attribute.StringSlice("models", []string{"a", "b"}),
+	))
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+
+	if token != "secret" {
+		t.Errorf("expected configured header, got %q", token)
+	}
+
+	var req otlpRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("invalid export body: %v", err)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+
+	if span.Name != "child" {
+		t.Errorf("expected span child, got %s", span.Name)
+	}
+	if span.TraceID != parent.SpanContext().TraceID().String() || len(span.TraceID) != 32 {
+		t.Errorf("unexpected trace ID %s", span.TraceID)
+	}
+	if span.ParentSpanID != parent.SpanContext().SpanID().String() {
+		t.Errorf("expected parent span %s, got %s", parent.SpanContext().SpanID(), span.ParentSpanID)
+	}
+	if span.Status.Code != otlpStatusError || span.Status.Message != "boom" {
+		t.Errorf("unexpected status %+v", span.Status)
+	}
+
+	attrs := make(map[string]otlpAnyValue)
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if v := attrs["tokens"].IntValue; v == nil || *v != "42" {
+		t.Errorf("expected tokens encoded as \"42\", got %v", v)
+	}
+	if v := attrs["models"].ArrayValue; v == nil || len(v.Values) != 2 {
+		t.Errorf("expected models array of 2, got %v", v)
+	}
+}
+
+func TestExporter_CollectorError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	exp := newExporter(srv.URL, nil)
+	stub := tracetest.SpanStub{Name: "span"}
+	if err := exp.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{stub.Snapshot()}); err == nil {
+		t.Error("expected an error when the collector rejects the export")
+	}
+}
+
+func TestHandler_ContinuesTrace(t *testing.T) {
+	exp := useRecorder(t)
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	var traceID string
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID = trace.SpanContextFromContext(r.Context()).TraceID().String()
+		w.WriteHeader(http.StatusBadGateway)
+	}), "/health")
+
+	req := httptest.NewRequest("POST", "/prompt", nil)
+	req.Header.Set("traceparent", traceparent)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the incoming trace to continue, got trace %s", traceID)
+	}
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("expected a 5xx response to mark the span as failed")
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	if len(exp.GetSpans()) != 1 {
+		t.Error("expected skipped paths not to be traced")
+	}
+}
+
+func TestTransport_InjectsTraceparent(t *testing.T) {
+	exp := useRecorder(t)
+
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: Transport(nil)}
+
+	// Outside a trace nothing is added
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if received != "" || len(exp.GetSpans()) != 0 {
+		t.Errorf("expected untraced request, got traceparent %q", received)
+	}
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	if received == "" {
+		t.Fatal("expected a traceparent header")
+	}
+	carrier := propagation.MapCarrier{"traceparent": received}
+	got := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+	if got.TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf("expected trace %s, got %s", parent.SpanContext().TraceID(), got.TraceID())
+	}
+	if len(exp.GetSpans()) != 2 {
+		t.Errorf("expected client and parent spans, got %d", len(exp.GetSpans()))
+	}
+}
+
+func TestMetadataCarrier_RoundTrip(t *testing.T) {
+	useRecorder(t)
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "call")
+	defer span.End()
+
+	md := metadata.MD{}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	if len(md.Get("traceparent")) != 1 {
+		t.Fatalf("expected traceparent in metadata, got %v", md)
+	}
+
+	extracted := otel.GetTextMapPropagator().Extract(context.Background(), metadataCarrier(md))
+	if got := trace.SpanContextFromContext(extracted); got.TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("expected trace %s, got %s", span.SpanContext().TraceID(), got.TraceID())
+	}
+}
+
+func TestSetRPCStatus(t *testing.T) {
+	exp := useRecorder(t)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "call")
+	setRPCStatus(span, errors.New("unavailable"))
+	span.End()
+
+	if got := exp.GetSpans()[0].Status.Code; got != codes.Error {
+		t.Errorf("expected error status, got %v", got)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	got := ParseHeaders("x-api-key=abc, x-team = ops,invalid")
+	if len(got) != 2 || got["x-api-key"] != "abc" || got["x-team"] != "ops" {
+		t.Errorf("unexpected headers %v", got)
+	}
+}